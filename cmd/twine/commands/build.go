@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// embedTag is the build tag embed.gen.go is built with
+const embedTag = "twine_embed"
+
+// embedFile is the file twine build generates to embed templates/
+const embedFile = "embed.gen.go"
+
+// embedSource is the content of embed.gen.go
+const embedSource = `// Code generated by twine build. DO NOT EDIT.
+
+//go:build ` + embedTag + `
+
+package main
+
+import (
+	"embed"
+
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// Templates load from the binary rather than from disk
+func init() {
+	template.UseFS(embeddedTemplates)
+}
+`
+
+// NewBuildCommand creates the build command
+func NewBuildCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Build a single binary with its templates embedded",
+		Long: `Regenerate app/routes.gen.go, write embed.gen.go embedding templates/, then
+build the project with go build -tags ` + embedTag + `. The binary loads its
+templates from itself, so deployments don't need the templates directory.
+
+embed.gen.go only builds with the ` + embedTag + ` tag, so go build and twine dev
+keep reading templates from disk, where they reload as they change.
+templates.patterns must be relative to the project root, as in the default
+templates/**/*.html.`,
+		Example: "  twine build\n  twine build -o dist/app",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("getting current directory: %w", err)
+			}
+			if _, err := os.Stat(filepath.Join(cwd, "go.mod")); os.IsNotExist(err) {
+				return fmt.Errorf("go.mod not found. Run 'twine build' from the project root")
+			}
+			cmd.SilenceUsage = true
+
+			appDir := filepath.Join(cwd, "app")
+			if dirExists(appDir) {
+				if err := generateRoutes(cwd, appDir); err != nil {
+					return err
+				}
+			}
+
+			if err := writeEmbedFile(cwd); err != nil {
+				return err
+			}
+
+			if output == "" {
+				output = filepath.Join("bin", filepath.Base(cwd))
+			}
+			build := exec.Command("go", "build", "-tags", embedTag, "-trimpath", "-o", output, ".")
+			build.Dir = cwd
+			build.Stdout = cmd.OutOrStdout()
+			build.Stderr = cmd.ErrOrStderr()
+			if err := build.Run(); err != nil {
+				return fmt.Errorf("build failed: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "✓ Built %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Binary path (default bin/<project directory>)")
+
+	return cmd
+}
+
+// writeEmbedFile writes embed.gen.go when the project in cwd has a
+// templates directory, and removes a stale one when it doesn't, since
+// //go:embed fails for missing directories
+func writeEmbedFile(cwd string) error {
+	path := filepath.Join(cwd, embedFile)
+	if !dirExists(filepath.Join(cwd, "templates")) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", embedFile, err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(embedSource), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", embedFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteEmbedFile tests generating embed.gen.go for projects with
+// templates
+func TestWriteEmbedFile(t *testing.T) {
+	projectDir := setupTestProject(t)
+	path := filepath.Join(projectDir, embedFile)
+
+	require.NoError(t, writeEmbedFile(projectDir))
+	assert.NoFileExists(t, path, "no templates, nothing to embed")
+
+	writeTestFile(t, projectDir, "templates/pages/index.html", `{{define "index"}}{{end}}`)
+	require.NoError(t, writeEmbedFile(projectDir))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "//go:build twine_embed\n")
+	assert.Contains(t, string(content), "//go:embed templates\n")
+	assert.Contains(t, string(content), "template.UseFS(embeddedTemplates)")
+	_, err = parser.ParseFile(token.NewFileSet(), path, content, parser.ParseComments)
+	assert.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(projectDir, "templates")))
+	require.NoError(t, writeEmbedFile(projectDir))
+	assert.NoFileExists(t, path, "a stale file is removed")
+}
+
+// TestBuildCommand tests building the project binary
+func TestBuildCommand(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+
+	projectDir := setupTestProject(t)
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "app")))
+	writeTestFile(t, projectDir, "main.go", "package main\n\nfunc main() {}\n")
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	var out bytes.Buffer
+	cmd := NewBuildCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"-o", "dist/app"})
+	require.NoError(t, cmd.Execute())
+	assert.FileExists(t, filepath.Join(projectDir, "dist", "app"))
+	assert.Contains(t, out.String(), "Built dist/app")
+
+	writeTestFile(t, projectDir, "main.go", "package main\n\nfunc main() { undefined() }\n")
+	cmd = NewBuildCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "build failed")
+	assert.Contains(t, out.String(), "undefined")
+}
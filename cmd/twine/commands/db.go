@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// seedsDir holds the project's seeds, relative to the project root
+const seedsDir = "db/seeds"
+
+// dbRunnerConfig is the data passed to internal/scaffold/db/main.go.tmpl
+type dbRunnerConfig struct {
+	ModulePath string
+	Migrations bool
+	Seeds      bool
+}
+
+// NewDBCommand creates the db command
+func NewDBCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Run database migrations and seeds",
+		Long: `Apply, roll back and inspect the migrations in db/migrations, and run
+the seeds in db/seeds. Go migrations and <version>_<name>.up.sql and
+.down.sql files there run together in version order.
+
+Applied migrations are recorded in the schema_migrations table. The database
+connection is read from the DB_* environment variables or .env, the same as
+the application.`,
+	}
+
+	var plan bool
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending migrations",
+		Long: `Apply pending migrations. With --plan, print the migrations that would
+run in order, with their dependencies and SQL statements, without changing
+the database.`,
+		Example: "  twine db migrate\n  twine db migrate --plan",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if plan {
+				return runDBCommand(cmd, "migrate", "--plan")
+			}
+			return runDBCommand(cmd, "migrate")
+		},
+	}
+	migrate.Flags().BoolVar(&plan, "plan", false, "Print the pending migrations without applying them")
+	cmd.AddCommand(migrate)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "rollback [n]",
+		Short:   "Roll back the last n migrations (default 1)",
+		Example: "  twine db rollback\n  twine db rollback 3",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if n, err := strconv.Atoi(args[0]); err != nil || n < 1 {
+					return fmt.Errorf("invalid rollback count %q: must be a positive number", args[0])
+				}
+			}
+			return runDBCommand(cmd, append([]string{"rollback"}, args...)...)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show applied and pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDBCommand(cmd, "status")
+		},
+	})
+
+	cmd.AddCommand(newDBSeedCommand())
+
+	return cmd
+}
+
+// newDBSeedCommand creates the db seed subcommand
+func newDBSeedCommand() *cobra.Command {
+	var env string
+	var truncate bool
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Run the seeds for an environment",
+		Long: `Run the seeds registered in db/seeds for an environment.
+
+Seeds registered without environments run in every environment. With
+--truncate, the tables each selected seed fills are cleared first.`,
+		Example: "  twine db seed\n  twine db seed --env test --truncate",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if env == "" {
+				return fmt.Errorf("--env must not be empty")
+			}
+			runArgs := []string{"seed", "--env", env}
+			if truncate {
+				runArgs = append(runArgs, "--truncate")
+			}
+			return runDBCommand(cmd, runArgs...)
+		},
+	}
+
+	cmd.Flags().StringVarP(&env, "env", "e", "dev", "Environment whose seeds to run (e.g. dev, test)")
+	cmd.Flags().BoolVar(&truncate, "truncate", false, "Clear the seeded tables before seeding")
+
+	return cmd
+}
+
+// runDBCommand builds a program in the project that imports db/migrations and
+// db/seeds and runs database.RunCommand with args, since the migrations and
+// seeds are compiled into the project rather than the twine binary
+func runDBCommand(cmd *cobra.Command, args ...string) error {
+	cwd, modulePath, err := prepareGenerate(false)
+	if err != nil {
+		return err
+	}
+
+	// A directory of SQL migrations alone has no package to import;
+	// RunCommand loads the files itself
+	config := &dbRunnerConfig{
+		ModulePath: modulePath,
+		Migrations: hasGoFiles(filepath.Join(cwd, migrationsDir)),
+		Seeds:      dirExists(filepath.Join(cwd, seedsDir)),
+	}
+	if args[0] == "seed" {
+		if !config.Seeds {
+			return fmt.Errorf("%s not found. Register seeds with database.RegisterSeed in a package there", seedsDir)
+		}
+	} else if !dirExists(filepath.Join(cwd, migrationsDir)) {
+		return fmt.Errorf("%s not found. Create a migration with 'twine generate model' or 'twine generate migration'", migrationsDir)
+	}
+	cmd.SilenceUsage = true
+
+	// A dot directory is skipped by ./... so the runner never shows up in
+	// the project's own builds and tests
+	dir, err := os.MkdirTemp(cwd, ".twine-db-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := renderScaffold("db/main.go.tmpl", config)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), content, 0644); err != nil {
+		return err
+	}
+
+	run := exec.Command("go", append([]string{"run", "./" + filepath.Base(dir)}, args...)...)
+	run.Dir = cwd
+	run.Stdout = cmd.OutOrStdout()
+	run.Stderr = cmd.ErrOrStderr()
+	if err := run.Run(); err != nil {
+		return fmt.Errorf("twine db %s failed: %w", args[0], err)
+	}
+
+	return nil
+}
+
+// dirExists reports whether path is an existing directory
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// hasGoFiles reports whether dir contains a Go file, so it can be imported
+func hasGoFiles(dir string) bool {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if !strings.HasSuffix(file, "_test.go") {
+			return true
+		}
+	}
+	return false
+}
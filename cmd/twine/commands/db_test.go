@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewDBCommand tests db command creation
+func TestNewDBCommand(t *testing.T) {
+	cmd := NewDBCommand()
+
+	assert.Equal(t, "db", cmd.Use)
+
+	uses := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		uses = append(uses, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"migrate", "rollback", "status", "seed"}, uses)
+
+	var seed *cobra.Command
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == "seed" {
+			seed = sub
+		}
+	}
+	require.NotNil(t, seed)
+
+	migrate, _, err := cmd.Find([]string{"migrate"})
+	require.NoError(t, err)
+	assert.Equal(t, "false", migrate.Flags().Lookup("plan").DefValue)
+	assert.Equal(t, "dev", seed.Flags().Lookup("env").DefValue)
+	assert.NotNil(t, seed.Flags().Lookup("truncate"))
+}
+
+// TestDBCommand_Errors tests argument and project checks that run before
+// the migration runner is built
+func TestDBCommand_Errors(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	tests := []struct {
+		name     string
+		args     []string
+		errorMsg string
+	}{
+		{"no migrations", []string{"migrate"}, "db/migrations not found"},
+		{"no seeds", []string{"seed", "--env", "test"}, "db/seeds not found"},
+		{"empty env", []string{"seed", "--env", ""}, "--env must not be empty"},
+		{"invalid count", []string{"rollback", "zero"}, `invalid rollback count "zero"`},
+		{"zero count", []string{"rollback", "0"}, "must be a positive number"},
+		{"too many args", []string{"rollback", "1", "2"}, "accepts at most 1 arg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewDBCommand()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+// TestRenderDBRunner tests the generated migration runner
+func TestRenderDBRunner(t *testing.T) {
+	content, err := renderScaffold("db/main.go.tmpl", &dbRunnerConfig{ModulePath: "github.com/test/project", Migrations: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `_ "github.com/test/project/db/migrations"`)
+	assert.NotContains(t, string(content), "db/seeds")
+	assert.Contains(t, string(content), "database.RunCommand(os.Args[1:], os.Stdout)")
+
+	content, err = renderScaffold("db/main.go.tmpl", &dbRunnerConfig{ModulePath: "github.com/test/project", Migrations: true, Seeds: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `_ "github.com/test/project/db/seeds"`)
+}
+
+// TestHasGoFiles tests detecting an importable migrations package
+func TestHasGoFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "001_index.up.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "migrations_test.go"), []byte("package migrations"), 0644))
+	assert.False(t, hasGoFiles(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "002_create_posts.go"), []byte("package migrations"), 0644))
+	assert.True(t, hasGoFiles(dir))
+}
@@ -1,23 +1,57 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cstone-io/twine/internal/routing"
+	"github.com/cstone-io/twine/pkg/config"
 	"github.com/fsnotify/fsnotify"
+	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
 
+// devBuildDir holds the binary built by twine dev, relative to the project root
+const devBuildDir = "tmp"
+
+// devReadyTimeout is how long a proxied request waits for the app to come up
+const devReadyTimeout = 30 * time.Second
+
 // NewDevCommand creates the dev command
 func NewDevCommand() *cobra.Command {
-	return &cobra.Command{
+	var port, appPort int
+
+	cmd := &cobra.Command{
 		Use:   "dev",
 		Short: "Start development server with hot reload",
-		Long:  "Start the development server with automatic route generation and hot reload",
+		Long: `Start the development server with automatic route generation and hot reload.
+
+twine dev builds and runs the app, watching Go files, templates, app/ and
+public/. Route changes regenerate app/routes.gen.go, Go changes rebuild the
+binary and .env changes restart it. The app runs with TWINE_ENV=development,
+unless the environment or .env sets it, and TEMPLATES_RELOAD=true, so it
+shows errors in detail and re-parses edited templates on the next render and shows parse errors
+in the browser; template changes only reload open pages. The app listens on
+--app-port, passed to it as PORT, behind a proxy on --port that holds
+requests while the app restarts. The proxy reloads open pages after each
+restart and swaps stylesheets in place when only CSS under public/assets/css
+changes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Get current directory
 			cwd, err := os.Getwd()
@@ -25,6 +59,17 @@ func NewDevCommand() *cobra.Command {
 				return fmt.Errorf("getting current directory: %w", err)
 			}
 
+			if _, err := os.Stat(filepath.Join(cwd, "go.mod")); os.IsNotExist(err) {
+				return fmt.Errorf("go.mod not found. Run 'twine dev' from the project root")
+			}
+			if appPort == 0 {
+				appPort = port + 1
+			}
+			if port == appPort {
+				return fmt.Errorf("--port and --app-port must differ")
+			}
+			cmd.SilenceUsage = true
+
 			// Check if app/ directory exists
 			appDir := filepath.Join(cwd, "app")
 			if _, err := os.Stat(appDir); err == nil {
@@ -32,119 +77,143 @@ func NewDevCommand() *cobra.Command {
 				if err := generateRoutes(cwd, appDir); err != nil {
 					fmt.Printf("⚠️  Warning: failed to generate routes: %v\n", err)
 				}
-
-				// Start file watcher
-				go watchAppDirectory(cwd, appDir)
 			} else {
+				appDir = ""
 				fmt.Println("ℹ️  No app/ directory found. Skipping route generation.")
 				fmt.Println("   Run 'twine init' to create the app/ structure.")
 			}
 
-			// Check if Air is installed
-			if _, err := exec.LookPath("air"); err != nil {
-				return fmt.Errorf("air not found. Install it with: go install github.com/air-verse/air@latest")
-			}
-
-			// Start Air
-			fmt.Println("🚀 Starting development server with Air...")
-			fmt.Println()
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
 
-			airCmd := exec.Command("air")
-			airCmd.Stdout = os.Stdout
-			airCmd.Stderr = os.Stderr
-			airCmd.Stdin = os.Stdin
-
-			return airCmd.Run()
+			server := newDevServer(cwd, appDir, appPort)
+			return server.Run(ctx, port)
 		},
 	}
+
+	cmd.Flags().IntVarP(&port, "port", "p", 3000, "Port for the development proxy")
+	cmd.Flags().IntVar(&appPort, "app-port", 0, "Port the app listens on behind the proxy (default --port + 1)")
+
+	return cmd
 }
 
-func generateRoutes(cwd, appDir string) error {
-	// Scan routes
-	root, err := routing.ScanRoutes(appDir)
-	if err != nil {
-		return fmt.Errorf("scanning routes: %w", err)
-	}
+// devChange describes what a batch of file changes requires
+type devChange struct {
+	routes  bool // regenerate routes.gen.go
+	build   bool // rebuild the binary
+	restart bool // restart the binary
+	reload  bool // reload open pages
+	css     bool // swap stylesheets in open pages
+}
 
-	// Validate routes
-	if err := root.Validate(); err != nil {
-		return fmt.Errorf("validation error: %w", err)
-	}
+// devServer builds and runs the app, restarting it when files change, behind
+// a proxy that holds requests until the app is ready
+type devServer struct {
+	cwd      string
+	appDir   string
+	bin      string
+	appAddr  string
+	gate     *devGate
+	reloader *liveReloader
 
-	// Get module path
-	modulePath, err := routing.GetModulePath(cwd)
-	if err != nil {
-		return fmt.Errorf("getting module path: %w", err)
-	}
+	mu      sync.Mutex
+	process *exec.Cmd
+	exited  chan struct{}
+}
 
-	// Generate code
-	outputFile := filepath.Join(appDir, "routes.gen.go")
-	generator := &routing.CodeGenerator{
-		RouteTree:   root,
-		ModulePath:  modulePath,
-		ProjectRoot: cwd,
-		OutputFile:  outputFile,
+// newDevServer creates a dev server for the project in cwd. appDir is empty
+// when the project has no file-based routes.
+func newDevServer(cwd, appDir string, appPort int) *devServer {
+	bin := filepath.Join(cwd, devBuildDir, "main")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
 	}
 
-	if err := generator.Generate(); err != nil {
-		return fmt.Errorf("generating routes: %w", err)
+	return &devServer{
+		cwd:      cwd,
+		appDir:   appDir,
+		bin:      bin,
+		appAddr:  net.JoinHostPort("127.0.0.1", strconv.Itoa(appPort)),
+		gate:     newDevGate(),
+		reloader: newLiveReloader(),
 	}
-
-	return nil
 }
 
-func watchAppDirectory(cwd, appDir string) {
+// Run serves the proxy on port and keeps the app running until ctx is done
+func (s *devServer) Run(ctx context.Context, port int) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		fmt.Printf("⚠️  Failed to create file watcher: %v\n", err)
-		return
+		return fmt.Errorf("creating file watcher: %w", err)
 	}
 	defer watcher.Close()
 
-	// Add app directory and all subdirectories
-	if err := addDirectoryRecursive(watcher, appDir); err != nil {
-		fmt.Printf("⚠️  Failed to watch app/ directory: %v\n", err)
-		return
+	if err := addDirectoryRecursive(watcher, s.cwd); err != nil {
+		return fmt.Errorf("watching project: %w", err)
 	}
 
+	proxy := &http.Server{
+		Addr:    ":" + strconv.Itoa(port),
+		Handler: s.proxyHandler(),
+	}
+	listener, err := net.Listen("tcp", proxy.Addr)
+	if err != nil {
+		return fmt.Errorf("starting proxy: %w", err)
+	}
+	go proxy.Serve(listener)
+	defer proxy.Close()
+
+	fmt.Printf("🚀 Development server running at http://localhost:%d\n\n", port)
+
+	s.reload(devChange{build: true, restart: true})
+	defer s.stop()
+
+	s.watch(ctx, watcher)
+
+	fmt.Println("\n👋 Stopping development server...")
+	return nil
+}
+
+// watch batches file changes and applies them until ctx is done
+func (s *devServer) watch(ctx context.Context, watcher *fsnotify.Watcher) {
 	// Debounce timer
-	var debounceTimer *time.Timer
+	var debounceTimer <-chan time.Time
 	debounceDelay := 500 * time.Millisecond
+	var pending devChange
 
 	for {
 		select {
+		case <-ctx.Done():
+			return
+
 		case event, ok := <-watcher.Events:
 			if !ok {
 				return
 			}
 
-			// Only watch .go files and directory changes
-			if !isWatchedFile(event.Name) && event.Op != fsnotify.Create {
-				continue
+			// Watch new directories
+			if event.Op.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !isIgnoredDir(info.Name()) {
+					addDirectoryRecursive(watcher, event.Name)
+					continue
+				}
 			}
 
-			// Reset debounce timer
-			if debounceTimer != nil {
-				debounceTimer.Stop()
+			change := s.classify(event)
+			if change == (devChange{}) {
+				continue
 			}
 
-			debounceTimer = time.AfterFunc(debounceDelay, func() {
-				fmt.Println("🔄 App directory changed, regenerating routes...")
-
-				// Check if new directory was created
-				if event.Op == fsnotify.Create {
-					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
-						// Add new directory to watcher
-						addDirectoryRecursive(watcher, event.Name)
-					}
-				}
+			pending.routes = pending.routes || change.routes
+			pending.build = pending.build || change.build
+			pending.restart = pending.restart || change.restart
+			pending.reload = pending.reload || change.reload
+			pending.css = pending.css || change.css
+			debounceTimer = time.After(debounceDelay)
 
-				if err := generateRoutes(cwd, appDir); err != nil {
-					fmt.Printf("❌ Failed to regenerate routes: %v\n", err)
-				} else {
-					fmt.Println("✅ Routes regenerated")
-				}
-			})
+		case <-debounceTimer:
+			s.reload(pending)
+			pending = devChange{}
+			debounceTimer = nil
 
 		case err, ok := <-watcher.Errors:
 			if !ok {
@@ -155,6 +224,369 @@ func watchAppDirectory(cwd, appDir string) {
 	}
 }
 
+// classify returns what a file event requires. Go files and removed
+// directories under app/ regenerate routes, other Go files rebuild, .env
+// restarts, stylesheets are swapped and templates, which the app re-parses
+// itself, and other public files reload the page.
+func (s *devServer) classify(event fsnotify.Event) devChange {
+	name := event.Name
+	rel, err := filepath.Rel(s.cwd, name)
+	if err != nil {
+		return devChange{}
+	}
+	inApp := s.appDir != "" && strings.HasPrefix(rel, "app"+string(filepath.Separator))
+
+	switch {
+	case inApp && filepath.Ext(name) == "" && event.Op.Has(fsnotify.Remove|fsnotify.Rename):
+		return devChange{routes: true, build: true, restart: true}
+
+	case filepath.Ext(name) == ".go":
+		if strings.HasSuffix(name, "_test.go") || filepath.Base(name) == "routes.gen.go" {
+			return devChange{}
+		}
+		return devChange{routes: inApp && isWatchedFile(name), build: true, restart: true}
+
+	case filepath.Ext(name) == ".html" && strings.HasPrefix(rel, "templates"+string(filepath.Separator)):
+		return devChange{reload: true}
+
+	case rel == ".env":
+		return devChange{restart: true}
+
+	case strings.HasPrefix(rel, filepath.Join("public", "assets", "css")+string(filepath.Separator)) && filepath.Ext(name) == ".css":
+		return devChange{css: true}
+
+	case strings.HasPrefix(rel, "public"+string(filepath.Separator)) && filepath.Ext(name) != "":
+		return devChange{reload: true}
+	}
+
+	return devChange{}
+}
+
+// reload applies a batch of changes. A failed route generation or build
+// keeps the running app and reports the error to proxied requests. Open
+// pages reload once the restarted app is ready.
+func (s *devServer) reload(change devChange) {
+	if change.routes {
+		fmt.Println("🔄 App directory changed, regenerating routes...")
+		if err := generateRoutes(s.cwd, s.appDir); err != nil {
+			fmt.Printf("❌ Failed to regenerate routes: %v\n", err)
+			s.fail(err)
+			return
+		}
+		fmt.Println("✅ Routes regenerated")
+	}
+
+	if change.build {
+		fmt.Println("🔨 Building...")
+		if err := s.build(); err != nil {
+			fmt.Printf("❌ Build failed:\n%v\n", err)
+			s.fail(err)
+			return
+		}
+	}
+
+	switch {
+	case change.restart:
+		s.stop()
+		if err := s.start(); err != nil {
+			fmt.Printf("❌ Failed to start app: %v\n", err)
+			s.fail(err)
+		}
+	case change.reload:
+		s.reloader.Send("reload")
+	case change.css:
+		s.reloader.Send("css")
+	}
+}
+
+// fail reports err to proxied requests and reloads open pages to show it
+func (s *devServer) fail(err error) {
+	s.gate.Fail(err)
+	s.reloader.Send("reload")
+}
+
+// build compiles the project into the dev binary
+func (s *devServer) build() error {
+	build := exec.Command("go", "build", "-o", s.bin, ".")
+	build.Dir = s.cwd
+	output, err := build.CombinedOutput()
+	if err != nil {
+		return errors.New(strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// start runs the dev binary and opens the gate once it accepts connections
+func (s *devServer) start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.bin); err != nil {
+		return fmt.Errorf("binary not built")
+	}
+
+	_, port, _ := net.SplitHostPort(s.appAddr)
+	process := exec.Command(s.bin)
+	process.Dir = s.cwd
+	process.Env = s.appEnv(port)
+	process.Stdout = os.Stdout
+	process.Stderr = os.Stderr
+	if err := process.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		process.Wait()
+		close(exited)
+	}()
+	s.process = process
+	s.exited = exited
+
+	go s.awaitReady(exited)
+	return nil
+}
+
+// appEnv returns the environment of the dev binary listening on port. It
+// runs in development unless the environment or .env names another
+// TWINE_ENV, as applications default to production.
+func (s *devServer) appEnv(port string) []string {
+	return withTwineEnv(s.cwd, config.EnvDevelopment, append(os.Environ(), "PORT="+port, "TEMPLATES_RELOAD=true"))
+}
+
+// withTwineEnv adds TWINE_ENV=name to env unless the environment or the .env
+// file in dir already sets TWINE_ENV
+func withTwineEnv(dir, name string, env []string) []string {
+	if _, ok := os.LookupEnv("TWINE_ENV"); ok {
+		return env
+	}
+	if dotenv, err := godotenv.Read(filepath.Join(dir, ".env")); err == nil && dotenv["TWINE_ENV"] != "" {
+		return env
+	}
+	return append(env, "TWINE_ENV="+name)
+}
+
+func (s *devServer) awaitReady(exited chan struct{}) {
+	for {
+		select {
+		case <-exited:
+			s.settle(exited, fmt.Errorf("app exited before accepting connections on %s; check that main.go listens on PORT", s.appAddr))
+			return
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", s.appAddr, 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			s.settle(exited, nil)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// settle opens or fails the gate if exited still belongs to the running app
+func (s *devServer) settle(exited chan struct{}, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.exited != exited {
+		return
+	}
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	s.gate.Open()
+	s.reloader.Send("reload")
+}
+
+// stop closes the gate and shuts down the running app, giving it a few
+// seconds to finish in-flight requests
+func (s *devServer) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gate.Close()
+	if s.process == nil {
+		return
+	}
+
+	if err := s.process.Process.Signal(os.Interrupt); err != nil {
+		s.process.Process.Kill()
+	}
+	select {
+	case <-s.exited:
+	case <-time.After(5 * time.Second):
+		s.process.Process.Kill()
+		<-s.exited
+	}
+	s.process = nil
+	s.exited = nil
+}
+
+// proxyHandler forwards requests to the app, waiting while it restarts. It
+// serves the live reload endpoint and files under public/ itself, so
+// stylesheets can change without a rebuild, and injects the live reload
+// script into full HTML pages.
+func (s *devServer) proxyHandler() http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: s.appAddr})
+	proxy.ModifyResponse = injectLiveReload
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		writeDevError(w, r, err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == liveReloadPath {
+			s.reloader.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/public/") {
+			file := filepath.Join(s.cwd, filepath.FromSlash(path.Clean(r.URL.Path)))
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				w.Header().Set("Cache-Control", "no-cache")
+				http.ServeFile(w, r, file)
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), devReadyTimeout)
+		defer cancel()
+
+		if err := s.gate.Wait(ctx); err != nil {
+			writeDevError(w, r, err)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// writeDevError reports a build or proxy error to the browser. Pages get an
+// HTML error that reloads once the app is fixed.
+func writeDevError(w http.ResponseWriter, r *http.Request, err error) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(w, "twine dev: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadGateway)
+	devErrorPage.Execute(w, err.Error())
+}
+
+// devGate tracks whether the app is ready for requests. Waiters block while
+// the gate is closed and are released when it opens or fails.
+type devGate struct {
+	mu    sync.Mutex
+	ready chan struct{}
+	err   error
+}
+
+// newDevGate creates a closed gate
+func newDevGate() *devGate {
+	return &devGate{ready: make(chan struct{})}
+}
+
+// Wait blocks until the gate opens, fails or ctx is done
+func (g *devGate) Wait(ctx context.Context) error {
+	g.mu.Lock()
+	ready := g.ready
+	g.mu.Unlock()
+
+	select {
+	case <-ready:
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out waiting for the app to start")
+		}
+		return ctx.Err()
+	}
+}
+
+// Open releases waiters to the running app
+func (g *devGate) Open() {
+	g.release(nil)
+}
+
+// Fail releases waiters with err until the gate is closed again
+func (g *devGate) Fail(err error) {
+	g.release(err)
+}
+
+// Close makes new waiters block until the gate opens or fails
+func (g *devGate) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	select {
+	case <-g.ready:
+		g.ready = make(chan struct{})
+	default:
+	}
+	g.err = nil
+}
+
+func (g *devGate) release(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.err = err
+	select {
+	case <-g.ready:
+	default:
+		close(g.ready)
+	}
+}
+
+func generateRoutes(cwd, appDir string) error {
+	// Scan routes
+	root, err := routing.ScanRoutes(appDir)
+	if err != nil {
+		return fmt.Errorf("scanning routes: %w", err)
+	}
+
+	// Validate routes
+	if err := root.Validate(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	// Get module path
+	modulePath, err := routing.GetModulePath(cwd)
+	if err != nil {
+		return fmt.Errorf("getting module path: %w", err)
+	}
+
+	projectCfg, err := loadProjectConfig(cwd)
+	if err != nil {
+		return err
+	}
+
+	// Generate code
+	outputFile := filepath.Join(appDir, "routes.gen.go")
+	generator := &routing.CodeGenerator{
+		RouteTree:   root,
+		ModulePath:  modulePath,
+		ProjectRoot: cwd,
+		OutputFile:  outputFile,
+		Template:    projectCfg.Routes.Template,
+		API:         projectCfg.apiVersioning(),
+	}
+
+	if err := generator.Generate(); err != nil {
+		return fmt.Errorf("generating routes: %w", err)
+	}
+
+	return nil
+}
+
+// addDirectoryRecursive watches dir and its subdirectories, skipping build
+// output, dependencies and hidden directories
 func addDirectoryRecursive(watcher *fsnotify.Watcher, dir string) error {
 	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -162,6 +594,9 @@ func addDirectoryRecursive(watcher *fsnotify.Watcher, dir string) error {
 		}
 
 		if info.IsDir() {
+			if path != dir && isIgnoredDir(info.Name()) {
+				return filepath.SkipDir
+			}
 			return watcher.Add(path)
 		}
 
@@ -169,6 +604,15 @@ func addDirectoryRecursive(watcher *fsnotify.Watcher, dir string) error {
 	})
 }
 
+// isIgnoredDir reports whether twine dev skips a directory when watching
+func isIgnoredDir(name string) bool {
+	switch name {
+	case devBuildDir, "vendor", "node_modules", "testdata":
+		return true
+	}
+	return strings.HasPrefix(name, ".")
+}
+
 func isWatchedFile(path string) bool {
 	// Exclude generated files to prevent infinite regeneration loop
 	basename := filepath.Base(path)
@@ -1,9 +1,15 @@
 package commands
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +25,158 @@ func TestNewDevCommand(t *testing.T) {
 	assert.Equal(t, "Start development server with hot reload", cmd.Short)
 	assert.NotEmpty(t, cmd.Long)
 	assert.NotNil(t, cmd.RunE)
+	assert.Equal(t, "3000", cmd.Flags().Lookup("port").DefValue)
+	assert.NotNil(t, cmd.Flags().Lookup("app-port"))
+}
+
+// TestDevCommand_Errors tests checks that run before the dev server starts
+func TestDevCommand_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	cmd := NewDevCommand()
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "go.mod not found")
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module github.com/test/project\n"), 0644))
+
+	cmd = NewDevCommand()
+	cmd.SetArgs([]string{"--port", "4000", "--app-port", "4000"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must differ")
+}
+
+// TestDevServer_Classify tests which file changes regenerate routes, rebuild
+// or restart the app
+func TestDevServer_Classify(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	server := newDevServer(root, filepath.Join(root, "app"), 3001)
+
+	tests := []struct {
+		name     string
+		path     string
+		op       fsnotify.Op
+		expected devChange
+	}{
+		{"route file", "app/pages/users/page.go", fsnotify.Write, devChange{routes: true, build: true, restart: true}},
+		{"removed route directory", "app/pages/users", fsnotify.Remove, devChange{routes: true, build: true, restart: true}},
+		{"created route directory", "app/pages/users", fsnotify.Create, devChange{}},
+		{"generated routes", "app/routes.gen.go", fsnotify.Write, devChange{}},
+		{"Go file", "stores/user.go", fsnotify.Write, devChange{build: true, restart: true}},
+		{"test file", "stores/user_test.go", fsnotify.Write, devChange{}},
+		{"template", "templates/pages/users.html", fsnotify.Write, devChange{reload: true}},
+		{"html outside templates", "public/index.html", fsnotify.Write, devChange{reload: true}},
+		{"env file", ".env", fsnotify.Write, devChange{restart: true}},
+		{"stylesheet", "public/assets/css/output.css", fsnotify.Write, devChange{css: true}},
+		{"script", "public/assets/js/app.js", fsnotify.Write, devChange{reload: true}},
+		{"public directory", "public/assets", fsnotify.Remove, devChange{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := fsnotify.Event{Name: filepath.Join(root, filepath.FromSlash(tt.path)), Op: tt.op}
+			assert.Equal(t, tt.expected, server.classify(event))
+		})
+	}
+
+	// Without app/, Go changes only rebuild
+	server = newDevServer(root, "", 3001)
+	event := fsnotify.Event{Name: filepath.Join(root, "app", "pages", "page.go"), Op: fsnotify.Write}
+	assert.Equal(t, devChange{build: true, restart: true}, server.classify(event))
+}
+
+// TestIsIgnoredDir tests directories skipped by the watcher
+func TestIsIgnoredDir(t *testing.T) {
+	for _, name := range []string{"tmp", "vendor", "node_modules", "testdata", ".git", ".twine-db-123"} {
+		assert.True(t, isIgnoredDir(name), name)
+	}
+	for _, name := range []string{"app", "templates", "models", "db"} {
+		assert.False(t, isIgnoredDir(name), name)
+	}
+}
+
+// TestDevGate tests holding requests while the app restarts
+func TestDevGate(t *testing.T) {
+	gate := newDevGate()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Error(t, gate.Wait(ctx), "a closed gate blocks")
+
+	done := make(chan error)
+	go func() { done <- gate.Wait(context.Background()) }()
+	gate.Open()
+	assert.NoError(t, <-done)
+
+	gate.Fail(errors.New("build failed"))
+	err := gate.Wait(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "build failed")
+
+	gate.Close()
+	gate.Open()
+	assert.NoError(t, gate.Wait(context.Background()))
+}
+
+// TestDevServer_Proxy tests that requests made during a restart wait for
+// the app instead of failing
+func TestDevServer_Proxy(t *testing.T) {
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello from " + r.URL.Path))
+	}))
+	defer app.Close()
+
+	server := newDevServer(t.TempDir(), "", 0)
+	server.appAddr = app.Listener.Addr().String()
+	proxy := httptest.NewServer(server.proxyHandler())
+	defer proxy.Close()
+
+	// The app comes up after the request is made
+	time.AfterFunc(50*time.Millisecond, server.gate.Open)
+
+	resp, err := http.Get(proxy.URL + "/users")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello from /users", string(body))
+
+	// Build errors are shown instead of the app
+	server.gate.Fail(errors.New("main.go:1: syntax error"))
+	resp, err = http.Get(proxy.URL + "/users")
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Contains(t, string(body), "syntax error")
+}
+
+// TestDevServer_AppEnv tests running the app in development unless
+// TWINE_ENV is set
+func TestDevServer_AppEnv(t *testing.T) {
+	t.Setenv("TWINE_ENV", "")
+	os.Unsetenv("TWINE_ENV")
+	server := newDevServer(t.TempDir(), "", 0)
+
+	env := server.appEnv("3001")
+	assert.Contains(t, env, "PORT=3001")
+	assert.Contains(t, env, "TWINE_ENV=development")
+
+	require.NoError(t, os.WriteFile(filepath.Join(server.cwd, ".env"), []byte("TWINE_ENV=staging\n"), 0644))
+	assert.NotContains(t, server.appEnv("3001"), "TWINE_ENV=development", ".env names another environment")
+
+	t.Setenv("TWINE_ENV", "test")
+	assert.NotContains(t, server.appEnv("3001"), "TWINE_ENV=development")
 }
 
 // TestIsWatchedFile tests file extension filtering
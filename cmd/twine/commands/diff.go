@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ANSI color codes for diff output
+const (
+	colorReset = "\033[0m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// unifiedDiff returns a unified diff between the current and generated
+// contents of name, or an empty string if they are identical
+func unifiedDiff(name string, current, generated []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(current)),
+		B:        difflib.SplitLines(string(generated)),
+		FromFile: name,
+		ToFile:   name + " (generated)",
+		Context:  3,
+	})
+}
+
+// colorizeDiff highlights additions, removals and hunk headers
+func colorizeDiff(diff string) string {
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		color := ""
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			color = colorGreen
+		case strings.HasPrefix(line, "-"):
+			color = colorRed
+		case strings.HasPrefix(line, "@@"):
+			color = colorCyan
+		}
+		if color == "" {
+			sb.WriteString(line)
+			continue
+		}
+		sb.WriteString(color + strings.TrimSuffix(line, "\n") + colorReset + "\n")
+	}
+	return sb.String()
+}
+
+// useColor reports whether w is a terminal and NO_COLOR is unset
+func useColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnifiedDiff tests diff generation between file versions
+func TestUnifiedDiff(t *testing.T) {
+	diff, err := unifiedDiff("routes.gen.go", []byte("a\nb\n"), []byte("a\nc\n"))
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- routes.gen.go\n")
+	assert.Contains(t, diff, "+++ routes.gen.go (generated)\n")
+	assert.Contains(t, diff, "-b\n")
+	assert.Contains(t, diff, "+c\n")
+
+	diff, err = unifiedDiff("routes.gen.go", []byte("a\n"), []byte("a\n"))
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+// TestColorizeDiff tests ANSI highlighting of diff lines
+func TestColorizeDiff(t *testing.T) {
+	diff := "--- a\n+++ b\n@@ -1 +1 @@\n-old\n+new\n same\n"
+	colored := colorizeDiff(diff)
+
+	assert.Contains(t, colored, "--- a\n+++ b\n")
+	assert.Contains(t, colored, colorCyan+"@@ -1 +1 @@"+colorReset+"\n")
+	assert.Contains(t, colored, colorRed+"-old"+colorReset+"\n")
+	assert.Contains(t, colored, colorGreen+"+new"+colorReset+"\n")
+	assert.Contains(t, colored, " same\n")
+}
+
+// TestUseColor tests that non-terminal writers get plain output
+func TestUseColor(t *testing.T) {
+	assert.False(t, useColor(&bytes.Buffer{}))
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, useColor(&bytes.Buffer{}))
+}
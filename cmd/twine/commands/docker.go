@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// dockerConfig is the data passed to the internal/scaffold/docker templates
+type dockerConfig struct {
+	ProjectName string // Binary and database name
+	Port        string
+	GoVersion   string // Go image version, from the go directive in go.mod
+	NodeVersion string // Node image version for building CSS
+	Node        bool   // package.json exists, so CSS is built in its own stage
+	Templates   bool   // templates/ exists and is copied into the image
+	Public      bool   // public/ exists and is copied into the image
+}
+
+// dockerNodeVersion is the Node image used to build CSS
+const dockerNodeVersion = "22"
+
+func newGenerateDockerCommand(force *bool) *cobra.Command {
+	var port string
+
+	cmd := &cobra.Command{
+		Use:   "docker",
+		Short: "Generate a Dockerfile, compose.yaml and .dockerignore",
+		Long: `Generate a multi-stage Dockerfile building the CSS and a static binary, a
+compose.yaml running the app with Postgres using the DB_* variables read by
+config.Get, and a .dockerignore.`,
+		Example: "  twine generate docker\n  twine generate docker --port 8080",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, _, err := prepareGenerate(false)
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+
+			config, err := newDockerConfig(cwd, filepath.Base(cwd), port)
+			if err != nil {
+				return err
+			}
+
+			if err := writeDocker(cwd, config, *force); err != nil {
+				return err
+			}
+
+			fmt.Println("\nRun 'docker compose up --build' to start the app and Postgres")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&port, "port", "p", "3000", "Port the app listens on")
+
+	return cmd
+}
+
+// newDockerConfig inspects the project at projectPath for the files its
+// image needs
+func newDockerConfig(projectPath, projectName, port string) (*dockerConfig, error) {
+	goVersion, err := goDirective(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(projectPath, name))
+		return err == nil
+	}
+
+	return &dockerConfig{
+		ProjectName: projectName,
+		Port:        port,
+		GoVersion:   goVersion,
+		NodeVersion: dockerNodeVersion,
+		Node:        exists("package.json"),
+		Templates:   exists("templates"),
+		Public:      exists("public"),
+	}, nil
+}
+
+// writeDocker writes the Dockerfile, compose.yaml and .dockerignore
+func writeDocker(projectPath string, config *dockerConfig, force bool) error {
+	files := []struct {
+		src, dest string
+	}{
+		{"docker/Dockerfile.tmpl", "Dockerfile"},
+		{"docker/compose.yaml.tmpl", "compose.yaml"},
+		{"docker/dockerignore.tmpl", ".dockerignore"},
+	}
+
+	for _, f := range files {
+		if err := writeTextScaffold(f.src, filepath.Join(projectPath, f.dest), config, force); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// goDirective returns the version in the go directive of the project's go.mod
+func goDirective(projectPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(projectPath, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("reading go.mod: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "go" {
+			return fields[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("go directive not found in go.mod")
+}
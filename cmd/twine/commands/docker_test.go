@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteDocker tests the generated files for a full project
+func TestWriteDocker(t *testing.T) {
+	projectDir := setupTestProject(t)
+	writeTestFile(t, projectDir, "package.json", "{}")
+	writeTestFile(t, projectDir, "templates/pages/index.html", "")
+	writeTestFile(t, projectDir, "public/assets/css/input.css", "")
+
+	docker, err := newDockerConfig(projectDir, "blog", "8080")
+	require.NoError(t, err)
+	assert.Equal(t, "1.22", docker.GoVersion)
+	require.NoError(t, writeDocker(projectDir, docker, false))
+
+	dockerfile, err := os.ReadFile(filepath.Join(projectDir, "Dockerfile"))
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), "FROM node:"+dockerNodeVersion+"-alpine AS assets")
+	assert.Contains(t, string(dockerfile), "FROM golang:1.22-alpine AS build")
+	assert.Contains(t, string(dockerfile), "COPY templates ./templates")
+	assert.Contains(t, string(dockerfile), "COPY --from=assets /src/public ./public")
+	assert.Contains(t, string(dockerfile), "EXPOSE 8080")
+	assert.Contains(t, string(dockerfile), `CMD ["./blog"]`)
+
+	compose, err := os.ReadFile(filepath.Join(projectDir, "compose.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(compose), `"8080:8080"`)
+	assert.Contains(t, string(compose), "POSTGRES_DB: blog")
+
+	assert.FileExists(t, filepath.Join(projectDir, ".dockerignore"))
+
+	err = writeDocker(projectDir, docker, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+	require.NoError(t, writeDocker(projectDir, docker, true))
+}
+
+// TestWriteDocker_GoOnly tests a project without Node tooling or static files
+func TestWriteDocker_GoOnly(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	docker, err := newDockerConfig(projectDir, "api", "3000")
+	require.NoError(t, err)
+	require.NoError(t, writeDocker(projectDir, docker, false))
+
+	dockerfile, err := os.ReadFile(filepath.Join(projectDir, "Dockerfile"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(dockerfile), "node:")
+	assert.NotContains(t, string(dockerfile), "COPY templates")
+	assert.NotContains(t, string(dockerfile), "COPY public")
+}
+
+// TestWriteDocker_ComposeDatabaseEnv tests that compose.yaml sets every
+// database variable config.Get reads
+func TestWriteDocker_ComposeDatabaseEnv(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	docker, err := newDockerConfig(projectDir, "blog", "3000")
+	require.NoError(t, err)
+	require.NoError(t, writeDocker(projectDir, docker, false))
+
+	compose, err := os.ReadFile(filepath.Join(projectDir, "compose.yaml"))
+	require.NoError(t, err)
+
+	for _, v := range config.EnvVars {
+		if strings.HasPrefix(v.Name, "DB_") || v.Name == "AUTH_SECRET" {
+			assert.Contains(t, string(compose), "      "+v.Name+":", "compose.yaml does not set %s", v.Name)
+		}
+	}
+}
+
+// TestGoDirective tests reading the go version from go.mod
+func TestGoDirective(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "go.mod", "module example.com/app\n\ngo 1.23.4\n\ntoolchain go1.24.0\n")
+
+	version, err := goDirective(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "1.23.4", version)
+
+	writeTestFile(t, dir, "go.mod", "module example.com/app\n")
+	_, err = goDirective(dir)
+	assert.Error(t, err)
+}
+
+// TestGenerateDocker_Init tests that init only writes Docker files with --with-docker
+func TestGenerateDocker_Init(t *testing.T) {
+	projectDir := setupTestProject(t)
+	project := ProjectConfig{ProjectName: "my-app", Port: "3000"}
+
+	require.NoError(t, generateDocker(project, projectDir))
+	assert.NoFileExists(t, filepath.Join(projectDir, "Dockerfile"))
+
+	project.WithDocker = true
+	require.NoError(t, generateDocker(project, projectDir))
+	assert.FileExists(t, filepath.Join(projectDir, "Dockerfile"))
+	assert.FileExists(t, filepath.Join(projectDir, "compose.yaml"))
+	assert.FileExists(t, filepath.Join(projectDir, ".dockerignore"))
+}
@@ -0,0 +1,749 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html/template"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/cstone-io/twine/internal/routing"
+	"github.com/cstone-io/twine/pkg/config"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+	twinetemplate "github.com/cstone-io/twine/pkg/template"
+)
+
+// doctorStatus is the outcome of a doctor check
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorSkip
+	doctorWarn
+	doctorFail
+)
+
+// doctorResult reports one check and, unless it passed, how to fix it
+type doctorResult struct {
+	Name    string
+	Status  doctorStatus
+	Message string
+	Fix     string
+}
+
+// doctorCheck inspects the project in root
+type doctorCheck func(root string, env map[string]string) doctorResult
+
+// doctorChecks are run in order by twine doctor
+var doctorChecks = []doctorCheck{
+	checkModulePath,
+	checkRoutesFresh,
+	checkTemplates,
+	checkComponentProps,
+	checkEnvVars,
+	checkErrorCodes,
+	checkNodeTooling,
+	checkDatabase,
+}
+
+// doctorDBTimeout bounds the database connectivity check
+const doctorDBTimeout = 5 * time.Second
+
+// NewDoctorCommand creates the doctor command
+func NewDoctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check project health",
+		Long: `Check the project for common problems and print how to fix them.
+
+twine doctor checks that imports match the go.mod module path, that
+app/routes.gen.go is up to date, that templates parse and components only
+use fields of their props structs, that the environment variables read by
+config are set, that error codes registered with errors.Register don't
+collide, that Node tooling is installed when the project has a
+package.json, and that the database is reachable.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("getting current directory: %w", err)
+			}
+			cmd.SilenceUsage = true
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintln(out, "🩺 Checking project health...")
+			fmt.Fprintln(out)
+
+			env := doctorEnv(cwd)
+			failed := 0
+			for _, check := range doctorChecks {
+				result := check(cwd, env)
+				writeDoctorResult(out, result)
+				if result.Status == doctorFail {
+					failed++
+				}
+			}
+
+			fmt.Fprintln(out)
+			if failed > 0 {
+				return fmt.Errorf("%d check(s) failed", failed)
+			}
+			fmt.Fprintln(out, "✅ No problems found")
+			return nil
+		},
+	}
+}
+
+// writeDoctorResult prints a check result and its fix
+func writeDoctorResult(out io.Writer, r doctorResult) {
+	icon := map[doctorStatus]string{
+		doctorOK:   "✅",
+		doctorSkip: "➖",
+		doctorWarn: "⚠️ ",
+		doctorFail: "❌",
+	}[r.Status]
+
+	fmt.Fprintf(out, "%s %-16s %s\n", icon, r.Name, strings.ReplaceAll(r.Message, "\n", "\n                    "))
+	if r.Fix != "" && (r.Status == doctorWarn || r.Status == doctorFail) {
+		fmt.Fprintf(out, "   → %s\n", r.Fix)
+	}
+}
+
+// doctorEnv returns the environment the application would see: .env in the
+// project root, overridden by the process environment as godotenv does
+func doctorEnv(root string) map[string]string {
+	env, err := godotenv.Read(filepath.Join(root, ".env"))
+	if err != nil {
+		env = map[string]string{}
+	}
+	for _, v := range config.EnvVars {
+		names := []string{v.Name}
+		if v.Secret {
+			names = append(names, v.Name+"_FILE")
+		}
+		for _, name := range names {
+			if value, ok := os.LookupEnv(name); ok {
+				env[name] = value
+			}
+		}
+	}
+	return env
+}
+
+// checkModulePath reports project imports that name a package in the
+// project under a module path other than the one in go.mod
+func checkModulePath(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Module path"}
+
+	modulePath, err := routing.GetModulePath(root)
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = "go.mod not found or has no module directive"
+		result.Fix = "Run twine doctor from the project root, or create a project with 'twine init'"
+		return result
+	}
+
+	problems := []string{}
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && isIgnoredDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil
+		}
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			if pkg := localPackage(root, modulePath, importPath); pkg != "" {
+				rel, _ := filepath.Rel(root, path)
+				problems = append(problems, fmt.Sprintf("%s imports %s, expected %s/%s", filepath.ToSlash(rel), importPath, modulePath, pkg))
+			}
+		}
+		return nil
+	})
+
+	if len(problems) == 0 {
+		result.Message = modulePath
+		return result
+	}
+
+	result.Status = doctorFail
+	result.Message = strings.Join(problems, "\n")
+	result.Fix = "Update the imports to the go.mod module path and run 'twine routes generate' to refresh app/routes.gen.go"
+	return result
+}
+
+// localPackage returns the project directory importPath refers to if it
+// ends in a package of the project but does not start with modulePath
+func localPackage(root, modulePath, importPath string) string {
+	if importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/") {
+		return ""
+	}
+
+	parts := strings.Split(importPath, "/")
+	if !strings.Contains(parts[0], ".") || len(parts) < 2 {
+		return "" // standard library
+	}
+
+	for i := 1; i < len(parts); i++ {
+		pkg := strings.Join(parts[i:], "/")
+		matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(pkg), "*.go"))
+		if len(matches) > 0 {
+			return pkg
+		}
+	}
+	return ""
+}
+
+// checkRoutesFresh reports a missing or stale app/routes.gen.go
+func checkRoutesFresh(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Routes", Fix: "Run 'twine routes generate'"}
+
+	appDir := filepath.Join(root, "app")
+	if _, err := os.Stat(appDir); os.IsNotExist(err) {
+		result.Status = doctorSkip
+		result.Message = "no app/ directory"
+		return result
+	}
+
+	tree, err := routing.ScanRoutes(appDir)
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("scanning routes: %v", err)
+		return result
+	}
+	if errs := relativeDiagnostics(tree.Diagnose(), root).Errors(); len(errs) > 0 {
+		result.Status = doctorFail
+		result.Message = errs.Error()
+		result.Fix = "Fix the route errors above, then run 'twine routes generate'"
+		return result
+	}
+
+	modulePath, err := routing.GetModulePath(root)
+	if err != nil {
+		result.Status = doctorSkip
+		result.Message = "no module path"
+		return result
+	}
+	projectCfg, err := loadProjectConfig(root)
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = err.Error()
+		result.Fix = "Fix " + projectConfigFile
+		return result
+	}
+
+	generator := &routing.CodeGenerator{
+		RouteTree:   tree,
+		ModulePath:  modulePath,
+		ProjectRoot: root,
+		OutputFile:  filepath.Join(appDir, "routes.gen.go"),
+		Template:    projectCfg.Routes.Template,
+		API:         projectCfg.apiVersioning(),
+	}
+	generated, err := generator.Render()
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("generating routes: %v", err)
+		return result
+	}
+
+	current, err := os.ReadFile(generator.OutputFile)
+	switch {
+	case os.IsNotExist(err):
+		result.Status = doctorFail
+		result.Message = "app/routes.gen.go is missing"
+	case err != nil:
+		result.Status = doctorFail
+		result.Message = err.Error()
+	case !bytes.Equal(current, generated):
+		result.Status = doctorFail
+		result.Message = "app/routes.gen.go is out of date"
+	default:
+		result.Message = "app/routes.gen.go is up to date"
+	}
+	return result
+}
+
+// checkTemplates parses every template under templates/ and reports syntax
+// errors, references to undefined templates and files the default
+// templates/**/*.html pattern does not load
+func checkTemplates(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Templates"}
+
+	dir := filepath.Join(root, "templates")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		result.Status = doctorSkip
+		result.Message = "no templates/ directory"
+		return result
+	}
+
+	defined := map[string]bool{}
+	references := map[string][]string{} // template name -> files referencing it
+	errs := []string{}
+	unmatched := []string{}
+	count := 0
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".html" {
+			return nil
+		}
+		count++
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+
+		// ParseGlob treats ** like *, so only templates/<dir>/<file>.html load
+		if strings.Count(rel, "/") != 2 {
+			unmatched = append(unmatched, rel)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return nil
+		}
+		tmpl, err := template.New(rel).Funcs(twinetemplate.FuncMap()).Parse(string(content))
+		if err != nil {
+			errs = append(errs, err.Error())
+			return nil
+		}
+
+		for _, t := range tmpl.Templates() {
+			if t.Tree == nil {
+				continue
+			}
+			if t.Name() != rel {
+				defined[t.Name()] = true
+			}
+			for _, name := range templateReferences(t.Tree.Root) {
+				references[name] = append(references[name], rel)
+			}
+		}
+		return nil
+	})
+
+	undefined := []string{}
+	for name, files := range references {
+		if !defined[name] {
+			undefined = append(undefined, fmt.Sprintf("%s references undefined template %q", files[0], name))
+		}
+	}
+	sort.Strings(undefined)
+	errs = append(errs, undefined...)
+
+	switch {
+	case len(errs) > 0:
+		result.Status = doctorFail
+		result.Message = strings.Join(errs, "\n")
+		result.Fix = "Fix the template errors above; the app fails to start or render until they are fixed"
+	case len(unmatched) > 0:
+		result.Status = doctorWarn
+		result.Message = "not loaded by templates/**/*.html: " + strings.Join(unmatched, ", ")
+		result.Fix = "Move these files to templates/<dir>/, or pass their pattern to template.LoadTemplates"
+	default:
+		result.Message = fmt.Sprintf("%d template file(s) parsed", count)
+	}
+	return result
+}
+
+// templateReferences returns the names used in {{template}} actions and
+// {{component}} calls under node
+func templateReferences(node parse.Node) []string {
+	names := []string{}
+
+	var walk func(parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			if name, ok := componentCall(n); ok {
+				names = append(names, name)
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.TemplateNode:
+			names = append(names, n.Name)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	walk(node)
+
+	return names
+}
+
+// componentCall returns the component a {{component "name" props}} command
+// renders, when its name is a literal
+func componentCall(cmd *parse.CommandNode) (string, bool) {
+	if len(cmd.Args) < 2 {
+		return "", false
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "component" {
+		return "", false
+	}
+	name, ok := cmd.Args[1].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	return name.Text, true
+}
+
+// checkEnvVars reports variables config reads that are unset and have no
+// default, invalid numbers, and similarly named variables config ignores
+func checkEnvVars(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Environment"}
+
+	known := map[string]bool{}
+	missing := []string{}
+	for _, v := range config.EnvVars {
+		known[v.Name] = true
+		set := env[v.Name] != ""
+		if v.Secret {
+			// Docker-style secrets name a file with the value
+			known[v.Name+"_FILE"] = true
+			set = set || env[v.Name+"_FILE"] != ""
+		}
+		if !set && v.Default == "" && !v.Optional {
+			missing = append(missing, v.Name)
+		}
+	}
+
+	if port := env["DB_PORT"]; port != "" {
+		if _, err := strconv.Atoi(port); err != nil {
+			result.Status = doctorFail
+			result.Message = fmt.Sprintf("DB_PORT %q is not a number; the app exits on startup", port)
+			result.Fix = "Set DB_PORT to a port number, e.g. 5432"
+			return result
+		}
+	}
+
+	unknown := []string{}
+	for name := range env {
+		if known[name] {
+			continue
+		}
+		for _, prefix := range []string{"DB_", "LOGGER_", "AUTH_"} {
+			if strings.HasPrefix(name, prefix) {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	sort.Strings(unknown)
+
+	if len(missing) == 0 && len(unknown) == 0 {
+		result.Message = "all variables read by config are set"
+		return result
+	}
+
+	messages := []string{}
+	if len(missing) > 0 {
+		messages = append(messages, "not set: "+strings.Join(missing, ", "))
+	}
+	if len(unknown) > 0 {
+		messages = append(messages, "not read by config: "+strings.Join(unknown, ", "))
+	}
+	result.Status = doctorWarn
+	result.Message = strings.Join(messages, "\n")
+	result.Fix = "Set the variables in .env or the environment. Config reads " + envVarNames()
+	return result
+}
+
+// envVarNames lists the variables config reads
+func envVarNames() string {
+	names := make([]string, len(config.EnvVars))
+	for i, v := range config.EnvVars {
+		names[i] = v.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// errorsImportPath is the package whose Register calls checkErrorCodes reads
+const errorsImportPath = "github.com/cstone-io/twine/pkg/errors"
+
+// checkErrorCodes reports error codes registered by the project that are
+// reserved for Twine or registered more than once. Register panics on these
+// at startup; the check finds them without running the app.
+func checkErrorCodes(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Error codes"}
+
+	registered := map[int][]string{} // code -> positions registering it
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && isIgnoredDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil || !importsPath(file, errorsImportPath) {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		ast.Inspect(file, func(node ast.Node) bool {
+			if code, ok := registeredCode(node); ok {
+				position := fmt.Sprintf("%s:%d", filepath.ToSlash(rel), fset.Position(node.Pos()).Line)
+				registered[code] = append(registered[code], position)
+			}
+			return true
+		})
+		return nil
+	})
+
+	if len(registered) == 0 {
+		result.Status = doctorSkip
+		result.Message = "no error codes registered"
+		return result
+	}
+
+	codes := make([]int, 0, len(registered))
+	for code := range registered {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	problems := []string{}
+	for _, code := range codes {
+		positions := registered[code]
+		if code < twineerrors.AppCodeMin {
+			problems = append(problems, fmt.Sprintf("%s registers %d, reserved for twine", positions[0], code))
+		}
+		if len(positions) > 1 {
+			problems = append(problems, fmt.Sprintf("%d is registered at %s", code, strings.Join(positions, ", ")))
+		}
+	}
+
+	if len(problems) == 0 {
+		result.Message = fmt.Sprintf("%d error code(s) registered", len(codes))
+		return result
+	}
+	result.Status = doctorFail
+	result.Message = strings.Join(problems, "\n")
+	result.Fix = fmt.Sprintf("Give each error its own code from %d up; the app panics on startup until they are unique", twineerrors.AppCodeMin)
+	return result
+}
+
+// importsPath reports whether file imports path
+func importsPath(file *ast.File, path string) bool {
+	for _, spec := range file.Imports {
+		if importPath, _ := strconv.Unquote(spec.Path.Value); importPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// registeredCode returns the code of a Register call with a literal code,
+// such as errors.Register(4001, ...) or payments.Register(5001, ...)
+func registeredCode(node ast.Node) (int, bool) {
+	call, ok := node.(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 {
+		return 0, false
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "Register" {
+		return 0, false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, false
+	}
+	code, err := strconv.ParseInt(lit.Value, 0, 0)
+	if err != nil {
+		return 0, false
+	}
+	return int(code), true
+}
+
+// checkNodeTooling reports missing Node tooling for projects with a
+// package.json
+func checkNodeTooling(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Node tooling"}
+
+	if _, err := os.Stat(filepath.Join(root, "package.json")); os.IsNotExist(err) {
+		result.Status = doctorSkip
+		result.Message = "no package.json"
+		return result
+	}
+
+	for _, tool := range []string{"node", "npm", "npx"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			result.Status = doctorFail
+			result.Message = tool + " not found in PATH"
+			result.Fix = "Install Node.js from https://nodejs.org to build CSS"
+			return result
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "node_modules")); os.IsNotExist(err) {
+		result.Status = doctorWarn
+		result.Message = "node_modules not found"
+		result.Fix = "Run 'npm install'"
+		return result
+	}
+
+	result.Message = "node, npm and npx found"
+	return result
+}
+
+// checkDatabase connects to the configured database when the project uses
+// one
+func checkDatabase(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Database"}
+
+	switch driver := envOrDefault(env, "DB_DRIVER", config.DriverPostgres); driver {
+	case config.DriverSQLite:
+		return checkSQLite(env)
+	case config.DriverMySQL:
+		result.Status = doctorSkip
+		result.Message = "doctor only connects to Postgres and SQLite databases"
+		return result
+	case config.DriverPostgres:
+	default:
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("unknown DB_DRIVER %q", driver)
+		result.Fix = "Set DB_DRIVER to postgres, sqlite or mysql"
+		return result
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "db")); os.IsNotExist(err) && env["DB_HOST"] == "" {
+		result.Status = doctorSkip
+		result.Message = "no db/ directory and DB_HOST not set"
+		return result
+	}
+	if env["DB_HOST"] == "" {
+		result.Status = doctorFail
+		result.Message = "DB_HOST not set"
+		result.Fix = "Set the DB_* variables in .env"
+		return result
+	}
+
+	port, _ := strconv.Atoi(env["DB_PORT"])
+	cfg := config.DatabaseConfig{
+		Host:     env["DB_HOST"],
+		Port:     port,
+		Username: env["DB_USERNAME"],
+		Password: env["DB_PASSWORD"],
+		Name:     env["DB_NAME"],
+		SSLMode:  envOrDefault(env, "DB_SSLMODE", "disable"),
+		TimeZone: envOrDefault(env, "DB_TIMEZONE", "UTC"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorDBTimeout)
+	defer cancel()
+
+	dsn := cfg.DSN() + " connect_timeout=" + strconv.Itoa(int(doctorDBTimeout.Seconds()))
+	client, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:                 logger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err == nil {
+		sqlDB, dbErr := client.DB()
+		if err = dbErr; err == nil {
+			defer sqlDB.Close()
+			err = sqlDB.PingContext(ctx)
+		}
+	}
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("cannot connect to %s:%d: %v", cfg.Host, cfg.Port, err)
+		result.Fix = "Check that the database is running and the DB_* variables in .env are correct"
+		return result
+	}
+
+	result.Message = fmt.Sprintf("connected to %s on %s:%d", cfg.Name, cfg.Host, cfg.Port)
+	return result
+}
+
+// checkSQLite checks that the directory of the SQLite database file exists,
+// so it can be created
+func checkSQLite(env map[string]string) doctorResult {
+	result := doctorResult{Name: "Database"}
+
+	name := env["DB_NAME"]
+	if name == "" {
+		result.Status = doctorFail
+		result.Message = "DB_NAME not set"
+		result.Fix = "Set DB_NAME to the SQLite database file in .env"
+		return result
+	}
+	if dir := filepath.Dir(name); !strings.HasPrefix(name, "file:") && name != ":memory:" {
+		if _, err := os.Stat(dir); err != nil {
+			result.Status = doctorFail
+			result.Message = fmt.Sprintf("directory %s for SQLite database %s not found", dir, name)
+			result.Fix = "Create " + dir + " or change DB_NAME"
+			return result
+		}
+	}
+
+	result.Message = "SQLite database " + name
+	return result
+}
+
+// envOrDefault returns env[key], or def if it is empty
+func envOrDefault(env map[string]string, key, def string) string {
+	if value := env[key]; value != "" {
+		return value
+	}
+	return def
+}
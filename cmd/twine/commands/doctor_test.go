@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const doctorTestPage = `package users
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`
+
+// writeTestFile writes content to path under dir, creating directories
+func writeTestFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+	require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+}
+
+// TestDoctor_ModulePath tests detecting imports under a stale module path
+func TestDoctor_ModulePath(t *testing.T) {
+	projectDir := setupTestProject(t)
+	writeTestFile(t, projectDir, "models/user.go", "package models\n")
+	writeTestFile(t, projectDir, "main.go", `package main
+
+import (
+	"fmt"
+
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/old/name/models"
+	"github.com/test/project/app"
+)
+`)
+
+	result := checkModulePath(projectDir, nil)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Equal(t, "main.go imports github.com/old/name/models, expected github.com/test/project/models", result.Message)
+
+	writeTestFile(t, projectDir, "main.go", "package main\n\nimport _ \"github.com/test/project/models\"\n")
+	result = checkModulePath(projectDir, nil)
+	assert.Equal(t, doctorOK, result.Status)
+	assert.Equal(t, "github.com/test/project", result.Message)
+
+	result = checkModulePath(t.TempDir(), nil)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Contains(t, result.Message, "go.mod not found")
+}
+
+// TestDoctor_RoutesFresh tests detecting a missing or stale routes.gen.go
+func TestDoctor_RoutesFresh(t *testing.T) {
+	projectDir := setupTestProject(t)
+	createTestRoute(t, projectDir, "pages/users/page.go", doctorTestPage)
+
+	result := checkRoutesFresh(projectDir, nil)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Equal(t, "app/routes.gen.go is missing", result.Message)
+	assert.Equal(t, "Run 'twine routes generate'", result.Fix)
+
+	require.NoError(t, generateRoutes(projectDir, filepath.Join(projectDir, "app")))
+	result = checkRoutesFresh(projectDir, nil)
+	assert.Equal(t, doctorOK, result.Status)
+
+	createTestRoute(t, projectDir, "pages/posts/page.go", doctorTestPage)
+	result = checkRoutesFresh(projectDir, nil)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Equal(t, "app/routes.gen.go is out of date", result.Message)
+
+	result = checkRoutesFresh(t.TempDir(), nil)
+	assert.Equal(t, doctorSkip, result.Status)
+}
+
+// TestDoctor_Templates tests template parsing and reference checks
+func TestDoctor_Templates(t *testing.T) {
+	projectDir := setupTestProject(t)
+	writeTestFile(t, projectDir, "templates/layouts/base.html", `{{define "base"}}<body>{{template "content" .}}</body>{{end}}`)
+	writeTestFile(t, projectDir, "templates/pages/index.html", `{{define "index"}}{{template "base" .}}{{end}}{{define "content"}}{{formatDate .Now}}{{end}}`)
+
+	result := checkTemplates(projectDir, nil)
+	assert.Equal(t, doctorOK, result.Status)
+	assert.Equal(t, "2 template file(s) parsed", result.Message)
+
+	writeTestFile(t, projectDir, "templates/pages/users.html", `{{define "users"}}{{if .Users}}{{template "user-row" .}}{{end}}{{end}}`)
+	writeTestFile(t, projectDir, "templates/pages/team.html", `{{define "team"}}{{range .}}{{component "user-card" (props "Name" .Name)}}{{end}}{{end}}`)
+	writeTestFile(t, projectDir, "templates/pages/broken.html", `{{define "broken"}}{{.Name}`)
+	result = checkTemplates(projectDir, nil)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Contains(t, result.Message, "templates/pages/broken.html")
+	assert.Contains(t, result.Message, `templates/pages/users.html references undefined template "user-row"`)
+	assert.Contains(t, result.Message, `templates/pages/team.html references undefined template "user-card"`)
+
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "templates", "pages", "broken.html")))
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "templates", "pages", "users.html")))
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "templates", "pages", "team.html")))
+	writeTestFile(t, projectDir, "templates/components/forms/input.html", `{{define "input"}}<input>{{end}}`)
+	result = checkTemplates(projectDir, nil)
+	assert.Equal(t, doctorWarn, result.Status)
+	assert.Contains(t, result.Message, "templates/components/forms/input.html")
+}
+
+// TestDoctor_EnvVars tests reporting missing, invalid and unknown variables
+func TestDoctor_EnvVars(t *testing.T) {
+	env := map[string]string{
+		"DB_HOST":     "localhost",
+		"DB_PORT":     "5432",
+		"DB_USERNAME": "postgres",
+		"DB_PASSWORD": "postgres",
+		"DB_NAME":     "app",
+		"AUTH_SECRET": "secret",
+	}
+	result := checkEnvVars("", env)
+	assert.Equal(t, doctorOK, result.Status)
+
+	delete(env, "AUTH_SECRET")
+	env["JWT_SECRET"] = "secret"
+	env["DB_USER"] = "postgres"
+	result = checkEnvVars("", env)
+	assert.Equal(t, doctorWarn, result.Status)
+	assert.Equal(t, "not set: AUTH_SECRET\nnot read by config: DB_USER", result.Message)
+
+	// Secrets can name a file instead
+	env["AUTH_SECRET_FILE"] = "/run/secrets/auth_secret"
+	delete(env, "DB_USER")
+	result = checkEnvVars("", env)
+	assert.Equal(t, doctorOK, result.Status)
+
+	env["DB_PORT"] = "postgres"
+	result = checkEnvVars("", env)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Contains(t, result.Message, `DB_PORT "postgres" is not a number`)
+}
+
+// TestDoctor_ErrorCodes tests reporting colliding and reserved error codes
+func TestDoctor_ErrorCodes(t *testing.T) {
+	projectDir := setupTestProject(t)
+	result := checkErrorCodes(projectDir, nil)
+	assert.Equal(t, doctorSkip, result.Status)
+
+	writeTestFile(t, projectDir, "app/errors.go", `package app
+
+import "github.com/cstone-io/twine/pkg/errors"
+
+var (
+	ErrQuota = errors.Register(4001, errors.ErrDefaultMinor)
+	payments = errors.Reserve("payments", 5000, 5099)
+	ErrCard  = payments.Register(0x1389, errors.ErrDefaultMinor)
+)
+`)
+	result = checkErrorCodes(projectDir, nil)
+	assert.Equal(t, doctorOK, result.Status)
+	assert.Equal(t, "2 error code(s) registered", result.Message)
+
+	writeTestFile(t, projectDir, "billing/errors.go", `package billing
+
+import "github.com/cstone-io/twine/pkg/errors"
+
+var (
+	ErrInvoice = errors.Register(4001, errors.ErrDefaultMinor)
+	ErrTax     = errors.Register(2101, errors.ErrDefaultMinor)
+)
+`)
+	// Other packages' Register calls are ignored
+	writeTestFile(t, projectDir, "metrics/metrics.go", "package metrics\n\nvar _ = registry.Register(4001, nil)\n")
+
+	result = checkErrorCodes(projectDir, nil)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Equal(t, "billing/errors.go:7 registers 2101, reserved for twine\n4001 is registered at app/errors.go:6, billing/errors.go:6", result.Message)
+}
+
+// TestDoctor_Database tests that the database check only runs for projects
+// that use a database
+func TestDoctor_Database(t *testing.T) {
+	projectDir := t.TempDir()
+
+	result := checkDatabase(projectDir, map[string]string{})
+	assert.Equal(t, doctorSkip, result.Status)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "db", "migrations"), 0755))
+	result = checkDatabase(projectDir, map[string]string{})
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Equal(t, "DB_HOST not set", result.Message)
+
+	t.Run("sqlite", func(t *testing.T) {
+		result := checkDatabase(projectDir, map[string]string{"DB_DRIVER": "sqlite", "DB_NAME": filepath.Join(projectDir, "db", "app.db")})
+		assert.Equal(t, doctorOK, result.Status, result.Message)
+
+		result = checkDatabase(projectDir, map[string]string{"DB_DRIVER": "sqlite", "DB_NAME": filepath.Join(projectDir, "data", "app.db")})
+		assert.Equal(t, doctorFail, result.Status)
+		assert.Contains(t, result.Message, "not found")
+	})
+
+	result = checkDatabase(projectDir, map[string]string{"DB_DRIVER": "oracle"})
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Equal(t, `unknown DB_DRIVER "oracle"`, result.Message)
+}
+
+// TestDoctorCommand tests the command output and exit status
+func TestDoctorCommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+	createTestRoute(t, projectDir, "pages/users/page.go", doctorTestPage)
+	t.Setenv("DB_HOST", "") // Skip the database check
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	var out bytes.Buffer
+	cmd := NewDoctorCommand()
+	cmd.SetArgs([]string{})
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 check(s) failed")
+	assert.Contains(t, out.String(), "❌ Routes")
+	assert.Contains(t, out.String(), "→ Run 'twine routes generate'")
+	assert.Contains(t, out.String(), "➖ Node tooling")
+
+	require.NoError(t, generateRoutes(projectDir, filepath.Join(projectDir, "app")))
+	out.Reset()
+	cmd = NewDoctorCommand()
+	cmd.SetArgs([]string{})
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "✅ No problems found")
+}
@@ -0,0 +1,648 @@
+package commands
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cstone-io/twine/internal/routing"
+	"github.com/spf13/cobra"
+)
+
+// fieldType maps a generator field type to its Go type, column tag and form input
+type fieldType struct {
+	GoType string
+	Gorm   string // gorm tag, empty for the default column type
+	Input  string // HTML input type, or "textarea"
+}
+
+// fieldTypes are the types accepted in name:type field arguments
+var fieldTypes = map[string]fieldType{
+	"string": {GoType: "string", Input: "text"},
+	"text":   {GoType: "string", Gorm: "type:text", Input: "textarea"},
+	"int":    {GoType: "int", Input: "number"},
+	"float":  {GoType: "float64", Input: "number"},
+	"bool":   {GoType: "bool", Input: "checkbox"},
+	"time":   {GoType: "time.Time", Input: "datetime-local"},
+}
+
+// baseModelColumns are provided by database.BaseModel and cannot be redeclared
+var baseModelColumns = []string{"id", "created_at", "updated_at", "deleted_at"}
+
+// resourceField is a parsed name:type argument
+type resourceField struct {
+	Name   string // Go field name, e.g. "PublishedAt"
+	Column string // Column, JSON and form name, e.g. "published_at"
+	Label  string // Form label, e.g. "Published At"
+	Type   string // Generator type, e.g. "time"
+	fieldType
+}
+
+// Tags returns the struct tags for the field
+func (f resourceField) Tags() string {
+	tags := make([]string, 0, 3)
+	if f.Gorm != "" {
+		tags = append(tags, fmt.Sprintf("gorm:%q", f.Gorm))
+	}
+	tags = append(tags, fmt.Sprintf("json:%q", f.Column), fmt.Sprintf("form:%q", f.Column))
+	return "`" + strings.Join(tags, " ") + "`"
+}
+
+// resourceConfig is the data passed to the internal/scaffold/generate templates
+// for models and resources
+type resourceConfig struct {
+	ModulePath string
+	Package    string // Go package name of the file being written
+	Name       string // Model name, e.g. "BlogPost"
+	Plural     string // e.g. "BlogPosts"
+	Var        string // Variable name, e.g. "blogPost"
+	PluralVar  string // e.g. "blogPosts"
+	Path       string // Table, directory and URL segment, e.g. "blog_posts"
+	Title      string // Human readable plural, e.g. "Blog Posts"
+	Singular   string // Human readable singular, e.g. "Blog Post"
+	Fields     []resourceField
+	HasTime    bool // A field needs the time import
+}
+
+// Columns returns the number of list table columns, one per field plus actions
+func (c *resourceConfig) Columns() int {
+	return len(c.Fields) + 1
+}
+
+// migrationConfig is the data passed to generate/migration.go.tmpl
+type migrationConfig struct {
+	ModulePath string
+	Var        string   // Exported variable, e.g. "CreatePosts"
+	Name       string   // Versioned name, also the file name, e.g. "20240301093000_create_posts"
+	Comment    string   // Doc comment after the variable name
+	Model      string   // Model to auto-migrate, empty for an Up-only migration
+	Create     bool     // Migration creates the model's table, so Down drops it
+	Deps       []string // Variables of migrations that must run first
+	DepNames   []string // Names of migrations a SQL migration must run after
+	dest       string
+}
+
+// DepsList returns the dependencies as a Go argument list
+func (c *migrationConfig) DepsList() string {
+	return strings.Join(c.Deps, ", ")
+}
+
+// DepNamesList returns a SQL migration's dependencies for its deps directive
+func (c *migrationConfig) DepNamesList() string {
+	return strings.Join(c.DepNames, ", ")
+}
+
+// migrationsDir holds one file per migration, named by its versioned name so
+// the files sort in the order they were generated
+var migrationsDir = filepath.Join("db", "migrations")
+
+// migrationTime returns the timestamp for new migrations, replaced in tests
+var migrationTime = time.Now
+
+// NewGenerateCommand creates the generate command
+func NewGenerateCommand() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:     "generate",
+		Aliases: []string{"g"},
+		Short:   "Generate models, migrations, stores, CRUD resources and Docker files",
+		Long:    "Generate database-backed code following the project conventions",
+	}
+
+	cmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "Overwrite existing files")
+
+	cmd.AddCommand(newGenerateResourceCommand(&force))
+	cmd.AddCommand(newGenerateModelCommand(&force))
+	cmd.AddCommand(newGenerateMigrationCommand(&force))
+	cmd.AddCommand(newGenerateDockerCommand(&force))
+
+	return cmd
+}
+
+func newGenerateResourceCommand(force *bool) *cobra.Command {
+	var (
+		generate bool
+		deps     []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "resource <Name> [field:type...]",
+		Short: "Generate a model, migration, store, pages, API routes and templates",
+		Long: `Generate a full CRUD slice for a model:
+
+  models/<name>.go                    GORM model embedding database.BaseModel
+  db/migrations/<version>.go          Migration creating the table
+  stores/<name>.go                    Store backed by database.CRUDStore
+  app/pages/<plural>/...              List, show, new and edit pages
+  app/api/<plural>/...                JSON API routes
+  templates/pages/<plural>*.html      Page templates using Alpine Ajax
+
+Field types: ` + strings.Join(fieldTypeNames(), ", "),
+		Example: "  twine generate resource Post title:string body:text published:bool",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, modulePath, err := prepareGenerate(true)
+			if err != nil {
+				return err
+			}
+
+			config, err := newResourceConfig(modulePath, args[0], args[1:])
+			if err != nil {
+				return err
+			}
+
+			if err := writeModel(cwd, config, deps, *force); err != nil {
+				return err
+			}
+			if err := writeResource(cwd, config, *force); err != nil {
+				return err
+			}
+
+			printGenerateHints(cwd, modulePath)
+			return finishNew(cwd, generate)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&generate, "generate", "g", false, "Regenerate routes.gen.go afterwards")
+	cmd.Flags().StringSliceVar(&deps, "deps", nil, "Migrations that must run first, e.g. create_users")
+
+	return cmd
+}
+
+func newGenerateModelCommand(force *bool) *cobra.Command {
+	var deps []string
+
+	cmd := &cobra.Command{
+		Use:   "model <Name> [field:type...]",
+		Short: "Generate a model and the migration creating its table",
+		Long: `Generate models/<name>.go embedding database.BaseModel and a timestamped
+db/migrations/<version>.go registering it with NewMigrationBuilder.
+
+Field types: ` + strings.Join(fieldTypeNames(), ", "),
+		Example: "  twine generate model Comment body:text\n  twine generate model Comment body:text --deps create_posts,create_users",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, modulePath, err := prepareGenerate(false)
+			if err != nil {
+				return err
+			}
+
+			config, err := newResourceConfig(modulePath, args[0], args[1:])
+			if err != nil {
+				return err
+			}
+
+			if err := writeModel(cwd, config, deps, *force); err != nil {
+				return err
+			}
+
+			printGenerateHints(cwd, modulePath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&deps, "deps", nil, "Migrations that must run first, e.g. create_users")
+
+	return cmd
+}
+
+func newGenerateMigrationCommand(force *bool) *cobra.Command {
+	var (
+		model string
+		deps  []string
+		sql   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migration <name>",
+		Short: "Generate a timestamped migration",
+		Long: `Generate db/migrations/<version>.go with a migration registered via
+NewMigrationBuilder. With --model the model is auto-migrated, otherwise the
+migration has an Up function to fill in.
+
+With --sql, generate <version>.up.sql and <version>.down.sql instead, for
+changes AutoMigrate can't express such as extensions or concurrent indexes.
+twine db runs them in version order with the Go migrations.`,
+		Example: "  twine generate migration backfill_post_slugs --deps create_posts\n  twine generate migration add_slug_to_posts --model Post\n  twine generate migration index_posts_slug --sql",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, modulePath, err := prepareGenerate(false)
+			if err != nil {
+				return err
+			}
+
+			if sql && model != "" {
+				return fmt.Errorf("--sql and --model can't be used together")
+			}
+
+			config, err := newMigrationConfig(cwd, modulePath, args[0], deps, sql, *force)
+			if err != nil {
+				return err
+			}
+			if sql {
+				if err := writeTextScaffold("generate/migration.up.sql.tmpl", config.dest+".up.sql", config, *force); err != nil {
+					return err
+				}
+				if err := writeTextScaffold("generate/migration.down.sql.tmpl", config.dest+".down.sql", config, *force); err != nil {
+					return err
+				}
+				return nil
+			}
+			if model != "" {
+				config.Model = exportedIdent(model)
+				config.Comment = "migrates models." + config.Model
+			}
+
+			if err := writeScaffold("generate/migration.go.tmpl", config.dest, config, *force); err != nil {
+				return err
+			}
+
+			printGenerateHints(cwd, modulePath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&model, "model", "m", "", "Model to auto-migrate, e.g. Post")
+	cmd.Flags().StringSliceVar(&deps, "deps", nil, "Migrations that must run first, e.g. create_users")
+	cmd.Flags().BoolVar(&sql, "sql", false, "Generate .up.sql and .down.sql files instead of Go")
+
+	return cmd
+}
+
+// prepareGenerate returns the project root and module path, checking for
+// app/ when the generated code includes routes
+func prepareGenerate(needsApp bool) (string, string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("getting current directory: %w", err)
+	}
+
+	if needsApp {
+		if _, err := os.Stat(filepath.Join(cwd, "app")); os.IsNotExist(err) {
+			return "", "", fmt.Errorf("app/ directory not found. Create it first or run 'twine init'")
+		}
+	}
+
+	modulePath, err := routing.GetModulePath(cwd)
+	if err != nil {
+		return "", "", err
+	}
+
+	return cwd, modulePath, nil
+}
+
+// writeModel writes the model and the migration creating its table
+func writeModel(cwd string, config *resourceConfig, deps []string, force bool) error {
+	migration, err := newMigrationConfig(cwd, config.ModulePath, "create_"+config.Path, deps, false, force)
+	if err != nil {
+		return err
+	}
+	migration.Model = config.Name
+	migration.Create = true
+	migration.Comment = "creates the " + config.Path + " table for models." + config.Name
+
+	config.Package = "models"
+	if err := writeScaffold("generate/model.go.tmpl", filepath.Join(cwd, "models", snakeCase(config.Name)+".go"), config, force); err != nil {
+		return err
+	}
+
+	return writeScaffold("generate/migration.go.tmpl", migration.dest, migration, force)
+}
+
+// newMigrationConfig names a new Go or SQL migration and checks its
+// dependencies exist. With force, an existing migration of the same name
+// keeps its version so regenerating it does not reorder the history.
+func newMigrationConfig(cwd, modulePath, name string, deps []string, sql, force bool) (*migrationConfig, error) {
+	base := snakeCase(name)
+	if base == "" || !unicode.IsLetter(rune(base[0])) {
+		return nil, fmt.Errorf("invalid migration name %q", name)
+	}
+
+	dir := filepath.Join(cwd, migrationsDir)
+	existing, err := existingMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &migrationConfig{
+		ModulePath: modulePath,
+		Var:        exportedIdent(base),
+		Comment:    "is a schema change applied by its Up function",
+	}
+
+	if file, ok := existing[config.Var]; ok {
+		if !force || isSQLMigration(file) != sql {
+			return nil, fmt.Errorf("migration %s already exists in %s (use --force to overwrite)", config.Var, file)
+		}
+		config.Name = migrationName(file)
+	} else {
+		config.Name = nextMigrationVersion(existing) + "_" + base
+	}
+	config.dest = filepath.Join(dir, config.Name)
+	if !sql {
+		config.dest += ".go"
+	}
+
+	for _, dep := range deps {
+		v := exportedIdent(dep)
+		file, ok := existing[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown migration dependency %q: no %s in %s", dep, v, migrationsDir)
+		}
+		if v == config.Var {
+			continue
+		}
+		if sql {
+			if !containsString(config.DepNames, migrationName(file)) {
+				config.DepNames = append(config.DepNames, migrationName(file))
+			}
+			continue
+		}
+		if isSQLMigration(file) {
+			return nil, fmt.Errorf("migration dependency %q is a SQL migration; migrations run in version order, so drop it from --deps", dep)
+		}
+		if !containsString(config.Deps, v) {
+			config.Deps = append(config.Deps, v)
+		}
+	}
+
+	return config, nil
+}
+
+// nextMigrationVersion returns the current UTC timestamp, moved past the
+// newest existing migration so files always sort in the order generated
+func nextMigrationVersion(existing map[string]string) string {
+	const layout = "20060102150405"
+
+	version := migrationTime().UTC().Truncate(time.Second)
+	for _, file := range existing {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "_")
+		if latest, err := time.Parse(layout, prefix); err == nil && !version.After(latest) {
+			version = latest.Add(time.Second)
+		}
+	}
+
+	return version.Format(layout)
+}
+
+// isSQLMigration reports whether file is a SQL migration's up file
+func isSQLMigration(file string) bool {
+	return strings.HasSuffix(file, ".up.sql")
+}
+
+// migrationName returns the versioned name of the migration in file
+func migrationName(file string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".go"), ".up.sql")
+}
+
+// existingMigrations maps the top-level variables declared in the migrations
+// directory to the files declaring them, and SQL migrations' names, as
+// variables, to their up files
+func existingMigrations(dir string) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	sqlFiles, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range sqlFiles {
+		if _, base, ok := strings.Cut(migrationName(file), "_"); ok {
+			vars[exportedIdent(base)] = file
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					vars[name.Name] = file
+				}
+			}
+		}
+	}
+
+	return vars, nil
+}
+
+// printGenerateHints reminds the user of the manual steps after generating
+// database code
+func printGenerateHints(cwd, modulePath string) {
+	importPath := modulePath + "/" + filepath.ToSlash(migrationsDir)
+	if main, err := os.ReadFile(filepath.Join(cwd, "main.go")); err != nil || !strings.Contains(string(main), `"`+importPath+`"`) {
+		fmt.Printf("\nImport the migrations in main.go so they are registered:\n\n\t_ %q\n", importPath)
+	}
+	fmt.Println("\nRun 'go mod tidy' to add any new dependencies")
+}
+
+// newResourceConfig parses the model name and field arguments
+func newResourceConfig(modulePath, name string, fields []string) (*resourceConfig, error) {
+	model := exportedIdent(name)
+	if model == "" || !unicode.IsLetter(rune(model[0])) {
+		return nil, fmt.Errorf("invalid model name %q", name)
+	}
+
+	plural := pluralize(model)
+	config := &resourceConfig{
+		ModulePath: modulePath,
+		Name:       model,
+		Plural:     plural,
+		Var:        lowerFirst(model),
+		PluralVar:  lowerFirst(plural),
+		Path:       snakeCase(plural),
+		Title:      humanize(plural),
+		Singular:   humanize(model),
+	}
+
+	seen := make(map[string]bool)
+	for _, arg := range fields {
+		field, err := parseResourceField(arg)
+		if err != nil {
+			return nil, err
+		}
+		if seen[field.Column] {
+			return nil, fmt.Errorf("duplicate field %q", field.Column)
+		}
+		seen[field.Column] = true
+
+		if field.Type == "time" {
+			config.HasTime = true
+		}
+		config.Fields = append(config.Fields, field)
+	}
+
+	return config, nil
+}
+
+// parseResourceField parses a name:type argument; the type defaults to string
+func parseResourceField(arg string) (resourceField, error) {
+	name, typ, found := strings.Cut(arg, ":")
+	if !found {
+		typ = "string"
+	}
+
+	ft, ok := fieldTypes[strings.ToLower(typ)]
+	if !ok {
+		return resourceField{}, fmt.Errorf("unknown type %q for field %q (expected one of %s)", typ, name, strings.Join(fieldTypeNames(), ", "))
+	}
+
+	column := snakeCase(name)
+	if column == "" || !unicode.IsLetter(rune(column[0])) {
+		return resourceField{}, fmt.Errorf("invalid field name %q", name)
+	}
+	if containsString(baseModelColumns, column) {
+		return resourceField{}, fmt.Errorf("field %q is provided by database.BaseModel", name)
+	}
+
+	return resourceField{
+		Name:      exportedIdent(column),
+		Column:    column,
+		Label:     humanize(column),
+		Type:      strings.ToLower(typ),
+		fieldType: ft,
+	}, nil
+}
+
+// writeResource writes the store, pages, API routes and templates of a resource
+func writeResource(cwd string, config *resourceConfig, force bool) error {
+	file := snakeCase(config.Name) + ".go"
+	pages := filepath.Join(cwd, "app", "pages", config.Path)
+	api := filepath.Join(cwd, "app", "api", config.Path)
+	templates := filepath.Join(cwd, "templates", "pages")
+
+	goFiles := []struct {
+		src, dest, pkg string
+	}{
+		{"generate/store.go.tmpl", filepath.Join(cwd, "stores", file), "stores"},
+		{"generate/list.go.tmpl", filepath.Join(pages, "page.go"), newPackageName("pages", []string{config.Path})},
+		{"generate/new.go.tmpl", filepath.Join(pages, "new", "page.go"), "new"},
+		{"generate/show.go.tmpl", filepath.Join(pages, "[id]", "page.go"), newPackageName("pages", []string{"[id]"})},
+		{"generate/edit.go.tmpl", filepath.Join(pages, "[id]", "edit", "page.go"), "edit"},
+		{"generate/api.go.tmpl", filepath.Join(api, "route.go"), newPackageName("api", []string{config.Path})},
+		{"generate/api_item.go.tmpl", filepath.Join(api, "[id]", "route.go"), newPackageName("api", []string{"[id]"})},
+	}
+
+	for _, f := range goFiles {
+		config.Package = f.pkg
+		if err := writeScaffold(f.src, f.dest, config, force); err != nil {
+			return err
+		}
+	}
+
+	htmlFiles := []struct {
+		src, dest string
+	}{
+		{"generate/list.html.tmpl", filepath.Join(templates, config.Path+".html")},
+		{"generate/show.html.tmpl", filepath.Join(templates, config.Path+"_id.html")},
+		{"generate/new.html.tmpl", filepath.Join(templates, config.Path+"_new.html")},
+		{"generate/edit.html.tmpl", filepath.Join(templates, config.Path+"_id_edit.html")},
+		{"generate/form.html.tmpl", filepath.Join(cwd, "templates", "components", config.Path+"_form.html")},
+	}
+
+	for _, f := range htmlFiles {
+		if err := writeHTMLScaffold(f.src, f.dest, config, force); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fieldTypeNames() []string {
+	return []string{"string", "text", "int", "float", "bool", "time"}
+}
+
+// exportedIdent converts "blog_post", "blog-post" or "blogPost" to "BlogPost"
+func exportedIdent(s string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// snakeCase converts "BlogPost", "blogPost" or "blog-post" to "blog_post"
+func snakeCase(s string) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		case sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_"):
+			sb.WriteRune('_')
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
+
+// humanize converts "BlogPost" or "published_at" to "Blog Post" or "Published At"
+func humanize(s string) string {
+	words := strings.Split(snakeCase(s), "_")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// pluralize applies simple English plural rules, matching the table names
+// GORM derives for the model
+func pluralize(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	default:
+		return s + "s"
+	}
+}
@@ -0,0 +1,312 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewGenerateCommand tests generate command creation
+func TestNewGenerateCommand(t *testing.T) {
+	cmd := NewGenerateCommand()
+
+	assert.Equal(t, "generate", cmd.Use)
+	assert.NotNil(t, cmd.PersistentFlags().Lookup("force"))
+
+	uses := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		uses = append(uses, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"resource", "model", "migration", "docker"}, uses)
+}
+
+// TestParseResourceField tests name:type field parsing
+func TestParseResourceField(t *testing.T) {
+	tests := []struct {
+		arg      string
+		name     string
+		goType   string
+		tags     string
+		errorMsg string
+	}{
+		{arg: "title:string", name: "Title", goType: "string", tags: "`json:\"title\" form:\"title\"`"},
+		{arg: "title", name: "Title", goType: "string", tags: "`json:\"title\" form:\"title\"`"},
+		{arg: "body:text", name: "Body", goType: "string", tags: "`gorm:\"type:text\" json:\"body\" form:\"body\"`"},
+		{arg: "publishedAt:time", name: "PublishedAt", goType: "time.Time", tags: "`json:\"published_at\" form:\"published_at\"`"},
+		{arg: "view-count:INT", name: "ViewCount", goType: "int", tags: "`json:\"view_count\" form:\"view_count\"`"},
+		{arg: "price:money", errorMsg: `unknown type "money"`},
+		{arg: "1st:string", errorMsg: "invalid field name"},
+		{arg: "id:string", errorMsg: "provided by database.BaseModel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			field, err := parseResourceField(tt.arg)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, field.Name)
+			assert.Equal(t, tt.goType, field.GoType)
+			assert.Equal(t, tt.tags, field.Tags())
+		})
+	}
+}
+
+// TestResourceNames tests model, plural and path naming
+func TestResourceNames(t *testing.T) {
+	config, err := newResourceConfig("github.com/test/project", "blog-post", []string{"title", "posted_at:time"})
+	require.NoError(t, err)
+	assert.Equal(t, "BlogPost", config.Name)
+	assert.Equal(t, "BlogPosts", config.Plural)
+	assert.Equal(t, "blogPost", config.Var)
+	assert.Equal(t, "blog_posts", config.Path)
+	assert.Equal(t, "Blog Posts", config.Title)
+	assert.True(t, config.HasTime)
+
+	assert.Equal(t, "Categories", pluralize("Category"))
+	assert.Equal(t, "Days", pluralize("Day"))
+	assert.Equal(t, "Boxes", pluralize("Box"))
+	assert.Equal(t, "Addresses", pluralize("Address"))
+	assert.Equal(t, "http_request", snakeCase("HTTPRequest"))
+
+	_, err = newResourceConfig("github.com/test/project", "Post", []string{"title", "Title:text"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate field")
+}
+
+// TestGenerateResourceCommand tests resource scaffolding end to end
+func TestGenerateResourceCommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := NewGenerateCommand()
+	cmd.SetArgs([]string{"resource", "Post", "title:string", "body:text", "published:bool", "--generate"})
+	require.NoError(t, cmd.Execute())
+
+	model, err := os.ReadFile(filepath.Join(projectDir, "models", "post.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(model), "database.BaseModel `gorm:\"embedded\"`")
+	assert.Contains(t, string(model), "Body               string `gorm:\"type:text\" json:\"body\" form:\"body\"`")
+	assert.NotContains(t, string(model), `"time"`)
+
+	migrations, err := filepath.Glob(filepath.Join(projectDir, "db", "migrations", "*_create_posts.go"))
+	require.NoError(t, err)
+	assert.Len(t, migrations, 1)
+
+	store, err := os.ReadFile(filepath.Join(projectDir, "stores", "post.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(store), `"github.com/test/project/models"`)
+	assert.Contains(t, string(store), "*database.CRUDStore[models.Post]")
+	assert.Contains(t, string(store), `Columns("created_at", "updated_at", "title", "body", "published")`)
+
+	page, err := os.ReadFile(filepath.Join(projectDir, "app", "pages", "posts", "page.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "stores.NewPostStore().List(k.Request.Context(), opts)")
+
+	show, err := os.ReadFile(filepath.Join(projectDir, "app", "pages", "posts", "[id]", "page.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(show), "package id_param")
+	assert.Contains(t, string(show), `return k.Render("posts_id", map[string]any{`)
+	assert.Contains(t, string(show), `stores.NewPostStore().Get(k.Request.Context(), k.PathValue("id"))`)
+
+	for _, file := range []string{
+		"app/pages/posts/page.go",
+		"app/pages/posts/new/page.go",
+		"app/pages/posts/[id]/edit/page.go",
+		"app/api/posts/route.go",
+		"app/api/posts/[id]/route.go",
+		"templates/pages/posts.html",
+		"templates/pages/posts_new.html",
+		"templates/pages/posts_id_edit.html",
+	} {
+		assert.FileExists(t, filepath.Join(projectDir, file))
+	}
+
+	list, err := os.ReadFile(filepath.Join(projectDir, "templates", "pages", "posts.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(list), `{{define "posts"}}`)
+	assert.Contains(t, string(list), `{{range .Posts}}`)
+	assert.Contains(t, string(list), `<form method="delete" action="/posts/{{.ID}}" x-target="posts" class="inline">`)
+	assert.Contains(t, string(list), `colspan="4"`)
+
+	form, err := os.ReadFile(filepath.Join(projectDir, "templates", "components", "posts_form.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(form), `<textarea id="body" name="body" rows="6" class="w-full rounded-lg border-gray-300">{{.Body}}</textarea>`)
+	assert.Contains(t, string(form), `value="true" {{if .Published}}checked{{end}}`)
+
+	// --generate registers the pages and API routes
+	generated, err := os.ReadFile(filepath.Join(projectDir, "app", "routes.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(generated), `r.Delete("/posts/{id}"`)
+	assert.Contains(t, string(generated), `r.Put("/api/posts/{id}"`)
+
+	// Existing files are not overwritten without --force
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"resource", "Post", "title"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+// setMigrationTime fixes the timestamp used for new migrations
+func setMigrationTime(t *testing.T, ts time.Time) {
+	t.Helper()
+	original := migrationTime
+	migrationTime = func() time.Time { return ts }
+	t.Cleanup(func() { migrationTime = original })
+}
+
+// TestGenerateModelCommand tests model and migration scaffolding
+func TestGenerateModelCommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+	setMigrationTime(t, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC))
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := NewGenerateCommand()
+	cmd.SetArgs([]string{"model", "User", "email", "born_on:time"})
+	require.NoError(t, cmd.Execute())
+
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"model", "Comment", "body:text", "--deps", "create_users"})
+	require.NoError(t, cmd.Execute())
+
+	model, err := os.ReadFile(filepath.Join(projectDir, "models", "user.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(model), `"time"`)
+	assert.Contains(t, string(model), "BornOn             time.Time `json:\"born_on\" form:\"born_on\"`")
+	assert.NotContains(t, string(model), "RegisterMigration")
+
+	// The second migration is moved past the first even within the same second
+	users, err := os.ReadFile(filepath.Join(projectDir, "db", "migrations", "20240301093000_create_users.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(users), "var CreateUsers = database.NewMigrationBuilder().")
+	assert.Contains(t, string(users), "Model(&models.User{}).")
+	assert.Contains(t, string(users), `Name("20240301093000_create_users").`)
+	assert.Contains(t, string(users), "return tx.Migrator().DropTable(&models.User{})")
+	assert.Contains(t, string(users), "database.RegisterMigration(CreateUsers)")
+
+	comments, err := os.ReadFile(filepath.Join(projectDir, "db", "migrations", "20240301093001_create_comments.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(comments), "Deps(CreateUsers).")
+
+	// Unknown dependencies are rejected before anything is written
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"model", "Tag", "--deps", "create_posts"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no CreatePosts in db/migrations")
+	assert.NoFileExists(t, filepath.Join(projectDir, "models", "tag.go"))
+}
+
+// TestGenerateMigrationCommand tests standalone migrations
+func TestGenerateMigrationCommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+	setMigrationTime(t, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC))
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := NewGenerateCommand()
+	cmd.SetArgs([]string{"model", "Post", "title"})
+	require.NoError(t, cmd.Execute())
+
+	setMigrationTime(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"migration", "backfill_slugs", "--deps", "CreatePosts"})
+	require.NoError(t, cmd.Execute())
+
+	path := filepath.Join(projectDir, "db", "migrations", "20240401000000_backfill_slugs.go")
+	migration, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), `"gorm.io/gorm"`)
+	assert.NotContains(t, string(migration), "Model(")
+	assert.Contains(t, string(migration), "Deps(CreatePosts).")
+	assert.Contains(t, string(migration), "Up(func(tx *gorm.DB) error {")
+	assert.Contains(t, string(migration), "Down(func(tx *gorm.DB) error {")
+	assert.NotContains(t, string(migration), "DropTable")
+
+	// Regenerating with --force keeps the original version
+	setMigrationTime(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"migration", "backfill_slugs", "--model", "post", "--force"})
+	require.NoError(t, cmd.Execute())
+
+	migration, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), "// BackfillSlugs migrates models.Post")
+	assert.Contains(t, string(migration), "Model(&models.Post{}).")
+	assert.NotContains(t, string(migration), "Up(")
+
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"migration", "backfill_slugs"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+// TestGenerateMigrationCommand_SQL tests SQL migration files
+func TestGenerateMigrationCommand_SQL(t *testing.T) {
+	projectDir := setupTestProject(t)
+	setMigrationTime(t, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC))
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := NewGenerateCommand()
+	cmd.SetArgs([]string{"model", "Post", "title"})
+	require.NoError(t, cmd.Execute())
+
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"migration", "index_posts_title", "--sql", "--deps", "create_posts"})
+	require.NoError(t, cmd.Execute())
+
+	dir := filepath.Join(projectDir, "db", "migrations")
+	up, err := os.ReadFile(filepath.Join(dir, "20240301093001_index_posts_title.up.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(up), "-- twine:deps 20240301093000_create_posts\n")
+	assert.FileExists(t, filepath.Join(dir, "20240301093001_index_posts_title.down.sql"))
+
+	// SQL migrations count towards the next version and name clashes
+	cmd = NewGenerateCommand()
+	cmd.SetArgs([]string{"migration", "backfill_titles"})
+	require.NoError(t, cmd.Execute())
+	assert.FileExists(t, filepath.Join(dir, "20240301093002_backfill_titles.go"))
+
+	tests := []struct {
+		name     string
+		args     []string
+		errorMsg string
+	}{
+		{"existing SQL migration", []string{"migration", "index_posts_title", "--sql"}, "already exists"},
+		{"Go over SQL", []string{"migration", "index_posts_title", "--force"}, "already exists"},
+		{"Go depending on SQL", []string{"migration", "fix_titles", "--deps", "index_posts_title"}, "is a SQL migration"},
+		{"with model", []string{"migration", "add_slug", "--sql", "--model", "Post"}, "can't be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewGenerateCommand()
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
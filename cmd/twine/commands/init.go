@@ -20,6 +20,9 @@ type ProjectConfig struct {
 	WithDB      bool
 	WithAuth    bool
 	NoExamples  bool
+	WithDocker  bool
+	Template    string            // Starter template source; empty for the built-in scaffold
+	Vars        map[string]string // Starter template variables, available as .Vars
 }
 
 func NewInitCommand() *cobra.Command {
@@ -29,15 +32,41 @@ func NewInitCommand() *cobra.Command {
 		noExamples bool
 		withDB     bool
 		withAuth   bool
+		withDocker bool
+		starter    string
+		varFlags   []string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "init <project-name>",
 		Short: "Initialize a new Twine project",
-		Args:  cobra.ExactArgs(1),
+		Long: `Initialize a new Twine project from the built-in scaffold, or from a
+starter template with --template.
+
+A starter template is a local directory or a git repository, optionally at a
+ref given after @. Files ending in .tmpl are rendered with text/template and
+lose the suffix; {{ }} in file names is rendered too. Templates can use
+.ProjectName, .ModulePath, .Port and .Vars, whose values are declared in an
+optional twine-template.yaml and set with --var.`,
+		Example: `  twine init my-app
+  twine init my-app --template github.com/org/starter
+  twine init my-app --template github.com/org/starter@v2 --var Team=payments
+  twine init my-app --template ../starter`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			projectName := args[0]
 
+			if starter == "" && len(varFlags) > 0 {
+				return fmt.Errorf("--var requires --template")
+			}
+			if starter != "" && noExamples {
+				return fmt.Errorf("--no-examples only applies to the built-in scaffold")
+			}
+			vars, err := parseVarFlags(varFlags)
+			if err != nil {
+				return err
+			}
+
 			// Default module path
 			if modulePath == "" {
 				modulePath = fmt.Sprintf("example.com/%s", projectName)
@@ -50,7 +79,11 @@ func NewInitCommand() *cobra.Command {
 				WithDB:      withDB,
 				WithAuth:    withAuth,
 				NoExamples:  noExamples,
+				WithDocker:  withDocker,
+				Template:    starter,
+				Vars:        vars,
 			}
+			cmd.SilenceUsage = true
 
 			return initProject(config)
 		},
@@ -61,11 +94,18 @@ func NewInitCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&noExamples, "no-examples", false, "Skip example pages")
 	cmd.Flags().BoolVar(&withDB, "with-db", false, "Include database setup")
 	cmd.Flags().BoolVar(&withAuth, "with-auth", false, "Include auth setup")
+	cmd.Flags().BoolVar(&withDocker, "with-docker", false, "Include a Dockerfile and compose.yaml with Postgres")
+	cmd.Flags().StringVarP(&starter, "template", "t", "", "Starter template: a local directory or git repository such as github.com/org/starter[@ref]")
+	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "Starter template variable as name=value (repeatable)")
 
 	return cmd
 }
 
 func initProject(config ProjectConfig) error {
+	if config.Template != "" {
+		return initStarterProject(config)
+	}
+
 	// 1. Check Node.js availability
 	if err := checkNodeJS(); err != nil {
 		return err
@@ -94,6 +134,72 @@ func initProject(config ProjectConfig) error {
 		return err
 	}
 
+	if err := generateDocker(config, projectPath); err != nil {
+		return err
+	}
+
+	finishProject(config, projectPath, true)
+	return nil
+}
+
+// initStarterProject creates a project from the starter template in
+// config.Template. Variables are resolved and the template fetched before
+// the project directory is created, so mistakes leave nothing behind.
+func initStarterProject(config ProjectConfig) error {
+	fmt.Printf("Fetching template %s...\n", config.Template)
+	dir, cleanup, err := fetchStarter(config.Template)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	manifest, err := loadStarterManifest(dir)
+	if err != nil {
+		return err
+	}
+	if config.Vars, err = manifest.resolveVars(config.Vars); err != nil {
+		return err
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "package.json"))
+	withNode := err == nil
+	if withNode {
+		if err := checkNodeJS(); err != nil {
+			return err
+		}
+		if err := checkNodeVersion(); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(config.ProjectName, 0755); err != nil {
+		return fmt.Errorf("failed to create project directory: %w", err)
+	}
+
+	projectPath, _ := filepath.Abs(config.ProjectName)
+	if manifest.Name != "" {
+		fmt.Printf("Creating new Twine project from %s: %s\n", manifest.Name, projectPath)
+	} else {
+		fmt.Printf("Creating new Twine project: %s\n", projectPath)
+	}
+
+	if err := renderStarter(dir, projectPath, manifest, config); err != nil {
+		os.RemoveAll(projectPath)
+		return err
+	}
+
+	if err := generateDocker(config, projectPath); err != nil {
+		return err
+	}
+
+	finishProject(config, projectPath, withNode)
+	return nil
+}
+
+// finishProject downloads dependencies, initializes git and prints the next
+// steps for a newly created project
+func finishProject(config ProjectConfig, projectPath string, withNode bool) {
+
 	// 6. Run go mod tidy
 	fmt.Println("\n✓ Downloading Go dependencies...")
 	cmd := exec.Command("go", "mod", "tidy")
@@ -121,9 +227,11 @@ func initProject(config ProjectConfig) error {
 	}
 
 	// 7. Install Node.js dependencies
-	if err := installNodeDependencies(projectPath); err != nil {
-		fmt.Printf("\nWarning: Could not install npm dependencies automatically.\n")
-		fmt.Printf("You can manually run 'npm install' in the project directory.\n")
+	if withNode {
+		if err := installNodeDependencies(projectPath); err != nil {
+			fmt.Printf("\nWarning: Could not install npm dependencies automatically.\n")
+			fmt.Printf("You can manually run 'npm install' in the project directory.\n")
+		}
 	}
 
 	// 8. Initialize git repository
@@ -134,7 +242,6 @@ func initProject(config ProjectConfig) error {
 
 	// 9. Print success message
 	printSuccessMessage(config)
-	return nil
 }
 
 func printDependencyTroubleshooting(projectName string) {
@@ -161,8 +268,8 @@ func generateFiles(config ProjectConfig, projectPath string) error {
 		{"go.mod.tmpl", "go.mod"},
 		{"gitignore.tmpl", ".gitignore"},
 		{"env.example.tmpl", ".env.example"},
+		{"twine.yaml.tmpl", "twine.yaml"},
 		{"README.md.tmpl", "README.md"},
-		{".air.toml.tmpl", ".air.toml"},
 	}
 
 	for _, t := range templates {
@@ -170,6 +277,11 @@ func generateFiles(config ProjectConfig, projectPath string) error {
 			return err
 		}
 	}
+	if config.WithAuth {
+		if err := generateFromTemplate(config, "auth.go.tmpl", filepath.Join(projectPath, "auth.go")); err != nil {
+			return err
+		}
+	}
 
 	// Copy HTML templates (no templating needed)
 	if err := copyTemplates(config, projectPath); err != nil {
@@ -184,6 +296,20 @@ func generateFiles(config ProjectConfig, projectPath string) error {
 	return nil
 }
 
+// generateDocker writes the Docker files if requested, once the rest of the
+// project exists so the Dockerfile matches it
+func generateDocker(config ProjectConfig, projectPath string) error {
+	if !config.WithDocker {
+		return nil
+	}
+
+	docker, err := newDockerConfig(projectPath, filepath.Base(projectPath), config.Port)
+	if err != nil {
+		return err
+	}
+	return writeDocker(projectPath, docker, false)
+}
+
 func generateFromTemplate(config ProjectConfig, templatePath, outputPath string) error {
 	// Read template from embed.FS
 	content, err := scaffold.FS.ReadFile(templatePath)
@@ -283,8 +409,8 @@ import (
 func Layout() middleware.Middleware {
 	return func(next kit.HandlerFunc) kit.HandlerFunc {
 		return func(k *kit.Kit) error {
-			// Setup common data available to all pages
-			k.SetContext("appName", "My Twine App")
+			// Data every page's template sees, merged with the handler's
+			k.View().AppName = "My Twine App"
 			return next(k)
 		}
 	}
@@ -309,6 +435,73 @@ func GET(k *kit.Kit) error {
 		return err
 	}
 
+	if config.WithAuth {
+		return createAuthRoutes(appPath)
+	}
+	return nil
+}
+
+// authProviders are the providers of pkg/auth/oauth that twine init
+// --with-auth creates sign-in routes for
+var authProviders = []string{"google", "github", "oidc"}
+
+// createAuthRoutes writes the routes that sign users in with each of
+// authProviders, as app/api/auth/{provider} and its callback, and sign
+// them out
+func createAuthRoutes(appPath string) error {
+	authPath := filepath.Join(appPath, "api", "auth")
+	routes := map[string]string{
+		"logout": `package logout
+
+import (
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// POST ends the user's session and revokes their tokens
+func POST(k *kit.Kit) error {
+	if err := k.Logout(); err != nil {
+		return err
+	}
+	return k.Redirect("/")
+}
+`,
+	}
+	for _, provider := range authProviders {
+		routes[provider] = fmt.Sprintf(`package %[1]s
+
+import (
+	"github.com/cstone-io/twine/pkg/auth/oauth"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// GET sends the user to %[1]s to sign in
+func GET(k *kit.Kit) error {
+	return oauth.Begin(k, %[1]q)
+}
+`, provider)
+		routes[filepath.Join(provider, "callback")] = fmt.Sprintf(`package callback
+
+import (
+	"github.com/cstone-io/twine/pkg/auth/oauth"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// GET signs the user in when %[1]s sends them back
+func GET(k *kit.Kit) error {
+	return oauth.Callback(k, %[1]q)
+}
+`, provider)
+	}
+
+	for dir, content := range routes {
+		dir = filepath.Join(authPath, dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "route.go"), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -316,6 +509,13 @@ func printSuccessMessage(config ProjectConfig) {
 	fmt.Println("\n✅ Project created successfully!")
 	fmt.Printf("\nNext steps:\n")
 	fmt.Printf("  cd %s\n\n", config.ProjectName)
+
+	// Starter templates lay out projects their own way; see their README
+	if config.Template != "" {
+		fmt.Printf("  twine dev            # Start dev server with hot reload\n")
+		return
+	}
+
 	fmt.Printf("For development, run these commands in separate terminals:\n\n")
 	fmt.Printf("  Terminal 1:\n")
 	fmt.Printf("    npm run watch:css    # Watch and compile CSS\n\n")
@@ -327,6 +527,11 @@ func printSuccessMessage(config ProjectConfig) {
 	fmt.Printf("\nFile-based routing is enabled in app/ directory:\n")
 	fmt.Printf("  app/pages/           - HTML pages (renders templates)\n")
 	fmt.Printf("  app/api/             - JSON API routes\n")
+	if config.WithAuth {
+		fmt.Printf("\nSign-in with Google, GitHub or OpenID Connect is in app/api/auth/.\n")
+		fmt.Printf("Set the AUTH_*_CLIENT_ID and AUTH_*_CLIENT_SECRET variables in .env.example,\n")
+		fmt.Printf("and map provider accounts to your users in auth.go.\n")
+	}
 	fmt.Printf("\nFrontend tooling:\n")
 	fmt.Printf("  npm run build:css    - Build CSS for production\n")
 	fmt.Printf("  npm run watch:css    - Watch CSS during development\n")
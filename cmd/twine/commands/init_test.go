@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 // TestNewInitCommand tests init command creation
@@ -121,7 +122,51 @@ func TestGenerateFromTemplate_MainGo(t *testing.T) {
 	content, err := os.ReadFile(outputPath)
 	require.NoError(t, err)
 	assert.Contains(t, string(content), "package main")
-	assert.Contains(t, string(content), "3000") // Port
+	assert.Contains(t, string(content), "cfg := config.Get()")
+	assert.Contains(t, string(content), "template.LoadTemplates(cfg.Templates.Patterns...)")
+	assert.Contains(t, string(content), "server.NewServerFromConfig(cfg, mux)")
+	assert.Contains(t, string(content), `flag.Bool("check-config"`)
+	assert.Contains(t, string(content), `srv.OnShutdown("events", events.Drain)`)
+	assert.Contains(t, string(content), "srv.Run(context.Background())")
+	assert.Contains(t, string(content), "RegisterOnShutdown(realtime.Close)")
+	assert.Contains(t, string(content), "debug.Mount(srv, r)")
+}
+
+// TestGenerateFromTemplate_TwineYAML tests that twine.yaml parses into the
+// framework's config with the chosen port
+func TestGenerateFromTemplate_TwineYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := ProjectConfig{ProjectName: "testproject", Port: "8080"}
+
+	outputPath := filepath.Join(tmpDir, "twine.yaml")
+	require.NoError(t, generateFromTemplate(config, "twine.yaml.tmpl", outputPath))
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	var file struct {
+		Server struct {
+			Port           string   `yaml:"port"`
+			TrustedProxies []string `yaml:"trusted_proxies"`
+		} `yaml:"server"`
+		Templates struct {
+			Patterns []string `yaml:"patterns"`
+		} `yaml:"templates"`
+		Meta struct {
+			Title string `yaml:"title"`
+		} `yaml:"meta"`
+		Environments map[string]any `yaml:"environments"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &file))
+	assert.Equal(t, "8080", file.Server.Port)
+	assert.Empty(t, file.Server.TrustedProxies)
+	assert.Equal(t, []string{"templates/**/*.html"}, file.Templates.Patterns)
+	assert.Equal(t, "testproject", file.Meta.Title)
+	assert.Contains(t, file.Environments, "production")
+
+	// The CLI reads the same file
+	_, err = loadProjectConfig(tmpDir)
+	assert.NoError(t, err)
 }
 
 // TestGenerateFromTemplate_InvalidTemplate tests error handling
@@ -249,8 +294,9 @@ func TestGenerateFiles(t *testing.T) {
 	assert.FileExists(t, filepath.Join(tmpDir, "go.mod"))
 	assert.FileExists(t, filepath.Join(tmpDir, ".gitignore"))
 	assert.FileExists(t, filepath.Join(tmpDir, ".env.example"))
+	assert.FileExists(t, filepath.Join(tmpDir, "twine.yaml"))
 	assert.FileExists(t, filepath.Join(tmpDir, "README.md"))
-	assert.FileExists(t, filepath.Join(tmpDir, ".air.toml"))
+	assert.NoFileExists(t, filepath.Join(tmpDir, ".air.toml"))
 
 	// Verify app structure
 	assert.FileExists(t, filepath.Join(tmpDir, "app", "pages", "page.go"))
@@ -408,7 +454,7 @@ func TestCreateAppStructure_ContentVerification(t *testing.T) {
 
 	assert.Contains(t, string(layoutContent), "package pages")
 	assert.Contains(t, string(layoutContent), "func Layout() middleware.Middleware")
-	assert.Contains(t, string(layoutContent), "k.SetContext")
+	assert.Contains(t, string(layoutContent), "k.View().AppName")
 
 	// Verify health route content
 	healthContent, err := os.ReadFile(filepath.Join(tmpDir, "app", "api", "health", "route.go"))
@@ -451,8 +497,8 @@ func TestGenerateFromTemplate_AllTemplates(t *testing.T) {
 		{"go.mod.tmpl", "go.mod"},
 		{"gitignore.tmpl", ".gitignore"},
 		{"env.example.tmpl", ".env.example"},
+		{"twine.yaml.tmpl", "twine.yaml"},
 		{"README.md.tmpl", "README.md"},
-		{".air.toml.tmpl", ".air.toml"},
 	}
 
 	for _, tmpl := range templates {
@@ -522,3 +568,42 @@ func TestGenerateNodeConfig_PackageJSON(t *testing.T) {
 	// Note: Exact content depends on template
 	assert.NotEmpty(t, content)
 }
+
+// TestCreateAppStructure_WithAuth tests generating the sign-in routes
+func TestCreateAppStructure_WithAuth(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := ProjectConfig{ProjectName: "authproject", ModulePath: "github.com/test/authproject", Port: "3000", WithAuth: true}
+	require.NoError(t, generateFiles(config, tmpDir))
+
+	for _, provider := range authProviders {
+		begin, err := os.ReadFile(filepath.Join(tmpDir, "app", "api", "auth", provider, "route.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(begin), "package "+provider)
+		assert.Contains(t, string(begin), `oauth.Begin(k, "`+provider+`")`)
+
+		callback, err := os.ReadFile(filepath.Join(tmpDir, "app", "api", "auth", provider, "callback", "route.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(callback), `oauth.Callback(k, "`+provider+`")`)
+	}
+
+	assert.FileExists(t, filepath.Join(tmpDir, "app", "api", "auth", "logout", "route.go"))
+
+	upsert, err := os.ReadFile(filepath.Join(tmpDir, "auth.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(upsert), "oauth.UseUpsert(upsertUser)")
+	assert.Contains(t, string(upsert), "kit.UseLogout(auth.Logout)")
+
+	env, err := os.ReadFile(filepath.Join(tmpDir, ".env.example"))
+	require.NoError(t, err)
+	assert.Contains(t, string(env), "AUTH_GOOGLE_CLIENT_ID")
+
+	// Without the flag none of it is generated
+	plain := t.TempDir()
+	require.NoError(t, generateFiles(ProjectConfig{ProjectName: "plain", ModulePath: "example.com/plain", Port: "3000"}, plain))
+	assert.NoDirExists(t, filepath.Join(plain, "app", "api", "auth"))
+	assert.NoFileExists(t, filepath.Join(plain, "auth.go"))
+	env, err = os.ReadFile(filepath.Join(plain, ".env.example"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(env), "AUTH_GOOGLE_CLIENT_ID")
+}
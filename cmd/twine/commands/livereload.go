@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// liveReloadPath is the server-sent events endpoint served by the dev proxy
+const liveReloadPath = "/__twine/livereload"
+
+// liveReloadScript reloads the page on a "reload" event and re-fetches the
+// page's stylesheets on a "css" event
+const liveReloadScript = `<script>
+(function () {
+  var source = new EventSource("` + liveReloadPath + `");
+  source.addEventListener("reload", function () { location.reload(); });
+  source.addEventListener("css", function () {
+    document.querySelectorAll('link[rel="stylesheet"]').forEach(function (link) {
+      var url = new URL(link.href);
+      if (url.origin !== location.origin) return;
+      url.searchParams.set("twine-reload", Date.now());
+      link.href = url.toString();
+    });
+  });
+})();
+</script>
+`
+
+// liveReloader broadcasts reload events to connected browsers
+type liveReloader struct {
+	mu      sync.Mutex
+	clients map[chan string]struct{}
+}
+
+// newLiveReloader creates a live reloader with no clients
+func newLiveReloader() *liveReloader {
+	return &liveReloader{clients: make(map[chan string]struct{})}
+}
+
+// Send broadcasts an event to every connected browser. Browsers that are
+// not keeping up miss the event rather than blocking the dev server.
+func (l *liveReloader) Send(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for client := range l.clients {
+		select {
+		case client <- event:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams events to a browser until it disconnects
+func (l *liveReloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan string, 1)
+	l.mu.Lock()
+	l.clients[client] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, client)
+		l.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-client:
+			fmt.Fprintf(w, "event: %s\ndata: \n\n", event)
+			flusher.Flush()
+		}
+	}
+}
+
+// injectLiveReload adds the live reload script to full HTML pages. Partial
+// responses, such as Alpine Ajax fragments, have no </body> and are left
+// unchanged.
+func injectLiveReload(resp *http.Response) error {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if i := bytes.LastIndex(bytes.ToLower(body), []byte("</body>")); i >= 0 {
+		injected := make([]byte, 0, len(body)+len(liveReloadScript))
+		injected = append(injected, body[:i]...)
+		injected = append(injected, liveReloadScript...)
+		body = append(injected, body[i:]...)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// devErrorPage shows a build or proxy error and reloads once it is fixed
+var devErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><title>twine dev</title></head>
+<body style="font-family: monospace; padding: 2rem;">
+<h1>twine dev</h1>
+<pre style="white-space: pre-wrap;">{{.}}</pre>
+` + liveReloadScript + `</body>
+</html>
+`))
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInjectLiveReload tests that only full HTML pages get the script
+func TestInjectLiveReload(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		injected    bool
+	}{
+		{"full page", "text/html; charset=utf-8", "<html><body><h1>Hi</h1></body></html>", true},
+		{"uppercase body tag", "text/html", "<HTML><BODY>Hi</BODY></HTML>", true},
+		{"partial", "text/html; charset=utf-8", `<div id="stats">42</div>`, false},
+		{"json", "application/json", `{"body":"</body>"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Type": {tt.contentType}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}
+			require.NoError(t, injectLiveReload(resp))
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if !tt.injected {
+				assert.Equal(t, tt.body, string(body))
+				return
+			}
+			assert.Contains(t, string(body), liveReloadPath)
+			assert.True(t, strings.HasSuffix(strings.ToLower(string(body)), "</body></html>"))
+			assert.Equal(t, int64(len(body)), resp.ContentLength)
+		})
+	}
+}
+
+// TestLiveReloader tests broadcasting events to a connected browser
+func TestLiveReloader(t *testing.T) {
+	reloader := newLiveReloader()
+	server := httptest.NewServer(reloader)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Wait for the client to register before sending
+	require.Eventually(t, func() bool {
+		reloader.mu.Lock()
+		defer reloader.mu.Unlock()
+		return len(reloader.clients) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	reloader.Send("css")
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: css\n", line)
+}
+
+// TestDevServer_ProxyLiveReload tests the script injection, public files
+// and error page served by the dev proxy
+func TestDevServer_ProxyLiveReload(t *testing.T) {
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body>app</body></html>"))
+	}))
+	defer app.Close()
+
+	projectDir := t.TempDir()
+	cssDir := filepath.Join(projectDir, "public", "assets", "css")
+	require.NoError(t, os.MkdirAll(cssDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cssDir, "output.css"), []byte("body { color: red; }"), 0644))
+
+	server := newDevServer(projectDir, "", 0)
+	server.appAddr = app.Listener.Addr().String()
+	server.gate.Open()
+	proxy := httptest.NewServer(server.proxyHandler())
+	defer proxy.Close()
+
+	get := func(path, accept string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, proxy.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	_, body := get("/", "text/html")
+	assert.Contains(t, body, "app")
+	assert.Contains(t, body, `new EventSource("/__twine/livereload")`)
+
+	// Public files are served from disk without waiting for the app
+	server.gate.Close()
+	resp, body := get("/public/assets/css/output.css", "text/css")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "body { color: red; }", body)
+
+	// Pages show errors as HTML that reloads once fixed
+	server.gate.Fail(errors.New("main.go:1: <syntax> error"))
+	resp, body = get("/", "text/html")
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Contains(t, body, "main.go:1: &lt;syntax&gt; error")
+	assert.Contains(t, body, liveReloadPath)
+}
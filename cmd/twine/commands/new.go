@@ -0,0 +1,412 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/cstone-io/twine/internal/routing"
+	"github.com/cstone-io/twine/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+// routerMethods are the HTTP methods the router can register
+var routerMethods = []string{"GET", "POST", "PUT", "DELETE"}
+
+// newFileConfig is the data passed to the internal/scaffold/new templates
+type newFileConfig struct {
+	Package      string   // Go package name for the directory
+	Pattern      string   // URL pattern, e.g. "/users/{id}"
+	TemplateName string   // HTML template name rendered by GET
+	Title        string   // Page title
+	Methods      []string // HTTP methods to export
+	Params       []string // Path parameter names
+}
+
+// newRoutePath is a parsed route path such as "users/[id]"
+type newRoutePath struct {
+	segments []string // Directory names
+	pattern  string   // URL pattern below the section
+	params   []string
+}
+
+// NewNewCommand creates the new command
+func NewNewCommand() *cobra.Command {
+	var (
+		generate bool
+		force    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "new",
+		Short: "Scaffold pages, API routes and layouts",
+		Long:  "Create files in app/ following the file-based routing conventions",
+	}
+
+	cmd.PersistentFlags().BoolVarP(&generate, "generate", "g", false, "Regenerate routes.gen.go afterwards")
+	cmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "Overwrite existing files")
+
+	cmd.AddCommand(newNewPageCommand(&generate, &force))
+	cmd.AddCommand(newNewAPICommand(&generate, &force))
+	cmd.AddCommand(newNewLayoutCommand(&generate, &force))
+
+	return cmd
+}
+
+func newNewPageCommand(generate, force *bool) *cobra.Command {
+	var (
+		methods    []string
+		noTemplate bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "page <path>",
+		Short:   "Create a page.go and its HTML template",
+		Example: "  twine new page users/[id]\n  twine new page contact --methods GET,POST",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, route, config, err := prepareNew("pages", args[0], methods)
+			if err != nil {
+				return err
+			}
+
+			dir := filepath.Join(append([]string{cwd, "app", "pages"}, route.segments...)...)
+			if err := writeScaffold("new/page.go.tmpl", filepath.Join(dir, "page.go"), config, *force); err != nil {
+				return err
+			}
+
+			if !noTemplate && containsString(config.Methods, "GET") {
+				htmlPath := filepath.Join(cwd, "templates", "pages", config.TemplateName+".html")
+				if err := writeHTMLScaffold("new/page.html.tmpl", htmlPath, config, *force); err != nil {
+					return err
+				}
+			}
+
+			return finishNew(cwd, *generate)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&methods, "methods", "m", []string{"GET"}, "HTTP methods to export (GET, POST, PUT, DELETE)")
+	cmd.Flags().BoolVar(&noTemplate, "no-template", false, "Skip creating the HTML template")
+
+	return cmd
+}
+
+func newNewAPICommand(generate, force *bool) *cobra.Command {
+	var methods []string
+
+	cmd := &cobra.Command{
+		Use:     "api <path>",
+		Short:   "Create an API route.go",
+		Example: "  twine new api v1/orders\n  twine new api v1/orders/[id] --methods GET,PUT,DELETE",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, route, config, err := prepareNew("api", args[0], methods)
+			if err != nil {
+				return err
+			}
+
+			dir := filepath.Join(append([]string{cwd, "app", "api"}, route.segments...)...)
+			if err := writeScaffold("new/route.go.tmpl", filepath.Join(dir, "route.go"), config, *force); err != nil {
+				return err
+			}
+
+			return finishNew(cwd, *generate)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&methods, "methods", "m", []string{"GET"}, "HTTP methods to export (GET, POST, PUT, DELETE)")
+
+	return cmd
+}
+
+func newNewLayoutCommand(generate, force *bool) *cobra.Command {
+	return &cobra.Command{
+		Use:     "layout <path>",
+		Short:   "Create a layout.go for a pages directory",
+		Example: "  twine new layout dashboard\n  twine new layout .",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, route, config, err := prepareNew("pages", args[0], nil)
+			if err != nil {
+				return err
+			}
+
+			dir := filepath.Join(append([]string{cwd, "app", "pages"}, route.segments...)...)
+			if err := writeScaffold("new/layout.go.tmpl", filepath.Join(dir, "layout.go"), config, *force); err != nil {
+				return err
+			}
+
+			return finishNew(cwd, *generate)
+		},
+	}
+}
+
+// prepareNew validates the route path and methods and builds the template data
+func prepareNew(section, path string, methods []string) (string, *newRoutePath, *newFileConfig, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("getting current directory: %w", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(cwd, "app")); os.IsNotExist(err) {
+		return "", nil, nil, fmt.Errorf("app/ directory not found. Create it first or run 'twine init'")
+	}
+
+	route, err := parseNewRoutePath(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	normalized := make([]string, 0, len(methods))
+	for _, m := range methods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if !containsString(routerMethods, m) {
+			return "", nil, nil, fmt.Errorf("unsupported method %q (expected one of %s)", m, strings.Join(routerMethods, ", "))
+		}
+		if !containsString(normalized, m) {
+			normalized = append(normalized, m)
+		}
+	}
+
+	pattern := route.pattern
+	if section == "api" {
+		pattern = "/api" + strings.TrimSuffix(pattern, "/")
+	}
+
+	return cwd, route, &newFileConfig{
+		Package:      newPackageName(section, route.segments),
+		Pattern:      pattern,
+		TemplateName: newTemplateName(route.segments),
+		Title:        newTitle(route.segments),
+		Methods:      normalized,
+		Params:       route.params,
+	}, nil
+}
+
+// parseNewRoutePath splits a path like "users/[id]" into directory segments,
+// applying the same bracket and catch-all rules as the route scanner
+func parseNewRoutePath(path string) (*newRoutePath, error) {
+	route := &newRoutePath{segments: make([]string, 0)}
+
+	parts := strings.Split(strings.Trim(filepath.ToSlash(path), "/"), "/")
+	urlParts := make([]string, 0, len(parts))
+	for i, part := range parts {
+		if part == "" || part == "." {
+			continue
+		}
+		if part == ".." {
+			return nil, fmt.Errorf("invalid path %q: must stay inside app/", path)
+		}
+
+		segment := part
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			name := strings.TrimSuffix(strings.TrimPrefix(part, "["), "]")
+			catchAll := strings.HasPrefix(name, "...")
+			name = strings.TrimPrefix(name, "...")
+
+			if err := routing.ValidateParamName(name); err != nil {
+				return nil, fmt.Errorf("invalid path %q: %w", path, err)
+			}
+			if catchAll && i != len(parts)-1 {
+				return nil, fmt.Errorf("invalid path %q: catch-all segment must be the last segment in the route", path)
+			}
+
+			segment = "{" + name + "}"
+			if catchAll {
+				segment = "{" + name + "...}"
+			}
+			route.params = append(route.params, name)
+		}
+
+		route.segments = append(route.segments, part)
+		urlParts = append(urlParts, segment)
+	}
+
+	route.pattern = "/" + strings.Join(urlParts, "/")
+	return route, nil
+}
+
+// newPackageName returns the package name for the last directory, matching
+// the names used in the routing docs (id_param, path_catchall)
+func newPackageName(section string, segments []string) string {
+	if len(segments) == 0 {
+		return section
+	}
+
+	dir := segments[len(segments)-1]
+	if strings.HasPrefix(dir, "[") && strings.HasSuffix(dir, "]") {
+		return routing.SanitizePackageName(dir)
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, dir)
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "p" + name
+	}
+	return name
+}
+
+// newTemplateName returns the HTML template name for a page, e.g. "users_id"
+func newTemplateName(segments []string) string {
+	parts := make([]string, 0, len(segments))
+	for _, s := range segments {
+		s = strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"), "...")
+		parts = append(parts, strings.ReplaceAll(s, "-", "_"))
+	}
+	if len(parts) == 0 {
+		return "index"
+	}
+	return strings.Join(parts, "_")
+}
+
+// newTitle returns a page title from the last static segment
+func newTitle(segments []string) string {
+	for i := len(segments) - 1; i >= 0; i-- {
+		s := segments[i]
+		if strings.HasPrefix(s, "[") {
+			continue
+		}
+		words := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' })
+		for j, w := range words {
+			words[j] = strings.ToUpper(w[:1]) + w[1:]
+		}
+		return strings.Join(words, " ")
+	}
+	return "Home"
+}
+
+// writeScaffold renders a Go file from internal/scaffold, formats it and
+// writes it to dest, refusing to overwrite unless force is set
+func writeScaffold(src, dest string, data any, force bool) error {
+	formatted, err := renderScaffold(src, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", dest, err)
+	}
+
+	return writeNewFile(dest, formatted, force)
+}
+
+// renderScaffold renders and formats a Go file from internal/scaffold
+func renderScaffold(src string, data any) ([]byte, error) {
+	content, err := scaffold.FS.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting: %w", err)
+	}
+
+	return formatted, nil
+}
+
+// writeHTMLScaffold writes an HTML template. It uses [[ ]] delimiters since
+// the output itself is a Go template.
+func writeHTMLScaffold(src, dest string, data any, force bool) error {
+	content, err := scaffold.FS.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("").Delims("[[", "]]").Parse(string(content))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	return writeNewFile(dest, buf.Bytes(), force)
+}
+
+// writeTextScaffold writes a file from internal/scaffold that is neither Go
+// nor a Go template, such as a Dockerfile
+func writeTextScaffold(src, dest string, data any, force bool) error {
+	content, err := scaffold.FS.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("").Parse(string(content))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	return writeNewFile(dest, buf.Bytes(), force)
+}
+
+func writeNewFile(dest string, content []byte, force bool) error {
+	if _, err := os.Stat(dest); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", dest)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(dest, content, 0644); err != nil {
+		return err
+	}
+
+	rel := dest
+	if cwd, err := os.Getwd(); err == nil {
+		if r, err := filepath.Rel(cwd, dest); err == nil {
+			rel = r
+		}
+	}
+	fmt.Printf("✓ Created %s\n", rel)
+
+	return nil
+}
+
+// finishNew regenerates routes.gen.go if requested
+func finishNew(cwd string, generate bool) error {
+	if !generate {
+		fmt.Println("\nRun 'twine routes generate' to register the new files")
+		return nil
+	}
+
+	if err := generateRoutes(cwd, filepath.Join(cwd, "app")); err != nil {
+		return err
+	}
+	fmt.Println("✓ Regenerated app/routes.gen.go")
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
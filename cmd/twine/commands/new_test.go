@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewNewCommand tests new command creation
+func TestNewNewCommand(t *testing.T) {
+	cmd := NewNewCommand()
+
+	assert.Equal(t, "new", cmd.Use)
+	assert.NotNil(t, cmd.PersistentFlags().Lookup("generate"))
+	assert.NotNil(t, cmd.PersistentFlags().Lookup("force"))
+
+	uses := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		uses = append(uses, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"page", "api", "layout"}, uses)
+}
+
+// TestParseNewRoutePath tests route path parsing
+func TestParseNewRoutePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		pattern  string
+		params   []string
+		errorMsg string
+	}{
+		{path: ".", pattern: "/"},
+		{path: "users", pattern: "/users"},
+		{path: "/users/[id]/", pattern: "/users/{id}", params: []string{"id"}},
+		{path: "docs/[...path]", pattern: "/docs/{path...}", params: []string{"path"}},
+		{path: "docs/[...path]/edit", errorMsg: "catch-all segment must be the last segment"},
+		{path: "users/[1id]", errorMsg: "parameter name must start with letter or underscore"},
+		{path: "../outside", errorMsg: "must stay inside app/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, err := parseNewRoutePath(tt.path)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.pattern, route.pattern)
+			assert.Equal(t, tt.params, route.params)
+		})
+	}
+}
+
+// TestNewNames tests package, template and title naming
+func TestNewNames(t *testing.T) {
+	assert.Equal(t, "pages", newPackageName("pages", nil))
+	assert.Equal(t, "id_param", newPackageName("pages", []string{"users", "[id]"}))
+	assert.Equal(t, "path_catchall", newPackageName("pages", []string{"[...path]"}))
+	assert.Equal(t, "user_list", newPackageName("api", []string{"User-List"}))
+	assert.Equal(t, "p2024", newPackageName("pages", []string{"2024"}))
+
+	assert.Equal(t, "index", newTemplateName(nil))
+	assert.Equal(t, "users_id", newTemplateName([]string{"users", "[id]"}))
+	assert.Equal(t, "blog_my_post", newTemplateName([]string{"blog", "my-post"}))
+
+	assert.Equal(t, "Home", newTitle(nil))
+	assert.Equal(t, "Users", newTitle([]string{"users", "[id]"}))
+	assert.Equal(t, "Order History", newTitle([]string{"order-history"}))
+}
+
+// TestNewPageCommand tests page scaffolding end to end
+func TestNewPageCommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := NewNewCommand()
+	cmd.SetArgs([]string{"page", "users/[id]", "--methods", "get,POST", "--generate"})
+	require.NoError(t, cmd.Execute())
+
+	page, err := os.ReadFile(filepath.Join(projectDir, "app", "pages", "users", "[id]", "page.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "package id_param")
+	assert.Contains(t, string(page), `return k.Render("users_id", map[string]any{`)
+	assert.Contains(t, string(page), `"id":    k.PathValue("id"),`)
+	assert.Contains(t, string(page), "func POST(k *kit.Kit) error")
+
+	html, err := os.ReadFile(filepath.Join(projectDir, "templates", "pages", "users_id.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `{{define "users_id"}}`)
+
+	// --generate registers the new route
+	generated, err := os.ReadFile(filepath.Join(projectDir, "app", "routes.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(generated), `r.Post("/users/{id}"`)
+
+	// Existing files are not overwritten without --force
+	cmd = NewNewCommand()
+	cmd.SetArgs([]string{"page", "users/[id]"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+// TestNewAPICommand tests API route scaffolding
+func TestNewAPICommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := NewNewCommand()
+	cmd.SetArgs([]string{"api", "v1/orders", "--methods", "GET,POST,DELETE"})
+	require.NoError(t, cmd.Execute())
+
+	route, err := os.ReadFile(filepath.Join(projectDir, "app", "api", "v1", "orders", "route.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(route), "package orders")
+	assert.Contains(t, string(route), "// GET handles GET /api/v1/orders")
+	assert.Contains(t, string(route), "return k.JSON(201, map[string]any{})")
+	assert.Contains(t, string(route), "return k.NoContent()")
+
+	assert.NoFileExists(t, filepath.Join(projectDir, "app", "routes.gen.go"))
+
+	cmd = NewNewCommand()
+	cmd.SetArgs([]string{"api", "v1/orders", "--methods", "PATCH"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported method")
+}
+
+// TestNewLayoutCommand tests layout scaffolding
+func TestNewLayoutCommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := NewNewCommand()
+	cmd.SetArgs([]string{"layout", "dashboard"})
+	require.NoError(t, cmd.Execute())
+
+	layout, err := os.ReadFile(filepath.Join(projectDir, "app", "pages", "dashboard", "layout.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(layout), "package dashboard")
+	assert.Contains(t, string(layout), "func Layout() middleware.Middleware")
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cstone-io/twine/internal/routing"
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigFile is the optional project-level configuration file
+const projectConfigFile = "twine.yaml"
+
+// projectConfig holds the CLI settings read from twine.yaml
+type projectConfig struct {
+	Routes struct {
+		// Template is a text/template file overriding blocks of the default
+		// routes.gen.go template, relative to the project root
+		Template string `yaml:"template"`
+		// API configures the versions under app/api, e.g. app/api/v1
+		API struct {
+			// DefaultVersion is also registered without its version
+			// segment, at /api
+			DefaultVersion string `yaml:"default_version"`
+			// Versions holds the deprecation of each version
+			Versions map[string]apiVersionConfig `yaml:"versions"`
+		} `yaml:"api"`
+	} `yaml:"routes"`
+}
+
+// apiVersionConfig is an entry of routes.api.versions in twine.yaml
+type apiVersionConfig struct {
+	Deprecated string `yaml:"deprecated"` // Deprecation date, YYYY-MM-DD
+	Sunset     string `yaml:"sunset"`     // Removal date, YYYY-MM-DD
+	Link       string `yaml:"link"`       // Migration guide URL
+}
+
+// loadProjectConfig reads twine.yaml from the project root. A missing file
+// is not an error and yields the defaults.
+func loadProjectConfig(projectRoot string) (*projectConfig, error) {
+	cfg := &projectConfig{}
+
+	data, err := os.ReadFile(filepath.Join(projectRoot, projectConfigFile))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", projectConfigFile, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", projectConfigFile, err)
+	}
+
+	if cfg.Routes.Template != "" && !filepath.IsAbs(cfg.Routes.Template) {
+		cfg.Routes.Template = filepath.Join(projectRoot, cfg.Routes.Template)
+	}
+
+	return cfg, nil
+}
+
+// apiVersioning returns the API version settings passed to the route
+// generator. Versions without a deprecation date aren't deprecated.
+func (c *projectConfig) apiVersioning() routing.APIVersioning {
+	api := routing.APIVersioning{DefaultVersion: c.Routes.API.DefaultVersion}
+	for version, v := range c.Routes.API.Versions {
+		if v.Deprecated == "" {
+			continue
+		}
+		if api.Deprecations == nil {
+			api.Deprecations = make(map[string]routing.Deprecation)
+		}
+		api.Deprecations[version] = routing.Deprecation{Date: v.Deprecated, Sunset: v.Sunset, Link: v.Link}
+	}
+	return api
+}
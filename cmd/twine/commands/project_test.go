@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cstone-io/twine/internal/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadProjectConfig tests reading twine.yaml
+func TestLoadProjectConfig(t *testing.T) {
+	t.Run("missing file uses defaults", func(t *testing.T) {
+		cfg, err := loadProjectConfig(t.TempDir())
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Routes.Template)
+	})
+
+	t.Run("relative template path", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "twine.yaml"), []byte("routes:\n  template: tools/routes.tmpl\n"), 0644))
+
+		cfg, err := loadProjectConfig(dir)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "tools", "routes.tmpl"), cfg.Routes.Template)
+	})
+
+	t.Run("api versions", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "twine.yaml"), []byte(`routes:
+  api:
+    default_version: v2
+    versions:
+      v1:
+        deprecated: 2026-06-01
+        link: https://example.com/migrate
+      v2: {}
+`), 0644))
+
+		cfg, err := loadProjectConfig(dir)
+		require.NoError(t, err)
+		assert.Equal(t, routing.APIVersioning{
+			DefaultVersion: "v2",
+			Deprecations: map[string]routing.Deprecation{
+				"v1": {Date: "2026-06-01", Link: "https://example.com/migrate"},
+			},
+		}, cfg.apiVersioning())
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "twine.yaml"), []byte("routes: [\n"), 0644))
+
+		_, err := loadProjectConfig(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parsing twine.yaml")
+	})
+}
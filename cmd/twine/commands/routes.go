@@ -1,9 +1,14 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
@@ -26,10 +31,28 @@ func NewRoutesCommand() *cobra.Command {
 }
 
 func newRoutesGenerateCommand() *cobra.Command {
-	return &cobra.Command{
+	var (
+		format    string
+		dryRun    bool
+		check     bool
+		withTests bool
+	)
+
+	cmd := &cobra.Command{
 		Use:   "generate",
 		Short: "Generate routes.gen.go from app/ directory",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unknown format %q (expected text or json)", format)
+			}
+			if withTests && (dryRun || check) {
+				return fmt.Errorf("--with-tests cannot be used with --dry-run or --check")
+			}
+			quiet := format == "json"
+
+			// Flags are valid, keep usage text out of machine-readable output
+			cmd.SilenceUsage = true
+
 			// Get current directory
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -43,15 +66,29 @@ func newRoutesGenerateCommand() *cobra.Command {
 			}
 
 			// Scan routes
-			fmt.Println("🔍 Scanning routes in app/...")
+			if !quiet {
+				fmt.Println("🔍 Scanning routes in app/...")
+			}
 			root, err := routing.ScanRoutes(appDir)
 			if err != nil {
 				return fmt.Errorf("scanning routes: %w", err)
 			}
 
 			// Validate routes
-			if err := root.Validate(); err != nil {
-				return fmt.Errorf("validation error: %w", err)
+			diags := relativeDiagnostics(root.Diagnose(), cwd)
+			if quiet {
+				if err := writeDiagnosticsJSON(cmd.OutOrStdout(), diags); err != nil {
+					return err
+				}
+			}
+			if errs := diags.Errors(); len(errs) > 0 {
+				return fmt.Errorf("validation error: %d problem(s) found\n%w", len(errs), errs)
+			}
+			if warnings := diags.Warnings(); len(warnings) > 0 && !quiet {
+				fmt.Println("⚠️  Route warnings:")
+				for _, w := range warnings {
+					fmt.Printf("   %s\n", w)
+				}
 			}
 
 			// Get module path
@@ -60,6 +97,11 @@ func newRoutesGenerateCommand() *cobra.Command {
 				return fmt.Errorf("getting module path: %w", err)
 			}
 
+			projectCfg, err := loadProjectConfig(cwd)
+			if err != nil {
+				return err
+			}
+
 			// Generate code
 			outputFile := filepath.Join(appDir, "routes.gen.go")
 			generator := &routing.CodeGenerator{
@@ -67,13 +109,31 @@ func newRoutesGenerateCommand() *cobra.Command {
 				ModulePath:  modulePath,
 				ProjectRoot: cwd,
 				OutputFile:  outputFile,
+				Template:    projectCfg.Routes.Template,
+				API:         projectCfg.apiVersioning(),
+			}
+
+			if dryRun || check {
+				return compareGenerated(cmd.OutOrStdout(), generator, cwd, !quiet, check)
 			}
 
-			fmt.Println("📝 Generating routes.gen.go...")
+			if !quiet {
+				fmt.Println("📝 Generating routes.gen.go...")
+			}
 			if err := generator.Generate(); err != nil {
 				return fmt.Errorf("generating routes: %w", err)
 			}
 
+			if withTests {
+				if err := writeRouteTests(cmd.OutOrStdout(), root, cwd, modulePath, !quiet); err != nil {
+					return err
+				}
+			}
+
+			if quiet {
+				return nil
+			}
+
 			fmt.Printf("✅ Routes generated successfully: %s\n", outputFile)
 
 			// Display route table
@@ -82,13 +142,228 @@ func newRoutesGenerateCommand() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Diagnostic output format (text, json)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print a diff against the existing routes.gen.go without writing it")
+	cmd.Flags().BoolVar(&check, "check", false, "Exit non-zero if routes.gen.go is out of date")
+	cmd.Flags().BoolVar(&withTests, "with-tests", false, "Write a twinetest skeleton next to each route without one")
+
+	return cmd
+}
+
+// compareGenerated diffs the existing routes.gen.go against freshly generated
+// code without writing anything. With check set, a stale file is an error.
+func compareGenerated(out io.Writer, generator *routing.CodeGenerator, projectRoot string, verbose, check bool) error {
+	generated, err := generator.Render()
+	if err != nil {
+		return fmt.Errorf("generating routes: %w", err)
+	}
+
+	current, err := os.ReadFile(generator.OutputFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", generator.OutputFile, err)
+	}
+
+	name := generator.OutputFile
+	if rel, err := filepath.Rel(projectRoot, name); err == nil {
+		name = rel
+	}
+
+	diff, err := unifiedDiff(name, current, generated)
+	if err != nil {
+		return fmt.Errorf("diffing %s: %w", name, err)
+	}
+
+	if diff == "" {
+		if verbose {
+			fmt.Fprintf(out, "✅ %s is up to date\n", name)
+		}
+		return nil
+	}
+
+	if verbose {
+		if useColor(out) {
+			diff = colorizeDiff(diff)
+		}
+		fmt.Fprint(out, diff)
+	}
+
+	if check {
+		return fmt.Errorf("%s is out of date, run 'twine routes generate'", name)
+	}
+
+	return nil
+}
+
+// routeParamPattern matches the path parameters of a route pattern
+var routeParamPattern = regexp.MustCompile(`\{[^}]+\}`)
+
+// routeTestConfig is the data passed to generate/route_test.go.tmpl
+type routeTestConfig struct {
+	ModulePath string
+	Package    string // Package of the handler, the test is in Package_test
+	Pattern    string // URL pattern, e.g. "/posts/{id}"
+	Params     string // Path parameters to fill in, e.g. "{id}", empty for none
+	Form       bool   // A test posts a form, so the file needs net/url
+	Migrations bool   // db/migrations has a package to import
+	Methods    []routeTestMethod
+}
+
+// routeTestMethod is the test of one exported method
+type routeTestMethod struct {
+	Method string // e.g. "POST"
+	Call   string // twinetest.App call, e.g. `PostJSON("/api/posts", map[string]any{})`
+	Status string // Expected net/http status constant, e.g. "StatusCreated"
+	// Skip the test until it's filled in, as the request fails without the
+	// records, path parameters or sign-in the handler needs
+	Skip bool
+}
+
+// writeRouteTests writes page_test.go or route_test.go next to each
+// handler that doesn't have one, with a test per exported method
+func writeRouteTests(out io.Writer, root *routing.RouteNode, projectRoot, modulePath string, verbose bool) error {
+	migrations := hasGoFiles(filepath.Join(projectRoot, migrationsDir))
+
+	for _, route := range collectAllRoutes(root) {
+		dest := strings.TrimSuffix(route.HandlerFile, ".go") + "_test.go"
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+
+		config := newRouteTestConfig(route, modulePath)
+		config.Migrations = migrations
+		content, err := renderScaffold("generate/route_test.go.tmpl", config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dest, err)
+		}
+		if err := os.WriteFile(dest, content, 0644); err != nil {
+			return err
+		}
+
+		if verbose {
+			rel := dest
+			if r, err := filepath.Rel(projectRoot, dest); err == nil {
+				rel = r
+			}
+			fmt.Fprintf(out, "🧪 Created %s\n", rel)
+		}
+	}
+
+	return nil
+}
+
+// newRouteTestConfig builds the test skeleton data for a route, expecting
+// the statuses the handlers from 'twine new' and 'twine generate' return.
+// Only GET tests of pages without path parameters run as generated; the
+// others are skipped until filled in.
+func newRouteTestConfig(route *routing.RouteNode, modulePath string) *routeTestConfig {
+	pattern := route.ToURLPattern()
+	config := &routeTestConfig{
+		ModulePath: modulePath,
+		Package:    route.PackageName,
+		Pattern:    pattern,
+		Params:     strings.Join(routeParamPattern.FindAllString(pattern, -1), ", "),
+	}
+
+	path := strconv.Quote(pattern)
+	for _, method := range route.Methods {
+		m := routeTestMethod{Method: method, Status: "StatusOK", Skip: method != "GET" || !route.IsPage || config.Params != ""}
+		switch {
+		case method == "GET":
+			m.Call = fmt.Sprintf("Get(%s)", path)
+		case method == "DELETE":
+			m.Call = fmt.Sprintf("Delete(%s)", path)
+			m.Status = "StatusNoContent"
+		case method == "POST" && route.IsPage:
+			m.Call = fmt.Sprintf("PostForm(%s, url.Values{})", path)
+			m.Status = "StatusSeeOther"
+			config.Form = true
+		case method == "POST":
+			m.Call = fmt.Sprintf("PostJSON(%s, map[string]any{})", path)
+			m.Status = "StatusCreated"
+		case route.IsPage:
+			m.Call = fmt.Sprintf("Request(%q, %s).Do()", method, path)
+			m.Status = "StatusNoContent"
+		default:
+			m.Call = fmt.Sprintf("Request(%q, %s).JSON(map[string]any{}).Do()", method, path)
+		}
+		config.Methods = append(config.Methods, m)
+	}
+
+	return config
+}
+
+// relativeDiagnostics rewrites diagnostic file paths relative to the project root
+func relativeDiagnostics(diags routing.Diagnostics, root string) routing.Diagnostics {
+	for i, d := range diags {
+		if rel, err := filepath.Rel(root, d.File); err == nil {
+			diags[i].File = rel
+		}
+	}
+	return diags
+}
+
+// writeDiagnosticsJSON writes validation results as a JSON document
+func writeDiagnosticsJSON(w io.Writer, diags routing.Diagnostics) error {
+	if diags == nil {
+		diags = routing.Diagnostics{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Diagnostics routing.Diagnostics `json:"diagnostics"`
+	}{diags})
 }
 
 func newRoutesListCommand() *cobra.Command {
-	return &cobra.Command{
+	var (
+		asJSON     bool
+		asMarkdown bool
+		filters    []string
+		middleware bool
+		diff       bool
+	)
+
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all discovered routes",
+		Long: `List the routes discovered in app/.
+
+Filters take key=value and can be repeated; a route must match all of them.
+method matches any of a comma-separated list of methods, path matches the
+route pattern where * stands for any characters, including /.
+
+--diff compares two API versions under app/api, such as app/api/v1 and
+app/api/v2, by method and path without the version segment.`,
+		Example: `  twine routes list --filter method=POST
+  twine routes list --filter path=/api/* --middleware
+  twine routes list --json
+  twine routes list --markdown > ROUTES.md
+  twine routes list --diff v1 v2`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if diff && len(args) != 2 {
+				return fmt.Errorf("--diff takes two API versions, e.g. --diff v1 v2")
+			}
+			if !diff && len(args) > 0 {
+				return fmt.Errorf("unexpected arguments %v", args)
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if asJSON && asMarkdown {
+				return fmt.Errorf("--json and --markdown cannot be used together")
+			}
+			if diff && asMarkdown {
+				return fmt.Errorf("--diff and --markdown cannot be used together")
+			}
+			match, err := parseRouteFilters(filters)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
 			// Get current directory
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -107,12 +382,278 @@ func newRoutesListCommand() *cobra.Command {
 				return fmt.Errorf("scanning routes: %w", err)
 			}
 
-			// Display route table
-			displayRouteTable(root)
+			out := cmd.OutOrStdout()
+			if diff {
+				versions := root.APIVersions()
+				for _, version := range args {
+					if !slices.Contains(versions, version) {
+						return fmt.Errorf("API version %s not found in app/api", version)
+					}
+				}
+				report := diffRouteVersions(filterRoutes(listRoutes(root), match), args[0], args[1])
+				if asJSON {
+					return writeRouteDiffJSON(out, report)
+				}
+				writeRouteDiffText(out, report)
+				return nil
+			}
+
+			switch {
+			case asJSON:
+				return writeRoutesJSON(out, filterRoutes(listRoutes(root), match))
+			case asMarkdown:
+				writeRoutesMarkdown(out, filterRoutes(listRoutes(root), match), middleware)
+			case len(filters) > 0 || middleware:
+				writeRoutesText(out, filterRoutes(listRoutes(root), match), middleware)
+			default:
+				// Display route table
+				displayRouteTable(root)
+			}
 
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print routes as JSON")
+	cmd.Flags().BoolVar(&asMarkdown, "markdown", false, "Print routes as a Markdown table")
+	cmd.Flags().StringArrayVar(&filters, "filter", nil, "Only list matching routes, e.g. method=POST or path=/api/* (repeatable)")
+	cmd.Flags().BoolVar(&middleware, "middleware", false, "Show the layout, middleware and error boundary chain of each route")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Compare the routes of two API versions, e.g. --diff v1 v2")
+
+	return cmd
+}
+
+// routeEntry is one method of a route, as listed by 'twine routes list'
+type routeEntry struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Kind   string `json:"kind"` // "page" or "api"
+	File   string `json:"file"`
+	// Version is the API version, e.g. "v1" for routes under app/api/v1
+	Version string `json:"version,omitempty"`
+	// Middleware lists the files wrapping the handler, outermost first:
+	// layouts, then directory middleware, then the error boundary
+	Middleware []string `json:"middleware"`
+}
+
+// listRoutes flattens the route tree into one entry per route and method.
+// Files are relative to the project root.
+func listRoutes(root *routing.RouteNode) []routeEntry {
+	rel := func(file string) string {
+		return strings.TrimPrefix(file, filepath.Dir(root.Path)+"/")
+	}
+
+	entries := make([]routeEntry, 0)
+	for _, route := range collectAllRoutes(root) {
+		kind := "page"
+		if route.IsAPI {
+			kind = "api"
+		}
+
+		chain := make([]string, 0)
+		for _, layout := range routing.BuildLayoutChain(route, "").Layouts {
+			chain = append(chain, rel(layout.FilePath))
+		}
+		for _, mw := range routing.BuildMiddlewareChain(route, "").Middlewares {
+			chain = append(chain, rel(mw.FilePath))
+		}
+		if boundary := routing.FindErrorBoundary(route, ""); boundary != nil {
+			chain = append(chain, rel(boundary.FilePath))
+		}
+
+		for _, method := range route.Methods {
+			entries = append(entries, routeEntry{
+				Method:     method,
+				Path:       route.ToURLPattern(),
+				Kind:       kind,
+				File:       rel(route.HandlerFile),
+				Version:    route.APIVersion,
+				Middleware: chain,
+			})
+		}
+	}
+
+	return entries
+}
+
+// parseRouteFilters parses --filter key=value flags into a predicate
+// matching routes that satisfy all of them
+func parseRouteFilters(filters []string) (func(routeEntry) bool, error) {
+	matchers := make([]func(routeEntry) bool, 0, len(filters))
+
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid --filter %q: expected key=value", filter)
+		}
+
+		switch strings.ToLower(key) {
+		case "method":
+			methods := strings.Split(strings.ToUpper(value), ",")
+			matchers = append(matchers, func(e routeEntry) bool {
+				return slices.Contains(methods, e.Method)
+			})
+		case "path":
+			pattern, err := regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, ".*") + "$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid --filter %q: %w", filter, err)
+			}
+			matchers = append(matchers, func(e routeEntry) bool {
+				return pattern.MatchString(e.Path)
+			})
+		default:
+			return nil, fmt.Errorf("unknown --filter key %q (expected method or path)", key)
+		}
+	}
+
+	return func(e routeEntry) bool {
+		for _, match := range matchers {
+			if !match(e) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// filterRoutes returns the entries matching match
+func filterRoutes(entries []routeEntry, match func(routeEntry) bool) []routeEntry {
+	filtered := make([]routeEntry, 0, len(entries))
+	for _, e := range entries {
+		if match(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// writeRoutesText writes routes as an aligned table
+func writeRoutesText(w io.Writer, entries []routeEntry, middleware bool) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "📭 No routes found")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t→ %s", e.Method, e.Path, e.File)
+		if middleware {
+			fmt.Fprintf(tw, "\t%s", formatRouteChain(e.Middleware, " → "))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+// writeRoutesJSON writes routes as a JSON document
+func writeRoutesJSON(w io.Writer, entries []routeEntry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Routes []routeEntry `json:"routes"`
+	}{entries})
+}
+
+// routeDiff compares the routes of two API versions
+type routeDiff struct {
+	From      string       `json:"from"`
+	To        string       `json:"to"`
+	Added     []routeEntry `json:"added"`     // Only in To
+	Removed   []routeEntry `json:"removed"`   // Only in From
+	Unchanged []routeEntry `json:"unchanged"` // In both, as in To
+}
+
+// diffRouteVersions compares the entries of API versions from and to by
+// method and path without the version segment
+func diffRouteVersions(entries []routeEntry, from, to string) routeDiff {
+	key := func(e routeEntry) string {
+		return e.Method + " " + routing.StripAPIVersion(e.Path, e.Version)
+	}
+
+	inFrom := make(map[string]bool)
+	inTo := make(map[string]bool)
+	for _, e := range entries {
+		switch e.Version {
+		case from:
+			inFrom[key(e)] = true
+		case to:
+			inTo[key(e)] = true
+		}
+	}
+
+	report := routeDiff{From: from, To: to, Added: []routeEntry{}, Removed: []routeEntry{}, Unchanged: []routeEntry{}}
+	for _, e := range entries {
+		switch {
+		case e.Version == from && !inTo[key(e)]:
+			report.Removed = append(report.Removed, e)
+		case e.Version == to && inFrom[key(e)]:
+			report.Unchanged = append(report.Unchanged, e)
+		case e.Version == to:
+			report.Added = append(report.Added, e)
+		}
+	}
+	return report
+}
+
+// writeRouteDiffText writes a version comparison as +/- lines
+func writeRouteDiffText(w io.Writer, report routeDiff) {
+	fmt.Fprintf(w, "API %s → %s: %d added, %d removed, %d unchanged\n",
+		report.From, report.To, len(report.Added), len(report.Removed), len(report.Unchanged))
+	if len(report.Added) == 0 && len(report.Removed) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, e := range report.Removed {
+		fmt.Fprintf(tw, "-  %s\t%s\t→ %s\n", e.Method, e.Path, e.File)
+	}
+	for _, e := range report.Added {
+		fmt.Fprintf(tw, "+  %s\t%s\t→ %s\n", e.Method, e.Path, e.File)
+	}
+	tw.Flush()
+}
+
+// writeRouteDiffJSON writes a version comparison as a JSON document
+func writeRouteDiffJSON(w io.Writer, report routeDiff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// writeRoutesMarkdown writes routes as a Markdown table
+func writeRoutesMarkdown(w io.Writer, entries []routeEntry, middleware bool) {
+	if middleware {
+		fmt.Fprintln(w, "| Method | Path | File | Middleware |")
+		fmt.Fprintln(w, "| --- | --- | --- | --- |")
+	} else {
+		fmt.Fprintln(w, "| Method | Path | File |")
+		fmt.Fprintln(w, "| --- | --- | --- |")
+	}
+
+	code := func(s string) string {
+		return "`" + s + "`"
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(w, "| %s | %s | %s |", e.Method, code(e.Path), code(e.File))
+		if middleware {
+			chain := make([]string, len(e.Middleware))
+			for i, file := range e.Middleware {
+				chain[i] = code(file)
+			}
+			fmt.Fprintf(w, " %s |", formatRouteChain(chain, " → "))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// formatRouteChain joins a middleware chain, or returns "-" if it is empty
+func formatRouteChain(chain []string, sep string) string {
+	if len(chain) == 0 {
+		return "-"
+	}
+	return strings.Join(chain, sep)
 }
 
 func displayRouteTable(root *routing.RouteNode) {
@@ -153,6 +694,48 @@ func displayRouteTable(root *routing.RouteNode) {
 		}
 		fmt.Println()
 	}
+
+	// Display directory middleware
+	middlewares := collectAllMiddlewares(root)
+	if len(middlewares) > 0 {
+		fmt.Println("\n🛡️  Middleware active:")
+		fmt.Println()
+
+		for _, mw := range middlewares {
+			pathPattern := getLayoutPattern(mw)
+			relPath := strings.TrimPrefix(mw.MiddlewareFile, filepath.Dir(root.Path)+"/")
+			fmt.Printf("   %s\t→ %s\n", pathPattern, relPath)
+		}
+		fmt.Println()
+	}
+
+	// Display not-found pages
+	notFounds := collectAllNotFounds(root)
+	if len(notFounds) > 0 {
+		fmt.Println("\n🔎 Not-found pages:")
+		fmt.Println()
+
+		for _, nf := range notFounds {
+			pathPattern := getLayoutPattern(nf)
+			relPath := strings.TrimPrefix(nf.NotFoundFile, filepath.Dir(root.Path)+"/")
+			fmt.Printf("   %s\t→ %s\n", pathPattern, relPath)
+		}
+		fmt.Println()
+	}
+
+	// Display error boundaries
+	boundaries := collectAllErrorBoundaries(root)
+	if len(boundaries) > 0 {
+		fmt.Println("\n🚧 Error boundaries:")
+		fmt.Println()
+
+		for _, boundary := range boundaries {
+			pathPattern := getLayoutPattern(boundary)
+			relPath := strings.TrimPrefix(boundary.ErrorFile, filepath.Dir(root.Path)+"/")
+			fmt.Printf("   %s\t→ %s\n", pathPattern, relPath)
+		}
+		fmt.Println()
+	}
 }
 
 func collectAllRoutes(node *routing.RouteNode) []*routing.RouteNode {
@@ -183,6 +766,48 @@ func collectAllLayouts(node *routing.RouteNode) []*routing.RouteNode {
 	return layouts
 }
 
+func collectAllMiddlewares(node *routing.RouteNode) []*routing.RouteNode {
+	middlewares := make([]*routing.RouteNode, 0)
+
+	if node.HasMiddleware {
+		middlewares = append(middlewares, node)
+	}
+
+	for _, child := range node.Children {
+		middlewares = append(middlewares, collectAllMiddlewares(child)...)
+	}
+
+	return middlewares
+}
+
+func collectAllErrorBoundaries(node *routing.RouteNode) []*routing.RouteNode {
+	boundaries := make([]*routing.RouteNode, 0)
+
+	if node.HasError {
+		boundaries = append(boundaries, node)
+	}
+
+	for _, child := range node.Children {
+		boundaries = append(boundaries, collectAllErrorBoundaries(child)...)
+	}
+
+	return boundaries
+}
+
+func collectAllNotFounds(node *routing.RouteNode) []*routing.RouteNode {
+	notFounds := make([]*routing.RouteNode, 0)
+
+	if node.HasNotFound {
+		notFounds = append(notFounds, node)
+	}
+
+	for _, child := range node.Children {
+		notFounds = append(notFounds, collectAllNotFounds(child)...)
+	}
+
+	return notFounds
+}
+
 func getLayoutPattern(node *routing.RouteNode) string {
 	path := node.GetFullPath()
 	if path == "" {
@@ -1,8 +1,11 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cstone-io/twine/internal/routing"
@@ -362,6 +365,82 @@ func TestCollectAllLayouts_Empty(t *testing.T) {
 	assert.Empty(t, layouts)
 }
 
+// TestCollectAllMiddlewares tests directory middleware collection
+func TestCollectAllMiddlewares(t *testing.T) {
+	root := &routing.RouteNode{
+		Path: "/app",
+		Children: []*routing.RouteNode{
+			{
+				Path:           "/app/api",
+				URLSegment:     "api",
+				MiddlewareFile: "/app/api/middleware.go",
+				HasMiddleware:  true,
+				Children: []*routing.RouteNode{
+					{
+						Path:        "/app/api/users",
+						URLSegment:  "users",
+						HandlerFile: "/app/api/users/route.go",
+					},
+				},
+			},
+		},
+	}
+
+	middlewares := collectAllMiddlewares(root)
+
+	require.Len(t, middlewares, 1)
+	assert.Equal(t, "/app/api/middleware.go", middlewares[0].MiddlewareFile)
+}
+
+// TestCollectAllErrorBoundaries tests error boundary collection
+func TestCollectAllErrorBoundaries(t *testing.T) {
+	root := &routing.RouteNode{
+		Path: "/app",
+		Children: []*routing.RouteNode{
+			{
+				Path:       "/app/pages",
+				URLSegment: "pages",
+				ErrorFile:  "/app/pages/error.go",
+				HasError:   true,
+				Children: []*routing.RouteNode{
+					{
+						Path:       "/app/pages/dashboard",
+						URLSegment: "dashboard",
+						ErrorFile:  "/app/pages/dashboard/error.go",
+						HasError:   true,
+					},
+				},
+			},
+		},
+	}
+
+	boundaries := collectAllErrorBoundaries(root)
+
+	require.Len(t, boundaries, 2)
+	assert.Equal(t, "/app/pages/error.go", boundaries[0].ErrorFile)
+	assert.Equal(t, "/app/pages/dashboard/error.go", boundaries[1].ErrorFile)
+}
+
+// TestCollectAllNotFounds tests not-found page collection
+func TestCollectAllNotFounds(t *testing.T) {
+	root := &routing.RouteNode{
+		Path: "/app",
+		Children: []*routing.RouteNode{
+			{
+				Path:         "/app/pages",
+				URLSegment:   "pages",
+				NotFoundFile: "/app/pages/notfound.go",
+				HasNotFound:  true,
+			},
+		},
+	}
+
+	notFounds := collectAllNotFounds(root)
+
+	require.Len(t, notFounds, 1)
+	assert.Equal(t, "/app/pages/notfound.go", notFounds[0].NotFoundFile)
+}
+
 // TestGetLayoutPattern tests layout pattern generation
 func TestGetLayoutPattern(t *testing.T) {
 	tests := []struct {
@@ -518,3 +597,503 @@ func Layout() middleware.Middleware {
 	assert.Contains(t, generated, "/api/users")
 	assert.Contains(t, generated, "applyMiddleware") // Layout middleware
 }
+
+// TestRoutesGenerateCommand_JSONFormat tests machine-readable diagnostics
+func TestRoutesGenerateCommand_JSONFormat(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	createTestRoute(t, projectDir, "pages/test/page.go", `package test
+
+func helper() {}
+`)
+	createTestRoute(t, projectDir, "pages/[1id]/page.go", `package id
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	var out bytes.Buffer
+	cmd := newRoutesGenerateCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--format", "json"})
+	err := cmd.Execute()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validation error")
+
+	var result struct {
+		Diagnostics []routing.Diagnostic `json:"diagnostics"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	require.Len(t, result.Diagnostics, 2)
+
+	codes := []string{result.Diagnostics[0].Code, result.Diagnostics[1].Code}
+	assert.ElementsMatch(t, []string{routing.CodeNoMethods, routing.CodeInvalidParam}, codes)
+	for _, d := range result.Diagnostics {
+		assert.False(t, filepath.IsAbs(d.File), "file paths should be relative: %s", d.File)
+	}
+}
+
+// TestRoutesGenerateCommand_UnknownFormat tests format flag validation
+func TestRoutesGenerateCommand_UnknownFormat(t *testing.T) {
+	cmd := newRoutesGenerateCommand()
+	cmd.SetArgs([]string{"--format", "xml"})
+	err := cmd.Execute()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown format")
+}
+
+// TestRoutesGenerateCommand_DryRunAndCheck tests stale detection without writing
+func TestRoutesGenerateCommand_DryRunAndCheck(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	createTestRoute(t, projectDir, "pages/index/page.go", `package index
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	routesFile := filepath.Join(projectDir, "app", "routes.gen.go")
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := newRoutesGenerateCommand()
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	// Dry run on a missing file shows the whole file as added and writes nothing
+	out, err := run("--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, out, "+++ app/routes.gen.go (generated)")
+	assert.Contains(t, out, "+func RegisterRoutes(r *router.Router) {")
+	assert.NotContains(t, out, "\033[", "no color when not writing to a terminal")
+	assert.NoFileExists(t, routesFile)
+
+	// Check fails while the file is missing
+	_, err = run("--check")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app/routes.gen.go is out of date")
+
+	// Check passes once generated
+	_, err = run()
+	require.NoError(t, err)
+	out, err = run("--check")
+	require.NoError(t, err)
+	assert.Contains(t, out, "app/routes.gen.go is up to date")
+
+	// Check fails when the file is edited by hand
+	require.NoError(t, os.WriteFile(routesFile, []byte("package app\n\nvar edited = true\n"), 0644))
+	out, err = run("--check")
+	require.Error(t, err)
+	assert.Contains(t, out, "-var edited = true")
+
+	// Neither flag writes the file
+	content, err := os.ReadFile(routesFile)
+	require.NoError(t, err)
+	assert.Equal(t, "package app\n\nvar edited = true\n", string(content))
+}
+
+// TestRoutesGenerateCommand_CustomTemplate tests the routes.template setting in twine.yaml
+func TestRoutesGenerateCommand_CustomTemplate(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	createTestRoute(t, projectDir, "pages/index/page.go", `package index
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "twine.yaml"), []byte("routes:\n  template: routes.tmpl\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "routes.tmpl"), []byte(
+		`{{define "handler"}}instrument({{.Handler}}){{end}}`), 0644))
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := newRoutesGenerateCommand()
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+
+	content, err := os.ReadFile(filepath.Join(projectDir, "app", "routes.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `r.Get("/index", instrument(`)
+}
+
+// setupRoutesListProject creates pages and API routes with a layout,
+// directory middleware and an error boundary
+func setupRoutesListProject(t *testing.T) string {
+	t.Helper()
+	projectDir := setupTestProject(t)
+
+	createTestRoute(t, projectDir, "pages/layout.go", `package pages
+
+import "github.com/cstone-io/twine/pkg/middleware"
+
+func Layout() middleware.Middleware { return nil }
+`)
+	createTestRoute(t, projectDir, "pages/dashboard/page.go", `package dashboard
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/middleware.go", `package api
+
+import "github.com/cstone-io/twine/pkg/middleware"
+
+func Middleware() []middleware.Middleware { return nil }
+`)
+	createTestRoute(t, projectDir, "api/error.go", `package api
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func Error(k *kit.Kit, err error) error { return err }
+`)
+	createTestRoute(t, projectDir, "api/posts/[id]/route.go", `package id
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+func POST(k *kit.Kit) error { return nil }
+`)
+
+	return projectDir
+}
+
+// executeRoutesList runs 'twine routes list' in projectDir and returns its output
+func executeRoutesList(t *testing.T, projectDir string, args ...string) (string, error) {
+	t.Helper()
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	var out bytes.Buffer
+	cmd := newRoutesListCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// TestListRoutes tests flattening routes with their middleware chains
+func TestListRoutes(t *testing.T) {
+	projectDir := setupRoutesListProject(t)
+
+	root, err := routing.ScanRoutes(filepath.Join(projectDir, "app"))
+	require.NoError(t, err)
+
+	entries := listRoutes(root)
+	require.Len(t, entries, 3)
+
+	byRoute := make(map[string]routeEntry)
+	for _, e := range entries {
+		byRoute[e.Method+" "+e.Path] = e
+	}
+
+	dashboard := byRoute["GET /dashboard"]
+	assert.Equal(t, "page", dashboard.Kind)
+	assert.Equal(t, "app/pages/dashboard/page.go", dashboard.File)
+	assert.Equal(t, []string{"app/pages/layout.go"}, dashboard.Middleware)
+
+	post := byRoute["POST /api/posts/{id}"]
+	assert.Equal(t, "api", post.Kind)
+	assert.Equal(t, "app/api/posts/[id]/route.go", post.File)
+	assert.Equal(t, []string{"app/api/middleware.go", "app/api/error.go"}, post.Middleware)
+}
+
+// TestParseRouteFilters tests method and path filters
+func TestParseRouteFilters(t *testing.T) {
+	get := routeEntry{Method: "GET", Path: "/api/posts/{id}"}
+	post := routeEntry{Method: "POST", Path: "/dashboard"}
+
+	tests := []struct {
+		filters []string
+		get     bool
+		post    bool
+	}{
+		{nil, true, true},
+		{[]string{"method=post"}, false, true},
+		{[]string{"method=GET,POST"}, true, true},
+		{[]string{"path=/api/*"}, true, false},
+		{[]string{"path=/api/posts/{id}"}, true, false},
+		{[]string{"path=/dash*"}, false, true},
+		{[]string{"path=/api"}, false, false},
+		{[]string{"path=/api/*", "method=POST"}, false, false},
+	}
+
+	for _, tt := range tests {
+		match, err := parseRouteFilters(tt.filters)
+		require.NoError(t, err)
+		assert.Equal(t, tt.get, match(get), "%v matching GET", tt.filters)
+		assert.Equal(t, tt.post, match(post), "%v matching POST", tt.filters)
+	}
+
+	_, err := parseRouteFilters([]string{"file=page.go"})
+	assert.EqualError(t, err, `unknown --filter key "file" (expected method or path)`)
+
+	_, err = parseRouteFilters([]string{"method"})
+	assert.Error(t, err)
+}
+
+// TestRoutesListCommand_JSON tests machine-readable output with filters
+func TestRoutesListCommand_JSON(t *testing.T) {
+	projectDir := setupRoutesListProject(t)
+
+	out, err := executeRoutesList(t, projectDir, "--json", "--filter", "path=/api/*")
+	require.NoError(t, err)
+
+	var result struct {
+		Routes []routeEntry `json:"routes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Routes, 2)
+	assert.Equal(t, "/api/posts/{id}", result.Routes[0].Path)
+	assert.Equal(t, []string{"app/api/middleware.go", "app/api/error.go"}, result.Routes[0].Middleware)
+
+	out, err = executeRoutesList(t, projectDir, "--json", "--filter", "method=DELETE")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"routes": []}`, out)
+}
+
+// TestRoutesListCommand_Markdown tests the Markdown table with the middleware column
+func TestRoutesListCommand_Markdown(t *testing.T) {
+	projectDir := setupRoutesListProject(t)
+
+	out, err := executeRoutesList(t, projectDir, "--markdown", "--middleware", "--filter", "path=/dashboard")
+	require.NoError(t, err)
+	assert.Equal(t, "| Method | Path | File | Middleware |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| GET | `/dashboard` | `app/pages/dashboard/page.go` | `app/pages/layout.go` |\n", out)
+}
+
+// TestRoutesListCommand_Text tests the filtered text table
+func TestRoutesListCommand_Text(t *testing.T) {
+	projectDir := setupRoutesListProject(t)
+
+	out, err := executeRoutesList(t, projectDir, "--filter", "method=POST", "--middleware")
+	require.NoError(t, err)
+	assert.Contains(t, out, "POST   /api/posts/{id}   → app/api/posts/[id]/route.go   app/api/middleware.go → app/api/error.go")
+	assert.NotContains(t, out, "/dashboard")
+
+	out, err = executeRoutesList(t, projectDir, "--filter", "method=PATCH")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No routes found")
+
+	_, err = executeRoutesList(t, projectDir, "--json", "--markdown")
+	assert.EqualError(t, err, "--json and --markdown cannot be used together")
+}
+
+// setupVersionedProject creates app/api/v1 and app/api/v2, where v2 drops
+// DELETE /users/{id} and adds /orders
+func setupVersionedProject(t *testing.T) string {
+	t.Helper()
+	projectDir := setupTestProject(t)
+
+	createTestRoute(t, projectDir, "api/v1/users/route.go", `package users
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/v1/users/[id]/route.go", `package id
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+func DELETE(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/v2/users/route.go", `package users
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/v2/users/[id]/route.go", `package id
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/v2/orders/route.go", `package orders
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func POST(k *kit.Kit) error { return nil }
+`)
+
+	return projectDir
+}
+
+// TestRoutesListCommand_Diff tests comparing two API versions
+func TestRoutesListCommand_Diff(t *testing.T) {
+	projectDir := setupVersionedProject(t)
+
+	out, err := executeRoutesList(t, projectDir, "--diff", "v1", "v2")
+	require.NoError(t, err)
+	assert.Contains(t, out, "API v1 → v2: 1 added, 1 removed, 2 unchanged")
+	assert.Contains(t, out, "-  DELETE   /api/v1/users/{id}   → app/api/v1/users/[id]/route.go")
+	assert.Contains(t, out, "+  POST     /api/v2/orders       → app/api/v2/orders/route.go")
+
+	out, err = executeRoutesList(t, projectDir, "--diff", "v1", "v2", "--json", "--filter", "method=GET")
+	require.NoError(t, err)
+	var report routeDiff
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, "v1", report.From)
+	assert.Empty(t, report.Added)
+	assert.Empty(t, report.Removed)
+	require.Len(t, report.Unchanged, 2)
+	assert.Equal(t, "v2", report.Unchanged[0].Version)
+
+	_, err = executeRoutesList(t, projectDir, "--diff", "v1", "v3")
+	assert.EqualError(t, err, "API version v3 not found in app/api")
+
+	_, err = executeRoutesList(t, projectDir, "--diff", "v1")
+	assert.EqualError(t, err, "--diff takes two API versions, e.g. --diff v1 v2")
+
+	_, err = executeRoutesList(t, projectDir, "v1")
+	assert.Error(t, err)
+}
+
+// TestRoutesGenerateCommand_APIVersions tests the routes.api settings in twine.yaml
+func TestRoutesGenerateCommand_APIVersions(t *testing.T) {
+	projectDir := setupVersionedProject(t)
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "twine.yaml"), []byte(`routes:
+  api:
+    default_version: v2
+    versions:
+      v1:
+        deprecated: 2026-06-01
+        sunset: 2027-01-01
+        link: https://example.com/migrate
+`), 0644))
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	cmd := newRoutesGenerateCommand()
+	cmd.SetArgs([]string{"--format", "json"})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	content, err := os.ReadFile(filepath.Join(projectDir, "app", "routes.gen.go"))
+	require.NoError(t, err)
+	code := string(content)
+	assert.Contains(t, code, `r.Get("/api/v2/users/{id}", `)
+	assert.Regexp(t, `r\.Get\("/api/users/\{id\}", \w*api_v2_users_id_param\.GET\)`, code)
+	assert.Contains(t, code, `middleware.Deprecation("2026-06-01", middleware.WithSunset("2027-01-01"), middleware.WithDeprecationLink("https://example.com/migrate"))`)
+	assert.NotContains(t, code, `"/api/users/{id}", applyMiddleware`)
+}
+
+// TestRoutesGenerateCommand_WithTests tests writing twinetest skeletons
+func TestRoutesGenerateCommand_WithTests(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	createTestRoute(t, projectDir, "pages/contact/page.go", `package contact
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func GET(k *kit.Kit) error { return nil }
+func POST(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/posts/[id]/route.go", `package id
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func PUT(k *kit.Kit) error { return nil }
+func DELETE(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/posts/route.go", `package posts
+
+import "github.com/cstone-io/twine/pkg/kit"
+
+func POST(k *kit.Kit) error { return nil }
+`)
+	createTestRoute(t, projectDir, "api/posts/route_test.go", "package posts_test\n")
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	var out bytes.Buffer
+	cmd := newRoutesGenerateCommand()
+	cmd.SetArgs([]string{"--with-tests"})
+	cmd.SetOut(&out)
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "🧪 Created app/pages/contact/page_test.go")
+	assert.NotContains(t, out.String(), "app/api/posts/route_test.go")
+
+	page, err := os.ReadFile(filepath.Join(projectDir, "app/pages/contact/page_test.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "package contact_test")
+	assert.Contains(t, string(page), `"github.com/test/project/app"`)
+	assert.Contains(t, string(page), "func TestGET(t *testing.T) {")
+	assert.Contains(t, string(page), "ta := twinetest.New(t, twinetest.Routes(app.RegisterRoutes))")
+	assert.Contains(t, string(page), "func TestGET(t *testing.T) {\n\tta := ")
+	assert.Contains(t, string(page), `ta.Get("/contact").AssertStatus(http.StatusOK)`)
+	assert.Contains(t, string(page), "func TestPOST(t *testing.T) {\n\tt.Skip(\"TODO: assert the expected response\")")
+	assert.Contains(t, string(page), `ta.PostForm("/contact", url.Values{}).AssertStatus(http.StatusSeeOther)`)
+	assert.NotContains(t, string(page), "replace")
+
+	route, err := os.ReadFile(filepath.Join(projectDir, "app/api/posts/[id]/route_test.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(route), "package id_test")
+	assert.Contains(t, string(route), "// TODO: replace {id} in the path")
+	assert.Equal(t, 2, strings.Count(string(route), `t.Skip("TODO: assert the expected response")`), "tests of routes with parameters are skipped")
+	assert.Contains(t, string(route), `ta.Request("PUT", "/api/posts/{id}").JSON(map[string]any{}).Do().AssertStatus(http.StatusOK)`)
+	assert.Contains(t, string(route), `ta.Delete("/api/posts/{id}").AssertStatus(http.StatusNoContent)`)
+	assert.NotContains(t, string(route), "net/url")
+
+	// Existing tests are left alone
+	existing, err := os.ReadFile(filepath.Join(projectDir, "app/api/posts/route_test.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package posts_test\n", string(existing))
+
+	cmd = newRoutesGenerateCommand()
+	cmd.SetArgs([]string{"--with-tests", "--check"})
+	cmd.SetOut(&bytes.Buffer{})
+	assert.EqualError(t, cmd.Execute(), "--with-tests cannot be used with --dry-run or --check")
+}
+
+// TestNewRouteTestConfig tests the requests and statuses of test skeletons
+func TestNewRouteTestConfig(t *testing.T) {
+	route := &routing.RouteNode{
+		Path:        "app/api/v1/orders",
+		URLSegment:  "orders",
+		Parent:      &routing.RouteNode{URLSegment: "v1", Parent: &routing.RouteNode{URLSegment: "api"}},
+		IsAPI:       true,
+		PackageName: "orders",
+		Methods:     []string{"GET", "POST", "PATCH"},
+	}
+
+	config := newRouteTestConfig(route, "github.com/test/project")
+	assert.Equal(t, "/api/v1/orders", config.Pattern)
+	assert.Empty(t, config.Params)
+	assert.False(t, config.Form)
+	assert.Equal(t, []routeTestMethod{
+		{Method: "GET", Call: `Get("/api/v1/orders")`, Status: "StatusOK", Skip: true},
+		{Method: "POST", Call: `PostJSON("/api/v1/orders", map[string]any{})`, Status: "StatusCreated", Skip: true},
+		{Method: "PATCH", Call: `Request("PATCH", "/api/v1/orders").JSON(map[string]any{}).Do()`, Status: "StatusOK", Skip: true},
+	}, config.Methods)
+
+	route = &routing.RouteNode{URLSegment: "about", IsPage: true, PackageName: "about", Methods: []string{"GET"}}
+	config = newRouteTestConfig(route, "github.com/test/project")
+	assert.False(t, config.Methods[0].Skip, "GET tests of pages without parameters run")
+}
@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// starterManifestFile describes a starter template, at the template's root
+const starterManifestFile = "twine-template.yaml"
+
+// starterManifest is the optional manifest of a starter template
+type starterManifest struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Variables   []starterVariable `yaml:"variables"`
+	// Exclude lists slash-separated glob patterns of files not to copy
+	Exclude []string `yaml:"exclude"`
+}
+
+// starterVariable is a value a starter template asks for with --var
+type starterVariable struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	Required    bool   `yaml:"required"`
+}
+
+// fetchStarter returns a directory holding the starter template at source
+// and a function that removes it once the project is created. A source that
+// exists on disk is used as is; anything else is cloned with git.
+func fetchStarter(source string) (string, func(), error) {
+	if info, err := os.Stat(source); err == nil {
+		if !info.IsDir() {
+			return "", nil, fmt.Errorf("template %s is not a directory", source)
+		}
+		dir, err := filepath.Abs(source)
+		return dir, func() {}, err
+	}
+
+	url, ref := parseStarterSource(source)
+
+	dir, err := os.MkdirTemp("", "twine-template-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	args := []string{"clone", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	clone := exec.Command("git", append(args, url, dir)...)
+	if output, err := clone.CombinedOutput(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("cloning template %s: %s", source, strings.TrimSpace(string(output)))
+	}
+
+	return dir, cleanup, nil
+}
+
+// parseStarterSource splits a template source into a git URL and an
+// optional ref given after @. Sources without a scheme, such as
+// github.com/org/starter, are cloned over https.
+func parseStarterSource(source string) (url, ref string) {
+	start := strings.LastIndex(source, "/")
+	if strings.HasPrefix(source, "git@") && start < len("git@") {
+		start = len("git@")
+	}
+	if i := strings.LastIndex(source, "@"); i > start {
+		source, ref = source[:i], source[i+1:]
+	}
+
+	if strings.Contains(source, "://") || strings.HasPrefix(source, "git@") {
+		return source, ref
+	}
+	return "https://" + source, ref
+}
+
+// loadStarterManifest reads the manifest of the template in dir. A template
+// without one has no variables.
+func loadStarterManifest(dir string) (*starterManifest, error) {
+	manifest := &starterManifest{}
+
+	data, err := os.ReadFile(filepath.Join(dir, starterManifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", starterManifestFile, err)
+	}
+
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", starterManifestFile, err)
+	}
+
+	for _, v := range manifest.Variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("parsing %s: variable without a name", starterManifestFile)
+		}
+	}
+
+	return manifest, nil
+}
+
+// resolveVars applies --var values over the manifest's defaults. Unknown
+// variables and required variables without a value are errors.
+func (m *starterManifest) resolveVars(given map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(m.Variables))
+	declared := make(map[string]bool, len(m.Variables))
+	missing := []string{}
+
+	for _, v := range m.Variables {
+		declared[v.Name] = true
+		value, ok := given[v.Name]
+		if !ok {
+			value = v.Default
+		}
+		if value == "" && v.Required {
+			hint := v.Name
+			if v.Description != "" {
+				hint += " (" + v.Description + ")"
+			}
+			missing = append(missing, hint)
+		}
+		vars[v.Name] = value
+	}
+
+	unknown := []string{}
+	for name := range given {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown template variable(s): %s", strings.Join(unknown, ", "))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required template variable(s), set them with --var name=value: %s", strings.Join(missing, ", "))
+	}
+
+	return vars, nil
+}
+
+// parseVarFlags parses --var name=value flags
+func parseVarFlags(flags []string) (map[string]string, error) {
+	vars := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected name=value", flag)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// renderStarter writes the starter template in dir into projectPath. Files
+// ending in .tmpl are rendered with config and lose the suffix, other files
+// are copied as is, and {{ }} in file and directory names is rendered too.
+func renderStarter(dir, projectPath string, manifest *starterManifest, config ProjectConfig) error {
+	return filepath.Walk(dir, func(src string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, src)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == ".git" || rel == starterManifestFile || manifest.excludes(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		destRel, err := renderStarterString(rel, rel, config)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(projectPath, filepath.FromSlash(destRel))
+
+		if info.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(dest, ".tmpl") {
+			dest = strings.TrimSuffix(dest, ".tmpl")
+			rendered, err := renderStarterString(rel, string(content), config)
+			if err != nil {
+				return err
+			}
+			content = []byte(rendered)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(dest, content, info.Mode().Perm())
+	})
+}
+
+// renderStarterString renders text from the template file name
+func renderStarterString(name, text string, config ProjectConfig) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, config); err != nil {
+		return "", fmt.Errorf("rendering template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// excludes reports whether the manifest excludes the file at rel
+func (m *starterManifest) excludes(rel string) bool {
+	for _, pattern := range m.Exclude {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
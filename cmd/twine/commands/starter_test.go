@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const starterTestManifest = `name: Team starter
+variables:
+  - name: Team
+    description: Owning team
+    required: true
+  - name: Region
+    default: eu-west-1
+exclude:
+  - docs/*
+`
+
+// setupTestStarter creates a local starter template
+func setupTestStarter(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestFile(t, dir, starterManifestFile, starterTestManifest)
+	writeTestFile(t, dir, "go.mod.tmpl", "module {{.ModulePath}}\n")
+	writeTestFile(t, dir, "README.md.tmpl", "# {{.ProjectName}}\n\nOwned by {{.Vars.Team}} in {{.Vars.Region}}\n")
+	writeTestFile(t, dir, "cmd/{{.ProjectName}}/main.go", "package main\n")
+	writeTestFile(t, dir, "templates/page.html", `{{define "page"}}{{.Title}}{{end}}`)
+	writeTestFile(t, dir, "docs/notes.md", "internal notes\n")
+	writeTestFile(t, dir, ".git/HEAD", "ref: refs/heads/main\n")
+	return dir
+}
+
+// TestParseStarterSource tests splitting template sources into URL and ref
+func TestParseStarterSource(t *testing.T) {
+	tests := []struct {
+		source string
+		url    string
+		ref    string
+	}{
+		{"github.com/org/starter", "https://github.com/org/starter", ""},
+		{"github.com/org/starter@v2", "https://github.com/org/starter", "v2"},
+		{"https://gitlab.com/org/starter.git@main", "https://gitlab.com/org/starter.git", "main"},
+		{"git@github.com:org/starter.git", "git@github.com:org/starter.git", ""},
+		{"git@github.com:org/starter.git@v1.2.0", "git@github.com:org/starter.git", "v1.2.0"},
+		{"file:///tmp/starter", "file:///tmp/starter", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			url, ref := parseStarterSource(tt.source)
+			assert.Equal(t, tt.url, url)
+			assert.Equal(t, tt.ref, ref)
+		})
+	}
+}
+
+// TestStarterManifest_ResolveVars tests defaults, required and unknown variables
+func TestStarterManifest_ResolveVars(t *testing.T) {
+	manifest, err := loadStarterManifest(setupTestStarter(t))
+	require.NoError(t, err)
+	assert.Equal(t, "Team starter", manifest.Name)
+
+	vars, err := manifest.resolveVars(map[string]string{"Team": "payments"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Team": "payments", "Region": "eu-west-1"}, vars)
+
+	_, err = manifest.resolveVars(map[string]string{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing required template variable(s)")
+	assert.Contains(t, err.Error(), "Team (Owning team)")
+
+	_, err = manifest.resolveVars(map[string]string{"Team": "payments", "Colour": "blue"})
+	require.Error(t, err)
+	assert.Equal(t, "unknown template variable(s): Colour", err.Error())
+
+	manifest, err = loadStarterManifest(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Variables)
+}
+
+// TestParseVarFlags tests parsing --var name=value flags
+func TestParseVarFlags(t *testing.T) {
+	vars, err := parseVarFlags([]string{"Team=payments", "Greeting=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Team": "payments", "Greeting": "a=b"}, vars)
+
+	_, err = parseVarFlags([]string{"Team"})
+	assert.Error(t, err)
+}
+
+// TestRenderStarter tests rendering, copying and excluding template files
+func TestRenderStarter(t *testing.T) {
+	dir := setupTestStarter(t)
+	manifest, err := loadStarterManifest(dir)
+	require.NoError(t, err)
+
+	config := ProjectConfig{
+		ProjectName: "my-app",
+		ModulePath:  "github.com/test/my-app",
+		Vars:        map[string]string{"Team": "payments", "Region": "us-east-1"},
+	}
+	projectPath := t.TempDir()
+	require.NoError(t, renderStarter(dir, projectPath, manifest, config))
+
+	content, err := os.ReadFile(filepath.Join(projectPath, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module github.com/test/my-app\n", string(content))
+
+	content, err = os.ReadFile(filepath.Join(projectPath, "README.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Owned by payments in us-east-1")
+
+	// Files without the .tmpl suffix are copied untouched
+	content, err = os.ReadFile(filepath.Join(projectPath, "templates", "page.html"))
+	require.NoError(t, err)
+	assert.Equal(t, `{{define "page"}}{{.Title}}{{end}}`, string(content))
+
+	assert.FileExists(t, filepath.Join(projectPath, "cmd", "my-app", "main.go"))
+	assert.NoFileExists(t, filepath.Join(projectPath, "go.mod.tmpl"))
+	assert.NoFileExists(t, filepath.Join(projectPath, starterManifestFile))
+	assert.NoDirExists(t, filepath.Join(projectPath, ".git"))
+	assert.NoFileExists(t, filepath.Join(projectPath, "docs", "notes.md"))
+}
+
+// TestRenderStarter_UndefinedVariable tests that unknown template fields fail
+func TestRenderStarter_UndefinedVariable(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "README.md.tmpl", "{{.Vars.Missing}}")
+
+	err := renderStarter(dir, t.TempDir(), &starterManifest{}, ProjectConfig{Vars: map[string]string{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "README.md.tmpl")
+}
+
+// TestFetchStarter_Git tests cloning a template from a git repository
+func TestFetchStarter_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	writeTestFile(t, repo, "go.mod.tmpl", "module {{.ModulePath}}\n")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-qm", "initial"},
+		{"tag", "v1"},
+	} {
+		git := exec.Command("git", args...)
+		git.Dir = repo
+		output, err := git.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	dir, cleanup, err := fetchStarter("file://" + repo + "@v1")
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "go.mod.tmpl"))
+	cleanup()
+	assert.NoDirExists(t, dir)
+
+	_, _, err = fetchStarter("file://" + filepath.Join(repo, "missing"))
+	assert.Error(t, err)
+}
+
+// TestNewInitCommand_TemplateFlags tests validation of the starter flags
+func TestNewInitCommand_TemplateFlags(t *testing.T) {
+	cmd := NewInitCommand()
+	assert.NotNil(t, cmd.Flags().Lookup("template"))
+	assert.NotNil(t, cmd.Flags().Lookup("var"))
+
+	cmd.SetArgs([]string{"my-app", "--var", "Team=payments"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--var requires --template")
+
+	cmd = NewInitCommand()
+	cmd.SetArgs([]string{"my-app", "--template", "github.com/org/starter", "--no-examples"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--no-examples")
+}
@@ -0,0 +1,352 @@
+package commands
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template/parse"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	twinetemplate "github.com/cstone-io/twine/pkg/template"
+)
+
+// NewTemplatesCommand creates the templates command
+func NewTemplatesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Check templates",
+	}
+
+	cmd.AddCommand(newTemplatesCheckCommand())
+
+	return cmd
+}
+
+func newTemplatesCheckCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Check that templates parse and components get the props they use",
+		Long: `Parse every template under templates/ and check that each {{template}} and
+{{component}} names a defined template, then check each component under
+templates/components/ against its props struct.
+
+A component named user-card takes the props struct UserCardProps, found in
+the project's Go files. Fields and methods the component reads from dot or
+$ that the struct lacks are reported, since html/template would only fail
+on them at render time. Components without a props struct are only parsed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("getting current directory: %w", err)
+			}
+			cmd.SilenceUsage = true
+
+			out := cmd.OutOrStdout()
+			failed := 0
+			for _, check := range []doctorCheck{checkTemplates, checkComponentProps} {
+				result := check(cwd, nil)
+				writeDoctorResult(out, result)
+				if result.Status == doctorFail {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d check(s) failed", failed)
+			}
+			return nil
+		},
+	}
+}
+
+// checkComponentProps reports fields components under templates/components
+// use that their <Name>Props struct lacks
+func checkComponentProps(root string, env map[string]string) doctorResult {
+	result := doctorResult{Name: "Component props"}
+
+	dir := filepath.Join(root, "templates", "components")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		result.Status = doctorSkip
+		result.Message = "no templates/components/ directory"
+		return result
+	}
+
+	structs := findPropsStructs(root)
+	problems := []string{}
+	components, checked := 0, 0
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".html" {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		// checkTemplates reports files that don't parse
+		tmpl, err := template.New(rel).Funcs(twinetemplate.FuncMap()).Parse(string(content))
+		if err != nil {
+			return nil
+		}
+
+		for _, t := range tmpl.Templates() {
+			if t.Tree == nil || t.Name() == rel {
+				continue
+			}
+			components++
+			name := propsStructName(t.Name())
+			fields, ok := structs.fields(name)
+			if !ok {
+				continue
+			}
+			checked++
+			for _, field := range dotFields(t.Tree.Root) {
+				if !fields[field] {
+					problems = append(problems, fmt.Sprintf("%s: component %q uses .%s, which %s doesn't have", rel, t.Name(), field, name))
+				}
+			}
+		}
+		return nil
+	})
+
+	sort.Strings(problems)
+	switch {
+	case len(problems) > 0:
+		result.Status = doctorFail
+		result.Message = strings.Join(problems, "\n")
+		result.Fix = "Add the fields to the props structs, or fix the components; rendering them fails until then"
+	default:
+		result.Message = fmt.Sprintf("%d of %d component(s) checked against a props struct", checked, components)
+	}
+	return result
+}
+
+// propsStructName returns the props struct of a component:
+// UserCardProps for user-card or user_card
+func propsStructName(component string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range component {
+		if r == '-' || r == '_' || r == '.' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + "Props"
+}
+
+// dotFields returns the fields and methods read from the template's data
+// under node: from dot outside range and with, and from $ anywhere
+func dotFields(node parse.Node) []string {
+	seen := map[string]bool{}
+	fields := []string{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			fields = append(fields, name)
+		}
+	}
+
+	var walk func(n parse.Node, dot bool)
+	walk = func(n parse.Node, dot bool) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, dot)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, dot)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, dot)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, dot)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, dot)
+		case *parse.FieldNode:
+			if dot {
+				add(n.Ident[0])
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				add(n.Ident[1])
+			}
+		case *parse.TemplateNode:
+			walk(n.Pipe, dot)
+		case *parse.IfNode:
+			walk(n.Pipe, dot)
+			walk(n.List, dot)
+			walk(n.ElseList, dot)
+		case *parse.RangeNode:
+			walk(n.Pipe, dot)
+			walk(n.List, false)
+			walk(n.ElseList, dot)
+		case *parse.WithNode:
+			walk(n.Pipe, dot)
+			walk(n.List, false)
+			walk(n.ElseList, dot)
+		}
+	}
+	walk(node, true)
+
+	return fields
+}
+
+// goStruct is a struct type declared in the project
+type goStruct struct {
+	fields   map[string]bool // fields, embedded types and methods
+	embedded []string        // names of embedded types, whose fields are promoted
+}
+
+// goStructs are the project's struct types by name. A name declared in
+// several packages is nil, since which one a component takes is unknown.
+type goStructs map[string]*goStruct
+
+// fields returns the fields and methods of the named struct, including
+// those promoted from embedded structs. ok is false when the struct or one
+// it embeds is unknown, so its fields can't all be listed.
+func (s goStructs) fields(name string) (map[string]bool, bool) {
+	st := s[name]
+	if st == nil {
+		return nil, false
+	}
+
+	fields := map[string]bool{}
+	for field := range st.fields {
+		fields[field] = true
+	}
+	for _, embedded := range st.embedded {
+		promoted, ok := s.fields(embedded)
+		if !ok {
+			return nil, false
+		}
+		for field := range promoted {
+			fields[field] = true
+		}
+	}
+	return fields, true
+}
+
+// findPropsStructs parses the project's Go files for struct types and their
+// methods, skipping tests, build output and dependencies
+func findPropsStructs(root string) goStructs {
+	structs := goStructs{}
+	declared := map[string]int{}
+	methods := map[string][]string{}
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && isIgnoredDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					structType, ok := typeSpec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					declared[typeSpec.Name.Name]++
+					structs[typeSpec.Name.Name] = newGoStruct(structType)
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil && len(decl.Recv.List) == 1 {
+					if recv := typeName(decl.Recv.List[0].Type); recv != "" {
+						methods[recv] = append(methods[recv], decl.Name.Name)
+					}
+				}
+			}
+		}
+		return nil
+	})
+
+	for name, count := range declared {
+		if count > 1 {
+			structs[name] = nil
+			continue
+		}
+		for _, method := range methods[name] {
+			structs[name].fields[method] = true
+		}
+	}
+	return structs
+}
+
+// newGoStruct lists the fields of a struct type
+func newGoStruct(structType *ast.StructType) *goStruct {
+	st := &goStruct{fields: map[string]bool{}}
+	for _, field := range structType.Fields.List {
+		if len(field.Names) == 0 {
+			name := typeName(field.Type)
+			st.fields[name] = true
+			st.embedded = append(st.embedded, name)
+			continue
+		}
+		for _, name := range field.Names {
+			st.fields[name.Name] = true
+		}
+	}
+	return st
+}
+
+// typeName returns the name of a type expression such as T, *T, pkg.T or
+// T[P], without its package
+func typeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.StarExpr:
+		return typeName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.IndexExpr:
+		return typeName(expr.X)
+	case *ast.IndexListExpr:
+		return typeName(expr.X)
+	}
+	return ""
+}
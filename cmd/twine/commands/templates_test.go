@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPropsStructName tests naming components' props structs
+func TestPropsStructName(t *testing.T) {
+	assert.Equal(t, "UserCardProps", propsStructName("user-card"))
+	assert.Equal(t, "PostsFormProps", propsStructName("posts_form"))
+	assert.Equal(t, "ButtonProps", propsStructName("button"))
+}
+
+// TestCheckComponentProps tests comparing components with their props
+// structs
+func TestCheckComponentProps(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	result := checkComponentProps(projectDir, nil)
+	assert.Equal(t, doctorSkip, result.Status)
+
+	writeTestFile(t, projectDir, "components/props.go", `package components
+
+type Base struct{ ID string }
+
+type UserCardProps struct {
+	Base
+	Name  string
+	Roles []string
+}
+
+func (p UserCardProps) Initials() string { return p.Name[:1] }
+
+type BadgeProps struct {
+	External
+	Label string
+}
+`)
+	writeTestFile(t, projectDir, "templates/components/user-card.html",
+		`{{define "user-card"}}<div id="{{.ID}}">{{.Initials}} {{.Name}}{{range .Roles}}{{.Title}} {{$.Name}}{{end}}</div>{{end}}`)
+	writeTestFile(t, projectDir, "templates/components/badge.html", `{{define "badge"}}{{.Anything}}{{end}}`)
+	writeTestFile(t, projectDir, "templates/components/button.html", `{{define "button"}}{{.Text}}{{end}}`)
+
+	result = checkComponentProps(projectDir, nil)
+	assert.Equal(t, doctorOK, result.Status, result.Message)
+	assert.Equal(t, "1 of 3 component(s) checked against a props struct", result.Message,
+		"fields inside range, of unknown embedded types and without props structs aren't checked")
+
+	writeTestFile(t, projectDir, "templates/components/user-card.html",
+		`{{define "user-card"}}{{if .Avatar}}<img src="{{.Avatar}}">{{end}}{{range .Roles}}{{$.Email}}{{end}}{{end}}`)
+	result = checkComponentProps(projectDir, nil)
+	assert.Equal(t, doctorFail, result.Status)
+	assert.Equal(t, `templates/components/user-card.html: component "user-card" uses .Avatar, which UserCardProps doesn't have
+templates/components/user-card.html: component "user-card" uses .Email, which UserCardProps doesn't have`, result.Message)
+}
+
+// TestTemplatesCheckCommand tests failing for template problems
+func TestTemplatesCheckCommand(t *testing.T) {
+	projectDir := setupTestProject(t)
+	writeTestFile(t, projectDir, "templates/pages/index.html", `{{define "index"}}{{component "card" .}}{{end}}`)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	var out bytes.Buffer
+	cmd := NewTemplatesCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"check"})
+	require.Error(t, cmd.Execute())
+	assert.Contains(t, out.String(), `references undefined template "card"`)
+
+	writeTestFile(t, projectDir, "templates/components/card.html", `{{define "card"}}{{.Title}}{{end}}`)
+	out.Reset()
+	cmd = NewTemplatesCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"check"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "0 of 1 component(s) checked")
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// NewTestCommand creates the test command
+func NewTestCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "test [go test flags] [packages]",
+		Short: "Regenerate routes and run the project's tests",
+		Long: `Regenerate app/routes.gen.go, so tests see the current routes, then run
+go test. Arguments are passed to go test unchanged; without packages, every
+package in the project is tested. Tests run with TWINE_ENV=test unless the
+environment or .env sets it.
+
+Use pkg/twinetest to test routes in memory:
+
+  ta := twinetest.New(t, twinetest.Routes(app.RegisterRoutes))
+  ta.Get("/").AssertStatus(200).AssertTemplateUsed("index")`,
+		Example: "  twine test\n  twine test -run TestHome -v ./app/...",
+		// Flags belong to go test
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, arg := range args {
+				if arg == "-h" || arg == "--help" {
+					return cmd.Help()
+				}
+			}
+			cmd.SilenceUsage = true
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("getting current directory: %w", err)
+			}
+
+			appDir := filepath.Join(cwd, "app")
+			if dirExists(appDir) {
+				if err := generateRoutes(cwd, appDir); err != nil {
+					return err
+				}
+			}
+
+			run := exec.Command("go", goTestArgs(args)...)
+			run.Dir = cwd
+			run.Env = withTwineEnv(cwd, config.EnvTest, os.Environ())
+			run.Stdin = os.Stdin
+			run.Stdout = cmd.OutOrStdout()
+			run.Stderr = cmd.ErrOrStderr()
+			if err := run.Run(); err != nil {
+				return fmt.Errorf("tests failed: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// goTestArgs returns the go command arguments for twine test's arguments,
+// testing every package when none are named
+func goTestArgs(args []string) []string {
+	hasPackage := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) == 0 || arg[0] != '-' {
+			hasPackage = true
+			break
+		}
+		// Flags like -run take their value as the next argument
+		if goTestValueFlags[arg] {
+			i++
+		}
+	}
+
+	goArgs := append([]string{"test"}, args...)
+	if !hasPackage {
+		goArgs = append(goArgs, "./...")
+	}
+	return goArgs
+}
+
+// goTestValueFlags are the common go test flags whose value can be passed as
+// a separate argument
+var goTestValueFlags = map[string]bool{
+	"-run": true, "-skip": true, "-bench": true, "-count": true,
+	"-timeout": true, "-tags": true, "-cpu": true, "-parallel": true,
+	"-coverprofile": true, "-coverpkg": true, "-covermode": true, "-p": true,
+}
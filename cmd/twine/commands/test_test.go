@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGoTestArgs tests defaulting to every package
+func TestGoTestArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"test", "./..."}},
+		{[]string{"-v"}, []string{"test", "-v", "./..."}},
+		{[]string{"-run", "TestHome"}, []string{"test", "-run", "TestHome", "./..."}},
+		{[]string{"-run=TestHome", "./app/..."}, []string{"test", "-run=TestHome", "./app/..."}},
+		{[]string{"-count", "1", "./app"}, []string{"test", "-count", "1", "./app"}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, goTestArgs(tt.args), "args %v", tt.args)
+	}
+}
+
+// TestTestCommand tests running go test and reporting failures
+func TestTestCommand(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+
+	projectDir := setupTestProject(t)
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "app")))
+	writeTestFile(t, projectDir, "math_test.go", `package project
+
+import "testing"
+
+func TestPass(t *testing.T) {}
+
+func TestFail(t *testing.T) { t.Fatal("boom") }
+`)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	var out bytes.Buffer
+	cmd := NewTestCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"-run", "TestPass", "-v"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "--- PASS: TestPass")
+
+	out.Reset()
+	cmd = NewTestCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tests failed")
+	assert.Contains(t, out.String(), "boom")
+}
@@ -3,7 +3,9 @@ package commands
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"strings"
 	"text/tabwriter"
 
@@ -16,6 +18,8 @@ var (
 	checkOnly     bool
 	listReleases  bool
 	skipConfirm   bool
+	skipVerify    bool
+	skipProject   bool
 )
 
 func NewUpdateCommand() *cobra.Command {
@@ -24,25 +28,35 @@ func NewUpdateCommand() *cobra.Command {
 		Short: "Update twine to the latest version",
 		Long: `Update twine CLI to the latest version or a specific version.
 
+Downloads are checked against the release's checksums.txt before the binary
+is replaced. Run in a project, update also checks the framework version in
+go.mod against the CLI and offers to bump it, printing the changelog between
+the two versions.
+
 Examples:
   twine update                      # Update to latest with confirmation
   twine update --check              # Check if update available
   twine update --list               # List all available releases
-  twine update --version v0.2.0     # Update to specific version
+  twine update --to v0.2.0          # Update to (or pin) a specific version
+  twine update --version v0.2.0     # Same as --to
   twine update --yes                # Update without confirmation`,
 		RunE: runUpdate,
 	}
 
-	cmd.Flags().StringVar(&updateVersion, "version", "", "Update to specific version (e.g., v0.2.0)")
+	cmd.Flags().StringVar(&updateVersion, "to", "", "Update to specific version (e.g., v0.2.0)")
+	cmd.Flags().StringVar(&updateVersion, "version", "", "Alias for --to")
 	cmd.Flags().BoolVar(&checkOnly, "check", false, "Check if update is available without installing")
 	cmd.Flags().BoolVar(&listReleases, "list", false, "List all available releases")
-	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "Skip confirmation prompts")
+	cmd.Flags().BoolVar(&skipVerify, "skip-verify", false, "Install releases that have no checksums.txt")
+	cmd.Flags().BoolVar(&skipProject, "skip-project", false, "Don't check the framework version in go.mod")
 
 	return cmd
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
 	u := updater.NewUpdater()
+	input := bufio.NewReader(os.Stdin)
 
 	// Handle --list flag
 	if listReleases {
@@ -55,7 +69,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Perform the update
-	return handleUpdate(u)
+	return handleUpdate(u, input)
 }
 
 func handleListReleases(u *updater.Updater) error {
@@ -107,14 +121,18 @@ func handleCheckForUpdate(u *updater.Updater) error {
 		fmt.Printf("\nRun 'twine update' to upgrade to %s\n", result.ToVersion)
 	}
 
+	if !skipProject {
+		return checkProjectVersion(u, nil, Version)
+	}
 	return nil
 }
 
-func handleUpdate(u *updater.Updater) error {
+func handleUpdate(u *updater.Updater, input *bufio.Reader) error {
 	fmt.Printf("Current version: %s\n", Version)
 
 	// If no specific version requested, check for latest
-	if updateVersion == "" {
+	target := updater.NormalizeVersion(updateVersion)
+	if target == "" {
 		fmt.Println("Checking for updates...")
 		result, err := u.CheckForUpdate(Version)
 		if err != nil {
@@ -123,31 +141,42 @@ func handleUpdate(u *updater.Updater) error {
 
 		if !updater.IsNewer(Version, result.ToVersion) {
 			fmt.Println(result.Message)
+			if !skipProject {
+				return checkProjectVersion(u, input, Version)
+			}
 			return nil
 		}
 
-		fmt.Printf("Update available: %s → %s\n", Version, result.ToVersion)
+		target = result.ToVersion
+		fmt.Printf("Update available: %s → %s\n", Version, target)
 	} else {
-		fmt.Printf("Target version: %s\n", updateVersion)
+		fmt.Printf("Target version: %s\n", target)
+	}
+
+	// Show what changed, except for dev builds which have no release to start from
+	var releases []updater.GitHubRelease
+	if updater.IsValid(Version) {
+		var err error
+		releases, err = u.GetGitHubClient().ListReleases()
+		if err != nil {
+			fmt.Printf("Could not fetch the changelog: %v\n", err)
+		}
+		printChangelog(os.Stdout, updater.Changelog(releases, Version, target))
 	}
 
 	// Prompt for confirmation unless --yes is set or current version is dev
 	if !skipConfirm {
+		prompt := "\nProceed with update? [y/N]: "
 		if Version == "dev" {
 			fmt.Println("\nCurrent version is 'dev' (development build).")
-			fmt.Print("Update anyway? [y/N]: ")
-		} else {
-			fmt.Print("\nProceed with update? [y/N]: ")
+			prompt = "Update anyway? [y/N]: "
 		}
 
-		reader := bufio.NewReader(os.Stdin)
-		response, err := reader.ReadString('\n')
+		ok, err := confirm(input, prompt)
 		if err != nil {
-			return fmt.Errorf("failed to read input: %w", err)
+			return err
 		}
-
-		response = strings.ToLower(strings.TrimSpace(response))
-		if response != "y" && response != "yes" {
+		if !ok {
 			fmt.Println("Update cancelled")
 			return nil
 		}
@@ -159,6 +188,7 @@ func handleUpdate(u *updater.Updater) error {
 	opts := updater.UpdateOptions{
 		CurrentVersion: Version,
 		TargetVersion:  updateVersion,
+		SkipVerify:     skipVerify,
 	}
 
 	result, err := u.Update(opts)
@@ -184,5 +214,112 @@ func handleUpdate(u *updater.Updater) error {
 		fmt.Println(result.Message)
 	}
 
+	if !skipProject {
+		return checkProjectVersion(u, input, result.ToVersion)
+	}
 	return nil
 }
+
+// checkProjectVersion compares the framework version required by the
+// project in the current directory with the CLI version. With input, it
+// offers to bump an older project to the CLI version; without, it only
+// reports the mismatch.
+func checkProjectVersion(u *updater.Updater, input *bufio.Reader, cliVersion string) error {
+	projectVersion, replaced, err := updater.ProjectVersion(".")
+	if os.IsNotExist(err) || (err == nil && projectVersion == "") {
+		// Not in a twine project
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !updater.IsValid(cliVersion) {
+		// Development builds have no release to compare against
+		return nil
+	}
+
+	fmt.Println()
+	switch {
+	case replaced:
+		fmt.Printf("go.mod replaces %s, leaving it unchanged\n", updater.FrameworkModule)
+		return nil
+	case updater.CompareVersions(projectVersion, cliVersion) == 0:
+		fmt.Printf("✓ Project uses %s %s, matching the CLI\n", updater.FrameworkModule, projectVersion)
+		return nil
+	case updater.IsNewer(cliVersion, projectVersion):
+		fmt.Printf("⚠️  Project uses %s %s, newer than the CLI (%s)\n", updater.FrameworkModule, projectVersion, cliVersion)
+		fmt.Printf("Run 'twine update --to %s' to match it\n", projectVersion)
+		return nil
+	}
+
+	fmt.Printf("Project uses %s %s, the CLI is %s\n", updater.FrameworkModule, projectVersion, cliVersion)
+	if input == nil {
+		fmt.Println("Run 'twine update' in the project to bump it")
+		return nil
+	}
+
+	releases, err := u.GetGitHubClient().ListReleases()
+	if err != nil {
+		fmt.Printf("Could not fetch the changelog: %v\n", err)
+	}
+	printChangelog(os.Stdout, updater.Changelog(releases, projectVersion, cliVersion))
+
+	if !skipConfirm {
+		ok, err := confirm(input, fmt.Sprintf("\nBump %s to %s and run 'go mod tidy'? [y/N]: ", updater.FrameworkModule, cliVersion))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Project unchanged")
+			return nil
+		}
+	}
+
+	return bumpProject(cliVersion)
+}
+
+// bumpProject requires the framework at version and tidies the module
+func bumpProject(version string) error {
+	for _, args := range [][]string{
+		{"get", updater.FrameworkModule + "@" + version},
+		{"mod", "tidy"},
+	} {
+		cmd := exec.Command("go", args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("go %s failed: %w", strings.Join(args, " "), err)
+		}
+	}
+
+	fmt.Printf("✓ Project now uses %s %s\n", updater.FrameworkModule, version)
+	return nil
+}
+
+// printChangelog writes the notes of each release, oldest first
+func printChangelog(w io.Writer, releases []updater.GitHubRelease) {
+	if len(releases) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nChangelog:")
+	for _, release := range releases {
+		fmt.Fprintf(w, "\n## %s (%s)\n", release.TagName, release.PublishedAt.Format("2006-01-02"))
+		if body := strings.TrimSpace(release.Body); body != "" {
+			fmt.Fprintln(w, body)
+		}
+	}
+}
+
+// confirm prints prompt and reports whether the answer was yes
+func confirm(input *bufio.Reader, prompt string) (bool, error) {
+	fmt.Print(prompt)
+
+	response, err := input.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes", nil
+}
@@ -1,9 +1,19 @@
 package commands
 
 import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cstone-io/twine/internal/updater"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewUpdateCommand(t *testing.T) {
@@ -49,6 +59,7 @@ func TestUpdateCommandExamples(t *testing.T) {
 	assert.Contains(t, cmd.Long, "twine update --check")
 	assert.Contains(t, cmd.Long, "twine update --list")
 	assert.Contains(t, cmd.Long, "twine update --version")
+	assert.Contains(t, cmd.Long, "twine update --to")
 	assert.Contains(t, cmd.Long, "twine update --yes")
 }
 
@@ -59,3 +70,71 @@ func TestUpdateCommandValidation(t *testing.T) {
 	assert.False(t, cmd.Args != nil, "Command should not require args")
 	assert.NotNil(t, cmd.RunE, "Command should have RunE handler")
 }
+
+func TestUpdateCommandPinningAndVerifyFlags(t *testing.T) {
+	cmd := NewUpdateCommand()
+
+	for _, name := range []string{"to", "skip-verify", "skip-project"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing --%s", name)
+	}
+
+	// --to and --version set the same target
+	require.NoError(t, cmd.Flags().Parse([]string{"--to", "v0.3.0"}))
+	assert.Equal(t, "v0.3.0", updateVersion)
+	require.NoError(t, cmd.Flags().Parse([]string{"--version", "v0.2.0"}))
+	assert.Equal(t, "v0.2.0", updateVersion)
+	updateVersion = ""
+}
+
+func TestPrintChangelog(t *testing.T) {
+	var out bytes.Buffer
+	printChangelog(&out, []updater.GitHubRelease{
+		{TagName: "v0.2.0", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Body: "### Features\n\n* routes list --json\n"},
+		{TagName: "v0.3.0", PublishedAt: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+	})
+
+	assert.Equal(t, "\nChangelog:\n\n## v0.2.0 (2025-03-01)\n### Features\n\n* routes list --json\n\n## v0.3.0 (2025-04-01)\n", out.String())
+
+	out.Reset()
+	printChangelog(&out, nil)
+	assert.Empty(t, out.String())
+}
+
+func TestCheckProjectVersion(t *testing.T) {
+	projectDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"tag_name": "v0.3.0", "body": "* new things"}, {"tag_name": "v0.1.0"}]`))
+	}))
+	defer server.Close()
+	u := updater.NewUpdaterWithBaseURL(server.URL)
+
+	// Outside a project there is nothing to check
+	assert.NoError(t, checkProjectVersion(u, nil, "v0.3.0"))
+
+	writeGoMod := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(content), 0644))
+	}
+
+	// Matching, newer and replaced versions are only reported
+	writeGoMod("module example.com/app\n\nrequire github.com/cstone-io/twine v0.3.0\n")
+	assert.NoError(t, checkProjectVersion(u, nil, "v0.3.0"))
+	assert.NoError(t, checkProjectVersion(u, nil, "v0.2.0"))
+	assert.NoError(t, checkProjectVersion(u, nil, "dev"))
+	writeGoMod("module example.com/app\n\nrequire github.com/cstone-io/twine v0.1.0\n\nreplace github.com/cstone-io/twine => ../twine\n")
+	assert.NoError(t, checkProjectVersion(u, nil, "v0.3.0"))
+
+	// Declining the bump leaves go.mod unchanged
+	writeGoMod("module example.com/app\n\nrequire github.com/cstone-io/twine v0.1.0\n")
+	skipConfirm = false
+	input := bufio.NewReader(strings.NewReader("n\n"))
+	assert.NoError(t, checkProjectVersion(u, input, "v0.3.0"))
+
+	version, _, err := updater.ProjectVersion(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, "v0.1.0", version)
+}
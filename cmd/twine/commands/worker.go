@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// jobsDir holds the project's job handlers, relative to the project root
+const jobsDir = "jobs"
+
+// workerRunnerConfig is the data passed to internal/scaffold/worker/main.go.tmpl
+type workerRunnerConfig struct {
+	ModulePath string
+}
+
+// NewWorkerCommand creates the worker command
+func NewWorkerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Run background jobs",
+		Long: `Run the background jobs queued with jobs.Enqueue, using the handlers
+registered with jobs.Register in the project's jobs package. The worker
+stops claiming jobs on Ctrl+C or SIGTERM and waits for running jobs to
+finish.
+
+The queue is read from JOBS_DRIVER and the other JOBS_* environment
+variables or .env, the same as the application.`,
+		Example: "  twine worker\n  twine worker failed --limit 10\n  twine worker retry 3f2c...",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorkerCommand(cmd, "work")
+		},
+	}
+
+	var limit int
+	failed := &cobra.Command{
+		Use:   "failed",
+		Short: "List failed jobs, most recently failed first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit < 1 {
+				return fmt.Errorf("--limit must be a positive number")
+			}
+			return runWorkerCommand(cmd, "failed", "--limit", strconv.Itoa(limit))
+		},
+	}
+	failed.Flags().IntVarP(&limit, "limit", "n", 50, "Number of failed jobs to list")
+	cmd.AddCommand(failed)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "retry <id>",
+		Short: "Queue a failed job to run again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorkerCommand(cmd, "retry", args[0])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "discard <id>",
+		Short: "Remove a failed job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorkerCommand(cmd, "discard", args[0])
+		},
+	})
+
+	return cmd
+}
+
+// runWorkerCommand builds a program in the project that imports its jobs
+// package and runs jobs.RunCommand with args, since the handlers are
+// compiled into the project rather than the twine binary
+func runWorkerCommand(cmd *cobra.Command, args ...string) error {
+	cwd, modulePath, err := prepareGenerate(false)
+	if err != nil {
+		return err
+	}
+	if !hasGoFiles(filepath.Join(cwd, jobsDir)) {
+		return fmt.Errorf("%s/ not found. Register job handlers with jobs.Register in a package there", jobsDir)
+	}
+	cmd.SilenceUsage = true
+
+	// A dot directory is skipped by ./... so the runner never shows up in
+	// the project's own builds and tests
+	dir, err := os.MkdirTemp(cwd, ".twine-worker-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := renderScaffold("worker/main.go.tmpl", &workerRunnerConfig{ModulePath: modulePath})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), content, 0644); err != nil {
+		return err
+	}
+
+	run := exec.Command("go", append([]string{"run", "./" + filepath.Base(dir)}, args...)...)
+	run.Dir = cwd
+	run.Stdout = cmd.OutOrStdout()
+	run.Stderr = cmd.ErrOrStderr()
+	if err := run.Run(); err != nil {
+		return fmt.Errorf("twine worker %s failed: %w", args[0], err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewWorkerCommand tests worker command creation
+func TestNewWorkerCommand(t *testing.T) {
+	cmd := NewWorkerCommand()
+
+	assert.Equal(t, "worker", cmd.Use)
+
+	uses := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		uses = append(uses, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"failed", "retry", "discard"}, uses)
+
+	failed, _, err := cmd.Find([]string{"failed"})
+	require.NoError(t, err)
+	assert.Equal(t, "50", failed.Flags().Lookup("limit").DefValue)
+}
+
+// TestWorkerCommand_Errors tests argument and project checks that run
+// before the worker runner is built
+func TestWorkerCommand_Errors(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	tests := []struct {
+		name     string
+		args     []string
+		errorMsg string
+	}{
+		{"no jobs package", []string{}, "jobs/ not found"},
+		{"no jobs package for failed", []string{"failed"}, "jobs/ not found"},
+		{"zero limit", []string{"failed", "--limit", "0"}, "--limit must be a positive number"},
+		{"retry without id", []string{"retry"}, "accepts 1 arg"},
+		{"unexpected args", []string{"now"}, "unknown command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewWorkerCommand()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+// TestRenderWorkerRunner tests the generated worker runner
+func TestRenderWorkerRunner(t *testing.T) {
+	content, err := renderScaffold("worker/main.go.tmpl", &workerRunnerConfig{ModulePath: "github.com/test/project"})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `_ "github.com/test/project/jobs"`)
+	assert.Contains(t, string(content), "jobs.RunCommand(ctx, os.Args[1:], os.Stdout)")
+}
@@ -16,11 +16,19 @@ func main() {
 	}
 
 	// Add subcommands
+	rootCmd.AddCommand(commands.NewBuildCommand())
+	rootCmd.AddCommand(commands.NewDBCommand())
 	rootCmd.AddCommand(commands.NewDevCommand())
+	rootCmd.AddCommand(commands.NewDoctorCommand())
+	rootCmd.AddCommand(commands.NewGenerateCommand())
 	rootCmd.AddCommand(commands.NewInitCommand())
+	rootCmd.AddCommand(commands.NewNewCommand())
 	rootCmd.AddCommand(commands.NewRoutesCommand())
+	rootCmd.AddCommand(commands.NewTemplatesCommand())
+	rootCmd.AddCommand(commands.NewTestCommand())
 	rootCmd.AddCommand(commands.NewUpdateCommand())
 	rootCmd.AddCommand(commands.NewVersionCommand())
+	rootCmd.AddCommand(commands.NewWorkerCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
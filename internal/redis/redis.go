@@ -0,0 +1,308 @@
+// Package redis is a small Redis client speaking RESP, the Redis protocol,
+// for the framework's Redis-backed drivers
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// poolSize is how many idle connections a Client keeps
+const poolSize = 8
+
+// dialTimeout bounds connecting to Redis and each round trip when the
+// context has no deadline
+const dialTimeout = 5 * time.Second
+
+// Client sends commands to a Redis server over a pool of connections
+type Client struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      bool
+	idle     chan *Conn
+}
+
+// NewClient creates a Client for a redis:// or rediss:// URL, such as
+// redis://:password@localhost:6379/0. It connects when first used.
+func NewClient(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("Redis URL scheme %q must be redis or rediss", u.Scheme)
+	}
+
+	c := &Client{
+		addr: u.Host,
+		tls:  u.Scheme == "rediss",
+		idle: make(chan *Conn, poolSize),
+	}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("Redis database %q must be a number", db)
+		}
+	}
+	return c, nil
+}
+
+// Addr returns the server's host and port
+func (c *Client) Addr() string {
+	return c.addr
+}
+
+// Do sends commands in one round trip and returns their replies: a string,
+// int64, []any, or nil for a missing value. A reply that is a Redis error
+// fails the call; errors inside array replies, such as those of EXEC, are
+// Error items of the array.
+func (c *Client) Do(ctx context.Context, commands ...[]string) ([]any, error) {
+	conn, err := c.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := conn.Do(ctx, commands...)
+	if _, ok := err.(Error); ok || err == nil {
+		c.release(conn)
+	} else {
+		conn.Close()
+	}
+	return replies, err
+}
+
+// Close closes the idle connections
+func (c *Client) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Dial returns an idle connection, or connects, authenticates and selects
+// the database on a new one. Connections from Dial that aren't passed to
+// Do are the caller's to close, such as one held for a subscription.
+func (c *Client) Dial(ctx context.Context) (*Conn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var nc net.Conn
+	var err error
+	if c.tls {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: c.tlsConfig()}
+		nc, err = tlsDialer.DialContext(ctx, "tcp", c.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to Redis: %w", err)
+	}
+
+	conn := &Conn{conn: nc, r: bufio.NewReader(nc)}
+	var setup [][]string
+	if c.password != "" {
+		if c.username != "" {
+			setup = append(setup, []string{"AUTH", c.username, c.password})
+		} else {
+			setup = append(setup, []string{"AUTH", c.password})
+		}
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	if len(setup) > 0 {
+		if _, err := conn.Do(ctx, setup...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("connecting to Redis: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// tlsConfig is the TLS configuration of rediss:// connections, verifying
+// the certificate against the host of the server's address, which may be
+// an IPv6 address
+func (c *Client) tlsConfig() *tls.Config {
+	host, _, err := net.SplitHostPort(c.addr)
+	if err != nil {
+		host = c.addr
+	}
+	return &tls.Config{ServerName: host}
+}
+
+// release returns conn to the pool, closing it if the pool is full
+func (c *Client) release(conn *Conn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// Conn is one connection to Redis
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Error is an error reply from Redis, after which the connection can still
+// be used
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Do writes commands and reads one reply for each
+func (c *Conn) Do(ctx context.Context, commands ...[]string) ([]any, error) {
+	if err := c.Send(ctx, commands...); err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, len(commands))
+	var replyErr error
+	for i := range commands {
+		reply, err := c.Receive()
+		if e, ok := err.(Error); ok {
+			// Read the remaining replies, so the connection stays in step
+			if replyErr == nil {
+				replyErr = e
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, replyErr
+}
+
+// Send writes commands without reading their replies, with the context's
+// deadline or dialTimeout for the round trip
+func (c *Conn) Send(ctx context.Context, commands ...[]string) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(dialTimeout)
+	}
+	c.conn.SetDeadline(deadline)
+
+	var b strings.Builder
+	for _, args := range commands {
+		fmt.Fprintf(&b, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	_, err := io.WriteString(c.conn, b.String())
+	return err
+}
+
+// Receive reads one reply, such as a message on a subscribed connection
+func (c *Conn) Receive() (any, error) {
+	return c.read()
+}
+
+// SetReadDeadline bounds the next Receive; the zero time waits forever
+func (c *Conn) SetReadDeadline(deadline time.Time) error {
+	return c.conn.SetReadDeadline(deadline)
+}
+
+// read reads one reply: a string, int64, []any, or nil for a missing value.
+// Error replies inside an array are items of it, so the rest of the array
+// is still read and the connection stays in step.
+func (c *Conn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := c.read()
+			if e, ok := err.(Error); ok {
+				items[i] = e
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: malformed reply %q", line)
+}
+
+// Strings converts an array reply to strings, skipping nil items
+func Strings(reply any) []string {
+	items, _ := reply.([]any)
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
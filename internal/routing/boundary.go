@@ -0,0 +1,18 @@
+package routing
+
+// FindErrorBoundary walks from node to root returning the nearest error.go,
+// or nil if no directory in the chain defines one
+func FindErrorBoundary(node *RouteNode, modulePath string) *ErrorBoundaryInfo {
+	for current := node; current != nil; current = current.Parent {
+		if current.HasError {
+			return &ErrorBoundaryInfo{
+				FilePath:    current.ErrorFile,
+				PackagePath: current.GetPackagePath(modulePath),
+				PackageName: current.GetPackageAlias(),
+				FuncName:    "Error",
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindErrorBoundary_None tests routes without any error.go
+func TestFindErrorBoundary_None(t *testing.T) {
+	node := &RouteNode{
+		Path:        "/app/pages/users",
+		URLSegment:  "users",
+		HandlerFile: "/app/pages/users/page.go",
+	}
+
+	assert.Nil(t, FindErrorBoundary(node, "github.com/user/project"))
+}
+
+// TestFindErrorBoundary_Nearest tests that the closest error.go wins
+func TestFindErrorBoundary_Nearest(t *testing.T) {
+	pages := &RouteNode{
+		Path:       "/app/pages",
+		URLSegment: "pages",
+		ErrorFile:  "/app/pages/error.go",
+		HasError:   true,
+	}
+
+	dashboard := &RouteNode{
+		Path:       "/app/pages/dashboard",
+		URLSegment: "dashboard",
+		ErrorFile:  "/app/pages/dashboard/error.go",
+		HasError:   true,
+		Parent:     pages,
+	}
+
+	reports := &RouteNode{
+		Path:        "/app/pages/dashboard/reports",
+		URLSegment:  "reports",
+		HandlerFile: "/app/pages/dashboard/reports/page.go",
+		Parent:      dashboard,
+	}
+
+	boundary := FindErrorBoundary(reports, "github.com/user/project")
+
+	require.NotNil(t, boundary)
+	assert.Equal(t, "/app/pages/dashboard/error.go", boundary.FilePath)
+	assert.Equal(t, "pages_dashboard", boundary.PackageName)
+	assert.Equal(t, "Error", boundary.FuncName)
+	assert.Contains(t, boundary.PackagePath, "github.com/user/project")
+}
+
+// TestFindErrorBoundary_Inherited tests boundaries inherited from ancestors
+func TestFindErrorBoundary_Inherited(t *testing.T) {
+	pages := &RouteNode{
+		Path:       "/app/pages",
+		URLSegment: "pages",
+		ErrorFile:  "/app/pages/error.go",
+		HasError:   true,
+	}
+
+	users := &RouteNode{
+		Path:        "/app/pages/users/[id]",
+		URLSegment:  "{id}",
+		HandlerFile: "/app/pages/users/[id]/page.go",
+		Parent:      pages,
+	}
+
+	boundary := FindErrorBoundary(users, "github.com/user/project")
+
+	require.NotNil(t, boundary)
+	assert.Equal(t, "/app/pages/error.go", boundary.FilePath)
+}
@@ -15,10 +15,27 @@ type CodeGenerator struct {
 	ModulePath  string
 	ProjectRoot string // Absolute path to project root
 	OutputFile  string
+	Template    string // Optional custom text/template file, see templates/routes.go.tmpl
+	API         APIVersioning
 }
 
 // Generate creates the routes.gen.go file
 func (g *CodeGenerator) Generate() error {
+	formatted, err := g.Render()
+	if err != nil {
+		return err
+	}
+
+	// Write to file
+	if err := os.WriteFile(g.OutputFile, formatted, 0644); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+
+	return nil
+}
+
+// Render returns the contents of routes.gen.go without writing it
+func (g *CodeGenerator) Render() ([]byte, error) {
 	// Collect all routes and their metadata
 	routes := g.collectRoutes(g.RouteTree)
 
@@ -27,8 +44,15 @@ func (g *CodeGenerator) Generate() error {
 		return routes[i].GetFullPath() < routes[j].GetFullPath()
 	})
 
+	if err := g.checkAPIVersioning(routes); err != nil {
+		return nil, err
+	}
+
 	// Generate code
-	code := g.generateCode(routes)
+	code, err := g.generateCode(routes)
+	if err != nil {
+		return nil, fmt.Errorf("generating code: %w", err)
+	}
 
 	// Format code
 	formatted, err := format.Source([]byte(code))
@@ -38,12 +62,7 @@ func (g *CodeGenerator) Generate() error {
 		formatted = []byte(code)
 	}
 
-	// Write to file
-	if err := os.WriteFile(g.OutputFile, formatted, 0644); err != nil {
-		return fmt.Errorf("writing output file: %w", err)
-	}
-
-	return nil
+	return formatted, nil
 }
 
 func (g *CodeGenerator) collectRoutes(node *RouteNode) []*RouteNode {
@@ -60,73 +79,42 @@ func (g *CodeGenerator) collectRoutes(node *RouteNode) []*RouteNode {
 	return routes
 }
 
-func (g *CodeGenerator) generateCode(routes []*RouteNode) string {
-	var sb strings.Builder
-
-	// Header
-	sb.WriteString("// Code generated by twine routes generate. DO NOT EDIT.\n\n")
-	sb.WriteString("package app\n\n")
+func (g *CodeGenerator) generateCode(routes []*RouteNode) (string, error) {
+	tmpl, err := parseTemplate(g.Template)
+	if err != nil {
+		return "", err
+	}
 
-	// Imports
-	sb.WriteString("import (\n")
-	sb.WriteString("\t\"github.com/cstone-io/twine/pkg/kit\"\n")
-	sb.WriteString("\t\"github.com/cstone-io/twine/pkg/router\"\n")
-	sb.WriteString("\t\"github.com/cstone-io/twine/pkg/middleware\"\n")
-	sb.WriteString("\n")
+	data := &TemplateData{
+		Package:    "app",
+		ModulePath: g.ModulePath,
+	}
 
 	// Collect unique package imports
-	imports := g.collectImports(routes)
-	for alias, path := range imports {
-		sb.WriteString(fmt.Sprintf("\t%s \"%s\"\n", alias, path))
+	// Sorted so the output is byte-for-byte stable, which --check relies on
+	imports := g.collectImports(append(routes, g.collectNotFounds()...))
+	aliases := make([]string, 0, len(imports))
+	for alias := range imports {
+		aliases = append(aliases, alias)
 	}
-
-	sb.WriteString(")\n\n")
-
-	// Helper function for middleware
-	sb.WriteString("// applyMiddleware wraps a handler with a middleware chain\n")
-	sb.WriteString("func applyMiddleware(middlewares []middleware.Middleware, handler kit.HandlerFunc) kit.HandlerFunc {\n")
-	sb.WriteString("\tif len(middlewares) == 0 {\n")
-	sb.WriteString("\t\treturn handler\n")
-	sb.WriteString("\t}\n")
-	sb.WriteString("\treturn middleware.ApplyMiddlewares(handler, middlewares...)\n")
-	sb.WriteString("}\n\n")
-
-	// RegisterRoutes function
-	sb.WriteString("// RegisterRoutes registers all file-based routes\n")
-	sb.WriteString("func RegisterRoutes(r *router.Router) {\n")
-
-	// Group routes by prefix (pages vs api)
-	pageRoutes := make([]*RouteNode, 0)
-	apiRoutes := make([]*RouteNode, 0)
-
-	for _, route := range routes {
-		if route.IsAPI || strings.HasPrefix(route.GetFullPath(), "/api") {
-			apiRoutes = append(apiRoutes, route)
-		} else {
-			pageRoutes = append(pageRoutes, route)
-		}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		data.Imports = append(data.Imports, TemplateImport{Alias: alias, Path: imports[alias]})
 	}
 
-	// Generate page routes
-	if len(pageRoutes) > 0 {
-		sb.WriteString("\t// Page routes\n")
-		for _, route := range pageRoutes {
-			g.generateRouteRegistration(&sb, route, "r")
-		}
-		sb.WriteString("\n")
-	}
+	// Group routes into pages and api sections and their top-level
+	// directories. Not-found fallbacks are registered last in each group so
+	// they read after the real routes (ServeMux still prefers the more
+	// specific patterns).
+	templateGroups(data, routes, g.collectNotFounds(), g.templateRoute)
+	data.SitemapPages = sitemapPages(routes)
 
-	// Generate API routes
-	if len(apiRoutes) > 0 {
-		sb.WriteString("\t// API routes\n")
-		for _, route := range apiRoutes {
-			g.generateRouteRegistration(&sb, route, "r")
-		}
+	var sb strings.Builder
+	if err := tmpl.ExecuteTemplate(&sb, "routes", data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
 	}
 
-	sb.WriteString("}\n")
-
-	return sb.String()
+	return sb.String(), nil
 }
 
 func (g *CodeGenerator) collectImports(routes []*RouteNode) map[string]string {
@@ -139,7 +127,10 @@ func (g *CodeGenerator) collectImports(routes []*RouteNode) map[string]string {
 		alias := route.GetPackageAlias()
 
 		// Ensure unique aliases
-		if seen[alias] {
+		if seen[alias] && imports[alias] == packagePath {
+			// Already imported (e.g. a not-found page next to a page.go)
+			alias = ""
+		} else if seen[alias] {
 			// Add numeric suffix for duplicates
 			counter := 2
 			for {
@@ -152,8 +143,10 @@ func (g *CodeGenerator) collectImports(routes []*RouteNode) map[string]string {
 			}
 		}
 
-		imports[alias] = packagePath
-		seen[alias] = true
+		if alias != "" {
+			imports[alias] = packagePath
+			seen[alias] = true
+		}
 
 		// Add layout package imports
 		chain := g.buildLayoutChain(route)
@@ -166,54 +159,63 @@ func (g *CodeGenerator) collectImports(routes []*RouteNode) map[string]string {
 			imports[layoutAlias] = layout.PackagePath
 			seen[layoutAlias] = true
 		}
+
+		// Add directory middleware package imports
+		mwChain := g.buildMiddlewareChain(route)
+		for _, mw := range mwChain.Middlewares {
+			if seen[mw.PackageName] {
+				// Already imported
+				continue
+			}
+			imports[mw.PackageName] = mw.PackagePath
+			seen[mw.PackageName] = true
+		}
+
+		// Add error boundary package import
+		if boundary := g.findErrorBoundary(route); boundary != nil && !seen[boundary.PackageName] {
+			imports[boundary.PackageName] = boundary.PackagePath
+			seen[boundary.PackageName] = true
+		}
 	}
 
 	return imports
 }
 
-func (g *CodeGenerator) generateRouteRegistration(sb *strings.Builder, route *RouteNode, routerVar string) {
-	urlPattern := route.ToURLPattern()
-	alias := route.GetPackageAlias()
-
-	// Build layout chain
-	chain := g.buildLayoutChain(route)
+// collectNotFounds returns all nodes with a notfound.go, sorted by pattern
+func (g *CodeGenerator) collectNotFounds() []*RouteNode {
+	if g.RouteTree == nil {
+		return nil
+	}
 
-	// Generate layout middleware setup if needed
-	var middlewareVar string
-	if chain.HasLayouts() {
-		middlewareVar = fmt.Sprintf("%s_middleware", strings.ReplaceAll(alias, "/", "_"))
-		sb.WriteString(fmt.Sprintf("\t// Layout chain for %s\n", urlPattern))
+	nodes := collectNotFoundNodes(g.RouteTree)
+	sort.Slice(nodes, func(i, j int) bool {
+		return GetNotFoundPattern(nodes[i]) < GetNotFoundPattern(nodes[j])
+	})
+	return nodes
+}
 
-		// Build middleware chain from layouts
-		sb.WriteString(fmt.Sprintf("\t%s := []middleware.Middleware{\n", middlewareVar))
-		for _, layout := range chain.Layouts {
-			sb.WriteString(fmt.Sprintf("\t\t%s.%s(),\n", layout.PackageName, layout.FuncName))
+// hasRootNotFound reports whether a not-found page covers the whole site
+func (g *CodeGenerator) hasRootNotFound() bool {
+	for _, node := range g.collectNotFounds() {
+		if GetNotFoundPattern(node) == "/" {
+			return true
 		}
-		sb.WriteString("\t}\n")
 	}
+	return false
+}
 
-	// Register each HTTP method
-	for _, method := range route.Methods {
-		handler := fmt.Sprintf("%s.%s", alias, method)
-		methodName := getRouterMethodName(method)
-
-		if middlewareVar != "" {
-			// Wrap handler with middleware
-			wrappedHandler := fmt.Sprintf("applyMiddleware(%s, %s)", middlewareVar, handler)
-			sb.WriteString(fmt.Sprintf("\t%s.%s(\"%s\", %s)\n",
-				routerVar,
-				methodName,
-				urlPattern,
-				wrappedHandler))
-		} else {
-			// Direct handler registration
-			sb.WriteString(fmt.Sprintf("\t%s.%s(\"%s\", %s)\n",
-				routerVar,
-				methodName,
-				urlPattern,
-				handler))
-		}
+func collectNotFoundNodes(node *RouteNode) []*RouteNode {
+	nodes := make([]*RouteNode, 0)
+
+	if node.HasNotFound {
+		nodes = append(nodes, node)
+	}
+
+	for _, child := range node.Children {
+		nodes = append(nodes, collectNotFoundNodes(child)...)
 	}
+
+	return nodes
 }
 
 // GetModulePath parses go.mod to extract module name
@@ -273,6 +275,46 @@ func (g *CodeGenerator) buildLayoutChain(node *RouteNode) *LayoutChain {
 	return chain
 }
 
+// buildMiddlewareChain builds the directory middleware chain for a route
+func (g *CodeGenerator) buildMiddlewareChain(node *RouteNode) *MiddlewareChain {
+	chain := &MiddlewareChain{
+		Middlewares: make([]MiddlewareInfo, 0),
+	}
+
+	current := node
+	for current != nil {
+		if current.HasMiddleware {
+			mw := MiddlewareInfo{
+				FilePath:    current.MiddlewareFile,
+				PackagePath: g.getPackagePath(current),
+				PackageName: current.GetPackageAlias(),
+				FuncName:    "Middleware",
+			}
+			// Prepend to maintain order from root to leaf
+			chain.Middlewares = append([]MiddlewareInfo{mw}, chain.Middlewares...)
+		}
+		current = current.Parent
+	}
+
+	return chain
+}
+
+// findErrorBoundary returns the nearest error boundary for a route
+func (g *CodeGenerator) findErrorBoundary(node *RouteNode) *ErrorBoundaryInfo {
+	for current := node; current != nil; current = current.Parent {
+		if current.HasError {
+			return &ErrorBoundaryInfo{
+				FilePath:    current.ErrorFile,
+				PackagePath: g.getPackagePath(current),
+				PackageName: current.GetPackageAlias(),
+				FuncName:    "Error",
+			}
+		}
+	}
+
+	return nil
+}
+
 // getRouterMethodName converts HTTP method to router method name
 func getRouterMethodName(method string) string {
 	switch method {
@@ -606,6 +606,181 @@ go 1.22
 	assert.Contains(t, code, ".Layout()")
 }
 
+// TestCodeGenerator_Generate_WithMiddleware tests directory middleware generation
+func TestCodeGenerator_Generate_WithMiddleware(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	apiNode := &RouteNode{
+		Path:           filepath.Join(tmpDir, "app/api"),
+		URLSegment:     "api",
+		MiddlewareFile: filepath.Join(tmpDir, "app/api/middleware.go"),
+		HasMiddleware:  true,
+	}
+	usersNode := &RouteNode{
+		Path:        filepath.Join(tmpDir, "app/api/users"),
+		URLSegment:  "users",
+		HandlerFile: filepath.Join(tmpDir, "app/api/users/route.go"),
+		IsAPI:       true,
+		Methods:     []string{"GET"},
+		PackageName: "users",
+		Parent:      apiNode,
+	}
+	apiNode.Children = []*RouteNode{usersNode}
+
+	root := &RouteNode{
+		Path:     filepath.Join(tmpDir, "app"),
+		Children: []*RouteNode{apiNode},
+	}
+	apiNode.Parent = root
+
+	outputFile := filepath.Join(tmpDir, "routes.gen.go")
+
+	gen := &CodeGenerator{
+		RouteTree:   root,
+		ModulePath:  "github.com/user/testproject",
+		ProjectRoot: tmpDir,
+		OutputFile:  outputFile,
+	}
+
+	err := gen.Generate()
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	code := string(content)
+
+	fset := token.NewFileSet()
+	_, err = parser.ParseFile(fset, outputFile, content, 0)
+	assert.NoError(t, err, "Generated code should be valid Go")
+
+	// Aliases include the temp dir, so only check the stable parts
+	assert.Contains(t, code, `"github.com/user/testproject/app/api"`)
+	assert.Contains(t, code, "_middleware := []middleware.Middleware{}")
+	assert.Contains(t, code, "// Directory middleware for /api/users")
+	assert.Contains(t, code, "_api.Middleware()...)")
+	assert.Contains(t, code, `r.Get("/api/users", applyMiddleware(`)
+}
+
+// TestCodeGenerator_Generate_WithErrorBoundary tests error boundary generation
+func TestCodeGenerator_Generate_WithErrorBoundary(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	pagesNode := &RouteNode{
+		Path:       filepath.Join(tmpDir, "app/pages"),
+		URLSegment: "pages",
+		ErrorFile:  filepath.Join(tmpDir, "app/pages/error.go"),
+		HasError:   true,
+	}
+	dashboardNode := &RouteNode{
+		Path:        filepath.Join(tmpDir, "app/pages/dashboard"),
+		URLSegment:  "dashboard",
+		HandlerFile: filepath.Join(tmpDir, "app/pages/dashboard/page.go"),
+		IsPage:      true,
+		Methods:     []string{"GET"},
+		PackageName: "dashboard",
+		Parent:      pagesNode,
+	}
+	pagesNode.Children = []*RouteNode{dashboardNode}
+
+	root := &RouteNode{
+		Path:     filepath.Join(tmpDir, "app"),
+		Children: []*RouteNode{pagesNode},
+	}
+	pagesNode.Parent = root
+
+	outputFile := filepath.Join(tmpDir, "routes.gen.go")
+
+	gen := &CodeGenerator{
+		RouteTree:   root,
+		ModulePath:  "github.com/user/testproject",
+		ProjectRoot: tmpDir,
+		OutputFile:  outputFile,
+	}
+
+	err := gen.Generate()
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	code := string(content)
+
+	fset := token.NewFileSet()
+	_, err = parser.ParseFile(fset, outputFile, content, 0)
+	assert.NoError(t, err, "Generated code should be valid Go")
+
+	assert.Contains(t, code, `"github.com/user/testproject/app/pages"`)
+	assert.Contains(t, code, "// Error boundary for /dashboard")
+	assert.Contains(t, code, "middleware.ErrorBoundaryMiddleware(")
+	assert.Contains(t, code, "_pages.Error))")
+	assert.Contains(t, code, `r.Get("/dashboard", applyMiddleware(`)
+}
+
+// TestCodeGenerator_Generate_WithNotFound tests not-found fallback generation
+func TestCodeGenerator_Generate_WithNotFound(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	appNode := &RouteNode{
+		Path: filepath.Join(tmpDir, "app"),
+	}
+	pagesNode := &RouteNode{
+		Path:            filepath.Join(tmpDir, "app/pages"),
+		URLSegment:      "pages",
+		HandlerFile:     filepath.Join(tmpDir, "app/pages/page.go"),
+		IsPage:          true,
+		Methods:         []string{"GET"},
+		LayoutFile:      filepath.Join(tmpDir, "app/pages/layout.go"),
+		HasLayout:       true,
+		NotFoundFile:    filepath.Join(tmpDir, "app/pages/notfound.go"),
+		HasNotFound:     true,
+		NotFoundMethods: []string{"GET"},
+		Parent:          appNode,
+	}
+	dashboardNode := &RouteNode{
+		Path:            filepath.Join(tmpDir, "app/pages/dashboard"),
+		URLSegment:      "dashboard",
+		NotFoundFile:    filepath.Join(tmpDir, "app/pages/dashboard/notfound.go"),
+		HasNotFound:     true,
+		NotFoundMethods: []string{"GET"},
+		Parent:          pagesNode,
+	}
+	appNode.Children = []*RouteNode{pagesNode}
+	pagesNode.Children = []*RouteNode{dashboardNode}
+
+	outputFile := filepath.Join(tmpDir, "routes.gen.go")
+
+	gen := &CodeGenerator{
+		RouteTree:   appNode,
+		ModulePath:  "github.com/user/testproject",
+		ProjectRoot: tmpDir,
+		OutputFile:  outputFile,
+	}
+
+	err := gen.Generate()
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	code := string(content)
+
+	fset := token.NewFileSet()
+	_, err = parser.ParseFile(fset, outputFile, content, 0)
+	assert.NoError(t, err, "Generated code should be valid Go")
+
+	// Root page matches exactly so the root not-found page can claim "/"
+	assert.Contains(t, code, `r.Get("/{$}", applyMiddleware(`)
+
+	// Fallbacks come after real routes and carry their layout chain
+	assert.Contains(t, code, "// Not-found fallbacks")
+	assert.Contains(t, code, "_notfound_middleware := []middleware.Middleware{")
+	assert.Contains(t, code, `r.Any("/", applyMiddleware(`)
+	assert.Contains(t, code, `r.Any("/dashboard/", applyMiddleware(`)
+	assert.Contains(t, code, "middleware.NotFoundMiddleware()(")
+	assert.Less(t, strings.Index(code, `"/{$}"`), strings.Index(code, "// Not-found fallbacks"))
+
+	// The package shared by page.go and notfound.go is imported once
+	assert.Equal(t, 1, strings.Count(code, `"github.com/user/testproject/app/pages"`))
+}
+
 // TestCodeGenerator_Generate_WithDynamicRoutes tests dynamic route generation
 func TestCodeGenerator_Generate_WithDynamicRoutes(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -683,7 +858,8 @@ func TestCodeGenerator_GenerateCode_Header(t *testing.T) {
 	}
 
 	routes := []*RouteNode{}
-	code := gen.generateCode(routes)
+	code, err := gen.generateCode(routes)
+	require.NoError(t, err)
 
 	assert.Contains(t, code, "// Code generated by twine routes generate. DO NOT EDIT.")
 	assert.Contains(t, code, "package app")
@@ -712,7 +888,8 @@ func TestCodeGenerator_GenerateCode_Imports(t *testing.T) {
 		},
 	}
 
-	code := gen.generateCode(routes)
+	code, err := gen.generateCode(routes)
+	require.NoError(t, err)
 
 	// Verify standard imports
 	assert.Contains(t, code, `"github.com/cstone-io/twine/pkg/kit"`)
@@ -728,7 +905,8 @@ func TestCodeGenerator_GenerateCode_ApplyMiddleware(t *testing.T) {
 	}
 
 	routes := []*RouteNode{}
-	code := gen.generateCode(routes)
+	code, err := gen.generateCode(routes)
+	require.NoError(t, err)
 
 	assert.Contains(t, code, "func applyMiddleware(middlewares []middleware.Middleware, handler kit.HandlerFunc) kit.HandlerFunc")
 	assert.Contains(t, code, "middleware.ApplyMiddlewares(handler, middlewares...)")
@@ -742,7 +920,8 @@ func TestCodeGenerator_GenerateCode_RegisterRoutes(t *testing.T) {
 	}
 
 	routes := []*RouteNode{}
-	code := gen.generateCode(routes)
+	code, err := gen.generateCode(routes)
+	require.NoError(t, err)
 
 	assert.Contains(t, code, "func RegisterRoutes(r *router.Router)")
 }
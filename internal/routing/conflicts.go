@@ -0,0 +1,192 @@
+package routing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// patternRelation describes how the path sets matched by two patterns relate,
+// following Go 1.22 ServeMux precedence rules
+type patternRelation int
+
+const (
+	relationDisjoint     patternRelation = iota // No path matches both
+	relationEquivalent                          // Same paths, ServeMux panics on registration
+	relationMoreSpecific                        // First pattern wins where both match
+	relationMoreGeneral                         // Second pattern wins where both match
+	relationOverlaps                            // Neither wins, ServeMux panics on registration
+)
+
+// patternSegment is one "/"-separated part of a route pattern
+type patternSegment struct {
+	literal  string
+	wildcard bool // {param}
+	multi    bool // {param...}
+}
+
+// parsePattern splits a URL pattern into segments. The root pattern "/" is
+// treated as an exact match so it does not shadow every other route.
+func parsePattern(pattern string) []patternSegment {
+	segments := make([]patternSegment, 0)
+	for _, part := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			segments = append(segments, patternSegment{
+				wildcard: true,
+				multi:    strings.HasSuffix(part, "...}"),
+			})
+			continue
+		}
+		segments = append(segments, patternSegment{literal: part})
+	}
+	return segments
+}
+
+// comparePatterns reports how pattern a relates to pattern b
+func comparePatterns(a, b []patternSegment) patternRelation {
+	aSpecific, bSpecific := false, false
+
+	for i := 0; ; i++ {
+		aDone, bDone := i >= len(a), i >= len(b)
+		if aDone && bDone {
+			break
+		}
+		// A catch-all needs at least the "/" after the preceding segment,
+		// so a shorter pattern never overlaps with a longer one
+		if aDone || bDone {
+			return relationDisjoint
+		}
+
+		// A catch-all matches everything the other pattern's remaining
+		// segments can match, so comparison stops here
+		sa, sb := a[i], b[i]
+		if sa.multi || sb.multi {
+			if !sa.multi {
+				aSpecific = true
+			}
+			if !sb.multi {
+				bSpecific = true
+			}
+			break
+		}
+
+		switch {
+		case !sa.wildcard && !sb.wildcard:
+			if sa.literal != sb.literal {
+				return relationDisjoint
+			}
+		case !sa.wildcard:
+			aSpecific = true
+		case !sb.wildcard:
+			bSpecific = true
+		}
+	}
+
+	switch {
+	case aSpecific && bSpecific:
+		return relationOverlaps
+	case aSpecific:
+		return relationMoreSpecific
+	case bSpecific:
+		return relationMoreGeneral
+	default:
+		return relationEquivalent
+	}
+}
+
+// hasCatchAll reports whether the pattern ends in a {param...} segment
+func hasCatchAll(segments []patternSegment) bool {
+	return len(segments) > 0 && segments[len(segments)-1].multi
+}
+
+// diagnoseOverlaps compares every registered route against every other route
+// in the tree, so conflicts between the pages and api subtrees or between
+// routes at different depths are caught before ServeMux panics at startup
+func (n *RouteNode) diagnoseOverlaps() Diagnostics {
+	diags := make(Diagnostics, 0)
+
+	routes := collectHandlerNodes(n)
+	parsed := make([][]patternSegment, len(routes))
+	for i, route := range routes {
+		parsed[i] = parsePattern(route.ToURLPattern())
+	}
+
+	for i := 0; i < len(routes); i++ {
+		for j := i + 1; j < len(routes); j++ {
+			a, b := routes[i], routes[j]
+			methods := sharedMethods(a.Methods, b.Methods)
+			if len(methods) == 0 {
+				continue
+			}
+
+			switch comparePatterns(parsed[i], parsed[j]) {
+			case relationEquivalent:
+				// Sibling duplicates are already reported by diagnoseConflicts
+				if a.Parent == b.Parent && a.URLSegment == b.URLSegment {
+					continue
+				}
+				diags = append(diags, Diagnostic{
+					Code:     CodeDuplicateRoute,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("duplicate route: %s and %s both match %s for %s", a.HandlerFile, b.HandlerFile, a.ToURLPattern(), methods),
+					File:     b.HandlerFile,
+					Pattern:  b.ToURLPattern(),
+				})
+			case relationOverlaps:
+				diags = append(diags, Diagnostic{
+					Code:     CodeAmbiguousRoutes,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("ambiguous routes: %s (%s) and %s (%s) match some of the same paths for %s and neither is more specific", a.ToURLPattern(), a.HandlerFile, b.ToURLPattern(), b.HandlerFile, methods),
+					File:     b.HandlerFile,
+					Pattern:  b.ToURLPattern(),
+				})
+			case relationMoreSpecific:
+				diags = append(diags, shadowDiagnostic(b, parsed[j], a, methods))
+			case relationMoreGeneral:
+				diags = append(diags, shadowDiagnostic(a, parsed[i], b, methods))
+			}
+		}
+	}
+
+	return diags
+}
+
+// shadowDiagnostic warns that some paths matched by loser are served by winner
+func shadowDiagnostic(loser *RouteNode, loserSegments []patternSegment, winner *RouteNode, methods string) Diagnostic {
+	code := CodeRouteShadowed
+	if hasCatchAll(loserSegments) {
+		code = CodeCatchAllShadowed
+	}
+	return Diagnostic{
+		Code:     code,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%s also matches %s for %s; %s (%s) wins for those paths", loser.ToURLPattern(), winner.ToURLPattern(), methods, winner.ToURLPattern(), winner.HandlerFile),
+		File:     loser.HandlerFile,
+		Pattern:  loser.ToURLPattern(),
+	}
+}
+
+// collectHandlerNodes returns every node with a handler file, in tree order
+func collectHandlerNodes(node *RouteNode) []*RouteNode {
+	nodes := make([]*RouteNode, 0)
+	if node.HandlerFile != "" {
+		nodes = append(nodes, node)
+	}
+	for _, child := range node.Children {
+		nodes = append(nodes, collectHandlerNodes(child)...)
+	}
+	return nodes
+}
+
+// sharedMethods returns the methods exported by both handlers, comma separated
+func sharedMethods(a, b []string) string {
+	shared := make([]string, 0)
+	for _, m := range a {
+		if containsMethod(b, m) {
+			shared = append(shared, m)
+		}
+	}
+	return strings.Join(shared, ", ")
+}
@@ -0,0 +1,139 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addRoute adds a handler node below parent for testing
+func addRoute(parent *RouteNode, segment, file string, methods ...string) *RouteNode {
+	node := &RouteNode{
+		Path:        parent.Path + "/" + segment,
+		URLSegment:  segment,
+		HandlerFile: file,
+		Methods:     methods,
+		Parent:      parent,
+	}
+	parent.Children = append(parent.Children, node)
+	return node
+}
+
+// TestComparePatterns tests ServeMux precedence between patterns
+func TestComparePatterns(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want patternRelation
+	}{
+		{"different literals", "/users", "/posts", relationDisjoint},
+		{"different lengths", "/users", "/users/{id}", relationDisjoint},
+		{"identical", "/users/{id}", "/users/{id}", relationEquivalent},
+		{"different wildcard names", "/users/{id}", "/users/{slug}", relationEquivalent},
+		{"static beats wildcard", "/users/new", "/users/{id}", relationMoreSpecific},
+		{"wildcard loses to static", "/users/{id}", "/users/new", relationMoreGeneral},
+		{"wildcard beats catch-all", "/docs/{page}", "/docs/{path...}", relationMoreSpecific},
+		{"nested beats catch-all", "/docs/{path...}", "/docs/guide/intro", relationMoreGeneral},
+		{"catch-all needs a segment", "/docs", "/docs/{path...}", relationDisjoint},
+		{"both catch-all", "/docs/{a...}", "/docs/{b...}", relationEquivalent},
+		{"crossed wildcards", "/{org}/edit", "/users/{id}", relationOverlaps},
+		{"catch-all crossed", "/docs/{path...}", "/{section}/intro", relationOverlaps},
+		{"root is exact", "/", "/users", relationDisjoint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, comparePatterns(parsePattern(tt.a), parsePattern(tt.b)))
+		})
+	}
+}
+
+// TestDiagnoseOverlaps_PagesAndAPI tests collisions between the pages and api subtrees
+func TestDiagnoseOverlaps_PagesAndAPI(t *testing.T) {
+	root := &RouteNode{Path: "/app"}
+	pages := addRoute(root, "pages", "")
+	api := addRoute(root, "api", "")
+	pagesAPI := addRoute(pages, "api", "")
+	addRoute(pagesAPI, "users", "/app/pages/api/users/page.go", "GET")
+	addRoute(api, "users", "/app/api/users/route.go", "GET", "POST")
+
+	diags := root.Diagnose()
+	require.Len(t, diags, 1)
+	assert.Equal(t, CodeDuplicateRoute, diags[0].Code)
+	assert.Equal(t, SeverityError, diags[0].Severity)
+	assert.Equal(t, "/api/users", diags[0].Pattern)
+	assert.Contains(t, diags[0].Message, "both match /api/users for GET")
+
+	require.Error(t, root.Validate())
+}
+
+// TestDiagnoseOverlaps_DifferentMethods tests that disjoint methods never conflict
+func TestDiagnoseOverlaps_DifferentMethods(t *testing.T) {
+	root := &RouteNode{Path: "/app"}
+	pages := addRoute(root, "pages", "")
+	users := addRoute(pages, "users", "")
+	addRoute(users, "{id}", "/app/pages/users/[id]/page.go", "GET")
+	addRoute(users, "new", "/app/pages/users/new/page.go", "POST")
+
+	assert.Empty(t, root.Diagnose())
+}
+
+// TestDiagnoseOverlaps_Shadowing tests static/dynamic shadowing warnings
+func TestDiagnoseOverlaps_Shadowing(t *testing.T) {
+	root := &RouteNode{Path: "/app"}
+	pages := addRoute(root, "pages", "")
+	users := addRoute(pages, "users", "")
+	id := addRoute(users, "{id}", "/app/pages/users/[id]/page.go", "GET")
+	id.IsDynamic, id.ParamName = true, "id"
+	addRoute(users, "new", "/app/pages/users/new/page.go", "GET")
+
+	diags := root.Diagnose()
+	require.Len(t, diags, 1)
+	assert.Equal(t, CodeRouteShadowed, diags[0].Code)
+	assert.Equal(t, SeverityWarning, diags[0].Severity)
+	assert.Equal(t, "/app/pages/users/[id]/page.go", diags[0].File)
+	assert.Equal(t, "/users/{id}", diags[0].Pattern)
+	assert.Contains(t, diags[0].Message, "/users/new (/app/pages/users/new/page.go) wins")
+
+	// Warnings do not fail validation
+	assert.NoError(t, root.Validate())
+}
+
+// TestDiagnoseOverlaps_CatchAll tests catch-all routes that lose paths to deeper routes
+func TestDiagnoseOverlaps_CatchAll(t *testing.T) {
+	root := &RouteNode{Path: "/app"}
+	pages := addRoute(root, "pages", "")
+	docs := addRoute(pages, "docs", "")
+	catchAll := addRoute(docs, "{path...}", "/app/pages/docs/[...path]/page.go", "GET")
+	catchAll.IsDynamic, catchAll.IsCatchAll, catchAll.ParamName = true, true, "path"
+	guide := addRoute(docs, "guide", "")
+	addRoute(guide, "intro", "/app/pages/docs/guide/intro/page.go", "GET")
+
+	diags := root.Diagnose()
+	require.Len(t, diags, 1)
+	assert.Equal(t, CodeCatchAllShadowed, diags[0].Code)
+	assert.Equal(t, "/docs/{path...}", diags[0].Pattern)
+	assert.Contains(t, diags[0].Message, "/docs/guide/intro")
+}
+
+// TestDiagnoseOverlaps_Ambiguous tests overlapping routes across different subtrees
+func TestDiagnoseOverlaps_Ambiguous(t *testing.T) {
+	root := &RouteNode{Path: "/app"}
+	pages := addRoute(root, "pages", "")
+	org := addRoute(pages, "{org}", "")
+	org.IsDynamic, org.ParamName = true, "org"
+	addRoute(org, "edit", "/app/pages/[org]/edit/page.go", "GET")
+	users := addRoute(pages, "users", "")
+	id := addRoute(users, "{id}", "/app/pages/users/[id]/page.go", "GET")
+	id.IsDynamic, id.ParamName = true, "id"
+
+	err := root.Validate()
+	require.Error(t, err)
+
+	diags := err.(Diagnostics)
+	require.Len(t, diags, 1)
+	assert.Equal(t, CodeAmbiguousRoutes, diags[0].Code)
+	assert.Contains(t, diags[0].Message, "/{org}/edit")
+	assert.Contains(t, diags[0].Message, "/users/{id}")
+}
@@ -0,0 +1,30 @@
+package routing
+
+// BuildMiddlewareChain walks from node to root collecting middleware.go files
+func BuildMiddlewareChain(node *RouteNode, modulePath string) *MiddlewareChain {
+	chain := &MiddlewareChain{
+		Middlewares: make([]MiddlewareInfo, 0),
+	}
+
+	current := node
+	for current != nil {
+		if current.HasMiddleware {
+			mw := MiddlewareInfo{
+				FilePath:    current.MiddlewareFile,
+				PackagePath: current.GetPackagePath(modulePath),
+				PackageName: current.GetPackageAlias(),
+				FuncName:    "Middleware",
+			}
+			// Prepend to maintain order from root to leaf
+			chain.Middlewares = append([]MiddlewareInfo{mw}, chain.Middlewares...)
+		}
+		current = current.Parent
+	}
+
+	return chain
+}
+
+// HasMiddlewares returns true if the chain contains any directory middleware
+func (c *MiddlewareChain) HasMiddlewares() bool {
+	return len(c.Middlewares) > 0
+}
@@ -0,0 +1,73 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildMiddlewareChain_NoMiddleware tests chain with no middleware.go files
+func TestBuildMiddlewareChain_NoMiddleware(t *testing.T) {
+	node := &RouteNode{
+		Path:        "/app/api/users",
+		URLSegment:  "users",
+		HandlerFile: "/app/api/users/route.go",
+	}
+
+	chain := BuildMiddlewareChain(node, "github.com/user/project")
+
+	assert.NotNil(t, chain)
+	assert.Empty(t, chain.Middlewares)
+	assert.False(t, chain.HasMiddlewares())
+}
+
+// TestBuildMiddlewareChain_Order tests middleware ordering from root to leaf
+func TestBuildMiddlewareChain_Order(t *testing.T) {
+	api := &RouteNode{
+		Path:           "/app/api",
+		URLSegment:     "api",
+		MiddlewareFile: "/app/api/middleware.go",
+		HasMiddleware:  true,
+	}
+
+	admin := &RouteNode{
+		Path:           "/app/api/admin",
+		URLSegment:     "admin",
+		MiddlewareFile: "/app/api/admin/middleware.go",
+		HasMiddleware:  true,
+		Parent:         api,
+	}
+
+	// Intermediate node without middleware is skipped
+	users := &RouteNode{
+		Path:       "/app/api/admin/users",
+		URLSegment: "users",
+		Parent:     admin,
+	}
+
+	handler := &RouteNode{
+		Path:        "/app/api/admin/users/[id]",
+		URLSegment:  "{id}",
+		HandlerFile: "/app/api/admin/users/[id]/route.go",
+		Parent:      users,
+	}
+
+	chain := BuildMiddlewareChain(handler, "github.com/user/project")
+
+	require.Len(t, chain.Middlewares, 2)
+	assert.True(t, chain.HasMiddlewares())
+	assert.Equal(t, "/app/api/middleware.go", chain.Middlewares[0].FilePath)
+	assert.Equal(t, "/app/api/admin/middleware.go", chain.Middlewares[1].FilePath)
+	assert.Equal(t, "Middleware", chain.Middlewares[0].FuncName)
+	assert.Equal(t, "api", chain.Middlewares[0].PackageName)
+	assert.Equal(t, "api_admin", chain.Middlewares[1].PackageName)
+}
+
+// TestBuildMiddlewareChain_NilNode tests nil node handling
+func TestBuildMiddlewareChain_NilNode(t *testing.T) {
+	chain := BuildMiddlewareChain(nil, "github.com/user/project")
+
+	assert.NotNil(t, chain)
+	assert.Empty(t, chain.Middlewares)
+}
@@ -14,6 +14,12 @@ func (n *RouteNode) ToURLPattern() string {
 	return path
 }
 
+// GetNotFoundPattern returns the subtree fallback pattern for a notfound.go,
+// e.g. "/dashboard/" which ServeMux matches for any unmatched /dashboard/... path
+func GetNotFoundPattern(n *RouteNode) string {
+	return n.GetFullPath() + "/"
+}
+
 // GetFullPath returns complete URL path from root
 func (n *RouteNode) GetFullPath() string {
 	segments := make([]string, 0)
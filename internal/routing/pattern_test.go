@@ -626,3 +626,15 @@ func TestGetPackageAlias_ComplexPaths(t *testing.T) {
 		})
 	}
 }
+
+// TestGetNotFoundPattern tests subtree fallback patterns for notfound.go
+func TestGetNotFoundPattern(t *testing.T) {
+	app := &RouteNode{Path: "/app"}
+	pages := &RouteNode{Path: "/app/pages", URLSegment: "pages", Parent: app}
+	dashboard := &RouteNode{Path: "/app/pages/dashboard", URLSegment: "dashboard", Parent: pages}
+	api := &RouteNode{Path: "/app/api", URLSegment: "api", Parent: app}
+
+	assert.Equal(t, "/", GetNotFoundPattern(pages))
+	assert.Equal(t, "/dashboard/", GetNotFoundPattern(dashboard))
+	assert.Equal(t, "/api/", GetNotFoundPattern(api))
+}
@@ -7,9 +7,22 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 )
 
+// parseTask is a route file discovered while walking the tree. Files are
+// parsed after the walk by a worker pool, then applied to their nodes in
+// discovery order so results match a serial scan.
+type parseTask struct {
+	node *RouteNode
+	name string // "page.go", "layout.go", ...
+	path string
+	file *ast.File
+	err  error
+}
+
 // ScanRoutes walks app/ directory and builds route tree
 func ScanRoutes(rootDir string) (*RouteNode, error) {
 	root := &RouteNode{
@@ -19,12 +32,14 @@ func ScanRoutes(rootDir string) (*RouteNode, error) {
 		Children:    make([]*RouteNode, 0),
 	}
 
+	tasks := make([]*parseTask, 0)
+
 	// Scan both pages and api directories
 	pagesDir := filepath.Join(rootDir, "pages")
 	apiDir := filepath.Join(rootDir, "api")
 
 	if _, err := os.Stat(pagesDir); err == nil {
-		pagesNode, err := scanDirectoryTree(pagesDir, root, "pages")
+		pagesNode, err := scanDirectoryTree(pagesDir, root, "pages", &tasks)
 		if err != nil {
 			return nil, fmt.Errorf("scanning pages: %w", err)
 		}
@@ -34,19 +49,28 @@ func ScanRoutes(rootDir string) (*RouteNode, error) {
 	}
 
 	if _, err := os.Stat(apiDir); err == nil {
-		apiNode, err := scanDirectoryTree(apiDir, root, "api")
+		apiNode, err := scanDirectoryTree(apiDir, root, "api", &tasks)
 		if err != nil {
 			return nil, fmt.Errorf("scanning api: %w", err)
 		}
 		if apiNode != nil {
+			markAPIVersions(apiNode)
 			root.Children = append(root.Children, apiNode)
 		}
 	}
 
+	parseFiles(tasks)
+
+	for _, task := range tasks {
+		if err := task.apply(); err != nil {
+			return nil, err
+		}
+	}
+
 	return root, nil
 }
 
-func scanDirectoryTree(dir string, parent *RouteNode, urlSegment string) (*RouteNode, error) {
+func scanDirectoryTree(dir string, parent *RouteNode, urlSegment string, tasks *[]*parseTask) (*RouteNode, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
@@ -74,42 +98,32 @@ func scanDirectoryTree(dir string, parent *RouteNode, urlSegment string) (*Route
 		case "page.go":
 			node.HandlerFile = fullPath
 			node.IsPage = true
-			methods, err := DetectMethods(fullPath)
-			if err != nil {
-				return nil, fmt.Errorf("detecting methods in %s: %w", fullPath, err)
-			}
-			node.Methods = methods
-			pkg, err := getPackageName(fullPath)
-			if err != nil {
-				return nil, fmt.Errorf("getting package name from %s: %w", fullPath, err)
-			}
-			node.PackageName = pkg
 
 		case "route.go":
 			node.HandlerFile = fullPath
 			node.IsAPI = true
-			methods, err := DetectMethods(fullPath)
-			if err != nil {
-				return nil, fmt.Errorf("detecting methods in %s: %w", fullPath, err)
-			}
-			node.Methods = methods
-			pkg, err := getPackageName(fullPath)
-			if err != nil {
-				return nil, fmt.Errorf("getting package name from %s: %w", fullPath, err)
-			}
-			node.PackageName = pkg
 
 		case "layout.go":
 			node.LayoutFile = fullPath
 			node.HasLayout = true
-			if node.PackageName == "" {
-				pkg, err := getPackageName(fullPath)
-				if err != nil {
-					return nil, fmt.Errorf("getting package name from %s: %w", fullPath, err)
-				}
-				node.PackageName = pkg
-			}
+
+		case "middleware.go":
+			node.MiddlewareFile = fullPath
+			node.HasMiddleware = true
+
+		case "notfound.go":
+			node.NotFoundFile = fullPath
+			node.HasNotFound = true
+
+		case "error.go":
+			node.ErrorFile = fullPath
+			node.HasError = true
+
+		default:
+			continue
 		}
+
+		*tasks = append(*tasks, &parseTask{node: node, name: name, path: fullPath})
 	}
 
 	// Recursively scan subdirectories
@@ -141,13 +155,13 @@ func scanDirectoryTree(dir string, parent *RouteNode, urlSegment string) (*Route
 		}
 
 		// Recursively scan subdirectory
-		childNode, err := scanDirectoryTree(subPath, node, segment)
+		childNode, err := scanDirectoryTree(subPath, node, segment, tasks)
 		if err != nil {
 			return nil, err
 		}
 
 		// Add child node if it or its descendants have content
-		if childNode != nil && (childNode.HandlerFile != "" || childNode.HasLayout || len(childNode.Children) > 0) {
+		if childNode != nil && (childNode.HandlerFile != "" || childNode.HasLayout || childNode.HasMiddleware || childNode.HasError || childNode.HasNotFound || len(childNode.Children) > 0) {
 			childNode.IsDynamic = isDynamic
 			childNode.IsCatchAll = isCatchAll
 			childNode.ParamName = paramName
@@ -158,6 +172,76 @@ func scanDirectoryTree(dir string, parent *RouteNode, urlSegment string) (*Route
 	return node, nil
 }
 
+// parseFiles parses all tasks concurrently using a shared FileSet
+func parseFiles(tasks []*parseTask) {
+	fset := token.NewFileSet()
+	jobs := make(chan *parseTask)
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range jobs {
+				task.file, task.err = parser.ParseFile(fset, task.path, nil, task.mode())
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		jobs <- task
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// mode returns the parser mode needed for this file; only files that export
+// HTTP methods need their declarations
+func (t *parseTask) mode() parser.Mode {
+	switch t.name {
+	case "page.go", "route.go", "notfound.go":
+		return 0
+	default:
+		return parser.PackageClauseOnly
+	}
+}
+
+// apply copies the parse results onto the task's node
+func (t *parseTask) apply() error {
+	switch t.name {
+	case "page.go", "route.go":
+		if t.err != nil {
+			return fmt.Errorf("detecting methods in %s: %w", t.path, t.err)
+		}
+		t.node.Methods = exportedMethods(t.file)
+		t.node.PackageName = t.file.Name.Name
+
+	case "notfound.go":
+		if t.err != nil {
+			return fmt.Errorf("detecting methods in %s: %w", t.path, t.err)
+		}
+		t.node.NotFoundMethods = exportedMethods(t.file)
+		if t.node.PackageName == "" {
+			t.node.PackageName = t.file.Name.Name
+		}
+
+	default:
+		if t.err != nil {
+			return fmt.Errorf("getting package name from %s: %w", t.path, t.err)
+		}
+		if t.node.PackageName == "" {
+			t.node.PackageName = t.file.Name.Name
+		}
+	}
+
+	return nil
+}
+
 // DetectMethods parses a handler file and returns exported HTTP method functions
 func DetectMethods(filePath string) ([]string, error) {
 	fset := token.NewFileSet()
@@ -166,6 +250,11 @@ func DetectMethods(filePath string) ([]string, error) {
 		return nil, err
 	}
 
+	return exportedMethods(file), nil
+}
+
+// exportedMethods returns the exported HTTP method functions declared in file
+func exportedMethods(file *ast.File) []string {
 	methods := make([]string, 0)
 	validMethods := map[string]bool{
 		"GET":    true,
@@ -187,7 +276,7 @@ func DetectMethods(filePath string) ([]string, error) {
 		}
 	}
 
-	return methods, nil
+	return methods
 }
 
 // getPackageName extracts the package name from a Go file
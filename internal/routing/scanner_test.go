@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -48,6 +49,24 @@ func createTestLayout(packageName string) string {
 	return content
 }
 
+func createTestMiddleware(packageName string) string {
+	content := "package " + packageName + "\n\n"
+	content += "import \"github.com/cstone-io/twine/pkg/middleware\"\n\n"
+	content += "func Middleware() []middleware.Middleware {\n"
+	content += "\treturn nil\n"
+	content += "}\n"
+	return content
+}
+
+func createTestErrorBoundary(packageName string) string {
+	content := "package " + packageName + "\n\n"
+	content += "import \"github.com/cstone-io/twine/pkg/kit\"\n\n"
+	content += "func Error(k *kit.Kit, err error) error {\n"
+	content += "\treturn k.Text(500, err.Error())\n"
+	content += "}\n"
+	return content
+}
+
 // TestScanRoutes_EmptyDirectory tests scanning empty app directory
 func TestScanRoutes_EmptyDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -365,6 +384,88 @@ func TestScanRoutes_NestedLayouts(t *testing.T) {
 	assert.Equal(t, pages, dashboard.Parent)
 }
 
+// TestScanRoutes_WithMiddleware tests scanning middleware.go files
+func TestScanRoutes_WithMiddleware(t *testing.T) {
+	fixture := map[string]string{
+		"app/api/middleware.go":       createTestMiddleware("api"),
+		"app/api/users/route.go":      createTestPageHandler("users", "GET"),
+		"app/api/admin/middleware.go": createTestMiddleware("admin"),
+	}
+
+	rootDir := setupFixture(t, fixture)
+	appDir := filepath.Join(rootDir, "app")
+
+	root, err := ScanRoutes(appDir)
+
+	require.NoError(t, err)
+
+	api := root.Children[0]
+	assert.True(t, api.HasMiddleware)
+	assert.Equal(t, filepath.Join(rootDir, "app/api/middleware.go"), api.MiddlewareFile)
+	assert.Equal(t, "api", api.PackageName)
+
+	// A directory containing only middleware.go is kept in the tree
+	require.Len(t, api.Children, 2)
+	var admin *RouteNode
+	for _, child := range api.Children {
+		if child.URLSegment == "admin" {
+			admin = child
+		}
+	}
+	require.NotNil(t, admin)
+	assert.True(t, admin.HasMiddleware)
+	assert.Empty(t, admin.HandlerFile)
+}
+
+// TestScanRoutes_WithErrorBoundary tests scanning error.go files
+func TestScanRoutes_WithErrorBoundary(t *testing.T) {
+	fixture := map[string]string{
+		"app/pages/dashboard/error.go":        createTestErrorBoundary("dashboard"),
+		"app/pages/dashboard/reports/page.go": createTestPageHandler("reports", "GET"),
+	}
+
+	rootDir := setupFixture(t, fixture)
+	appDir := filepath.Join(rootDir, "app")
+
+	root, err := ScanRoutes(appDir)
+
+	require.NoError(t, err)
+
+	pages := root.Children[0]
+	dashboard := pages.Children[0]
+
+	assert.True(t, dashboard.HasError)
+	assert.Equal(t, filepath.Join(rootDir, "app/pages/dashboard/error.go"), dashboard.ErrorFile)
+	assert.Equal(t, "dashboard", dashboard.PackageName)
+	assert.Empty(t, dashboard.HandlerFile)
+	assert.Len(t, dashboard.Children, 1)
+}
+
+// TestScanRoutes_WithNotFound tests scanning notfound.go files
+func TestScanRoutes_WithNotFound(t *testing.T) {
+	fixture := map[string]string{
+		"app/pages/dashboard/notfound.go":     createTestPageHandler("dashboard", "GET"),
+		"app/pages/dashboard/reports/page.go": createTestPageHandler("reports", "GET"),
+	}
+
+	rootDir := setupFixture(t, fixture)
+	appDir := filepath.Join(rootDir, "app")
+
+	root, err := ScanRoutes(appDir)
+
+	require.NoError(t, err)
+
+	pages := root.Children[0]
+	dashboard := pages.Children[0]
+
+	assert.True(t, dashboard.HasNotFound)
+	assert.Equal(t, filepath.Join(rootDir, "app/pages/dashboard/notfound.go"), dashboard.NotFoundFile)
+	assert.Equal(t, []string{"GET"}, dashboard.NotFoundMethods)
+	assert.Equal(t, "dashboard", dashboard.PackageName)
+	assert.Empty(t, dashboard.HandlerFile)
+	assert.Empty(t, dashboard.Methods)
+}
+
 // TestDetectMethods_AllHTTPMethods tests detecting all HTTP methods
 func TestDetectMethods_AllHTTPMethods(t *testing.T) {
 	tests := []struct {
@@ -689,3 +790,81 @@ func TestScanRoutes_ParentLinks(t *testing.T) {
 	edit := userID.Children[0]
 	assert.Equal(t, userID, edit.Parent)
 }
+
+// TestScanRoutes_ManyFiles tests that parallel parsing assigns results to the right nodes
+func TestScanRoutes_ManyFiles(t *testing.T) {
+	fixture := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("section%d", i)
+		fixture["app/pages/"+name+"/page.go"] = createTestPageHandler(name, "GET")
+		fixture["app/api/"+name+"/route.go"] = createTestPageHandler(name+"_api", "GET", "POST")
+	}
+
+	rootDir := setupFixture(t, fixture)
+
+	root, err := ScanRoutes(filepath.Join(rootDir, "app"))
+	require.NoError(t, err)
+	require.Len(t, root.Children, 2)
+
+	for _, section := range root.Children {
+		require.Len(t, section.Children, 100)
+		for _, node := range section.Children {
+			if section.URLSegment == "api" {
+				assert.Equal(t, node.URLSegment+"_api", node.PackageName)
+				assert.Equal(t, []string{"GET", "POST"}, node.Methods)
+			} else {
+				assert.Equal(t, node.URLSegment, node.PackageName)
+				assert.Equal(t, []string{"GET"}, node.Methods)
+			}
+		}
+	}
+}
+
+// TestScanRoutes_ParseError tests that parse errors surface after the walk
+func TestScanRoutes_ParseError(t *testing.T) {
+	fixture := map[string]string{
+		"app/pages/users/page.go":  createTestPageHandler("users", "GET"),
+		"app/pages/broken/page.go": "package broken\n\nfunc GET(\n",
+	}
+
+	rootDir := setupFixture(t, fixture)
+
+	_, err := ScanRoutes(filepath.Join(rootDir, "app"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "detecting methods in")
+	assert.Contains(t, err.Error(), filepath.Join("broken", "page.go"))
+}
+
+// BenchmarkScanRoutes measures scanning a large app with 500+ route files
+func BenchmarkScanRoutes(b *testing.B) {
+	rootDir := b.TempDir()
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("section%d", i)
+		files := map[string]string{
+			"app/pages/" + name + "/page.go":      createTestPageHandler(name, "GET", "POST"),
+			"app/pages/" + name + "/[id]/page.go": createTestPageHandler("id_param", "GET", "PUT", "DELETE"),
+			"app/api/" + name + "/route.go":       createTestPageHandler(name, "GET", "POST"),
+		}
+		if i%10 == 0 {
+			files["app/pages/"+name+"/layout.go"] = createTestLayout(name)
+		}
+		for path, content := range files {
+			fullPath := filepath.Join(rootDir, path)
+			if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+				b.Fatal(err)
+			}
+			if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	appDir := filepath.Join(rootDir, "app")
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ScanRoutes(appDir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
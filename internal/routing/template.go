@@ -0,0 +1,303 @@
+package routing
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/routes.go.tmpl
+var defaultTemplate string
+
+// TemplateData is the root value passed to the routes.gen.go template
+type TemplateData struct {
+	Package    string           // Package name of the generated file
+	ModulePath string           // Module path from go.mod
+	Imports    []TemplateImport // Handler, layout and middleware packages, sorted by alias
+	PageRoutes []*TemplateRoute
+	APIRoutes  []*TemplateRoute
+	NotFounds  []*TemplateRoute // Not-found fallbacks, registered last
+	Pages      *TemplateGroup   // Routes under app/pages, split by top-level directory
+	API        *TemplateGroup   // Routes under app/api, split by top-level directory
+	// SitemapPages are the URLs of the pages without parameters, passed to
+	// sitemap.SetPages
+	SitemapPages []string
+}
+
+// TemplateGroup is a registration function for part of the route tree
+type TemplateGroup struct {
+	Func      string // Function name, e.g. "RegisterPagesDashboardRoutes"
+	Dir       string // Directory relative to app/, e.g. "pages/dashboard"
+	Comment   string // Section comment, e.g. "Page routes"
+	Routes    []*TemplateRoute
+	NotFounds []*TemplateRoute
+	Groups    []*TemplateGroup // Top-level directory groups, only set on sections
+}
+
+// TemplateImport is an aliased package import
+type TemplateImport struct {
+	Alias string
+	Path  string
+}
+
+// TemplateRoute describes one registered URL pattern and its middleware chain
+type TemplateRoute struct {
+	Pattern       string // ServeMux pattern, e.g. "/users/{id}"
+	File          string // Handler file (page.go, route.go or notfound.go)
+	Alias         string // Import alias of the handler package
+	NotFound      bool   // Route is a notfound.go subtree fallback
+	MiddlewareVar string // Variable holding the chain, empty if there is none
+	Layouts       []TemplateCall
+	Middlewares   []TemplateCall
+	ErrorBoundary *TemplateCall
+	Deprecation   *Deprecation // Set for routes of a deprecated API version
+	Aliases       []string     // Extra patterns, e.g. "/api/users" for the default API version
+	Methods       []*TemplateMethod
+}
+
+// TemplateMethod is a single method registration for a route
+type TemplateMethod struct {
+	Route      *TemplateRoute
+	Method     string // HTTP method, e.g. "GET", or empty for every method
+	RouterFunc string // Router method, e.g. "Get"
+	Handler    string // Unwrapped handler expression, e.g. "users.GET"
+}
+
+// TemplateCall references an exported function in an imported package
+type TemplateCall struct {
+	Alias string
+	Func  string
+}
+
+// parseTemplate loads the default template, then the custom template at
+// path if set, so custom templates only need to redefine the blocks they change
+func parseTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.New("routes.go.tmpl").Parse(defaultTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing default template: %w", err)
+	}
+
+	if path == "" {
+		return tmpl, nil
+	}
+
+	custom, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+	if _, err := tmpl.Parse(string(custom)); err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", path, err)
+	}
+
+	return tmpl, nil
+}
+
+// templateGroups splits routes and not-found fallbacks into the pages and api
+// sections, with one group per top-level directory below each section
+func templateGroups(data *TemplateData, routes, notFounds []*RouteNode, build func(*RouteNode, bool) *TemplateRoute) {
+	data.Pages = &TemplateGroup{Func: "RegisterPagesRoutes", Dir: "pages", Comment: "Page routes"}
+	data.API = &TemplateGroup{Func: "RegisterAPIRoutes", Dir: "api", Comment: "API routes"}
+
+	groups := make(map[*RouteNode]*TemplateGroup)
+	names := make(map[string]bool)
+
+	groupFor := func(node *RouteNode) (*TemplateGroup, bool) {
+		isAPI, top := sectionOf(node)
+		section, sectionName := data.Pages, "Pages"
+		if isAPI {
+			section, sectionName = data.API, "API"
+		}
+		if top == nil {
+			return section, isAPI
+		}
+		if group, ok := groups[top]; ok {
+			return group, isAPI
+		}
+
+		dir := filepath.Base(top.Path)
+		name := fmt.Sprintf("Register%s%sRoutes", sectionName, exportedName(dir))
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("Register%s%s%dRoutes", sectionName, exportedName(dir), i)
+		}
+		names[name] = true
+
+		group := &TemplateGroup{
+			Func:    name,
+			Dir:     section.Dir + "/" + dir,
+			Comment: section.Comment,
+		}
+		groups[top] = group
+		section.Groups = append(section.Groups, group)
+		return group, isAPI
+	}
+
+	for _, node := range routes {
+		route := build(node, false)
+		group, isAPI := groupFor(node)
+		group.Routes = append(group.Routes, route)
+		if isAPI {
+			data.APIRoutes = append(data.APIRoutes, route)
+		} else {
+			data.PageRoutes = append(data.PageRoutes, route)
+		}
+	}
+
+	for _, node := range notFounds {
+		route := build(node, true)
+		group, _ := groupFor(node)
+		group.NotFounds = append(group.NotFounds, route)
+		data.NotFounds = append(data.NotFounds, route)
+	}
+
+	for _, section := range []*TemplateGroup{data.Pages, data.API} {
+		sort.SliceStable(section.Groups, func(i, j int) bool {
+			return section.Groups[i].Dir < section.Groups[j].Dir
+		})
+	}
+}
+
+// sitemapPages returns the URL patterns of the GET pages below app/pages
+// without parameters, sorted
+func sitemapPages(routes []*RouteNode) []string {
+	pages := make([]string, 0)
+	for _, node := range routes {
+		if isAPI, _ := sectionOf(node); isAPI || !node.IsPage {
+			continue
+		}
+		pattern := node.ToURLPattern()
+		if strings.Contains(pattern, "{") {
+			continue
+		}
+		for _, method := range node.Methods {
+			if method == "GET" {
+				pages = append(pages, pattern)
+				break
+			}
+		}
+	}
+	sort.Strings(pages)
+	return pages
+}
+
+// sectionOf reports whether node is in the api section and returns its
+// top-level directory below app/pages or app/api, or nil if node is the
+// section itself. Nodes outside a section fall back to their URL.
+func sectionOf(node *RouteNode) (isAPI bool, top *RouteNode) {
+	ancestors := make([]*RouteNode, 0)
+	for current := node; current != nil; current = current.Parent {
+		ancestors = append([]*RouteNode{current}, ancestors...)
+	}
+
+	for i, current := range ancestors {
+		if current.URLSegment != "pages" && current.URLSegment != "api" {
+			continue
+		}
+		if i+1 < len(ancestors) {
+			top = ancestors[i+1]
+		}
+		return current.URLSegment == "api", top
+	}
+
+	return node.IsAPI || strings.HasPrefix(node.GetFullPath(), "/api"), nil
+}
+
+// exportedName converts a directory name such as "user-list", "[id]" or
+// "[...slug]" into an exported identifier fragment
+func exportedName(dir string) string {
+	suffix := ""
+	if strings.HasPrefix(dir, "[") && strings.HasSuffix(dir, "]") {
+		dir = strings.TrimSuffix(strings.TrimPrefix(dir, "["), "]")
+		suffix = "Param"
+		if strings.HasPrefix(dir, "...") {
+			dir = strings.TrimPrefix(dir, "...")
+			suffix = "CatchAll"
+		}
+	}
+
+	var sb strings.Builder
+	upper := true
+	for _, r := range dir {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String() + suffix
+}
+
+// templateRoute builds the template value for a handler or not-found node
+func (g *CodeGenerator) templateRoute(node *RouteNode, notFound bool) *TemplateRoute {
+	alias := node.GetPackageAlias()
+	route := &TemplateRoute{
+		Alias:    alias,
+		NotFound: notFound,
+	}
+
+	varName := fmt.Sprintf("%s_middleware", strings.ReplaceAll(alias, "/", "_"))
+	if notFound {
+		route.Pattern = GetNotFoundPattern(node)
+		route.File = node.NotFoundFile
+		varName = fmt.Sprintf("%s_notfound_middleware", strings.ReplaceAll(alias, "/", "_"))
+	} else {
+		route.Pattern = node.ToURLPattern()
+		route.File = node.HandlerFile
+
+		// A root not-found page claims "/", so the root page matches exactly
+		if route.Pattern == "/" && g.hasRootNotFound() {
+			route.Pattern = "/{$}"
+		}
+	}
+
+	for _, layout := range g.buildLayoutChain(node).Layouts {
+		route.Layouts = append(route.Layouts, TemplateCall{Alias: layout.PackageName, Func: layout.FuncName})
+	}
+	for _, mw := range g.buildMiddlewareChain(node).Middlewares {
+		route.Middlewares = append(route.Middlewares, TemplateCall{Alias: mw.PackageName, Func: mw.FuncName})
+	}
+	if boundary := g.findErrorBoundary(node); boundary != nil {
+		route.ErrorBoundary = &TemplateCall{Alias: boundary.PackageName, Func: boundary.FuncName}
+	}
+	if deprecation, ok := g.API.Deprecations[node.APIVersion]; ok && node.APIVersion != "" {
+		route.Deprecation = &deprecation
+	}
+	if !notFound && node.APIVersion != "" && node.APIVersion == g.API.DefaultVersion {
+		route.Aliases = []string{node.UnversionedPath()}
+	}
+
+	if len(route.Layouts) > 0 || len(route.Middlewares) > 0 || route.ErrorBoundary != nil || route.Deprecation != nil {
+		route.MiddlewareVar = varName
+	}
+
+	// Fallbacks answer every method, so a POST to a missing path gets the
+	// scoped not-found page rather than 405
+	if notFound {
+		route.Methods = []*TemplateMethod{{
+			Route:      route,
+			RouterFunc: "Any",
+			Handler:    fmt.Sprintf("middleware.NotFoundMiddleware()(%s.GET)", alias),
+		}}
+		return route
+	}
+
+	for _, method := range node.Methods {
+		route.Methods = append(route.Methods, &TemplateMethod{
+			Route:      route,
+			Method:     method,
+			RouterFunc: getRouterMethodName(method),
+			Handler:    fmt.Sprintf("%s.%s", alias, method),
+		})
+	}
+
+	return route
+}
@@ -0,0 +1,181 @@
+package routing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func templateTestTree() *RouteNode {
+	root := &RouteNode{Path: "/project/app"}
+	pages := &RouteNode{Path: "/project/app/pages", URLSegment: "pages", Parent: root}
+	users := &RouteNode{
+		Path:        "/project/app/pages/users",
+		URLSegment:  "users",
+		HandlerFile: "/project/app/pages/users/page.go",
+		Methods:     []string{"GET", "POST"},
+		Parent:      pages,
+	}
+	pages.Children = []*RouteNode{users}
+	root.Children = []*RouteNode{pages}
+	return root
+}
+
+// TestCodeGenerator_CustomTemplate_Block tests overriding a single block
+func TestCodeGenerator_CustomTemplate_Block(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "routes.tmpl")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(
+		`{{define "handler"}}trace("{{.Method}} {{.Route.Pattern}}", {{.Handler}}){{end}}`), 0644))
+
+	tree := templateTestTree()
+	gen := &CodeGenerator{
+		RouteTree:   tree,
+		ModulePath:  "github.com/user/project",
+		ProjectRoot: "/project",
+		Template:    tmplPath,
+	}
+
+	code, err := gen.generateCode(gen.collectRoutes(tree))
+	require.NoError(t, err)
+
+	// Everything else comes from the default template
+	assert.Contains(t, code, "// Code generated by twine routes generate. DO NOT EDIT.")
+	assert.Contains(t, code, "func applyMiddleware(")
+	assert.Contains(t, code, `r.Get("/users", trace("GET /users", project_pages_users.GET))`)
+	assert.Contains(t, code, `r.Post("/users", trace("POST /users", project_pages_users.POST))`)
+}
+
+// TestCodeGenerator_CustomTemplate_Routes tests replacing the whole file
+func TestCodeGenerator_CustomTemplate_Routes(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "routes.tmpl")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`{{define "routes"}}package {{.Package}}
+
+import "net/http"
+
+func Register(mux *http.ServeMux) {
+{{- range .PageRoutes}}{{range .Methods}}
+	// {{.Method}} {{.Route.Pattern}} from {{.Route.File}}
+{{- end}}{{end}}
+}
+{{end}}`), 0644))
+
+	tree := templateTestTree()
+	gen := &CodeGenerator{
+		RouteTree:   tree,
+		ModulePath:  "github.com/user/project",
+		ProjectRoot: "/project",
+		Template:    tmplPath,
+	}
+
+	code, err := gen.generateCode(gen.collectRoutes(tree))
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "func Register(mux *http.ServeMux)")
+	assert.Contains(t, code, "// POST /users from /project/app/pages/users/page.go")
+	assert.NotContains(t, code, "RegisterRoutes")
+}
+
+// TestCodeGenerator_CustomTemplate_Errors tests missing and invalid templates
+func TestCodeGenerator_CustomTemplate_Errors(t *testing.T) {
+	tree := templateTestTree()
+	gen := &CodeGenerator{RouteTree: tree, ModulePath: "github.com/user/project", ProjectRoot: "/project"}
+
+	gen.Template = filepath.Join(t.TempDir(), "missing.tmpl")
+	_, err := gen.Render()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reading template")
+
+	gen.Template = filepath.Join(t.TempDir(), "broken.tmpl")
+	require.NoError(t, os.WriteFile(gen.Template, []byte(`{{define "handler"}}{{.Nope`), 0644))
+	_, err = gen.Render()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parsing template")
+
+	require.NoError(t, os.WriteFile(gen.Template, []byte(`{{define "handler"}}{{.Nope}}{{end}}`), 0644))
+	_, err = gen.Render()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "executing template")
+}
+
+// TestExportedName tests directory name to identifier conversion
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"dashboard":  "Dashboard",
+		"user-list":  "UserList",
+		"v1.2":       "V12",
+		"[id]":       "IdParam",
+		"[...slug]":  "SlugCatchAll",
+		"my_reports": "MyReports",
+	}
+
+	for dir, want := range tests {
+		assert.Equal(t, want, exportedName(dir), dir)
+	}
+}
+
+// TestCodeGenerator_SubtreeFunctions tests per-section and per-directory registration functions
+func TestCodeGenerator_SubtreeFunctions(t *testing.T) {
+	root := &RouteNode{Path: "/project/app"}
+	pages := &RouteNode{Path: "/project/app/pages", URLSegment: "pages", Parent: root, HandlerFile: "/project/app/pages/page.go", Methods: []string{"GET"}}
+	dashboard := &RouteNode{Path: "/project/app/pages/dashboard", URLSegment: "dashboard", Parent: pages, HandlerFile: "/project/app/pages/dashboard/page.go", Methods: []string{"GET"}}
+	settings := &RouteNode{Path: "/project/app/pages/dashboard/settings", URLSegment: "settings", Parent: dashboard, HandlerFile: "/project/app/pages/dashboard/settings/page.go", Methods: []string{"GET"}}
+	nestedAPI := &RouteNode{Path: "/project/app/pages/api", URLSegment: "api", Parent: pages, HandlerFile: "/project/app/pages/api/page.go", Methods: []string{"GET"}}
+	api := &RouteNode{Path: "/project/app/api", URLSegment: "api", Parent: root}
+	orders := &RouteNode{Path: "/project/app/api/orders", URLSegment: "orders", Parent: api, HandlerFile: "/project/app/api/orders/route.go", Methods: []string{"GET"}, IsAPI: true}
+	dashboard.Children = []*RouteNode{settings}
+	pages.Children = []*RouteNode{dashboard, nestedAPI}
+	api.Children = []*RouteNode{orders}
+	root.Children = []*RouteNode{pages, api}
+
+	gen := &CodeGenerator{RouteTree: root, ModulePath: "github.com/user/project", ProjectRoot: "/project"}
+	out, err := gen.Render()
+	require.NoError(t, err)
+	code := string(out)
+
+	assert.Contains(t, code, "func RegisterRoutes(r *router.Router) {\n\tsitemap.SetPages(SitemapPages...)\n\tRegisterPagesRoutes(r)\n\tRegisterAPIRoutes(r)\n}")
+	assert.Contains(t, code, "func RegisterPagesRoutes(r *router.Router) {")
+	assert.Contains(t, code, "func RegisterPagesDashboardRoutes(r *router.Router) {")
+	assert.Contains(t, code, "func RegisterAPIOrdersRoutes(r *router.Router) {")
+
+	// A pages/api directory belongs to the pages section
+	assert.Contains(t, code, "func RegisterPagesApiRoutes(r *router.Router) {")
+
+	// Section functions call their directory functions
+	pagesFunc := code[strings.Index(code, "func RegisterPagesRoutes"):strings.Index(code, "func RegisterPagesDashboardRoutes")]
+	assert.Contains(t, pagesFunc, `r.Get("/", project_pages.GET)`)
+	assert.Contains(t, pagesFunc, "RegisterPagesDashboardRoutes(r)")
+	assert.Contains(t, pagesFunc, "RegisterPagesApiRoutes(r)")
+	assert.NotContains(t, pagesFunc, `"/dashboard/settings"`)
+
+	// Nested routes stay in their top-level directory's function
+	dashboardFunc := code[strings.Index(code, "func RegisterPagesDashboardRoutes"):]
+	assert.Contains(t, dashboardFunc, `r.Get("/dashboard/settings", project_pages_dashboard_settings.GET)`)
+}
+
+// TestCodeGenerator_SitemapPages tests listing the static pages for the
+// sitemap
+func TestCodeGenerator_SitemapPages(t *testing.T) {
+	root := &RouteNode{Path: "/project/app"}
+	pages := &RouteNode{Path: "/project/app/pages", URLSegment: "pages", Parent: root, HandlerFile: "/project/app/pages/page.go", Methods: []string{"GET"}, IsPage: true}
+	about := &RouteNode{Path: "/project/app/pages/about", URLSegment: "about", Parent: pages, HandlerFile: "/project/app/pages/about/page.go", Methods: []string{"GET"}, IsPage: true}
+	users := &RouteNode{Path: "/project/app/pages/users", URLSegment: "users", Parent: pages, HandlerFile: "/project/app/pages/users/page.go", Methods: []string{"GET", "POST"}, IsPage: true}
+	user := &RouteNode{Path: "/project/app/pages/users/[id]", URLSegment: "{id}", Parent: users, HandlerFile: "/project/app/pages/users/[id]/page.go", Methods: []string{"GET"}, IsPage: true, IsDynamic: true, ParamName: "id"}
+	logout := &RouteNode{Path: "/project/app/pages/logout", URLSegment: "logout", Parent: pages, HandlerFile: "/project/app/pages/logout/page.go", Methods: []string{"POST"}, IsPage: true}
+	api := &RouteNode{Path: "/project/app/api", URLSegment: "api", Parent: root}
+	orders := &RouteNode{Path: "/project/app/api/orders", URLSegment: "orders", Parent: api, HandlerFile: "/project/app/api/orders/route.go", Methods: []string{"GET"}, IsAPI: true}
+	users.Children = []*RouteNode{user}
+	pages.Children = []*RouteNode{users, about, logout}
+	api.Children = []*RouteNode{orders}
+	root.Children = []*RouteNode{pages, api}
+
+	gen := &CodeGenerator{RouteTree: root, ModulePath: "github.com/user/project", ProjectRoot: "/project"}
+	out, err := gen.Render()
+	require.NoError(t, err)
+
+	// Pages with parameters, without GET and API routes are left out
+	assert.Contains(t, string(out), "var SitemapPages = []string{\n\t\"/\",\n\t\"/about\",\n\t\"/users\",\n}")
+}
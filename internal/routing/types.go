@@ -8,23 +8,33 @@ type RouteNode struct {
 	Parent     *RouteNode   // Parent node (for layout chain)
 
 	// File detection
-	HandlerFile string // "page.go" or "route.go" (full path)
-	LayoutFile  string // "layout.go" (full path)
+	HandlerFile    string // "page.go" or "route.go" (full path)
+	LayoutFile     string // "layout.go" (full path)
+	MiddlewareFile string // "middleware.go" (full path)
+	ErrorFile      string // "error.go" (full path)
+	NotFoundFile   string // "notfound.go" (full path)
 
 	// Handler metadata
-	Methods     []string // ["GET", "POST"] - detected from exports
-	PackageName string   // Go package name for this directory
+	Methods         []string // ["GET", "POST"] - detected from exports
+	NotFoundMethods []string // Methods exported by notfound.go
+	PackageName     string   // Go package name for this directory
 
 	// Route type detection
-	IsDirectory bool // Just a directory (no handler)
-	IsPage      bool // page.go found
-	IsAPI       bool // route.go found
-	HasLayout   bool // layout.go found
+	IsDirectory   bool // Just a directory (no handler)
+	IsPage        bool // page.go found
+	IsAPI         bool // route.go found
+	HasLayout     bool // layout.go found
+	HasMiddleware bool // middleware.go found
+	HasError      bool // error.go found
+	HasNotFound   bool // notfound.go found
 
 	// Dynamic route handling
 	IsDynamic  bool   // [param] style
 	IsCatchAll bool   // [...param] style
 	ParamName  string // "param" extracted from [param] or [...param]
+
+	// API versioning
+	APIVersion string // "v1" for app/api/v1 and everything below it
 }
 
 // LayoutChain represents an ordered chain of layout middleware
@@ -39,3 +49,24 @@ type LayoutInfo struct {
 	PackageName string // Package identifier for imports
 	FuncName    string // "Layout" (function name to call)
 }
+
+// MiddlewareChain represents an ordered chain of directory middleware
+type MiddlewareChain struct {
+	Middlewares []MiddlewareInfo // Ordered from outermost (root) to innermost (leaf)
+}
+
+// MiddlewareInfo contains information about a single middleware.go in the chain
+type MiddlewareInfo struct {
+	FilePath    string // Filesystem path to middleware.go
+	PackagePath string // Go import path
+	PackageName string // Package identifier for imports
+	FuncName    string // "Middleware" (function name to call)
+}
+
+// ErrorBoundaryInfo contains information about the error boundary for a route
+type ErrorBoundaryInfo struct {
+	FilePath    string // Filesystem path to error.go
+	PackagePath string // Go import path
+	PackageName string // Package identifier for imports
+	FuncName    string // "Error" (function name to call)
+}
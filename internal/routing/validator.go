@@ -2,67 +2,205 @@ package routing
 
 import (
 	"fmt"
+	"strings"
 	"unicode"
 )
 
-// Validate checks the route tree for conflicts and invalid configurations
-func (n *RouteNode) Validate() error {
-	// Validate this node
-	if err := n.validateNode(); err != nil {
-		return err
+// Stable diagnostic codes reported by the validator. Codes are never reused
+// so editors and CI can match on them.
+const (
+	CodeDuplicateRoute      = "TWN001" // Two handlers map to the same URL
+	CodeInvalidParam        = "TWN002" // [param] is not a valid Go identifier
+	CodeCatchAllNotLast     = "TWN003" // Handlers nested below a [...param] directory
+	CodeNoMethods           = "TWN004" // Handler file exports no HTTP method functions
+	CodeMultipleCatchAll    = "TWN005" // More than one [...param] directory at one level
+	CodeNotFoundMissingGET  = "TWN006" // notfound.go does not export GET
+	CodeNotFoundUnreachable = "TWN007" // notfound.go shadowed by a catch-all route
+	CodeAmbiguousRoutes     = "TWN008" // Overlapping routes where neither is more specific
+	CodeRouteShadowed       = "TWN009" // A wildcard route loses some paths to a more specific one
+	CodeCatchAllShadowed    = "TWN010" // A catch-all route loses some paths to a more specific one
+)
+
+// Diagnostic severities. Only errors fail validation.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic describes a single problem found in the route tree
+type Diagnostic struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	File     string `json:"file,omitempty"`    // File or directory the problem was found in
+	Pattern  string `json:"pattern,omitempty"` // Route pattern affected, if any
+}
+
+// String formats the diagnostic as "CODE file: message (route pattern)"
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s %s: %s", d.Code, d.File, d.Message)
+	if d.Pattern != "" {
+		s += fmt.Sprintf(" (route %s)", d.Pattern)
 	}
+	return s
+}
+
+// Diagnostics is a list of validation problems that implements error
+type Diagnostics []Diagnostic
+
+// Error joins all diagnostics, one per line
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Errors returns only the diagnostics with error severity
+func (d Diagnostics) Errors() Diagnostics {
+	return d.filter(SeverityError)
+}
+
+// Warnings returns only the diagnostics with warning severity
+func (d Diagnostics) Warnings() Diagnostics {
+	return d.filter(SeverityWarning)
+}
+
+func (d Diagnostics) filter(severity string) Diagnostics {
+	out := make(Diagnostics, 0)
+	for _, diag := range d {
+		if diag.Severity == severity {
+			out = append(out, diag)
+		}
+	}
+	return out
+}
+
+// err returns the error diagnostics as an error, or nil if there are none
+func (d Diagnostics) err() error {
+	errs := d.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Validate checks the route tree for conflicts and invalid configurations.
+// All errors are collected; the returned error is a Diagnostics value.
+// Warnings are not returned, use Diagnose to see them.
+func (n *RouteNode) Validate() error {
+	return n.Diagnose().err()
+}
+
+// Diagnose walks the route tree and returns every problem found, including
+// overlaps between routes in different subtrees
+func (n *RouteNode) Diagnose() Diagnostics {
+	diags := n.diagnose()
+	return append(diags, n.diagnoseOverlaps()...)
+}
+
+func (n *RouteNode) diagnose() Diagnostics {
+	diags := n.diagnoseNode()
 
 	// Recursively validate children
 	for _, child := range n.Children {
-		if err := child.Validate(); err != nil {
-			return err
-		}
+		diags = append(diags, child.diagnose()...)
 	}
 
 	// Check for route conflicts among children
-	if err := n.checkConflicts(); err != nil {
-		return err
-	}
+	diags = append(diags, n.diagnoseConflicts()...)
 
-	return nil
+	return diags
 }
 
 func (n *RouteNode) validateNode() error {
+	return n.diagnoseNode().err()
+}
+
+func (n *RouteNode) diagnoseNode() Diagnostics {
+	diags := make(Diagnostics, 0)
+
 	// Validate dynamic segment names
 	if n.IsDynamic {
 		if err := validateParamName(n.ParamName); err != nil {
-			return fmt.Errorf("%s: %w", n.Path, err)
+			diags = append(diags, Diagnostic{
+				Code:     CodeInvalidParam,
+				Severity: SeverityError,
+				Message:  err.Error(),
+				File:     n.Path,
+				Pattern:  n.patternIfRouted(),
+			})
 		}
 	}
 
 	// Validate catch-all is last segment
 	if n.IsCatchAll {
-		if len(n.Children) > 0 {
-			// Check if any children have handlers
-			for _, child := range n.Children {
-				if child.HandlerFile != "" {
-					return fmt.Errorf("%s: catch-all segment must be the last segment in the route", n.Path)
-				}
+		for _, child := range n.Children {
+			if child.HandlerFile != "" {
+				diags = append(diags, Diagnostic{
+					Code:     CodeCatchAllNotLast,
+					Severity: SeverityError,
+					Message:  "catch-all segment must be the last segment in the route",
+					File:     n.Path,
+					Pattern:  child.ToURLPattern(),
+				})
 			}
 		}
 	}
 
 	// Validate handler has at least one method
 	if n.HandlerFile != "" && len(n.Methods) == 0 {
-		return fmt.Errorf("%s: handler file must export at least one HTTP method function (GET, POST, PUT, DELETE, PATCH)", n.HandlerFile)
+		diags = append(diags, Diagnostic{
+			Code:     CodeNoMethods,
+			Severity: SeverityError,
+			Message:  "handler file must export at least one HTTP method function (GET, POST, PUT, DELETE, PATCH)",
+			File:     n.HandlerFile,
+			Pattern:  n.ToURLPattern(),
+		})
 	}
 
-	return nil
+	// Validate not-found page exports GET and is reachable
+	if n.HasNotFound {
+		if !containsMethod(n.NotFoundMethods, "GET") {
+			diags = append(diags, Diagnostic{
+				Code:     CodeNotFoundMissingGET,
+				Severity: SeverityError,
+				Message:  "notfound.go must export a GET function",
+				File:     n.NotFoundFile,
+				Pattern:  GetNotFoundPattern(n),
+			})
+		}
+		for _, child := range n.Children {
+			if child.IsCatchAll && child.HandlerFile != "" {
+				diags = append(diags, Diagnostic{
+					Code:     CodeNotFoundUnreachable,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("not-found page is unreachable, catch-all route %s already matches every path", child.HandlerFile),
+					File:     n.NotFoundFile,
+					Pattern:  GetNotFoundPattern(n),
+				})
+			}
+		}
+	}
+
+	return diags
 }
 
 func (n *RouteNode) checkConflicts() error {
+	return n.diagnoseConflicts().err()
+}
+
+func (n *RouteNode) diagnoseConflicts() Diagnostics {
+	diags := make(Diagnostics, 0)
+
 	// Group children by type
 	static := make([]*RouteNode, 0)
 	dynamic := make([]*RouteNode, 0)
 	catchAll := make([]*RouteNode, 0)
 
 	for _, child := range n.Children {
-		if child.HandlerFile == "" && !child.HasLayout {
+		if child.HandlerFile == "" && !child.HasLayout && !child.HasMiddleware && !child.HasError && !child.HasNotFound {
 			continue
 		}
 
@@ -77,7 +215,13 @@ func (n *RouteNode) checkConflicts() error {
 
 	// Check for multiple catch-all routes
 	if len(catchAll) > 1 {
-		return fmt.Errorf("%s: multiple catch-all routes at same level", n.Path)
+		diags = append(diags, Diagnostic{
+			Code:     CodeMultipleCatchAll,
+			Severity: SeverityError,
+			Message:  "multiple catch-all routes at same level",
+			File:     n.Path,
+			Pattern:  catchAll[0].ToURLPattern(),
+		})
 	}
 
 	// Check for conflicts between static and dynamic routes
@@ -91,13 +235,32 @@ func (n *RouteNode) checkConflicts() error {
 	for _, node := range static {
 		if existing, exists := seen[node.URLSegment]; exists {
 			if node.HandlerFile != "" && existing.HandlerFile != "" {
-				return fmt.Errorf("duplicate route: %s and %s both map to /%s", node.HandlerFile, existing.HandlerFile, node.URLSegment)
+				diags = append(diags, Diagnostic{
+					Code:     CodeDuplicateRoute,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("duplicate route: %s and %s both map to /%s", node.HandlerFile, existing.HandlerFile, node.URLSegment),
+					File:     node.HandlerFile,
+					Pattern:  node.ToURLPattern(),
+				})
 			}
 		}
 		seen[node.URLSegment] = node
 	}
 
-	return nil
+	return diags
+}
+
+// patternIfRouted returns the node's URL pattern if it has a handler
+func (n *RouteNode) patternIfRouted() string {
+	if n.HandlerFile == "" {
+		return ""
+	}
+	return n.ToURLPattern()
+}
+
+// ValidateParamName checks that name can be used for a [param] directory
+func ValidateParamName(name string) error {
+	return validateParamName(name)
 }
 
 func validateParamName(name string) error {
@@ -123,3 +286,12 @@ func validateParamName(name string) error {
 
 	return nil
 }
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
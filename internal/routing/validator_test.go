@@ -223,6 +223,50 @@ func TestRouteNode_ValidateNode(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "not-found page with GET (valid)",
+			node: &RouteNode{
+				Path:            "/app/pages/dashboard",
+				URLSegment:      "dashboard",
+				NotFoundFile:    "/app/pages/dashboard/notfound.go",
+				HasNotFound:     true,
+				NotFoundMethods: []string{"GET"},
+			},
+			wantError: false,
+		},
+		{
+			name: "not-found page without GET",
+			node: &RouteNode{
+				Path:            "/app/pages/dashboard",
+				URLSegment:      "dashboard",
+				NotFoundFile:    "/app/pages/dashboard/notfound.go",
+				HasNotFound:     true,
+				NotFoundMethods: []string{"POST"},
+			},
+			wantError: true,
+			errorMsg:  "notfound.go must export a GET function",
+		},
+		{
+			name: "not-found page shadowed by catch-all",
+			node: &RouteNode{
+				Path:            "/app/pages/docs",
+				URLSegment:      "docs",
+				NotFoundFile:    "/app/pages/docs/notfound.go",
+				HasNotFound:     true,
+				NotFoundMethods: []string{"GET"},
+				Children: []*RouteNode{
+					{
+						Path:        "/app/pages/docs/[...slug]",
+						URLSegment:  "{slug...}",
+						IsCatchAll:  true,
+						HandlerFile: "/app/pages/docs/[...slug]/page.go",
+						Methods:     []string{"GET"},
+					},
+				},
+			},
+			wantError: true,
+			errorMsg:  "not-found page is unreachable",
+		},
 	}
 
 	for _, tt := range tests {
@@ -718,3 +762,79 @@ func TestValidateParamName_UnicodeCharacters(t *testing.T) {
 		})
 	}
 }
+
+// TestRouteNode_Diagnose_CollectsAll tests that every problem is reported, not just the first
+func TestRouteNode_Diagnose_CollectsAll(t *testing.T) {
+	root := &RouteNode{Path: "/app"}
+	pages := &RouteNode{Path: "/app/pages", URLSegment: "pages", Parent: root}
+	posts := &RouteNode{
+		Path:        "/app/pages/posts",
+		URLSegment:  "posts",
+		HandlerFile: "/app/pages/posts/page.go",
+		Methods:     []string{},
+		Parent:      pages,
+	}
+	param := &RouteNode{
+		Path:        "/app/pages/[1id]",
+		URLSegment:  "{1id}",
+		IsDynamic:   true,
+		ParamName:   "1id",
+		HandlerFile: "/app/pages/[1id]/page.go",
+		Methods:     []string{"GET"},
+		Parent:      pages,
+	}
+	pages.Children = []*RouteNode{posts, param}
+	root.Children = []*RouteNode{pages}
+
+	diags := root.Diagnose()
+	require.Len(t, diags, 2)
+
+	assert.Equal(t, CodeNoMethods, diags[0].Code)
+	assert.Equal(t, "/app/pages/posts/page.go", diags[0].File)
+	assert.Equal(t, "/posts", diags[0].Pattern)
+
+	assert.Equal(t, CodeInvalidParam, diags[1].Code)
+	assert.Equal(t, "/app/pages/[1id]", diags[1].File)
+	assert.Equal(t, "/{1id}", diags[1].Pattern)
+
+	err := root.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TWN004 /app/pages/posts/page.go: handler file must export")
+	assert.Contains(t, err.Error(), "TWN002 /app/pages/[1id]: parameter name must start with letter or underscore")
+}
+
+// TestRouteNode_Diagnose_DuplicateRoute tests the duplicate route code
+func TestRouteNode_Diagnose_DuplicateRoute(t *testing.T) {
+	pages := &RouteNode{Path: "/app/pages", URLSegment: "pages"}
+	pages.Children = []*RouteNode{
+		{URLSegment: "users", HandlerFile: "/app/pages/users/page.go", Methods: []string{"GET"}, Parent: pages},
+		{URLSegment: "users", HandlerFile: "/app/pages/Users/page.go", Methods: []string{"GET"}, Parent: pages},
+	}
+
+	diags := pages.Diagnose()
+	require.Len(t, diags, 1)
+	assert.Equal(t, CodeDuplicateRoute, diags[0].Code)
+	assert.Equal(t, "/users", diags[0].Pattern)
+}
+
+// TestRouteNode_Validate_NoProblems tests that a valid tree returns a nil error
+func TestRouteNode_Validate_NoProblems(t *testing.T) {
+	tree := &RouteNode{
+		Path: "/app",
+		Children: []*RouteNode{
+			{URLSegment: "users", HandlerFile: "/app/pages/users/page.go", Methods: []string{"GET"}},
+		},
+	}
+
+	assert.Empty(t, tree.Diagnose())
+	assert.Nil(t, tree.Validate())
+}
+
+// TestDiagnostic_String tests diagnostic formatting
+func TestDiagnostic_String(t *testing.T) {
+	d := Diagnostic{Code: CodeDuplicateRoute, Message: "duplicate route", File: "app/pages/a/page.go", Pattern: "/a"}
+	assert.Equal(t, "TWN001 app/pages/a/page.go: duplicate route (route /a)", d.String())
+
+	d.Pattern = ""
+	assert.Equal(t, "TWN001 app/pages/a/page.go: duplicate route", d.String())
+}
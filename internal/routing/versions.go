@@ -0,0 +1,163 @@
+package routing
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiVersionPattern matches the version directories directly under app/api,
+// e.g. "v1" or "v2"
+var apiVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// APIVersioning configures how the versions under app/api are registered
+type APIVersioning struct {
+	// DefaultVersion is the version whose routes are also registered without
+	// the version segment, e.g. /api/users for /api/v2/users. Empty for none.
+	DefaultVersion string
+	// Deprecations are the deprecated versions, e.g. "v1"
+	Deprecations map[string]Deprecation
+}
+
+// Deprecation describes a deprecated API version. Dates are YYYY-MM-DD or
+// RFC 3339 timestamps.
+type Deprecation struct {
+	Date   string // When the version was deprecated, sent as the Deprecation header
+	Sunset string // When the version stops working, sent as the Sunset header
+	Link   string // Migration guide, sent as a Link header with rel="deprecation"
+}
+
+// markAPIVersions sets APIVersion on the version directories below the api
+// section node and on everything under them
+func markAPIVersions(api *RouteNode) {
+	for _, child := range api.Children {
+		if apiVersionPattern.MatchString(child.URLSegment) {
+			setAPIVersion(child, child.URLSegment)
+		}
+	}
+}
+
+func setAPIVersion(node *RouteNode, version string) {
+	node.APIVersion = version
+	for _, child := range node.Children {
+		setAPIVersion(child, version)
+	}
+}
+
+// APIVersions returns the versions found under app/api, oldest first
+func (n *RouteNode) APIVersions() []string {
+	versions := make([]string, 0)
+	for _, section := range n.Children {
+		if section.URLSegment != "api" {
+			continue
+		}
+		for _, child := range section.Children {
+			if child.APIVersion != "" {
+				versions = append(versions, child.APIVersion)
+			}
+		}
+	}
+
+	slices.SortFunc(versions, func(a, b string) int {
+		return versionNumber(a) - versionNumber(b)
+	})
+	return versions
+}
+
+// versionNumber returns the number of a version such as "v2"
+func versionNumber(version string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	return n
+}
+
+// UnversionedPath returns the URL pattern of the node without its API
+// version segment, e.g. "/api/users" for "/api/v2/users". Nodes outside a
+// version return ToURLPattern.
+func (n *RouteNode) UnversionedPath() string {
+	return StripAPIVersion(n.ToURLPattern(), n.APIVersion)
+}
+
+// StripAPIVersion removes the version segment from pattern, a route under
+// /api/{version}
+func StripAPIVersion(pattern, version string) string {
+	if version == "" {
+		return pattern
+	}
+	prefix := "/api/" + version
+	if pattern == prefix {
+		return "/api"
+	}
+	if rest, ok := strings.CutPrefix(pattern, prefix+"/"); ok {
+		return "/api/" + rest
+	}
+	return pattern
+}
+
+// checkAPIVersioning reports settings naming versions that don't exist,
+// invalid deprecation dates, and default version aliases that collide with
+// routes outside the version, which ServeMux would panic on
+func (g *CodeGenerator) checkAPIVersioning(routes []*RouteNode) error {
+	if g.API.DefaultVersion == "" && len(g.API.Deprecations) == 0 {
+		return nil
+	}
+
+	versions := g.RouteTree.APIVersions()
+	if v := g.API.DefaultVersion; v != "" && !slices.Contains(versions, v) {
+		return fmt.Errorf("default API version %s not found in app/api", v)
+	}
+	for v, d := range g.API.Deprecations {
+		if !slices.Contains(versions, v) {
+			return fmt.Errorf("deprecated API version %s not found in app/api", v)
+		}
+		if _, err := parseVersionDate(d.Date); err != nil {
+			return fmt.Errorf("API version %s deprecation date: %w", v, err)
+		}
+		if d.Sunset == "" {
+			continue
+		}
+		if _, err := parseVersionDate(d.Sunset); err != nil {
+			return fmt.Errorf("API version %s sunset date: %w", v, err)
+		}
+	}
+
+	for _, alias := range routes {
+		if g.API.DefaultVersion == "" || alias.APIVersion != g.API.DefaultVersion {
+			continue
+		}
+		aliasPattern := parsePattern(alias.UnversionedPath())
+		for _, route := range routes {
+			if route.APIVersion != "" {
+				continue
+			}
+			switch comparePatterns(aliasPattern, parsePattern(route.ToURLPattern())) {
+			case relationEquivalent, relationOverlaps:
+				for _, method := range alias.Methods {
+					if containsMethod(route.Methods, method) {
+						return fmt.Errorf("%s %s, the default API version alias of %s, conflicts with %s",
+							method, alias.UnversionedPath(), alias.ToURLPattern(), route.ToURLPattern())
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseVersionDate parses a YYYY-MM-DD date or an RFC 3339 timestamp
+func parseVersionDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing date")
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date or RFC 3339 timestamp", value)
+	}
+	return t, nil
+}
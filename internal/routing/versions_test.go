@@ -0,0 +1,171 @@
+package routing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScanRoutes_APIVersions tests detecting app/api/vN directories
+func TestScanRoutes_APIVersions(t *testing.T) {
+	tmpDir := setupFixture(t, map[string]string{
+		"app/api/v10/users/route.go": createTestPageHandler("users", "GET"),
+		"app/api/v2/users/route.go":  createTestPageHandler("users", "GET"),
+		"app/api/v1/route.go":        createTestPageHandler("v1", "GET"),
+		"app/api/health/route.go":    createTestPageHandler("health", "GET"),
+		"app/api/vendors/route.go":   createTestPageHandler("vendors", "GET"),
+		"app/pages/v1/about/page.go": createTestPageHandler("about", "GET"),
+	})
+
+	root, err := ScanRoutes(filepath.Join(tmpDir, "app"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1", "v2", "v10"}, root.APIVersions())
+
+	versions := make(map[string]string)
+	for _, route := range collectRoutesForTest(root) {
+		versions[route.ToURLPattern()] = route.APIVersion
+	}
+	assert.Equal(t, map[string]string{
+		"/api/v10/users": "v10",
+		"/api/v2/users":  "v2",
+		"/api/v1":        "v1",
+		"/api/health":    "",
+		"/api/vendors":   "",
+		"/v1/about":      "",
+	}, versions)
+}
+
+// collectRoutesForTest returns the nodes with a handler below node
+func collectRoutesForTest(node *RouteNode) []*RouteNode {
+	return (&CodeGenerator{}).collectRoutes(node)
+}
+
+// TestStripAPIVersion tests removing the version segment from patterns
+func TestStripAPIVersion(t *testing.T) {
+	tests := []struct {
+		pattern, version, expected string
+	}{
+		{"/api/v2/users/{id}", "v2", "/api/users/{id}"},
+		{"/api/v2", "v2", "/api"},
+		{"/api/v20/users", "v2", "/api/v20/users"},
+		{"/api/users", "", "/api/users"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, StripAPIVersion(tt.pattern, tt.version), tt.pattern)
+	}
+}
+
+// TestCodeGenerator_APIVersioning tests default version aliases and
+// deprecated versions in the generated code
+func TestCodeGenerator_APIVersioning(t *testing.T) {
+	render := func(t *testing.T, files map[string]string, api APIVersioning) (string, error) {
+		t.Helper()
+		files["go.mod"] = "module github.com/user/testproject\n\ngo 1.22\n"
+		tmpDir := setupFixture(t, files)
+		root, err := ScanRoutes(filepath.Join(tmpDir, "app"))
+		require.NoError(t, err)
+
+		gen := &CodeGenerator{
+			RouteTree:   root,
+			ModulePath:  "github.com/user/testproject",
+			ProjectRoot: tmpDir,
+			OutputFile:  filepath.Join(tmpDir, "routes.gen.go"),
+			API:         api,
+		}
+		code, err := gen.Render()
+
+		// Aliases start with the temporary directory, leave it out
+		prefix := strings.TrimSuffix((&RouteNode{Path: filepath.Join(tmpDir, "app", "x")}).GetPackageAlias(), "x")
+		return strings.ReplaceAll(string(code), prefix, ""), err
+	}
+
+	t.Run("aliases the default version", func(t *testing.T) {
+		code, err := render(t, map[string]string{
+			"app/api/v1/users/route.go": createTestPageHandler("users", "GET"),
+			"app/api/v2/route.go":       createTestPageHandler("v2", "GET"),
+			"app/api/v2/users/route.go": createTestPageHandler("users", "GET", "POST"),
+		}, APIVersioning{DefaultVersion: "v2"})
+		require.NoError(t, err)
+
+		assert.Contains(t, code, `r.Get("/api/v2/users", api_v2_users.GET)`)
+		assert.Contains(t, code, `r.Get("/api/users", api_v2_users.GET)`)
+		assert.Contains(t, code, `r.Post("/api/users", api_v2_users.POST)`)
+		assert.Contains(t, code, `r.Get("/api", api_v2.GET)`)
+		assert.NotContains(t, code, `"/api/users", api_v1_users.GET`)
+		assert.NotContains(t, code, "Deprecation")
+	})
+
+	t.Run("marks deprecated versions", func(t *testing.T) {
+		code, err := render(t, map[string]string{
+			"app/api/v1/middleware.go":  createTestMiddleware("v1"),
+			"app/api/v1/users/route.go": createTestPageHandler("users", "GET"),
+			"app/api/v2/users/route.go": createTestPageHandler("users", "GET"),
+		}, APIVersioning{Deprecations: map[string]Deprecation{"v1": {Date: "2026-06-01", Sunset: "2027-01-01"}}})
+		require.NoError(t, err)
+
+		assert.Contains(t, code, `api_v1_users_middleware = append([]middleware.Middleware{middleware.Deprecation("2026-06-01", middleware.WithSunset("2027-01-01"))}, api_v1_users_middleware...)`)
+		assert.Contains(t, code, `r.Get("/api/v1/users", applyMiddleware(api_v1_users_middleware, api_v1_users.GET))`)
+		assert.Contains(t, code, `r.Get("/api/v2/users", api_v2_users.GET)`)
+	})
+
+	t.Run("rejects unknown versions and dates", func(t *testing.T) {
+		files := func() map[string]string {
+			return map[string]string{"app/api/v1/users/route.go": createTestPageHandler("users", "GET")}
+		}
+
+		_, err := render(t, files(), APIVersioning{DefaultVersion: "v2"})
+		assert.EqualError(t, err, "default API version v2 not found in app/api")
+
+		_, err = render(t, files(), APIVersioning{Deprecations: map[string]Deprecation{"v3": {Date: "2026-06-01"}}})
+		assert.EqualError(t, err, "deprecated API version v3 not found in app/api")
+
+		_, err = render(t, files(), APIVersioning{Deprecations: map[string]Deprecation{"v1": {Date: "June 2026"}}})
+		assert.EqualError(t, err, `API version v1 deprecation date: "June 2026" is not a YYYY-MM-DD date or RFC 3339 timestamp`)
+	})
+
+	t.Run("rejects aliases that conflict with unversioned routes", func(t *testing.T) {
+		_, err := render(t, map[string]string{
+			"app/api/v1/users/[id]/route.go":  createTestPageHandler("id", "GET"),
+			"app/api/users/[userID]/route.go": createTestPageHandler("userID", "GET", "PUT"),
+		}, APIVersioning{DefaultVersion: "v1"})
+		assert.EqualError(t, err, "GET /api/users/{id}, the default API version alias of /api/v1/users/{id}, conflicts with /api/users/{userID}")
+
+		_, err = render(t, map[string]string{
+			"app/api/v1/users/route.go": createTestPageHandler("users", "GET"),
+			"app/api/users/route.go":    createTestPageHandler("users", "POST"),
+		}, APIVersioning{DefaultVersion: "v1"})
+		assert.NoError(t, err)
+	})
+}
+
+// TestCodeGenerator_APIVersioning_CustomTemplate tests that a custom
+// template can redefine the deprecation block
+func TestCodeGenerator_APIVersioning_CustomTemplate(t *testing.T) {
+	tmpDir := setupFixture(t, map[string]string{
+		"go.mod":                    "module github.com/user/testproject\n\ngo 1.22\n",
+		"app/api/v1/users/route.go": createTestPageHandler("users", "GET"),
+		"routes.tmpl":               `{{define "deprecation"}}deprecated({{printf "%q" .Date}}){{end}}`,
+	})
+	root, err := ScanRoutes(filepath.Join(tmpDir, "app"))
+	require.NoError(t, err)
+
+	gen := &CodeGenerator{
+		RouteTree:   root,
+		ModulePath:  "github.com/user/testproject",
+		ProjectRoot: tmpDir,
+		OutputFile:  filepath.Join(tmpDir, "routes.gen.go"),
+		Template:    filepath.Join(tmpDir, "routes.tmpl"),
+		API:         APIVersioning{Deprecations: map[string]Deprecation{"v1": {Date: "2026-06-01"}}},
+	}
+	require.NoError(t, gen.Generate())
+
+	content, err := os.ReadFile(gen.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `[]middleware.Middleware{deprecated("2026-06-01")}`)
+}
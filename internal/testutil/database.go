@@ -13,7 +13,9 @@ import (
 
 // SetupTestDB creates a test database. By default, it creates an in-memory
 // SQLite database for fast, isolated testing. If POSTGRES_TEST_DSN environment
-// variable is set, it will use a PostgreSQL database instead.
+// variable is set, it will use a PostgreSQL database instead. The SQLite
+// database has a single connection, as each connection to :memory: is a
+// separate database.
 //
 // The database is automatically closed when the test completes.
 //
@@ -40,6 +42,13 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 			Logger: logger.Default.LogMode(logger.Silent),
 		})
 		require.NoError(t, err, "failed to create in-memory SQLite database")
+
+		// Every connection to :memory: is a separate database, so tests
+		// using more than one, such as concurrent or transactional ones,
+		// would see empty tables
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		sqlDB.SetMaxOpenConns(1)
 	}
 
 	// Cleanup: close database connection when test completes
@@ -44,6 +44,28 @@ func TestSetupTestDB_AutoMigrate(t *testing.T) {
 	assert.Equal(t, int64(0), count, "table should be empty")
 }
 
+func TestSetupTestDB_SharesTablesAcrossGoroutines(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	testutil.AutoMigrate(t, db, &TestModel{})
+
+	// A second connection to :memory: would be a new, empty database
+	errs := make(chan error, 4)
+	for range 4 {
+		go func() {
+			errs <- db.Transaction(func(tx *gorm.DB) error {
+				return tx.Create(&TestModel{ID: uuid.New(), Name: "Test"}).Error
+			})
+		}()
+	}
+	for range 4 {
+		require.NoError(t, <-errs)
+	}
+
+	var count int64
+	require.NoError(t, db.Model(&TestModel{}).Count(&count).Error)
+	assert.Equal(t, int64(4), count)
+}
+
 func TestAutoMigrate_CreatesTable(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 
@@ -0,0 +1,325 @@
+package testutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// FakeRedis is an in-memory server for the Redis commands the framework's
+// Redis drivers send. Expiry options are accepted and ignored.
+type FakeRedis struct {
+	// URL is the redis:// URL to connect to, with the password "secret"
+	// and database 3
+	URL string
+
+	mu       sync.Mutex
+	strings  map[string]string
+	sets     map[string]map[string]bool
+	zsets    map[string]map[string]float64
+	commands []string
+	// patterns are the channel patterns each connection subscribed to
+	patterns map[*fakeConn][]string
+}
+
+// fakeConn is a client connection, whose writes are serialized so
+// published messages don't interleave with replies
+type fakeConn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+func (c *fakeConn) write(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	io.WriteString(c.Conn, s)
+}
+
+// SetupFakeRedis serves a FakeRedis on a local port until the test ends
+func SetupFakeRedis(t *testing.T) *FakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "failed to listen for fake Redis")
+	t.Cleanup(func() { listener.Close() })
+
+	f := &FakeRedis{
+		URL:      "redis://:secret@" + listener.Addr().String() + "/3",
+		strings:  map[string]string{},
+		sets:     map[string]map[string]bool{},
+		zsets:    map[string]map[string]float64{},
+		patterns: map[*fakeConn][]string{},
+	}
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(c)
+		}
+	}()
+	return f
+}
+
+// Commands returns the commands received so far, with their arguments
+// separated by spaces
+func (f *FakeRedis) Commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func (f *FakeRedis) serve(nc net.Conn) {
+	c := &fakeConn{Conn: nc}
+	defer func() {
+		f.mu.Lock()
+		delete(f.patterns, c)
+		f.mu.Unlock()
+		c.Close()
+	}()
+	r := bufio.NewReader(c)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		c.write(f.reply(c, args))
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func (f *FakeRedis) reply(c *fakeConn, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, strings.Join(args, " "))
+
+	switch strings.ToUpper(args[0]) {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		if len(args) > 3 && strings.EqualFold(args[3], "NX") {
+			if _, ok := f.strings[args[1]]; ok {
+				return "$-1\r\n"
+			}
+		}
+		f.strings[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.strings[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(value)
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if f.exists(key) {
+				n++
+			}
+			delete(f.strings, key)
+			delete(f.sets, key)
+			delete(f.zsets, key)
+		}
+		return integer(n)
+	case "PEXPIRE", "PERSIST":
+		return integer(1)
+	case "SADD":
+		if f.sets[args[1]] == nil {
+			f.sets[args[1]] = map[string]bool{}
+		}
+		n := 0
+		for _, member := range args[2:] {
+			if !f.sets[args[1]][member] {
+				f.sets[args[1]][member] = true
+				n++
+			}
+		}
+		return integer(n)
+	case "SMEMBERS":
+		members := []string{}
+		for member := range f.sets[args[1]] {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+		return array(members)
+	case "ZADD":
+		if f.zsets[args[1]] == nil {
+			f.zsets[args[1]] = map[string]float64{}
+		}
+		n := 0
+		for i := 2; i+1 < len(args); i += 2 {
+			score, err := strconv.ParseFloat(args[i], 64)
+			if err != nil {
+				return "-ERR value is not a valid float\r\n"
+			}
+			if _, ok := f.zsets[args[1]][args[i+1]]; !ok {
+				n++
+			}
+			f.zsets[args[1]][args[i+1]] = score
+		}
+		return integer(n)
+	case "ZREM":
+		n := 0
+		for _, member := range args[2:] {
+			if _, ok := f.zsets[args[1]][member]; ok {
+				delete(f.zsets[args[1]], member)
+				n++
+			}
+		}
+		return integer(n)
+	case "ZSCORE":
+		score, ok := f.zsets[args[1]][args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(strconv.FormatFloat(score, 'f', -1, 64))
+	case "ZCARD":
+		return integer(len(f.zsets[args[1]]))
+	case "ZRANGEBYSCORE":
+		// ZRANGEBYSCORE key min max [LIMIT offset count]
+		min, max := parseScore(args[2]), parseScore(args[3])
+		members := f.sortedMembers(args[1], false)
+		var matched []string
+		for _, m := range members {
+			if s := f.zsets[args[1]][m]; s >= min && s <= max {
+				matched = append(matched, m)
+			}
+		}
+		return array(limit(matched, args[4:]))
+	case "ZRANGE", "ZREVRANGE":
+		members := f.sortedMembers(args[1], strings.EqualFold(args[0], "ZREVRANGE"))
+		start, _ := strconv.Atoi(args[2])
+		stop, _ := strconv.Atoi(args[3])
+		if stop < 0 || stop >= len(members) {
+			stop = len(members) - 1
+		}
+		if start > stop {
+			return array(nil)
+		}
+		return array(members[start : stop+1])
+	case "PSUBSCRIBE":
+		reply := ""
+		for _, pattern := range args[1:] {
+			f.patterns[c] = append(f.patterns[c], pattern)
+			reply += "*3\r\n" + bulk("psubscribe") + bulk(pattern) + integer(len(f.patterns[c]))
+		}
+		return reply
+	case "PUBLISH":
+		n := 0
+		for sub, patterns := range f.patterns {
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, args[1]); ok {
+					sub.write("*4\r\n" + bulk("pmessage") + bulk(pattern) + bulk(args[1]) + bulk(args[2]))
+					n++
+				}
+			}
+		}
+		return integer(n)
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func (f *FakeRedis) exists(key string) bool {
+	_, isString := f.strings[key]
+	return isString || f.sets[key] != nil || f.zsets[key] != nil
+}
+
+// sortedMembers returns a sorted set's members by score, then member
+func (f *FakeRedis) sortedMembers(key string, reverse bool) []string {
+	zset := f.zsets[key]
+	members := make([]string, 0, len(zset))
+	for m := range zset {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if reverse {
+			a, b = b, a
+		}
+		if zset[a] != zset[b] {
+			return zset[a] < zset[b]
+		}
+		return a < b
+	})
+	return members
+}
+
+func parseScore(s string) float64 {
+	switch s {
+	case "-inf":
+		return -1 << 62
+	case "+inf", "inf":
+		return 1 << 62
+	}
+	score, _ := strconv.ParseFloat(s, 64)
+	return score
+}
+
+// limit applies a LIMIT offset count option to members
+func limit(members []string, options []string) []string {
+	if len(options) < 3 || !strings.EqualFold(options[0], "LIMIT") {
+		return members
+	}
+	offset, _ := strconv.Atoi(options[1])
+	count, _ := strconv.Atoi(options[2])
+	if offset >= len(members) {
+		return nil
+	}
+	members = members[offset:]
+	if count >= 0 && count < len(members) {
+		members = members[:count]
+	}
+	return members
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func integer(n int) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
+
+func array(items []string) string {
+	reply := fmt.Sprintf("*%d\r\n", len(items))
+	for _, item := range items {
+		reply += bulk(item)
+	}
+	return reply
+}
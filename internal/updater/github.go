@@ -19,11 +19,23 @@ const (
 type GitHubRelease struct {
 	TagName     string         `json:"tag_name"`
 	Name        string         `json:"name"`
+	Body        string         `json:"body"`
 	Prerelease  bool           `json:"prerelease"`
 	PublishedAt time.Time      `json:"published_at"`
 	Assets      []GitHubAsset  `json:"assets"`
 }
 
+// AssetURL returns the download URL of the named asset, or "" if the
+// release doesn't have it.
+func (r *GitHubRelease) AssetURL(name string) string {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadURL
+		}
+	}
+	return ""
+}
+
 // GitHubAsset represents a downloadable asset from a release.
 type GitHubAsset struct {
 	Name               string `json:"name"`
@@ -0,0 +1,63 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/mod/modfile"
+)
+
+// FrameworkModule is the module path of the twine framework.
+const FrameworkModule = "github.com/cstone-io/twine"
+
+// ProjectVersion returns the framework version required by the go.mod in
+// dir, or "" if the project doesn't require the framework. replaced reports
+// whether go.mod replaces the framework, e.g. with a local checkout.
+func ProjectVersion(dir string) (version string, replaced bool, err error) {
+	path := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	file, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("parsing go.mod: %w", err)
+	}
+
+	for _, req := range file.Require {
+		if req.Mod.Path == FrameworkModule {
+			version = req.Mod.Version
+		}
+	}
+	for _, rep := range file.Replace {
+		if rep.Old.Path == FrameworkModule {
+			replaced = true
+		}
+	}
+
+	return version, replaced, nil
+}
+
+// Changelog returns the releases after from up to and including to, oldest
+// first. Prereleases are left out unless to is one.
+func Changelog(releases []GitHubRelease, from, to string) []GitHubRelease {
+	changes := make([]GitHubRelease, 0)
+	for _, release := range releases {
+		if !IsNewer(from, release.TagName) || IsNewer(to, release.TagName) {
+			continue
+		}
+		if release.Prerelease && CompareVersions(release.TagName, to) != 0 {
+			continue
+		}
+		changes = append(changes, release)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return IsNewer(changes[i].TagName, changes[j].TagName)
+	})
+
+	return changes
+}
@@ -0,0 +1,69 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectVersion(t *testing.T) {
+	writeGoMod := func(t *testing.T, content string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(content), 0644))
+		return dir
+	}
+
+	t.Run("requires framework", func(t *testing.T) {
+		dir := writeGoMod(t, "module example.com/app\n\ngo 1.25\n\nrequire github.com/cstone-io/twine v0.3.1\n")
+		version, replaced, err := ProjectVersion(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "v0.3.1", version)
+		assert.False(t, replaced)
+	})
+
+	t.Run("replaced framework", func(t *testing.T) {
+		dir := writeGoMod(t, "module example.com/app\n\nrequire github.com/cstone-io/twine v0.0.0\n\nreplace github.com/cstone-io/twine => ../twine\n")
+		version, replaced, err := ProjectVersion(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.0", version)
+		assert.True(t, replaced)
+	})
+
+	t.Run("no framework", func(t *testing.T) {
+		dir := writeGoMod(t, "module example.com/app\n")
+		version, _, err := ProjectVersion(dir)
+		require.NoError(t, err)
+		assert.Empty(t, version)
+	})
+
+	t.Run("no go.mod", func(t *testing.T) {
+		_, _, err := ProjectVersion(t.TempDir())
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestChangelog(t *testing.T) {
+	releases := []GitHubRelease{
+		{TagName: "v0.5.0-rc.1", Prerelease: true},
+		{TagName: "v0.4.0"},
+		{TagName: "v0.3.0"},
+		{TagName: "v0.2.0"},
+		{TagName: "v0.1.0"},
+	}
+
+	tags := func(releases []GitHubRelease) []string {
+		names := make([]string, 0, len(releases))
+		for _, r := range releases {
+			names = append(names, r.TagName)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"v0.2.0", "v0.3.0", "v0.4.0"}, tags(Changelog(releases, "v0.1.0", "v0.4.0")))
+	assert.Equal(t, []string{"v0.4.0", "v0.5.0-rc.1"}, tags(Changelog(releases, "v0.3.0", "v0.5.0-rc.1")))
+	assert.Empty(t, Changelog(releases, "v0.4.0", "v0.4.0"))
+	assert.Empty(t, Changelog(releases, "v0.4.0", "v0.2.0"))
+}
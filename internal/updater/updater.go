@@ -15,6 +15,10 @@ type UpdateOptions struct {
 
 	// CurrentVersion is the version of the currently running binary.
 	CurrentVersion string
+
+	// SkipVerify installs the binary even if the release has no checksums.
+	// A checksum or signature that is present but wrong is always an error.
+	SkipVerify bool
 }
 
 // UpdateResult contains information about the update operation.
@@ -44,6 +48,14 @@ func NewUpdater() *Updater {
 	}
 }
 
+// NewUpdaterWithBaseURL creates an updater that talks to a GitHub-compatible
+// API at baseURL, such as a mirror or a test server.
+func NewUpdaterWithBaseURL(baseURL string) *Updater {
+	u := NewUpdater()
+	u.github.baseURL = baseURL
+	return u
+}
+
 // GetGitHubClient returns the GitHub client for accessing release information.
 func (u *Updater) GetGitHubClient() *GitHubClient {
 	return u.github
@@ -109,13 +121,7 @@ func (u *Updater) Update(opts UpdateOptions) (*UpdateResult, error) {
 
 	// Find the binary for the current platform
 	assetName := getBinaryName(runtime.GOOS, runtime.GOARCH)
-	var assetURL string
-	for _, asset := range release.Assets {
-		if asset.Name == assetName {
-			assetURL = asset.BrowserDownloadURL
-			break
-		}
-	}
+	assetURL := release.AssetURL(assetName)
 
 	if assetURL == "" {
 		return nil, fmt.Errorf("no binary available for %s/%s in release %s", runtime.GOOS, runtime.GOARCH, release.TagName)
@@ -127,6 +133,11 @@ func (u *Updater) Update(opts UpdateOptions) (*UpdateResult, error) {
 		return nil, fmt.Errorf("failed to download binary: %w", err)
 	}
 
+	// Verify it before replacing the running binary
+	if err := u.verify(release, assetName, data, opts.SkipVerify); err != nil {
+		return nil, err
+	}
+
 	// Install the new binary
 	if err := installBinary(data); err != nil {
 		return nil, fmt.Errorf("failed to install binary: %w", err)
@@ -140,6 +151,39 @@ func (u *Updater) Update(opts UpdateOptions) (*UpdateResult, error) {
 	}, nil
 }
 
+// verify checks the downloaded binary against the release's checksums and,
+// when a signing key is built in, the checksums against their signature.
+func (u *Updater) verify(release *GitHubRelease, assetName string, data []byte, skipVerify bool) error {
+	checksumsURL := release.AssetURL(checksumsAsset)
+	if checksumsURL == "" {
+		if skipVerify {
+			return nil
+		}
+		return fmt.Errorf("release %s has no %s to verify the download against (use --skip-verify to install anyway)", release.TagName, checksumsAsset)
+	}
+
+	checksums, err := u.github.DownloadAsset(checksumsURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", checksumsAsset, err)
+	}
+
+	if SigningKey != "" {
+		signatureURL := release.AssetURL(signatureAsset)
+		if signatureURL == "" {
+			return fmt.Errorf("release %s has no %s", release.TagName, signatureAsset)
+		}
+		signature, err := u.github.DownloadAsset(signatureURL)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", signatureAsset, err)
+		}
+		if err := VerifySignature(checksums, signature, SigningKey); err != nil {
+			return err
+		}
+	}
+
+	return VerifyChecksum(data, assetName, checksums)
+}
+
 // getBinaryName returns the expected binary name for the given OS and architecture.
 func getBinaryName(goos, goarch string) string {
 	name := fmt.Sprintf("twine-%s-%s", goos, goarch)
@@ -0,0 +1,79 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// checksumsAsset lists the SHA-256 of every binary in a release, in the
+	// format written by sha256sum.
+	checksumsAsset = "checksums.txt"
+
+	// signatureAsset is the base64 ed25519 signature of checksumsAsset.
+	signatureAsset = "checksums.txt.sig"
+)
+
+// SigningKey is the base64 ed25519 public key that release checksums are
+// signed with. It is set at build time with -ldflags; when empty, checksums
+// are verified but signatures are not.
+var SigningKey = ""
+
+// VerifyChecksum checks data against the entry for assetName in a
+// sha256sum-style checksums file.
+func VerifyChecksum(data []byte, assetName string, checksums []byte) error {
+	want, err := findChecksum(checksums, assetName)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", assetName, want, got)
+	}
+
+	return nil
+}
+
+// findChecksum returns the hex SHA-256 listed for assetName.
+func findChecksum(checksums []byte, assetName string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary mode with a * before the file name
+		if strings.TrimPrefix(fields[1], "*") == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum for %s in %s", assetName, checksumsAsset)
+}
+
+// VerifySignature checks a base64 ed25519 signature of checksums against
+// the base64 public key.
+func VerifySignature(checksums, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid signing key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, sig) {
+		return fmt.Errorf("signature verification failed for %s", checksumsAsset)
+	}
+
+	return nil
+}
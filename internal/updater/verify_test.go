@@ -0,0 +1,120 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checksumLine(data []byte, name string) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + "  " + name + "\n"
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	binary := []byte("new binary")
+	checksums := []byte(checksumLine([]byte("other"), "twine-darwin-arm64") + checksumLine(binary, "twine-linux-amd64"))
+
+	t.Run("matching checksum", func(t *testing.T) {
+		assert.NoError(t, VerifyChecksum(binary, "twine-linux-amd64", checksums))
+	})
+
+	t.Run("binary mode marker", func(t *testing.T) {
+		sum := sha256.Sum256(binary)
+		starred := []byte(hex.EncodeToString(sum[:]) + " *twine-linux-amd64\n")
+		assert.NoError(t, VerifyChecksum(binary, "twine-linux-amd64", starred))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		err := VerifyChecksum([]byte("tampered"), "twine-linux-amd64", checksums)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch for twine-linux-amd64")
+	})
+
+	t.Run("missing entry", func(t *testing.T) {
+		err := VerifyChecksum(binary, "twine-windows-amd64.exe", checksums)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no checksum for twine-windows-amd64.exe")
+	})
+}
+
+func TestVerifySignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	key := base64.StdEncoding.EncodeToString(public)
+
+	checksums := []byte(checksumLine([]byte("binary"), "twine-linux-amd64"))
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, checksums)) + "\n")
+
+	t.Run("valid signature", func(t *testing.T) {
+		assert.NoError(t, VerifySignature(checksums, signature, key))
+	})
+
+	t.Run("modified checksums", func(t *testing.T) {
+		err := VerifySignature(append(checksums, "extra\n"...), signature, key)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signature verification failed")
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		err := VerifySignature(checksums, signature, "not-a-key")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid signing key")
+	})
+}
+
+func TestUpdaterVerify(t *testing.T) {
+	binary := []byte("new binary")
+	checksums := []byte(checksumLine(binary, "twine-linux-amd64"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(checksums)
+	}))
+	defer server.Close()
+
+	u := NewUpdater()
+	withChecksums := &GitHubRelease{
+		TagName: "v2.0.0",
+		Assets:  []GitHubAsset{{Name: checksumsAsset, BrowserDownloadURL: server.URL}},
+	}
+	withoutChecksums := &GitHubRelease{TagName: "v1.0.0"}
+
+	t.Run("verified download", func(t *testing.T) {
+		assert.NoError(t, u.verify(withChecksums, "twine-linux-amd64", binary, false))
+	})
+
+	t.Run("tampered download", func(t *testing.T) {
+		// A bad checksum fails even with skipVerify
+		err := u.verify(withChecksums, "twine-linux-amd64", []byte("tampered"), true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch")
+	})
+
+	t.Run("release without checksums", func(t *testing.T) {
+		err := u.verify(withoutChecksums, "twine-linux-amd64", binary, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--skip-verify")
+
+		assert.NoError(t, u.verify(withoutChecksums, "twine-linux-amd64", binary, true))
+	})
+
+	t.Run("signing key requires a signature", func(t *testing.T) {
+		public, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+
+		original := SigningKey
+		SigningKey = base64.StdEncoding.EncodeToString(public)
+		defer func() { SigningKey = original }()
+
+		err = u.verify(withChecksums, "twine-linux-amd64", binary, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no "+signatureAsset)
+	})
+}
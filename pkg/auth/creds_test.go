@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/cstone-io/twine/pkg/config"
 	twineerrors "github.com/cstone-io/twine/pkg/errors"
 )
 
@@ -32,14 +33,13 @@ func TestHashPassword(t *testing.T) {
 		assert.NotEqual(t, password, hash)
 	})
 
-	t.Run("hash starts with bcrypt prefix", func(t *testing.T) {
+	t.Run("hash is an argon2id PHC string", func(t *testing.T) {
 		password := "test123"
 
 		hash, err := HashPassword(password)
 		require.NoError(t, err)
 
-		// Bcrypt hashes start with $2a$, $2b$, or $2y$
-		assert.True(t, strings.HasPrefix(hash, "$2"))
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"))
 	})
 
 	t.Run("generates unique hashes for same password", func(t *testing.T) {
@@ -51,7 +51,7 @@ func TestHashPassword(t *testing.T) {
 		hash2, err := HashPassword(password)
 		require.NoError(t, err)
 
-		// Same password should produce different hashes (bcrypt uses salt)
+		// Same password should produce different hashes (random salt)
 		assert.NotEqual(t, hash1, hash2)
 	})
 
@@ -77,20 +77,32 @@ func TestHashPassword(t *testing.T) {
 	})
 
 	t.Run("handles very long password", func(t *testing.T) {
-		// Bcrypt has a 72-byte limit
-		password := strings.Repeat("a", 72)
+		// Argon2id has no 72-byte limit, unlike bcrypt
+		password := strings.Repeat("a", 100)
 
 		hash, err := HashPassword(password)
 		require.NoError(t, err)
-		assert.NotEmpty(t, hash)
+
+		creds := Credentials{Password: strings.Repeat("a", 72)}
+		assert.Error(t, creds.Authenticate(hash), "every byte counts")
 	})
 
-	t.Run("rejects password exceeding 72 bytes", func(t *testing.T) {
-		// Bcrypt has a 72-byte limit
+	t.Run("bcrypt rejects password exceeding 72 bytes", func(t *testing.T) {
+		withAuthConfig(t, func(a *config.AuthConfig) { a.PasswordHasher = config.PasswordBcrypt })
 		password := strings.Repeat("a", 100)
 
 		_, err := HashPassword(password)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, twineerrors.ErrHashPassword)
+	})
+
+	t.Run("uses configured argon2 parameters", func(t *testing.T) {
+		withAuthConfig(t, func(a *config.AuthConfig) {
+			a.Argon2Memory, a.Argon2Time, a.Argon2Threads = 8192, 1, 2
+		})
+
+		hash, err := HashPassword("password")
+		require.NoError(t, err)
+		assert.Contains(t, hash, "$m=8192,t=1,p=2$")
 	})
 
 	t.Run("handles special characters", func(t *testing.T) {
@@ -109,7 +121,8 @@ func TestHashPassword(t *testing.T) {
 		assert.NotEmpty(t, hash)
 	})
 
-	t.Run("hash can be verified with bcrypt", func(t *testing.T) {
+	t.Run("bcrypt hash can be verified with bcrypt", func(t *testing.T) {
+		withAuthConfig(t, func(a *config.AuthConfig) { a.PasswordHasher = config.PasswordBcrypt })
 		password := "testPassword"
 
 		hash, err := HashPassword(password)
@@ -121,6 +134,72 @@ func TestHashPassword(t *testing.T) {
 	})
 }
 
+// TestVerifyPassword_Bcrypt tests verifying hashes made before Argon2id
+func TestVerifyPassword_Bcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	assert.NoError(t, VerifyPassword(string(legacy), "password"))
+	assert.ErrorIs(t, VerifyPassword(string(legacy), "wrong"), twineerrors.ErrAuthInvalidCredentials)
+
+	// bcrypt would compare only the first 72 bytes and accept this
+	long := strings.Repeat("a", 72)
+	truncated, err := bcrypt.GenerateFromPassword([]byte(long), bcrypt.MinCost)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyPassword(string(truncated), long))
+	err = VerifyPassword(string(truncated), long+"extra")
+	assert.ErrorIs(t, err, twineerrors.ErrAuthInvalidCredentials)
+	assert.ErrorIs(t, err, bcrypt.ErrPasswordTooLong)
+}
+
+// TestVerifyPassword_MalformedArgon2 tests rejecting unreadable hashes
+func TestVerifyPassword_MalformedArgon2(t *testing.T) {
+	hash, err := HashPassword("password")
+	require.NoError(t, err)
+	parts := strings.Split(hash, "$")
+
+	for name, bad := range map[string]string{
+		"missing key":       strings.Join(parts[:5], "$"),
+		"other version":     strings.Replace(hash, "v=19", "v=16", 1),
+		"zero time":         strings.Replace(hash, "t=2", "t=0", 1),
+		"bad salt":          strings.Replace(hash, parts[4], "!!", 1),
+		"bad parameters":    strings.Replace(hash, parts[3], "m=x", 1),
+		"too little memory": strings.Replace(hash, "m=19456", "m=1", 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, VerifyPassword(bad, "password"), twineerrors.ErrAuthInvalidCredentials)
+		})
+	}
+}
+
+// TestNeedsRehash tests detecting hashes made with other settings
+func TestNeedsRehash(t *testing.T) {
+	current, err := HashPassword("password")
+	require.NoError(t, err)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	cheap, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	assert.False(t, NeedsRehash(current))
+	assert.True(t, NeedsRehash(string(legacy)), "bcrypt hashes upgrade to argon2id")
+	assert.True(t, NeedsRehash("not-a-hash"))
+
+	t.Run("after the argon2 parameters change", func(t *testing.T) {
+		withAuthConfig(t, func(a *config.AuthConfig) { a.Argon2Time = 3 })
+
+		assert.True(t, NeedsRehash(current))
+	})
+
+	t.Run("with the bcrypt hasher", func(t *testing.T) {
+		withAuthConfig(t, func(a *config.AuthConfig) { a.PasswordHasher = config.PasswordBcrypt })
+
+		assert.False(t, NeedsRehash(string(legacy)))
+		assert.True(t, NeedsRehash(string(cheap)), "below the default cost")
+		assert.True(t, NeedsRehash(current))
+	})
+}
+
 // TestCredentials_Authenticate tests password authentication
 func TestCredentials_Authenticate(t *testing.T) {
 	t.Run("authenticates with correct password", func(t *testing.T) {
@@ -395,7 +474,7 @@ func TestAuth_ThreadSafety(t *testing.T) {
 			assert.NotEmpty(t, hashes[i])
 		}
 
-		// All hashes should be unique (bcrypt uses random salt)
+		// All hashes should be unique (random salt)
 		seen := make(map[string]bool)
 		for _, hash := range hashes {
 			assert.False(t, seen[hash], "Found duplicate hash")
@@ -478,7 +557,7 @@ func TestAuth_Security(t *testing.T) {
 			"wrong1",
 			"wrong2",
 			"completely different",
-			"p", // Very short
+			"p",                      // Very short
 			strings.Repeat("a", 100), // Very long
 		}
 
@@ -519,8 +598,7 @@ func TestAuth_Security(t *testing.T) {
 
 // TestAuth_EdgeCases tests edge cases and boundary conditions
 func TestAuth_EdgeCases(t *testing.T) {
-	t.Run("password at 72 byte limit", func(t *testing.T) {
-		// Bcrypt has a max length of 72 bytes
+	t.Run("password at bcrypt's 72 byte limit", func(t *testing.T) {
 		password := strings.Repeat("a", 72)
 
 		hash, err := HashPassword(password)
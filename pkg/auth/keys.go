@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// JWK is a public key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// N and E are an RSA key's modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Crv and X are an Ed25519 key's curve and public key
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, served at /.well-known/jwks.json by
+// JWKSHandler
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeys returns the keys that verify the application's tokens:
+// AUTH_PRIVATE_KEY's public key first, then AUTH_PUBLIC_KEYS. Other
+// services verify tokens with the key whose kid is in the token's header.
+// With HS256 the set is empty, as the secret can't be shared.
+func PublicKeys() (*JWKS, error) {
+	keys, err := loadKeys()
+	if err != nil {
+		return nil, err
+	}
+	return &keys.jwks, nil
+}
+
+// JWKSHandler serves PublicKeys, for services that trust tokens signed
+// with RS256 or EdDSA. Mount it at the well-known path:
+//
+//	r.Get("/.well-known/jwks.json", auth.JWKSHandler)
+func JWKSHandler(k *kit.Kit) error {
+	keys, err := PublicKeys()
+	if err != nil {
+		return err
+	}
+	// Verifiers may cache the keys briefly; rotated keys stay listed in
+	// AUTH_PUBLIC_KEYS for longer than that
+	k.Response.Header().Set("Cache-Control", "public, max-age=300")
+	return k.JSON(http.StatusOK, keys)
+}
+
+// keySet holds the keys tokens are signed and verified with
+type keySet struct {
+	method jwt.SigningMethod
+	// kid names signKey in the header of signed tokens; empty with HS256
+	kid     string
+	signKey any
+	// verifyKeys are the public keys by kid
+	verifyKeys map[string]any
+	jwks       JWKS
+}
+
+// keySource is the configuration a keySet was loaded from
+type keySource struct {
+	algorithm, secret, privateKey, publicKeys string
+}
+
+var (
+	keysMu     sync.Mutex
+	keysSource keySource
+	keys       *keySet
+)
+
+// loadKeys returns the keySet for the current AuthConfig, loading it again
+// when a reload changed the keys
+func loadKeys() (*keySet, error) {
+	cfg := config.Get().Auth
+	source := keySource{cfg.Algorithm, cfg.SecretKey, cfg.PrivateKey, cfg.PublicKeys}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if keys != nil && keysSource == source {
+		return keys, nil
+	}
+
+	loaded, err := newKeySet(source)
+	if err != nil {
+		return nil, errors.ErrLoadSigningKey.Wrap(err).WithValue(cfg.Algorithm)
+	}
+	keys, keysSource = loaded, source
+	return keys, nil
+}
+
+// newKeySet parses the keys of source
+func newKeySet(source keySource) (*keySet, error) {
+	set := &keySet{verifyKeys: map[string]any{}, jwks: JWKS{Keys: []JWK{}}}
+
+	var parsePrivate func([]byte) (any, error)
+	var parsePublic func([]byte) (any, error)
+	switch source.algorithm {
+	case config.AuthHS256, "":
+		set.method = jwt.SigningMethodHS256
+		set.signKey = []byte(source.secret)
+		return set, nil
+	case config.AuthRS256:
+		set.method = jwt.SigningMethodRS256
+		parsePrivate = func(b []byte) (any, error) { return jwt.ParseRSAPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (any, error) { return jwt.ParseRSAPublicKeyFromPEM(b) }
+	case config.AuthEdDSA:
+		set.method = jwt.SigningMethodEdDSA
+		parsePrivate = func(b []byte) (any, error) { return jwt.ParseEdPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (any, error) { return jwt.ParseEdPublicKeyFromPEM(b) }
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", source.algorithm)
+	}
+
+	if source.privateKey == "" {
+		return nil, fmt.Errorf("AUTH_PRIVATE_KEY is required for %s", source.algorithm)
+	}
+	blocks, err := readPEM(source.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_PRIVATE_KEY: %w", err)
+	}
+	if len(blocks) != 1 {
+		return nil, fmt.Errorf("AUTH_PRIVATE_KEY: want one PEM key, found %d", len(blocks))
+	}
+	private, err := parsePrivate(blocks[0])
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_PRIVATE_KEY: %w", err)
+	}
+	set.signKey = private
+	if set.kid, err = set.addPublic(private.(crypto.Signer).Public()); err != nil {
+		return nil, fmt.Errorf("AUTH_PRIVATE_KEY: %w", err)
+	}
+
+	if source.publicKeys == "" {
+		return set, nil
+	}
+	blocks, err = readPEM(source.publicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_PUBLIC_KEYS: %w", err)
+	}
+	for _, block := range blocks {
+		public, err := parsePublic(block)
+		if err != nil {
+			return nil, fmt.Errorf("AUTH_PUBLIC_KEYS: %w", err)
+		}
+		if _, err := set.addPublic(public); err != nil {
+			return nil, fmt.Errorf("AUTH_PUBLIC_KEYS: %w", err)
+		}
+	}
+	return set, nil
+}
+
+// addPublic adds a verifying key to the set, returning its kid
+func (s *keySet) addPublic(public any) (string, error) {
+	jwk, err := newJWK(public, s.method.Alg())
+	if err != nil {
+		return "", err
+	}
+	if _, ok := s.verifyKeys[jwk.Kid]; !ok {
+		s.verifyKeys[jwk.Kid] = public
+		s.jwks.Keys = append(s.jwks.Keys, jwk)
+	}
+	return jwk.Kid, nil
+}
+
+// keyFunc returns the key that verifies token, rejecting tokens signed
+// with another algorithm
+func (s *keySet) keyFunc(token *jwt.Token) (any, error) {
+	if token.Method.Alg() != s.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	if len(s.verifyKeys) == 0 {
+		return s.signKey, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// readPEM returns the PEM blocks in value: PEM text, with newlines
+// escaped as \n when it came from a one-line variable, or comma-separated
+// paths of PEM files
+func readPEM(value string) ([][]byte, error) {
+	var data []byte
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		data = []byte(strings.ReplaceAll(value, `\n`, "\n"))
+	} else {
+		for _, path := range strings.Split(value, ",") {
+			file, err := os.ReadFile(strings.TrimSpace(path))
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, file...)
+			data = append(data, '\n')
+		}
+	}
+
+	var blocks [][]byte
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, pem.EncodeToMemory(block))
+		data = rest
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no PEM key found")
+	}
+	return blocks, nil
+}
+
+// newJWK describes public as a JWK, with its RFC 7638 thumbprint as the
+// kid, so the same key always has the same kid
+func newJWK(public any, alg string) (JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	var jwk JWK
+	var members any
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{Kty: "RSA", N: encode(key.N.Bytes()), E: encode(big.NewInt(int64(key.E)).Bytes())}
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case ed25519.PublicKey:
+		jwk = JWK{Kty: "OKP", Crv: "Ed25519", X: encode(key)}
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	// The thumbprint hashes the required members in lexicographic order
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return JWK{}, err
+	}
+	sum := sha256.Sum256(canonical)
+	jwk.Kid = encode(sum[:])
+	jwk.Use = "sig"
+	jwk.Alg = alg
+	return jwk, nil
+}
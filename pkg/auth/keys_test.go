@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// pemKeys returns the PKCS #8 private and PKIX public PEM of key
+func pemKeys(t *testing.T, key crypto.Signer) (private, public string) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func newEdKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+// TestKeys_RS256 tests signing with an RSA key and verifying with the
+// published JWK, as another service would
+func TestKeys_RS256(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	key := newRSAKey(t)
+	private, _ := pemKeys(t, key)
+	withAuthConfig(t, func(cfg *config.AuthConfig) {
+		cfg.Algorithm = config.AuthRS256
+		cfg.PrivateKey = private
+	})
+
+	userID := uuid.New()
+	token, err := NewToken(userID, "user@example.com")
+	require.NoError(t, err)
+	claims, err := ParseToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), claims.UserID)
+
+	jwks, err := PublicKeys()
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 1)
+	jwk := jwks.Keys[0]
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, "RS256", jwk.Alg)
+	assert.Equal(t, "sig", jwk.Use)
+
+	decode := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return new(big.Int).SetBytes(b)
+	}
+	published := &rsa.PublicKey{N: decode(jwk.N), E: int(decode(jwk.E).Int64())}
+	parsed, err := jwt.Parse(token.Token, func(token *jwt.Token) (any, error) {
+		assert.Equal(t, jwk.Kid, token.Header["kid"])
+		return published, nil
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+}
+
+// TestKeys_EdDSA tests signing with an Ed25519 key read from a file
+func TestKeys_EdDSA(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	private, _ := pemKeys(t, newEdKey(t))
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(path, []byte(private), 0600))
+	withAuthConfig(t, func(cfg *config.AuthConfig) {
+		cfg.Algorithm = config.AuthEdDSA
+		cfg.PrivateKey = path
+	})
+
+	token, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+	_, err = ParseToken(token.Token)
+	require.NoError(t, err)
+
+	jwks, err := PublicKeys()
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+}
+
+// TestKeys_Rotation tests verifying tokens signed with a rotated key while
+// it is listed in AUTH_PUBLIC_KEYS
+func TestKeys_Rotation(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	oldPrivate, oldPublic := pemKeys(t, newEdKey(t))
+	newPrivate, _ := pemKeys(t, newEdKey(t))
+	withAuthConfig(t, func(cfg *config.AuthConfig) {
+		cfg.Algorithm = config.AuthEdDSA
+		cfg.PrivateKey = oldPrivate
+	})
+	old, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+	before, err := PublicKeys()
+	require.NoError(t, err)
+
+	config.Get().Auth.PrivateKey = newPrivate
+	_, err = ParseToken(old.Token)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthInvalidToken, "the old key is gone")
+
+	config.Get().Auth.PublicKeys = oldPublic
+	_, err = ParseToken(old.Token)
+	assert.NoError(t, err)
+
+	current, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+	_, err = ParseToken(current.Token)
+	assert.NoError(t, err)
+
+	after, err := PublicKeys()
+	require.NoError(t, err)
+	require.Len(t, after.Keys, 2)
+	assert.NotEqual(t, before.Keys[0].Kid, after.Keys[0].Kid, "the new key signs")
+	assert.Equal(t, before.Keys[0].Kid, after.Keys[1].Kid, "a key keeps its kid")
+}
+
+// TestKeys_AlgorithmMismatch tests rejecting tokens signed with another
+// algorithm, such as an HS256 token keyed with the public key
+func TestKeys_AlgorithmMismatch(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	hs256, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+
+	private, _ := pemKeys(t, newRSAKey(t))
+	withAuthConfig(t, func(cfg *config.AuthConfig) {
+		cfg.Algorithm = config.AuthRS256
+		cfg.PrivateKey = private
+	})
+	_, err = ParseToken(hs256.Token)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthInvalidToken)
+}
+
+// TestKeys_Invalid tests reporting keys that don't load
+func TestKeys_Invalid(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	rsaPrivate, _ := pemKeys(t, newRSAKey(t))
+	edPrivate, edPublic := pemKeys(t, newEdKey(t))
+	for name, cfg := range map[string]config.AuthConfig{
+		"missing key":    {Algorithm: config.AuthRS256},
+		"missing file":   {Algorithm: config.AuthRS256, PrivateKey: filepath.Join(t.TempDir(), "missing.pem")},
+		"not pem":        {Algorithm: config.AuthRS256, PrivateKey: "-----BEGIN nothing"},
+		"wrong key type": {Algorithm: config.AuthRS256, PrivateKey: edPrivate},
+		"wrong public":   {Algorithm: config.AuthRS256, PrivateKey: rsaPrivate, PublicKeys: edPublic},
+	} {
+		withAuthConfig(t, func(c *config.AuthConfig) { *c = cfg })
+		_, err := PublicKeys()
+		assert.ErrorIs(t, err, twineerrors.ErrLoadSigningKey, name)
+	}
+}
+
+// TestReadPEM tests reading keys from text, escaped text and files
+func TestReadPEM(t *testing.T) {
+	_, first := pemKeys(t, newEdKey(t))
+	_, second := pemKeys(t, newEdKey(t))
+
+	blocks, err := readPEM(first + second)
+	require.NoError(t, err)
+	assert.Len(t, blocks, 2)
+
+	blocks, err = readPEM(strings.ReplaceAll(first, "\n", `\n`))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(first)}, blocks)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.pem"), []byte(first), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.pem"), []byte(second), 0600))
+	blocks, err = readPEM(filepath.Join(dir, "a.pem") + ", " + filepath.Join(dir, "b.pem"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(first), []byte(second)}, blocks)
+}
+
+// TestJWKSHandler tests serving the token verification keys, none with
+// HS256
+func TestJWKSHandler(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	k := &kit.Kit{Response: w, Request: httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)}
+
+	require.NoError(t, JWKSHandler(k))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"keys": []}`, w.Body.String())
+}
@@ -0,0 +1,307 @@
+// Package oauth signs users in with OAuth 2.0 and OpenID Connect providers
+// such as Google and GitHub, then starts the application's own session or
+// tokens for them.
+//
+// Two routes handle a provider, which twine init --with-auth generates:
+//
+//	// app/api/auth/google/route.go
+//	func GET(k *kit.Kit) error {
+//	    return oauth.Begin(k, "google")
+//	}
+//
+//	// app/api/auth/google/callback/route.go
+//	func GET(k *kit.Kit) error {
+//	    return oauth.Callback(k, "google")
+//	}
+//
+// Between them the application maps the provider's user to its own with
+// the function set by UseUpsert.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/auth"
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// StateCookie holds the state and PKCE verifier of a sign-in between
+// Begin and Callback
+const StateCookie = "oauth_state"
+
+// stateTTL is how long a user has to sign in at the provider
+const stateTTL = 10 * time.Minute
+
+// UpsertFunc finds or creates the application's user for a provider's
+// user, returning their ID
+type UpsertFunc func(ctx context.Context, user *User) (uuid.UUID, error)
+
+// SignInFunc signs in the application's user once Callback has found them
+type SignInFunc func(k *kit.Kit, userID uuid.UUID, user *User) error
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]*Provider{}
+	upsert    UpsertFunc
+	signIn    SignInFunc = SessionSignIn
+
+	// discovered caches the providers of AUTH_OIDC_ISSUER, by issuer
+	discovered = map[string]*Provider{}
+)
+
+// Register adds a provider, replacing one with the same name, including
+// those configured with AUTH_* settings
+func Register(p *Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name] = p
+}
+
+// UseUpsert sets the function that maps a provider's user to the
+// application's. It must be set before users can sign in:
+//
+//	oauth.UseUpsert(func(ctx context.Context, u *oauth.User) (uuid.UUID, error) {
+//	    user, err := users.FindOrCreateByProvider(ctx, u.Provider, u.ID, u.Email)
+//	    if err != nil {
+//	        return uuid.Nil, err
+//	    }
+//	    return user.ID, nil
+//	})
+func UseUpsert(fn UpsertFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	upsert = fn
+}
+
+// UseSignIn sets how users are signed in after Callback:
+// SessionSignIn, the default, or TokenSignIn
+func UseSignIn(fn SignInFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	signIn = fn
+}
+
+// SessionSignIn starts a server-side session with auth.Login
+func SessionSignIn(k *kit.Kit, userID uuid.UUID, _ *User) error {
+	return auth.Login(k, userID)
+}
+
+// TokenSignIn sets the access and refresh tokens of an auth.NewTokenPair
+// as cookies
+func TokenSignIn(k *kit.Kit, userID uuid.UUID, user *User) error {
+	pair, err := auth.NewTokenPair(k.Request.Context(), userID, user.Email)
+	if err != nil {
+		return err
+	}
+	k.SetAuthCookies(pair.AccessToken, pair.RefreshToken)
+	return nil
+}
+
+// Lookup returns the provider called name: one added with Register, or
+// google, github and oidc when their AUTH_* client ID is set. Unknown
+// providers fail with errors.ErrAuthUnknownProvider.
+func Lookup(ctx context.Context, name string) (*Provider, error) {
+	mu.RLock()
+	p, ok := providers[name]
+	mu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	cfg := config.Get().Auth.OAuth
+	switch {
+	case name == "google" && cfg.GoogleClientID != "":
+		return Google(cfg.GoogleClientID, cfg.GoogleClientSecret), nil
+	case name == "github" && cfg.GitHubClientID != "":
+		return GitHub(cfg.GitHubClientID, cfg.GitHubClientSecret), nil
+	case name == "oidc" && cfg.OIDCClientID != "":
+		return discover(ctx, cfg)
+	}
+	return nil, errors.ErrAuthUnknownProvider.WithValue(name)
+}
+
+// discover returns the provider of AUTH_OIDC_ISSUER, discovering its
+// endpoints once per issuer
+func discover(ctx context.Context, cfg config.OAuthConfig) (*Provider, error) {
+	mu.RLock()
+	p, ok := discovered[cfg.OIDCIssuer]
+	mu.RUnlock()
+	if !ok {
+		var err error
+		if p, err = Discover(ctx, "oidc", cfg.OIDCIssuer, "", ""); err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		discovered[cfg.OIDCIssuer] = p
+		mu.Unlock()
+	}
+
+	configured := *p
+	configured.ClientID, configured.ClientSecret = cfg.OIDCClientID, cfg.OIDCClientSecret
+	return &configured, nil
+}
+
+// Begin sends the user to the provider called name to sign in. A next
+// query parameter with a path on this site is where Callback sends them
+// afterwards.
+func Begin(k *kit.Kit, name string) error {
+	p, err := Lookup(k.Request.Context(), name)
+	if err != nil {
+		return err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return errors.ErrOAuthExchange.Wrap(err)
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return errors.ErrOAuthExchange.Wrap(err)
+	}
+	value := url.Values{
+		"provider": {name},
+		"state":    {state},
+		"verifier": {verifier},
+		"next":     {localPath(k.Request.URL.Query().Get("next"))},
+	}
+	setStateCookie(k, value.Encode(), stateTTL)
+
+	challenge := sha256.Sum256([]byte(verifier))
+	return k.Redirect(p.AuthCodeURL(state, base64.RawURLEncoding.EncodeToString(challenge[:]), redirectURL(k, p)))
+}
+
+// Callback completes a sign-in started by Begin when the provider sends
+// the user back: it checks the state, exchanges the code for a token,
+// loads the provider's user, maps them to the application's with the
+// UseUpsert function and signs them in. The user then goes to the next
+// path given to Begin, or /.
+//
+// Sign-ins not started by this browser fail with errors.ErrAuthOAuthState,
+// and those the user cancelled with errors.ErrAuthOAuthDenied.
+func Callback(k *kit.Kit, name string) error {
+	ctx := k.Request.Context()
+	p, err := Lookup(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	saved, _ := k.GetCookie(StateCookie)
+	setStateCookie(k, "", -1)
+	stored, _ := url.ParseQuery(saved)
+	query := k.Request.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		return errors.ErrAuthOAuthDenied.WithValue(reason)
+	}
+	state := query.Get("state")
+	if stored.Get("provider") != name || state == "" ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(stored.Get("state"))) != 1 {
+		return errors.ErrAuthOAuthState
+	}
+
+	mu.RLock()
+	upsertUser, signInUser := upsert, signIn
+	mu.RUnlock()
+	if upsertUser == nil {
+		return errors.ErrOAuthExchange.Wrap(fmt.Errorf("no user upsert function; call oauth.UseUpsert"))
+	}
+
+	token, err := p.Exchange(ctx, query.Get("code"), stored.Get("verifier"), redirectURL(k, p))
+	if err != nil {
+		return errors.ErrOAuthExchange.Wrap(err).WithValue(name)
+	}
+	user, err := p.User(ctx, token)
+	if err != nil {
+		if _, ok := errors.As(err); ok {
+			return err
+		}
+		return errors.ErrOAuthExchange.Wrap(err).WithValue(name)
+	}
+	userID, err := upsertUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	if err := signInUser(k, userID, user); err != nil {
+		return err
+	}
+
+	// The provider sent the user here, so SameSite=Strict cookies set
+	// above aren't sent on a redirect; a page on this site navigating
+	// onwards sends them
+	next := html.EscapeString(localPath(stored.Get("next")))
+	return k.HTML(http.StatusOK, `<!DOCTYPE html><meta http-equiv="refresh" content="0;url=`+next+`"><a href="`+next+`">Continue</a>`)
+}
+
+// redirectURL is the callback URL of p for the request's host
+func redirectURL(k *kit.Kit, p *Provider) string {
+	if p.RedirectURL != "" {
+		return p.RedirectURL
+	}
+
+	r := k.Request
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	cfg := config.Get()
+	if cfg.Server.IsTrustedProxy(r.RemoteAddr) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+	}
+	root := strings.TrimSuffix(cfg.Routes.Root, "/")
+	return scheme + "://" + host + root + "/api/auth/" + url.PathEscape(p.Name) + "/callback"
+}
+
+// localPath returns path if it is a path on this site, or /, so Callback
+// can't be used to send users elsewhere
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// setStateCookie sets StateCookie for ttl, or deletes it if ttl is
+// negative. It is SameSite=Lax so the provider's redirect back sends it.
+func setStateCookie(k *kit.Kit, value string, ttl time.Duration) {
+	cookie := &http.Cookie{
+		Name:     StateCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl / time.Second),
+		SameSite: http.SameSiteLaxMode,
+		Secure:   k.SecureCookies(),
+		HttpOnly: true,
+	}
+	if ttl < 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(k.Response, cookie)
+}
+
+// randomString returns 32 random bytes, base64url-encoded
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
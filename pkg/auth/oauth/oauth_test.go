@@ -0,0 +1,275 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/auth"
+	"github.com/cstone-io/twine/pkg/config"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// fakeProvider is an authorization server that issues one code
+type fakeProvider struct {
+	*httptest.Server
+	challenge string // code_challenge of the last authorization request
+	code      string
+	userinfo  map[string]any
+}
+
+// newFakeProvider starts a provider whose users are described by userinfo
+func newFakeProvider(t *testing.T, userinfo map[string]any) *fakeProvider {
+	t.Helper()
+	f := &fakeProvider{code: "the-code", userinfo: userinfo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"userinfo_endpoint":      f.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != f.code || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge ||
+			r.PostFormValue("client_secret") != "client-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "access", "token_type": "Bearer", "expires_in": 3600})
+	})
+	mux.HandleFunc("GET /userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(f.userinfo)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// provider returns a Provider for f called name
+func (f *fakeProvider) provider(name string) *Provider {
+	return &Provider{
+		Name:         name,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		AuthURL:      f.URL + "/authorize",
+		TokenURL:     f.URL + "/token",
+		UserInfoURL:  f.URL + "/userinfo",
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+// setupOAuth restores the registered providers and hooks after the test
+func setupOAuth(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	saved, savedUpsert, savedSignIn := providers, upsert, signIn
+	providers = map[string]*Provider{}
+	mu.Unlock()
+	auth.UseSessionStore(auth.NewMemorySessionStore())
+
+	t.Cleanup(func() {
+		mu.Lock()
+		providers, upsert, signIn = saved, savedUpsert, savedSignIn
+		mu.Unlock()
+		auth.ResetSessionStore()
+	})
+}
+
+// newKit returns a Kit for a GET of target carrying cookies
+func newKit(target string, cookies ...*http.Cookie) (*kit.Kit, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	return &kit.Kit{Response: w, Request: r}, w
+}
+
+// cookie returns the cookie called name set on w
+func cookie(t *testing.T, w *httptest.ResponseRecorder, name string) *http.Cookie {
+	t.Helper()
+	for _, c := range w.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	require.Failf(t, "missing cookie", "no %s cookie", name)
+	return nil
+}
+
+// begin starts a sign-in with provider, returning the state cookie and the
+// query of the provider's authorization URL
+func begin(t *testing.T, f *fakeProvider, provider, target string) (*http.Cookie, url.Values) {
+	t.Helper()
+	k, w := newKit(target)
+	require.NoError(t, Begin(k, provider))
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	query := location.Query()
+	f.challenge = query.Get("code_challenge")
+	return cookie(t, w, StateCookie), query
+}
+
+// TestSignIn tests signing in through a provider and being sent back
+func TestSignIn(t *testing.T) {
+	setupOAuth(t)
+	f := newFakeProvider(t, map[string]any{"sub": "42", "email": "ada@example.com", "email_verified": true, "name": "Ada"})
+	Register(f.provider("fake"))
+
+	userID := uuid.New()
+	var upserted *User
+	UseUpsert(func(ctx context.Context, user *User) (uuid.UUID, error) {
+		upserted = user
+		return userID, nil
+	})
+
+	state, query := begin(t, f, "fake", "/api/auth/fake?next=/orders")
+	assert.True(t, state.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, state.SameSite)
+	assert.Equal(t, "client-id", query.Get("client_id"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "openid email", query.Get("scope"))
+	assert.Equal(t, "http://example.com/api/auth/fake/callback", query.Get("redirect_uri"))
+
+	k, w := newKit("/api/auth/fake/callback?code=the-code&state="+query.Get("state"), state)
+	require.NoError(t, Callback(k, "fake"))
+	require.NotNil(t, upserted)
+	assert.Equal(t, User{
+		Provider: "fake", ID: "42", Email: "ada@example.com", EmailVerified: true, Name: "Ada", Raw: f.userinfo,
+	}, *upserted)
+	assert.Contains(t, w.Body.String(), `url=/orders"`)
+	assert.Equal(t, -1, cookie(t, w, StateCookie).MaxAge, "the state is used once")
+
+	current, err := auth.CurrentUser(k)
+	require.NoError(t, err, "a session was started")
+	assert.Equal(t, userID, current)
+}
+
+// TestCallback_Rejects tests refusing sign-ins this browser didn't start
+func TestCallback_Rejects(t *testing.T) {
+	setupOAuth(t)
+	f := newFakeProvider(t, map[string]any{"sub": "42"})
+	Register(f.provider("fake"))
+	Register(f.provider("other"))
+	UseUpsert(func(context.Context, *User) (uuid.UUID, error) {
+		return uuid.New(), nil
+	})
+
+	state, query := begin(t, f, "fake", "/api/auth/fake")
+
+	t.Run("without the state cookie", func(t *testing.T) {
+		k, _ := newKit("/api/auth/fake/callback?code=the-code&state=" + query.Get("state"))
+		assert.ErrorIs(t, Callback(k, "fake"), twineerrors.ErrAuthOAuthState)
+	})
+
+	t.Run("with another state", func(t *testing.T) {
+		k, _ := newKit("/api/auth/fake/callback?code=the-code&state=forged", state)
+		assert.ErrorIs(t, Callback(k, "fake"), twineerrors.ErrAuthOAuthState)
+	})
+
+	t.Run("for another provider", func(t *testing.T) {
+		k, _ := newKit("/api/auth/other/callback?code=the-code&state="+query.Get("state"), state)
+		assert.ErrorIs(t, Callback(k, "other"), twineerrors.ErrAuthOAuthState)
+	})
+
+	t.Run("cancelled at the provider", func(t *testing.T) {
+		k, _ := newKit("/api/auth/fake/callback?error=access_denied&state="+query.Get("state"), state)
+		assert.ErrorIs(t, Callback(k, "fake"), twineerrors.ErrAuthOAuthDenied)
+	})
+
+	t.Run("with a bad code", func(t *testing.T) {
+		k, _ := newKit("/api/auth/fake/callback?code=stolen&state="+query.Get("state"), state)
+		assert.ErrorIs(t, Callback(k, "fake"), twineerrors.ErrOAuthExchange)
+	})
+}
+
+// TestLookup tests finding registered and configured providers
+func TestLookup(t *testing.T) {
+	setupOAuth(t)
+	f := newFakeProvider(t, nil)
+	cfg := config.Get()
+	original := cfg.Auth
+	t.Cleanup(func() { cfg.Auth = original })
+	cfg.Auth.OAuth = config.OAuthConfig{
+		GoogleClientID: "google-id", GoogleClientSecret: "google-secret",
+		OIDCIssuer: f.URL, OIDCClientID: "oidc-id", OIDCClientSecret: "oidc-secret",
+	}
+	ctx := context.Background()
+
+	p, err := Lookup(ctx, "google")
+	require.NoError(t, err)
+	assert.Equal(t, "google-id", p.ClientID)
+	assert.Equal(t, "https://oauth2.googleapis.com/token", p.TokenURL)
+
+	p, err = Lookup(ctx, "oidc")
+	require.NoError(t, err)
+	assert.Equal(t, "oidc-id", p.ClientID)
+	assert.Equal(t, f.URL+"/token", p.TokenURL, "endpoints are discovered")
+
+	_, err = Lookup(ctx, "github")
+	assert.ErrorIs(t, err, twineerrors.ErrAuthUnknownProvider, "github has no client ID")
+
+	Register(&Provider{Name: "google", ClientID: "registered"})
+	p, err = Lookup(ctx, "google")
+	require.NoError(t, err)
+	assert.Equal(t, "registered", p.ClientID, "registered providers win")
+}
+
+// TestGitHubUser tests reading a GitHub user and their primary email
+func TestGitHubUser(t *testing.T) {
+	emails := []map[string]any{
+		{"email": "old@example.com", "primary": false, "verified": true},
+		{"email": "ada@example.com", "primary": true, "verified": true},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"id": 1234, "login": "ada", "avatar_url": "https://avatars.example.com/1234"})
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(emails)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	p := GitHub("id", "secret")
+	p.UserInfoURL = server.URL + "/user"
+
+	user, err := p.User(context.Background(), &Token{AccessToken: "access"})
+	require.NoError(t, err)
+	assert.Equal(t, "1234", user.ID)
+	assert.Equal(t, "ada", user.Name, "the login stands in for a missing name")
+	assert.Equal(t, "ada@example.com", user.Email)
+	assert.True(t, user.EmailVerified)
+
+	emails[1]["verified"] = false
+	_, err = p.User(context.Background(), &Token{AccessToken: "access"})
+	assert.ErrorIs(t, err, twineerrors.ErrPrimaryEmailNotFound)
+}
+
+// TestLocalPath tests keeping redirects after sign-in on this site
+func TestLocalPath(t *testing.T) {
+	assert.Equal(t, "/orders?page=2", localPath("/orders?page=2"))
+	assert.Equal(t, "/", localPath(""))
+	assert.Equal(t, "/", localPath("https://evil.example.com"))
+	assert.Equal(t, "/", localPath("//evil.example.com"))
+	assert.Equal(t, "/", localPath(`/\evil.example.com`))
+}
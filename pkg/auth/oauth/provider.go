@@ -0,0 +1,303 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// Provider is an OAuth 2.0 authorization server users sign in with
+type Provider struct {
+	// Name identifies the provider in URLs, as in /api/auth/{Name}
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	Scopes       []string
+	// RedirectURL is the callback URL registered with the provider. Empty
+	// uses /api/auth/{Name}/callback on the host of the sign-in request.
+	RedirectURL string
+	// FetchUser loads the signed-in user with their access token. Nil reads
+	// the OpenID Connect claims at UserInfoURL.
+	FetchUser func(ctx context.Context, p *Provider, token *Token) (*User, error)
+	// Client makes the requests to the provider; nil uses a client with a
+	// 10 second timeout
+	Client *http.Client
+}
+
+// Token is the response of a provider's token endpoint
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	IDToken      string
+	Expiry       time.Time
+}
+
+// User is the account a user signed in with at a provider
+type User struct {
+	// Provider is the Name of the provider
+	Provider string
+	// ID identifies the user at the provider; it never changes, unlike
+	// their email
+	ID            string
+	Email         string
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+	// Raw is the provider's response the user was read from
+	Raw map[string]any
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Google returns the provider for Google accounts
+func Google(clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         "google",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		UserInfoURL:  "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// GitHub returns the provider for GitHub accounts. The user's email is
+// their primary, verified address.
+func GitHub(clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         "github",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		UserInfoURL:  "https://api.github.com/user",
+		Scopes:       []string{"read:user", "user:email"},
+		FetchUser:    fetchGitHubUser,
+	}
+}
+
+// Discover returns the provider for an OpenID Connect issuer, reading its
+// endpoints from issuer/.well-known/openid-configuration
+func Discover(ctx context.Context, name, issuer, clientID, clientSecret string) (*Provider, error) {
+	p := &Provider{Name: name, ClientID: clientID, ClientSecret: clientSecret}
+	var doc struct {
+		Issuer      string `json:"issuer"`
+		AuthURL     string `json:"authorization_endpoint"`
+		TokenURL    string `json:"token_endpoint"`
+		UserInfoURL string `json:"userinfo_endpoint"`
+	}
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, "", &doc); err != nil {
+		return nil, errors.ErrOAuthExchange.Wrap(err).WithValue(issuer)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, errors.ErrOAuthExchange.Wrap(fmt.Errorf("discovery document is for issuer %q", doc.Issuer)).WithValue(issuer)
+	}
+	if doc.AuthURL == "" || doc.TokenURL == "" || doc.UserInfoURL == "" {
+		return nil, errors.ErrOAuthExchange.Wrap(fmt.Errorf("discovery document lacks an endpoint")).WithValue(issuer)
+	}
+
+	p.AuthURL, p.TokenURL, p.UserInfoURL = doc.AuthURL, doc.TokenURL, doc.UserInfoURL
+	p.Scopes = []string{"openid", "email", "profile"}
+	return p, nil
+}
+
+// AuthCodeURL is the provider's page that asks the user to sign in,
+// returning to redirectURL with a code. challenge is the S256 PKCE code
+// challenge.
+func (p *Provider) AuthCodeURL(state, challenge, redirectURL string) string {
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {redirectURL},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	if len(p.Scopes) > 0 {
+		q.Set("scope", strings.Join(p.Scopes, " "))
+	}
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + q.Encode()
+}
+
+// Exchange trades the code the provider returned for a token, proving with
+// the PKCE verifier that this client started the sign-in
+func (p *Provider) Exchange(ctx context.Context, code, verifier, redirectURL string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		AccessToken  string          `json:"access_token"`
+		TokenType    string          `json:"token_type"`
+		RefreshToken string          `json:"refresh_token"`
+		IDToken      string          `json:"id_token"`
+		ExpiresIn    json.RawMessage `json:"expires_in"`
+		Error        string          `json:"error"`
+		Description  string          `json:"error_description"`
+	}
+	if err := p.do(req, &body); err != nil && body.Error == "" {
+		return nil, err
+	}
+	// GitHub reports errors with 200 OK
+	if body.Error != "" {
+		return nil, fmt.Errorf("token endpoint: %s: %s", body.Error, body.Description)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned no access token")
+	}
+
+	token := &Token{
+		AccessToken:  body.AccessToken,
+		TokenType:    body.TokenType,
+		RefreshToken: body.RefreshToken,
+		IDToken:      body.IDToken,
+	}
+	// Some providers send expires_in as a string
+	if seconds, err := strconv.Atoi(strings.Trim(string(body.ExpiresIn), `"`)); err == nil && seconds > 0 {
+		token.Expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
+
+// User loads the user an access token belongs to
+func (p *Provider) User(ctx context.Context, token *Token) (*User, error) {
+	if p.FetchUser != nil {
+		return p.FetchUser(ctx, p, token)
+	}
+
+	var claims map[string]any
+	if err := p.getJSON(ctx, p.UserInfoURL, token.AccessToken, &claims); err != nil {
+		return nil, err
+	}
+	user := &User{
+		Provider:  p.Name,
+		ID:        stringClaim(claims, "sub"),
+		Email:     stringClaim(claims, "email"),
+		Name:      stringClaim(claims, "name"),
+		AvatarURL: stringClaim(claims, "picture"),
+		Raw:       claims,
+	}
+	// Some providers send email_verified as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		user.EmailVerified = verified
+	case string:
+		user.EmailVerified = verified == "true"
+	}
+	if user.ID == "" {
+		return nil, fmt.Errorf("userinfo has no sub claim")
+	}
+	return user, nil
+}
+
+// fetchGitHubUser reads the user from the GitHub API, with their primary
+// email, which /user leaves out when it is private
+func fetchGitHubUser(ctx context.Context, p *Provider, token *Token) (*User, error) {
+	var profile map[string]any
+	if err := p.getJSON(ctx, p.UserInfoURL, token.AccessToken, &profile); err != nil {
+		return nil, err
+	}
+	id, ok := profile["id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("GitHub user has no id")
+	}
+	user := &User{
+		Provider:  p.Name,
+		ID:        strconv.FormatInt(int64(id), 10),
+		Name:      stringClaim(profile, "name"),
+		AvatarURL: stringClaim(profile, "avatar_url"),
+		Raw:       profile,
+	}
+	if user.Name == "" {
+		user.Name = stringClaim(profile, "login")
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, p.UserInfoURL+"/emails", token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			user.Email, user.EmailVerified = e.Email, true
+			return user, nil
+		}
+	}
+	return nil, errors.ErrPrimaryEmailNotFound.WithValue(user.ID)
+}
+
+// getJSON decodes the response to a GET of rawURL, authorized by an
+// access token if there is one
+func (p *Provider) getJSON(ctx context.Context, rawURL, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return p.do(req, v)
+}
+
+// do sends req and decodes the JSON response into v, failing on statuses
+// other than 2xx after decoding what it can
+func (p *Provider) do(req *http.Request, v any) error {
+	client := p.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, v)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+	}
+	return decodeErr
+}
+
+// stringClaim returns claims[name] if it is a string
+func stringClaim(claims map[string]any, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// MaxBcryptPasswordLength is the longest password bcrypt hashes. Longer
+// passwords can't be hashed with bcrypt, and never match a bcrypt hash,
+// rather than being silently truncated to it.
+const MaxBcryptPasswordLength = 72
+
+// Lengths of the salt and key of Argon2id hashes
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// hashSlots bounds how many Argon2id hashes run at once, since each takes
+// AUTH_ARGON2_MEMORY: a burst of logins queues rather than exhausting
+// memory
+var hashSlots = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+// argon2Params are the parameters of an Argon2id hash
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// HashPassword hashes a password with AUTH_PASSWORD_HASHER: Argon2id, the
+// default, with the AUTH_ARGON2_* parameters, or bcrypt. Argon2id hashes
+// are PHC strings:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+//
+// bcrypt fails with errors.ErrHashPassword for passwords longer than
+// MaxBcryptPasswordLength bytes.
+func HashPassword(password string) (string, error) {
+	cfg := config.Get().Auth
+	if cfg.PasswordHasher == config.PasswordBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", errors.ErrHashPassword.Wrap(err)
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.ErrHashPassword.Wrap(err)
+	}
+	p := configuredArgon2(cfg)
+	key := deriveArgon2([]byte(password), salt, p, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPassword reports whether password matches hash, an Argon2id hash
+// or a bcrypt hash from before Argon2id was the default. Mismatches and
+// unreadable hashes fail with errors.ErrAuthInvalidCredentials.
+func VerifyPassword(hash, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return errors.ErrAuthInvalidCredentials.Wrap(err)
+		}
+		derived := deriveArgon2([]byte(password), salt, p, uint32(len(key)))
+		if subtle.ConstantTimeCompare(derived, key) != 1 {
+			return errors.ErrAuthInvalidCredentials
+		}
+		return nil
+	}
+
+	// bcrypt.CompareHashAndPassword would compare only the first 72 bytes
+	if len(password) > MaxBcryptPasswordLength {
+		return errors.ErrAuthInvalidCredentials.Wrap(bcrypt.ErrPasswordTooLong)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return errors.ErrAuthInvalidCredentials.Wrap(err)
+	}
+	return nil
+}
+
+// NeedsRehash reports whether hash was made other than HashPassword makes
+// hashes now: with bcrypt while AUTH_PASSWORD_HASHER is argon2id, with
+// other AUTH_ARGON2_* parameters, or with a lower bcrypt cost. Rehash the
+// password after it was verified, while it is at hand:
+//
+//	if err := creds.Authenticate(user.Password); err != nil {
+//	    return err
+//	}
+//	if auth.NeedsRehash(user.Password) {
+//	    if hash, err := auth.HashPassword(creds.Password); err == nil {
+//	        users.UpdatePassword(ctx, user.ID, hash)
+//	    }
+//	}
+func NeedsRehash(hash string) bool {
+	cfg := config.Get().Auth
+	if cfg.PasswordHasher == config.PasswordBcrypt {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost < bcrypt.DefaultCost
+	}
+
+	p, _, key, err := decodeArgon2(hash)
+	return err != nil || p != configuredArgon2(cfg) || len(key) != argon2KeyLength
+}
+
+// configuredArgon2 returns the AUTH_ARGON2_* parameters, or their defaults
+func configuredArgon2(cfg config.AuthConfig) argon2Params {
+	p := argon2Params{
+		memory:  uint32(config.DefaultArgon2Memory),
+		time:    uint32(config.DefaultArgon2Time),
+		threads: uint8(config.DefaultArgon2Threads),
+	}
+	if cfg.Argon2Memory > 0 {
+		p.memory = uint32(cfg.Argon2Memory)
+	}
+	if cfg.Argon2Time > 0 {
+		p.time = uint32(cfg.Argon2Time)
+	}
+	if cfg.Argon2Threads > 0 {
+		p.threads = uint8(cfg.Argon2Threads)
+	}
+	return p
+}
+
+// deriveArgon2 derives a key from password, waiting for a hash slot
+func deriveArgon2(password, salt []byte, p argon2Params, keyLength uint32) []byte {
+	hashSlots <- struct{}{}
+	defer func() { <-hashSlots }()
+	return argon2.IDKey(password, salt, p.time, p.memory, p.threads, keyLength)
+}
+
+// decodeArgon2 reads the parameters, salt and key of an Argon2id hash
+func decodeArgon2(hash string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, nil, nil, fmt.Errorf("argon2 parameters %q: %w", parts[3], err)
+	}
+	if p.time == 0 || p.threads == 0 || p.memory < 8*uint32(p.threads) {
+		return p, nil, nil, fmt.Errorf("invalid argon2 parameters %q", parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, fmt.Errorf("argon2 key: %w", err)
+	}
+	return p, salt, key, nil
+}
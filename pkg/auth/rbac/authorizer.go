@@ -0,0 +1,70 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+
+	"github.com/cstone-io/twine/pkg/auth"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// grants are the roles and permissions of a request's user
+type grants struct {
+	roles       []string
+	permissions []string
+}
+
+type grantsKey struct{}
+
+// HasRole reports whether the request's user has role, either assigned in
+// the database or in their JWT's roles claim
+func (r *RBAC) HasRole(k *kit.Kit, role string) (bool, error) {
+	g, err := r.grants(k)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(g.roles, role), nil
+}
+
+// Can reports whether any of the request's user's roles grants permission
+func (r *RBAC) Can(k *kit.Kit, permission string) (bool, error) {
+	g, err := r.grants(k)
+	if err != nil {
+		return false, err
+	}
+	return allows(g.permissions, permission), nil
+}
+
+// grants loads the roles and permissions of the user in
+// k.GetContext("user"), set by middleware.JWTMiddleware or
+// SessionMiddleware. They are loaded once per request.
+func (r *RBAC) grants(k *kit.Kit) (*grants, error) {
+	ctx := k.Request.Context()
+	if g, ok := ctx.Value(grantsKey{}).(*grants); ok {
+		return g, nil
+	}
+
+	user := k.GetContext("user")
+	if user == "" {
+		return &grants{}, nil
+	}
+	roles, err := r.roles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if claims := auth.ClaimsFromContext(ctx); claims != nil && claims.UserID == user {
+		for _, role := range claims.Roles {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	permissions, err := r.permissions(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &grants{roles: roles, permissions: permissions}
+	k.Request = k.Request.WithContext(context.WithValue(ctx, grantsKey{}, g))
+	return g, nil
+}
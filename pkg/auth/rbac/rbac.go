@@ -0,0 +1,251 @@
+// Package rbac grants permissions to users through roles kept in the
+// database. Importing it registers the tables' migrations and makes it the
+// kit.Authorizer behind k.Can and middleware.RequirePermission.
+package rbac
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// Permission is something a user may do, named like "orders:write"
+type Permission struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:100;uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// Role is a named set of permissions, such as "admin"
+type Role struct {
+	ID          uint         `gorm:"primaryKey"`
+	Name        string       `gorm:"size:100;uniqueIndex"`
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time
+}
+
+// UserRole assigns a role to a user
+type UserRole struct {
+	UserID    string `gorm:"primaryKey;size:36"`
+	RoleID    uint   `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// PermissionMigration creates the permissions table. It and the other
+// migrations below are registered when the package is imported.
+var PermissionMigration = database.NewMigrationBuilder().
+	Model(&Permission{}).
+	Name("permissions").
+	Down(func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&Permission{})
+	}).
+	Build()
+
+// RoleMigration creates the roles table and role_permissions, which joins
+// roles to their permissions
+var RoleMigration = database.NewMigrationBuilder().
+	Model(&Role{}).
+	Name("roles").
+	Deps(PermissionMigration).
+	Down(func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("role_permissions", &Role{})
+	}).
+	Build()
+
+// UserRoleMigration creates the user_roles table
+var UserRoleMigration = database.NewMigrationBuilder().
+	Model(&UserRole{}).
+	Name("user_roles").
+	Deps(RoleMigration).
+	Down(func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&UserRole{})
+	}).
+	Build()
+
+func init() {
+	database.RegisterMigrations(PermissionMigration, RoleMigration, UserRoleMigration)
+	kit.UseAuthorizer(Default())
+}
+
+// RBAC reads and changes the roles and permissions in the database
+type RBAC struct {
+	db *gorm.DB
+}
+
+// New creates an RBAC on db, or on database.GORM() if db is nil
+func New(db *gorm.DB) *RBAC {
+	return &RBAC{db: db}
+}
+
+// Default returns an RBAC on database.GORM()
+func Default() *RBAC {
+	return New(nil)
+}
+
+func (r *RBAC) client(ctx context.Context) *gorm.DB {
+	db := r.db
+	if db == nil {
+		db = database.GORM()
+	}
+	return db.WithContext(ctx)
+}
+
+// Grant gives role the permissions, creating the role and permissions that
+// don't exist yet:
+//
+//	err := rbac.Default().Grant(ctx, "admin", "orders:read", "orders:write")
+func (r *RBAC) Grant(ctx context.Context, role string, permissions ...string) error {
+	err := r.client(ctx).Transaction(func(tx *gorm.DB) error {
+		record, err := findOrCreateRole(tx, role)
+		if err != nil {
+			return err
+		}
+		records := make([]Permission, 0, len(permissions))
+		for _, name := range permissions {
+			p := Permission{Name: name}
+			if err := tx.Where(Permission{Name: name}).FirstOrCreate(&p).Error; err != nil {
+				return err
+			}
+			records = append(records, p)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		return tx.Model(record).Association("Permissions").Append(records)
+	})
+	if err != nil {
+		return errors.ErrUpdatePermissions.Wrap(err).WithValue(role)
+	}
+	return nil
+}
+
+// Revoke takes the permissions from role
+func (r *RBAC) Revoke(ctx context.Context, role string, permissions ...string) error {
+	err := r.client(ctx).Transaction(func(tx *gorm.DB) error {
+		var record Role
+		if err := tx.Where("name = ?", role).Limit(1).Find(&record).Error; err != nil || record.ID == 0 {
+			return err
+		}
+		var records []Permission
+		if err := tx.Where("name IN ?", permissions).Find(&records).Error; err != nil || len(records) == 0 {
+			return err
+		}
+		return tx.Model(&record).Association("Permissions").Delete(records)
+	})
+	if err != nil {
+		return errors.ErrUpdatePermissions.Wrap(err).WithValue(role)
+	}
+	return nil
+}
+
+// Assign gives a user the roles, creating the roles that don't exist yet
+func (r *RBAC) Assign(ctx context.Context, userID uuid.UUID, roles ...string) error {
+	err := r.client(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, name := range roles {
+			record, err := findOrCreateRole(tx, name)
+			if err != nil {
+				return err
+			}
+			assignment := UserRole{UserID: userID.String(), RoleID: record.ID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignment).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.ErrUpdatePermissions.Wrap(err).WithValue(userID.String())
+	}
+	return nil
+}
+
+// Unassign takes the roles from a user
+func (r *RBAC) Unassign(ctx context.Context, userID uuid.UUID, roles ...string) error {
+	db := r.client(ctx)
+	err := db.Where("user_id = ? AND role_id IN (?)", userID.String(),
+		db.Model(&Role{}).Select("id").Where("name IN ?", roles)).
+		Delete(&UserRole{}).Error
+	if err != nil {
+		return errors.ErrUpdatePermissions.Wrap(err).WithValue(userID.String())
+	}
+	return nil
+}
+
+// Roles returns the names of a user's roles, sorted
+func (r *RBAC) Roles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return r.roles(ctx, userID.String())
+}
+
+// Permissions returns the names of the permissions a user has through
+// their roles, sorted
+func (r *RBAC) Permissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	roles, err := r.roles(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	return r.permissions(ctx, roles)
+}
+
+// roles returns the names of userID's roles
+func (r *RBAC) roles(ctx context.Context, userID string) ([]string, error) {
+	names := []string{}
+	err := r.client(ctx).Model(&Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Pluck("roles.name", &names).Error
+	if err != nil {
+		return nil, errors.ErrGetPermissions.Wrap(err).WithValue(userID)
+	}
+	return names, nil
+}
+
+// permissions returns the names of the permissions granted to roles
+func (r *RBAC) permissions(ctx context.Context, roles []string) ([]string, error) {
+	names := []string{}
+	if len(roles) == 0 {
+		return names, nil
+	}
+	err := r.client(ctx).Model(&Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name IN ?", roles).
+		Order("permissions.name").
+		Pluck("permissions.name", &names).Error
+	if err != nil {
+		return nil, errors.ErrGetPermissions.Wrap(err)
+	}
+	return names, nil
+}
+
+// findOrCreateRole returns the role called name, creating it if needed
+func findOrCreateRole(tx *gorm.DB, name string) (*Role, error) {
+	role := Role{Name: name}
+	if err := tx.Where(Role{Name: name}).FirstOrCreate(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// allows reports whether granted includes permission, directly or through
+// a wildcard: "*" allows everything, and "orders:*" every permission
+// starting with "orders:"
+func allows(granted []string, permission string) bool {
+	return slices.ContainsFunc(granted, func(g string) bool {
+		if g == permission || g == "*" {
+			return true
+		}
+		prefix, ok := strings.CutSuffix(g, "*")
+		return ok && strings.HasPrefix(permission, prefix)
+	})
+}
@@ -0,0 +1,165 @@
+package rbac
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/auth"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// setupRBAC returns an RBAC on a fresh, migrated database
+func setupRBAC(t *testing.T) *RBAC {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&Permission{}, &Role{}, &UserRole{}))
+	return New(db)
+}
+
+// newUserKit returns a Kit for a request by user
+func newUserKit(user string) *kit.Kit {
+	k := &kit.Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+	if user != "" {
+		k.SetContext("user", user)
+	}
+	return k
+}
+
+// TestRBAC tests granting permissions to roles and roles to users
+func TestRBAC(t *testing.T) {
+	r := setupRBAC(t)
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+
+	require.NoError(t, r.Grant(ctx, "editor", "orders:read", "orders:write"))
+	require.NoError(t, r.Grant(ctx, "viewer", "orders:read"))
+	require.NoError(t, r.Grant(ctx, "editor", "orders:write"), "granting twice is harmless")
+	require.NoError(t, r.Assign(ctx, alice, "editor", "viewer"))
+	require.NoError(t, r.Assign(ctx, alice, "editor"), "assigning twice is harmless")
+	require.NoError(t, r.Assign(ctx, bob, "viewer"))
+
+	roles, err := r.Roles(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor", "viewer"}, roles)
+
+	permissions, err := r.Permissions(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders:read", "orders:write"}, permissions)
+
+	permissions, err = r.Permissions(ctx, bob)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders:read"}, permissions)
+
+	require.NoError(t, r.Revoke(ctx, "editor", "orders:write"))
+	permissions, err = r.Permissions(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders:read"}, permissions)
+
+	require.NoError(t, r.Unassign(ctx, alice, "editor", "viewer"))
+	roles, err = r.Roles(ctx, alice)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	roles, err = r.Roles(ctx, bob)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"viewer"}, roles, "other users keep their roles")
+
+	require.NoError(t, r.Revoke(ctx, "missing", "orders:read"), "revoking from a missing role is harmless")
+}
+
+// TestRBAC_Authorizer tests checking a request's user against their roles
+func TestRBAC_Authorizer(t *testing.T) {
+	r := setupRBAC(t)
+	ctx := context.Background()
+	user := uuid.New()
+
+	require.NoError(t, r.Grant(ctx, "admin", "*"))
+	require.NoError(t, r.Grant(ctx, "clerk", "orders:*"))
+	require.NoError(t, r.Grant(ctx, "auditor", "reports:read"))
+	require.NoError(t, r.Assign(ctx, user, "clerk"))
+
+	t.Run("checks roles and permissions from the database", func(t *testing.T) {
+		k := newUserKit(user.String())
+		ok, err := r.HasRole(k, "clerk")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = r.Can(k, "orders:write")
+		require.NoError(t, err)
+		assert.True(t, ok, "orders:* covers orders:write")
+
+		ok, err = r.Can(k, "reports:read")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("adds roles from the JWT", func(t *testing.T) {
+		claims := &auth.Claims{UserID: user.String(), Roles: []string{"auditor"}}
+		k := newUserKit(user.String())
+		k.Request = k.Request.WithContext(auth.ContextWithClaims(k.Request.Context(), claims))
+		ok, err := r.Can(k, "reports:read")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = r.Can(k, "orders:read")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("caches grants for the request", func(t *testing.T) {
+		k := newUserKit(user.String())
+		ok, err := r.Can(k, "reports:read")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		require.NoError(t, r.Assign(ctx, user, "admin"))
+		ok, err = r.Can(k, "reports:read")
+		require.NoError(t, err)
+		assert.False(t, ok, "the request keeps the grants it loaded")
+
+		ok, err = r.Can(newUserKit(user.String()), "reports:read")
+		require.NoError(t, err)
+		assert.True(t, ok, "* covers everything")
+	})
+
+	t.Run("denies requests without a user", func(t *testing.T) {
+		ok, err := r.Can(newUserKit(""), "orders:read")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+// TestRBAC_Errors tests wrapping database failures
+func TestRBAC_Errors(t *testing.T) {
+	r := New(testutil.SetupTestDB(t))
+	ctx := context.Background()
+
+	assert.ErrorIs(t, r.Grant(ctx, "admin", "*"), twineerrors.ErrUpdatePermissions)
+	_, err := r.Roles(ctx, uuid.New())
+	assert.ErrorIs(t, err, twineerrors.ErrGetPermissions)
+}
+
+// TestAllows tests matching permissions against wildcards
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		granted    []string
+		permission string
+		want       bool
+	}{
+		{[]string{"orders:write"}, "orders:write", true},
+		{[]string{"orders:read"}, "orders:write", false},
+		{[]string{"*"}, "orders:write", true},
+		{[]string{"orders:*"}, "orders:write", true},
+		{[]string{"orders:*"}, "ordersx:write", false},
+		{nil, "orders:write", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, allows(tt.granted, tt.permission), "%v allows %s", tt.granted, tt.permission)
+	}
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// TokenPair is a short-lived access token with the refresh token that
+// renews it
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresAt is when the access token expires
+	ExpiresAt time.Time `json:"expires_at"`
+	// RefreshExpiresAt is when the refresh token expires
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// refreshClaims are the claims of a refresh token: those of the access
+// tokens it renews, with the session it belongs to
+type refreshClaims struct {
+	Claims
+	Family string `json:"fam"`
+}
+
+// NewTokenPair generates an access token valid for AUTH_ACCESS_TTL and a
+// refresh token valid for AUTH_REFRESH_TTL, starting a new session in the
+// RefreshStore. Call it on login:
+//
+//	pair, err := auth.NewTokenPair(k.Request.Context(), user.ID, user.Email)
+//	if err != nil {
+//	    return err
+//	}
+//	k.SetAuthCookies(pair.AccessToken, pair.RefreshToken)
+//
+// The refresh token carries the roles, tenant and custom claims of opts, so
+// the access tokens it renews keep them.
+func NewTokenPair(ctx context.Context, userID uuid.UUID, email string, opts ...TokenOption) (*TokenPair, error) {
+	family := uuid.NewString()
+	id := uuid.NewString()
+	expiry := time.Now().Add(refreshTTL())
+
+	if err := DefaultRefreshStore().Save(ctx, family, id, expiry); err != nil {
+		return nil, errors.ErrGenerateToken.Wrap(err)
+	}
+	return newPair(newClaims(userID.String(), email, opts), family, id, expiry)
+}
+
+// RefreshToken exchanges a refresh token for a new pair. Each refresh token
+// works once: the new pair replaces it. A refresh token used a second time
+// was likely stolen, so the whole session is revoked and the call fails
+// with errors.ErrAuthTokenReused, logging out both the thief and the user.
+// An expired refresh token fails with errors.ErrAuthExpiredToken.
+func RefreshToken(ctx context.Context, refresh string) (*TokenPair, error) {
+	claims := &refreshClaims{}
+	err := parse(refresh, claims)
+	if stderrors.Is(err, jwt.ErrTokenExpired) {
+		return nil, errors.ErrAuthExpiredToken
+	}
+	if err != nil || claims.Type != refreshType {
+		return nil, errors.ErrAuthInvalidToken
+	}
+	old := claims.ID
+	if claims.UserID == "" || claims.Family == "" || old == "" {
+		return nil, errors.ErrAuthInvalidToken
+	}
+
+	store := DefaultRefreshStore()
+	id := uuid.NewString()
+	expiry := time.Now().Add(refreshTTL())
+	rotated, err := store.Rotate(ctx, claims.Family, old, id, expiry)
+	if err != nil {
+		return nil, errors.ErrRefreshToken.Wrap(err)
+	}
+	if !rotated {
+		if err := store.Revoke(ctx, claims.Family); err != nil {
+			return nil, errors.ErrRefreshToken.Wrap(err)
+		}
+		return nil, errors.ErrAuthTokenReused
+	}
+
+	access := claims.Claims
+	now := time.Now()
+	access.Type = accessType
+	access.ID = uuid.NewString()
+	access.IssuedAt = jwt.NewNumericDate(now)
+	access.ExpiresAt = jwt.NewNumericDate(now.Add(accessTTL()))
+	return newPair(&access, claims.Family, id, expiry)
+}
+
+// RevokeRefreshToken ends the session of a refresh token, so neither it
+// nor any token refreshed from it works again. Call it on logout. Invalid
+// and expired tokens have nothing to revoke.
+func RevokeRefreshToken(ctx context.Context, refresh string) error {
+	claims := &refreshClaims{}
+	if err := parse(refresh, claims); err != nil || claims.Type != refreshType {
+		return nil
+	}
+	if claims.Family == "" {
+		return nil
+	}
+	if err := DefaultRefreshStore().Revoke(ctx, claims.Family); err != nil {
+		return errors.ErrRefreshToken.Wrap(err)
+	}
+	return nil
+}
+
+// newPair signs access and the refresh token id of family, which carries
+// access's claims
+func newPair(access *Claims, family, id string, refreshExpiry time.Time) (*TokenPair, error) {
+	signed, err := sign(access)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := refreshClaims{Claims: *access, Family: family}
+	claims.Type = refreshType
+	claims.ID = id
+	claims.ExpiresAt = jwt.NewNumericDate(refreshExpiry)
+	refresh, err := sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      signed,
+		RefreshToken:     refresh,
+		ExpiresAt:        time.Unix(access.ExpiresAt.Unix(), 0),
+		RefreshExpiresAt: time.Unix(refreshExpiry.Unix(), 0),
+	}, nil
+}
+
+// refreshTTL is AUTH_REFRESH_TTL, or DefaultRefreshTTL without it
+func refreshTTL() time.Duration {
+	if ttl := config.Get().Auth.RefreshTTL; ttl > 0 {
+		return ttl
+	}
+	return config.DefaultRefreshTTL
+}
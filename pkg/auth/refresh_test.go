@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+// setupRefresh gives the test an empty MemoryRefreshStore
+func setupRefresh(t *testing.T) *MemoryRefreshStore {
+	t.Helper()
+	cleanup := setupTestAuth(t)
+	t.Cleanup(cleanup)
+	store := NewMemoryRefreshStore()
+	UseRefreshStore(store)
+	t.Cleanup(ResetRefreshStore)
+	return store
+}
+
+// claimsOf returns a token's claims without validating it
+func claimsOf(t *testing.T, token string) jwt.MapClaims {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	return claims
+}
+
+// TestNewTokenPair tests issuing an access and a refresh token for a new
+// session
+func TestNewTokenPair(t *testing.T) {
+	store := setupRefresh(t)
+	userID := uuid.New()
+
+	before := time.Now()
+	pair, err := NewTokenPair(context.Background(), userID, "user@example.com")
+	require.NoError(t, err)
+
+	parsed, err := ParseToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), parsed.UserID)
+	assert.WithinDuration(t, before.Add(config.DefaultAccessTTL), pair.ExpiresAt, 2*time.Second)
+	assert.WithinDuration(t, before.Add(config.DefaultRefreshTTL), pair.RefreshExpiresAt, 2*time.Second)
+
+	claims := claimsOf(t, pair.RefreshToken)
+	assert.Equal(t, "refresh", claims["typ"])
+	assert.Equal(t, userID.String(), claims["user_id"])
+	assert.Contains(t, store.families, claims["fam"])
+
+	_, err = ParseToken(pair.RefreshToken)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthInvalidToken, "refresh tokens aren't access tokens")
+}
+
+// TestRefreshToken tests rotating a refresh token, and revoking the session
+// when a rotated token is used again
+func TestRefreshToken(t *testing.T) {
+	setupRefresh(t)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	first, err := NewTokenPair(ctx, userID, "user@example.com")
+	require.NoError(t, err)
+
+	second, err := RefreshToken(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, claimsOf(t, first.RefreshToken)["fam"], claimsOf(t, second.RefreshToken)["fam"])
+	parsed, err := ParseToken(second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), parsed.UserID)
+	assert.Equal(t, "user@example.com", claimsOf(t, second.AccessToken)["email"])
+
+	_, err = RefreshToken(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthTokenReused)
+
+	_, err = RefreshToken(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthTokenReused, "reuse revokes the whole session")
+
+	other, err := NewTokenPair(ctx, userID, "user@example.com")
+	require.NoError(t, err)
+	_, err = RefreshToken(ctx, other.RefreshToken)
+	assert.NoError(t, err, "other sessions are unaffected")
+}
+
+// TestRefreshToken_KeepsClaims tests renewing access tokens with the
+// claims of the login
+func TestRefreshToken_KeepsClaims(t *testing.T) {
+	setupRefresh(t)
+	ctx := context.Background()
+
+	first, err := NewTokenPair(ctx, uuid.New(), "user@example.com",
+		WithRoles("admin"), WithTenant("acme"), WithClaim("plan", "pro"))
+	require.NoError(t, err)
+
+	second, err := RefreshToken(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	claims, err := ParseToken(second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	assert.Equal(t, "acme", claims.Tenant)
+	assert.Equal(t, "pro", claims.Custom["plan"])
+	firstClaims, err := ParseToken(first.AccessToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, claims.ID, "access tokens have their own jti")
+	assert.NotEqual(t, firstClaims.ID, claims.ID)
+	assert.NotEqual(t, claimsOf(t, second.RefreshToken)["jti"], claims.ID)
+}
+
+// TestRefreshToken_Invalid tests rejecting tokens that aren't valid
+// refresh tokens
+func TestRefreshToken_Invalid(t *testing.T) {
+	setupRefresh(t)
+	ctx := context.Background()
+
+	access, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+	_, err = RefreshToken(ctx, access.Token)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthInvalidToken)
+
+	_, err = RefreshToken(ctx, "not-a-token")
+	assert.ErrorIs(t, err, twineerrors.ErrAuthInvalidToken)
+
+	expired, err := sign(jwt.MapClaims{
+		"user_id": uuid.NewString(),
+		"typ":     refreshType,
+		"fam":     "login",
+		"jti":     "first",
+		"exp":     time.Now().Add(-time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+	_, err = RefreshToken(ctx, expired)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthExpiredToken)
+}
+
+// TestRevokeRefreshToken tests ending a session on logout
+func TestRevokeRefreshToken(t *testing.T) {
+	setupRefresh(t)
+	ctx := context.Background()
+
+	pair, err := NewTokenPair(ctx, uuid.New(), "user@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, RevokeRefreshToken(ctx, pair.RefreshToken))
+	_, err = RefreshToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthTokenReused)
+
+	assert.NoError(t, RevokeRefreshToken(ctx, "not-a-token"))
+}
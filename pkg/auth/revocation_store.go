@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cstone-io/twine/internal/redis"
+)
+
+// RevocationStore remembers revoked access tokens, by their jti claim,
+// until they expire. MemoryRevocationStore, DatabaseRevocationStore and
+// RedisRevocationStore implement it.
+type RevocationStore interface {
+	// Revoke marks the token id revoked until expiry
+	Revoke(ctx context.Context, id string, expiry time.Time) error
+	// IsRevoked reports whether the token id was revoked
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+var (
+	revocationMu    sync.Mutex
+	revocationStore RevocationStore
+)
+
+// DefaultRevocationStore returns the RevocationStore set with
+// UseRevocationStore, or a MemoryRevocationStore created when first called
+func DefaultRevocationStore() RevocationStore {
+	revocationMu.Lock()
+	defer revocationMu.Unlock()
+	if revocationStore == nil {
+		revocationStore = NewMemoryRevocationStore()
+	}
+	return revocationStore
+}
+
+// UseRevocationStore makes s the store revoked tokens are kept in. Call it
+// at startup; a token revoked on one instance is only rejected by the
+// others when they share a DatabaseRevocationStore or RedisRevocationStore.
+func UseRevocationStore(s RevocationStore) {
+	revocationMu.Lock()
+	defer revocationMu.Unlock()
+	revocationStore = s
+}
+
+// ResetRevocationStore forgets the current store, so the next
+// DefaultRevocationStore creates an empty one. It is meant for tests.
+func ResetRevocationStore() {
+	revocationMu.Lock()
+	defer revocationMu.Unlock()
+	revocationStore = nil
+}
+
+// MemoryRevocationStore is a RevocationStore within one process. It forgets
+// revocations when the process restarts.
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryRevocationStore creates an empty MemoryRevocationStore
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revoked: map[string]time.Time{}, now: time.Now}
+}
+
+// Revoke marks id revoked, dropping tokens that expired
+func (s *MemoryRevocationStore) Revoke(_ context.Context, id string, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for revoked, until := range s.revoked {
+		if !now.Before(until) {
+			delete(s.revoked, revoked)
+		}
+	}
+	s.revoked[id] = expiry
+	return nil
+}
+
+// IsRevoked reports whether id was revoked and hasn't expired since
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.revoked[id]
+	return ok && s.now().Before(until), nil
+}
+
+// RevokedToken is a row of the revoked_tokens table used by
+// DatabaseRevocationStore
+type RevokedToken struct {
+	TokenID   string    `gorm:"primaryKey;size:36"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// TableName is revoked_tokens
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// DatabaseRevocationStore is a RevocationStore in the revoked_tokens
+// table, shared by every instance. Register a migration for RevokedToken to
+// create the table:
+//
+//	database.RegisterMigration(database.NewMigrationBuilder().
+//	    Model(&auth.RevokedToken{}).
+//	    Name("revoked_tokens").
+//	    Build())
+type DatabaseRevocationStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseRevocationStore creates a DatabaseRevocationStore on db, such
+// as database.GORM()
+func NewDatabaseRevocationStore(db *gorm.DB) *DatabaseRevocationStore {
+	return &DatabaseRevocationStore{db: db}
+}
+
+// Revoke marks id revoked, deleting tokens that expired first
+func (s *DatabaseRevocationStore) Revoke(ctx context.Context, id string, expiry time.Time) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("expires_at <= ?", time.Now().UTC()).Delete(&RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&RevokedToken{TokenID: id, ExpiresAt: expiry.UTC()}).Error
+}
+
+// IsRevoked reports whether id was revoked and hasn't expired since
+func (s *DatabaseRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&RevokedToken{}).
+		Where("token_id = ? AND expires_at > ?", id, time.Now().UTC()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// redisRevokedPrefix namespaces RedisRevocationStore's keys
+const redisRevokedPrefix = "auth:revoked:"
+
+// RedisRevocationStore is a RevocationStore on a Redis server, keeping
+// each revoked token in a key that expires with it
+type RedisRevocationStore struct {
+	client *redis.Client
+}
+
+// NewRedisRevocationStore creates a RedisRevocationStore for a redis:// or
+// rediss:// URL. It connects when first used.
+func NewRedisRevocationStore(rawURL string) (*RedisRevocationStore, error) {
+	client, err := redis.NewClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisRevocationStore{client: client}, nil
+}
+
+// Revoke marks id revoked until expiry. Tokens that already expired need
+// no key.
+func (s *RedisRevocationStore) Revoke(ctx context.Context, id string, expiry time.Time) error {
+	ms := time.Until(expiry).Milliseconds()
+	if ms <= 0 {
+		return nil
+	}
+	_, err := s.client.Do(ctx, []string{"SET", redisRevokedPrefix + id, "1", "PX", strconv.FormatInt(ms, 10)})
+	return err
+}
+
+// IsRevoked reports whether id was revoked and hasn't expired since
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	replies, err := s.client.Do(ctx, []string{"GET", redisRevokedPrefix + id})
+	if err != nil {
+		return false, err
+	}
+	return replies[0] != nil, nil
+}
+
+// Close closes the idle connections
+func (s *RedisRevocationStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// testRevocationStore checks the behaviour every RevocationStore shares
+func testRevocationStore(t *testing.T, store RevocationStore) {
+	ctx := context.Background()
+
+	revoked, err := store.IsRevoked(ctx, "stolen")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, store.Revoke(ctx, "stolen", time.Now().Add(time.Hour)))
+	require.NoError(t, store.Revoke(ctx, "stolen", time.Now().Add(time.Hour)), "revoking twice is fine")
+	revoked, err = store.IsRevoked(ctx, "stolen")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, "other")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+// TestMemoryRevocationStore tests revoking tokens within a process
+func TestMemoryRevocationStore(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	testRevocationStore(t, store)
+
+	now := time.Now()
+	store.now = func() time.Time { return now.Add(2 * time.Hour) }
+	revoked, err := store.IsRevoked(context.Background(), "stolen")
+	require.NoError(t, err)
+	assert.False(t, revoked, "the token expired")
+
+	require.NoError(t, store.Revoke(context.Background(), "next", now.Add(3*time.Hour)))
+	assert.Len(t, store.revoked, 1, "expired tokens are dropped")
+}
+
+// TestDatabaseRevocationStore tests revoking tokens in the revoked_tokens
+// table
+func TestDatabaseRevocationStore(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&RevokedToken{}))
+	store := NewDatabaseRevocationStore(db)
+	testRevocationStore(t, store)
+
+	ctx := context.Background()
+	require.NoError(t, store.Revoke(ctx, "expired", time.Now().Add(-time.Second)))
+	revoked, err := store.IsRevoked(ctx, "expired")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// Revoking clears expired tokens
+	require.NoError(t, store.Revoke(ctx, "next", time.Now().Add(time.Hour)))
+	var count int64
+	require.NoError(t, db.Model(&RevokedToken{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+// TestRedisRevocationStore tests revoking tokens on a Redis server
+func TestRedisRevocationStore(t *testing.T) {
+	server := testutil.SetupFakeRedis(t)
+	store, err := NewRedisRevocationStore(server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	testRevocationStore(t, store)
+	assert.Contains(t, server.Commands(), "GET auth:revoked:stolen")
+
+	require.NoError(t, store.Revoke(context.Background(), "expired", time.Now().Add(-time.Second)))
+	for _, command := range server.Commands() {
+		assert.NotContains(t, command, "auth:revoked:expired", "expired tokens need no key")
+	}
+}
+
+// TestDefaultRevocationStore tests the store set with UseRevocationStore
+func TestDefaultRevocationStore(t *testing.T) {
+	ResetRevocationStore()
+	t.Cleanup(ResetRevocationStore)
+	assert.IsType(t, &MemoryRevocationStore{}, DefaultRevocationStore())
+
+	store := NewMemoryRevocationStore()
+	UseRevocationStore(store)
+	assert.Same(t, store, DefaultRevocationStore())
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// Revoke rejects the access token whose jti claim is tokenID from now until
+// expiry, when it stops working anyway, so a stolen token can be shut out
+// before then. JWTMiddleware checks the RevocationStore on every request:
+//
+//	claims := auth.ClaimsFromContext(k.Request.Context())
+//	err := auth.Revoke(k.Request.Context(), claims.ID, claims.ExpiresAt.Time)
+func Revoke(ctx context.Context, tokenID string, expiry time.Time) error {
+	if tokenID == "" {
+		return nil
+	}
+	if err := DefaultRevocationStore().Revoke(ctx, tokenID, expiry); err != nil {
+		return errors.ErrRevocationStore.Wrap(err)
+	}
+	return nil
+}
+
+// RevokeToken revokes a signed access token until it expires. Invalid and
+// expired tokens, and those issued without a jti claim, have nothing to
+// revoke.
+func RevokeToken(ctx context.Context, token string) error {
+	claims := &Claims{}
+	if err := parse(token, claims); err != nil || claims.Type == refreshType || claims.ExpiresAt == nil {
+		return nil
+	}
+	return Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// IsRevoked reports whether the access token whose jti claim is tokenID
+// was revoked
+func IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if tokenID == "" {
+		return false, nil
+	}
+	revoked, err := DefaultRevocationStore().IsRevoked(ctx, tokenID)
+	if err != nil {
+		return false, errors.ErrRevocationStore.Wrap(err)
+	}
+	return revoked, nil
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// setupRevocation gives the test an empty MemoryRevocationStore
+func setupRevocation(t *testing.T) *MemoryRevocationStore {
+	t.Helper()
+	store := NewMemoryRevocationStore()
+	UseRevocationStore(store)
+	t.Cleanup(ResetRevocationStore)
+	return store
+}
+
+// TestRevoke tests revoking access tokens by their jti claim
+func TestRevoke(t *testing.T) {
+	setupRefresh(t)
+	setupRevocation(t)
+	ctx := context.Background()
+
+	token, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+	claims, err := ParseToken(token.Token)
+	require.NoError(t, err)
+	require.NotEmpty(t, claims.ID)
+
+	revoked, err := IsRevoked(ctx, claims.ID)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, Revoke(ctx, claims.ID, claims.ExpiresAt.Time))
+	revoked, err = IsRevoked(ctx, claims.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	other, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+	require.NoError(t, RevokeToken(ctx, other.Token))
+	otherClaims, err := ParseToken(other.Token)
+	require.NoError(t, err)
+	revoked, err = IsRevoked(ctx, otherClaims.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.NoError(t, RevokeToken(ctx, "not-a-token"), "invalid tokens have nothing to revoke")
+	revoked, err = IsRevoked(ctx, "")
+	require.NoError(t, err)
+	assert.False(t, revoked, "tokens without a jti can't be revoked")
+}
+
+// TestLogout_RevokesTokens tests that logging out shuts out the request's
+// access and refresh tokens
+func TestLogout_RevokesTokens(t *testing.T) {
+	setupRefresh(t)
+	setupRevocation(t)
+	ctx := context.Background()
+
+	pair, err := NewTokenPair(ctx, uuid.New(), "user@example.com")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+	r.AddCookie(&http.Cookie{Name: kit.TokenCookie, Value: pair.AccessToken})
+	r.AddCookie(&http.Cookie{Name: kit.RefreshTokenCookie, Value: pair.RefreshToken})
+	require.NoError(t, Logout(&kit.Kit{Response: w, Request: r}))
+
+	claims, err := ParseToken(pair.AccessToken)
+	require.NoError(t, err)
+	revoked, err := IsRevoked(ctx, claims.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = RefreshToken(ctx, pair.RefreshToken)
+	assert.Error(t, err, "the login's refresh tokens were revoked")
+
+	cleared := map[string]bool{}
+	for _, c := range w.Result().Cookies() {
+		cleared[c.Name] = c.MaxAge < 0
+	}
+	assert.True(t, cleared[kit.TokenCookie])
+	assert.True(t, cleared[kit.RefreshTokenCookie])
+	assert.True(t, cleared[kit.SessionCookie])
+}
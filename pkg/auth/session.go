@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// touchInterval is how far a session's expiry must move before it is
+// saved, so busy sessions don't write to the store on every request
+const touchInterval = time.Minute
+
+type sessionKey struct{}
+
+// Login signs userID in with a server-side session, an alternative to JWTs
+// that can be revoked at any time. The session ID is an opaque random
+// value in kit.SessionCookie; the session lasts AUTH_SESSION_TTL after the
+// last request that used it:
+//
+//	if err := creds.Authenticate(user.Password); err != nil {
+//	    return err
+//	}
+//	if err := auth.Login(k, user.ID); err != nil {
+//	    return err
+//	}
+//	return k.Redirect("/")
+//
+// A session the request already had is ended, so an ID planted before
+// signing in is worthless after it.
+func Login(k *kit.Kit, userID uuid.UUID) error {
+	ctx := k.Request.Context()
+	store := DefaultSessionStore()
+	if old, err := k.GetCookie(kit.SessionCookie); err == nil && old != "" {
+		if err := store.Delete(ctx, hashSessionID(old)); err != nil {
+			return errors.ErrSessionStore.Wrap(err)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return errors.ErrSessionStore.Wrap(err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(secret)
+
+	ttl := sessionTTL()
+	now := time.Now()
+	session := Session{
+		ID:        hashSessionID(id),
+		UserID:    userID.String(),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := store.Create(ctx, session); err != nil {
+		return errors.ErrSessionStore.Wrap(err)
+	}
+
+	k.SetSessionCookie(id, ttl)
+	k.Request = k.Request.WithContext(context.WithValue(ctx, sessionKey{}, &session))
+	return nil
+}
+
+// Logout signs the request's user out. It ends their session, if they
+// have one, revokes their access token and the refresh tokens of its
+// login, and clears the cookies of both. k.Logout calls it once it has
+// checked the request came from this site.
+func Logout(k *kit.Kit) error {
+	ctx := k.Request.Context()
+	if id, err := k.GetCookie(kit.SessionCookie); err == nil && id != "" {
+		if err := DefaultSessionStore().Delete(ctx, hashSessionID(id)); err != nil {
+			return errors.ErrSessionStore.Wrap(err)
+		}
+	}
+	if token, err := k.Authorization(); err == nil {
+		if err := RevokeToken(ctx, token); err != nil {
+			return err
+		}
+	}
+	if refresh, err := k.GetCookie(kit.RefreshTokenCookie); err == nil && refresh != "" {
+		if err := RevokeRefreshToken(ctx, refresh); err != nil {
+			return err
+		}
+	}
+	k.ClearSessionCookie()
+	k.ClearAuthCookies()
+	k.Request = k.Request.WithContext(context.WithValue(ctx, sessionKey{}, (*Session)(nil)))
+	return nil
+}
+
+// CurrentUser returns the ID of the user signed in with Login, extending
+// the session. Requests without a live session fail with
+// errors.ErrAuthNoSession.
+func CurrentUser(k *kit.Kit) (uuid.UUID, error) {
+	session, err := CurrentSession(k)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userID, err := uuid.Parse(session.UserID)
+	if err != nil {
+		return uuid.Nil, errors.ErrAuthNoSession
+	}
+	return userID, nil
+}
+
+// CurrentSession returns the request's session, extending it. It is loaded
+// from the SessionStore once per request.
+func CurrentSession(k *kit.Kit) (*Session, error) {
+	ctx := k.Request.Context()
+	if session, ok := ctx.Value(sessionKey{}).(*Session); ok {
+		if session == nil {
+			return nil, errors.ErrAuthNoSession
+		}
+		return session, nil
+	}
+
+	id, err := k.GetCookie(kit.SessionCookie)
+	if err != nil || id == "" {
+		return nil, errors.ErrAuthNoSession
+	}
+	store := DefaultSessionStore()
+	session, err := store.Find(ctx, hashSessionID(id))
+	if err != nil {
+		return nil, errors.ErrSessionStore.Wrap(err)
+	}
+	if session == nil {
+		k.ClearSessionCookie()
+		k.Request = k.Request.WithContext(context.WithValue(ctx, sessionKey{}, (*Session)(nil)))
+		return nil, errors.ErrAuthNoSession
+	}
+
+	ttl := sessionTTL()
+	if expiry := time.Now().Add(ttl); expiry.Sub(session.ExpiresAt) > touchInterval {
+		if err := store.Touch(ctx, session.ID, expiry); err != nil {
+			return nil, errors.ErrSessionStore.Wrap(err)
+		}
+		session.ExpiresAt = expiry
+		k.SetSessionCookie(id, ttl)
+	}
+
+	k.Request = k.Request.WithContext(context.WithValue(ctx, sessionKey{}, session))
+	return session, nil
+}
+
+// RevokeSessions ends every session of userID, signing them out on every
+// device, such as after a password change
+func RevokeSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := DefaultSessionStore().DeleteUser(ctx, userID.String()); err != nil {
+		return errors.ErrSessionStore.Wrap(err)
+	}
+	return nil
+}
+
+// hashSessionID is the key a session ID is stored under
+func hashSessionID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionTTL is AUTH_SESSION_TTL, or DefaultSessionTTL without it
+func sessionTTL() time.Duration {
+	if ttl := config.Get().Auth.SessionTTL; ttl > 0 {
+		return ttl
+	}
+	return config.DefaultSessionTTL
+}
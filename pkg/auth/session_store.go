@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is a signed-in user's server-side session, and a row of the
+// sessions table used by DatabaseSessionStore
+type Session struct {
+	// ID is the SHA-256 hash of the session ID in the cookie, so stored
+	// sessions can't be used to sign in
+	ID        string    `gorm:"primaryKey;size:64"`
+	UserID    string    `gorm:"size:36;index"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// TableName is sessions
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// SessionStore keeps the sessions started by Login. MemorySessionStore and
+// DatabaseSessionStore implement it.
+type SessionStore interface {
+	// Create stores a new session
+	Create(ctx context.Context, session Session) error
+	// Find returns the session with id, or nil if there is none or it
+	// expired
+	Find(ctx context.Context, id string) (*Session, error)
+	// Touch extends session id until expiry
+	Touch(ctx context.Context, id string, expiry time.Time) error
+	// Delete ends session id
+	Delete(ctx context.Context, id string) error
+	// DeleteUser ends every session of userID
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+var (
+	sessionStoreMu sync.Mutex
+	sessionStore   SessionStore
+)
+
+// DefaultSessionStore returns the SessionStore set with UseSessionStore, or
+// a MemorySessionStore created when first called
+func DefaultSessionStore() SessionStore {
+	sessionStoreMu.Lock()
+	defer sessionStoreMu.Unlock()
+	if sessionStore == nil {
+		sessionStore = NewMemorySessionStore()
+	}
+	return sessionStore
+}
+
+// UseSessionStore makes s the store sessions are kept in. Call it at
+// startup; applications with a database keep sessions across restarts and
+// instances with a DatabaseSessionStore.
+func UseSessionStore(s SessionStore) {
+	sessionStoreMu.Lock()
+	defer sessionStoreMu.Unlock()
+	sessionStore = s
+}
+
+// ResetSessionStore forgets the current store, so the next
+// DefaultSessionStore creates an empty one. It is meant for tests.
+func ResetSessionStore() {
+	sessionStoreMu.Lock()
+	defer sessionStoreMu.Unlock()
+	sessionStore = nil
+}
+
+// MemorySessionStore is a SessionStore within one process. Its sessions
+// end when the process restarts.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	now      func() time.Time
+}
+
+// NewMemorySessionStore creates an empty MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: map[string]Session{}, now: time.Now}
+}
+
+// Create stores a new session, dropping expired ones
+func (s *MemorySessionStore) Create(_ context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, stored := range s.sessions {
+		if !now.Before(stored.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+	s.sessions[session.ID] = session
+	return nil
+}
+
+// Find returns the session with id
+func (s *MemorySessionStore) Find(_ context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || !s.now().Before(session.ExpiresAt) {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// Touch extends session id
+func (s *MemorySessionStore) Touch(_ context.Context, id string, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		session.ExpiresAt = expiry
+		s.sessions[id] = session
+	}
+	return nil
+}
+
+// Delete ends session id
+func (s *MemorySessionStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// DeleteUser ends every session of userID
+func (s *MemorySessionStore) DeleteUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+// DatabaseSessionStore is a SessionStore in the sessions table, shared by
+// every instance. Register a migration for Session to create the table:
+//
+//	database.RegisterMigration(database.NewMigrationBuilder().
+//	    Model(&auth.Session{}).
+//	    Name("sessions").
+//	    Build())
+type DatabaseSessionStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseSessionStore creates a DatabaseSessionStore on db, such as
+// database.GORM()
+func NewDatabaseSessionStore(db *gorm.DB) *DatabaseSessionStore {
+	return &DatabaseSessionStore{db: db}
+}
+
+// Create stores a new session, deleting expired sessions first
+func (s *DatabaseSessionStore) Create(ctx context.Context, session Session) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("expires_at <= ?", time.Now().UTC()).Delete(&Session{}).Error; err != nil {
+		return err
+	}
+	session.ExpiresAt = session.ExpiresAt.UTC()
+	session.CreatedAt = session.CreatedAt.UTC()
+	return db.Create(&session).Error
+}
+
+// Find returns the session with id
+func (s *DatabaseSessionStore) Find(ctx context.Context, id string) (*Session, error) {
+	var sessions []Session
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND expires_at > ?", id, time.Now().UTC()).
+		Limit(1).
+		Find(&sessions).Error
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// Touch extends session id
+func (s *DatabaseSessionStore) Touch(ctx context.Context, id string, expiry time.Time) error {
+	return s.db.WithContext(ctx).Model(&Session{}).
+		Where("id = ?", id).
+		Update("expires_at", expiry.UTC()).Error
+}
+
+// Delete ends session id
+func (s *DatabaseSessionStore) Delete(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&Session{}).Error
+}
+
+// DeleteUser ends every session of userID
+func (s *DatabaseSessionStore) DeleteUser(ctx context.Context, userID string) error {
+	return s.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&Session{}).Error
+}
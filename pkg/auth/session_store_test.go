@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// testSessionStore checks the behaviour every SessionStore shares
+func testSessionStore(t *testing.T, store SessionStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, store.Create(ctx, Session{ID: "first", UserID: "alice", ExpiresAt: now.Add(time.Hour), CreatedAt: now}))
+	require.NoError(t, store.Create(ctx, Session{ID: "second", UserID: "alice", ExpiresAt: now.Add(time.Hour), CreatedAt: now}))
+	require.NoError(t, store.Create(ctx, Session{ID: "other", UserID: "bob", ExpiresAt: now.Add(time.Hour), CreatedAt: now}))
+
+	session, err := store.Find(ctx, "first")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, "alice", session.UserID)
+	assert.WithinDuration(t, now.Add(time.Hour), session.ExpiresAt, time.Second)
+
+	session, err = store.Find(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, session)
+
+	require.NoError(t, store.Touch(ctx, "first", now.Add(2*time.Hour)))
+	session, err = store.Find(ctx, "first")
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(2*time.Hour), session.ExpiresAt, time.Second)
+
+	require.NoError(t, store.Touch(ctx, "first", now.Add(-time.Second)))
+	session, err = store.Find(ctx, "first")
+	require.NoError(t, err)
+	assert.Nil(t, session, "the session expired")
+
+	require.NoError(t, store.Delete(ctx, "second"))
+	session, err = store.Find(ctx, "second")
+	require.NoError(t, err)
+	assert.Nil(t, session)
+
+	require.NoError(t, store.Create(ctx, Session{ID: "third", UserID: "alice", ExpiresAt: now.Add(time.Hour), CreatedAt: now}))
+	require.NoError(t, store.DeleteUser(ctx, "alice"))
+	session, err = store.Find(ctx, "third")
+	require.NoError(t, err)
+	assert.Nil(t, session)
+	session, err = store.Find(ctx, "other")
+	require.NoError(t, err)
+	assert.NotNil(t, session, "other users keep their sessions")
+}
+
+// TestMemorySessionStore tests keeping sessions within a process
+func TestMemorySessionStore(t *testing.T) {
+	testSessionStore(t, NewMemorySessionStore())
+}
+
+// TestDatabaseSessionStore tests keeping sessions in the sessions table
+func TestDatabaseSessionStore(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&Session{}))
+
+	testSessionStore(t, NewDatabaseSessionStore(db))
+}
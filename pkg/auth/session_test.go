@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// setupSessions gives the test an empty MemorySessionStore
+func setupSessions(t *testing.T) *MemorySessionStore {
+	t.Helper()
+	store := NewMemorySessionStore()
+	UseSessionStore(store)
+	t.Cleanup(ResetSessionStore)
+	return store
+}
+
+// newSessionKit returns a Kit for a request carrying cookies
+func newSessionKit(cookies ...*http.Cookie) (*kit.Kit, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	return &kit.Kit{Response: w, Request: r}, w
+}
+
+// sessionCookie returns the session cookie set on w
+func sessionCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, c := range w.Result().Cookies() {
+		if c.Name == kit.SessionCookie {
+			return c
+		}
+	}
+	require.Fail(t, "no session cookie")
+	return nil
+}
+
+// TestLogin tests signing in, and reading the user back on later requests
+func TestLogin(t *testing.T) {
+	store := setupSessions(t)
+	userID := uuid.New()
+
+	k, w := newSessionKit()
+	require.NoError(t, Login(k, userID))
+	cookie := sessionCookie(t, w)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, int(config.DefaultSessionTTL/time.Second), cookie.MaxAge)
+	assert.NotContains(t, store.sessions, cookie.Value, "only a hash of the ID is stored")
+	assert.Contains(t, store.sessions, hashSessionID(cookie.Value))
+
+	current, err := CurrentUser(k)
+	require.NoError(t, err, "the login's request is signed in")
+	assert.Equal(t, userID, current)
+
+	next, _ := newSessionKit(cookie)
+	current, err = CurrentUser(next)
+	require.NoError(t, err)
+	assert.Equal(t, userID, current)
+
+	anonymous, _ := newSessionKit()
+	_, err = CurrentUser(anonymous)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthNoSession)
+
+	forged, _ := newSessionKit(&http.Cookie{Name: kit.SessionCookie, Value: "forged"})
+	_, err = CurrentUser(forged)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthNoSession)
+}
+
+// TestLogin_ReplacesSession tests ending the session a request had before
+// signing in
+func TestLogin_ReplacesSession(t *testing.T) {
+	setupSessions(t)
+
+	k, w := newSessionKit()
+	require.NoError(t, Login(k, uuid.New()))
+	planted := sessionCookie(t, w)
+
+	k, w = newSessionKit(planted)
+	require.NoError(t, Login(k, uuid.New()))
+	assert.NotEqual(t, planted.Value, sessionCookie(t, w).Value)
+
+	old, _ := newSessionKit(planted)
+	_, err := CurrentUser(old)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthNoSession)
+}
+
+// TestCurrentSession_Sliding tests extending a session as it is used
+func TestCurrentSession_Sliding(t *testing.T) {
+	store := setupSessions(t)
+
+	k, w := newSessionKit()
+	require.NoError(t, Login(k, uuid.New()))
+	cookie := sessionCookie(t, w)
+	key := hashSessionID(cookie.Value)
+
+	// Used soon after signing in, the session isn't written again
+	k, w = newSessionKit(cookie)
+	_, err := CurrentSession(k)
+	require.NoError(t, err)
+	assert.Empty(t, w.Result().Cookies())
+
+	// Used later, it lasts AUTH_SESSION_TTL from now
+	session := store.sessions[key]
+	session.ExpiresAt = time.Now().Add(time.Hour)
+	store.sessions[key] = session
+
+	k, w = newSessionKit(cookie)
+	session2, err := CurrentSession(k)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(config.DefaultSessionTTL), session2.ExpiresAt, 2*time.Second)
+	assert.WithinDuration(t, time.Now().Add(config.DefaultSessionTTL), store.sessions[key].ExpiresAt, 2*time.Second)
+	assert.Equal(t, cookie.Value, sessionCookie(t, w).Value, "the cookie is renewed")
+
+	// Expired, it is gone
+	store.now = func() time.Time { return time.Now().Add(2 * config.DefaultSessionTTL) }
+	k, w = newSessionKit(cookie)
+	_, err = CurrentSession(k)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthNoSession)
+	assert.Equal(t, -1, sessionCookie(t, w).MaxAge, "the stale cookie is cleared")
+}
+
+// TestLogout tests ending the request's session
+func TestLogout(t *testing.T) {
+	setupSessions(t)
+
+	k, w := newSessionKit()
+	require.NoError(t, Login(k, uuid.New()))
+	cookie := sessionCookie(t, w)
+
+	k, w = newSessionKit(cookie)
+	require.NoError(t, Logout(k))
+	assert.Equal(t, -1, sessionCookie(t, w).MaxAge)
+	_, err := CurrentUser(k)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthNoSession)
+
+	k, _ = newSessionKit(cookie)
+	_, err = CurrentUser(k)
+	assert.ErrorIs(t, err, twineerrors.ErrAuthNoSession, "the session was revoked")
+
+	k, _ = newSessionKit()
+	assert.NoError(t, Logout(k), "signed out requests have nothing to end")
+}
+
+// TestRevokeSessions tests signing a user out on every device
+func TestRevokeSessions(t *testing.T) {
+	setupSessions(t)
+	userID := uuid.New()
+
+	var cookies []*http.Cookie
+	for range 2 {
+		k, w := newSessionKit()
+		require.NoError(t, Login(k, userID))
+		cookies = append(cookies, sessionCookie(t, w))
+	}
+	k, w := newSessionKit()
+	require.NoError(t, Login(k, uuid.New()))
+	other := sessionCookie(t, w)
+
+	require.NoError(t, RevokeSessions(context.Background(), userID))
+	for _, cookie := range cookies {
+		k, _ := newSessionKit(cookie)
+		_, err := CurrentUser(k)
+		assert.ErrorIs(t, err, twineerrors.ErrAuthNoSession)
+	}
+	k, _ = newSessionKit(other)
+	_, err := CurrentUser(k)
+	assert.NoError(t, err, "other users stay signed in")
+}
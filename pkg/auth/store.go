@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshStore tracks the current refresh token of each session, a family
+// of tokens rotated from one login. MemoryRefreshStore and
+// DatabaseRefreshStore implement it.
+type RefreshStore interface {
+	// Save starts family with id as its current token until expiry
+	Save(ctx context.Context, family, id string, expiry time.Time) error
+	// Rotate replaces family's current token old with id until expiry. It
+	// reports false, changing nothing, if old isn't the current token:
+	// it was rotated already, or the family was revoked or expired.
+	Rotate(ctx context.Context, family, old, id string, expiry time.Time) (bool, error)
+	// Revoke ends family, so none of its tokens rotate again
+	Revoke(ctx context.Context, family string) error
+}
+
+var (
+	storeMu sync.Mutex
+	store   RefreshStore
+)
+
+// DefaultRefreshStore returns the RefreshStore set with UseRefreshStore, or
+// a MemoryRefreshStore created when first called
+func DefaultRefreshStore() RefreshStore {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if store == nil {
+		store = NewMemoryRefreshStore()
+	}
+	return store
+}
+
+// UseRefreshStore makes s the store refresh tokens are tracked in. Call it
+// at startup; applications with a database keep sessions across restarts
+// and instances with a DatabaseRefreshStore.
+func UseRefreshStore(s RefreshStore) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	store = s
+}
+
+// ResetRefreshStore forgets the current store, so the next
+// DefaultRefreshStore creates an empty one. It is meant for tests.
+func ResetRefreshStore() {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	store = nil
+}
+
+// MemoryRefreshStore is a RefreshStore within one process. Its sessions
+// end when the process restarts.
+type MemoryRefreshStore struct {
+	mu       sync.Mutex
+	families map[string]memorySession
+	now      func() time.Time
+}
+
+type memorySession struct {
+	id     string
+	expiry time.Time
+}
+
+// NewMemoryRefreshStore creates an empty MemoryRefreshStore
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{families: map[string]memorySession{}, now: time.Now}
+}
+
+// Save starts family with id as its current token, dropping expired
+// families
+func (s *MemoryRefreshStore) Save(_ context.Context, family, id string, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for f, session := range s.families {
+		if !now.Before(session.expiry) {
+			delete(s.families, f)
+		}
+	}
+	s.families[family] = memorySession{id: id, expiry: expiry}
+	return nil
+}
+
+// Rotate replaces family's current token old with id
+func (s *MemoryRefreshStore) Rotate(_ context.Context, family, old, id string, expiry time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.families[family]
+	if !ok || session.id != old || !s.now().Before(session.expiry) {
+		return false, nil
+	}
+	s.families[family] = memorySession{id: id, expiry: expiry}
+	return true, nil
+}
+
+// Revoke ends family
+func (s *MemoryRefreshStore) Revoke(_ context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.families, family)
+	return nil
+}
+
+// RefreshSession is a row of the refresh_tokens table used by
+// DatabaseRefreshStore
+type RefreshSession struct {
+	Family    string    `gorm:"primaryKey;size:36"`
+	TokenID   string    `gorm:"size:36"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// TableName is refresh_tokens
+func (RefreshSession) TableName() string {
+	return "refresh_tokens"
+}
+
+// DatabaseRefreshStore is a RefreshStore in the refresh_tokens table,
+// shared by every instance. Register a migration for RefreshSession to
+// create the table:
+//
+//	database.RegisterMigration(database.NewMigrationBuilder().
+//	    Model(&auth.RefreshSession{}).
+//	    Name("refresh_tokens").
+//	    Build())
+type DatabaseRefreshStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseRefreshStore creates a DatabaseRefreshStore on db, such as
+// database.GORM()
+func NewDatabaseRefreshStore(db *gorm.DB) *DatabaseRefreshStore {
+	return &DatabaseRefreshStore{db: db}
+}
+
+// Save starts family with id as its current token, deleting expired
+// sessions first
+func (s *DatabaseRefreshStore) Save(ctx context.Context, family, id string, expiry time.Time) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("expires_at <= ?", time.Now().UTC()).Delete(&RefreshSession{}).Error; err != nil {
+		return err
+	}
+	return db.Create(&RefreshSession{Family: family, TokenID: id, ExpiresAt: expiry.UTC()}).Error
+}
+
+// Rotate replaces family's current token old with id in one conditional
+// update, so of two requests rotating the same token only one succeeds
+func (s *DatabaseRefreshStore) Rotate(ctx context.Context, family, old, id string, expiry time.Time) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&RefreshSession{}).
+		Where("family = ? AND token_id = ? AND expires_at > ?", family, old, time.Now().UTC()).
+		Updates(map[string]any{"token_id": id, "expires_at": expiry.UTC()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Revoke ends family
+func (s *DatabaseRefreshStore) Revoke(ctx context.Context, family string) error {
+	return s.db.WithContext(ctx).Where("family = ?", family).Delete(&RefreshSession{}).Error
+}
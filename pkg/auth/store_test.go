@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// setupDatabaseRefreshStore creates a DatabaseRefreshStore on a new SQLite
+// database
+func setupDatabaseRefreshStore(t *testing.T) *DatabaseRefreshStore {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&RefreshSession{}))
+	return NewDatabaseRefreshStore(db)
+}
+
+// testRefreshStore checks the behaviour every RefreshStore shares
+func testRefreshStore(t *testing.T, store RefreshStore) {
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour)
+
+	require.NoError(t, store.Save(ctx, "login", "first", expiry))
+
+	ok, err := store.Rotate(ctx, "login", "first", "second", expiry)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.Rotate(ctx, "login", "first", "third", expiry)
+	require.NoError(t, err)
+	assert.False(t, ok, "first was rotated already")
+
+	ok, err = store.Rotate(ctx, "other", "first", "third", expiry)
+	require.NoError(t, err)
+	assert.False(t, ok, "the family doesn't exist")
+
+	require.NoError(t, store.Revoke(ctx, "login"))
+	ok, err = store.Rotate(ctx, "login", "second", "third", expiry)
+	require.NoError(t, err)
+	assert.False(t, ok, "the family was revoked")
+
+	require.NoError(t, store.Save(ctx, "expired", "first", time.Now().Add(-time.Second)))
+	ok, err = store.Rotate(ctx, "expired", "first", "second", expiry)
+	require.NoError(t, err)
+	assert.False(t, ok, "the family expired")
+}
+
+// TestMemoryRefreshStore tests tracking sessions within a process
+func TestMemoryRefreshStore(t *testing.T) {
+	testRefreshStore(t, NewMemoryRefreshStore())
+}
+
+// TestDatabaseRefreshStore tests tracking sessions in the refresh_tokens
+// table
+func TestDatabaseRefreshStore(t *testing.T) {
+	store := setupDatabaseRefreshStore(t)
+	testRefreshStore(t, store)
+
+	// Saving clears expired sessions
+	require.NoError(t, store.Save(context.Background(), "next", "first", time.Now().Add(time.Hour)))
+	var count int64
+	require.NoError(t, store.db.Model(&RefreshSession{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestDefaultRefreshStore tests the store set with UseRefreshStore
+func TestDefaultRefreshStore(t *testing.T) {
+	ResetRefreshStore()
+	t.Cleanup(ResetRefreshStore)
+	assert.IsType(t, &MemoryRefreshStore{}, DefaultRefreshStore())
+
+	store := setupDatabaseRefreshStore(t)
+	UseRefreshStore(store)
+	assert.Same(t, store, DefaultRefreshStore())
+}
@@ -1,7 +1,8 @@
 package auth
 
 import (
-	"net/http"
+	"context"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,52 +17,194 @@ type Token struct {
 	Token string `json:"token"`
 }
 
-// NewToken generates a new JWT token for a user
-func NewToken(userID uuid.UUID, email string) (*Token, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"email":   email,
-		"exp":     time.Now().Add(time.Hour).Unix(),
+// Token types, in a token's typ claim
+const (
+	accessType  = "access"
+	refreshType = "refresh"
+)
+
+// Claims are the claims of an access token, returned by ParseToken
+type Claims struct {
+	UserID string   `json:"user_id"`
+	Email  string   `json:"email"`
+	Roles  []string `json:"roles,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+	// Custom holds the claims added with WithClaim. Values come back from
+	// ParseToken as JSON decodes them: numbers are float64, objects
+	// map[string]any.
+	Custom map[string]any `json:"custom,omitempty"`
+	// Type is "access" for the tokens ParseToken accepts
+	Type string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// HasRole reports whether the token was issued with role
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// TokenOption adds to the claims of a token issued by NewToken or
+// NewTokenPair
+type TokenOption func(*Claims)
+
+// WithRoles adds roles to the token
+func WithRoles(roles ...string) TokenOption {
+	return func(c *Claims) {
+		c.Roles = append(c.Roles, roles...)
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// WithTenant sets the tenant the token is for
+func WithTenant(tenant string) TokenOption {
+	return func(c *Claims) {
+		c.Tenant = tenant
+	}
+}
 
-	cfg := config.Get()
-	key := cfg.Auth.SecretKey
+// WithClaim adds an application claim, read back from Claims.Custom
+func WithClaim(key string, value any) TokenOption {
+	return func(c *Claims) {
+		if c.Custom == nil {
+			c.Custom = map[string]any{}
+		}
+		c.Custom[key] = value
+	}
+}
+
+// WithTTL makes the access token valid for ttl instead of AUTH_ACCESS_TTL.
+// Access tokens renewed with RefreshToken last AUTH_ACCESS_TTL again.
+func WithTTL(ttl time.Duration) TokenOption {
+	return func(c *Claims) {
+		c.ExpiresAt = jwt.NewNumericDate(c.IssuedAt.Add(ttl))
+	}
+}
 
-	signed, err := token.SignedString([]byte(key))
+// NewToken generates a new JWT access token for a user, valid for
+// AUTH_ACCESS_TTL and issued by AUTH_ISSUER for AUTH_AUDIENCE when they
+// are set. Its jti claim identifies it for Revoke:
+//
+//	token, err := auth.NewToken(user.ID, user.Email,
+//	    auth.WithRoles("admin"),
+//	    auth.WithTenant(user.OrgID.String()))
+func NewToken(userID uuid.UUID, email string, opts ...TokenOption) (*Token, error) {
+	signed, err := sign(newClaims(userID.String(), email, opts))
 	if err != nil {
-		return nil, errors.ErrGenerateToken.Wrap(err).WithValue(signed)
+		return nil, err
 	}
 
 	return &Token{Token: signed}, nil
 }
 
-// ParseToken validates and parses a JWT token, returning the user ID
-func ParseToken(tokenString string) (string, error) {
-	cfg := config.Get()
-	key := cfg.Auth.SecretKey
+// newClaims builds the claims of an access token, applying opts last
+func newClaims(userID, email string, opts []TokenOption) *Claims {
+	cfg := config.Get().Auth
+	now := time.Now()
+	claims := &Claims{
+		UserID: userID,
+		Email:  email,
+		Type:   accessType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTTL())),
+		},
+	}
+	if cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{cfg.Audience}
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+	return claims
+}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, http.ErrAbortHandler
-		}
-		return []byte(key), nil
-	})
+// sign signs claims with AUTH_SECRET, or AUTH_PRIVATE_KEY for RS256 and
+// EdDSA
+func sign(claims jwt.Claims) (string, error) {
+	keys, err := loadKeys()
+	if err != nil {
+		return "", errors.ErrGenerateToken.Wrap(err)
+	}
+
+	token := jwt.NewWithClaims(keys.method, claims)
+	if keys.kid != "" {
+		token.Header["kid"] = keys.kid
+	}
+
+	signed, err := token.SignedString(keys.signKey)
+	if err != nil {
+		return "", errors.ErrGenerateToken.Wrap(err).WithValue(signed)
+	}
+	return signed, nil
+}
+
+// accessTTL is AUTH_ACCESS_TTL, or DefaultAccessTTL without it
+func accessTTL() time.Duration {
+	if ttl := config.Get().Auth.AccessTTL; ttl > 0 {
+		return ttl
+	}
+	return config.DefaultAccessTTL
+}
+
+// ParseToken validates and parses a JWT access token, returning its
+// claims. Refresh tokens are rejected, as are tokens from another
+// AUTH_ISSUER or for another AUTH_AUDIENCE when they are set.
+func ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if err := parse(tokenString, claims); err != nil {
+		return nil, errors.ErrAuthInvalidToken
+	}
+	if claims.Type == refreshType || claims.UserID == "" {
+		return nil, errors.ErrAuthInvalidToken
+	}
+
+	return claims, nil
+}
 
-	if err != nil || !token.Valid {
-		return "", errors.ErrAuthInvalidToken
+// parse validates a token's signature, expiry, issuer and audience,
+// decoding its claims into claims
+func parse(tokenString string, claims jwt.Claims) error {
+	keys, err := loadKeys()
+	if err != nil {
+		return err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", errors.ErrAuthInvalidToken
+	cfg := config.Get()
+	var opts []jwt.ParserOption
+	if cfg.Auth.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Auth.Issuer))
+	}
+	if cfg.Auth.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Auth.Audience))
 	}
 
-	userID, ok := claims["user_id"].(string)
-	if !ok {
-		return "", errors.ErrAuthInvalidToken
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.keyFunc, opts...)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.ErrAuthInvalidToken
 	}
+	return nil
+}
+
+type claimsKey struct{}
+
+// ContextWithClaims returns a copy of ctx carrying claims. JWTMiddleware
+// stores the claims of each request's token this way.
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
 
-	return userID, nil
+// ClaimsFromContext returns the claims stored by ContextWithClaims, or nil
+// without any:
+//
+//	claims := auth.ClaimsFromContext(k.Request.Context())
+//	if !claims.HasRole("admin") {
+//	    return errors.ErrInsufficientPermissions
+//	}
+func ClaimsFromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsKey{}).(*Claims)
+	return claims
 }
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"os"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cstone-io/twine/pkg/config"
 	twineerrors "github.com/cstone-io/twine/pkg/errors"
 )
 
@@ -27,6 +29,8 @@ func setupTestAuth(t *testing.T) func() {
 
 	// Reset config singleton
 	resetConfig()
+	// The config may have loaded before AUTH_SECRET was set
+	withAuthConfig(t, func(a *config.AuthConfig) { a.SecretKey = "test-secret-key-for-testing" })
 
 	return func() {
 		if originalSecret == "" {
@@ -180,10 +184,10 @@ func TestToken_ParseToken(t *testing.T) {
 		token, err := NewToken(userID, email)
 		require.NoError(t, err)
 
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
 
-		assert.Equal(t, userID.String(), parsedUserID)
+		assert.Equal(t, userID.String(), parsed.UserID)
 	})
 
 	t.Run("rejects invalid signature", func(t *testing.T) {
@@ -300,9 +304,9 @@ func TestToken_Lifecycle(t *testing.T) {
 		assert.NotEmpty(t, token.Token)
 
 		// Parse
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
-		assert.Equal(t, originalUserID.String(), parsedUserID)
+		assert.Equal(t, originalUserID.String(), parsed.UserID)
 	})
 
 	t.Run("token is valid for the duration", func(t *testing.T) {
@@ -312,16 +316,16 @@ func TestToken_Lifecycle(t *testing.T) {
 		require.NoError(t, err)
 
 		// Should be valid immediately
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
-		assert.Equal(t, userID.String(), parsedUserID)
+		assert.Equal(t, userID.String(), parsed.UserID)
 
 		// Small delay (should still be valid)
 		time.Sleep(10 * time.Millisecond)
 
-		parsedUserID, err = ParseToken(token.Token)
+		parsed, err = ParseToken(token.Token)
 		require.NoError(t, err)
-		assert.Equal(t, userID.String(), parsedUserID)
+		assert.Equal(t, userID.String(), parsed.UserID)
 	})
 }
 
@@ -363,7 +367,7 @@ func TestToken_ThreadSafety(t *testing.T) {
 		// Parse concurrently
 		const goroutines = 100
 		var wg sync.WaitGroup
-		results := make([]string, goroutines)
+		results := make([]*Claims, goroutines)
 		errors := make([]error, goroutines)
 
 		wg.Add(goroutines)
@@ -379,7 +383,7 @@ func TestToken_ThreadSafety(t *testing.T) {
 		// All should succeed with same result
 		for i, err := range errors {
 			require.NoError(t, err, "Token parsing %d failed", i)
-			assert.Equal(t, userID.String(), results[i])
+			assert.Equal(t, userID.String(), results[i].UserID)
 		}
 	})
 }
@@ -393,9 +397,9 @@ func TestToken_EdgeCases(t *testing.T) {
 		token, err := NewToken(uuid.Nil, "test@example.com")
 		require.NoError(t, err)
 
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
-		assert.Equal(t, uuid.Nil.String(), parsedUserID)
+		assert.Equal(t, uuid.Nil.String(), parsed.UserID)
 	})
 
 	t.Run("empty email", func(t *testing.T) {
@@ -405,9 +409,9 @@ func TestToken_EdgeCases(t *testing.T) {
 		require.NoError(t, err)
 
 		// Token should still be valid
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
-		assert.Equal(t, userID.String(), parsedUserID)
+		assert.Equal(t, userID.String(), parsed.UserID)
 	})
 
 	t.Run("very long email", func(t *testing.T) {
@@ -417,9 +421,9 @@ func TestToken_EdgeCases(t *testing.T) {
 		token, err := NewToken(userID, longEmail)
 		require.NoError(t, err)
 
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
-		assert.Equal(t, userID.String(), parsedUserID)
+		assert.Equal(t, userID.String(), parsed.UserID)
 	})
 
 	t.Run("special characters in email", func(t *testing.T) {
@@ -429,9 +433,9 @@ func TestToken_EdgeCases(t *testing.T) {
 		token, err := NewToken(userID, email)
 		require.NoError(t, err)
 
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
-		assert.Equal(t, userID.String(), parsedUserID)
+		assert.Equal(t, userID.String(), parsed.UserID)
 	})
 }
 
@@ -451,11 +455,11 @@ func TestToken_Integration(t *testing.T) {
 
 		// Client stores token and uses it for subsequent requests
 		// Server validates token
-		parsedUserID, err := ParseToken(token.Token)
+		parsed, err := ParseToken(token.Token)
 		require.NoError(t, err)
 
 		// Server can now identify the user
-		assert.Equal(t, userID.String(), parsedUserID)
+		assert.Equal(t, userID.String(), parsed.UserID)
 	})
 
 	t.Run("multiple users have unique tokens", func(t *testing.T) {
@@ -474,11 +478,11 @@ func TestToken_Integration(t *testing.T) {
 		// Each token should parse to correct user
 		parsed1, err := ParseToken(token1.Token)
 		require.NoError(t, err)
-		assert.Equal(t, user1ID.String(), parsed1)
+		assert.Equal(t, user1ID.String(), parsed1.UserID)
 
 		parsed2, err := ParseToken(token2.Token)
 		require.NoError(t, err)
-		assert.Equal(t, user2ID.String(), parsed2)
+		assert.Equal(t, user2ID.String(), parsed2.UserID)
 	})
 
 	t.Run("token refresh scenario", func(t *testing.T) {
@@ -497,13 +501,98 @@ func TestToken_Integration(t *testing.T) {
 		// Both should be valid
 		parsed1, err := ParseToken(token1.Token)
 		require.NoError(t, err)
-		assert.Equal(t, userID.String(), parsed1)
+		assert.Equal(t, userID.String(), parsed1.UserID)
 
 		parsed2, err := ParseToken(token2.Token)
 		require.NoError(t, err)
-		assert.Equal(t, userID.String(), parsed2)
+		assert.Equal(t, userID.String(), parsed2.UserID)
 
 		// Tokens should differ due to different exp times
 		assert.NotEqual(t, token1.Token, token2.Token)
 	})
 }
+
+// withAuthConfig changes the loaded AuthConfig for the rest of the test
+func withAuthConfig(t *testing.T, change func(*config.AuthConfig)) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Auth
+	change(&cfg.Auth)
+	t.Cleanup(func() { cfg.Auth = original })
+}
+
+// TestToken_Options tests adding roles, a tenant, custom claims and a
+// lifetime to a token
+func TestToken_Options(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	userID := uuid.New()
+	before := time.Now()
+	token, err := NewToken(userID, "user@example.com",
+		WithRoles("admin", "editor"),
+		WithTenant("acme"),
+		WithClaim("plan", "pro"),
+		WithClaim("seats", 5),
+		WithTTL(5*time.Minute))
+	require.NoError(t, err)
+
+	claims, err := ParseToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), claims.UserID)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.Equal(t, []string{"admin", "editor"}, claims.Roles)
+	assert.True(t, claims.HasRole("admin"))
+	assert.False(t, claims.HasRole("owner"))
+	assert.Equal(t, "acme", claims.Tenant)
+	assert.Equal(t, map[string]any{"plan": "pro", "seats": float64(5)}, claims.Custom)
+	assert.WithinDuration(t, before.Add(5*time.Minute), claims.ExpiresAt.Time, 2*time.Second)
+	assert.WithinDuration(t, before, claims.IssuedAt.Time, 2*time.Second)
+
+	plain, err := NewToken(userID, "user@example.com")
+	require.NoError(t, err)
+	claims, err = ParseToken(plain.Token)
+	require.NoError(t, err)
+	assert.Empty(t, claims.Roles)
+	assert.Empty(t, claims.Tenant)
+	assert.Nil(t, claims.Custom)
+}
+
+// TestToken_IssuerAudience tests issuing tokens for AUTH_ISSUER and
+// AUTH_AUDIENCE, and rejecting tokens issued for others
+func TestToken_IssuerAudience(t *testing.T) {
+	cleanup := setupTestAuth(t)
+	defer cleanup()
+
+	withAuthConfig(t, func(cfg *config.AuthConfig) {
+		cfg.Issuer = "https://auth.example.com"
+		cfg.Audience = "api"
+	})
+
+	token, err := NewToken(uuid.New(), "user@example.com")
+	require.NoError(t, err)
+	claims, err := ParseToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"api"}, claims.Audience)
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"other issuer":   {"user_id": uuid.NewString(), "iss": "https://other.example.com", "aud": "api"},
+		"other audience": {"user_id": uuid.NewString(), "iss": "https://auth.example.com", "aud": "admin"},
+		"no issuer":      {"user_id": uuid.NewString(), "aud": "api"},
+	} {
+		signed, err := sign(claims)
+		require.NoError(t, err)
+		_, err = ParseToken(signed)
+		assert.ErrorIs(t, err, twineerrors.ErrAuthInvalidToken, name)
+	}
+}
+
+// TestClaimsFromContext tests carrying claims in a context
+func TestClaimsFromContext(t *testing.T) {
+	assert.Nil(t, ClaimsFromContext(context.Background()))
+
+	claims := &Claims{UserID: uuid.NewString()}
+	ctx := ContextWithClaims(context.Background(), claims)
+	assert.Same(t, claims, ClaimsFromContext(ctx))
+}
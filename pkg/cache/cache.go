@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/logger"
+	"github.com/cstone-io/twine/pkg/server"
+)
+
+// The server's readiness endpoint checks the Store returned by Get
+func init() {
+	server.RegisterHealthCheck("cache", Ping)
+}
+
+// Store holds cached values as bytes. MemoryStore and RedisStore implement
+// it; applications can use their own with Use.
+type Store interface {
+	// Get returns the value stored for key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl, or without expiry if ttl is 0,
+	// and records it under tags for Invalidate
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	// Delete removes keys
+	Delete(ctx context.Context, keys ...string) error
+	// Invalidate removes the entries stored with any of tags
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+var (
+	mu       sync.Mutex
+	instance Store
+)
+
+// Get returns the application's Store, creating it from config.Get().Cache
+// when first called. A store that can't be created, such as for a
+// malformed CACHE_REDIS_URL, is logged and replaced by a MemoryStore.
+func Get() Store {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if instance == nil {
+		cfg := config.Get().Cache
+		store, err := New(cfg)
+		if err != nil {
+			logger.Get().Error("Creating %s cache: %v; using memory", cfg.Driver, err)
+			store = NewMemoryStore(cfg.MaxEntries)
+		}
+		instance = store
+	}
+	return instance
+}
+
+// New creates the Store for cfg.Driver
+func New(cfg config.CacheConfig) (Store, error) {
+	switch cfg.Driver {
+	case config.CacheMemory, "":
+		return NewMemoryStore(cfg.MaxEntries), nil
+	case config.CacheRedis:
+		return NewRedisStore(cfg.RedisURL)
+	}
+	return nil, fmt.Errorf("unknown cache driver %q", cfg.Driver)
+}
+
+// Ping checks that the Store returned by Get answers, reading a key
+func Ping(ctx context.Context) error {
+	_, _, err := Get().Get(ctx, "twine:health")
+	return err
+}
+
+// Use makes store the one returned by Get. It is meant for tests and for
+// applications with their own Store.
+func Use(store Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = store
+}
+
+// Reset forgets the current store, so the next Get creates it again
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = nil
+}
+
+// Cache is a Store bound to a context, such as a request's. Kit.Cache
+// returns one for the request.
+type Cache struct {
+	store Store
+	ctx   context.Context
+}
+
+// NewCache returns a Cache using store, or Get() if store is nil, with ctx
+func NewCache(ctx context.Context, store Store) *Cache {
+	return &Cache{store: store, ctx: ctx}
+}
+
+// Store returns the Store the cache uses
+func (c *Cache) Store() Store {
+	if c.store == nil {
+		return Get()
+	}
+	return c.store
+}
+
+// Context returns the context the cache's operations run with
+func (c *Cache) Context() context.Context {
+	return c.ctx
+}
+
+// Delete removes keys
+func (c *Cache) Delete(keys ...string) error {
+	return c.Store().Delete(c.ctx, keys...)
+}
+
+// Invalidate removes the entries stored with any of tags, for example
+// after a write changes the records they were computed from
+func (c *Cache) Invalidate(tags ...string) error {
+	return c.Store().Invalidate(c.ctx, tags...)
+}
+
+// GetOrSet returns the value cached under key, or calls fn, caches its
+// result for ttl and returns it. The result is stored as JSON, so T's
+// exported fields are what is cached. tags group the entry for
+// Invalidate:
+//
+//	posts, err := cache.GetOrSet("posts:recent", time.Minute, func() ([]Post, error) {
+//	    return store.List(ctx, database.ListOptions{Sort: "-created_at", PerPage: 10})
+//	}, "posts")
+//
+// Errors from fn are returned and not cached. A cache that fails is
+// logged and bypassed, so pages keep working without it. GetOrSet uses
+// the Store from Get; see In for a request's cache.
+func GetOrSet[T any](key string, ttl time.Duration, fn func() (T, error), tags ...string) (T, error) {
+	return In(NewCache(context.Background(), nil), key, ttl, fn, tags...)
+}
+
+// In is GetOrSet with c's store and context:
+//
+//	posts, err := cache.In(k.Cache(), "posts:recent", time.Minute, loadPosts, "posts")
+func In[T any](c *Cache, key string, ttl time.Duration, fn func() (T, error), tags ...string) (T, error) {
+	store := c.Store()
+
+	data, ok, err := store.Get(c.ctx, key)
+	if err != nil {
+		logger.Get().Warn("Reading cache key %s: %v", key, err)
+	}
+	if ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+		logger.Get().Warn("Decoding cache key %s: the cached value no longer matches its type", key)
+	}
+
+	value, err := fn()
+	if err != nil {
+		return value, err
+	}
+
+	if data, err = json.Marshal(value); err != nil {
+		logger.Get().Warn("Encoding cache key %s: %v", key, err)
+		return value, nil
+	}
+	if err := store.Set(c.ctx, key, data, ttl, tags...); err != nil {
+		logger.Get().Warn("Writing cache key %s: %v", key, err)
+	}
+	return value, nil
+}
+
+// Memoize wraps fn so its results are cached for ttl under prefix and the
+// argument, for functions called with the same argument on many pages:
+//
+//	var popularPosts = cache.Memoize("posts:popular", 5*time.Minute, func(category string) ([]Post, error) {
+//	    return loadPopular(category)
+//	}, "posts")
+//
+// The argument is formatted with %v to form the key.
+func Memoize[A any, T any](prefix string, ttl time.Duration, fn func(A) (T, error), tags ...string) func(A) (T, error) {
+	return func(arg A) (T, error) {
+		return GetOrSet(fmt.Sprintf("%s:%v", prefix, arg), ttl, func() (T, error) {
+			return fn(arg)
+		}, tags...)
+	}
+}
+
+// Invalidate removes the entries stored with any of tags from the Store
+// returned by Get
+func Invalidate(ctx context.Context, tags ...string) error {
+	return Get().Invalidate(ctx, tags...)
+}
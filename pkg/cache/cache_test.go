@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+type post struct {
+	ID    int
+	Title string
+}
+
+// failingStore is a Store whose every operation fails
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("unavailable")
+}
+func (failingStore) Set(context.Context, string, []byte, time.Duration, ...string) error {
+	return errors.New("unavailable")
+}
+func (failingStore) Delete(context.Context, ...string) error     { return errors.New("unavailable") }
+func (failingStore) Invalidate(context.Context, ...string) error { return errors.New("unavailable") }
+
+// TestGetOrSet tests computing values once and reusing them until
+// invalidated
+func TestGetOrSet(t *testing.T) {
+	Use(NewMemoryStore(10))
+	t.Cleanup(Reset)
+
+	calls := 0
+	load := func() ([]post, error) {
+		calls++
+		return []post{{ID: 1, Title: "Hello"}}, nil
+	}
+
+	for range 2 {
+		posts, err := GetOrSet("posts:recent", time.Minute, load, "posts")
+		require.NoError(t, err)
+		assert.Equal(t, []post{{ID: 1, Title: "Hello"}}, posts)
+	}
+	assert.Equal(t, 1, calls, "the second call is served from the cache")
+
+	require.NoError(t, Invalidate(context.Background(), "posts"))
+	_, err := GetOrSet("posts:recent", time.Minute, load, "posts")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls, "invalidating the tag recomputes the value")
+}
+
+// TestGetOrSet_Errors tests that errors aren't cached and failing stores
+// are bypassed
+func TestGetOrSet_Errors(t *testing.T) {
+	Use(NewMemoryStore(10))
+	t.Cleanup(Reset)
+
+	loadErr := errors.New("query failed")
+	_, err := GetOrSet("count", time.Minute, func() (int, error) { return 0, loadErr })
+	assert.ErrorIs(t, err, loadErr)
+	assert.Equal(t, 0, Get().(*MemoryStore).Len(), "errors are not cached")
+
+	Use(failingStore{})
+	count, err := GetOrSet("count", time.Minute, func() (int, error) { return 5, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+// TestPing tests checking the store for the readiness endpoint
+func TestPing(t *testing.T) {
+	t.Cleanup(Reset)
+
+	Use(NewMemoryStore(10))
+	assert.NoError(t, Ping(context.Background()))
+
+	Use(failingStore{})
+	assert.Error(t, Ping(context.Background()))
+}
+
+// TestGetOrSet_TypeChange tests recomputing a value cached with another type
+func TestGetOrSet_TypeChange(t *testing.T) {
+	store := NewMemoryStore(10)
+	c := NewCache(context.Background(), store)
+
+	_, err := In(c, "value", 0, func() (string, error) { return "text", nil })
+	require.NoError(t, err)
+	n, err := In(c, "value", 0, func() (int, error) { return 7, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 7, n)
+}
+
+// TestMemoize tests caching a function's results by argument
+func TestMemoize(t *testing.T) {
+	store := NewMemoryStore(10)
+	Use(store)
+	t.Cleanup(Reset)
+
+	calls := map[string]int{}
+	popular := Memoize("posts:popular", time.Minute, func(category string) ([]string, error) {
+		calls[category]++
+		return []string{category + "-1"}, nil
+	}, "posts")
+
+	for range 2 {
+		posts, err := popular("go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go-1"}, posts)
+	}
+	_, err := popular("sql")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"go": 1, "sql": 1}, calls)
+	_, ok, _ := store.Get(context.Background(), "posts:popular:go")
+	assert.True(t, ok)
+}
+
+// TestNew tests creating stores for each driver
+func TestNew(t *testing.T) {
+	store, err := New(config.CacheConfig{Driver: config.CacheMemory, MaxEntries: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 5, store.(*MemoryStore).maxEntries)
+
+	store, err = New(config.CacheConfig{Driver: config.CacheRedis, RedisURL: "redis://localhost/2"})
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:6379", store.(*RedisStore).client.Addr())
+
+	_, err = New(config.CacheConfig{Driver: config.CacheRedis, RedisURL: "http://localhost"})
+	assert.Error(t, err)
+
+	_, err = New(config.CacheConfig{Driver: "memcached"})
+	assert.Error(t, err)
+}
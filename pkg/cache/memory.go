@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// MemoryStore is an in-process Store that evicts the least recently used
+// entries beyond its size. Each process has its own entries, so
+// invalidating in one process doesn't reach the others.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List // Front is the most recently used
+	tags       map[string]map[string]struct{}
+	now        func() time.Time
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // Zero never expires
+	tags    []string
+}
+
+// NewMemoryStore creates a MemoryStore holding at most maxEntries entries,
+// or config.DefaultCacheMaxEntries if maxEntries is 0
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = config.DefaultCacheMaxEntries
+	}
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		tags:       map[string]map[string]struct{}{},
+		now:        time.Now,
+	}
+}
+
+// Get returns the value stored for key, if it hasn't expired
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && !s.now().Before(entry.expires) {
+		s.remove(element)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set stores value under key for ttl, or until evicted if ttl is 0
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+
+	entry := &memoryEntry{key: key, value: value, tags: tags}
+	if ttl > 0 {
+		entry.expires = s.now().Add(ttl)
+	}
+	s.entries[key] = s.lru.PushFront(entry)
+	for _, tag := range tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = map[string]struct{}{}
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+
+	for s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+// Delete removes keys
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := s.entries[key]; ok {
+			s.remove(element)
+		}
+	}
+	return nil
+}
+
+// Invalidate removes the entries stored with any of tags
+func (s *MemoryStore) Invalidate(ctx context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			s.remove(s.entries[key])
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet
+// removed
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// remove deletes element's entry and its tag references
+func (s *MemoryStore) remove(element *list.Element) {
+	entry := s.lru.Remove(element).(*memoryEntry)
+	delete(s.entries, entry.key)
+	for _, tag := range entry.tags {
+		delete(s.tags[tag], entry.key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryStore tests storing, expiring and deleting entries
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore(10)
+	s.now = func() time.Time { return now }
+
+	_, ok, err := s.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, s.Set(ctx, "b", []byte("2"), 0))
+	value, ok, err := s.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = s.Get(ctx, "a")
+	assert.False(t, ok, "entries expire after their ttl")
+	_, ok, _ = s.Get(ctx, "b")
+	assert.True(t, ok, "entries without a ttl don't expire")
+
+	require.NoError(t, s.Delete(ctx, "b"))
+	assert.Equal(t, 0, s.Len())
+}
+
+// TestMemoryStore_Evict tests evicting the least recently used entries
+func TestMemoryStore_Evict(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2)
+
+	require.NoError(t, s.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, s.Set(ctx, "b", []byte("2"), 0))
+	s.Get(ctx, "a")
+	require.NoError(t, s.Set(ctx, "c", []byte("3"), 0))
+
+	assert.Equal(t, 2, s.Len())
+	_, ok, _ := s.Get(ctx, "b")
+	assert.False(t, ok, "b was least recently used")
+	_, ok, _ = s.Get(ctx, "a")
+	assert.True(t, ok)
+
+	assert.Equal(t, 10000, NewMemoryStore(0).maxEntries, "0 uses the default size")
+}
+
+// TestMemoryStore_Invalidate tests removing entries by tag
+func TestMemoryStore_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(10)
+
+	require.NoError(t, s.Set(ctx, "posts:recent", []byte("[]"), 0, "posts"))
+	require.NoError(t, s.Set(ctx, "posts:1", []byte("{}"), 0, "posts", "post:1"))
+	require.NoError(t, s.Set(ctx, "users:1", []byte("{}"), 0, "users"))
+
+	require.NoError(t, s.Invalidate(ctx, "post:1"))
+	_, ok, _ := s.Get(ctx, "posts:1")
+	assert.False(t, ok)
+	_, ok, _ = s.Get(ctx, "posts:recent")
+	assert.True(t, ok, "other entries tagged posts remain")
+
+	require.NoError(t, s.Invalidate(ctx, "posts", "unknown"))
+	assert.Equal(t, 1, s.Len())
+	assert.Empty(t, s.tags["posts"])
+
+	// Replacing an entry drops its old tags
+	require.NoError(t, s.Set(ctx, "users:1", []byte("{}"), 0))
+	require.NoError(t, s.Invalidate(ctx, "users"))
+	assert.Equal(t, 1, s.Len())
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cstone-io/twine/internal/redis"
+)
+
+// RedisStore is a Store on a Redis server, shared by every process using
+// it. Entries live under "cache:" keys and tags in "cache:tag:" sets. Tags
+// need Redis 7 or later.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a RedisStore for a redis:// or rediss:// URL, such
+// as redis://:password@localhost:6379/0. It connects when first used.
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	client, err := redis.NewClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Get returns the value stored for key
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	replies, err := s.client.Do(ctx, []string{"GET", redisKey(key)})
+	if err != nil {
+		return nil, false, err
+	}
+	if replies[0] == nil {
+		return nil, false, nil
+	}
+	return []byte(replies[0].(string)), true, nil
+}
+
+// Set stores value under key for ttl, or until Redis evicts it if ttl is
+// 0, adding key to the sets of its tags
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	set := []string{"SET", redisKey(key), string(value)}
+	if ttl > 0 {
+		set = append(set, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	commands := [][]string{set}
+	for _, tag := range tags {
+		commands = append(commands, []string{"SADD", redisTagKey(tag), redisKey(key)})
+		if ttl > 0 {
+			// A tag's set lives as long as its longest-lived entry
+			ms := strconv.FormatInt(ttl.Milliseconds(), 10)
+			commands = append(commands,
+				[]string{"PEXPIRE", redisTagKey(tag), ms, "NX"},
+				[]string{"PEXPIRE", redisTagKey(tag), ms, "GT"})
+		} else {
+			commands = append(commands, []string{"PERSIST", redisTagKey(tag)})
+		}
+	}
+	_, err := s.client.Do(ctx, commands...)
+	return err
+}
+
+// Delete removes keys
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	del := []string{"DEL"}
+	for _, key := range keys {
+		del = append(del, redisKey(key))
+	}
+	_, err := s.client.Do(ctx, del)
+	return err
+}
+
+// Invalidate removes the entries stored with any of tags
+func (s *RedisStore) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		replies, err := s.client.Do(ctx, []string{"SMEMBERS", redisTagKey(tag)})
+		if err != nil {
+			return err
+		}
+		del := append([]string{"DEL", redisTagKey(tag)}, redis.Strings(replies[0])...)
+		if _, err := s.client.Do(ctx, del); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the idle connections
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func redisKey(key string) string {
+	return "cache:" + key
+}
+
+func redisTagKey(tag string) string {
+	return "cache:tag:" + tag
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// TestRedisStore tests storing, invalidating and deleting entries on a
+// Redis server
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	server := testutil.SetupFakeRedis(t)
+	s, err := NewRedisStore(server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	_, ok, err := s.Get(ctx, "posts:1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.Set(ctx, "posts:1", []byte(`{"id":1}`), time.Minute, "posts"))
+	require.NoError(t, s.Set(ctx, "users:1", []byte(`{}`), 0, "users"))
+	value, ok, err := s.Get(ctx, "posts:1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"id":1}`, string(value))
+
+	require.NoError(t, s.Invalidate(ctx, "posts"))
+	_, ok, _ = s.Get(ctx, "posts:1")
+	assert.False(t, ok)
+	_, ok, _ = s.Get(ctx, "users:1")
+	assert.True(t, ok)
+
+	require.NoError(t, s.Delete(ctx, "users:1"))
+	_, ok, _ = s.Get(ctx, "users:1")
+	assert.False(t, ok)
+
+	commands := server.Commands()
+	assert.Contains(t, commands, "SET cache:posts:1 {\"id\":1} PX 60000")
+	assert.Contains(t, commands, "PEXPIRE cache:tag:posts 60000 GT")
+	assert.Contains(t, commands, "PERSIST cache:tag:users")
+}
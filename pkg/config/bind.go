@@ -0,0 +1,245 @@
+package config
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator is implemented by structs passed to Bind that check their own
+// settings. Returning a ValidationError reports each problem separately.
+type Validator interface {
+	Validate() error
+}
+
+// reservedSections are the twine.yaml sections the framework reads
+var reservedSections = []string{"server", "routes", "templates", "static", "flags", "environments"}
+
+// Bind fills target, a pointer to a struct, from the name section of
+// twine.yaml and the environment, the way the framework's own settings are
+// loaded. See (*Config).Bind.
+func Bind(name string, target any) error {
+	return Get().Bind(name, target)
+}
+
+// Bind fills target, a pointer to a struct, with the application settings
+// in the name section of twine.yaml. Fields are set from, in order:
+//
+//   - the default tag
+//   - the section at the top level of twine.yaml, then under the current
+//     environment, decoded with the yaml tags
+//   - the variable in the env tag, from the environment or .env files
+//   - secrets providers, for fields tagged secret:"true"
+//
+// Fields tagged required:"true" must end up non-zero. If target implements
+// Validator, Validate is called last. Every problem is returned at once in
+// a ValidationError, as with (*Config).Validate:
+//
+//	type PaymentConfig struct {
+//	    Provider string        `yaml:"provider" env:"PAYMENTS_PROVIDER" default:"stripe"`
+//	    APIKey   string        `yaml:"-" env:"PAYMENTS_API_KEY" secret:"true" required:"true"`
+//	    Timeout  time.Duration `yaml:"timeout" default:"10s"`
+//	}
+//
+//	var payments PaymentConfig
+//	err := cfg.Bind("payments", &payments)
+func (c *Config) Bind(name string, target any) error {
+	if slices.Contains(reservedSections, name) {
+		return fmt.Errorf("binding %s: the section is used by the framework", name)
+	}
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("binding %s: target must be a pointer to a struct, not %T", name, target)
+	}
+
+	problems := ValidationError{}
+	if err := bindDefaults(name, v.Elem()); err != nil {
+		return err
+	}
+	for i, sections := range c.sections {
+		section, ok := sections[name]
+		if !ok {
+			continue
+		}
+		if err := section.Decode(target); err != nil {
+			if i > 0 {
+				return fmt.Errorf("binding %s: %s: environments.%s: %w", name, File, c.Env, err)
+			}
+			return fmt.Errorf("binding %s: %s: %w", name, File, err)
+		}
+	}
+	if err := c.bindEnv(name, v.Elem(), &problems); err != nil {
+		return err
+	}
+
+	if validator, ok := target.(Validator); ok && len(problems) == 0 {
+		err := validator.Validate()
+		var invalid ValidationError
+		switch {
+		case err == nil:
+		case errors.As(err, &invalid):
+			problems = append(problems, invalid...)
+		default:
+			problems = append(problems, Problem{Var: name, Message: err.Error()})
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// bindDefaults sets the fields of v with a default tag
+func bindDefaults(name string, v reflect.Value) error {
+	return eachField(v, func(field reflect.StructField, value reflect.Value) error {
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := setField(value, def); err != nil {
+				return fmt.Errorf("binding %s: default for %s: %w", name, field.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// bindEnv sets the fields of v with an env tag from variables and secrets,
+// then checks required fields
+func (c *Config) bindEnv(name string, v reflect.Value, problems *ValidationError) error {
+	return eachField(v, func(field reflect.StructField, value reflect.Value) error {
+		variable := field.Tag.Get("env")
+		if variable != "" && c.src != nil {
+			if raw := c.src.getenv(variable); raw != "" {
+				if err := setField(value, raw); err != nil {
+					*problems = append(*problems, Problem{Var: variable, Message: err.Error()})
+				}
+			}
+		}
+
+		if variable != "" && field.Tag.Get("secret") == "true" {
+			for _, provider := range c.secrets {
+				secret, ok, err := provider.Secret(context.Background(), variable)
+				if err != nil {
+					return fmt.Errorf("%s: resolving secret: %w", variable, err)
+				}
+				if ok {
+					if err := setField(value, secret); err != nil {
+						*problems = append(*problems, Problem{Var: variable, Message: err.Error()})
+					}
+					break
+				}
+			}
+		}
+
+		if field.Tag.Get("required") == "true" && value.IsZero() {
+			setting := variable
+			if setting == "" {
+				setting = name + "." + yamlName(field)
+			}
+			*problems = append(*problems, Problem{Var: setting, Message: "is required"})
+		}
+		return nil
+	})
+}
+
+// eachField calls fn for the exported fields of v, descending into nested
+// structs that aren't values such as time.Time
+func eachField(v reflect.Value, fn func(reflect.StructField, reflect.Value) error) error {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && !isScalar(value) {
+			if err := eachField(value, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yamlName is the key a field is read from in twine.yaml
+func yamlName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name != "" && name != "-" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// isScalar reports whether v is set from a single string
+func isScalar(v reflect.Value) bool {
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// setField parses raw into v. Slices are comma-separated lists.
+func setField(v reflect.Value, raw string) error {
+	if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q must be a duration such as 30s", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+		return nil
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q must be true or false", raw)
+		}
+		v.SetBool(b)
+		return nil
+	case v.CanInt():
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q must be a whole number", raw)
+		}
+		v.SetInt(n)
+		return nil
+	case v.CanUint():
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q must be a positive whole number", raw)
+		}
+		v.SetUint(n)
+		return nil
+	case v.CanFloat():
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q must be a number", raw)
+		}
+		v.SetFloat(f)
+		return nil
+	case v.Kind() == reflect.Slice:
+		items := splitList(raw)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setField(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
@@ -1,18 +1,33 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cstone-io/twine/pkg/logger/rotate"
 )
 
+// File is the project configuration file read from the working directory
+const File = "twine.yaml"
+
 var (
-	once     sync.Once
-	instance *Config
+	once          sync.Once
+	instanceMutex sync.RWMutex
+	instance      *Config
 )
 
 // LogLevel represents logging verbosity levels
@@ -29,13 +44,71 @@ const (
 
 // Config holds all application configuration
 type Config struct {
-	Database DatabaseConfig
-	Logger   LoggerConfig
-	Auth     AuthConfig
+	// Env is the environment whose section of twine.yaml applies, from
+	// TWINE_ENV: development, test or production. Unset, it is production,
+	// so a deployment that forgets it doesn't show clients error details.
+	Env string `yaml:"-"`
+
+	Database  DatabaseConfig  `yaml:"-"`
+	Logger    LoggerConfig    `yaml:"-"`
+	Auth      AuthConfig      `yaml:"-"`
+	Cache     CacheConfig     `yaml:"-"`
+	Jobs      JobsConfig      `yaml:"-"`
+	Schedule  ScheduleConfig  `yaml:"-"`
+	Storage   StorageConfig   `yaml:"-"`
+	Realtime  RealtimeConfig  `yaml:"-"`
+	TLS       TLSConfig       `yaml:"-"`
+	Debug     DebugConfig     `yaml:"-"`
+	Server    ServerConfig    `yaml:"server"`
+	Routes    RoutesConfig    `yaml:"routes"`
+	Templates TemplatesConfig `yaml:"templates"`
+	Static    StaticConfig    `yaml:"static"`
+	Sitemap   SitemapConfig   `yaml:"sitemap"`
+	Robots    RobotsConfig    `yaml:"robots"`
+	Meta      MetaConfig      `yaml:"meta"`
+
+	// Flags are feature flags, read from flags in twine.yaml
+	Flags map[string]bool `yaml:"flags"`
+
+	// Where Load read the settings, for Bind
+	src      *source
+	secrets  []SecretsProvider
+	sections []map[string]yaml.Node // twine.yaml, then its environment section
+}
+
+// Flag reports whether the named feature flag is on
+func (c *Config) Flag(name string) bool {
+	return c.Flags[name]
+}
+
+// The environments of TWINE_ENV that change the framework's defaults. Other
+// names, such as staging, select their twine.yaml section and behave as
+// production.
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// IsDev reports whether the application runs in development, where
+// templates reload, error responses carry their cause and stack trace, and
+// nothing is compressed or cached
+func (c *Config) IsDev() bool {
+	return c.Env == EnvDevelopment
+}
+
+// IsProd reports whether the application runs outside development and
+// test, such as in production or staging, where cookies are Secure,
+// responses are compressed and static files are cached
+func (c *Config) IsProd() bool {
+	return c.Env != EnvDevelopment && c.Env != EnvTest
 }
 
 // DatabaseConfig holds database connection settings
 type DatabaseConfig struct {
+	// Driver is postgres, sqlite or mysql. For sqlite, Name is the database
+	// file and the other connection settings are unused.
+	Driver   string
 	Host     string
 	Port     int
 	Username string
@@ -43,10 +116,45 @@ type DatabaseConfig struct {
 	Name     string
 	SSLMode  string
 	TimeZone string
+
+	// SlowQueryThreshold is how long a query runs before it is logged as a
+	// warning; 0 turns slow query warnings off
+	SlowQueryThreshold time.Duration
+	// LogParams writes query parameters into logged SQL instead of
+	// leaving the placeholders
+	LogParams bool
+
+	// Connection pool limits; 0 keeps database/sql's defaults of unlimited
+	// open connections, 2 idle ones, and no maximum lifetime
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
-// DSN constructs a PostgreSQL connection string
+// Database drivers for DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMySQL    = "mysql"
+)
+
+// DSN constructs a connection string for the Driver
 func (d *DatabaseConfig) DSN() string {
+	switch d.Driver {
+	case DriverSQLite:
+		return d.Name
+	case DriverMySQL:
+		params := url.Values{}
+		params.Set("charset", "utf8mb4")
+		params.Set("parseTime", "true")
+		params.Set("loc", d.TimeZone)
+		if d.SSLMode != "" && d.SSLMode != "disable" {
+			params.Set("tls", "true")
+		}
+		return d.Username + ":" + d.Password +
+			"@tcp(" + net.JoinHostPort(d.Host, strconv.Itoa(d.Port)) + ")/" + d.Name +
+			"?" + params.Encode()
+	}
 	return "host=" + d.Host +
 		" user=" + d.Username +
 		" password=" + d.Password +
@@ -59,61 +167,1042 @@ func (d *DatabaseConfig) DSN() string {
 // LoggerConfig holds logging configuration
 type LoggerConfig struct {
 	Level       LogLevel
+	Format      LogFormat
 	Output      io.Writer
 	ErrorOutput io.Writer
+
+	// Rotation applies to the log files in LOGGER_OUTPUT and
+	// LOGGER_ERROR_OUTPUT
+	Rotation rotate.Options
+
+	// StackTraces records where errors are wrapped, for CustomError and
+	// error reporters
+	StackTraces bool
+
+	// RequestFormat is how middleware.LoggingMiddleware writes requests:
+	// RequestLogDefault, RequestLogCommon, RequestLogCombined or
+	// RequestLogJSON
+	RequestFormat RequestLogFormat
+	// RequestFields are the RequestLogFields written with each request in
+	// the default and JSON formats, after the method, path and status
+	RequestFields []string
+	// RequestSampleFirst and RequestSampleThereafter sample the requests
+	// answered with a 2xx status under high traffic: each second, the first
+	// RequestSampleFirst are logged, then one in every
+	// RequestSampleThereafter. Zero RequestSampleFirst logs every request.
+	RequestSampleFirst      int
+	RequestSampleThereafter int
+
+	// files are the log files Load opened, for Close
+	files []io.Closer
 }
 
+// Close closes the log files opened for the outputs. Standard streams and
+// outputs added with RegisterOutput are left open.
+func (c *LoggerConfig) Close() error {
+	var errs []error
+	for _, file := range c.files {
+		errs = append(errs, file.Close())
+	}
+	c.files = nil
+	return errors.Join(errs...)
+}
+
+// LogFormat is how log entries are encoded
+type LogFormat string
+
+const (
+	// LogText writes lines such as "INFO: 2025/01/02 15:04:05 file.go:12: message key=value"
+	LogText LogFormat = "text"
+	// LogJSON writes one JSON object per entry, for log collectors such as
+	// Loki and Datadog
+	LogJSON LogFormat = "json"
+)
+
+// RequestLogFormat is how LoggingMiddleware writes requests
+type RequestLogFormat string
+
+const (
+	// RequestLogDefault writes an entry through the logger, as text or JSON
+	// with LOGGER_FORMAT, with the request's fields
+	RequestLogDefault RequestLogFormat = "default"
+	// RequestLogCommon writes Apache's Common Log Format
+	RequestLogCommon RequestLogFormat = "common"
+	// RequestLogCombined writes Apache's Combined Log Format, the Common
+	// Log Format followed by the referer and user agent
+	RequestLogCombined RequestLogFormat = "combined"
+	// RequestLogJSON writes one JSON object per request, with the time,
+	// method, path, status and fields, whatever LOGGER_FORMAT is
+	RequestLogJSON RequestLogFormat = "json"
+)
+
+// RequestLogFields are the optional fields of logged requests, for
+// LOGGER_REQUEST_FIELDS
+var RequestLogFields = []string{"latency", "bytes", "user_agent", "referer", "remote_ip", "request_id", "user_id", "route"}
+
+// DefaultRequestLogFields are the fields logged without LOGGER_REQUEST_FIELDS
+var DefaultRequestLogFields = []string{"latency", "bytes", "request_id", "user_id"}
+
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
 	SecretKey string
+	// Algorithm signs tokens: AuthHS256 with SecretKey, or AuthRS256 and
+	// AuthEdDSA with PrivateKey
+	Algorithm string
+	// PrivateKey is the PEM private key, or the path of a PEM file, that
+	// signs tokens with AuthRS256 or AuthEdDSA
+	PrivateKey string
+	// PublicKeys are PEM public keys, or comma-separated paths of PEM
+	// files, that also verify tokens: those of keys rotated out that signed
+	// tokens still in use
+	PublicKeys string
+	// AccessTTL is how long an access token is valid; 0 uses
+	// DefaultAccessTTL
+	AccessTTL time.Duration
+	// RefreshTTL is how long a refresh token is valid, renewed each time
+	// it is used; 0 uses DefaultRefreshTTL
+	RefreshTTL time.Duration
+	// SessionTTL is how long a server-side session lasts without requests,
+	// renewed as it is used; 0 uses DefaultSessionTTL
+	SessionTTL time.Duration
+	// Issuer is the iss claim of issued tokens; when set, tokens from
+	// another issuer are rejected
+	Issuer string
+	// Audience is the aud claim of issued tokens; when set, tokens for
+	// another audience are rejected
+	Audience string
+	// OAuth holds the credentials of the sign-in providers of pkg/auth/oauth
+	OAuth OAuthConfig
+	// PasswordHasher hashes new passwords: PasswordArgon2id or
+	// PasswordBcrypt. Hashes of either kind are verified.
+	PasswordHasher string
+	// Argon2 parameters of new password hashes: memory in KiB, passes
+	// over it, and threads. Zero uses the Default values.
+	Argon2Memory  int
+	Argon2Time    int
+	Argon2Threads int
+}
+
+// Password hashers, for AuthConfig.PasswordHasher
+const (
+	PasswordArgon2id = "argon2id"
+	PasswordBcrypt   = "bcrypt"
+)
+
+// Defaults for AuthConfig's Argon2 parameters, the minimum OWASP
+// recommends: 19 MiB, 2 passes and 1 thread
+const (
+	DefaultArgon2Memory  = 19 * 1024
+	DefaultArgon2Time    = 2
+	DefaultArgon2Threads = 1
+)
+
+// OAuthConfig holds the OAuth client of each sign-in provider. A provider
+// is enabled by setting its client ID.
+type OAuthConfig struct {
+	GoogleClientID     string
+	GoogleClientSecret string
+	GitHubClientID     string
+	GitHubClientSecret string
+	// OIDCIssuer is the URL of any OpenID Connect provider, whose
+	// endpoints are discovered from it
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+}
+
+// Token signing algorithms, for AuthConfig.Algorithm
+const (
+	AuthHS256 = "HS256"
+	AuthRS256 = "RS256"
+	AuthEdDSA = "EdDSA"
+)
+
+// Defaults for AuthConfig without AUTH_ACCESS_TTL, AUTH_REFRESH_TTL and
+// AUTH_SESSION_TTL
+const (
+	DefaultAccessTTL  = time.Hour
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+	DefaultSessionTTL = 24 * time.Hour
+)
+
+// CacheConfig holds application cache settings
+type CacheConfig struct {
+	// Driver is memory or redis
+	Driver string
+	// RedisURL locates the Redis server for the redis driver, e.g.
+	// redis://:password@localhost:6379/0
+	RedisURL string
+	// MaxEntries caps the memory driver, evicting the least recently used
+	// entries; 0 uses DefaultCacheMaxEntries
+	MaxEntries int
+}
+
+// Cache drivers for CACHE_DRIVER
+const (
+	CacheMemory = "memory"
+	CacheRedis  = "redis"
+)
+
+// DefaultCacheMaxEntries is the memory cache's size without CACHE_MAX_ENTRIES
+const DefaultCacheMaxEntries = 10000
+
+// JobsConfig holds background job settings
+type JobsConfig struct {
+	// Driver is database or redis
+	Driver string
+	// RedisURL locates the Redis server for the redis driver
+	RedisURL string
+	// Concurrency is how many jobs a worker runs at once; 0 uses
+	// DefaultJobsConcurrency
+	Concurrency int
+	// PollInterval is how long an idle worker waits before checking for
+	// jobs again; 0 uses DefaultJobsPollInterval
+	PollInterval time.Duration
+}
+
+// Job queue drivers for JOBS_DRIVER
+const (
+	JobsDatabase = "database"
+	JobsRedis    = "redis"
+)
+
+// Defaults for JobsConfig without JOBS_CONCURRENCY and JOBS_POLL_INTERVAL
+const (
+	DefaultJobsConcurrency  = 4
+	DefaultJobsPollInterval = time.Second
+)
+
+// ScheduleConfig holds scheduled task settings
+type ScheduleConfig struct {
+	// Locker is database, redis or memory. Instances sharing a database or
+	// Redis server run each scheduled task once; memory suits a single
+	// instance.
+	Locker string
+	// RedisURL locates the Redis server for the redis locker
+	RedisURL string
+}
+
+// Schedule lockers for SCHEDULE_LOCKER
+const (
+	ScheduleDatabase = "database"
+	ScheduleRedis    = "redis"
+	ScheduleMemory   = "memory"
+)
+
+// StorageConfig holds file storage settings
+type StorageConfig struct {
+	// Driver is local or s3
+	Driver string
+	// Root is the local driver's directory
+	Root string
+	// URL is where the local driver's files are served, e.g. /files, for
+	// signed URLs
+	URL string
+	// S3Endpoint is the S3-compatible server, e.g. http://localhost:9000 for
+	// MinIO; empty uses AWS in S3Region
+	S3Endpoint string
+	S3Region   string
+	S3Bucket   string
+	// S3AccessKey and S3SecretKey are the credentials requests are signed
+	// with
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses the bucket in the path rather than the host
+	// name, as MinIO expects
+	S3PathStyle bool
+}
+
+// Storage drivers for STORAGE_DRIVER
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// RealtimeConfig holds settings for realtime events streamed to browsers
+type RealtimeConfig struct {
+	// Driver is memory or redis. Instances sharing a Redis server deliver
+	// events published on any of them; memory suits a single instance.
+	Driver string
+	// RedisURL locates the Redis server for the redis driver
+	RedisURL string
+	// Heartbeat is how often an idle stream sends a comment, keeping
+	// proxies from closing it; 0 uses DefaultRealtimeHeartbeat
+	Heartbeat time.Duration
 }
 
-// Get returns the singleton config instance
+// TLSConfig holds the settings for serving HTTPS. Servers created with
+// server.NewServerFromConfig serve HTTPS when a certificate or autocert
+// domains are set.
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM certificate chain and private key
+	CertFile string
+	KeyFile  string
+	// AutocertDomains are the domains to get certificates for from an ACME
+	// certificate authority, Let's Encrypt by default, with HTTP-01 or
+	// TLS-ALPN-01 challenges, instead of CertFile and KeyFile
+	AutocertDomains []string
+	// AutocertEmail is the contact for the ACME account, notified about
+	// expiring certificates
+	AutocertEmail string
+	// AutocertCacheDir keeps the account key and certificates between
+	// restarts
+	AutocertCacheDir string
+	// AutocertDirectoryURL is the ACME directory, such as Let's Encrypt's
+	// staging environment; empty uses Let's Encrypt
+	AutocertDirectoryURL string
+	// RedirectPort is the port of a listener redirecting HTTP to HTTPS,
+	// which also answers HTTP-01 challenges. Empty means none, or "80" with
+	// autocert.
+	RedirectPort string
+}
+
+// Enabled reports whether HTTPS is configured
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != "" || len(t.AutocertDomains) > 0
+}
+
+// DebugConfig holds the settings of the profiling and inspection endpoints
+// of pkg/debug, which are off unless Enabled
+type DebugConfig struct {
+	Enabled bool
+	// Token is the bearer token requests to the endpoints need. With a
+	// token, they are served on the application's port; without one, only
+	// on Addr.
+	Token string
+	// Addr is the address of a listener serving only the endpoints, such
+	// as 127.0.0.1:6060. Without a token it must be a loopback address,
+	// and defaults to DefaultDebugAddr.
+	Addr string
+}
+
+// DefaultDebugAddr is where the debug endpoints listen when enabled
+// without DEBUG_TOKEN or DEBUG_ADDR
+const DefaultDebugAddr = "127.0.0.1:6060"
+
+// Realtime drivers for REALTIME_DRIVER
+const (
+	RealtimeMemory = "memory"
+	RealtimeRedis  = "redis"
+)
+
+// DefaultRealtimeHeartbeat is the heartbeat without REALTIME_HEARTBEAT
+const DefaultRealtimeHeartbeat = 25 * time.Second
+
+// ServerConfig holds HTTP server settings
+type ServerConfig struct {
+	Port string `yaml:"port"`
+
+	// TrustedProxies are the IPs and CIDR ranges of reverse proxies whose
+	// X-Forwarded-* headers can be believed
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	// IPAllow and IPDeny are the IPs and CIDR ranges of clients that
+	// middleware.IPFilterFromConfig lets through and refuses. An empty
+	// IPAllow lets through every client not denied.
+	IPAllow []string `yaml:"ip_allow"`
+	IPDeny  []string `yaml:"ip_deny"`
+
+	// Limits on each connection, applied to the http.Server. A zero
+	// timeout means none, and zero MaxHeaderBytes means 1 MB.
+	ReadTimeout    time.Duration `yaml:"read_timeout"`
+	WriteTimeout   time.Duration `yaml:"write_timeout"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes int           `yaml:"max_header_bytes"`
+
+	// ShutdownTimeout is how long shutdown waits for open requests to
+	// finish, and then again for shutdown hooks to return. Zero means no
+	// limit.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// HealthPath and ReadyPath are where the server answers liveness and
+	// readiness probes; empty turns an endpoint off
+	HealthPath string `yaml:"health_path"`
+	ReadyPath  string `yaml:"ready_path"`
+
+	// Compress gzips responses for clients that accept it. It defaults to
+	// on in production.
+	Compress bool `yaml:"compress"`
+}
+
+// Defaults for the server limits, for a server facing the internet
+const (
+	DefaultReadTimeout    = 15 * time.Second
+	DefaultWriteTimeout   = 30 * time.Second
+	DefaultIdleTimeout    = 60 * time.Second
+	DefaultMaxHeaderBytes = 1 << 20
+)
+
+// DefaultShutdownTimeout is the shutdown grace period without
+// SERVER_SHUTDOWN_TIMEOUT
+const DefaultShutdownTimeout = 10 * time.Second
+
+// Addr returns the address to listen on
+func (s *ServerConfig) Addr() string {
+	return ":" + s.Port
+}
+
+// IsTrustedProxy reports whether ip, optionally with a port, belongs to a
+// trusted proxy
+func (s *ServerConfig) IsTrustedProxy(ip string) bool {
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, proxy := range s.TrustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if trusted := net.ParseIP(proxy); trusted != nil && trusted.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutesConfig holds routing settings
+type RoutesConfig struct {
+	// Root is the URL path the application's routes are mounted under
+	Root string `yaml:"root"`
+}
+
+// TemplatesConfig holds template loading settings
+type TemplatesConfig struct {
+	// Patterns are the globs passed to template.LoadTemplates
+	Patterns []string `yaml:"patterns"`
+	// Reload re-parses templates whose files changed before rendering, and
+	// shows parse errors in the browser. It defaults to true in development;
+	// TEMPLATES_RELOAD overrides it.
+	Reload bool `yaml:"reload"`
+}
+
+// StaticConfig holds static file settings
+type StaticConfig struct {
+	// Dirs are the directories static files are served from
+	Dirs []string `yaml:"dirs"`
+}
+
+// SitemapConfig holds the settings of /sitemap.xml
+type SitemapConfig struct {
+	// BaseURL is the site's URL, such as https://example.com, that sitemap
+	// paths are joined to. When empty, the request's scheme and host are
+	// used.
+	BaseURL string `yaml:"base_url"`
+	// Exclude are path.Match patterns of pages left out of the sitemap,
+	// such as /login or /admin/*
+	Exclude []string `yaml:"exclude"`
+}
+
+// RobotsConfig holds the rules /robots.txt gives crawlers
+type RobotsConfig struct {
+	// Disallow are the paths crawlers are asked not to visit. [/] keeps a
+	// site, such as a staging environment, out of search engines.
+	Disallow []string `yaml:"disallow"`
+	// Allow are paths under Disallow crawlers may visit after all
+	Allow []string `yaml:"allow"`
+}
+
+// MetaConfig holds the head tags of pages that don't set their own with
+// k.SetMeta
+type MetaConfig struct {
+	// SiteName is og:site_name
+	SiteName    string `yaml:"site_name"`
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	// OGImage is the image link previews show, as an absolute URL
+	OGImage string `yaml:"og_image"`
+	// TwitterCard is twitter:card, such as summary_large_image
+	TwitterCard string `yaml:"twitter_card"`
+}
+
+// EnvVar describes an environment variable read into Config
+type EnvVar struct {
+	Name    string
+	Default string // Used when the variable is unset; empty if there is none
+	// Optional variables have no default but don't need to be set, because
+	// twine.yaml or Config's defaults cover them
+	Optional bool
+	// Secret variables can also be resolved by a SecretsProvider, e.g. from
+	// the file named by <Name>_FILE
+	Secret bool
+}
+
+// EnvVars lists the environment variables Load reads, in the order it reads them
+var EnvVars = []EnvVar{
+	{Name: "DB_DRIVER", Default: "postgres"},
+	{Name: "DB_HOST"},
+	{Name: "DB_PORT"},
+	{Name: "DB_USERNAME"},
+	{Name: "DB_PASSWORD", Secret: true},
+	{Name: "DB_NAME"},
+	{Name: "DB_SSLMODE", Default: "disable"},
+	{Name: "DB_TIMEZONE", Default: "UTC"},
+	{Name: "DB_SLOW_QUERY_THRESHOLD", Default: "200ms"},
+	{Name: "DB_LOG_PARAMS", Optional: true},
+	{Name: "DB_MAX_OPEN_CONNS", Optional: true},
+	{Name: "DB_MAX_IDLE_CONNS", Optional: true},
+	{Name: "DB_CONN_MAX_LIFETIME", Optional: true},
+	{Name: "CACHE_DRIVER", Default: "memory"},
+	{Name: "CACHE_REDIS_URL", Optional: true, Secret: true},
+	{Name: "CACHE_MAX_ENTRIES", Optional: true},
+	{Name: "JOBS_DRIVER", Default: "database"},
+	{Name: "JOBS_REDIS_URL", Optional: true, Secret: true},
+	{Name: "JOBS_CONCURRENCY", Optional: true},
+	{Name: "JOBS_POLL_INTERVAL", Optional: true},
+	{Name: "SCHEDULE_LOCKER", Default: "database"},
+	{Name: "SCHEDULE_REDIS_URL", Optional: true, Secret: true},
+	{Name: "STORAGE_DRIVER", Default: "local"},
+	{Name: "STORAGE_ROOT", Default: "storage"},
+	{Name: "STORAGE_URL", Optional: true},
+	{Name: "STORAGE_S3_ENDPOINT", Optional: true},
+	{Name: "STORAGE_S3_REGION", Default: "us-east-1"},
+	{Name: "STORAGE_S3_BUCKET", Optional: true},
+	{Name: "STORAGE_S3_ACCESS_KEY", Optional: true, Secret: true},
+	{Name: "STORAGE_S3_SECRET_KEY", Optional: true, Secret: true},
+	{Name: "STORAGE_S3_PATH_STYLE", Optional: true},
+	{Name: "REALTIME_DRIVER", Default: "memory"},
+	{Name: "REALTIME_REDIS_URL", Optional: true, Secret: true},
+	{Name: "REALTIME_HEARTBEAT", Optional: true},
+	{Name: "TLS_CERT_FILE", Optional: true},
+	{Name: "TLS_KEY_FILE", Optional: true},
+	{Name: "TLS_AUTOCERT_DOMAINS", Optional: true},
+	{Name: "TLS_AUTOCERT_EMAIL", Optional: true},
+	{Name: "TLS_AUTOCERT_CACHE_DIR", Default: "certs"},
+	{Name: "TLS_AUTOCERT_DIRECTORY_URL", Optional: true},
+	{Name: "TLS_REDIRECT_PORT", Optional: true},
+	{Name: "DEBUG_ENABLED", Optional: true},
+	{Name: "DEBUG_TOKEN", Optional: true, Secret: true},
+	{Name: "DEBUG_ADDR", Optional: true},
+	{Name: "LOGGER_LEVEL", Default: "info"},
+	{Name: "LOGGER_FORMAT", Default: "text"},
+	{Name: "LOGGER_STACK_TRACES", Default: "true"},
+	{Name: "LOGGER_OUTPUT", Default: "stdout"},
+	{Name: "LOGGER_ERROR_OUTPUT", Default: "stderr"},
+	{Name: "LOGGER_ROTATE_SIZE", Optional: true},
+	{Name: "LOGGER_ROTATE_AGE", Optional: true},
+	{Name: "LOGGER_MAX_BACKUPS", Optional: true},
+	{Name: "LOGGER_COMPRESS", Optional: true},
+	{Name: "LOGGER_REQUEST_FORMAT", Default: "default"},
+	{Name: "LOGGER_REQUEST_FIELDS", Optional: true},
+	{Name: "LOGGER_REQUEST_SAMPLE_FIRST", Optional: true},
+	{Name: "LOGGER_REQUEST_SAMPLE_THEREAFTER", Optional: true},
+	{Name: "AUTH_SECRET", Secret: true},
+	{Name: "AUTH_ALGORITHM", Default: "HS256"},
+	{Name: "AUTH_PRIVATE_KEY", Optional: true, Secret: true},
+	{Name: "AUTH_PUBLIC_KEYS", Optional: true},
+	{Name: "AUTH_ACCESS_TTL", Default: "1h"},
+	{Name: "AUTH_REFRESH_TTL", Default: "720h"},
+	{Name: "AUTH_SESSION_TTL", Default: "24h"},
+	{Name: "AUTH_ISSUER", Optional: true},
+	{Name: "AUTH_AUDIENCE", Optional: true},
+	{Name: "AUTH_GOOGLE_CLIENT_ID", Optional: true},
+	{Name: "AUTH_GOOGLE_CLIENT_SECRET", Optional: true, Secret: true},
+	{Name: "AUTH_GITHUB_CLIENT_ID", Optional: true},
+	{Name: "AUTH_GITHUB_CLIENT_SECRET", Optional: true, Secret: true},
+	{Name: "AUTH_OIDC_ISSUER", Optional: true},
+	{Name: "AUTH_OIDC_CLIENT_ID", Optional: true},
+	{Name: "AUTH_OIDC_CLIENT_SECRET", Optional: true, Secret: true},
+	{Name: "AUTH_PASSWORD_HASHER", Default: "argon2id"},
+	{Name: "AUTH_ARGON2_MEMORY", Default: "19456"},
+	{Name: "AUTH_ARGON2_TIME", Default: "2"},
+	{Name: "AUTH_ARGON2_THREADS", Default: "1"},
+	{Name: "TWINE_ENV", Default: "production"},
+	{Name: "PORT", Optional: true},
+	{Name: "TRUSTED_PROXIES", Optional: true},
+	{Name: "SERVER_IP_ALLOW", Optional: true},
+	{Name: "SERVER_IP_DENY", Optional: true},
+	{Name: "SERVER_READ_TIMEOUT", Optional: true},
+	{Name: "SERVER_WRITE_TIMEOUT", Optional: true},
+	{Name: "SERVER_IDLE_TIMEOUT", Optional: true},
+	{Name: "SERVER_MAX_HEADER_BYTES", Optional: true},
+	{Name: "SERVER_SHUTDOWN_TIMEOUT", Optional: true},
+	{Name: "SERVER_COMPRESS", Optional: true},
+	{Name: "TEMPLATES_RELOAD", Optional: true},
+}
+
+// Get returns the singleton config instance, loaded from the process
+// environment, .env and twine.yaml. It exits if the configuration can't be
+// loaded. New code can call Load and pass the Config to constructors instead.
+// After Reload, Get returns the reloaded Config.
 func Get() *Config {
 	once.Do(func() {
-		instance = &Config{}
-		initialize()
+		// Unlike Load, Get exports .env to the process environment
+		if err := exportDotenv(); err != nil {
+			log.Println("Warning: .env file not found, using environment variables")
+		}
+
+		cfg, err := loadDefault()
+		if err != nil {
+			log.Fatalf("Error loading configuration: %v", err)
+		}
+
+		instanceMutex.Lock()
+		instance = cfg
+		instanceMutex.Unlock()
 	})
+
+	instanceMutex.RLock()
+	defer instanceMutex.RUnlock()
 	return instance
 }
 
-func initialize() {
-	err := godotenv.Load()
+// loadDefault loads the configuration Get returns, from the process
+// environment after exportDotenv
+func loadDefault() (*Config, error) {
+	return Load(WithEnvFiles(), WithSecrets(getDefaultSecrets()...))
+}
+
+// Option configures Load
+type Option func(*loadOptions)
+
+type loadOptions struct {
+	envFiles []string
+	file     string
+	env      string
+	lookup   func(string) (string, bool)
+	secrets  []SecretsProvider
+}
+
+// WithEnvFiles reads variables from the given dotenv files instead of .env.
+// Earlier files and the environment take precedence, as with godotenv.Load.
+// With no paths, no dotenv files are read.
+func WithEnvFiles(paths ...string) Option {
+	return func(o *loadOptions) {
+		o.envFiles = paths
+	}
+}
+
+// WithFile reads settings from path instead of twine.yaml. An empty path
+// skips the file.
+func WithFile(path string) Option {
+	return func(o *loadOptions) {
+		o.file = path
+	}
+}
+
+// WithEnv selects the twine.yaml environment section instead of TWINE_ENV
+func WithEnv(name string) Option {
+	return func(o *loadOptions) {
+		o.env = name
+	}
+}
+
+// WithVars reads variables from vars instead of the process environment
+func WithVars(vars map[string]string) Option {
+	return func(o *loadOptions) {
+		o.lookup = func(key string) (string, bool) {
+			value, ok := vars[key]
+			return value, ok
+		}
+	}
+}
+
+// Load builds a Config from the environment, .env and twine.yaml in the
+// working directory. Unlike Get, it keeps no global state, doesn't change the
+// process environment and returns errors rather than exiting, so tests and
+// applications embedding several configurations can each load their own.
+func Load(opts ...Option) (*Config, error) {
+	o := &loadOptions{envFiles: []string{".env"}, file: File, lookup: os.LookupEnv}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	src := &source{lookup: o.lookup, dotenv: map[string]string{}}
+	for _, path := range o.envFiles {
+		values, err := godotenv.Read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		for key, value := range values {
+			if _, ok := src.dotenv[key]; !ok {
+				src.dotenv[key] = value
+			}
+		}
+	}
+
+	cfg := &Config{src: src, secrets: o.secrets}
+	var err error
+
+	cfg.Database.Driver = src.getEnvOrDefault("DB_DRIVER", "postgres")
+	cfg.Database.Host = src.getenv("DB_HOST")
+	if cfg.Database.Port, err = atoi(src.getenv("DB_PORT")); err != nil {
+		return nil, fmt.Errorf("DB_PORT: %w", err)
+	}
+	cfg.Database.Username = src.getenv("DB_USERNAME")
+	cfg.Database.Password = src.getenv("DB_PASSWORD")
+	cfg.Database.Name = src.getenv("DB_NAME")
+	cfg.Database.SSLMode = src.getEnvOrDefault("DB_SSLMODE", "disable")
+	cfg.Database.TimeZone = src.getEnvOrDefault("DB_TIMEZONE", "UTC")
+	if err := parseDuration(src.getEnvOrDefault("DB_SLOW_QUERY_THRESHOLD", "200ms"), &cfg.Database.SlowQueryThreshold); err != nil {
+		return nil, fmt.Errorf("DB_SLOW_QUERY_THRESHOLD: %w", err)
+	}
+	if logParams := src.getenv("DB_LOG_PARAMS"); logParams != "" {
+		if cfg.Database.LogParams, err = strconv.ParseBool(logParams); err != nil {
+			return nil, fmt.Errorf("DB_LOG_PARAMS: %w", err)
+		}
+	}
+	if cfg.Database.MaxOpenConns, err = atoi(src.getenv("DB_MAX_OPEN_CONNS")); err != nil {
+		return nil, fmt.Errorf("DB_MAX_OPEN_CONNS: %w", err)
+	}
+	if cfg.Database.MaxIdleConns, err = atoi(src.getenv("DB_MAX_IDLE_CONNS")); err != nil {
+		return nil, fmt.Errorf("DB_MAX_IDLE_CONNS: %w", err)
+	}
+	if err := parseDuration(src.getenv("DB_CONN_MAX_LIFETIME"), &cfg.Database.ConnMaxLifetime); err != nil {
+		return nil, fmt.Errorf("DB_CONN_MAX_LIFETIME: %w", err)
+	}
+
+	cfg.Cache.Driver = src.getEnvOrDefault("CACHE_DRIVER", "memory")
+	cfg.Cache.RedisURL = src.getenv("CACHE_REDIS_URL")
+	if cfg.Cache.MaxEntries, err = atoi(src.getenv("CACHE_MAX_ENTRIES")); err != nil {
+		return nil, fmt.Errorf("CACHE_MAX_ENTRIES: %w", err)
+	}
+
+	cfg.Jobs.Driver = src.getEnvOrDefault("JOBS_DRIVER", "database")
+	cfg.Jobs.RedisURL = src.getenv("JOBS_REDIS_URL")
+	if cfg.Jobs.Concurrency, err = atoi(src.getenv("JOBS_CONCURRENCY")); err != nil {
+		return nil, fmt.Errorf("JOBS_CONCURRENCY: %w", err)
+	}
+	if err := parseDuration(src.getenv("JOBS_POLL_INTERVAL"), &cfg.Jobs.PollInterval); err != nil {
+		return nil, fmt.Errorf("JOBS_POLL_INTERVAL: %w", err)
+	}
+
+	cfg.Schedule.Locker = src.getEnvOrDefault("SCHEDULE_LOCKER", "database")
+	cfg.Schedule.RedisURL = src.getenv("SCHEDULE_REDIS_URL")
+
+	cfg.Storage.Driver = src.getEnvOrDefault("STORAGE_DRIVER", "local")
+	cfg.Storage.Root = src.getEnvOrDefault("STORAGE_ROOT", "storage")
+	cfg.Storage.URL = src.getenv("STORAGE_URL")
+	cfg.Storage.S3Endpoint = src.getenv("STORAGE_S3_ENDPOINT")
+	cfg.Storage.S3Region = src.getEnvOrDefault("STORAGE_S3_REGION", "us-east-1")
+	cfg.Storage.S3Bucket = src.getenv("STORAGE_S3_BUCKET")
+	cfg.Storage.S3AccessKey = src.getenv("STORAGE_S3_ACCESS_KEY")
+	cfg.Storage.S3SecretKey = src.getenv("STORAGE_S3_SECRET_KEY")
+	if pathStyle := src.getenv("STORAGE_S3_PATH_STYLE"); pathStyle != "" {
+		if cfg.Storage.S3PathStyle, err = strconv.ParseBool(pathStyle); err != nil {
+			return nil, fmt.Errorf("STORAGE_S3_PATH_STYLE: %w", err)
+		}
+	}
+
+	cfg.Realtime.Driver = src.getEnvOrDefault("REALTIME_DRIVER", "memory")
+	cfg.Realtime.RedisURL = src.getenv("REALTIME_REDIS_URL")
+	if err := parseDuration(src.getenv("REALTIME_HEARTBEAT"), &cfg.Realtime.Heartbeat); err != nil {
+		return nil, fmt.Errorf("REALTIME_HEARTBEAT: %w", err)
+	}
+
+	cfg.TLS.CertFile = src.getenv("TLS_CERT_FILE")
+	cfg.TLS.KeyFile = src.getenv("TLS_KEY_FILE")
+	cfg.TLS.AutocertDomains = splitList(src.getenv("TLS_AUTOCERT_DOMAINS"))
+	cfg.TLS.AutocertEmail = src.getenv("TLS_AUTOCERT_EMAIL")
+	cfg.TLS.AutocertCacheDir = src.getEnvOrDefault("TLS_AUTOCERT_CACHE_DIR", "certs")
+	cfg.TLS.AutocertDirectoryURL = src.getenv("TLS_AUTOCERT_DIRECTORY_URL")
+	cfg.TLS.RedirectPort = src.getenv("TLS_REDIRECT_PORT")
+	if cfg.TLS.RedirectPort == "" && len(cfg.TLS.AutocertDomains) > 0 {
+		cfg.TLS.RedirectPort = "80"
+	}
+
+	if enabled := src.getenv("DEBUG_ENABLED"); enabled != "" {
+		if cfg.Debug.Enabled, err = strconv.ParseBool(enabled); err != nil {
+			return nil, fmt.Errorf("DEBUG_ENABLED: %w", err)
+		}
+	}
+	cfg.Debug.Token = src.getenv("DEBUG_TOKEN")
+	cfg.Debug.Addr = src.getenv("DEBUG_ADDR")
+	if cfg.Debug.Enabled && cfg.Debug.Token == "" && cfg.Debug.Addr == "" {
+		cfg.Debug.Addr = DefaultDebugAddr
+	}
+
+	cfg.Logger.Level = parseLogLevel(src.getenv("LOGGER_LEVEL"))
+	if cfg.Logger.Format, err = parseLogFormat(src.getEnvOrDefault("LOGGER_FORMAT", "text")); err != nil {
+		return nil, fmt.Errorf("LOGGER_FORMAT: %w", err)
+	}
+	if cfg.Logger.StackTraces, err = strconv.ParseBool(src.getEnvOrDefault("LOGGER_STACK_TRACES", "true")); err != nil {
+		return nil, fmt.Errorf("LOGGER_STACK_TRACES: %w", err)
+	}
+	rotation := &cfg.Logger.Rotation
+	if rotation.MaxSize, err = parseSize(src.getenv("LOGGER_ROTATE_SIZE")); err != nil {
+		return nil, fmt.Errorf("LOGGER_ROTATE_SIZE: %w", err)
+	}
+	if err := parseDuration(src.getenv("LOGGER_ROTATE_AGE"), &rotation.MaxAge); err != nil {
+		return nil, fmt.Errorf("LOGGER_ROTATE_AGE: %w", err)
+	}
+	if rotation.MaxBackups, err = atoi(src.getenv("LOGGER_MAX_BACKUPS")); err != nil {
+		return nil, fmt.Errorf("LOGGER_MAX_BACKUPS: %w", err)
+	}
+	if compress := src.getenv("LOGGER_COMPRESS"); compress != "" {
+		if rotation.Compress, err = strconv.ParseBool(compress); err != nil {
+			return nil, fmt.Errorf("LOGGER_COMPRESS: %w", err)
+		}
+	}
+	if cfg.Logger.RequestFormat, err = parseRequestLogFormat(src.getEnvOrDefault("LOGGER_REQUEST_FORMAT", "default")); err != nil {
+		return nil, fmt.Errorf("LOGGER_REQUEST_FORMAT: %w", err)
+	}
+	cfg.Logger.RequestFields = DefaultRequestLogFields
+	if fields := src.getenv("LOGGER_REQUEST_FIELDS"); fields != "" {
+		cfg.Logger.RequestFields = splitList(fields)
+		for _, field := range cfg.Logger.RequestFields {
+			if !slices.Contains(RequestLogFields, field) {
+				return nil, fmt.Errorf("LOGGER_REQUEST_FIELDS: %q must be one of %s", field, strings.Join(RequestLogFields, ", "))
+			}
+		}
+	}
+	if cfg.Logger.RequestSampleFirst, err = atoi(src.getenv("LOGGER_REQUEST_SAMPLE_FIRST")); err != nil {
+		return nil, fmt.Errorf("LOGGER_REQUEST_SAMPLE_FIRST: %w", err)
+	}
+	if cfg.Logger.RequestSampleThereafter, err = atoi(src.getenv("LOGGER_REQUEST_SAMPLE_THEREAFTER")); err != nil {
+		return nil, fmt.Errorf("LOGGER_REQUEST_SAMPLE_THEREAFTER: %w", err)
+	}
+	opened := map[string]io.Writer{}
+	if cfg.Logger.Output, err = openOutputs(src.getEnvOrDefault("LOGGER_OUTPUT", "stdout"), &cfg.Logger, opened); err != nil {
+		return nil, fmt.Errorf("LOGGER_OUTPUT: %w", err)
+	}
+	if cfg.Logger.ErrorOutput, err = openOutputs(src.getEnvOrDefault("LOGGER_ERROR_OUTPUT", "stderr"), &cfg.Logger, opened); err != nil {
+		cfg.Logger.Close()
+		return nil, fmt.Errorf("LOGGER_ERROR_OUTPUT: %w", err)
+	}
+
+	cfg.Auth.SecretKey = src.getenv("AUTH_SECRET")
+	cfg.Auth.Algorithm = src.getEnvOrDefault("AUTH_ALGORITHM", "HS256")
+	cfg.Auth.PrivateKey = src.getenv("AUTH_PRIVATE_KEY")
+	cfg.Auth.PublicKeys = src.getenv("AUTH_PUBLIC_KEYS")
+	if err := parseDuration(src.getEnvOrDefault("AUTH_ACCESS_TTL", "1h"), &cfg.Auth.AccessTTL); err != nil {
+		return nil, fmt.Errorf("AUTH_ACCESS_TTL: %w", err)
+	}
+	if err := parseDuration(src.getEnvOrDefault("AUTH_REFRESH_TTL", "720h"), &cfg.Auth.RefreshTTL); err != nil {
+		return nil, fmt.Errorf("AUTH_REFRESH_TTL: %w", err)
+	}
+	if err := parseDuration(src.getEnvOrDefault("AUTH_SESSION_TTL", "24h"), &cfg.Auth.SessionTTL); err != nil {
+		return nil, fmt.Errorf("AUTH_SESSION_TTL: %w", err)
+	}
+	cfg.Auth.Issuer = src.getenv("AUTH_ISSUER")
+	cfg.Auth.Audience = src.getenv("AUTH_AUDIENCE")
+	cfg.Auth.OAuth.GoogleClientID = src.getenv("AUTH_GOOGLE_CLIENT_ID")
+	cfg.Auth.OAuth.GoogleClientSecret = src.getenv("AUTH_GOOGLE_CLIENT_SECRET")
+	cfg.Auth.OAuth.GitHubClientID = src.getenv("AUTH_GITHUB_CLIENT_ID")
+	cfg.Auth.OAuth.GitHubClientSecret = src.getenv("AUTH_GITHUB_CLIENT_SECRET")
+	cfg.Auth.OAuth.OIDCIssuer = src.getenv("AUTH_OIDC_ISSUER")
+	cfg.Auth.OAuth.OIDCClientID = src.getenv("AUTH_OIDC_CLIENT_ID")
+	cfg.Auth.OAuth.OIDCClientSecret = src.getenv("AUTH_OIDC_CLIENT_SECRET")
+	cfg.Auth.PasswordHasher = src.getEnvOrDefault("AUTH_PASSWORD_HASHER", "argon2id")
+	if cfg.Auth.Argon2Memory, err = atoi(src.getEnvOrDefault("AUTH_ARGON2_MEMORY", "19456")); err != nil {
+		return nil, fmt.Errorf("AUTH_ARGON2_MEMORY: %w", err)
+	}
+	if cfg.Auth.Argon2Time, err = atoi(src.getEnvOrDefault("AUTH_ARGON2_TIME", "2")); err != nil {
+		return nil, fmt.Errorf("AUTH_ARGON2_TIME: %w", err)
+	}
+	if cfg.Auth.Argon2Threads, err = atoi(src.getEnvOrDefault("AUTH_ARGON2_THREADS", "1")); err != nil {
+		return nil, fmt.Errorf("AUTH_ARGON2_THREADS: %w", err)
+	}
+	if err := resolveSecrets(context.Background(), cfg, o.secrets); err != nil {
+		return nil, err
+	}
+
+	cfg.Env = src.getEnvOrDefault("TWINE_ENV", "production")
+	if o.env != "" {
+		cfg.Env = o.env
+	}
+	setDefaults(cfg)
+	if o.file != "" {
+		if err := loadFile(cfg, o.file, cfg.Env); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", o.file, err)
+		}
+	}
+	if port := src.getenv("PORT"); port != "" {
+		cfg.Server.Port = port
+	}
+	if proxies := src.getenv("TRUSTED_PROXIES"); proxies != "" {
+		cfg.Server.TrustedProxies = splitList(proxies)
+	}
+	if err := validateRanges(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	if allow := src.getenv("SERVER_IP_ALLOW"); allow != "" {
+		cfg.Server.IPAllow = splitList(allow)
+	}
+	if err := validateRanges(cfg.Server.IPAllow); err != nil {
+		return nil, fmt.Errorf("SERVER_IP_ALLOW: %w", err)
+	}
+	if deny := src.getenv("SERVER_IP_DENY"); deny != "" {
+		cfg.Server.IPDeny = splitList(deny)
+	}
+	if err := validateRanges(cfg.Server.IPDeny); err != nil {
+		return nil, fmt.Errorf("SERVER_IP_DENY: %w", err)
+	}
+	if err := parseDuration(src.getenv("SERVER_READ_TIMEOUT"), &cfg.Server.ReadTimeout); err != nil {
+		return nil, fmt.Errorf("SERVER_READ_TIMEOUT: %w", err)
+	}
+	if err := parseDuration(src.getenv("SERVER_WRITE_TIMEOUT"), &cfg.Server.WriteTimeout); err != nil {
+		return nil, fmt.Errorf("SERVER_WRITE_TIMEOUT: %w", err)
+	}
+	if err := parseDuration(src.getenv("SERVER_IDLE_TIMEOUT"), &cfg.Server.IdleTimeout); err != nil {
+		return nil, fmt.Errorf("SERVER_IDLE_TIMEOUT: %w", err)
+	}
+	if bytes := src.getenv("SERVER_MAX_HEADER_BYTES"); bytes != "" {
+		if cfg.Server.MaxHeaderBytes, err = strconv.Atoi(bytes); err != nil {
+			return nil, fmt.Errorf("SERVER_MAX_HEADER_BYTES: %w", err)
+		}
+	}
+	if err := parseDuration(src.getenv("SERVER_SHUTDOWN_TIMEOUT"), &cfg.Server.ShutdownTimeout); err != nil {
+		return nil, fmt.Errorf("SERVER_SHUTDOWN_TIMEOUT: %w", err)
+	}
+	if compress := src.getenv("SERVER_COMPRESS"); compress != "" {
+		if cfg.Server.Compress, err = strconv.ParseBool(compress); err != nil {
+			return nil, fmt.Errorf("SERVER_COMPRESS: %w", err)
+		}
+	}
+	if reload := src.getenv("TEMPLATES_RELOAD"); reload != "" {
+		if cfg.Templates.Reload, err = strconv.ParseBool(reload); err != nil {
+			return nil, fmt.Errorf("TEMPLATES_RELOAD: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// source looks variables up in the environment, then in dotenv files
+type source struct {
+	lookup func(string) (string, bool)
+	dotenv map[string]string
+}
+
+func (s *source) getenv(key string) string {
+	if value, ok := s.lookup(key); ok {
+		return value
+	}
+	return s.dotenv[key]
+}
+
+func (s *source) getEnvOrDefault(key, defaultValue string) string {
+	if value := s.getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// setDefaults sets the settings twine.yaml can change to the values a
+// project created by twine init expects
+func setDefaults(cfg *Config) {
+	cfg.Server.Port = "3000"
+	cfg.Server.ReadTimeout = DefaultReadTimeout
+	cfg.Server.WriteTimeout = DefaultWriteTimeout
+	cfg.Server.IdleTimeout = DefaultIdleTimeout
+	cfg.Server.MaxHeaderBytes = DefaultMaxHeaderBytes
+	cfg.Server.ShutdownTimeout = DefaultShutdownTimeout
+	cfg.Server.HealthPath = "/healthz"
+	cfg.Server.ReadyPath = "/readyz"
+	cfg.Routes.Root = "/"
+	cfg.Templates.Patterns = []string{"templates/**/*.html"}
+	cfg.Server.Compress = cfg.IsProd()
+	cfg.Templates.Reload = cfg.IsDev()
+	cfg.Static.Dirs = []string{"public"}
+}
+
+// loadFile overlays the top-level settings in path, then the section for
+// env under environments. A missing file leaves cfg unchanged.
+func loadFile(cfg *Config, path, env string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		log.Println("Warning: .env file not found, using environment variables")
+		return err
 	}
 
-	instance.Database.Host = os.Getenv("DB_HOST")
-	instance.Database.Port = mustAtoi(os.Getenv("DB_PORT"))
-	instance.Database.Username = os.Getenv("DB_USERNAME")
-	instance.Database.Password = os.Getenv("DB_PASSWORD")
-	instance.Database.Name = os.Getenv("DB_NAME")
-	instance.Database.SSLMode = getEnvOrDefault("DB_SSLMODE", "disable")
-	instance.Database.TimeZone = getEnvOrDefault("DB_TIMEZONE", "UTC")
+	var file struct {
+		Environments map[string]yaml.Node `yaml:"environments"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return err
+	}
+	sections := map[string]yaml.Node{}
+	if err := yaml.Unmarshal(data, &sections); err != nil {
+		return err
+	}
+	cfg.sections = []map[string]yaml.Node{sections}
 
-	instance.Logger.Level = parseLogLevel(os.Getenv("LOGGER_LEVEL"))
-	instance.Logger.Output = parseOutput(getEnvOrDefault("LOGGER_OUTPUT", "stdout"))
-	instance.Logger.ErrorOutput = parseOutput(getEnvOrDefault("LOGGER_ERROR_OUTPUT", "stderr"))
+	if section, ok := file.Environments[env]; ok {
+		if err := section.Decode(cfg); err != nil {
+			return fmt.Errorf("environments.%s: %w", env, err)
+		}
+		sections := map[string]yaml.Node{}
+		if err := section.Decode(&sections); err != nil {
+			return fmt.Errorf("environments.%s: %w", env, err)
+		}
+		cfg.sections = append(cfg.sections, sections)
+	}
+
+	return nil
+}
+
+// validateRanges checks that every entry of a list of trusted proxies or
+// filtered clients is an IP or CIDR range
+func validateRanges(ranges []string) error {
+	for _, r := range ranges {
+		if _, _, err := net.ParseCIDR(r); err == nil {
+			continue
+		}
+		if net.ParseIP(r) == nil {
+			return fmt.Errorf("%q is not an IP address or CIDR range", r)
+		}
+	}
+	return nil
+}
 
-	instance.Auth.SecretKey = os.Getenv("AUTH_SECRET")
+// splitList splits a comma-separated list, dropping empty items
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
-func mustAtoi(s string) int {
+// parseDuration sets d from s, such as 30s, unless s is empty
+func parseDuration(s string, d *time.Duration) error {
 	if s == "" {
-		return 0
+		return nil
 	}
-	i, err := strconv.Atoi(s)
+	parsed, err := time.ParseDuration(s)
 	if err != nil {
-		log.Fatalf("Error converting string to int: %v", err)
+		return err
 	}
-	return i
+	*d = parsed
+	return nil
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func atoi(s string) (int, error) {
+	if s == "" {
+		return 0, nil
 	}
-	return defaultValue
+	return strconv.Atoi(s)
+}
+
+func parseLogFormat(format string) (LogFormat, error) {
+	switch LogFormat(format) {
+	case LogText, LogJSON:
+		return LogFormat(format), nil
+	}
+	return "", fmt.Errorf("%q must be text or json", format)
+}
+
+func parseRequestLogFormat(format string) (RequestLogFormat, error) {
+	switch RequestLogFormat(format) {
+	case RequestLogDefault, RequestLogCommon, RequestLogCombined, RequestLogJSON:
+		return RequestLogFormat(format), nil
+	}
+	return "", fmt.Errorf("%q must be default, common, combined or json", format)
 }
 
 func parseLogLevel(level string) LogLevel {
@@ -134,18 +1223,3 @@ func parseLogLevel(level string) LogLevel {
 		return LogInfo
 	}
 }
-
-func parseOutput(output string) io.Writer {
-	switch output {
-	case "stdout":
-		return os.Stdout
-	case "stderr":
-		return os.Stderr
-	default:
-		file, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			log.Fatalf("Error opening log file: %v", err)
-		}
-		return file
-	}
-}
@@ -2,14 +2,21 @@ package config
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/logger/rotate"
 )
 
 // resetConfig resets the singleton for testing
@@ -73,22 +80,32 @@ func TestConfig_DatabaseConfig_FromEnv(t *testing.T) {
 		{
 			name: "all database environment variables set",
 			envVars: map[string]string{
-				"DB_HOST":     "testhost",
-				"DB_PORT":     "5433",
-				"DB_USERNAME": "testuser",
-				"DB_PASSWORD": "testpass",
-				"DB_NAME":     "testdb",
-				"DB_SSLMODE":  "require",
-				"DB_TIMEZONE": "America/New_York",
+				"DB_HOST":                 "testhost",
+				"DB_PORT":                 "5433",
+				"DB_USERNAME":             "testuser",
+				"DB_PASSWORD":             "testpass",
+				"DB_NAME":                 "testdb",
+				"DB_SSLMODE":              "require",
+				"DB_TIMEZONE":             "America/New_York",
+				"DB_SLOW_QUERY_THRESHOLD": "1s",
+				"DB_LOG_PARAMS":           "true",
+				"DB_MAX_OPEN_CONNS":       "20",
+				"DB_MAX_IDLE_CONNS":       "10",
+				"DB_CONN_MAX_LIFETIME":    "30m",
 			},
 			expected: DatabaseConfig{
-				Host:     "testhost",
-				Port:     5433,
-				Username: "testuser",
-				Password: "testpass",
-				Name:     "testdb",
-				SSLMode:  "require",
-				TimeZone: "America/New_York",
+				Host:               "testhost",
+				Port:               5433,
+				Username:           "testuser",
+				Password:           "testpass",
+				Name:               "testdb",
+				SSLMode:            "require",
+				TimeZone:           "America/New_York",
+				SlowQueryThreshold: time.Second,
+				LogParams:          true,
+				MaxOpenConns:       20,
+				MaxIdleConns:       10,
+				ConnMaxLifetime:    30 * time.Minute,
 			},
 		},
 		{
@@ -101,13 +118,14 @@ func TestConfig_DatabaseConfig_FromEnv(t *testing.T) {
 				"DB_NAME":     "db",
 			},
 			expected: DatabaseConfig{
-				Host:     "localhost",
-				Port:     5432,
-				Username: "user",
-				Password: "pass",
-				Name:     "db",
-				SSLMode:  "disable", // default
-				TimeZone: "UTC",     // default
+				Host:               "localhost",
+				Port:               5432,
+				Username:           "user",
+				Password:           "pass",
+				Name:               "db",
+				SSLMode:            "disable",              // default
+				TimeZone:           "UTC",                  // default
+				SlowQueryThreshold: 200 * time.Millisecond, // default
 			},
 		},
 		{
@@ -120,13 +138,14 @@ func TestConfig_DatabaseConfig_FromEnv(t *testing.T) {
 				"DB_NAME":     "db",
 			},
 			expected: DatabaseConfig{
-				Host:     "localhost",
-				Port:     0, // mustAtoi returns 0 for empty string
-				Username: "user",
-				Password: "pass",
-				Name:     "db",
-				SSLMode:  "disable",
-				TimeZone: "UTC",
+				Host:               "localhost",
+				Port:               0, // mustAtoi returns 0 for empty string
+				Username:           "user",
+				Password:           "pass",
+				Name:               "db",
+				SSLMode:            "disable",
+				TimeZone:           "UTC",
+				SlowQueryThreshold: 200 * time.Millisecond,
 			},
 		},
 	}
@@ -148,6 +167,11 @@ func TestConfig_DatabaseConfig_FromEnv(t *testing.T) {
 			assert.Equal(t, tt.expected.Name, cfg.Database.Name)
 			assert.Equal(t, tt.expected.SSLMode, cfg.Database.SSLMode)
 			assert.Equal(t, tt.expected.TimeZone, cfg.Database.TimeZone)
+			assert.Equal(t, tt.expected.SlowQueryThreshold, cfg.Database.SlowQueryThreshold)
+			assert.Equal(t, tt.expected.LogParams, cfg.Database.LogParams)
+			assert.Equal(t, tt.expected.MaxOpenConns, cfg.Database.MaxOpenConns)
+			assert.Equal(t, tt.expected.MaxIdleConns, cfg.Database.MaxIdleConns)
+			assert.Equal(t, tt.expected.ConnMaxLifetime, cfg.Database.ConnMaxLifetime)
 		})
 	}
 }
@@ -216,6 +240,27 @@ func TestDatabaseConfig_DSN(t *testing.T) {
 	}
 }
 
+// TestDatabaseConfig_DSN_Drivers tests the DSNs for SQLite and MySQL
+func TestDatabaseConfig_DSN_Drivers(t *testing.T) {
+	sqlite := DatabaseConfig{Driver: DriverSQLite, Name: "data/app.db", Host: "ignored"}
+	assert.Equal(t, "data/app.db", sqlite.DSN())
+
+	mysql := DatabaseConfig{
+		Driver:   DriverMySQL,
+		Host:     "db.example.com",
+		Port:     3306,
+		Username: "app",
+		Password: "secret",
+		Name:     "app",
+		SSLMode:  "disable",
+		TimeZone: "America/New_York",
+	}
+	assert.Equal(t, "app:secret@tcp(db.example.com:3306)/app?charset=utf8mb4&loc=America%2FNew_York&parseTime=true", mysql.DSN())
+
+	mysql.SSLMode = "require"
+	assert.Contains(t, mysql.DSN(), "&tls=true")
+}
+
 // TestConfig_LoggerConfig_FromEnv tests logger configuration from environment variables
 func TestConfig_LoggerConfig_FromEnv(t *testing.T) {
 	tests := []struct {
@@ -470,12 +515,14 @@ func TestParseLogLevel(t *testing.T) {
 // TestParseOutput tests the parseOutput function
 func TestParseOutput(t *testing.T) {
 	t.Run("stdout", func(t *testing.T) {
-		output := parseOutput("stdout")
+		output, err := parseOutput("stdout")
+		require.NoError(t, err)
 		assert.Equal(t, os.Stdout, output)
 	})
 
 	t.Run("stderr", func(t *testing.T) {
-		output := parseOutput("stderr")
+		output, err := parseOutput("stderr")
+		require.NoError(t, err)
 		assert.Equal(t, os.Stderr, output)
 	})
 
@@ -483,7 +530,8 @@ func TestParseOutput(t *testing.T) {
 		tempDir := t.TempDir()
 		logFile := filepath.Join(tempDir, "test.log")
 
-		output := parseOutput(logFile)
+		output, err := parseOutput(logFile)
+		require.NoError(t, err)
 		assert.NotNil(t, output)
 
 		// Verify we can write to it
@@ -491,7 +539,7 @@ func TestParseOutput(t *testing.T) {
 		require.True(t, ok, "Output should be a file")
 		defer file.Close()
 
-		_, err := file.WriteString("test\n")
+		_, err = file.WriteString("test\n")
 		assert.NoError(t, err)
 
 		// Verify content was written
@@ -509,7 +557,8 @@ func TestParseOutput(t *testing.T) {
 		require.NoError(t, err)
 
 		// Open with parseOutput (should append)
-		output := parseOutput(logFile)
+		output, err := parseOutput(logFile)
+		require.NoError(t, err)
 		file, ok := output.(*os.File)
 		require.True(t, ok)
 		defer file.Close()
@@ -523,9 +572,14 @@ func TestParseOutput(t *testing.T) {
 		assert.Contains(t, string(content), "initial\n")
 		assert.Contains(t, string(content), "appended\n")
 	})
+
+	t.Run("unopenable file", func(t *testing.T) {
+		_, err := parseOutput(filepath.Join(t.TempDir(), "missing", "test.log"))
+		assert.Error(t, err)
+	})
 }
 
-// TestGetEnvOrDefault tests the getEnvOrDefault helper function
+// TestGetEnvOrDefault tests the getEnvOrDefault helper method
 func TestGetEnvOrDefault(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -559,21 +613,23 @@ func TestGetEnvOrDefault(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			vars := map[string]string{}
 			if tt.envValue != "" {
-				os.Setenv(tt.key, tt.envValue)
-				defer os.Unsetenv(tt.key)
-			} else {
-				os.Unsetenv(tt.key)
+				vars[tt.key] = tt.envValue
 			}
+			src := &source{lookup: func(key string) (string, bool) {
+				value, ok := vars[key]
+				return value, ok
+			}}
 
-			result := getEnvOrDefault(tt.key, tt.defaultValue)
+			result := src.getEnvOrDefault(tt.key, tt.defaultValue)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
-// TestMustAtoi tests the mustAtoi helper function
-func TestMustAtoi(t *testing.T) {
+// TestAtoi tests the atoi helper function
+func TestAtoi(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
@@ -603,10 +659,14 @@ func TestMustAtoi(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := mustAtoi(tt.input)
+			result, err := atoi(tt.input)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
+
+	_, err := atoi("5432a")
+	assert.Error(t, err)
 }
 
 // TestConfig_Integration tests a complete configuration scenario
@@ -753,3 +813,1100 @@ func TestConfig_MultipleWriters(t *testing.T) {
 	assert.Equal(t, message, buf1.String())
 	assert.Equal(t, message, buf2.String())
 }
+
+// TestEnvVars_MatchLoad tests that EnvVars lists every variable Load reads,
+// with the same defaults
+func TestEnvVars_MatchLoad(t *testing.T) {
+	source, err := os.ReadFile("config.go")
+	require.NoError(t, err)
+
+	read := map[string]string{}
+	for _, m := range regexp.MustCompile(`\.getenv\("(\w+)"\)`).FindAllStringSubmatch(string(source), -1) {
+		read[m[1]] = ""
+	}
+	for _, m := range regexp.MustCompile(`getEnvOrDefault\("(\w+)", "([\w-]*)"\)`).FindAllStringSubmatch(string(source), -1) {
+		read[m[1]] = m[2]
+	}
+	// parseLogLevel falls back to info
+	read["LOGGER_LEVEL"] = "info"
+
+	listed := map[string]string{}
+	for _, v := range EnvVars {
+		listed[v.Name] = v.Default
+	}
+
+	assert.Equal(t, read, listed)
+}
+
+// chdirTemp changes to a new temporary directory for the rest of the test
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(originalDir) })
+	return dir
+}
+
+const testConfigFile = `server:
+  port: 4000
+  trusted_proxies: [10.0.0.0/8]
+routes:
+  root: /app
+templates:
+  patterns:
+    - views/*.html
+meta:
+  site_name: Example
+sitemap:
+  base_url: https://example.com
+  exclude: [/login]
+environments:
+  test:
+    server:
+      port: 4001
+    robots:
+      disallow: [/]
+  production:
+    server:
+      port: "8080"
+      trusted_proxies:
+        - 192.168.1.10
+    static:
+      dirs: [dist, public]
+`
+
+// TestConfig_Defaults tests the settings used without twine.yaml
+func TestConfig_Defaults(t *testing.T) {
+	chdirTemp(t)
+	t.Setenv("TWINE_ENV", "")
+	t.Setenv("PORT", "")
+	t.Setenv("TRUSTED_PROXIES", "")
+	t.Setenv("TEMPLATES_RELOAD", "")
+	resetConfig()
+	defer resetConfig()
+
+	cfg := Get()
+
+	assert.Equal(t, "production", cfg.Env, "production unless TWINE_ENV says otherwise")
+	assert.Equal(t, "3000", cfg.Server.Port)
+	assert.Equal(t, ":3000", cfg.Server.Addr())
+	assert.Empty(t, cfg.Server.TrustedProxies)
+	assert.Equal(t, "/", cfg.Routes.Root)
+	assert.Equal(t, []string{"templates/**/*.html"}, cfg.Templates.Patterns)
+	assert.False(t, cfg.Templates.Reload, "templates don't reload in production")
+	assert.Equal(t, []string{"public"}, cfg.Static.Dirs)
+	assert.Empty(t, cfg.Sitemap.BaseURL)
+	assert.Empty(t, cfg.Robots.Disallow)
+	assert.Equal(t, DefaultReadTimeout, cfg.Server.ReadTimeout)
+	assert.Equal(t, DefaultWriteTimeout, cfg.Server.WriteTimeout)
+	assert.Equal(t, DefaultIdleTimeout, cfg.Server.IdleTimeout)
+	assert.Equal(t, DefaultMaxHeaderBytes, cfg.Server.MaxHeaderBytes)
+	assert.Equal(t, DefaultShutdownTimeout, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "/healthz", cfg.Server.HealthPath)
+	assert.Equal(t, "/readyz", cfg.Server.ReadyPath)
+	assert.True(t, cfg.Server.Compress, "compression in production")
+	assert.Equal(t, LogText, cfg.Logger.Format)
+}
+
+// TestConfig_Environment tests the defaults each environment switches
+func TestConfig_Environment(t *testing.T) {
+	tests := []struct {
+		env          string
+		dev, prod    bool
+		reload, gzip bool
+	}{
+		{EnvDevelopment, true, false, true, false},
+		{EnvTest, false, false, false, false},
+		{EnvProduction, false, true, false, true},
+		{"staging", false, true, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			cfg, err := Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{"TWINE_ENV": tt.env}))
+			require.NoError(t, err)
+			assert.Equal(t, tt.dev, cfg.IsDev())
+			assert.Equal(t, tt.prod, cfg.IsProd())
+			assert.Equal(t, tt.reload, cfg.Templates.Reload)
+			assert.Equal(t, tt.gzip, cfg.Server.Compress)
+		})
+	}
+
+	cfg, err := Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{"TWINE_ENV": EnvProduction, "SERVER_COMPRESS": "false"}))
+	require.NoError(t, err)
+	assert.False(t, cfg.Server.Compress, "SERVER_COMPRESS overrides the environment")
+}
+
+// TestConfig_File tests merging twine.yaml's environment sections and
+// environment variables
+func TestConfig_File(t *testing.T) {
+	dir := chdirTemp(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, File), []byte(testConfigFile), 0644))
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		port    string
+		proxies []string
+		static  []string
+		reload  bool
+	}{
+		{
+			name:    "development uses the top-level settings",
+			env:     map[string]string{"TWINE_ENV": "development", "PORT": "", "TRUSTED_PROXIES": "", "TEMPLATES_RELOAD": ""},
+			port:    "4000",
+			proxies: []string{"10.0.0.0/8"},
+			static:  []string{"public"},
+			reload:  true,
+		},
+		{
+			name:    "environment section overrides the top level",
+			env:     map[string]string{"TWINE_ENV": "test", "PORT": "", "TRUSTED_PROXIES": "", "TEMPLATES_RELOAD": ""},
+			port:    "4001",
+			proxies: []string{"10.0.0.0/8"},
+			static:  []string{"public"},
+		},
+		{
+			name:    "lists are replaced, not appended",
+			env:     map[string]string{"TWINE_ENV": "production", "PORT": "", "TRUSTED_PROXIES": "", "TEMPLATES_RELOAD": ""},
+			port:    "8080",
+			proxies: []string{"192.168.1.10"},
+			static:  []string{"dist", "public"},
+		},
+		{
+			name:    "environment variables override the file",
+			env:     map[string]string{"TWINE_ENV": "production", "PORT": "9000", "TRUSTED_PROXIES": "127.0.0.1, ::1", "TEMPLATES_RELOAD": "true"},
+			port:    "9000",
+			proxies: []string{"127.0.0.1", "::1"},
+			static:  []string{"dist", "public"},
+			reload:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			resetConfig()
+			defer resetConfig()
+
+			cfg := Get()
+
+			assert.Equal(t, tt.port, cfg.Server.Port)
+			assert.Equal(t, tt.proxies, cfg.Server.TrustedProxies)
+			assert.Equal(t, tt.static, cfg.Static.Dirs)
+			assert.Equal(t, "/app", cfg.Routes.Root)
+			assert.Equal(t, []string{"views/*.html"}, cfg.Templates.Patterns)
+			assert.Equal(t, tt.reload, cfg.Templates.Reload)
+			assert.Equal(t, "Example", cfg.Meta.SiteName)
+			assert.Equal(t, "https://example.com", cfg.Sitemap.BaseURL)
+			assert.Equal(t, []string{"/login"}, cfg.Sitemap.Exclude)
+			if cfg.Env == "test" {
+				assert.Equal(t, []string{"/"}, cfg.Robots.Disallow)
+			} else {
+				assert.Empty(t, cfg.Robots.Disallow)
+			}
+		})
+	}
+}
+
+// TestLoadFile_Errors tests invalid twine.yaml files
+func TestLoadFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, File)
+
+	require.NoError(t, loadFile(&Config{}, path, "development"))
+
+	require.NoError(t, os.WriteFile(path, []byte("server: [\n"), 0644))
+	assert.Error(t, loadFile(&Config{}, path, "development"))
+
+	require.NoError(t, os.WriteFile(path, []byte("environments:\n  test:\n    server: [1]\n"), 0644))
+	err := loadFile(&Config{}, path, "test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "environments.test")
+}
+
+// TestServerConfig_IsTrustedProxy tests matching IPs and CIDR ranges
+func TestServerConfig_IsTrustedProxy(t *testing.T) {
+	server := ServerConfig{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.10", "::1"}}
+
+	assert.True(t, server.IsTrustedProxy("10.1.2.3"))
+	assert.True(t, server.IsTrustedProxy("10.1.2.3:54321"))
+	assert.True(t, server.IsTrustedProxy("192.168.1.10"))
+	assert.True(t, server.IsTrustedProxy("[::1]:80"))
+	assert.False(t, server.IsTrustedProxy("192.168.1.11"))
+	assert.False(t, server.IsTrustedProxy("not-an-ip"))
+}
+
+// TestValidateRanges tests rejecting malformed trusted proxies and IP filters
+func TestValidateRanges(t *testing.T) {
+	assert.NoError(t, validateRanges([]string{"10.0.0.0/8", "127.0.0.1", "::1"}))
+	assert.Error(t, validateRanges([]string{"10.0.0.0/33"}))
+	assert.Error(t, validateRanges([]string{"proxy.internal"}))
+}
+
+// resetFeatures forgets required features for the rest of the test
+func resetFeatures(t *testing.T) {
+	t.Helper()
+	featuresMutex.Lock()
+	saved := features
+	features = map[Feature]bool{}
+	featuresMutex.Unlock()
+
+	t.Cleanup(func() {
+		featuresMutex.Lock()
+		features = saved
+		featuresMutex.Unlock()
+	})
+}
+
+// validConfig returns a config that passes Validate with every feature
+func validConfig() *Config {
+	return &Config{
+		Server: ServerConfig{Port: "3000"},
+		Auth:   AuthConfig{SecretKey: "0123456789abcdef0123456789abcdef"},
+		Database: DatabaseConfig{
+			Host:     "localhost",
+			Port:     5432,
+			Username: "postgres",
+			Name:     "app",
+			SSLMode:  "disable",
+		},
+	}
+}
+
+// TestConfig_Validate tests the checks for each setting
+func TestConfig_Validate(t *testing.T) {
+	resetFeatures(t)
+	Require(FeatureAuth)
+	Require(FeatureDatabase)
+
+	tests := []struct {
+		name     string
+		modify   func(*Config)
+		problems []string
+	}{
+		{"valid", func(*Config) {}, nil},
+		{"port not a number", func(c *Config) { c.Server.Port = "http" }, []string{"PORT"}},
+		{"port out of range", func(c *Config) { c.Server.Port = "70000" }, []string{"PORT"}},
+		{"short secret", func(c *Config) { c.Auth.SecretKey = "secret" }, []string{"AUTH_SECRET"}},
+		{"missing secret", func(c *Config) { c.Auth.SecretKey = "" }, []string{"AUTH_SECRET"}},
+		{"bad sslmode", func(c *Config) { c.Database.SSLMode = "on" }, []string{"DB_SSLMODE"}},
+		{"db port out of range", func(c *Config) { c.Database.Port = 99999 }, []string{"DB_PORT"}},
+		{"negative timeout", func(c *Config) { c.Server.IdleTimeout = -time.Second }, []string{"SERVER_IDLE_TIMEOUT"}},
+		{"negative max header bytes", func(c *Config) { c.Server.MaxHeaderBytes = -1 }, []string{"SERVER_MAX_HEADER_BYTES"}},
+		{"negative shutdown timeout", func(c *Config) { c.Server.ShutdownTimeout = -time.Second }, []string{"SERVER_SHUTDOWN_TIMEOUT"}},
+		{"negative pool size", func(c *Config) { c.Database.MaxOpenConns = -1 }, []string{"DB_MAX_OPEN_CONNS"}},
+		{"negative connection lifetime", func(c *Config) { c.Database.ConnMaxLifetime = -time.Minute }, []string{"DB_CONN_MAX_LIFETIME"}},
+		{"unknown driver", func(c *Config) { c.Database.Driver = "oracle" }, []string{"DB_DRIVER"}},
+		{"sqlite needs only a file", func(c *Config) { c.Database = DatabaseConfig{Driver: DriverSQLite, Name: "app.db"} }, nil},
+		{"sqlite without a file", func(c *Config) { c.Database = DatabaseConfig{Driver: DriverSQLite} }, []string{"DB_NAME"}},
+		{"unknown cache driver", func(c *Config) { c.Cache.Driver = "memcached" }, []string{"CACHE_DRIVER"}},
+		{"redis without a url", func(c *Config) { c.Cache.Driver = CacheRedis }, []string{"CACHE_REDIS_URL"}},
+		{"redis with a bad url", func(c *Config) { c.Cache = CacheConfig{Driver: CacheRedis, RedisURL: "localhost:6379"} }, []string{"CACHE_REDIS_URL"}},
+		{"redis", func(c *Config) { c.Cache = CacheConfig{Driver: CacheRedis, RedisURL: "redis://localhost:6379/0"} }, nil},
+		{"negative cache size", func(c *Config) { c.Cache.MaxEntries = -1 }, []string{"CACHE_MAX_ENTRIES"}},
+		{"unknown jobs driver", func(c *Config) { c.Jobs.Driver = "sqs" }, []string{"JOBS_DRIVER"}},
+		{"jobs on redis without a url", func(c *Config) { c.Jobs.Driver = JobsRedis }, []string{"JOBS_REDIS_URL"}},
+		{"negative concurrency", func(c *Config) { c.Jobs.Concurrency = -2 }, []string{"JOBS_CONCURRENCY"}},
+		{"negative poll interval", func(c *Config) { c.Jobs.PollInterval = -time.Second }, []string{"JOBS_POLL_INTERVAL"}},
+		{"unknown schedule locker", func(c *Config) { c.Schedule.Locker = "etcd" }, []string{"SCHEDULE_LOCKER"}},
+		{"schedule on redis with a bad url", func(c *Config) { c.Schedule = ScheduleConfig{Locker: ScheduleRedis, RedisURL: "tcp://localhost"} }, []string{"SCHEDULE_REDIS_URL"}},
+		{"schedule in memory", func(c *Config) { c.Schedule.Locker = ScheduleMemory }, nil},
+		{"unknown storage driver", func(c *Config) { c.Storage.Driver = "ftp" }, []string{"STORAGE_DRIVER"}},
+		{"storage url without a scheme", func(c *Config) { c.Storage.URL = "files" }, []string{"STORAGE_URL"}},
+		{"storage url", func(c *Config) { c.Storage.URL = "https://cdn.example.com/files" }, nil},
+		{"s3 without settings", func(c *Config) { c.Storage.Driver = StorageS3 }, []string{"STORAGE_S3_BUCKET", "STORAGE_S3_ACCESS_KEY"}},
+		{"s3 with a bad endpoint", func(c *Config) {
+			c.Storage = StorageConfig{Driver: StorageS3, S3Bucket: "uploads", S3AccessKey: "key", S3SecretKey: "secret", S3Endpoint: "localhost:9000"}
+		}, []string{"STORAGE_S3_ENDPOINT"}},
+		{"s3", func(c *Config) {
+			c.Storage = StorageConfig{Driver: StorageS3, S3Bucket: "uploads", S3AccessKey: "key", S3SecretKey: "secret", S3Endpoint: "http://localhost:9000"}
+		}, nil},
+		{"unknown auth algorithm", func(c *Config) { c.Auth.Algorithm = "ES256" }, []string{"AUTH_ALGORITHM"}},
+		{"unknown password hasher", func(c *Config) { c.Auth.PasswordHasher = "scrypt" }, []string{"AUTH_PASSWORD_HASHER"}},
+		{"negative argon2 time", func(c *Config) { c.Auth.Argon2Time = -1 }, []string{"AUTH_ARGON2_TIME"}},
+		{"argon2 memory below 8 KiB per thread", func(c *Config) {
+			c.Auth.Argon2Memory, c.Auth.Argon2Threads = 16, 4
+		}, []string{"AUTH_ARGON2_MEMORY"}},
+		{"bcrypt", func(c *Config) { c.Auth.PasswordHasher = PasswordBcrypt }, nil},
+		{"oauth client without a secret", func(c *Config) { c.Auth.OAuth.GitHubClientID = "gh-client" }, []string{"AUTH_GITHUB_CLIENT_SECRET"}},
+		{"oidc client without an issuer", func(c *Config) {
+			c.Auth.OAuth = OAuthConfig{OIDCClientID: "client", OIDCClientSecret: "secret"}
+		}, []string{"AUTH_OIDC_ISSUER"}},
+		{"oidc issuer without a scheme", func(c *Config) {
+			c.Auth.OAuth = OAuthConfig{OIDCIssuer: "accounts.example.com", OIDCClientID: "client", OIDCClientSecret: "secret"}
+		}, []string{"AUTH_OIDC_ISSUER"}},
+		{"oauth clients", func(c *Config) {
+			c.Auth.OAuth = OAuthConfig{
+				GoogleClientID: "google", GoogleClientSecret: "secret",
+				OIDCIssuer: "https://accounts.example.com", OIDCClientID: "client", OIDCClientSecret: "secret",
+			}
+		}, nil},
+		{"rs256 without a private key", func(c *Config) { c.Auth.Algorithm = AuthRS256 }, []string{"AUTH_PRIVATE_KEY"}},
+		{"eddsa without a secret", func(c *Config) {
+			c.Auth = AuthConfig{Algorithm: AuthEdDSA, PrivateKey: "keys/jwt.pem"}
+		}, nil},
+		{"negative access ttl", func(c *Config) { c.Auth.AccessTTL = -time.Minute }, []string{"AUTH_ACCESS_TTL"}},
+		{"negative session ttl", func(c *Config) { c.Auth.SessionTTL = -time.Hour }, []string{"AUTH_SESSION_TTL"}},
+		{"unknown realtime driver", func(c *Config) { c.Realtime.Driver = "nats" }, []string{"REALTIME_DRIVER"}},
+		{"realtime on redis without a url", func(c *Config) { c.Realtime.Driver = RealtimeRedis }, []string{"REALTIME_REDIS_URL"}},
+		{"negative heartbeat", func(c *Config) { c.Realtime.Heartbeat = -time.Second }, []string{"REALTIME_HEARTBEAT"}},
+		{"tls certificate", func(c *Config) { c.TLS = TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", RedirectPort: "80"} }, nil},
+		{"tls certificate without a key", func(c *Config) { c.TLS.CertFile = "cert.pem" }, []string{"TLS_CERT_FILE"}},
+		{"autocert", func(c *Config) {
+			c.TLS = TLSConfig{AutocertDomains: []string{"example.com"}, AutocertCacheDir: "certs", RedirectPort: "80"}
+		}, nil},
+		{"autocert with a certificate", func(c *Config) {
+			c.TLS = TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", AutocertDomains: []string{"example.com"}, AutocertCacheDir: "certs"}
+		}, []string{"TLS_AUTOCERT_DOMAINS"}},
+		{"autocert without a cache", func(c *Config) {
+			c.TLS = TLSConfig{AutocertDomains: []string{"example.com"}, AutocertDirectoryURL: "acme.test/directory"}
+		}, []string{"TLS_AUTOCERT_CACHE_DIR", "TLS_AUTOCERT_DIRECTORY_URL"}},
+		{"invalid redirect port", func(c *Config) { c.TLS.RedirectPort = "http" }, []string{"TLS_REDIRECT_PORT"}},
+		{"negative request sampling", func(c *Config) { c.Logger.RequestSampleThereafter = -1 }, []string{"LOGGER_REQUEST_SAMPLE_THEREAFTER"}},
+		{"debug on a local address", func(c *Config) { c.Debug = DebugConfig{Enabled: true, Addr: "localhost:6060"} }, nil},
+		{"debug with a token", func(c *Config) {
+			c.Debug = DebugConfig{Enabled: true, Token: strings.Repeat("t", MinSecretLength), Addr: ":6060"}
+		}, nil},
+		{"debug on a public address without a token", func(c *Config) { c.Debug = DebugConfig{Enabled: true, Addr: ":6060"} }, []string{"DEBUG_ADDR"}},
+		{"debug with a short token", func(c *Config) { c.Debug = DebugConfig{Enabled: true, Token: "short"} }, []string{"DEBUG_TOKEN"}},
+		{"invalid debug address", func(c *Config) { c.Debug = DebugConfig{Enabled: true, Addr: "6060"} }, []string{"DEBUG_ADDR"}},
+		{"debug settings while disabled", func(c *Config) { c.Debug = DebugConfig{Token: "short", Addr: ":6060"} }, nil},
+		{
+			name: "every problem at once",
+			modify: func(c *Config) {
+				c.Server.Port = "0"
+				c.Database = DatabaseConfig{SSLMode: "disable"}
+			},
+			problems: []string{"PORT", "DB_HOST", "DB_USERNAME", "DB_NAME", "DB_PORT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			vars := []string{}
+			for _, p := range validationErr {
+				vars = append(vars, p.Var)
+			}
+			assert.Equal(t, tt.problems, vars)
+		})
+	}
+}
+
+// TestConfig_Validate_Features tests that unused features aren't checked
+func TestConfig_Validate_Features(t *testing.T) {
+	resetFeatures(t)
+	cfg := &Config{Server: ServerConfig{Port: "3000"}}
+	assert.NoError(t, cfg.Validate())
+
+	// A secret that is set is always checked
+	cfg.Auth.SecretKey = "short"
+	assert.Error(t, cfg.Validate())
+	cfg.Auth.SecretKey = ""
+
+	Require(FeatureDatabase)
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_HOST: is required")
+	assert.NotContains(t, err.Error(), "AUTH_SECRET")
+}
+
+// TestValidationError_Error tests listing every problem with its variable
+func TestValidationError_Error(t *testing.T) {
+	err := ValidationError{
+		{Var: "PORT", Message: "must be a number"},
+		{Var: "AUTH_SECRET", Message: "is required"},
+	}
+	assert.Equal(t, "invalid configuration:\n  PORT: must be a number\n  AUTH_SECRET: is required", err.Error())
+}
+
+// TestLoad tests loading independent configurations without the singleton
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "tenant.env")
+	require.NoError(t, os.WriteFile(envFile, []byte("DB_HOST=envhost\nDB_NAME=envdb\n"), 0644))
+	configFile := filepath.Join(dir, "tenant.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(testConfigFile), 0644))
+
+	first, err := Load(
+		WithVars(map[string]string{"DB_HOST": "tenant1", "DB_PORT": "5433", "AUTH_SECRET": "one", "TWINE_ENV": "development"}),
+		WithEnvFiles(envFile),
+		WithFile(configFile),
+	)
+	require.NoError(t, err)
+
+	second, err := Load(
+		WithVars(map[string]string{"DB_HOST": "tenant2", "TWINE_ENV": "test"}),
+		WithEnvFiles(),
+		WithFile(configFile),
+		WithEnv("production"),
+	)
+	require.NoError(t, err)
+
+	// Variables take precedence over dotenv files
+	assert.Equal(t, "tenant1", first.Database.Host)
+	assert.Equal(t, "envdb", first.Database.Name)
+	assert.Equal(t, 5433, first.Database.Port)
+	assert.Equal(t, "one", first.Auth.SecretKey)
+	assert.Equal(t, "development", first.Env)
+	assert.Equal(t, "4000", first.Server.Port)
+
+	// WithEnv overrides TWINE_ENV
+	assert.Equal(t, "tenant2", second.Database.Host)
+	assert.Empty(t, second.Database.Name)
+	assert.Equal(t, "production", second.Env)
+	assert.Equal(t, "8080", second.Server.Port)
+	assert.Empty(t, second.Auth.SecretKey)
+
+	assert.NotSame(t, first, second)
+}
+
+// TestLoad_Defaults tests loading without any files or variables
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load(WithVars(nil), WithEnvFiles(), WithFile(""))
+	require.NoError(t, err)
+
+	assert.Equal(t, "disable", cfg.Database.SSLMode)
+	assert.Equal(t, "UTC", cfg.Database.TimeZone)
+	assert.Equal(t, CacheMemory, cfg.Cache.Driver)
+	assert.Equal(t, JobsDatabase, cfg.Jobs.Driver)
+	assert.Equal(t, StorageLocal, cfg.Storage.Driver)
+	assert.Equal(t, "storage", cfg.Storage.Root)
+	assert.Equal(t, "us-east-1", cfg.Storage.S3Region)
+	assert.Equal(t, ScheduleDatabase, cfg.Schedule.Locker)
+	assert.Equal(t, LogInfo, cfg.Logger.Level)
+	assert.Equal(t, os.Stdout, cfg.Logger.Output)
+	assert.True(t, cfg.Logger.StackTraces)
+	assert.Equal(t, "3000", cfg.Server.Port)
+	assert.False(t, cfg.TLS.Enabled())
+	assert.Equal(t, "certs", cfg.TLS.AutocertCacheDir)
+	assert.Empty(t, cfg.TLS.RedirectPort)
+	assert.Equal(t, DebugConfig{}, cfg.Debug)
+	assert.Equal(t, RequestLogDefault, cfg.Logger.RequestFormat)
+	assert.Equal(t, DefaultRequestLogFields, cfg.Logger.RequestFields)
+	assert.Zero(t, cfg.Logger.RequestSampleFirst)
+}
+
+// TestLoad_TLS tests reading the TLS settings
+func TestLoad_TLS(t *testing.T) {
+	cfg, err := Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{
+		"TLS_AUTOCERT_DOMAINS": "example.com, www.example.com",
+		"TLS_AUTOCERT_EMAIL":   "ops@example.com",
+	}))
+	require.NoError(t, err)
+
+	assert.True(t, cfg.TLS.Enabled())
+	assert.Equal(t, []string{"example.com", "www.example.com"}, cfg.TLS.AutocertDomains)
+	assert.Equal(t, "ops@example.com", cfg.TLS.AutocertEmail)
+	assert.Equal(t, "80", cfg.TLS.RedirectPort, "autocert answers challenges on port 80")
+
+	cfg, err = Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{
+		"TLS_CERT_FILE":     "cert.pem",
+		"TLS_KEY_FILE":      "key.pem",
+		"TLS_REDIRECT_PORT": "8080",
+	}))
+	require.NoError(t, err)
+
+	assert.True(t, cfg.TLS.Enabled())
+	assert.Equal(t, "cert.pem", cfg.TLS.CertFile)
+	assert.Equal(t, "key.pem", cfg.TLS.KeyFile)
+	assert.Equal(t, "8080", cfg.TLS.RedirectPort)
+}
+
+// TestLoad_RequestLog tests reading the request logging settings
+func TestLoad_RequestLog(t *testing.T) {
+	cfg, err := Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{
+		"LOGGER_REQUEST_FORMAT":            "combined",
+		"LOGGER_REQUEST_FIELDS":            "latency, remote_ip",
+		"LOGGER_REQUEST_SAMPLE_FIRST":      "100",
+		"LOGGER_REQUEST_SAMPLE_THEREAFTER": "10",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, RequestLogCombined, cfg.Logger.RequestFormat)
+	assert.Equal(t, []string{"latency", "remote_ip"}, cfg.Logger.RequestFields)
+	assert.Equal(t, 100, cfg.Logger.RequestSampleFirst)
+	assert.Equal(t, 10, cfg.Logger.RequestSampleThereafter)
+}
+
+// TestLoad_Debug tests reading the debug endpoint settings
+func TestLoad_Debug(t *testing.T) {
+	cfg, err := Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{"DEBUG_ENABLED": "true"}))
+	require.NoError(t, err)
+	assert.True(t, cfg.Debug.Enabled)
+	assert.Equal(t, DefaultDebugAddr, cfg.Debug.Addr, "without a token, only a local listener")
+
+	token := strings.Repeat("t", MinSecretLength)
+	cfg, err = Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{"DEBUG_ENABLED": "true", "DEBUG_TOKEN": token}))
+	require.NoError(t, err)
+	assert.Equal(t, token, cfg.Debug.Token)
+	assert.Empty(t, cfg.Debug.Addr)
+}
+
+// TestLoad_Errors tests that Load returns errors instead of exiting
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		errorMsg string
+	}{
+		{
+			name:     "invalid DB_PORT",
+			opts:     []Option{WithVars(map[string]string{"DB_PORT": "postgres"})},
+			errorMsg: "DB_PORT",
+		},
+		{
+			name:     "invalid slow query threshold",
+			opts:     []Option{WithVars(map[string]string{"DB_SLOW_QUERY_THRESHOLD": "200"})},
+			errorMsg: "DB_SLOW_QUERY_THRESHOLD",
+		},
+		{
+			name:     "invalid log params flag",
+			opts:     []Option{WithVars(map[string]string{"DB_LOG_PARAMS": "maybe"})},
+			errorMsg: "DB_LOG_PARAMS",
+		},
+		{
+			name:     "invalid pool size",
+			opts:     []Option{WithVars(map[string]string{"DB_MAX_OPEN_CONNS": "many"})},
+			errorMsg: "DB_MAX_OPEN_CONNS",
+		},
+		{
+			name:     "invalid connection lifetime",
+			opts:     []Option{WithVars(map[string]string{"DB_CONN_MAX_LIFETIME": "30"})},
+			errorMsg: "DB_CONN_MAX_LIFETIME",
+		},
+		{
+			name:     "unopenable log file",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_OUTPUT": filepath.Join(dir, "missing", "app.log")})},
+			errorMsg: "LOGGER_OUTPUT",
+		},
+		{
+			name:     "invalid trusted proxy",
+			opts:     []Option{WithVars(map[string]string{"TRUSTED_PROXIES": "proxy.internal"})},
+			errorMsg: "trusted proxies",
+		},
+		{
+			name:     "invalid IP filter",
+			opts:     []Option{WithVars(map[string]string{"SERVER_IP_DENY": "10.0.0.0/8, office"})},
+			errorMsg: "SERVER_IP_DENY",
+		},
+		{
+			name:     "invalid rotation size",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_ROTATE_SIZE": "big"})},
+			errorMsg: "LOGGER_ROTATE_SIZE",
+		},
+		{
+			name:     "invalid compress flag",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_COMPRESS": "gzip"})},
+			errorMsg: "LOGGER_COMPRESS",
+		},
+		{
+			name:     "no outputs",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_OUTPUT": " , "})},
+			errorMsg: "LOGGER_OUTPUT",
+		},
+		{
+			name:     "invalid log format",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_FORMAT": "logfmt"})},
+			errorMsg: "LOGGER_FORMAT",
+		},
+		{
+			name:     "invalid stack traces flag",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_STACK_TRACES": "sometimes"})},
+			errorMsg: "LOGGER_STACK_TRACES",
+		},
+		{
+			name:     "invalid server timeout",
+			opts:     []Option{WithVars(map[string]string{"SERVER_WRITE_TIMEOUT": "30"})},
+			errorMsg: "SERVER_WRITE_TIMEOUT",
+		},
+		{
+			name:     "invalid max header bytes",
+			opts:     []Option{WithVars(map[string]string{"SERVER_MAX_HEADER_BYTES": "1MB"})},
+			errorMsg: "SERVER_MAX_HEADER_BYTES",
+		},
+		{
+			name:     "invalid shutdown timeout",
+			opts:     []Option{WithVars(map[string]string{"SERVER_SHUTDOWN_TIMEOUT": "soon"})},
+			errorMsg: "SERVER_SHUTDOWN_TIMEOUT",
+		},
+		{
+			name:     "invalid compression flag",
+			opts:     []Option{WithVars(map[string]string{"SERVER_COMPRESS": "gzip"})},
+			errorMsg: "SERVER_COMPRESS",
+		},
+		{
+			name:     "invalid request log format",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_REQUEST_FORMAT": "apache"})},
+			errorMsg: "LOGGER_REQUEST_FORMAT",
+		},
+		{
+			name:     "unknown request log field",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_REQUEST_FIELDS": "latency,cookies"})},
+			errorMsg: `LOGGER_REQUEST_FIELDS: "cookies"`,
+		},
+		{
+			name:     "invalid request sampling",
+			opts:     []Option{WithVars(map[string]string{"LOGGER_REQUEST_SAMPLE_FIRST": "all"})},
+			errorMsg: "LOGGER_REQUEST_SAMPLE_FIRST",
+		},
+		{
+			name:     "invalid debug flag",
+			opts:     []Option{WithVars(map[string]string{"DEBUG_ENABLED": "on"})},
+			errorMsg: "DEBUG_ENABLED",
+		},
+		{
+			name:     "unreadable env file",
+			opts:     []Option{WithVars(nil), WithEnvFiles(dir)},
+			errorMsg: "reading " + dir,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithEnvFiles(), WithFile("")}, tt.opts...)
+			_, err := Load(opts...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+// TestLoad_Secrets tests resolving secrets through providers in order
+func TestLoad_Secrets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("from-file\n"), 0600))
+
+	store := SecretsFunc(func(_ context.Context, name string) (string, bool, error) {
+		if name == "AUTH_SECRET" {
+			return "from-store", true, nil
+		}
+		return "", false, nil
+	})
+
+	cfg, err := Load(
+		WithVars(map[string]string{"AUTH_SECRET": "from-env", "DB_PASSWORD": "from-env"}),
+		WithEnvFiles(),
+		WithFile(""),
+		WithSecrets(FileSecrets{Dir: dir}, store),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "from-store", cfg.Auth.SecretKey)
+	assert.Equal(t, "from-file", cfg.Database.Password)
+
+	// Without a provider that has the secret, the variable is kept
+	cfg, err = Load(
+		WithVars(map[string]string{"AUTH_SECRET": "from-env"}),
+		WithEnvFiles(),
+		WithFile(""),
+		WithSecrets(FileSecrets{Dir: dir}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.Auth.SecretKey)
+
+	failing := SecretsFunc(func(context.Context, string) (string, bool, error) {
+		return "", false, errors.New("vault sealed")
+	})
+	_, err = Load(WithVars(nil), WithEnvFiles(), WithFile(""), WithSecrets(failing))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_PASSWORD: resolving secret: vault sealed")
+}
+
+// TestFileSecrets tests reading Docker-style secret files
+func TestFileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "AUTH_SECRET"), []byte("upper\r\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("lower"), 0600))
+	explicit := filepath.Join(dir, "explicit")
+	require.NoError(t, os.WriteFile(explicit, []byte("explicit\n"), 0600))
+
+	secrets := FileSecrets{Dir: dir}
+	ctx := context.Background()
+
+	value, ok, err := secrets.Secret(ctx, "AUTH_SECRET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "upper", value)
+
+	value, ok, err = secrets.Secret(ctx, "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "lower", value)
+
+	_, ok, err = secrets.Secret(ctx, "MISSING")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// <name>_FILE takes precedence, and must exist
+	t.Setenv("AUTH_SECRET_FILE", explicit)
+	value, ok, err = secrets.Secret(ctx, "AUTH_SECRET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "explicit", value)
+
+	t.Setenv("AUTH_SECRET_FILE", filepath.Join(dir, "missing"))
+	_, _, err = FileSecrets{}.Secret(ctx, "AUTH_SECRET")
+	assert.Error(t, err)
+}
+
+// TestEnvSecrets tests reading secrets from the process environment
+func TestEnvSecrets(t *testing.T) {
+	t.Setenv("AUTH_SECRET", "from-env")
+	t.Setenv("DB_PASSWORD", "")
+
+	value, ok, err := EnvSecrets{}.Secret(context.Background(), "AUTH_SECRET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "from-env", value)
+
+	_, ok, err = EnvSecrets{}.Secret(context.Background(), "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// TestConfig_Redacted tests hiding the secrets of a copy
+func TestConfig_Redacted(t *testing.T) {
+	cfg := &Config{
+		Database: DatabaseConfig{Host: "db", Password: "hunter2"},
+		Auth:     AuthConfig{SecretKey: "signing-key"},
+		Cache:    CacheConfig{Driver: CacheRedis, RedisURL: "redis://:password@cache:6379/0"},
+	}
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "db", redacted.Database.Host)
+	assert.Equal(t, Redacted, redacted.Database.Password)
+	assert.Equal(t, Redacted, redacted.Auth.SecretKey)
+	assert.Equal(t, Redacted, redacted.Cache.RedisURL)
+	assert.Empty(t, redacted.Debug.Token, "unset secrets stay empty")
+	assert.Equal(t, "hunter2", cfg.Database.Password, "the original is unchanged")
+}
+
+// TestReload tests that Reload applies hot settings and keeps boot-only ones
+func TestReload(t *testing.T) {
+	dir := chdirTemp(t)
+	resetFeatures(t)
+	t.Setenv("TWINE_ENV", "")
+	t.Setenv("PORT", "")
+	t.Setenv("TRUSTED_PROXIES", "")
+	t.Setenv("AUTH_SECRET", "")
+	t.Setenv("LOGGER_LEVEL", "info")
+	path := filepath.Join(dir, File)
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 4000\nflags:\n  beta: false\n"), 0644))
+	resetConfig()
+	defer resetConfig()
+
+	before := Get()
+	assert.False(t, before.Flag("beta"))
+
+	var old, new *Config
+	unsubscribe := Subscribe(func(o, n *Config) { old, new = o, n })
+	defer unsubscribe()
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 5000\n  trusted_proxies: [127.0.0.1]\nflags:\n  beta: true\n"), 0644))
+	t.Setenv("LOGGER_LEVEL", "debug")
+	t.Setenv("AUTH_SECRET", "a-new-secret-of-at-least-32-characters")
+	require.NoError(t, Reload())
+
+	cfg := Get()
+	assert.True(t, cfg.Flag("beta"))
+	assert.False(t, cfg.Flag("missing"))
+	assert.Equal(t, LogDebug, cfg.Logger.Level)
+	assert.Equal(t, "a-new-secret-of-at-least-32-characters", cfg.Auth.SecretKey)
+	assert.Equal(t, []string{"127.0.0.1"}, cfg.Server.TrustedProxies)
+	assert.Equal(t, "4000", cfg.Server.Port, "port is boot-only")
+
+	assert.Same(t, before, old)
+	assert.Same(t, cfg, new)
+	assert.False(t, before.Flag("beta"), "the previous Config is not modified")
+
+	t.Run("invalid configuration is not applied", func(t *testing.T) {
+		t.Setenv("AUTH_SECRET", "short")
+		err := Reload()
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Same(t, cfg, Get())
+	})
+
+	t.Run("unsubscribed functions are not called", func(t *testing.T) {
+		unsubscribe()
+		new = nil
+		require.NoError(t, Reload())
+		assert.Nil(t, new)
+	})
+}
+
+// TestWatch tests reloading when twine.yaml changes
+func TestWatch(t *testing.T) {
+	dir := chdirTemp(t)
+	resetFeatures(t)
+	t.Setenv("TWINE_ENV", "")
+	t.Setenv("PORT", "")
+	t.Setenv("AUTH_SECRET", "")
+	path := filepath.Join(dir, File)
+	require.NoError(t, os.WriteFile(path, []byte("flags:\n  beta: false\n"), 0644))
+	resetConfig()
+	defer resetConfig()
+	Get()
+
+	reloaded := make(chan *Config, 1)
+	defer Subscribe(func(_, cfg *Config) {
+		select {
+		case reloaded <- cfg:
+		default:
+		}
+	})()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, Watch(ctx))
+
+	require.NoError(t, os.WriteFile(path, []byte("flags:\n  beta: true\n"), 0644))
+
+	select {
+	case cfg := <-reloaded:
+		assert.True(t, cfg.Flag("beta"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+}
+
+// TestExportDotenv tests that reloads update variables from .env without
+// overriding the environment
+func TestExportDotenv(t *testing.T) {
+	dir := chdirTemp(t)
+	t.Setenv("TWINE_TEST_DOTENV", "")
+	t.Setenv("TWINE_TEST_EXTERNAL", "from-environment")
+	os.Unsetenv("TWINE_TEST_DOTENV")
+	envFile := filepath.Join(dir, ".env")
+
+	require.NoError(t, os.WriteFile(envFile, []byte("TWINE_TEST_DOTENV=one\nTWINE_TEST_EXTERNAL=from-dotenv\n"), 0644))
+	require.NoError(t, exportDotenv())
+	assert.Equal(t, "one", os.Getenv("TWINE_TEST_DOTENV"))
+	assert.Equal(t, "from-environment", os.Getenv("TWINE_TEST_EXTERNAL"))
+
+	require.NoError(t, os.WriteFile(envFile, []byte("TWINE_TEST_DOTENV=two\n"), 0644))
+	require.NoError(t, exportDotenv())
+	assert.Equal(t, "two", os.Getenv("TWINE_TEST_DOTENV"))
+
+	require.NoError(t, os.WriteFile(envFile, []byte(""), 0644))
+	require.NoError(t, exportDotenv())
+	_, set := os.LookupEnv("TWINE_TEST_DOTENV")
+	assert.False(t, set, "variables removed from .env are unset")
+	assert.Equal(t, "from-environment", os.Getenv("TWINE_TEST_EXTERNAL"))
+}
+
+type testPaymentConfig struct {
+	Provider string        `yaml:"provider" env:"PAYMENTS_PROVIDER" default:"stripe"`
+	APIKey   string        `yaml:"-" env:"PAYMENTS_API_KEY" secret:"true" required:"true"`
+	Timeout  time.Duration `yaml:"timeout" default:"10s"`
+	Retries  int           `yaml:"retries" env:"PAYMENTS_RETRIES" default:"3"`
+	Regions  []string      `yaml:"regions" env:"PAYMENTS_REGIONS"`
+	Webhook  struct {
+		URL string `yaml:"url" required:"true"`
+	} `yaml:"webhook"`
+}
+
+func (p *testPaymentConfig) Validate() error {
+	if p.Retries > 5 {
+		return errors.New("retries must be at most 5")
+	}
+	return nil
+}
+
+// TestConfig_Bind tests loading application settings into a struct
+func TestConfig_Bind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File)
+	require.NoError(t, os.WriteFile(path, []byte(`payments:
+  provider: adyen
+  timeout: 30s
+  regions: [eu]
+  webhook:
+    url: https://example.com/hook
+environments:
+  production:
+    payments:
+      retries: 5
+`), 0644))
+
+	load := func(t *testing.T, env string, vars map[string]string) *Config {
+		t.Helper()
+		cfg, err := Load(WithFile(path), WithEnvFiles(), WithEnv(env), WithVars(vars),
+			WithSecrets(SecretsFunc(func(_ context.Context, name string) (string, bool, error) {
+				return "from-provider", name == "PAYMENTS_API_KEY", nil
+			})))
+		require.NoError(t, err)
+		return cfg
+	}
+
+	t.Run("defaults, file, environment section and variables", func(t *testing.T) {
+		cfg := load(t, "production", map[string]string{"PAYMENTS_REGIONS": "us, eu"})
+
+		var payments testPaymentConfig
+		require.NoError(t, cfg.Bind("payments", &payments))
+
+		assert.Equal(t, "adyen", payments.Provider)
+		assert.Equal(t, "from-provider", payments.APIKey)
+		assert.Equal(t, 30*time.Second, payments.Timeout)
+		assert.Equal(t, 5, payments.Retries)
+		assert.Equal(t, []string{"us", "eu"}, payments.Regions)
+		assert.Equal(t, "https://example.com/hook", payments.Webhook.URL)
+	})
+
+	t.Run("defaults without a section", func(t *testing.T) {
+		cfg := load(t, "development", nil)
+
+		var other testPaymentConfig
+		err := cfg.Bind("other", &other)
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, ValidationError{{Var: "other.url", Message: "is required"}}, validationErr)
+		assert.Equal(t, "stripe", other.Provider)
+		assert.Equal(t, 10*time.Second, other.Timeout)
+		assert.Equal(t, 3, other.Retries)
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		cfg := load(t, "development", map[string]string{"PAYMENTS_RETRIES": "many"})
+		cfg.secrets = nil
+
+		var payments testPaymentConfig
+		err := cfg.Bind("payments", &payments)
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, ValidationError{
+			{Var: "PAYMENTS_API_KEY", Message: "is required"},
+			{Var: "PAYMENTS_RETRIES", Message: `"many" must be a whole number`},
+		}, validationErr)
+	})
+
+	t.Run("Validate is called", func(t *testing.T) {
+		cfg := load(t, "development", map[string]string{"PAYMENTS_RETRIES": "9"})
+
+		var payments testPaymentConfig
+		err := cfg.Bind("payments", &payments)
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, ValidationError{{Var: "payments", Message: "retries must be at most 5"}}, validationErr)
+	})
+
+	t.Run("invalid targets", func(t *testing.T) {
+		cfg := load(t, "development", nil)
+
+		assert.ErrorContains(t, cfg.Bind("server", &testPaymentConfig{}), "used by the framework")
+		assert.ErrorContains(t, cfg.Bind("payments", testPaymentConfig{}), "pointer to a struct")
+		assert.ErrorContains(t, cfg.Bind("payments", &struct {
+			Timeout int `yaml:"timeout"`
+		}{}), File)
+	})
+}
+
+// TestLoad_ServerLimits tests reading the server limits from twine.yaml and
+// the environment
+func TestLoad_ServerLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File)
+	require.NoError(t, os.WriteFile(path, []byte(`server:
+  read_timeout: 5s
+  write_timeout: 0s
+  max_header_bytes: 65536
+  shutdown_timeout: 20s
+`), 0644))
+
+	cfg, err := Load(WithEnvFiles(), WithFile(path), WithVars(map[string]string{"SERVER_IDLE_TIMEOUT": "2m"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, time.Duration(0), cfg.Server.WriteTimeout)
+	assert.Equal(t, 2*time.Minute, cfg.Server.IdleTimeout)
+	assert.Equal(t, 65536, cfg.Server.MaxHeaderBytes)
+	assert.Equal(t, 20*time.Second, cfg.Server.ShutdownTimeout)
+
+	cfg, err = Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{"SERVER_SHUTDOWN_TIMEOUT": "30s"}))
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Server.ShutdownTimeout)
+}
+
+// TestLoad_LogFormat tests selecting JSON logs
+func TestLoad_LogFormat(t *testing.T) {
+	cfg, err := Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{"LOGGER_FORMAT": "json"}))
+	require.NoError(t, err)
+	assert.Equal(t, LogJSON, cfg.Logger.Format)
+}
+
+// TestLoad_Outputs tests multiple sinks, registered outputs and rotation
+func TestLoad_Outputs(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "app.log")
+	var shipped bytes.Buffer
+	RegisterOutput("test-shipper", &shipped)
+	defer RegisterOutput("test-shipper", nil)
+
+	cfg, err := Load(WithEnvFiles(), WithFile(""), WithVars(map[string]string{
+		"LOGGER_OUTPUT":       logFile + ", test-shipper",
+		"LOGGER_ERROR_OUTPUT": logFile,
+		"LOGGER_ROTATE_SIZE":  "10MB",
+		"LOGGER_ROTATE_AGE":   "24h",
+		"LOGGER_MAX_BACKUPS":  "7",
+		"LOGGER_COMPRESS":     "true",
+	}))
+	require.NoError(t, err)
+	defer cfg.Logger.Close()
+
+	assert.Equal(t, rotate.Options{MaxSize: 10 << 20, MaxAge: 24 * time.Hour, MaxBackups: 7, Compress: true}, cfg.Logger.Rotation)
+
+	_, err = cfg.Logger.Output.Write([]byte("info\n"))
+	require.NoError(t, err)
+	_, err = cfg.Logger.ErrorOutput.Write([]byte("error\n"))
+	require.NoError(t, err)
+
+	_, ok := cfg.Logger.ErrorOutput.(*rotate.File)
+	assert.True(t, ok, "files rotate")
+	assert.Len(t, cfg.Logger.files, 1, "both outputs share the file")
+	assert.Equal(t, "info\n", shipped.String())
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, "info\nerror\n", string(content))
+
+	require.NoError(t, cfg.Logger.Close())
+	assert.Empty(t, cfg.Logger.files)
+}
+
+// TestParseSize tests parsing rotation sizes
+func TestParseSize(t *testing.T) {
+	for input, want := range map[string]int64{
+		"":       0,
+		"512":    512,
+		"64KB":   64 << 10,
+		"100mb":  100 << 20,
+		"2 GB":   2 << 30,
+		"1024 B": 1024,
+	} {
+		got, err := parseSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"MB", "ten", "-1MB", "1.5GB"} {
+		_, err := parseSize(input)
+		assert.Error(t, err, input)
+	}
+}
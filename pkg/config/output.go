@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cstone-io/twine/pkg/logger/rotate"
+)
+
+var (
+	outputsMutex sync.Mutex
+	outputs      = map[string]io.Writer{}
+)
+
+// RegisterOutput makes w available to LOGGER_OUTPUT and LOGGER_ERROR_OUTPUT
+// under name, e.g. to send logs to a log shipper as well as stdout:
+//
+//	config.RegisterOutput("shipper", shipper)
+//	// LOGGER_OUTPUT=stdout,shipper
+//
+// Call it before the configuration is loaded. A nil w removes the name.
+func RegisterOutput(name string, w io.Writer) {
+	outputsMutex.Lock()
+	defer outputsMutex.Unlock()
+	if w == nil {
+		delete(outputs, name)
+		return
+	}
+	outputs[name] = w
+}
+
+// registeredOutput returns the writer registered as name
+func registeredOutput(name string) (io.Writer, bool) {
+	outputsMutex.Lock()
+	defer outputsMutex.Unlock()
+	w, ok := outputs[name]
+	return w, ok
+}
+
+// openOutputs opens the comma-separated sinks in spec: stdout, stderr, a
+// name given to RegisterOutput or a file path. Files rotate if cfg.Rotation
+// is set, and are added to cfg.files. opened shares files between outputs.
+func openOutputs(spec string, cfg *LoggerConfig, opened map[string]io.Writer) (io.Writer, error) {
+	writers := []io.Writer{}
+	for _, name := range splitList(spec) {
+		if w, ok := registeredOutput(name); ok {
+			writers = append(writers, w)
+			continue
+		}
+		if w, ok := opened[name]; ok {
+			writers = append(writers, w)
+			continue
+		}
+
+		var w io.Writer
+		var err error
+		if name != "stdout" && name != "stderr" && cfg.Rotation != (rotate.Options{}) {
+			w, err = rotate.Open(name, cfg.Rotation)
+			if err != nil {
+				err = fmt.Errorf("opening log file: %w", err)
+			}
+		} else {
+			w, err = parseOutput(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if closer, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+			cfg.files = append(cfg.files, closer)
+			opened[name] = w
+		}
+		writers = append(writers, w)
+	}
+
+	switch len(writers) {
+	case 0:
+		return nil, fmt.Errorf("no outputs in %q", spec)
+	case 1:
+		return writers[0], nil
+	}
+	return io.MultiWriter(writers...), nil
+}
+
+// parseOutput opens stdout, stderr or a log file without rotation
+func parseOutput(output string) (io.Writer, error) {
+	switch output {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		file, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("opening log file: %w", err)
+		}
+		return file, nil
+	}
+}
+
+// sizeUnits are the suffixes parseSize accepts, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size such as 100MB, or a number of bytes. An empty
+// string is 0.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = strings.TrimSpace(number), unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q must be a size such as 100MB", s)
+	}
+	return n * multiplier, nil
+}
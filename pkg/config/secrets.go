@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SecretsProvider resolves secrets by variable name, e.g. from Docker
+// secrets, Vault or AWS SSM. ok is false when the provider doesn't have the
+// secret, so the next provider is asked.
+type SecretsProvider interface {
+	Secret(ctx context.Context, name string) (value string, ok bool, err error)
+}
+
+// SecretsFunc adapts a function to SecretsProvider. It is the hook for
+// secret stores without a built-in provider:
+//
+//	vault := config.SecretsFunc(func(ctx context.Context, name string) (string, bool, error) {
+//	    secret, err := client.KVv2("app").Get(ctx, strings.ToLower(name))
+//	    if err != nil {
+//	        return "", false, err
+//	    }
+//	    value, ok := secret.Data["value"].(string)
+//	    return value, ok, nil
+//	})
+type SecretsFunc func(ctx context.Context, name string) (string, bool, error)
+
+// Secret calls f
+func (f SecretsFunc) Secret(ctx context.Context, name string) (string, bool, error) {
+	return f(ctx, name)
+}
+
+// EnvSecrets reads secrets from process environment variables of the same
+// name. List it before other providers to let the environment override them.
+type EnvSecrets struct{}
+
+// Secret returns the environment variable name, if it is set and not empty
+func (EnvSecrets) Secret(_ context.Context, name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	return value, ok && value != "", nil
+}
+
+// FileSecrets reads secrets from files, such as Docker secrets mounted in
+// /run/secrets. A secret is read from the file named by <name>_FILE when that
+// variable is set, otherwise from <name> or its lowercase form in Dir.
+// Trailing newlines are trimmed.
+type FileSecrets struct {
+	Dir string
+}
+
+// DockerSecretsDir is where Docker and Compose mount secrets
+const DockerSecretsDir = "/run/secrets"
+
+// Secret reads the file for name
+func (f FileSecrets) Secret(_ context.Context, name string) (string, bool, error) {
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		return readSecretFile(path, true)
+	}
+	if f.Dir == "" {
+		return "", false, nil
+	}
+
+	for _, file := range []string{name, strings.ToLower(name)} {
+		value, ok, err := readSecretFile(filepath.Join(f.Dir, file), false)
+		if ok || err != nil {
+			return value, ok, err
+		}
+	}
+	return "", false, nil
+}
+
+// readSecretFile reads a secret, treating a missing file as not found
+// unless it was asked for explicitly
+func readSecretFile(path string, required bool) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// WithSecrets resolves AUTH_SECRET and DB_PASSWORD through providers, in order. The first
+// provider with a secret wins; without one, the variable's value is kept.
+func WithSecrets(providers ...SecretsProvider) Option {
+	return func(o *loadOptions) {
+		o.secrets = providers
+	}
+}
+
+var (
+	secretsMutex   sync.Mutex
+	defaultSecrets []SecretsProvider
+)
+
+// UseSecrets sets the providers Get resolves secrets with. Call it at the
+// start of main, before anything calls Get.
+func UseSecrets(providers ...SecretsProvider) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	defaultSecrets = providers
+}
+
+// getDefaultSecrets returns the providers set with UseSecrets
+func getDefaultSecrets() []SecretsProvider {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	return defaultSecrets
+}
+
+// Redacted is what Redacted puts in place of a secret
+const Redacted = "[REDACTED]"
+
+// Redacted returns a copy of c with every set secret replaced by Redacted,
+// for showing the configuration
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	for _, field := range secretFields(&redacted) {
+		if *field != "" {
+			*field = Redacted
+		}
+	}
+	return &redacted
+}
+
+// secretFields returns the fields of cfg holding the Secret variables, by
+// name
+func secretFields(cfg *Config) map[string]*string {
+	return map[string]*string{
+		"AUTH_SECRET":               &cfg.Auth.SecretKey,
+		"AUTH_PRIVATE_KEY":          &cfg.Auth.PrivateKey,
+		"AUTH_GOOGLE_CLIENT_SECRET": &cfg.Auth.OAuth.GoogleClientSecret,
+		"AUTH_GITHUB_CLIENT_SECRET": &cfg.Auth.OAuth.GitHubClientSecret,
+		"AUTH_OIDC_CLIENT_SECRET":   &cfg.Auth.OAuth.OIDCClientSecret,
+		"DB_PASSWORD":               &cfg.Database.Password,
+		"CACHE_REDIS_URL":           &cfg.Cache.RedisURL,
+		"JOBS_REDIS_URL":            &cfg.Jobs.RedisURL,
+		"SCHEDULE_REDIS_URL":        &cfg.Schedule.RedisURL,
+		"STORAGE_S3_ACCESS_KEY":     &cfg.Storage.S3AccessKey,
+		"STORAGE_S3_SECRET_KEY":     &cfg.Storage.S3SecretKey,
+		"REALTIME_REDIS_URL":        &cfg.Realtime.RedisURL,
+		"DEBUG_TOKEN":               &cfg.Debug.Token,
+	}
+}
+
+// resolveSecrets replaces the secrets in cfg with values from providers
+func resolveSecrets(ctx context.Context, cfg *Config, providers []SecretsProvider) error {
+	fields := secretFields(cfg)
+	for _, v := range EnvVars {
+		if !v.Secret {
+			continue
+		}
+		name := v.Name
+		for _, provider := range providers {
+			value, ok, err := provider.Secret(ctx, name)
+			if err != nil {
+				return fmt.Errorf("%s: resolving secret: %w", name, err)
+			}
+			if ok {
+				*fields[name] = value
+				break
+			}
+		}
+	}
+	return nil
+}
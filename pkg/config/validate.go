@@ -0,0 +1,346 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MinSecretLength is the shortest AUTH_SECRET Validate accepts, 256 bits
+// for the HS256 tokens pkg/auth signs
+const MinSecretLength = 32
+
+// Feature is a part of the framework with required settings
+type Feature string
+
+const (
+	// FeatureDatabase requires the DB_* settings
+	FeatureDatabase Feature = "database"
+	// FeatureAuth requires AUTH_SECRET, or AUTH_PRIVATE_KEY with RS256 and
+	// EdDSA
+	FeatureAuth Feature = "auth"
+)
+
+var (
+	featuresMutex sync.Mutex
+	features      = map[Feature]bool{}
+)
+
+// Require marks a feature as used, so Validate checks its settings. Packages
+// call it when they are imported or set up, e.g. pkg/database and
+// middleware.JWTMiddleware.
+func Require(feature Feature) {
+	featuresMutex.Lock()
+	defer featuresMutex.Unlock()
+	features[feature] = true
+}
+
+// required reports whether feature is used
+func required(feature Feature) bool {
+	featuresMutex.Lock()
+	defer featuresMutex.Unlock()
+	return features[feature]
+}
+
+// Problem is a setting that failed validation
+type Problem struct {
+	Var     string // Environment variable the setting is read from
+	Message string
+}
+
+// ValidationError lists every problem Validate found
+type ValidationError []Problem
+
+func (e ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, p := range e {
+		b.WriteString("\n  " + p.Var + ": " + p.Message)
+	}
+	return b.String()
+}
+
+// Validate checks the loaded configuration. It returns a ValidationError
+// listing every problem, or nil.
+func Validate() error {
+	return Get().Validate()
+}
+
+// Validate checks c, reporting every problem at once in a ValidationError
+func (c *Config) Validate() error {
+	problems := ValidationError{}
+	add := func(name, message string) {
+		problems = append(problems, Problem{Var: name, Message: message})
+	}
+
+	if !validPort(c.Server.Port) {
+		add("PORT", "server port "+strconv.Quote(c.Server.Port)+" must be a number from 1 to 65535")
+	}
+
+	for _, v := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"DB_SLOW_QUERY_THRESHOLD", c.Database.SlowQueryThreshold},
+		{"DB_CONN_MAX_LIFETIME", c.Database.ConnMaxLifetime},
+		{"JOBS_POLL_INTERVAL", c.Jobs.PollInterval},
+		{"REALTIME_HEARTBEAT", c.Realtime.Heartbeat},
+		{"AUTH_ACCESS_TTL", c.Auth.AccessTTL},
+		{"AUTH_REFRESH_TTL", c.Auth.RefreshTTL},
+		{"AUTH_SESSION_TTL", c.Auth.SessionTTL},
+	} {
+		if v.value < 0 {
+			add(v.name, v.value.String()+" must not be negative")
+		}
+	}
+	for _, v := range []struct {
+		name  string
+		value int
+	}{
+		{"SERVER_MAX_HEADER_BYTES", c.Server.MaxHeaderBytes},
+		{"DB_MAX_OPEN_CONNS", c.Database.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", c.Database.MaxIdleConns},
+		{"CACHE_MAX_ENTRIES", c.Cache.MaxEntries},
+		{"JOBS_CONCURRENCY", c.Jobs.Concurrency},
+		{"AUTH_ARGON2_MEMORY", c.Auth.Argon2Memory},
+		{"AUTH_ARGON2_TIME", c.Auth.Argon2Time},
+		{"AUTH_ARGON2_THREADS", c.Auth.Argon2Threads},
+		{"LOGGER_REQUEST_SAMPLE_FIRST", c.Logger.RequestSampleFirst},
+		{"LOGGER_REQUEST_SAMPLE_THEREAFTER", c.Logger.RequestSampleThereafter},
+	} {
+		if v.value < 0 {
+			add(v.name, strconv.Itoa(v.value)+" must not be negative")
+		}
+	}
+
+	secret := c.Auth.SecretKey
+	hmac := c.Auth.Algorithm == AuthHS256 || c.Auth.Algorithm == ""
+	switch {
+	case secret == "" && hmac && required(FeatureAuth):
+		add("AUTH_SECRET", "is required to sign tokens")
+	case secret != "" && len(secret) < MinSecretLength:
+		add("AUTH_SECRET", "must be at least "+strconv.Itoa(MinSecretLength)+" characters")
+	}
+	switch c.Auth.Algorithm {
+	case AuthHS256, "":
+	case AuthRS256, AuthEdDSA:
+		if c.Auth.PrivateKey == "" && required(FeatureAuth) {
+			add("AUTH_PRIVATE_KEY", "is required for AUTH_ALGORITHM="+c.Auth.Algorithm)
+		}
+	default:
+		add("AUTH_ALGORITHM", strconv.Quote(c.Auth.Algorithm)+" must be one of "+AuthHS256+", "+AuthRS256+", "+AuthEdDSA)
+	}
+
+	switch c.Auth.PasswordHasher {
+	case PasswordArgon2id, PasswordBcrypt, "":
+	default:
+		add("AUTH_PASSWORD_HASHER", strconv.Quote(c.Auth.PasswordHasher)+" must be "+PasswordArgon2id+" or "+PasswordBcrypt)
+	}
+	if c.Auth.Argon2Threads > 255 {
+		add("AUTH_ARGON2_THREADS", strconv.Itoa(c.Auth.Argon2Threads)+" must be at most 255")
+	}
+	if c.Auth.Argon2Memory > 0 && c.Auth.Argon2Memory < 8*max(c.Auth.Argon2Threads, 1) {
+		add("AUTH_ARGON2_MEMORY", "must be at least 8 KiB per thread")
+	}
+
+	oauth := c.Auth.OAuth
+	for _, client := range []struct{ id, secret, name string }{
+		{oauth.GoogleClientID, oauth.GoogleClientSecret, "AUTH_GOOGLE"},
+		{oauth.GitHubClientID, oauth.GitHubClientSecret, "AUTH_GITHUB"},
+		{oauth.OIDCClientID, oauth.OIDCClientSecret, "AUTH_OIDC"},
+	} {
+		if client.id != "" && client.secret == "" {
+			add(client.name+"_CLIENT_SECRET", "is required with "+client.name+"_CLIENT_ID")
+		}
+	}
+	switch {
+	case oauth.OIDCClientID != "" && oauth.OIDCIssuer == "":
+		add("AUTH_OIDC_ISSUER", "is required with AUTH_OIDC_CLIENT_ID")
+	case oauth.OIDCIssuer != "" && !isHTTPURL(oauth.OIDCIssuer):
+		add("AUTH_OIDC_ISSUER", strconv.Quote(oauth.OIDCIssuer)+" must be an http:// or https:// URL")
+	}
+
+	switch c.Cache.Driver {
+	case CacheMemory, "":
+	case CacheRedis:
+		if c.Cache.RedisURL == "" {
+			add("CACHE_REDIS_URL", "is required for CACHE_DRIVER=redis")
+		} else if !isRedisURL(c.Cache.RedisURL) {
+			add("CACHE_REDIS_URL", "must be a redis:// or rediss:// URL")
+		}
+	default:
+		add("CACHE_DRIVER", strconv.Quote(c.Cache.Driver)+" must be one of "+CacheMemory+", "+CacheRedis)
+	}
+
+	switch c.Jobs.Driver {
+	case JobsDatabase, "":
+	case JobsRedis:
+		if c.Jobs.RedisURL == "" {
+			add("JOBS_REDIS_URL", "is required for JOBS_DRIVER=redis")
+		} else if !isRedisURL(c.Jobs.RedisURL) {
+			add("JOBS_REDIS_URL", "must be a redis:// or rediss:// URL")
+		}
+	default:
+		add("JOBS_DRIVER", strconv.Quote(c.Jobs.Driver)+" must be one of "+JobsDatabase+", "+JobsRedis)
+	}
+
+	switch c.Schedule.Locker {
+	case ScheduleDatabase, ScheduleMemory, "":
+	case ScheduleRedis:
+		if c.Schedule.RedisURL == "" {
+			add("SCHEDULE_REDIS_URL", "is required for SCHEDULE_LOCKER=redis")
+		} else if !isRedisURL(c.Schedule.RedisURL) {
+			add("SCHEDULE_REDIS_URL", "must be a redis:// or rediss:// URL")
+		}
+	default:
+		add("SCHEDULE_LOCKER", strconv.Quote(c.Schedule.Locker)+" must be one of "+ScheduleDatabase+", "+ScheduleRedis+", "+ScheduleMemory)
+	}
+
+	switch c.Storage.Driver {
+	case StorageLocal, "":
+		if c.Storage.URL != "" && !strings.HasPrefix(c.Storage.URL, "/") && !isHTTPURL(c.Storage.URL) {
+			add("STORAGE_URL", "must be a path or an http:// or https:// URL")
+		}
+	case StorageS3:
+		if c.Storage.S3Bucket == "" {
+			add("STORAGE_S3_BUCKET", "is required for STORAGE_DRIVER=s3")
+		}
+		if c.Storage.S3AccessKey == "" || c.Storage.S3SecretKey == "" {
+			add("STORAGE_S3_ACCESS_KEY", "and STORAGE_S3_SECRET_KEY are required for STORAGE_DRIVER=s3")
+		}
+		if c.Storage.S3Endpoint != "" && !isHTTPURL(c.Storage.S3Endpoint) {
+			add("STORAGE_S3_ENDPOINT", "must be an http:// or https:// URL")
+		}
+	default:
+		add("STORAGE_DRIVER", strconv.Quote(c.Storage.Driver)+" must be one of "+StorageLocal+", "+StorageS3)
+	}
+
+	switch c.Realtime.Driver {
+	case RealtimeMemory, "":
+	case RealtimeRedis:
+		if c.Realtime.RedisURL == "" {
+			add("REALTIME_REDIS_URL", "is required for REALTIME_DRIVER=redis")
+		} else if !isRedisURL(c.Realtime.RedisURL) {
+			add("REALTIME_REDIS_URL", "must be a redis:// or rediss:// URL")
+		}
+	default:
+		add("REALTIME_DRIVER", strconv.Quote(c.Realtime.Driver)+" must be one of "+RealtimeMemory+", "+RealtimeRedis)
+	}
+
+	tls := c.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		add("TLS_CERT_FILE", "and TLS_KEY_FILE must be set together")
+	}
+	if len(tls.AutocertDomains) > 0 {
+		if tls.CertFile != "" {
+			add("TLS_AUTOCERT_DOMAINS", "can't be used with TLS_CERT_FILE")
+		}
+		if tls.AutocertCacheDir == "" {
+			add("TLS_AUTOCERT_CACHE_DIR", "is required for TLS_AUTOCERT_DOMAINS")
+		}
+		if tls.AutocertDirectoryURL != "" && !isHTTPURL(tls.AutocertDirectoryURL) {
+			add("TLS_AUTOCERT_DIRECTORY_URL", "must be an http:// or https:// URL")
+		}
+	}
+	if tls.RedirectPort != "" && !validPort(tls.RedirectPort) {
+		add("TLS_REDIRECT_PORT", strconv.Quote(tls.RedirectPort)+" must be a number from 1 to 65535")
+	}
+
+	if debug := c.Debug; debug.Enabled {
+		if debug.Token != "" && len(debug.Token) < MinSecretLength {
+			add("DEBUG_TOKEN", "must be at least "+strconv.Itoa(MinSecretLength)+" characters")
+		}
+		if debug.Addr != "" {
+			host, port, err := net.SplitHostPort(debug.Addr)
+			switch {
+			case err != nil || !validPort(port):
+				add("DEBUG_ADDR", strconv.Quote(debug.Addr)+" must be a host and port, such as "+DefaultDebugAddr)
+			case debug.Token == "" && !isLoopback(host):
+				add("DEBUG_ADDR", "must be a loopback address, such as "+DefaultDebugAddr+", without DEBUG_TOKEN")
+			}
+		}
+	}
+
+	if required(FeatureDatabase) {
+		db := c.Database
+		switch db.Driver {
+		case DriverSQLite:
+			if db.Name == "" {
+				add("DB_NAME", "is required to open the SQLite database file")
+			}
+		case DriverPostgres, DriverMySQL, "":
+			c.validateServer(add)
+		default:
+			add("DB_DRIVER", strconv.Quote(db.Driver)+" must be one of "+strings.Join(drivers, ", "))
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// drivers are the DB_DRIVER values Validate accepts
+var drivers = []string{DriverPostgres, DriverSQLite, DriverMySQL}
+
+// validateServer checks the settings for connecting to a database server
+func (c *Config) validateServer(add func(name, problem string)) {
+	db := c.Database
+	for _, v := range []struct{ name, value string }{
+		{"DB_HOST", db.Host},
+		{"DB_USERNAME", db.Username},
+		{"DB_NAME", db.Name},
+	} {
+		if v.value == "" {
+			add(v.name, "is required to connect to the database")
+		}
+	}
+	if db.Port == 0 {
+		add("DB_PORT", "is required to connect to the database")
+	} else if !validPort(strconv.Itoa(db.Port)) {
+		add("DB_PORT", strconv.Itoa(db.Port)+" must be a number from 1 to 65535")
+	}
+	if !slices.Contains(sslModes, db.SSLMode) {
+		add("DB_SSLMODE", strconv.Quote(db.SSLMode)+" must be one of "+strings.Join(sslModes, ", "))
+	}
+}
+
+// sslModes are the sslmode values Postgres accepts
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// validPort reports whether port is a TCP port number
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// isHTTPURL reports whether s is an http:// or https:// URL with a host
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isLoopback reports whether host only accepts connections from the same
+// machine
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isRedisURL reports whether s is a redis:// or rediss:// URL with a host
+func isRedisURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "redis" || u.Scheme == "rediss") && u.Host != ""
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/joho/godotenv"
+)
+
+// reloadDebounce groups the events of one save into a single reload
+const reloadDebounce = 100 * time.Millisecond
+
+var (
+	// reloadMutex serializes Reload and guards dotenvExported
+	reloadMutex sync.Mutex
+	// dotenvExported holds the variables Get set from .env, with their
+	// values, so a reload can update or unset them
+	dotenvExported = map[string]string{}
+
+	subscribersMutex sync.Mutex
+	subscribers      = map[int]func(old, new *Config){}
+	nextSubscriber   int
+)
+
+// Subscribe calls fn with the previous and new Config after each reload,
+// e.g. to adjust rate limits or react to flags. It returns a function that
+// removes the subscription.
+func Subscribe(fn func(old, new *Config)) (unsubscribe func()) {
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+
+	id := nextSubscriber
+	nextSubscriber++
+	subscribers[id] = fn
+
+	return func() {
+		subscribersMutex.Lock()
+		defer subscribersMutex.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+// notify calls every subscriber
+func notify(old, new *Config) {
+	subscribersMutex.Lock()
+	fns := make([]func(old, new *Config), 0, len(subscribers))
+	for _, fn := range subscribers {
+		fns = append(fns, fn)
+	}
+	subscribersMutex.Unlock()
+
+	for _, fn := range fns {
+		fn(old, new)
+	}
+}
+
+// Reload re-reads .env, twine.yaml and secrets, and replaces the Config Get
+// returns with one carrying the new hot settings: LOGGER_LEVEL,
+// LOGGER_STACK_TRACES, AUTH_*, trusted proxies, IP filters and flags. Other settings keep their values until the
+// process restarts, and changes to them are logged. If the configuration
+// doesn't load or validate, the current one is kept and the error returned.
+func Reload() error {
+	// Get first: its first call exports .env under reloadMutex
+	Get()
+
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	current := Get()
+	exportDotenvLocked()
+
+	loaded, err := loadDefault()
+	if err != nil {
+		return err
+	}
+	// The running logger keeps the files opened at startup
+	defer loaded.Logger.Close()
+
+	next := *current
+	next.Logger.Level = loaded.Logger.Level
+	next.Logger.StackTraces = loaded.Logger.StackTraces
+	next.Auth = loaded.Auth
+	next.Server.TrustedProxies = loaded.Server.TrustedProxies
+	next.Server.IPAllow, next.Server.IPDeny = loaded.Server.IPAllow, loaded.Server.IPDeny
+	next.Flags = loaded.Flags
+	next.src, next.secrets, next.sections = loaded.src, loaded.secrets, loaded.sections
+
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	if changed := bootOnlyChanges(current, loaded); len(changed) > 0 {
+		log.Printf("Config: restart to apply changes to %s", strings.Join(changed, ", "))
+	}
+
+	instanceMutex.Lock()
+	instance = &next
+	instanceMutex.Unlock()
+
+	notify(current, &next)
+	return nil
+}
+
+// bootOnlyChanges names the settings read at startup that differ in loaded
+func bootOnlyChanges(current, loaded *Config) []string {
+	changed := []string{}
+	if current.Env != loaded.Env {
+		changed = append(changed, "TWINE_ENV")
+	}
+	if current.Logger.RequestFormat != loaded.Logger.RequestFormat ||
+		!slices.Equal(current.Logger.RequestFields, loaded.Logger.RequestFields) ||
+		current.Logger.RequestSampleFirst != loaded.Logger.RequestSampleFirst ||
+		current.Logger.RequestSampleThereafter != loaded.Logger.RequestSampleThereafter {
+		changed = append(changed, "request logging")
+	}
+	if current.Database != loaded.Database {
+		changed = append(changed, "database")
+	}
+	if current.Cache != loaded.Cache {
+		changed = append(changed, "cache")
+	}
+	if current.Jobs != loaded.Jobs {
+		changed = append(changed, "jobs")
+	}
+	if current.Schedule != loaded.Schedule {
+		changed = append(changed, "schedule")
+	}
+	if current.Storage != loaded.Storage {
+		changed = append(changed, "storage")
+	}
+	if current.Realtime != loaded.Realtime {
+		changed = append(changed, "realtime")
+	}
+	if current.Server.Port != loaded.Server.Port {
+		changed = append(changed, "server.port")
+	}
+	if current.Server.ReadTimeout != loaded.Server.ReadTimeout ||
+		current.Server.WriteTimeout != loaded.Server.WriteTimeout ||
+		current.Server.IdleTimeout != loaded.Server.IdleTimeout ||
+		current.Server.MaxHeaderBytes != loaded.Server.MaxHeaderBytes ||
+		current.Server.ShutdownTimeout != loaded.Server.ShutdownTimeout {
+		changed = append(changed, "server limits")
+	}
+	if current.Server.HealthPath != loaded.Server.HealthPath || current.Server.ReadyPath != loaded.Server.ReadyPath {
+		changed = append(changed, "server health paths")
+	}
+	if current.Server.Compress != loaded.Server.Compress {
+		changed = append(changed, "server.compress")
+	}
+	if !reflect.DeepEqual(current.TLS, loaded.TLS) {
+		changed = append(changed, "tls")
+	}
+	if current.Debug != loaded.Debug {
+		changed = append(changed, "debug")
+	}
+	if current.Routes != loaded.Routes {
+		changed = append(changed, "routes")
+	}
+	if !slices.Equal(current.Templates.Patterns, loaded.Templates.Patterns) {
+		changed = append(changed, "templates")
+	}
+	if !slices.Equal(current.Static.Dirs, loaded.Static.Dirs) {
+		changed = append(changed, "static")
+	}
+	return changed
+}
+
+// Watch reloads the configuration when .env or twine.yaml in the working
+// directory changes, or the process receives SIGHUP, until ctx is done.
+// Failed reloads are logged and keep the current configuration. It returns
+// once watching has started.
+func Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// Editors often replace files on save, so watch the directory
+	if err := watcher.Add("."); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+		defer signal.Stop(hup)
+
+		debounce := time.NewTimer(reloadDebounce)
+		debounce.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				debounce.Stop()
+				return
+			case <-hup:
+				reloadAndLog()
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if name := filepath.Base(event.Name); name == ".env" || name == File {
+					debounce.Reset(reloadDebounce)
+				}
+			case <-debounce.C:
+				reloadAndLog()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Config: watching for changes: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reloadAndLog reloads, logging failures since Watch has no caller to
+// return them to
+func reloadAndLog() {
+	if err := Reload(); err != nil {
+		log.Printf("Config: reload failed, keeping the current configuration: %v", err)
+	}
+}
+
+// exportDotenv sets the variables in .env that aren't already in the
+// environment, as godotenv.Load does, remembering them for reloads
+func exportDotenv() error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+	return exportDotenvLocked()
+}
+
+// exportDotenvLocked updates the variables exported from .env: new ones are
+// set, changed ones updated and removed ones unset. Variables changed by
+// anything else since are left alone. The caller holds reloadMutex.
+func exportDotenvLocked() error {
+	values, err := godotenv.Read()
+	if err != nil {
+		values = map[string]string{}
+	}
+
+	for key, exported := range dotenvExported {
+		if os.Getenv(key) != exported {
+			delete(dotenvExported, key)
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+			delete(dotenvExported, key)
+		}
+	}
+
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set {
+			if _, ours := dotenvExported[key]; !ours {
+				continue
+			}
+		}
+		os.Setenv(key, value)
+		dotenvExported[key] = value
+	}
+
+	return err
+}
@@ -13,7 +13,19 @@ type BaseModel struct {
 	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// SoftDelete adds soft delete support to models that don't embed
+// BaseModel. Deleting them sets deleted_at instead of removing the row, and
+// queries skip deleted rows unless they use WithDeleted or OnlyDeleted.
+//
+//	type AuditEntry struct {
+//	    ID uint
+//	    database.SoftDelete
+//	}
+type SoftDelete struct {
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 // BeforeCreate hook generates a UUID if not set
@@ -24,6 +36,19 @@ func (b *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// Version is the type of a model field that turns on optimistic locking.
+// CRUDStore.Update only saves a record whose version is unchanged since it
+// was read, then increments it; otherwise it returns ErrDatabaseConflict.
+// Round-trip the version through edit forms so that concurrent submissions
+// can't overwrite each other:
+//
+//	type Post struct {
+//	    database.BaseModel `gorm:"embedded"`
+//	    Title   string
+//	    Version database.Version `json:"version" form:"version"`
+//	}
+type Version int64
+
 // Polymorphic provides fields for polymorphic relationships
 // See: https://gorm.io/docs/polymorphism.html
 //
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// RunCommand runs a twine db subcommand against the configured database.
+// twine db builds a small program in the project that imports its migrations
+// and seeds and calls RunCommand, so the project's registered migrations and
+// seeds are available. SQL migration files in db/migrations are loaded
+// alongside them.
+func RunCommand(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a command: migrate, rollback, status or seed")
+	}
+
+	steps := 1
+	env := "dev"
+	truncate := false
+	plan := false
+	switch args[0] {
+	case "status":
+	case "migrate":
+		flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		flags.BoolVar(&plan, "plan", false, "")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() > 0 {
+			return fmt.Errorf("invalid migrate arguments: expected [--plan]")
+		}
+	case "seed":
+		flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		flags.StringVar(&env, "env", env, "")
+		flags.BoolVar(&truncate, "truncate", false, "")
+		if err := flags.Parse(args[1:]); err != nil {
+			return fmt.Errorf("invalid seed arguments: %w", err)
+		}
+		if env == "" || flags.NArg() > 0 {
+			return fmt.Errorf("invalid seed arguments: expected [--env name] [--truncate]")
+		}
+	case "rollback":
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid rollback count %q: must be a positive number", args[1])
+			}
+			steps = n
+		}
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	ms, err := withSQLMigrations(migrations, SQLMigrationsDir)
+	if err != nil {
+		return err
+	}
+
+	// A plan only reads, so it skips Open's setup
+	connect := Open
+	if plan {
+		connect = open
+	}
+	client, err := connect(config.Get().Database)
+	if err != nil {
+		return errors.ErrDatabaseConn.Wrap(err)
+	}
+	migrator := NewMigrator(client, ms...)
+
+	if plan {
+		pending, err := migrator.Plan()
+		if err != nil {
+			return err
+		}
+		WritePlan(out, pending)
+		return nil
+	}
+
+	switch args[0] {
+	case "migrate":
+		applied, err := migrator.Migrate()
+		for _, name := range applied {
+			fmt.Fprintf(out, "✓ Applied %s\n", name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "✅ Database is up to date")
+		}
+		return err
+
+	case "rollback":
+		rolledBack, err := migrator.Rollback(steps)
+		for _, name := range rolledBack {
+			fmt.Fprintf(out, "✓ Rolled back %s\n", name)
+		}
+		if err == nil && len(rolledBack) == 0 {
+			fmt.Fprintln(out, "Nothing to roll back")
+		}
+		return err
+
+	case "seed":
+		ran, err := RunSeeds(client, env, truncate)
+		for _, name := range ran {
+			fmt.Fprintf(out, "✓ Seeded %s\n", name)
+		}
+		if err == nil && len(ran) == 0 {
+			fmt.Fprintf(out, "No seeds registered for %s\n", env)
+		}
+		return err
+
+	default:
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		writeMigrationStatus(out, statuses)
+		return nil
+	}
+}
+
+// writeMigrationStatus prints migrations as a table
+func writeMigrationStatus(out io.Writer, statuses []MigrationStatus) {
+	if len(statuses) == 0 {
+		fmt.Fprintln(out, "No migrations registered")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tBATCH\tAPPLIED AT\tMIGRATION")
+	for _, s := range statuses {
+		switch {
+		case s.Missing:
+			fmt.Fprintf(w, "missing\t%d\t%s\t%s\n", s.Batch, s.AppliedAt.Format("2006-01-02 15:04:05"), s.Name)
+		case s.Applied:
+			fmt.Fprintf(w, "applied\t%d\t%s\t%s\n", s.Batch, s.AppliedAt.Format("2006-01-02 15:04:05"), s.Name)
+		default:
+			fmt.Fprintf(w, "pending\t-\t-\t%s\n", s.Name)
+		}
+	}
+	w.Flush()
+}
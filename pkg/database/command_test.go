@@ -0,0 +1,59 @@
+package database
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunCommand_InvalidArgs tests argument validation, which happens
+// before connecting to the database
+func TestRunCommand_InvalidArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		errorMsg string
+	}{
+		{"no command", nil, "expected a command"},
+		{"unknown command", []string{"reset"}, `unknown command "reset"`},
+		{"invalid count", []string{"rollback", "x"}, `invalid rollback count "x"`},
+		{"zero count", []string{"rollback", "0"}, "must be a positive number"},
+		{"unknown seed flag", []string{"seed", "--force"}, "invalid seed arguments"},
+		{"empty seed env", []string{"seed", "--env", ""}, "invalid seed arguments"},
+		{"extra seed args", []string{"seed", "users"}, "invalid seed arguments"},
+		{"unknown migrate flag", []string{"migrate", "--dry"}, "invalid migrate arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RunCommand(tt.args, &bytes.Buffer{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+// TestWriteMigrationStatus tests the status table
+func TestWriteMigrationStatus(t *testing.T) {
+	appliedAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	writeMigrationStatus(&out, []MigrationStatus{
+		{Name: "001_create_users", Applied: true, Batch: 1, AppliedAt: appliedAt},
+		{Name: "002_create_posts"},
+		{Name: "000_removed", Applied: true, Batch: 1, AppliedAt: appliedAt, Missing: true},
+	})
+
+	assert.Equal(t, `STATUS   BATCH  APPLIED AT           MIGRATION
+applied  1      2024-03-01 09:30:00  001_create_users
+pending  -      -                    002_create_posts
+missing  1      2024-03-01 09:30:00  000_removed
+`, out.String())
+
+	out.Reset()
+	writeMigrationStatus(&out, nil)
+	assert.Equal(t, "No migrations registered\n", out.String())
+}
@@ -1,23 +1,56 @@
 package database
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 
 	"github.com/cstone-io/twine/pkg/errors"
 )
 
+const (
+	// DefaultPerPage is the page size k.ListOptions and ListAfter use
+	// without one
+	DefaultPerPage = 20
+	// MaxPerPage caps the page size k.ListOptions reads, so clients can't
+	// list a whole table
+	MaxPerPage = 100
+)
+
+// ListOptions selects a page of records, sorted and filtered by the
+// store's allowed columns. k.ListOptions reads them from the query string.
+type ListOptions struct {
+	Page    int    // 1-based; 0 is the first page
+	PerPage int    // 0 lists every record
+	Sort    string // Comma-separated columns, "-" first for descending, e.g. "-created_at,title"
+	// Filters match columns to values exactly
+	Filters map[string]string
+	// Preloads are the associations to load, as for CRUDStore.Get
+	Preloads []string
+}
+
 // CRUDStoreInterface defines the interface for CRUD operations
 type CRUDStoreInterface[T any] interface {
-	List(preloads ...string) ([]T, error)
-	Get(id string, preloads ...string) (*T, error)
-	Create(item T) error
-	Update(item T) error
-	Delete(id string) error
+	List(ctx context.Context, opts ListOptions) ([]T, int64, error)
+	Get(ctx context.Context, id string, preloads ...string) (*T, error)
+	Create(ctx context.Context, item T) error
+	Update(ctx context.Context, item T) error
+	Delete(ctx context.Context, id string) error
 }
 
-// CRUDStore provides generic CRUD operations for any model type
+// CRUDStore provides generic CRUD operations for any model type. Its
+// queries run with the context passed to each method, so handlers passing
+// k.Request.Context() stop querying when the client disconnects.
 type CRUDStore[T any] struct {
 	client *gorm.DB
+	// columns are the columns List can sort and filter by
+	columns map[string]bool
 }
 
 // NewCRUDStore creates a new CRUD store for type T
@@ -25,25 +58,109 @@ func NewCRUDStore[T any](client *gorm.DB) *CRUDStore[T] {
 	return &CRUDStore[T]{client: client}
 }
 
-// List retrieves all records with optional preloads
-func (s *CRUDStore[T]) List(preloads ...string) ([]T, error) {
-	query := s.client
-	for _, preload := range preloads {
-		query = query.Preload(preload)
+// WithTx returns a copy of the store that runs its queries in tx, e.g. in
+// a Transaction or k.Tx closure
+func (s *CRUDStore[T]) WithTx(tx *gorm.DB) *CRUDStore[T] {
+	return &CRUDStore[T]{client: tx, columns: s.columns}
+}
+
+// Columns allows List to sort and filter by the named database columns.
+// Other columns in ListOptions are rejected, so clients can't probe
+// columns such as password hashes. It returns s for chaining after
+// NewCRUDStore.
+func (s *CRUDStore[T]) Columns(columns ...string) *CRUDStore[T] {
+	if s.columns == nil {
+		s.columns = map[string]bool{}
+	}
+	for _, column := range columns {
+		s.columns[column] = true
+	}
+	return s
+}
+
+// List retrieves the records matching opts.Filters, ordered by opts.Sort,
+// and the page selected by opts.Page and opts.PerPage. It also returns the
+// number of matching records on every page. Columns not allowed by Columns
+// return ErrDatabaseInvalidColumn. Soft-deleted records are left out; see
+// ListDeleted.
+func (s *CRUDStore[T]) List(ctx context.Context, opts ListOptions) ([]T, int64, error) {
+	return s.list(s.client.WithContext(ctx), opts)
+}
+
+// list runs List's queries on query, ordering by then after opts.Sort
+func (s *CRUDStore[T]) list(query *gorm.DB, opts ListOptions, then ...clause.Expression) ([]T, int64, error) {
+	query = query.Model(new(T))
+
+	filters := make([]string, 0, len(opts.Filters))
+	for column := range opts.Filters {
+		filters = append(filters, column)
+	}
+	sort.Strings(filters)
+	for _, column := range filters {
+		if !s.columns[column] {
+			return nil, 0, errors.ErrDatabaseInvalidColumn.WithValue(column)
+		}
+		query = query.Where(clause.Eq{Column: clause.Column{Name: column}, Value: opts.Filters[column]})
+	}
+
+	var order []clause.OrderByColumn
+	for _, field := range strings.Split(opts.Sort, ",") {
+		field = strings.TrimSpace(field)
+		column := strings.TrimPrefix(field, "-")
+		if column == "" {
+			continue
+		}
+		if !s.columns[column] {
+			return nil, 0, errors.ErrDatabaseInvalidColumn.WithValue(column)
+		}
+		order = append(order, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: field != column})
 	}
 
 	var items []T
-	result := query.Find(&items)
-	if result.Error != nil {
-		return items, errors.ErrDatabaseRead.Wrap(result.Error).WithValue(items)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return items, 0, errors.ErrDatabaseRead.Wrap(err)
+	}
+
+	if len(then) == 0 {
+		for _, o := range order {
+			query = query.Order(o)
+		}
+	} else {
+		// GORM drops the columns of an ORDER BY with an expression, so
+		// the columns go in the expression
+		sql := make([]string, 0, len(order)+len(then))
+		vars := make([]any, 0, len(order)+len(then))
+		for _, o := range order {
+			sql = append(sql, "?")
+			if o.Desc {
+				sql[len(sql)-1] += " DESC"
+			}
+			vars = append(vars, o.Column)
+		}
+		for _, o := range then {
+			sql = append(sql, "?")
+			vars = append(vars, o)
+		}
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(sql, ", "), Vars: vars}})
+	}
+	if opts.PerPage > 0 {
+		page := max(opts.Page, 1)
+		query = query.Limit(opts.PerPage).Offset((page - 1) * opts.PerPage)
+	}
+	for _, preload := range opts.Preloads {
+		query = query.Preload(preload)
 	}
 
-	return items, nil
+	if err := query.Find(&items).Error; err != nil {
+		return items, 0, errors.ErrDatabaseRead.Wrap(err).WithValue(items)
+	}
+	return items, total, nil
 }
 
 // Get retrieves a single record by ID with optional preloads
-func (s *CRUDStore[T]) Get(id string, preloads ...string) (*T, error) {
-	query := s.client
+func (s *CRUDStore[T]) Get(ctx context.Context, id string, preloads ...string) (*T, error) {
+	query := s.client.WithContext(ctx)
 	for _, preload := range preloads {
 		query = query.Preload(preload)
 	}
@@ -59,29 +176,113 @@ func (s *CRUDStore[T]) Get(id string, preloads ...string) (*T, error) {
 	return &item, nil
 }
 
-// Create inserts a new record
-func (s *CRUDStore[T]) Create(item T) error {
-	result := s.client.Create(&item)
+// Create inserts a new record and runs the OnCreate hooks
+func (s *CRUDStore[T]) Create(ctx context.Context, item T) error {
+	result := s.client.WithContext(ctx).Create(&item)
 	if result.Error != nil {
 		return errors.ErrDatabaseWrite.Wrap(result.Error)
 	}
+	fireCreate(ctx, hooksFor[T](hookCreate), item)
 	return nil
 }
 
-// Update saves changes to an existing record
-func (s *CRUDStore[T]) Update(item T) error {
-	result := s.client.Save(&item)
+// Update saves changes to an existing record and runs the OnUpdate hooks.
+// If T has a Version field, the record is only saved if its version still
+// matches, and ErrDatabaseConflict is returned if another update saved it
+// first.
+func (s *CRUDStore[T]) Update(ctx context.Context, item T) error {
+	hooks := hooksFor[T](hookUpdate)
+	var old T
+	if len(hooks) > 0 {
+		var err error
+		if old, err = s.current(ctx, &item); err != nil {
+			return errors.ErrDatabaseUpdate.Wrap(err)
+		}
+	}
+
+	if err := s.update(ctx, &item); err != nil {
+		return err
+	}
+	fireUpdate(ctx, hooks, old, item)
+	return nil
+}
+
+// update saves item, checking its Version field if it has one
+func (s *CRUDStore[T]) update(ctx context.Context, item *T) error {
+	version, err := s.versionField()
+	if err != nil {
+		return errors.ErrDatabaseUpdate.Wrap(err)
+	}
+	if version == nil {
+		result := s.client.WithContext(ctx).Save(item)
+		if result.Error != nil {
+			return errors.ErrDatabaseUpdate.Wrap(result.Error)
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(item).Elem()
+	current, _ := version.ValueOf(ctx, rv)
+	if err := version.Set(ctx, rv, current.(Version)+1); err != nil {
+		return errors.ErrDatabaseUpdate.Wrap(err)
+	}
+
+	result := s.client.WithContext(ctx).Model(item).
+		Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: version.DBName}, Value: current}).
+		Select("*").
+		Updates(item)
 	if result.Error != nil {
 		return errors.ErrDatabaseUpdate.Wrap(result.Error)
 	}
+	if result.RowsAffected == 0 {
+		return errors.ErrDatabaseConflict.WithValue(fmt.Sprintf("version %d", current))
+	}
 	return nil
 }
 
-// Delete soft-deletes a record by ID
-func (s *CRUDStore[T]) Delete(id string) error {
-	result := s.client.Delete(new(T), "id = ?", id)
+// versionType is the type of the field that turns on optimistic locking
+var versionType = reflect.TypeOf(Version(0))
+
+// versionField returns T's Version field, or nil if it has none
+func (s *CRUDStore[T]) versionField() (*schema.Field, error) {
+	stmt := &gorm.Statement{DB: s.client}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	for _, field := range stmt.Schema.Fields {
+		if field.FieldType == versionType {
+			return field, nil
+		}
+	}
+	return nil, nil
+}
+
+// Delete soft-deletes a record by ID, if T embeds BaseModel or
+// SoftDelete, and deletes it otherwise. It runs the OnDelete hooks.
+func (s *CRUDStore[T]) Delete(ctx context.Context, id string) error {
+	return s.delete(ctx, s.client.WithContext(ctx), id)
+}
+
+// delete deletes the record with id in query, running the OnDelete hooks
+// with the record as it was if it existed
+func (s *CRUDStore[T]) delete(ctx context.Context, query *gorm.DB, id string) error {
+	hooks := hooksFor[T](hookDelete)
+	var old T
+	found := false
+	if len(hooks) > 0 {
+		err := query.First(&old, "id = ?", id).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return errors.ErrDatabaseDelete.Wrap(err)
+		}
+		found = err == nil
+	}
+
+	result := query.Delete(new(T), "id = ?", id)
 	if result.Error != nil {
 		return errors.ErrDatabaseDelete.Wrap(result.Error)
 	}
+	if found && result.RowsAffected > 0 {
+		fireDelete(ctx, hooks, old)
+	}
 	return nil
 }
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/config"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+// TestCRUDStore_List tests paging, sorting and filtering by allowed columns
+func TestCRUDStore_List(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&migratorBook{}))
+	books := NewCRUDStore[migratorBook](db).Columns("title", "author_id")
+	ctx := context.Background()
+	for i, title := range []string{"C", "A", "E", "B", "D"} {
+		require.NoError(t, books.Create(ctx, migratorBook{AuthorID: uint(i%2 + 1), Title: title}))
+	}
+
+	titles := func(items []migratorBook) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Title)
+		}
+		return out
+	}
+
+	items, total, err := books.List(ctx, ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, items, 5)
+	assert.Equal(t, int64(5), total)
+
+	items, total, err = books.List(ctx, ListOptions{Page: 2, PerPage: 2, Sort: "title"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"C", "D"}, titles(items))
+	assert.Equal(t, int64(5), total, "the total counts every page")
+
+	items, total, err = books.List(ctx, ListOptions{Sort: "-title", Filters: map[string]string{"author_id": "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"E", "D", "C"}, titles(items))
+	assert.Equal(t, int64(3), total)
+
+	for _, opts := range []ListOptions{
+		{Sort: "id"},
+		{Sort: "title; DROP TABLE migrator_books"},
+		{Filters: map[string]string{"id": "1"}},
+	} {
+		_, _, err = books.List(ctx, opts)
+		assert.ErrorIs(t, err, twineerrors.ErrDatabaseInvalidColumn, "%+v", opts)
+	}
+}
+
+// TestCRUDStore_Context tests that queries run with the caller's context
+func TestCRUDStore_Context(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&migratorBook{}))
+	books := NewCRUDStore[migratorBook](db)
+
+	ctx := context.Background()
+	require.NoError(t, books.Create(ctx, migratorBook{ID: 1, Title: "Notes"}))
+	book, err := books.Get(ctx, "1")
+	require.NoError(t, err)
+	book.Title = "Letters"
+	require.NoError(t, books.Update(ctx, *book))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = books.Get(cancelled, "1")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, books.Create(cancelled, migratorBook{ID: 2}), context.Canceled)
+	assert.ErrorIs(t, books.Delete(cancelled, "1"), context.Canceled)
+
+	require.NoError(t, books.Delete(ctx, "1"))
+	_, err = books.Get(ctx, "1")
+	assert.ErrorIs(t, err, twineerrors.ErrDatabaseObjectNotFound)
+}
+
+type cursorPost struct {
+	BaseModel
+	Title string
+}
+
+// TestCRUDStore_ListAfter tests walking pages with cursors, newest first
+func TestCRUDStore_ListAfter(t *testing.T) {
+	// BaseModel's column default needs the SQLite dialector
+	db, err := Open(config.DatabaseConfig{Driver: config.DriverSQLite, Name: filepath.Join(t.TempDir(), "app.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&migratorBook{}, &cursorPost{}))
+	ctx := context.Background()
+
+	t.Run("created_at and id", func(t *testing.T) {
+		posts := NewCRUDStore[cursorPost](db)
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		// b and c share a timestamp, so the id breaks the tie
+		for i, title := range []string{"a", "b", "c", "d", "e"} {
+			created := start.Add(time.Duration([]int{0, 1, 1, 2, 3}[i]) * time.Minute)
+			require.NoError(t, posts.Create(ctx, cursorPost{BaseModel: BaseModel{CreatedAt: created}, Title: title}))
+		}
+
+		var seen []string
+		cursor := ""
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5)
+			items, next, err := posts.ListAfter(ctx, cursor, 2)
+			require.NoError(t, err)
+			for _, item := range items {
+				seen = append(seen, item.Title)
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		require.Len(t, seen, 5)
+		assert.Equal(t, []string{"e", "d"}, seen[:2])
+		assert.ElementsMatch(t, []string{"b", "c"}, seen[2:4])
+		assert.Equal(t, "a", seen[4])
+	})
+
+	t.Run("primary key only", func(t *testing.T) {
+		books := NewCRUDStore[migratorBook](db)
+		for _, title := range []string{"one", "two", "three"} {
+			require.NoError(t, books.Create(ctx, migratorBook{Title: title}))
+		}
+
+		items, next, err := books.ListAfter(ctx, "", 2)
+		require.NoError(t, err)
+		assert.Equal(t, "three", items[0].Title)
+		require.NotEmpty(t, next)
+
+		items, next, err = books.ListAfter(ctx, next, 2)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "one", items[0].Title)
+		assert.Empty(t, next)
+	})
+
+	t.Run("invalid cursors", func(t *testing.T) {
+		books := NewCRUDStore[migratorBook](db)
+		wrongCount, err := EncodeCursor(1, 2)
+		require.NoError(t, err)
+		wrongType, err := EncodeCursor("one")
+		require.NoError(t, err)
+
+		for _, cursor := range []string{"not base64!", "bm90IGpzb24", wrongCount, wrongType} {
+			_, _, err := books.ListAfter(ctx, cursor, 2)
+			assert.ErrorIs(t, err, twineerrors.ErrDatabaseInvalidCursor, cursor)
+		}
+	})
+}
+
+// TestCursor tests round-tripping cursor values
+func TestCursor(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC)
+	cursor, err := EncodeCursor(at, 42)
+	require.NoError(t, err)
+	assert.NotContains(t, cursor, "=", "cursors are URL-safe")
+
+	var decoded time.Time
+	var id uint
+	require.NoError(t, DecodeCursor(cursor, &decoded, &id))
+	assert.True(t, at.Equal(decoded))
+	assert.Equal(t, uint(42), id)
+}
+
+type versionedPage struct {
+	ID      uint
+	Body    string
+	Version Version
+}
+
+// TestCRUDStore_Update_Version tests optimistic locking on Version fields
+func TestCRUDStore_Update_Version(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&versionedPage{}))
+	pages := NewCRUDStore[versionedPage](db)
+	ctx := context.Background()
+	require.NoError(t, pages.Create(ctx, versionedPage{ID: 1, Body: "draft"}))
+
+	// Two forms load the same version
+	first, err := pages.Get(ctx, "1")
+	require.NoError(t, err)
+	second := *first
+
+	first.Body = "first"
+	require.NoError(t, pages.Update(ctx, *first))
+
+	second.Body = "second"
+	err = pages.Update(ctx, second)
+	assert.ErrorIs(t, err, twineerrors.ErrDatabaseConflict)
+	assert.Equal(t, http.StatusConflict, twineerrors.HTTPStatusOf(err))
+
+	saved, err := pages.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "first", saved.Body, "the stale update isn't saved")
+	assert.Equal(t, Version(1), saved.Version)
+
+	saved.Body = "second"
+	require.NoError(t, pages.Update(ctx, *saved), "updates from the current version succeed")
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// EncodeCursor encodes values, such as the sort keys of the last record on
+// a page, into an opaque URL-safe cursor
+func EncodeCursor(values ...any) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor from EncodeCursor into pointers to values
+// of the encoded types. Malformed cursors return ErrDatabaseInvalidCursor.
+func DecodeCursor(cursor string, values ...any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return errors.ErrDatabaseInvalidCursor.Wrap(err)
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.ErrDatabaseInvalidCursor.Wrap(err)
+	}
+	if len(raw) != len(values) {
+		return errors.ErrDatabaseInvalidCursor.Wrap(fmt.Errorf("cursor has %d values, want %d", len(raw), len(values)))
+	}
+	for i, value := range values {
+		if err := json.Unmarshal(raw[i], value); err != nil {
+			return errors.ErrDatabaseInvalidCursor.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// ListAfter retrieves up to limit records after cursor, newest first, and
+// the cursor for the next page, or "" after the last page. An empty cursor
+// starts at the newest record. Unlike List's offsets, each page is a range
+// scan, so deep pages of large tables stay fast:
+//
+//	posts, next, err := store.ListAfter(k.Request.Context(), k.Request.URL.Query().Get("cursor"), 20)
+//
+// Records are ordered by created_at, then the primary key, or by the
+// primary key alone if the model has no created_at. limit defaults to
+// DefaultPerPage.
+func (s *CRUDStore[T]) ListAfter(ctx context.Context, cursor string, limit int) ([]T, string, error) {
+	if limit <= 0 {
+		limit = DefaultPerPage
+	}
+
+	keys, err := s.cursorKeys()
+	if err != nil {
+		return nil, "", errors.ErrDatabaseRead.Wrap(err)
+	}
+
+	query := s.client.WithContext(ctx).Model(new(T))
+	if cursor != "" {
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = reflect.New(key.FieldType).Interface()
+		}
+		if err := DecodeCursor(cursor, values...); err != nil {
+			return nil, "", err
+		}
+		for i, value := range values {
+			values[i] = reflect.ValueOf(value).Elem().Interface()
+		}
+		query = query.Where(after(keys, values))
+	}
+	for _, key := range keys {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: key.DBName}, Desc: true})
+	}
+
+	var items []T
+	if err := query.Limit(limit + 1).Find(&items).Error; err != nil {
+		return items, "", errors.ErrDatabaseRead.Wrap(err)
+	}
+	if len(items) <= limit {
+		return items, "", nil
+	}
+
+	items = items[:limit]
+	last := reflect.ValueOf(&items[limit-1]).Elem()
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i], _ = key.ValueOf(ctx, last)
+	}
+	next, err := EncodeCursor(values...)
+	if err != nil {
+		return items, "", errors.ErrDatabaseRead.Wrap(err)
+	}
+	return items, next, nil
+}
+
+// cursorKeys returns the fields ListAfter orders by
+func (s *CRUDStore[T]) cursorKeys() ([]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: s.client}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	primary := stmt.Schema.PrioritizedPrimaryField
+	if primary == nil {
+		return nil, fmt.Errorf("%s has no primary key", stmt.Schema.Name)
+	}
+	if created := stmt.Schema.LookUpField("created_at"); created != nil {
+		return []*schema.Field{created, primary}, nil
+	}
+	return []*schema.Field{primary}, nil
+}
+
+// after matches the records that sort after values in ListAfter's
+// descending order of keys
+func after(keys []*schema.Field, values []any) clause.Expression {
+	var or []clause.Expression
+	for i := range keys {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Name: keys[j].DBName}, Value: values[j]})
+		}
+		and = append(and, clause.Lt{Column: clause.Column{Name: keys[i].DBName}, Value: values[i]})
+		or = append(or, clause.And(and...))
+	}
+	return clause.Or(or...)
+}
@@ -1,9 +1,10 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"sync"
 
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"github.com/cstone-io/twine/pkg/config"
@@ -20,9 +21,11 @@ type Database struct {
 	migrations []*Migration
 }
 
-// Get returns the singleton database instance
+// Get returns the singleton database instance, connecting with the DB_*
+// settings the first time, so config.Validate checks them from then on
 func Get() *Database {
 	if instance == nil {
+		config.Require(config.FeatureDatabase)
 		cfg := config.Get().Database
 		initialize(cfg)
 	}
@@ -39,20 +42,160 @@ func GORM() *gorm.DB {
 	return Get().client
 }
 
+// Open connects to the database without running migrations, with the
+// dialector for cfg.Driver and the configured connection pool limits
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	client, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enable the UUID extension
+	if client.Dialector.Name() == "postgres" {
+		client.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";")
+	}
+
+	return client, nil
+}
+
+// open connects to the database in cfg without changing it
+func open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := gorm.Open(dialector, &gorm.Config{
+		Logger: NewGORMLogger(logger.Get(), gormLoggerOptions(cfg)...),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := client.DB()
+	if err != nil {
+		return nil, err
+	}
+	configurePool(sqlDB, cfg)
+
+	return client, nil
+}
+
+// configurePool applies the pool limits set in cfg, keeping database/sql's
+// defaults for the others
+func configurePool(sqlDB *sql.DB, cfg config.DatabaseConfig) {
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+}
+
+// New connects to the database in cfg and applies the registered migrations.
+// Unlike Get, it doesn't touch the singleton, so each Config gets its own
+// Database.
+func New(cfg *config.Config) (*Database, error) {
+	client, err := Open(cfg.Database)
+	if err != nil {
+		return nil, errors.ErrDatabaseConn.Wrap(err)
+	}
+
+	d := &Database{
+		client:     client,
+		migrations: migrations,
+	}
+	if err := d.migrate(); err != nil {
+		return nil, errors.ErrDatabaseMigration.Wrap(err)
+	}
+
+	return d, nil
+}
+
+// GORM returns the database's GORM client
+func (d *Database) GORM() *gorm.DB {
+	return d.client
+}
+
+// Ping checks that the database accepts connections
+func (d *Database) Ping(ctx context.Context) error {
+	sqlDB, err := d.client.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Stats returns the connection pool statistics
+func (d *Database) Stats() sql.DBStats {
+	sqlDB, err := d.client.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+// Close closes the database's connection pool
+func (d *Database) Close() error {
+	sqlDB, err := d.client.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Ping checks that the database returned by Get accepts connections
+func Ping(ctx context.Context) error {
+	d := Get()
+	if d == nil {
+		return errors.ErrDatabaseConn
+	}
+	return d.Ping(ctx)
+}
+
+// Use makes client the database returned by Get and GORM instead of
+// connecting with config.Get, and applies the registered migrations to it.
+// It is meant for tests and for applications that open their own connection.
+func Use(client *gorm.DB) error {
+	instance = &Database{
+		client:     client,
+		migrations: migrations,
+	}
+
+	return instance.migrate()
+}
+
+// Reset forgets the current database, so the next Get connects again
+func Reset() {
+	instance = nil
+}
+
+// Close closes the database returned by Get, if it has connected, and
+// forgets it. Register it as a shutdown hook:
+//
+//	srv.OnShutdown("database", func(context.Context) error {
+//	    return database.Close()
+//	})
+func Close() error {
+	d := instance
+	Reset()
+	if d == nil {
+		return nil
+	}
+	return d.Close()
+}
+
 func initialize(cfg config.DatabaseConfig) *Database {
 	log := logger.Get()
 
-	dsn := cfg.DSN()
-
-	client, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	client, err := Open(cfg)
 	if err != nil {
 		log.CustomError(errors.ErrDatabaseConn.Wrap(err))
 		return nil
 	}
 
-	// Enable the UUID extension
-	client.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";")
-
 	instance = &Database{
 		client:     client,
 		migrations: migrations,
@@ -65,54 +208,29 @@ func initialize(cfg config.DatabaseConfig) *Database {
 	return instance
 }
 
-// RegisterMigration adds a migration to the database
+// RegisterMigration adds a migration to the database. Applications
+// registering migrations connect with the DB_* settings, so config.Validate
+// checks them.
 func RegisterMigration(m *Migration) {
+	config.Require(config.FeatureDatabase)
 	migrations = append(migrations, m)
 }
 
-// RegisterMigrations adds multiple migrations to the database
+// RegisterMigrations adds multiple migrations to the database, as
+// RegisterMigration does
 func RegisterMigrations(ms ...*Migration) {
+	config.Require(config.FeatureDatabase)
 	migrations = append(migrations, ms...)
 }
 
+// migrate applies pending migrations at startup
 func (d *Database) migrate() error {
-	sorted := []*Migration{}
-	visited := make(map[string]bool)
-
-	var visit func(*Migration) error
-	visit = func(m *Migration) error {
-		if visited[m.Name] {
-			return nil
-		}
-
-		visited[m.Name] = true
-
-		for _, dep := range m.Deps {
-			if err := visit(dep); err != nil {
-				return errors.ErrSortMigrations.Wrap(err).WithValue("dependency " + dep.Name + " of model " + m.Name)
-			}
-		}
-
-		sorted = append(sorted, m)
-		return nil
-	}
-
-	for _, migration := range d.migrations {
-		if err := visit(migration); err != nil {
-			return err
-		}
-	}
-
-	d.migrations = sorted
-
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	for _, m := range d.migrations {
-		if err := d.client.AutoMigrate(m.Model); err != nil {
-			return errors.ErrMigrateTable.Wrap(err).WithValue("model " + m.Name)
-		}
-		logger.Get().Debug("Migrated table: %s", m.Name)
+	applied, err := NewMigrator(d.client, d.migrations...).Migrate()
+	for _, name := range applied {
+		logger.Get().Debug("Applied migration: %s", name)
 	}
-	return nil
+	return err
 }
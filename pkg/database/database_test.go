@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// TestUse tests replacing the database and applying registered migrations
+func TestUse(t *testing.T) {
+	registered := migrations
+	t.Cleanup(func() {
+		migrations = registered
+		Reset()
+	})
+	migrations = nil
+	RegisterMigrations(testMigrations()...)
+
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, Use(db))
+	assert.Same(t, db, GORM())
+
+	var count int64
+	require.NoError(t, db.Model(&migratorAuthor{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	Reset()
+	assert.Nil(t, instance)
+}
+
+// TestClose tests closing and forgetting the database
+func TestClose(t *testing.T) {
+	t.Cleanup(Reset)
+
+	assert.NoError(t, Close(), "nothing to close before connecting")
+
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, Use(db))
+	require.NoError(t, Close())
+	assert.Nil(t, instance)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	assert.Error(t, sqlDB.Ping())
+}
+
+// TestNew_ConnectionError tests that New returns connection errors instead
+// of logging them
+func TestNew_ConnectionError(t *testing.T) {
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Host:     "127.0.0.1",
+		Port:     1,
+		Username: "postgres",
+		Name:     "app",
+		SSLMode:  "disable",
+		TimeZone: "UTC",
+	}}
+
+	db, err := New(cfg)
+	require.Error(t, err)
+	assert.Nil(t, db)
+	assert.Nil(t, instance)
+}
+
+// TestNew_SQLite tests connecting with DB_DRIVER=sqlite
+func TestNew_SQLite(t *testing.T) {
+	registered := migrations
+	t.Cleanup(func() { migrations = registered })
+	migrations = nil
+	RegisterMigrations(testMigrations()...)
+
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		Name:   filepath.Join(t.TempDir(), "app.db"),
+	}}
+
+	db, err := New(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", db.GORM().Dialector.Name())
+
+	var count int64
+	require.NoError(t, db.GORM().Model(&migratorAuthor{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestNew_Driver tests the errors for drivers that aren't registered
+func TestNew_Driver(t *testing.T) {
+	_, err := New(&config.Config{Database: config.DatabaseConfig{Driver: config.DriverMySQL}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database.RegisterDriver(config.DriverMySQL, mysql.Open)")
+
+	_, err = New(&config.Config{Database: config.DatabaseConfig{Driver: "oracle"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown DB_DRIVER "oracle"`)
+}
+
+// TestOpen_Pool tests applying the connection pool limits
+func TestOpen_Pool(t *testing.T) {
+	client, err := Open(config.DatabaseConfig{
+		Driver:          config.DriverSQLite,
+		Name:            filepath.Join(t.TempDir(), "app.db"),
+		MaxOpenConns:    3,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: time.Minute,
+	})
+	require.NoError(t, err)
+
+	d := &Database{client: client}
+	require.NoError(t, d.Ping(context.Background()))
+	assert.Equal(t, 3, d.Stats().MaxOpenConnections)
+}
+
+// TestHealth tests reporting the status and pool statistics
+func TestHealth(t *testing.T) {
+	d := &Database{client: testutil.SetupTestDB(t)}
+
+	health, err := d.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ok", health.Status)
+	assert.Positive(t, health.Pool.Open)
+
+	sqlDB, err := d.client.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	health, err = d.Health(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, "unavailable", health.Status)
+}
+
+// TestMigrated tests reporting pending migrations
+func TestMigrated(t *testing.T) {
+	ms := testMigrations()
+	authors := ms[2]
+	d := &Database{client: testutil.SetupTestDB(t), migrations: []*Migration{authors}}
+	require.NoError(t, d.migrate())
+	assert.NoError(t, d.Migrated(context.Background()))
+
+	d.migrations = ms
+	err := d.Migrated(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pending migrations: 003_seed_authors, 002_create_books")
+	assert.NotContains(t, err.Error(), authors.Name)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+var (
+	driversMutex sync.RWMutex
+	drivers      = map[string]func(dsn string) gorm.Dialector{
+		config.DriverPostgres: postgres.Open,
+		config.DriverSQLite:   SQLite,
+	}
+)
+
+// RegisterDriver makes open the dialector for DB_DRIVER=name. Postgres and
+// SQLite are built in; MySQL isn't, to keep its driver out of applications
+// that don't use it. Register it before connecting:
+//
+//	database.RegisterDriver(config.DriverMySQL, mysql.Open) // gorm.io/driver/mysql
+func RegisterDriver(name string, open func(dsn string) gorm.Dialector) {
+	driversMutex.Lock()
+	defer driversMutex.Unlock()
+	drivers[name] = open
+}
+
+// dialector returns the dialector for cfg's driver and DSN
+func dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	name := cfg.Driver
+	if name == "" {
+		name = config.DriverPostgres
+	}
+
+	driversMutex.RLock()
+	open, ok := drivers[name]
+	driversMutex.RUnlock()
+	if !ok {
+		if name == config.DriverMySQL {
+			return nil, fmt.Errorf("DB_DRIVER=mysql needs database.RegisterDriver(config.DriverMySQL, mysql.Open) from gorm.io/driver/mysql")
+		}
+		return nil, fmt.Errorf("unknown DB_DRIVER %q", name)
+	}
+	return open(cfg.DSN()), nil
+}
+
+// sqliteDialector is the SQLite dialector with a migrator that tolerates
+// Postgres column defaults
+type sqliteDialector struct {
+	*sqlite.Dialector
+}
+
+// SQLite returns the SQLite dialector for dsn, a file path or SQLite URI.
+// Its migrator leaves out column defaults SQLite can't parse, so models
+// embedding BaseModel migrate on both SQLite and Postgres.
+func SQLite(dsn string) gorm.Dialector {
+	return sqliteDialector{sqlite.Open(dsn).(*sqlite.Dialector)}
+}
+
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{d.Dialector.Migrator(db).(sqlite.Migrator)}
+}
+
+type sqliteMigrator struct {
+	sqlite.Migrator
+}
+
+// FullDataTypeOf drops function defaults such as BaseModel's
+// uuid_generate_v4(), which SQLite can't parse. Models set those values in
+// their hooks anyway.
+func (m sqliteMigrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	if strings.HasSuffix(strings.TrimSpace(field.DefaultValue), ")") {
+		field = cloneField(field)
+		field.DefaultValue = ""
+		field.HasDefaultValue = false
+	}
+	return m.Migrator.FullDataTypeOf(field)
+}
+
+func cloneField(field *schema.Field) *schema.Field {
+	clone := *field
+	return &clone
+}
@@ -0,0 +1,98 @@
+package factory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+type author struct {
+	ID    uint
+	Email string
+}
+
+type post struct {
+	ID        uint
+	Title     string
+	Published bool
+	AuthorID  uint
+}
+
+func testFactories() (*Factory[author], *Factory[post]) {
+	authors := New(func(seq int) author {
+		return author{Email: fmt.Sprintf("author%d@example.com", seq)}
+	})
+	posts := New(func(seq int) post {
+		return post{Title: fmt.Sprintf("Post %d", seq)}
+	}).Associate(func(tb testing.TB, db *gorm.DB, p *post) {
+		p.AuthorID = authors.Create(tb, db).ID
+	})
+	return authors, posts
+}
+
+// TestFactory_Build tests defaults, sequences and overrides
+func TestFactory_Build(t *testing.T) {
+	authors, _ := testFactories()
+
+	assert.Equal(t, author{Email: "author1@example.com"}, authors.Build())
+	assert.Equal(t, author{Email: "custom@example.com"}, authors.Build(func(a *author) { a.Email = "custom@example.com" }))
+
+	list := authors.BuildList(2)
+	assert.Equal(t, "author3@example.com", list[0].Email)
+	assert.Equal(t, "author4@example.com", list[1].Email)
+
+	authors.Reset()
+	assert.Equal(t, "author1@example.com", authors.Build().Email)
+}
+
+// TestFactory_Create tests saving records with their associations
+func TestFactory_Create(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&author{}, &post{}))
+	authors, posts := testFactories()
+
+	p := posts.Create(t, db, func(p *post) { p.Published = true })
+	assert.NotZero(t, p.ID)
+	assert.True(t, p.Published)
+	assert.NotZero(t, p.AuthorID)
+
+	var saved author
+	require.NoError(t, db.First(&saved, p.AuthorID).Error)
+	assert.Equal(t, "author1@example.com", saved.Email)
+
+	list := posts.CreateList(t, db, 2)
+	assert.Len(t, list, 2)
+	assert.NotEqual(t, list[0].AuthorID, list[1].AuthorID)
+
+	var count int64
+	db.Model(&author{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, "author4@example.com", authors.Build().Email)
+}
+
+// TestWithRollback tests that records created in a test are rolled back
+func TestWithRollback(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&author{}, &post{}))
+	_, posts := testFactories()
+
+	t.Run("isolated", func(t *testing.T) {
+		tx := WithRollback(t, db)
+		posts.CreateList(t, tx, 2)
+
+		var count int64
+		tx.Model(&post{}).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	var count int64
+	require.NoError(t, db.Model(&post{}).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&author{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
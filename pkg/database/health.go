@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// HealthTimeout bounds the ping made by Health
+const HealthTimeout = 2 * time.Second
+
+// Health is the status of a database and its connection pool, the JSON
+// body written by kit.DatabaseHealthz
+type Health struct {
+	Status string    `json:"status"` // "ok" or "unavailable"
+	Pool   PoolStats `json:"pool"`
+}
+
+// PoolStats are the connection pool statistics reported by Health
+type PoolStats struct {
+	MaxOpen      int   `json:"max_open"`
+	Open         int   `json:"open"`
+	InUse        int   `json:"in_use"`
+	Idle         int   `json:"idle"`
+	WaitCount    int64 `json:"wait_count"`
+	WaitDuration int64 `json:"wait_duration_ms"`
+}
+
+// Health pings d, within HealthTimeout, and returns its status with the
+// pool statistics. If the ping fails, the status is "unavailable" and the
+// ping's error is returned with it.
+func (d *Database) Health(ctx context.Context) (Health, error) {
+	ctx, cancel := context.WithTimeout(ctx, HealthTimeout)
+	defer cancel()
+
+	stats := d.Stats()
+	health := Health{
+		Status: "ok",
+		Pool: PoolStats{
+			MaxOpen:      stats.MaxOpenConnections,
+			Open:         stats.OpenConnections,
+			InUse:        stats.InUse,
+			Idle:         stats.Idle,
+			WaitCount:    stats.WaitCount,
+			WaitDuration: stats.WaitDuration.Milliseconds(),
+		},
+	}
+
+	if err := d.Ping(ctx); err != nil {
+		health.Status = "unavailable"
+		return health, err
+	}
+	return health, nil
+}
+
+// Migrated returns an error naming the registered migrations not yet
+// applied to the database returned by Get
+func Migrated(ctx context.Context) error {
+	d := Get()
+	if d == nil {
+		return errors.ErrDatabaseConn
+	}
+	return d.Migrated(ctx)
+}
+
+// Migrated returns an error naming d's migrations not yet applied
+func (d *Database) Migrated(ctx context.Context) error {
+	names := make([]string, len(d.migrations))
+	for i, m := range d.migrations {
+		names[i] = m.Name
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	var applied []string
+	if err := d.client.WithContext(ctx).Model(&SchemaMigration{}).Where("name IN ?", names).Pluck("name", &applied).Error; err != nil {
+		return err
+	}
+	pending := slices.DeleteFunc(names, func(name string) bool { return slices.Contains(applied, name) })
+	if len(pending) > 0 {
+		return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// hookKind is the store operation a hook runs after
+type hookKind int
+
+const (
+	hookCreate hookKind = iota
+	hookUpdate
+	hookDelete
+)
+
+type hookKey struct {
+	model reflect.Type
+	kind  hookKind
+}
+
+type hook struct {
+	id int
+	fn any
+}
+
+var (
+	modelHooksMutex sync.RWMutex
+	modelHooks      = map[hookKey][]hook{}
+	nextModelHook   int
+)
+
+// OnCreate calls fn after a CRUDStore[T] creates a record, with the record
+// as saved. Hooks run in registration order on the calling goroutine, e.g.
+// to invalidate caches or write audit trails. In a transaction they run
+// before it commits. It returns a function that removes the hook.
+func OnCreate[T any](fn func(ctx context.Context, created T)) (remove func()) {
+	return addHook[T](hookCreate, fn)
+}
+
+// OnUpdate calls fn after a CRUDStore[T] updates a record, with the record
+// before and after the update, as OnCreate does
+func OnUpdate[T any](fn func(ctx context.Context, old, updated T)) (remove func()) {
+	return addHook[T](hookUpdate, fn)
+}
+
+// OnDelete calls fn after a CRUDStore[T] deletes, soft or force deletes a
+// record, with the record before it was deleted, as OnCreate does
+func OnDelete[T any](fn func(ctx context.Context, deleted T)) (remove func()) {
+	return addHook[T](hookDelete, fn)
+}
+
+func addHook[T any](kind hookKind, fn any) func() {
+	modelHooksMutex.Lock()
+	defer modelHooksMutex.Unlock()
+
+	key := hookKey{reflect.TypeFor[T](), kind}
+	id := nextModelHook
+	nextModelHook++
+	modelHooks[key] = append(modelHooks[key], hook{id: id, fn: fn})
+
+	return func() {
+		modelHooksMutex.Lock()
+		defer modelHooksMutex.Unlock()
+		hooks := modelHooks[key]
+		for i, h := range hooks {
+			if h.id == id {
+				modelHooks[key] = append(hooks[:i:i], hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// hooksFor returns T's hooks of kind
+func hooksFor[T any](kind hookKind) []any {
+	modelHooksMutex.RLock()
+	defer modelHooksMutex.RUnlock()
+
+	hooks := modelHooks[hookKey{reflect.TypeFor[T](), kind}]
+	fns := make([]any, len(hooks))
+	for i, h := range hooks {
+		fns[i] = h.fn
+	}
+	return fns
+}
+
+func fireCreate[T any](ctx context.Context, fns []any, created T) {
+	for _, fn := range fns {
+		fn.(func(context.Context, T))(ctx, created)
+	}
+}
+
+func fireUpdate[T any](ctx context.Context, fns []any, old, updated T) {
+	for _, fn := range fns {
+		fn.(func(context.Context, T, T))(ctx, old, updated)
+	}
+}
+
+func fireDelete[T any](ctx context.Context, fns []any, deleted T) {
+	for _, fn := range fns {
+		fn.(func(context.Context, T))(ctx, deleted)
+	}
+}
+
+// current loads the stored version of item by its primary key, for the
+// update hooks' old value
+func (s *CRUDStore[T]) current(ctx context.Context, item *T) (T, error) {
+	var old T
+	stmt := &gorm.Statement{DB: s.client}
+	if err := stmt.Parse(item); err != nil {
+		return old, err
+	}
+	primary := stmt.Schema.PrioritizedPrimaryField
+	if primary == nil {
+		return old, gorm.ErrPrimaryKeyRequired
+	}
+
+	id, _ := primary.ValueOf(ctx, reflect.ValueOf(item).Elem())
+	err := s.client.WithContext(ctx).
+		Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: primary.DBName}, Value: id}).
+		First(&old).Error
+	return old, err
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// TestModelHooks tests the hooks run after store operations
+func TestModelHooks(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&softNote{}, &migratorBook{}))
+	notes := NewCRUDStore[softNote](db)
+	ctx := context.Background()
+
+	var events []string
+	removeCreate := OnCreate(func(_ context.Context, created softNote) {
+		events = append(events, "create "+created.Body)
+	})
+	defer removeCreate()
+	defer OnUpdate(func(_ context.Context, old, updated softNote) {
+		events = append(events, "update "+old.Body+" -> "+updated.Body)
+	})()
+	defer OnDelete(func(_ context.Context, deleted softNote) {
+		events = append(events, "delete "+deleted.Body)
+	})()
+	defer OnCreate(func(_ context.Context, created migratorBook) {
+		events = append(events, "other model")
+	})()
+
+	require.NoError(t, notes.Create(ctx, softNote{ID: 1, Body: "draft"}))
+	require.NoError(t, notes.Update(ctx, softNote{ID: 1, Body: "final"}))
+	require.NoError(t, notes.Delete(ctx, "1"))
+	require.NoError(t, notes.ForceDelete(ctx, "1"))
+	require.NoError(t, notes.Delete(ctx, "404"))
+
+	assert.Equal(t, []string{
+		"create draft",
+		"update draft -> final",
+		"delete final",
+		"delete final",
+	}, events, "hooks get old and new values, and only run for their model")
+
+	t.Run("failed operations", func(t *testing.T) {
+		events = nil
+		require.NoError(t, notes.Create(ctx, softNote{ID: 3, Body: "x"}))
+		assert.Error(t, notes.Create(ctx, softNote{ID: 3, Body: "duplicate"}))
+		assert.Equal(t, []string{"create x"}, events)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		events = nil
+		removeCreate()
+		require.NoError(t, notes.Create(ctx, softNote{ID: 4, Body: "quiet"}))
+		assert.Empty(t, events)
+	})
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// gormLogger writes GORM's messages and queries through a twine logger, so
+// they share its level, format and outputs. Failed queries are logged as
+// errors, slow queries as warnings and the rest at trace level.
+type gormLogger struct {
+	log   *logger.Logger
+	level gormlogger.LogLevel
+	// slow is the slow query threshold; 0 means no query is slow
+	slow time.Duration
+	// params keeps query parameters in logged SQL
+	params bool
+}
+
+// GORMLoggerOption configures a logger returned by NewGORMLogger
+type GORMLoggerOption func(*gormLogger)
+
+// WithSlowQueryThreshold logs queries that run longer than d as warnings,
+// instead of the 200ms default. 0 turns slow query warnings off.
+func WithSlowQueryThreshold(d time.Duration) GORMLoggerOption {
+	return func(g *gormLogger) {
+		g.slow = d
+	}
+}
+
+// WithQueryParams writes query parameters into logged SQL. By default they
+// are left as placeholders, so passwords, tokens and personal data stay out
+// of the logs. GORM's DB.Scan formats its SQL without the logger, so its
+// parameters are logged either way.
+func WithQueryParams() GORMLoggerOption {
+	return func(g *gormLogger) {
+		g.params = true
+	}
+}
+
+// gormLoggerOptions returns the options for the DB_SLOW_QUERY_THRESHOLD and
+// DB_LOG_PARAMS settings in cfg
+func gormLoggerOptions(cfg config.DatabaseConfig) []GORMLoggerOption {
+	opts := []GORMLoggerOption{WithSlowQueryThreshold(cfg.SlowQueryThreshold)}
+	if cfg.LogParams {
+		opts = append(opts, WithQueryParams())
+	}
+	return opts
+}
+
+// NewGORMLogger returns a GORM logger that writes through log. Open uses it
+// with the default logger and the DB_* settings; pass it in gorm.Config for
+// clients opened elsewhere.
+func NewGORMLogger(log *logger.Logger, opts ...GORMLoggerOption) gormlogger.Interface {
+	g := &gormLogger{
+		log:   log.With("component", "gorm"),
+		level: gormlogger.Info,
+		slow:  200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+func (g *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	next := *g
+	next.level = level
+	return &next
+}
+
+// ParamsFilter leaves the parameters out of logged SQL unless
+// WithQueryParams was given. GORM calls it before formatting each query.
+func (g *gormLogger) ParamsFilter(_ context.Context, sql string, params ...any) (string, []any) {
+	if g.params {
+		return sql, params
+	}
+	return sql, nil
+}
+
+func (g *gormLogger) Info(_ context.Context, msg string, data ...any) {
+	if g.level >= gormlogger.Info {
+		g.log.Info("%s", fmt.Sprintf(msg, data...))
+	}
+}
+
+func (g *gormLogger) Warn(_ context.Context, msg string, data ...any) {
+	if g.level >= gormlogger.Warn {
+		g.log.Warn("%s", fmt.Sprintf(msg, data...))
+	}
+}
+
+func (g *gormLogger) Error(_ context.Context, msg string, data ...any) {
+	if g.level >= gormlogger.Error {
+		g.log.Error("%s", fmt.Sprintf(msg, data...))
+	}
+}
+
+func (g *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+	log := g.log.With("sql", sql, "rows", rows, "dur_ms", elapsed.Milliseconds())
+
+	switch {
+	case err != nil && !errors.Is(err, gormlogger.ErrRecordNotFound) && g.level >= gormlogger.Error:
+		log.With("err", err).Error("query failed")
+	case g.slow > 0 && elapsed > g.slow && g.level >= gormlogger.Warn:
+		log.With("slow", true, "threshold_ms", g.slow.Milliseconds()).Warn("slow query")
+	case g.level >= gormlogger.Info:
+		log.Trace("query")
+	}
+}
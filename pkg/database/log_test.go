@@ -0,0 +1,106 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// TestGORMLogger tests writing GORM's queries through the logger
+func TestGORMLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewGORMLogger(logger.New(config.LoggerConfig{Level: config.LogTrace, Output: &buf, ErrorOutput: &buf}))
+	ctx := context.Background()
+	query := func() (string, int64) { return "SELECT * FROM users", 3 }
+
+	log.Trace(ctx, time.Now(), query, nil)
+	log.Trace(ctx, time.Now().Add(-time.Second), query, nil)
+	log.Trace(ctx, time.Now(), query, assert.AnError)
+	log.Trace(ctx, time.Now(), query, gormlogger.ErrRecordNotFound)
+	log.Warn(ctx, "deprecated %s", "option")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 5) {
+		assert.Contains(t, lines[0], `TRACE: `)
+		assert.Contains(t, lines[0], `query component=gorm sql="SELECT * FROM users" rows=3`)
+		assert.Contains(t, lines[1], `WARN: `)
+		assert.Contains(t, lines[1], `slow query`)
+		assert.Contains(t, lines[1], `slow=true threshold_ms=200`)
+		assert.Contains(t, lines[2], `ERROR: `)
+		assert.Contains(t, lines[2], `query failed`)
+		assert.Contains(t, lines[3], `TRACE: `, "missing records aren't errors")
+		assert.Contains(t, lines[4], `deprecated option component=gorm`)
+	}
+
+	t.Run("log mode", func(t *testing.T) {
+		buf.Reset()
+		quiet := log.LogMode(gormlogger.Error)
+		quiet.Trace(ctx, time.Now().Add(-time.Second), query, nil)
+		quiet.Warn(ctx, "ignored")
+		assert.Empty(t, buf.String())
+
+		log.LogMode(gormlogger.Silent).Trace(ctx, time.Now(), query, assert.AnError)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("slow query threshold", func(t *testing.T) {
+		buf.Reset()
+		out := logger.New(config.LoggerConfig{Level: config.LogWarn, Output: &buf, ErrorOutput: &buf})
+		NewGORMLogger(out, WithSlowQueryThreshold(2*time.Second)).Trace(ctx, time.Now().Add(-time.Second), query, nil)
+		NewGORMLogger(out, WithSlowQueryThreshold(0)).Trace(ctx, time.Now().Add(-time.Hour), query, nil)
+		assert.Empty(t, buf.String())
+
+		NewGORMLogger(out, WithSlowQueryThreshold(time.Millisecond)).Trace(ctx, time.Now().Add(-time.Second), query, nil)
+		assert.Contains(t, buf.String(), `threshold_ms=1`)
+	})
+}
+
+// TestGORMLogger_Params tests leaving query parameters out of logged SQL
+func TestGORMLogger_Params(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	tests := []struct {
+		name     string
+		opts     []GORMLoggerOption
+		expected string
+		hidden   string
+	}{
+		{
+			name:     "redacted by default",
+			expected: `sql="SELECT ? AS password"`,
+			hidden:   "hunter2",
+		},
+		{
+			name:     "logged with WithQueryParams",
+			opts:     []GORMLoggerOption{WithQueryParams()},
+			expected: `sql="SELECT \"hunter2\" AS password"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := NewGORMLogger(logger.New(config.LoggerConfig{Level: config.LogTrace, Output: &buf, ErrorOutput: &buf}), tt.opts...)
+
+			var password string
+			err := db.Session(&gorm.Session{Logger: log}).Raw("SELECT ? AS password", "hunter2").Row().Scan(&password)
+			require.NoError(t, err)
+
+			assert.Contains(t, buf.String(), tt.expected)
+			if tt.hidden != "" {
+				assert.NotContains(t, buf.String(), tt.hidden)
+			}
+		})
+	}
+}
@@ -1,13 +1,27 @@
 package database
 
+import "gorm.io/gorm"
+
 // migrations holds all registered migrations
 var migrations = []*Migration{}
 
-// Migration represents a database table migration with dependencies
+// Migration represents a database table migration with dependencies.
+// Model is auto-migrated first, then Up runs for changes AutoMigrate cannot
+// express, such as data backfills or dropping columns. Down reverts both
+// and is required to roll the migration back.
 type Migration struct {
 	Model interface{}
 	Name  string
 	Deps  []*Migration
+	Up    func(tx *gorm.DB) error
+	Down  func(tx *gorm.DB) error
+
+	// sql is a SQL migration's up statements, shown in plans
+	sql string
+	// depNames are dependencies named by SQL migrations' deps directive
+	depNames []string
+	// noTransaction runs the migration outside a transaction
+	noTransaction bool
 }
 
 // MigrationBuilder provides a fluent interface for building migrations
@@ -15,6 +29,8 @@ type MigrationBuilder struct {
 	model interface{}
 	name  string
 	deps  []*Migration
+	up    func(tx *gorm.DB) error
+	down  func(tx *gorm.DB) error
 }
 
 // NewMigrationBuilder creates a new MigrationBuilder instance
@@ -40,11 +56,25 @@ func (b *MigrationBuilder) Deps(deps ...*Migration) *MigrationBuilder {
 	return b
 }
 
+// Up sets a function to run after the model is migrated
+func (b *MigrationBuilder) Up(fn func(tx *gorm.DB) error) *MigrationBuilder {
+	b.up = fn
+	return b
+}
+
+// Down sets a function that reverts this migration
+func (b *MigrationBuilder) Down(fn func(tx *gorm.DB) error) *MigrationBuilder {
+	b.down = fn
+	return b
+}
+
 // Build constructs the final Migration
 func (b *MigrationBuilder) Build() *Migration {
 	return &Migration{
 		Model: b.model,
 		Name:  b.name,
 		Deps:  b.deps,
+		Up:    b.up,
+		Down:  b.down,
 	}
 }
@@ -4,6 +4,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/internal/testutil"
 )
 
 // TestMigration_NewMigrationBuilder tests the migration builder
@@ -451,3 +455,56 @@ func TestMigration_GlobalState(t *testing.T) {
 		assert.Len(t, migrations, 5)
 	})
 }
+
+// TestMigration_Up tests migrations with an Up function
+func TestMigration_Up(t *testing.T) {
+	type Tag struct {
+		ID   uint
+		Name string
+	}
+
+	t.Run("builder sets up function", func(t *testing.T) {
+		migration := NewMigrationBuilder().
+			Name("seed_tags").
+			Up(func(tx *gorm.DB) error { return nil }).
+			Build()
+
+		assert.Nil(t, migration.Model)
+		assert.NotNil(t, migration.Up)
+	})
+
+	t.Run("runs up after dependencies are migrated", func(t *testing.T) {
+		create := NewMigrationBuilder().
+			Model(&Tag{}).
+			Name("create_tags").
+			Build()
+
+		seed := NewMigrationBuilder().
+			Name("seed_tags").
+			Deps(create).
+			Up(func(tx *gorm.DB) error {
+				return tx.Create(&Tag{Name: "go"}).Error
+			}).
+			Build()
+
+		db := &Database{
+			client:     testutil.SetupTestDB(t),
+			migrations: []*Migration{seed},
+		}
+		require.NoError(t, db.migrate())
+
+		testutil.AssertRecordCount(t, db.client, &Tag{}, 1, "name = ?", "go")
+	})
+
+	t.Run("returns up errors", func(t *testing.T) {
+		db := &Database{
+			client: testutil.SetupTestDB(t),
+			migrations: []*Migration{NewMigrationBuilder().
+				Name("broken").
+				Up(func(tx *gorm.DB) error { return tx.Exec("SELECT * FROM missing").Error }).
+				Build()},
+		}
+
+		assert.Error(t, db.migrate())
+	})
+}
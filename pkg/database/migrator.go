@@ -0,0 +1,281 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// SchemaMigration records an applied migration in the schema_migrations table
+type SchemaMigration struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex;not null"`
+	Batch     int    `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+// TableName sets the table used to track applied migrations
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Name      string
+	Applied   bool
+	Batch     int
+	AppliedAt time.Time
+	Missing   bool // Applied but no longer registered
+}
+
+// Migrator applies and rolls back migrations, recording each one in the
+// schema_migrations table so it runs exactly once
+type Migrator struct {
+	client     *gorm.DB
+	migrations []*Migration
+}
+
+// NewMigrator creates a migrator for the given migrations, or for all
+// registered migrations if none are given
+func NewMigrator(client *gorm.DB, ms ...*Migration) *Migrator {
+	if len(ms) == 0 {
+		ms = migrations
+	}
+	return &Migrator{client: client, migrations: ms}
+}
+
+// Migrate applies every pending migration in version and dependency order
+// and returns their names. Each migration runs in its own transaction,
+// unless it is a SQL migration marked no-transaction; all migrations
+// applied by one call share a batch number.
+func (m *Migrator) Migrate() ([]string, error) {
+	sorted, err := sortMigrations(m.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool, len(applied))
+	batch := 0
+	for _, record := range applied {
+		done[record.Name] = true
+		if record.Batch > batch {
+			batch = record.Batch
+		}
+	}
+	batch++
+
+	names := make([]string, 0)
+	for _, migration := range sorted {
+		if done[migration.Name] {
+			continue
+		}
+
+		apply := func(tx *gorm.DB) error {
+			if migration.Model != nil {
+				if err := tx.AutoMigrate(migration.Model); err != nil {
+					return err
+				}
+			}
+			if migration.Up != nil {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Create(&SchemaMigration{Name: migration.Name, Batch: batch, AppliedAt: time.Now()}).Error
+		}
+
+		var err error
+		if migration.noTransaction {
+			err = apply(m.client)
+		} else {
+			err = m.client.Transaction(apply)
+		}
+		if err != nil {
+			return names, errors.ErrMigrateTable.Wrap(err).WithValue("migration " + migration.Name)
+		}
+
+		names = append(names, migration.Name)
+	}
+
+	return names, nil
+}
+
+// Rollback reverts the last steps applied migrations, newest first, and
+// returns their names. Every migration rolled back needs a Down function.
+func (m *Migrator) Rollback(steps int) ([]string, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	registered := make(map[string]*Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		registered[migration.Name] = migration
+	}
+
+	names := make([]string, 0, steps)
+	for i := len(applied) - 1; i >= 0 && len(names) < steps; i-- {
+		record := applied[i]
+
+		migration, ok := registered[record.Name]
+		if !ok {
+			return names, errors.ErrRollbackMigration.Wrap(fmt.Errorf("migration %s is applied but not registered", record.Name))
+		}
+		if migration.Down == nil {
+			return names, errors.ErrRollbackMigration.Wrap(fmt.Errorf("migration %s has no Down function", record.Name))
+		}
+
+		err := m.client.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, record.ID).Error
+		})
+		if err != nil {
+			return names, errors.ErrRollbackMigration.Wrap(err).WithValue("migration " + record.Name)
+		}
+
+		names = append(names, record.Name)
+	}
+
+	return names, nil
+}
+
+// Status lists every registered migration in dependency order, followed by
+// any applied migrations that are no longer registered
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	sorted, err := sortMigrations(m.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]SchemaMigration, len(applied))
+	for _, record := range applied {
+		records[record.Name] = record
+	}
+
+	statuses := make([]MigrationStatus, 0, len(sorted))
+	seen := make(map[string]bool, len(sorted))
+	for _, migration := range sorted {
+		seen[migration.Name] = true
+		status := MigrationStatus{Name: migration.Name}
+		if record, ok := records[migration.Name]; ok {
+			status.Applied = true
+			status.Batch = record.Batch
+			status.AppliedAt = record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, record := range applied {
+		if !seen[record.Name] {
+			statuses = append(statuses, MigrationStatus{
+				Name:      record.Name,
+				Applied:   true,
+				Batch:     record.Batch,
+				AppliedAt: record.AppliedAt,
+				Missing:   true,
+			})
+		}
+	}
+
+	return statuses, nil
+}
+
+// applied returns the applied migrations in the order they were applied,
+// creating the schema_migrations table if needed
+func (m *Migrator) applied() ([]SchemaMigration, error) {
+	if err := m.client.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, errors.ErrMigrateTable.Wrap(err).WithValue("schema_migrations")
+	}
+
+	var records []SchemaMigration
+	if err := m.client.Order("id").Find(&records).Error; err != nil {
+		return nil, errors.ErrDatabaseRead.Wrap(err).WithValue("schema_migrations")
+	}
+
+	return records, nil
+}
+
+// sortMigrations orders migrations by version, the number their names
+// start with, so Go and SQL migrations interleave in the order they were
+// written, then moves dependencies ahead of the migrations needing them.
+// Migrations without a version come first, in registration order.
+func sortMigrations(ms []*Migration) ([]*Migration, error) {
+	ordered := append([]*Migration{}, ms...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := migrationVersion(ordered[i].Name), migrationVersion(ordered[j].Name)
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+
+	byName := make(map[string]*Migration, len(ms))
+	for _, migration := range ms {
+		byName[migration.Name] = migration
+	}
+
+	sorted := []*Migration{}
+	visited := make(map[string]bool)
+
+	var visit func(*Migration) error
+	visit = func(m *Migration) error {
+		if visited[m.Name] {
+			return nil
+		}
+
+		visited[m.Name] = true
+
+		deps := append([]*Migration{}, m.Deps...)
+		for _, name := range m.depNames {
+			dep, ok := byName[name]
+			if !ok {
+				return errors.ErrSortMigrations.Wrap(fmt.Errorf("unknown dependency %s", name)).WithValue("migration " + m.Name)
+			}
+			deps = append(deps, dep)
+		}
+
+		for _, dep := range deps {
+			if err := visit(dep); err != nil {
+				return errors.ErrSortMigrations.Wrap(err).WithValue("dependency " + dep.Name + " of model " + m.Name)
+			}
+		}
+
+		sorted = append(sorted, m)
+		return nil
+	}
+
+	for _, migration := range ordered {
+		if err := visit(migration); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// migrationVersion returns the digits name starts with, or "" if it has
+// no version
+func migrationVersion(name string) string {
+	version, _, ok := strings.Cut(name, "_")
+	if !ok || strings.Trim(version, "0123456789") != "" {
+		return ""
+	}
+	return version
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+type migratorAuthor struct {
+	ID   uint
+	Name string
+}
+
+type migratorBook struct {
+	ID       uint
+	AuthorID uint
+	Title    string
+}
+
+// testMigrations returns create migrations for authors and books plus a
+// seed migration that depends on both
+func testMigrations() []*Migration {
+	authors := NewMigrationBuilder().
+		Model(&migratorAuthor{}).
+		Name("001_create_authors").
+		Down(func(tx *gorm.DB) error { return tx.Migrator().DropTable(&migratorAuthor{}) }).
+		Build()
+
+	books := NewMigrationBuilder().
+		Model(&migratorBook{}).
+		Name("002_create_books").
+		Deps(authors).
+		Down(func(tx *gorm.DB) error { return tx.Migrator().DropTable(&migratorBook{}) }).
+		Build()
+
+	seed := NewMigrationBuilder().
+		Name("003_seed_authors").
+		Deps(authors, books).
+		Up(func(tx *gorm.DB) error { return tx.Create(&migratorAuthor{Name: "Ada"}).Error }).
+		Down(func(tx *gorm.DB) error { return tx.Where("name = ?", "Ada").Delete(&migratorAuthor{}).Error }).
+		Build()
+
+	// Registered out of order to exercise dependency sorting
+	return []*Migration{seed, books, authors}
+}
+
+// TestMigrator_Migrate tests applying pending migrations once
+func TestMigrator_Migrate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	migrator := NewMigrator(db, testMigrations()...)
+
+	applied, err := migrator.Migrate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"001_create_authors", "002_create_books", "003_seed_authors"}, applied)
+
+	testutil.AssertRecordCount(t, db, &migratorAuthor{}, 1, "name = ?", "Ada")
+	testutil.AssertRecordCount(t, db, &SchemaMigration{}, 3, "batch = ?", 1)
+
+	// Applied migrations are skipped, so Up does not run again
+	applied, err = migrator.Migrate()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	testutil.AssertRecordCount(t, db, &migratorAuthor{}, 1, "name = ?", "Ada")
+}
+
+// TestMigrator_MigrateFailure tests that a failing migration is not recorded
+func TestMigrator_MigrateFailure(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	ok := NewMigrationBuilder().Model(&migratorAuthor{}).Name("001_create_authors").Build()
+	broken := NewMigrationBuilder().
+		Name("002_broken").
+		Deps(ok).
+		Up(func(tx *gorm.DB) error {
+			if err := tx.Create(&migratorAuthor{Name: "partial"}).Error; err != nil {
+				return err
+			}
+			return errors.New("boom")
+		}).
+		Build()
+
+	applied, err := NewMigrator(db, ok, broken).Migrate()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, twineerrors.ErrMigrateTable))
+	assert.Equal(t, []string{"001_create_authors"}, applied)
+
+	// The failed migration's transaction was rolled back
+	testutil.AssertRecordNotExists(t, db, &migratorAuthor{}, "name = ?", "partial")
+	testutil.AssertRecordNotExists(t, db, &SchemaMigration{}, "name = ?", "002_broken")
+}
+
+// TestMigrator_Rollback tests reverting applied migrations newest first
+func TestMigrator_Rollback(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	migrator := NewMigrator(db, testMigrations()...)
+
+	_, err := migrator.Migrate()
+	require.NoError(t, err)
+
+	rolledBack, err := migrator.Rollback(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"003_seed_authors", "002_create_books"}, rolledBack)
+
+	assert.False(t, db.Migrator().HasTable(&migratorBook{}))
+	testutil.AssertRecordNotExists(t, db, &migratorAuthor{}, "name = ?", "Ada")
+	testutil.AssertRecordCount(t, db, &SchemaMigration{}, 1, "name = ?", "001_create_authors")
+
+	// Re-applying starts a new batch
+	applied, err := migrator.Migrate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"002_create_books", "003_seed_authors"}, applied)
+	testutil.AssertRecordCount(t, db, &SchemaMigration{}, 2, "batch = ?", 2)
+
+	// Rolling back more than is applied stops at the first migration
+	rolledBack, err = migrator.Rollback(10)
+	require.NoError(t, err)
+	assert.Len(t, rolledBack, 3)
+	assert.False(t, db.Migrator().HasTable(&migratorAuthor{}))
+}
+
+// TestMigrator_RollbackWithoutDown tests that migrations need a Down function
+func TestMigrator_RollbackWithoutDown(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	migration := NewMigrationBuilder().Model(&migratorAuthor{}).Name("001_create_authors").Build()
+	migrator := NewMigrator(db, migration)
+
+	_, err := migrator.Migrate()
+	require.NoError(t, err)
+
+	rolledBack, err := migrator.Rollback(1)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, twineerrors.ErrRollbackMigration))
+	assert.Contains(t, err.Error(), "has no Down function")
+	assert.Empty(t, rolledBack)
+	assert.True(t, db.Migrator().HasTable(&migratorAuthor{}))
+}
+
+// TestMigrator_Status tests reporting applied, pending and missing migrations
+func TestMigrator_Status(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ms := testMigrations()
+
+	_, err := NewMigrator(db, ms[2]).Migrate()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&SchemaMigration{Name: "000_removed", Batch: 1}).Error)
+
+	statuses, err := NewMigrator(db, ms...).Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+
+	assert.Equal(t, "001_create_authors", statuses[0].Name)
+	assert.True(t, statuses[0].Applied)
+	assert.Equal(t, 1, statuses[0].Batch)
+
+	assert.Equal(t, "002_create_books", statuses[1].Name)
+	assert.False(t, statuses[1].Applied)
+
+	assert.Equal(t, "000_removed", statuses[3].Name)
+	assert.True(t, statuses[3].Missing)
+}
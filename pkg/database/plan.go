@@ -0,0 +1,109 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// PlannedMigration describes a pending migration, as Migrate would apply it
+type PlannedMigration struct {
+	Name          string
+	Deps          []string // Names of the migrations it depends on
+	Model         string   // Auto-migrated model, e.g. "models.Post"
+	Up            bool     // Has a Go Up function
+	SQL           string   // Statements of a SQL migration's up file
+	NoTransaction bool     // Runs outside a transaction
+}
+
+// PlanMigrations lists the registered migrations that Migrate would apply
+// to client, in the order it would apply them, without changing the
+// database. A nil client plans every registered migration.
+func PlanMigrations(client *gorm.DB) ([]PlannedMigration, error) {
+	return NewMigrator(client).Plan()
+}
+
+// Plan lists the pending migrations in the order Migrate would apply them.
+// It only reads the schema_migrations table, and treats a missing table or
+// nil client as no migrations applied.
+func (m *Migrator) Plan() ([]PlannedMigration, error) {
+	sorted, err := sortMigrations(m.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool)
+	if m.client != nil && m.client.Migrator().HasTable(&SchemaMigration{}) {
+		var records []SchemaMigration
+		if err := m.client.Find(&records).Error; err != nil {
+			return nil, errors.ErrDatabaseRead.Wrap(err).WithValue("schema_migrations")
+		}
+		for _, record := range records {
+			done[record.Name] = true
+		}
+	}
+
+	plan := make([]PlannedMigration, 0)
+	for _, migration := range sorted {
+		if done[migration.Name] {
+			continue
+		}
+
+		planned := PlannedMigration{
+			Name:          migration.Name,
+			Up:            migration.Up != nil && migration.sql == "",
+			SQL:           migration.sql,
+			NoTransaction: migration.noTransaction,
+		}
+		for _, dep := range migration.Deps {
+			planned.Deps = append(planned.Deps, dep.Name)
+		}
+		planned.Deps = append(planned.Deps, migration.depNames...)
+		if migration.Model != nil {
+			t := reflect.TypeOf(migration.Model)
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			planned.Model = t.String()
+		}
+		plan = append(plan, planned)
+	}
+
+	return plan, nil
+}
+
+// WritePlan prints a migration plan for review
+func WritePlan(out io.Writer, plan []PlannedMigration) {
+	if len(plan) == 0 {
+		fmt.Fprintln(out, "No pending migrations")
+		return
+	}
+
+	fmt.Fprintf(out, "%d pending migration(s), in the order they would run:\n", len(plan))
+	for i, p := range plan {
+		fmt.Fprintf(out, "\n%d. %s\n", i+1, p.Name)
+		if len(p.Deps) > 0 {
+			fmt.Fprintf(out, "   deps: %s\n", strings.Join(p.Deps, ", "))
+		}
+		if p.NoTransaction {
+			fmt.Fprintln(out, "   runs outside a transaction")
+		}
+		if p.Model != "" {
+			fmt.Fprintf(out, "   auto-migrates %s\n", p.Model)
+		}
+		if p.Up {
+			fmt.Fprintln(out, "   runs a Go Up function")
+		}
+		if sql := strings.TrimSpace(p.SQL); sql != "" {
+			fmt.Fprintln(out, "   SQL:")
+			for _, line := range strings.Split(sql, "\n") {
+				fmt.Fprintf(out, "     %s\n", strings.TrimRight(line, " \t\r"))
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// TestMigrator_Plan tests planning pending migrations without applying them
+func TestMigrator_Plan(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	sql, err := LoadSQLMigrations(fstest.MapFS{
+		"004_index_books.up.sql": {Data: []byte("-- twine:no-transaction\nCREATE INDEX idx_books_title ON migrator_books (title);\n")},
+	})
+	require.NoError(t, err)
+	migrator := NewMigrator(db, append(testMigrations(), sql...)...)
+
+	plan, err := migrator.Plan()
+	require.NoError(t, err)
+	require.Len(t, plan, 4)
+	assert.False(t, db.Migrator().HasTable(&SchemaMigration{}), "planning must not create schema_migrations")
+
+	assert.Equal(t, PlannedMigration{Name: "001_create_authors", Model: "database.migratorAuthor"}, plan[0])
+	assert.Equal(t, []string{"001_create_authors"}, plan[1].Deps)
+	assert.Equal(t, PlannedMigration{Name: "003_seed_authors", Deps: []string{"001_create_authors", "002_create_books"}, Up: true}, plan[2])
+	assert.Equal(t, "004_index_books", plan[3].Name)
+	assert.False(t, plan[3].Up)
+	assert.True(t, plan[3].NoTransaction)
+	assert.Contains(t, plan[3].SQL, "CREATE INDEX idx_books_title")
+
+	_, err = NewMigrator(db, testMigrations()...).Migrate()
+	require.NoError(t, err)
+
+	plan, err = migrator.Plan()
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+	assert.Equal(t, "004_index_books", plan[0].Name)
+	assert.False(t, db.Migrator().HasIndex(&migratorBook{}, "idx_books_title"))
+
+	plan, err = NewMigrator(nil, testMigrations()...).Plan()
+	require.NoError(t, err)
+	assert.Len(t, plan, 3)
+}
+
+// TestWritePlan tests the plan output
+func TestWritePlan(t *testing.T) {
+	var out bytes.Buffer
+	WritePlan(&out, nil)
+	assert.Equal(t, "No pending migrations\n", out.String())
+
+	out.Reset()
+	WritePlan(&out, []PlannedMigration{
+		{Name: "001_create_posts", Model: "models.Post"},
+		{Name: "002_index_posts", Deps: []string{"001_create_posts"}, SQL: "-- twine:no-transaction\nCREATE INDEX CONCURRENTLY idx ON posts (slug);\n", NoTransaction: true},
+		{Name: "003_backfill", Up: true},
+	})
+	assert.Equal(t, `3 pending migration(s), in the order they would run:
+
+1. 001_create_posts
+   auto-migrates models.Post
+
+2. 002_index_posts
+   deps: 001_create_posts
+   runs outside a transaction
+   SQL:
+     -- twine:no-transaction
+     CREATE INDEX CONCURRENTLY idx ON posts (slug);
+
+3. 003_backfill
+   runs a Go Up function
+`, out.String())
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// SearchOptions selects a page of the records matching a search.
+// k.SearchOptions reads them from the query string.
+type SearchOptions struct {
+	ListOptions
+	Query string // Search terms; empty matches every record
+}
+
+// SearchLanguage is the Postgres text search configuration Search and the
+// search indexes use to stem words
+var SearchLanguage = "english"
+
+// Search lists the records whose columns match opts.Query, best matches
+// first, as List lists records. On Postgres it matches the columns'
+// tsvector against websearch_to_tsquery, so queries may use "quoted
+// phrases", OR and -excluded words, and ranks by ts_rank. Other databases
+// match each word case-insensitively with LIKE, unranked. An empty query
+// matches every record. opts.Sort orders before the rank.
+func (s *CRUDStore[T]) Search(ctx context.Context, opts SearchOptions, columns ...string) ([]T, int64, error) {
+	if len(columns) == 0 {
+		return nil, 0, errors.ErrDatabaseRead.Wrap(fmt.Errorf("Search needs at least one column"))
+	}
+
+	query := s.client.WithContext(ctx)
+	if opts.Query == "" {
+		return s.list(query, opts.ListOptions)
+	}
+
+	if query.Dialector.Name() != "postgres" {
+		for _, word := range strings.Fields(strings.ToLower(opts.Query)) {
+			matches := make([]clause.Expression, len(columns))
+			for i, column := range columns {
+				matches[i] = clause.Expr{SQL: "LOWER(?) LIKE ?", Vars: []any{clause.Column{Name: column}, "%" + word + "%"}}
+			}
+			query = query.Where(clause.Or(matches...))
+		}
+		return s.list(query, opts.ListOptions)
+	}
+
+	document := searchDocument(columns)
+	terms := clause.Expr{SQL: "websearch_to_tsquery(" + searchLanguage() + ", ?)", Vars: []any{opts.Query}}
+	query = query.Where(clause.Expr{SQL: "? @@ ?", Vars: []any{document, terms}})
+	rank := clause.Expr{SQL: "ts_rank(?, ?) DESC", Vars: []any{document, terms}}
+	return s.list(query, opts.ListOptions, rank)
+}
+
+// CreateSearchIndex returns a migration step that creates a GIN index on
+// the tsvector Search matches for model's columns, named
+// idx_<table>_search. Search only uses it when given the same columns in
+// the same order:
+//
+//	database.NewMigrationBuilder().
+//	    Name("20240301093000_search_posts").
+//	    Up(database.CreateSearchIndex(&models.Post{}, "title", "body")).
+//	    Down(database.DropSearchIndex(&models.Post{})).
+//	    Build()
+//
+// It does nothing on databases other than Postgres.
+func CreateSearchIndex(model any, columns ...string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if tx.Dialector.Name() != "postgres" {
+			return nil
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("CreateSearchIndex needs at least one column")
+		}
+		table, err := searchTable(tx, model)
+		if err != nil {
+			return err
+		}
+		return tx.Exec("CREATE INDEX IF NOT EXISTS ? ON ? USING GIN (?)",
+			clause.Column{Name: searchIndexName(table)}, clause.Table{Name: table}, searchDocument(columns)).Error
+	}
+}
+
+// DropSearchIndex returns a migration step that drops the index created by
+// CreateSearchIndex
+func DropSearchIndex(model any) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if tx.Dialector.Name() != "postgres" {
+			return nil
+		}
+		table, err := searchTable(tx, model)
+		if err != nil {
+			return err
+		}
+		return tx.Exec("DROP INDEX IF EXISTS ?", clause.Column{Name: searchIndexName(table)}).Error
+	}
+}
+
+// searchDocument is the tsvector of columns, concatenated with nulls as
+// empty text. The language is a literal rather than a parameter, so the
+// expression can be indexed and matches the index.
+func searchDocument(columns []string) clause.Expr {
+	parts := make([]string, len(columns))
+	vars := make([]any, len(columns))
+	for i, column := range columns {
+		parts[i] = "coalesce(?::text, '')"
+		vars[i] = clause.Column{Name: column}
+	}
+	return clause.Expr{SQL: "to_tsvector(" + searchLanguage() + ", " + strings.Join(parts, " || ' ' || ") + ")", Vars: vars}
+}
+
+// searchLanguage returns SearchLanguage as a regconfig literal
+func searchLanguage() string {
+	return "'" + strings.ReplaceAll(SearchLanguage, "'", "''") + "'::regconfig"
+}
+
+func searchTable(tx *gorm.DB, model any) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
+
+func searchIndexName(table string) string {
+	return "idx_" + table + "_search"
+}
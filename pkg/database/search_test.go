@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+type searchPost struct {
+	ID    uint
+	Title string
+	Body  string
+}
+
+// TestCRUDStore_Search tests the LIKE search used outside Postgres
+func TestCRUDStore_Search(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&searchPost{}))
+	posts := NewCRUDStore[searchPost](db).Columns("title")
+	ctx := context.Background()
+	for _, p := range []searchPost{
+		{Title: "Go generics", Body: "Type parameters"},
+		{Title: "Rust traits", Body: "Generic bounds"},
+		{Title: "Cooking", Body: "Pasta"},
+	} {
+		require.NoError(t, posts.Create(ctx, p))
+	}
+
+	titles := func(items []searchPost) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Title)
+		}
+		return out
+	}
+
+	items, total, err := posts.Search(ctx, SearchOptions{Query: "GENERIC", ListOptions: ListOptions{Sort: "-title"}}, "title", "body")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Rust traits", "Go generics"}, titles(items))
+	assert.Equal(t, int64(2), total)
+
+	items, _, err = posts.Search(ctx, SearchOptions{Query: "generic bounds"}, "title", "body")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Rust traits"}, titles(items), "every word must match")
+
+	items, total, err = posts.Search(ctx, SearchOptions{ListOptions: ListOptions{PerPage: 2}}, "title")
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, int64(3), total, "an empty query lists every record")
+
+	_, _, err = posts.Search(ctx, SearchOptions{Query: "go"})
+	assert.ErrorIs(t, err, twineerrors.ErrDatabaseRead)
+}
+
+// TestSearch_PostgresSQL tests the full-text SQL generated for Postgres
+// without connecting to it
+func TestSearch_PostgresSQL(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+
+	var queries []string
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("capture", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}))
+
+	posts := NewCRUDStore[searchPost](db)
+	posts.Columns("title")
+	_, _, err = posts.Search(context.Background(), SearchOptions{Query: "go -rust", ListOptions: ListOptions{Sort: "-title"}}, "title", "body")
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+	document := `to_tsvector('english'::regconfig, coalesce("title"::text, '') || ' ' || coalesce("body"::text, ''))`
+	assert.Equal(t, `SELECT * FROM "search_posts" WHERE `+document+` @@ websearch_to_tsquery('english'::regconfig, $1) ORDER BY "title" DESC, ts_rank(`+document+`, websearch_to_tsquery('english'::regconfig, $2)) DESC`, queries[1])
+
+	// The index expression is the same, with no parameters
+	var sql string
+	require.NoError(t, db.Callback().Raw().Register("capture_raw", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+		assert.Empty(t, tx.Statement.Vars)
+	}))
+	require.NoError(t, CreateSearchIndex(&searchPost{}, "title", "body")(db))
+	assert.Equal(t, `CREATE INDEX IF NOT EXISTS "idx_search_posts_search" ON "search_posts" USING GIN (to_tsvector('english'::regconfig, coalesce("title"::text, '') || ' ' || coalesce("body"::text, '')))`, sql)
+
+	require.NoError(t, DropSearchIndex(&searchPost{})(db))
+	assert.Equal(t, `DROP INDEX IF EXISTS "idx_search_posts_search"`, sql)
+}
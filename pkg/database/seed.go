@@ -0,0 +1,137 @@
+package database
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// seeds holds all registered seeds
+var seeds = []*Seed{}
+
+// Seed populates the database with data for one or more environments.
+// A seed without environments runs in every environment. Models lists the
+// tables the seed fills, which are cleared first when seeding with truncate.
+type Seed struct {
+	Name   string
+	Envs   []string
+	Models []any
+	Run    func(s *Seeder) error
+}
+
+// RunsIn reports whether the seed belongs to the given environment
+func (s *Seed) RunsIn(env string) bool {
+	return len(s.Envs) == 0 || slices.Contains(s.Envs, env)
+}
+
+// SeedBuilder provides a fluent interface for building seeds
+type SeedBuilder struct {
+	name   string
+	envs   []string
+	models []any
+	run    func(s *Seeder) error
+}
+
+// NewSeedBuilder creates a new SeedBuilder instance
+func NewSeedBuilder() *SeedBuilder {
+	return &SeedBuilder{}
+}
+
+// Name sets the name of this seed
+func (b *SeedBuilder) Name(name string) *SeedBuilder {
+	b.name = name
+	return b
+}
+
+// Envs sets the environments this seed runs in
+func (b *SeedBuilder) Envs(envs ...string) *SeedBuilder {
+	b.envs = envs
+	return b
+}
+
+// Models sets the models whose tables this seed fills
+func (b *SeedBuilder) Models(models ...any) *SeedBuilder {
+	b.models = models
+	return b
+}
+
+// Run sets the function that inserts the seed data
+func (b *SeedBuilder) Run(fn func(s *Seeder) error) *SeedBuilder {
+	b.run = fn
+	return b
+}
+
+// Build constructs the final Seed
+func (b *SeedBuilder) Build() *Seed {
+	return &Seed{
+		Name:   b.name,
+		Envs:   b.envs,
+		Models: b.models,
+		Run:    b.run,
+	}
+}
+
+// RegisterSeed adds a seed to run with twine db seed
+func RegisterSeed(s *Seed) {
+	seeds = append(seeds, s)
+}
+
+// RegisterSeeds adds multiple seeds to run with twine db seed
+func RegisterSeeds(ss ...*Seed) {
+	seeds = append(seeds, ss...)
+}
+
+// RunSeeds runs the seeds for env in registration order, or all registered
+// seeds if none are given, and returns the names of those that ran. With
+// truncate, the tables of every selected seed are cleared first, in reverse
+// order so that tables seeded later are emptied before those they reference.
+// Each seed runs in its own transaction.
+func RunSeeds(client *gorm.DB, env string, truncate bool, ss ...*Seed) ([]string, error) {
+	if len(ss) == 0 {
+		ss = seeds
+	}
+
+	selected := make([]*Seed, 0, len(ss))
+	for _, seed := range ss {
+		if seed.RunsIn(env) {
+			selected = append(selected, seed)
+		}
+	}
+
+	if truncate {
+		err := client.Transaction(func(tx *gorm.DB) error {
+			seeder := NewSeeder(tx, 0)
+			for i := len(selected) - 1; i >= 0; i-- {
+				for j := len(selected[i].Models) - 1; j >= 0; j-- {
+					if err := seeder.Clear(selected[i].Models[j]); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.ErrDatabaseSeed.Wrap(err).WithValue("truncate")
+		}
+	}
+
+	names := make([]string, 0, len(selected))
+	for _, seed := range selected {
+		if seed.Run == nil {
+			continue
+		}
+
+		err := client.Transaction(func(tx *gorm.DB) error {
+			return seed.Run(NewSeeder(tx, 0))
+		})
+		if err != nil {
+			return names, errors.ErrDatabaseSeed.Wrap(err).WithValue("seed " + seed.Name)
+		}
+
+		names = append(names, seed.Name)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+type seedCountry struct {
+	ID   uint
+	Code string `gorm:"uniqueIndex"`
+	Name string
+}
+
+type seedUser struct {
+	ID    uint
+	Email string
+}
+
+// testSeeds returns reference data for every environment plus users for dev
+// and test only
+func testSeeds() []*Seed {
+	countries := NewSeedBuilder().
+		Name("countries").
+		Models(&seedCountry{}).
+		Run(func(s *Seeder) error {
+			return s.Upsert([]seedCountry{
+				{Code: "NZ", Name: "New Zealand"},
+				{Code: "AU", Name: "Australia"},
+			}, "code")
+		}).
+		Build()
+
+	users := NewSeedBuilder().
+		Name("users").
+		Envs("dev", "test").
+		Models(&seedUser{}).
+		Run(func(s *Seeder) error {
+			return s.Seed([]seedUser{{Email: "ada@example.com"}, {Email: "grace@example.com"}})
+		}).
+		Build()
+
+	return []*Seed{countries, users}
+}
+
+// TestRunSeeds tests selecting seeds by environment
+func TestRunSeeds(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&seedCountry{}, &seedUser{}))
+
+	ran, err := RunSeeds(db, "prod", false, testSeeds()...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"countries"}, ran)
+	testutil.AssertRecordCount(t, db, &seedUser{}, 0, "1 = 1")
+
+	ran, err = RunSeeds(db, "dev", false, testSeeds()...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"countries", "users"}, ran)
+
+	// Upserted reference data is not duplicated, but plain seeds are
+	testutil.AssertRecordCount(t, db, &seedCountry{}, 2, "1 = 1")
+	testutil.AssertRecordCount(t, db, &seedUser{}, 2, "1 = 1")
+}
+
+// TestRunSeeds_Truncate tests clearing seeded tables before seeding
+func TestRunSeeds_Truncate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&seedCountry{}, &seedUser{}))
+
+	_, err := RunSeeds(db, "test", false, testSeeds()...)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&seedCountry{Code: "FR", Name: "France"}).Error)
+
+	_, err = RunSeeds(db, "test", true, testSeeds()...)
+	require.NoError(t, err)
+	testutil.AssertRecordCount(t, db, &seedUser{}, 2, "1 = 1")
+	testutil.AssertRecordNotExists(t, db, &seedCountry{}, "code = ?", "FR")
+
+	// Tables of seeds outside the environment are left alone
+	_, err = RunSeeds(db, "prod", true, testSeeds()...)
+	require.NoError(t, err)
+	testutil.AssertRecordCount(t, db, &seedUser{}, 2, "1 = 1")
+}
+
+// TestRunSeeds_Failure tests that a failing seed is rolled back
+func TestRunSeeds_Failure(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&seedUser{}))
+
+	broken := NewSeedBuilder().
+		Name("broken").
+		Run(func(s *Seeder) error {
+			if err := s.SeedOne(&seedUser{Email: "partial@example.com"}); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		}).
+		Build()
+
+	ran, err := RunSeeds(db, "dev", false, broken)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, twineerrors.ErrDatabaseSeed))
+	assert.Empty(t, ran)
+	testutil.AssertRecordNotExists(t, db, &seedUser{}, "email = ?", "partial@example.com")
+}
+
+// TestSeeder_Upsert tests updating records that conflict on a column
+func TestSeeder_Upsert(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&seedCountry{}))
+	seeder := NewSeeder(db, 0)
+
+	require.NoError(t, seeder.Upsert([]seedCountry{{Code: "NZ", Name: "NZ"}}, "code"))
+	require.NoError(t, seeder.Upsert([]seedCountry{{Code: "NZ", Name: "New Zealand"}}, "code"))
+
+	testutil.AssertRecordCount(t, db, &seedCountry{}, 1, "name = ?", "New Zealand")
+
+	err := seeder.Upsert(seedCountry{Code: "AU"}, "code")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, twineerrors.ErrSeedObject))
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/cstone-io/twine/pkg/errors"
 )
@@ -54,6 +55,36 @@ func (s *Seeder) Seed(records any) error {
 	return err
 }
 
+// Upsert inserts a slice of records, updating any that conflict on the given
+// columns (the primary key if none are given). Running it again leaves the
+// table unchanged, which suits reference data.
+func (s *Seeder) Upsert(records any, columns ...string) error {
+	value := reflect.ValueOf(records)
+	if value.Kind() != reflect.Slice {
+		return errors.ErrSeedObject.WithValue("records must be a slice")
+	}
+
+	if value.Len() == 0 {
+		return nil
+	}
+
+	conflict := clause.OnConflict{UpdateAll: true}
+	for _, column := range columns {
+		conflict.Columns = append(conflict.Columns, clause.Column{Name: column})
+	}
+
+	if err := s.db.Clauses(conflict).CreateInBatches(records, s.batchSize).Error; err != nil {
+		return errors.ErrSeedObject.Wrap(err)
+	}
+	return nil
+}
+
+// DB returns the database the seeder writes to, for queries a seed needs
+// between inserts
+func (s *Seeder) DB() *gorm.DB {
+	return s.db
+}
+
 // SeedOne inserts a single record into the database
 func (s *Seeder) SeedOne(record any) error {
 	if err := s.db.Create(record).Error; err != nil {
@@ -62,9 +93,10 @@ func (s *Seeder) SeedOne(record any) error {
 	return nil
 }
 
-// Clear truncates the table for the given model
+// Clear deletes every row, including soft-deleted rows, from the table for
+// the given model
 func (s *Seeder) Clear(model any) error {
-	if err := s.db.Unscoped().Delete(model).Error; err != nil {
+	if err := s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(model).Error; err != nil {
 		return errors.ErrSeedObject.Wrap(err)
 	}
 	return nil
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// WithDeleted is a scope that includes soft-deleted rows:
+//
+//	db.Scopes(database.WithDeleted).Find(&posts)
+func WithDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// OnlyDeleted is a scope that selects only soft-deleted rows
+func OnlyDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: "deleted_at"}, Value: nil})
+}
+
+// ListDeleted lists the soft-deleted records, as List lists the others
+func (s *CRUDStore[T]) ListDeleted(ctx context.Context, opts ListOptions) ([]T, int64, error) {
+	if err := s.softDeletes(); err != nil {
+		return nil, 0, errors.ErrDatabaseRead.Wrap(err)
+	}
+	return s.list(s.client.WithContext(ctx).Scopes(OnlyDeleted), opts)
+}
+
+// Restore undeletes a soft-deleted record by ID. It returns
+// ErrDatabaseObjectNotFound if no deleted record has the ID.
+func (s *CRUDStore[T]) Restore(ctx context.Context, id string) error {
+	if err := s.softDeletes(); err != nil {
+		return errors.ErrDatabaseUpdate.Wrap(err)
+	}
+
+	result := s.client.WithContext(ctx).Scopes(OnlyDeleted).Model(new(T)).
+		Where("id = ?", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return errors.ErrDatabaseUpdate.Wrap(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrDatabaseObjectNotFound.WithValue(id)
+	}
+	return nil
+}
+
+// ForceDelete permanently deletes a record by ID, whether or not it was
+// soft-deleted, and runs the OnDelete hooks
+func (s *CRUDStore[T]) ForceDelete(ctx context.Context, id string) error {
+	return s.delete(ctx, s.client.WithContext(ctx).Unscoped().Session(&gorm.Session{}), id)
+}
+
+// deletedAtType is the type of the field that makes a model soft-delete
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+// softDeletes returns an error unless T has a deleted_at gorm.DeletedAt
+// field, from BaseModel, SoftDelete or its own declaration
+func (s *CRUDStore[T]) softDeletes() error {
+	stmt := &gorm.Statement{DB: s.client}
+	if err := stmt.Parse(new(T)); err != nil {
+		return err
+	}
+	if field := stmt.Schema.LookUpField("deleted_at"); field == nil || field.FieldType != deletedAtType {
+		return fmt.Errorf("%s doesn't soft-delete; embed database.BaseModel or database.SoftDelete", stmt.Schema.Name)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+type softNote struct {
+	ID   uint
+	Body string
+	SoftDelete
+}
+
+// TestCRUDStore_SoftDelete tests listing, restoring and force deleting
+// soft-deleted records
+func TestCRUDStore_SoftDelete(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&softNote{}, &migratorBook{}))
+	notes := NewCRUDStore[softNote](db)
+	ctx := context.Background()
+
+	for _, body := range []string{"keep", "trash", "purge"} {
+		require.NoError(t, notes.Create(ctx, softNote{Body: body}))
+	}
+	require.NoError(t, notes.Delete(ctx, "2"))
+	require.NoError(t, notes.Delete(ctx, "3"))
+
+	live, total, err := notes.List(ctx, ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "keep", live[0].Body)
+
+	deleted, total, err := notes.ListDeleted(ctx, ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, deleted, 2)
+
+	var all []softNote
+	require.NoError(t, db.Scopes(WithDeleted).Find(&all).Error)
+	assert.Len(t, all, 3)
+
+	require.NoError(t, notes.Restore(ctx, "2"))
+	restored, err := notes.Get(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "trash", restored.Body)
+	assert.ErrorIs(t, notes.Restore(ctx, "2"), twineerrors.ErrDatabaseObjectNotFound, "only deleted records are restored")
+
+	require.NoError(t, notes.ForceDelete(ctx, "3"))
+	var count int64
+	require.NoError(t, db.Scopes(WithDeleted).Model(&softNote{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	t.Run("models without soft delete", func(t *testing.T) {
+		books := NewCRUDStore[migratorBook](db)
+		_, _, err := books.ListDeleted(ctx, ListOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "doesn't soft-delete")
+		assert.Error(t, books.Restore(ctx, "1"))
+	})
+}
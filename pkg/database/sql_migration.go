@@ -0,0 +1,166 @@
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// SQLMigrationsDir is where RunCommand looks for SQL migration files,
+// relative to the project root
+const SQLMigrationsDir = "db/migrations"
+
+// sqlMigrationFile matches <version>_<name>.up.sql and .down.sql
+var sqlMigrationFile = regexp.MustCompile(`^([0-9]+_[A-Za-z0-9_]+)\.(up|down)\.sql$`)
+
+// SQL migration directives, in comment lines at the top of an up file
+const (
+	depsDirective          = "-- twine:deps"
+	noTransactionDirective = "-- twine:no-transaction"
+)
+
+// LoadSQLMigrations reads the <version>_<name>.up.sql and .down.sql files at
+// the top of fsys as migrations named <version>_<name>. The up file runs on
+// migrate and the optional down file on rollback. Comment lines at the top
+// of the up file can name dependencies and run it outside a transaction,
+// for statements such as CREATE INDEX CONCURRENTLY:
+//
+//	-- twine:deps 20240301093000_create_posts
+//	-- twine:no-transaction
+//	CREATE INDEX CONCURRENTLY idx_posts_slug ON posts (slug);
+func LoadSQLMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.ErrMigrateTable.Wrap(err).WithValue("SQL migrations")
+	}
+
+	byName := make(map[string]*Migration)
+	for _, entry := range entries {
+		match := sqlMigrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.ErrMigrateTable.Wrap(err).WithValue(entry.Name())
+		}
+
+		name := match[1]
+		migration, ok := byName[name]
+		if !ok {
+			migration = &Migration{Name: name}
+			byName[name] = migration
+		}
+		if match[2] == "down" {
+			migration.Down = execSQL(string(content))
+			continue
+		}
+		migration.Up = execSQL(string(content))
+		migration.sql = string(content)
+		migration.depNames, migration.noTransaction = sqlDirectives(string(content))
+	}
+
+	names := make([]string, 0, len(byName))
+	for name, migration := range byName {
+		if migration.Up == nil {
+			return nil, errors.ErrMigrateTable.Wrap(fmt.Errorf("%s.down.sql has no %s.up.sql", name, name))
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ms := make([]*Migration, len(names))
+	for i, name := range names {
+		ms[i] = byName[name]
+	}
+	return ms, nil
+}
+
+// RegisterSQLMigrations registers the SQL migrations in fsys, usually files
+// embedded in the migrations package:
+//
+//	//go:embed *.sql
+//	var sqlFiles embed.FS
+//
+//	func init() {
+//	    database.RegisterSQLMigrations(sqlFiles)
+//	}
+//
+// It panics if the files can't be loaded, as they are part of the build.
+func RegisterSQLMigrations(fsys fs.FS) {
+	ms, err := LoadSQLMigrations(fsys)
+	if err != nil {
+		panic(err)
+	}
+	RegisterMigrations(ms...)
+}
+
+// withSQLMigrations adds the SQL migrations in dir to ms, unless a
+// migration of the same name is already in ms
+func withSQLMigrations(ms []*Migration, dir string) ([]*Migration, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ms, nil
+	}
+
+	loaded, err := LoadSQLMigrations(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(ms))
+	for _, migration := range ms {
+		names[migration.Name] = true
+	}
+	all := append([]*Migration{}, ms...)
+	for _, migration := range loaded {
+		if !names[migration.Name] {
+			all = append(all, migration)
+		}
+	}
+	return all, nil
+}
+
+// execSQL returns a migration step that runs the statements in content.
+// Files with several statements rely on the driver running them in one
+// Exec; MySQL needs multiStatements=true in its DSN for that.
+func execSQL(content string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if strings.TrimSpace(content) == "" {
+			return nil
+		}
+		return tx.Exec(content).Error
+	}
+}
+
+// sqlDirectives reads the twine directives from the comment lines at the
+// top of an up file
+func sqlDirectives(content string) (deps []string, noTransaction bool) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case line == noTransactionDirective:
+			noTransaction = true
+		case strings.HasPrefix(line, depsDirective+" "):
+			for _, dep := range strings.Split(strings.TrimPrefix(line, depsDirective), ",") {
+				if dep = strings.TrimSpace(dep); dep != "" {
+					deps = append(deps, dep)
+				}
+			}
+		case strings.HasPrefix(line, "--"):
+			continue
+		default:
+			return deps, noTransaction
+		}
+	}
+	return deps, noTransaction
+}
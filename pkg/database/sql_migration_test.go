@@ -0,0 +1,111 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+// TestLoadSQLMigrations tests reading up and down files and directives
+func TestLoadSQLMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_index_books.up.sql":   {Data: []byte("-- Speeds up title search\n-- twine:deps 001_create_authors, 002_create_books\n-- twine:no-transaction\n\nCREATE INDEX idx_books_title ON migrator_books (title);\n")},
+		"002_index_books.down.sql": {Data: []byte("DROP INDEX idx_books_title;\n")},
+		"001_extension.up.sql":     {Data: []byte("SELECT 1;\n-- twine:no-transaction\n")},
+		"README.md":                {Data: []byte("not a migration")},
+		"create_things.up.sql":     {Data: []byte("no version")},
+	}
+
+	ms, err := LoadSQLMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, ms, 2)
+
+	assert.Equal(t, "001_extension", ms[0].Name)
+	assert.NotNil(t, ms[0].Up)
+	assert.Nil(t, ms[0].Down)
+	assert.False(t, ms[0].noTransaction, "directives after the first statement are ignored")
+
+	assert.Equal(t, "002_index_books", ms[1].Name)
+	assert.NotNil(t, ms[1].Down)
+	assert.Equal(t, []string{"001_create_authors", "002_create_books"}, ms[1].depNames)
+	assert.True(t, ms[1].noTransaction)
+
+	_, err = LoadSQLMigrations(fstest.MapFS{"003_orphan.down.sql": {Data: []byte("SELECT 1;")}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "003_orphan.down.sql has no 003_orphan.up.sql")
+}
+
+// TestMigrator_SQLMigrations tests SQL migrations interleaved with Go
+// migrations by version and dependency
+func TestMigrator_SQLMigrations(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	sql, err := LoadSQLMigrations(fstest.MapFS{
+		"0025_index_books.up.sql":   {Data: []byte("-- twine:deps 002_create_books\nCREATE INDEX idx_books_title ON migrator_books (title);\n")},
+		"0025_index_books.down.sql": {Data: []byte("DROP INDEX idx_books_title;\n")},
+		"000_settings.up.sql":       {Data: []byte("-- twine:no-transaction\nCREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT);\nINSERT INTO settings VALUES ('theme', 'dark');\n")},
+	})
+	require.NoError(t, err)
+
+	migrator := NewMigrator(db, append(testMigrations(), sql...)...)
+	applied, err := migrator.Migrate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"000_settings", "001_create_authors", "002_create_books", "003_seed_authors", "0025_index_books"}, applied)
+
+	var value string
+	require.NoError(t, db.Raw("SELECT value FROM settings WHERE key = 'theme'").Scan(&value).Error)
+	assert.Equal(t, "dark", value)
+	assert.True(t, db.Migrator().HasIndex(&migratorBook{}, "idx_books_title"))
+
+	rolledBack, err := migrator.Rollback(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0025_index_books"}, rolledBack)
+	assert.False(t, db.Migrator().HasIndex(&migratorBook{}, "idx_books_title"))
+}
+
+// TestSortMigrations_Versions tests ordering by version before dependencies
+func TestSortMigrations_Versions(t *testing.T) {
+	first := &Migration{Name: "20240101000000_first"}
+	second := &Migration{Name: "20240201000000_second"}
+	legacy := &Migration{Name: "create_users"}
+	needsLater := &Migration{Name: "20240102000000_needs_later", depNames: []string{"20240201000000_second"}}
+
+	sorted, err := sortMigrations([]*Migration{second, needsLater, first, legacy})
+	require.NoError(t, err)
+
+	names := make([]string, len(sorted))
+	for i, m := range sorted {
+		names[i] = m.Name
+	}
+	assert.Equal(t, []string{"create_users", "20240101000000_first", "20240201000000_second", "20240102000000_needs_later"}, names)
+
+	_, err = sortMigrations([]*Migration{{Name: "001_a", depNames: []string{"000_missing"}}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, twineerrors.ErrSortMigrations)
+	assert.Contains(t, err.Error(), "unknown dependency 000_missing")
+}
+
+// TestWithSQLMigrations tests loading a directory without duplicating
+// registered migrations
+func TestWithSQLMigrations(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "001_create_authors.up.sql"), []byte("SELECT 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "004_views.up.sql"), []byte("SELECT 1;"), 0644))
+
+	registered := testMigrations()
+	ms, err := withSQLMigrations(registered, dir)
+	require.NoError(t, err)
+	require.Len(t, ms, len(registered)+1)
+	assert.Equal(t, "004_views", ms[len(ms)-1].Name)
+
+	ms, err = withSQLMigrations(registered, filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.Len(t, ms, len(registered))
+}
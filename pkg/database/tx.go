@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// Transaction runs fn in a transaction on the database returned by Get. It
+// commits if fn returns nil, and rolls back if fn returns an error or
+// panics. Stores share the transaction through WithTx:
+//
+//	err := database.Transaction(ctx, func(tx *gorm.DB) error {
+//	    return posts.WithTx(tx).Create(ctx, post)
+//	})
+//
+// fn's error is returned as is; failing to begin or commit returns
+// ErrDatabaseTransaction.
+func Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	d := Get()
+	if d == nil {
+		return errors.ErrDatabaseConn
+	}
+	return d.Transaction(ctx, fn)
+}
+
+// Transaction runs fn in a transaction on d, as database.Transaction does
+func (d *Database) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	var fnErr error
+	err := d.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fnErr = fn(tx)
+		return fnErr
+	})
+	if err != nil && err != fnErr {
+		return errors.ErrDatabaseTransaction.Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+// useTxDB makes a database with the authors and books tables the one
+// returned by Get
+func useTxDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := testutil.SetupTestDB(t)
+
+	instance = &Database{client: db}
+	t.Cleanup(Reset)
+	require.NoError(t, db.AutoMigrate(&migratorAuthor{}, &migratorBook{}))
+	return db
+}
+
+// TestTransaction tests committing and rolling back with stores sharing
+// the transaction
+func TestTransaction(t *testing.T) {
+	db := useTxDB(t)
+	authors := NewCRUDStore[migratorAuthor](db)
+	books := NewCRUDStore[migratorBook](db)
+
+	err := Transaction(context.Background(), func(tx *gorm.DB) error {
+		if err := authors.WithTx(tx).Create(context.Background(), migratorAuthor{ID: 1, Name: "Ada"}); err != nil {
+			return err
+		}
+		return books.WithTx(tx).Create(context.Background(), migratorBook{AuthorID: 1, Title: "Notes"})
+	})
+	require.NoError(t, err)
+
+	list, _, err := books.List(context.Background(), ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	failure := fmt.Errorf("out of stock")
+	err = Transaction(context.Background(), func(tx *gorm.DB) error {
+		if err := authors.WithTx(tx).Create(context.Background(), migratorAuthor{ID: 2, Name: "Grace"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.Same(t, failure, err, "fn's error is returned as is")
+
+	_, err = authors.Get(context.Background(), "2")
+	assert.ErrorIs(t, err, twineerrors.ErrDatabaseObjectNotFound, "the write was rolled back")
+
+	assert.Panics(t, func() {
+		Transaction(context.Background(), func(tx *gorm.DB) error {
+			authors.WithTx(tx).Create(context.Background(), migratorAuthor{ID: 3, Name: "Edsger"})
+			panic("boom")
+		})
+	})
+	_, err = authors.Get(context.Background(), "3")
+	assert.Error(t, err, "panics roll back")
+}
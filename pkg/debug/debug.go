@@ -0,0 +1,217 @@
+// Package debug serves endpoints for inspecting a running application
+// under /_twine: pprof profiles, the registered routes, and the
+// configuration with its secrets redacted. They are off unless
+// DEBUG_ENABLED is set, and are served either behind DEBUG_TOKEN or only on
+// a loopback address, so production can be profiled without a custom
+// build.
+package debug
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+	"github.com/cstone-io/twine/pkg/router"
+	"github.com/cstone-io/twine/pkg/server"
+)
+
+// Prefix is the URL path the endpoints are served under
+const Prefix = "/_twine"
+
+// RouteInfo is an entry of /_twine/routes
+type RouteInfo struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Mount serves the endpoints when DEBUG_ENABLED is set: on srv's port when
+// DEBUG_TOKEN is set, requiring the token, and on their own listener at
+// DEBUG_ADDR, which is closed when srv shuts down. Call it after
+// r.InitializeAsRoot, so /_twine/routes lists every route:
+//
+//	mux := r.InitializeAsRoot()
+//	srv := server.NewServerFromConfig(cfg, mux)
+//	if err := debug.Mount(srv, r); err != nil {
+//	    log.Fatal(err)
+//	}
+func Mount(srv *server.Server, r *router.Router) error {
+	cfg := config.Get().Debug
+	if !cfg.Enabled {
+		return nil
+	}
+	handler := Handler(r, cfg.Token)
+
+	if cfg.Token != "" {
+		next := srv.Instance.Handler
+		if next == nil {
+			next = http.DefaultServeMux
+		}
+		srv.Instance.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == Prefix || strings.HasPrefix(req.URL.Path, Prefix+"/") {
+				handler.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+
+	if cfg.Addr != "" {
+		ln, err := net.Listen("tcp", cfg.Addr)
+		if err != nil {
+			return errors.ErrListenAndServe.Wrap(err).WithValue(cfg.Addr)
+		}
+		// No write timeout, which would cut CPU profiles and traces short
+		debugServer := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+		srv.OnShutdown("debug", func(ctx context.Context) error {
+			return debugServer.Close()
+		})
+
+		logger.Get().Info("Serving debug endpoints on %s%s", ln.Addr(), Prefix)
+		go func() {
+			if err := debugServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+				logger.Get().CustomError(errors.ErrListenAndServe.Wrap(err).WithValue(cfg.Addr))
+			}
+		}()
+	}
+	return nil
+}
+
+// Handler serves the endpoints under Prefix, listing the routes of r.
+// Requests need token in an Authorization: Bearer header or the token
+// query parameter, which go tool pprof can pass, unless token is empty.
+func Handler(r *router.Router, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Prefix+"/routes", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, Routes(r))
+	})
+	mux.HandleFunc("GET "+Prefix+"/config", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, config.Get().Redacted())
+	})
+	mux.HandleFunc(Prefix+"/pprof/", servePprof)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" && !authorized(req, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="twine debug"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+// Routes returns the routes of r, sorted by path and method
+func Routes(r *router.Router) []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.Routes))
+	for _, route := range r.Routes {
+		routes = append(routes, RouteInfo{Method: strings.TrimSpace(string(route.Method)), Path: route.Path()})
+	}
+	sort.Slice(routes, func(a, b int) bool {
+		if routes[a].Path != routes[b].Path {
+			return routes[a].Path < routes[b].Path
+		}
+		return routes[a].Method < routes[b].Method
+	})
+	return routes
+}
+
+// servePprof serves the index of profiles and each profile under
+// /_twine/pprof/. net/http/pprof isn't imported because it registers its
+// handlers on http.DefaultServeMux, which could expose them.
+func servePprof(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, Prefix+"/pprof/")
+	switch name {
+	case "":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, p := range pprof.Profiles() {
+			fmt.Fprintf(w, "%s\t%d\n", p.Name(), p.Count())
+		}
+		fmt.Fprintln(w, "profile\tCPU profile; ?seconds=30")
+		fmt.Fprintln(w, "trace\texecution trace; ?seconds=1")
+		return
+	case "cmdline":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, strings.Join(os.Args, "\x00"))
+		return
+	case "profile", "trace":
+		seconds, err := strconv.Atoi(req.URL.Query().Get("seconds"))
+		if err != nil || seconds <= 0 {
+			seconds = 30
+			if name == "trace" {
+				seconds = 1
+			}
+		}
+		record(w, req, name, time.Duration(seconds)*time.Second)
+		return
+	}
+
+	profile := pprof.Lookup(name)
+	if profile == nil {
+		http.Error(w, "Unknown profile", http.StatusNotFound)
+		return
+	}
+	if name == "heap" && req.URL.Query().Get("gc") != "" {
+		runtime.GC()
+	}
+	level, _ := strconv.Atoi(req.URL.Query().Get("debug"))
+	if level > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	}
+	profile.WriteTo(w, level)
+}
+
+// record writes a CPU profile or execution trace covering d, or until the
+// request is canceled
+func record(w http.ResponseWriter, req *http.Request, name string, d time.Duration) {
+	start, stop := pprof.StartCPUProfile, pprof.StopCPUProfile
+	if name == "trace" {
+		start, stop = trace.Start, trace.Stop
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if err := start(w); err != nil {
+		// Another profile or trace is running
+		w.Header().Del("Content-Disposition")
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	select {
+	case <-time.After(d):
+	case <-req.Context().Done():
+	}
+	stop()
+}
+
+// authorized reports whether req carries token
+func authorized(req *http.Request, token string) bool {
+	given := req.URL.Query().Get("token")
+	if auth, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		given = auth
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
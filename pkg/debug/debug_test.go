@@ -0,0 +1,170 @@
+package debug
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/router"
+	"github.com/cstone-io/twine/pkg/server"
+)
+
+// token is a DEBUG_TOKEN long enough to pass validation
+var token = strings.Repeat("t", config.MinSecretLength)
+
+// setDebugConfig sets the debug settings for a test
+func setDebugConfig(t *testing.T, debug config.DebugConfig) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Debug
+	t.Cleanup(func() { cfg.Debug = original })
+	cfg.Debug = debug
+}
+
+// newRouter returns an initialized router with a few routes
+func newRouter() *router.Router {
+	r := router.NewRouter("")
+	noop := func(k *kit.Kit) error { return nil }
+	r.Get("/posts/{id}", noop)
+	r.Post("/posts", noop)
+	r.Get("/posts", noop)
+	r.InitializeAsRoot()
+	return r
+}
+
+// get requests path from handler, with the bearer token if it is set
+func get(handler http.Handler, path, bearer string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestHandler tests the endpoints and the token they require
+func TestHandler(t *testing.T) {
+	handler := Handler(newRouter(), token)
+
+	t.Run("requires the token", func(t *testing.T) {
+		rec := get(handler, "/_twine/routes", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+
+		assert.Equal(t, http.StatusUnauthorized, get(handler, "/_twine/routes", "wrong").Code)
+		assert.Equal(t, http.StatusOK, get(handler, "/_twine/routes?token="+token, "").Code, "go tool pprof can't set headers")
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		rec := get(handler, "/_twine/routes", token)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var routes []RouteInfo
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &routes))
+		assert.Equal(t, []RouteInfo{
+			{Method: "GET", Path: "/posts"},
+			{Method: "POST", Path: "/posts"},
+			{Method: "GET", Path: "/posts/{id}"},
+		}, routes)
+	})
+
+	t.Run("config without secrets", func(t *testing.T) {
+		cfg := config.Get()
+		original := cfg.Auth.SecretKey
+		t.Cleanup(func() { cfg.Auth.SecretKey = original })
+		cfg.Auth.SecretKey = "signing-key"
+
+		rec := get(handler, "/_twine/config", token)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.NotContains(t, rec.Body.String(), "signing-key")
+		assert.Contains(t, rec.Body.String(), config.Redacted)
+	})
+
+	t.Run("pprof", func(t *testing.T) {
+		rec := get(handler, "/_twine/pprof/", token)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "goroutine")
+
+		rec = get(handler, "/_twine/pprof/goroutine?debug=1", token)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "goroutine profile")
+
+		rec = get(handler, "/_twine/pprof/heap", token)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+
+		rec = get(handler, "/_twine/pprof/profile?seconds=1", token)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, rec.Body.Bytes())
+
+		assert.Equal(t, http.StatusNotFound, get(handler, "/_twine/pprof/missing", token).Code)
+	})
+
+	t.Run("without a token", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(Handler(newRouter(), ""), "/_twine/routes", "").Code)
+	})
+}
+
+// TestMount tests serving the endpoints on the server's port and on their
+// own listener as configured
+func TestMount(t *testing.T) {
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		setDebugConfig(t, config.DebugConfig{Token: token})
+		srv := server.NewServer("", app)
+		require.NoError(t, Mount(srv, newRouter()))
+		assert.Equal(t, http.StatusTeapot, get(srv.Instance.Handler, "/_twine/routes", token).Code)
+	})
+
+	t.Run("with a token", func(t *testing.T) {
+		setDebugConfig(t, config.DebugConfig{Enabled: true, Token: token})
+		srv := server.NewServer("", app)
+		require.NoError(t, Mount(srv, newRouter()))
+		assert.Equal(t, http.StatusOK, get(srv.Instance.Handler, "/_twine/routes", token).Code)
+		assert.Equal(t, http.StatusUnauthorized, get(srv.Instance.Handler, "/_twine/routes", "").Code)
+		assert.Equal(t, http.StatusTeapot, get(srv.Instance.Handler, "/posts", "").Code)
+	})
+
+	t.Run("listener address in use", func(t *testing.T) {
+		blocker := httptest.NewServer(app)
+		t.Cleanup(blocker.Close)
+		setDebugConfig(t, config.DebugConfig{Enabled: true, Addr: blocker.Listener.Addr().String()})
+		assert.Error(t, Mount(server.NewServer("", app), newRouter()))
+	})
+}
+
+// TestMount_Listener tests requesting the endpoints from their own listener
+func TestMount_Listener(t *testing.T) {
+	blocker := httptest.NewServer(http.NotFoundHandler())
+	addr := blocker.Listener.Addr().String()
+	blocker.Close()
+
+	setDebugConfig(t, config.DebugConfig{Enabled: true, Addr: addr})
+	srv := server.NewServer("", http.NotFoundHandler())
+	require.NoError(t, Mount(srv, newRouter()))
+	assert.Equal(t, http.StatusNotFound, get(srv.Instance.Handler, "/_twine/routes", "").Code,
+		"not on the application's port without a token")
+
+	res, err := http.Get("http://" + addr + "/_twine/routes")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, string(body), "/posts/{id}")
+
+	require.NoError(t, srv.Shutdown())
+	_, err = http.Get("http://" + addr + "/_twine/routes")
+	assert.Error(t, err, "closed when the server shuts down")
+}
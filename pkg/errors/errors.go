@@ -1,8 +1,11 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 )
 
 // ErrSeverity represents the severity level of an error
@@ -14,14 +17,26 @@ const (
 	ErrCritical
 )
 
-// Error represents a structured error with code, message, and context
+// Error represents a structured error with code, message, and context.
+// Message and the cause are logged; clients are shown PublicMessage, or
+// Message if it is empty.
 type Error struct {
-	Code       int         `json:"code"`
-	Message    string      `json:"message"`
-	HTTPStatus int         `json:"-"`
-	Severity   ErrSeverity `json:"-"`
-	Cause      error       `json:"-"`
-	Value      any         `json:"-"`
+	Code          int         `json:"code"`
+	Message       string      `json:"message"`
+	PublicMessage string      `json:"-"`
+	HTTPStatus    int         `json:"-"`
+	Severity      ErrSeverity `json:"-"`
+	Cause         error       `json:"-"`
+	Value         any         `json:"-"`
+
+	// Transient errors, such as a lost connection, may succeed if retried.
+	// RetryAfter, if set, is how long clients should wait first; the error
+	// handler sends it as a Retry-After header.
+	Transient  bool          `json:"-"`
+	RetryAfter time.Duration `json:"-"`
+
+	// stack is where the error was wrapped, built or reported
+	stack []uintptr
 }
 
 // Error implements the error interface
@@ -38,15 +53,21 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%d: %s", e.Code, e.Message)
 }
 
-// Wrap wraps another error with this error's context
+// Wrap wraps another error with this error's context, recording the stack
+// trace of the call unless CaptureStacks is off
 func (e *Error) Wrap(cause error) *Error {
-	return NewErrorBuilder().
+	wrapped := NewErrorBuilder().
 		Code(e.Code).
 		Message(e.Message).
+		PublicMessage(e.PublicMessage).
 		HTTPStatus(e.HTTPStatus).
 		Severity(e.Severity).
+		Transient(e.Transient).
+		RetryAfter(e.RetryAfter).
 		Cause(cause).
 		Build()
+	wrapped.stack = stackIfEnabled(3)
+	return wrapped
 }
 
 // Unwrap returns the wrapped error for errors.Is/As support
@@ -56,22 +77,53 @@ func (e *Error) Unwrap() error {
 
 // WithValue adds a value to the error for debugging
 func (e *Error) WithValue(value any) *Error {
-	return NewErrorBuilder().
+	withValue := NewErrorBuilder().
 		Code(e.Code).
 		Message(e.Message).
+		PublicMessage(e.PublicMessage).
 		HTTPStatus(e.HTTPStatus).
 		Severity(e.Severity).
+		Transient(e.Transient).
+		RetryAfter(e.RetryAfter).
 		Cause(e.Cause).
 		Value(value).
 		Build()
+	withValue.stack = e.stack
+	return withValue
+}
+
+// WithPublicMessage returns a copy of the error that shows clients message
+func (e *Error) WithPublicMessage(message string) *Error {
+	withMessage := *e
+	withMessage.PublicMessage = message
+	return &withMessage
+}
+
+// WithRetryAfter returns a transient copy of the error telling clients to
+// retry after d, e.g. when a rate limit resets
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	withRetry := *e
+	withRetry.Transient = true
+	withRetry.RetryAfter = d
+	return &withRetry
+}
+
+// Public returns the message shown to clients: PublicMessage, or Message if
+// it is empty. Causes and values are never included.
+func (e *Error) Public() string {
+	if e.PublicMessage != "" {
+		return e.PublicMessage
+	}
+	return e.Message
 }
 
-// ErrorChain returns the full chain of wrapped errors
+// ErrorChain returns the full chain of wrapped errors, including those
+// wrapped by other errors in between, such as fmt.Errorf with %w
 func (e *Error) ErrorChain() string {
 	var chain string
 	for err := e; err != nil; {
 		chain += err.Error() + "\n"
-		if cause, ok := err.Cause.(*Error); ok {
+		if cause, ok := As(err.Cause); ok {
 			err = cause
 		} else {
 			break
@@ -90,6 +142,47 @@ func (e *Error) Is(target error) bool {
 	return false
 }
 
+// As returns the first *Error in err's chain, following Unwrap as the
+// standard errors.As does:
+//
+//	if e, ok := errors.As(err); ok && e.Severity == errors.ErrCritical {
+func As(err error) (*Error, bool) {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// CodeOf returns the code of the first *Error in err's chain, or 0 if there
+// is none
+func CodeOf(err error) int {
+	if e, ok := As(err); ok {
+		return e.Code
+	}
+	return 0
+}
+
+// HTTPStatusOf returns the status to respond to err with: the HTTPStatus of
+// the first *Error in its chain, or 500 if there is none or it has no
+// status. It returns 200 for nil.
+func HTTPStatusOf(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if e, ok := As(err); ok && e.HTTPStatus != 0 {
+		return e.HTTPStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// IsTransient reports whether the first *Error in err's chain is transient,
+// so the operation may succeed if retried
+func IsTransient(err error) bool {
+	e, ok := As(err)
+	return ok && e.Transient
+}
+
 // DisplayStatus returns the HTTP status as a string
 func (e *Error) DisplayStatus() string {
 	if e.HTTPStatus == 0 {
@@ -105,12 +198,16 @@ func (e *Error) DisplayCode() string {
 
 // ErrorBuilder provides a fluent interface for building errors
 type ErrorBuilder struct {
-	code       int
-	message    string
-	httpStatus int
-	severity   ErrSeverity
-	cause      error
-	value      any
+	code          int
+	message       string
+	publicMessage string
+	httpStatus    int
+	severity      ErrSeverity
+	cause         error
+	value         any
+	transient     bool
+	retryAfter    time.Duration
+	stack         bool
 }
 
 // NewErrorBuilder creates a new ErrorBuilder instance
@@ -130,6 +227,12 @@ func (b *ErrorBuilder) Message(message string) *ErrorBuilder {
 	return b
 }
 
+// PublicMessage sets the message shown to clients instead of Message
+func (b *ErrorBuilder) PublicMessage(message string) *ErrorBuilder {
+	b.publicMessage = message
+	return b
+}
+
 // HTTPStatus sets the HTTP status code
 func (b *ErrorBuilder) HTTPStatus(status int) *ErrorBuilder {
 	b.httpStatus = status
@@ -142,6 +245,18 @@ func (b *ErrorBuilder) Severity(severity ErrSeverity) *ErrorBuilder {
 	return b
 }
 
+// Transient sets whether the error may succeed if retried
+func (b *ErrorBuilder) Transient(transient bool) *ErrorBuilder {
+	b.transient = transient
+	return b
+}
+
+// RetryAfter sets how long clients should wait before retrying
+func (b *ErrorBuilder) RetryAfter(d time.Duration) *ErrorBuilder {
+	b.retryAfter = d
+	return b
+}
+
 // Cause sets the wrapped error
 func (b *ErrorBuilder) Cause(cause error) *ErrorBuilder {
 	b.cause = cause
@@ -154,14 +269,29 @@ func (b *ErrorBuilder) Value(value any) *ErrorBuilder {
 	return b
 }
 
+// CaptureStack records the stack trace of the Build call, unless
+// CaptureStacks is off. Use it for errors built where they occur, not for
+// package-level error variables.
+func (b *ErrorBuilder) CaptureStack() *ErrorBuilder {
+	b.stack = true
+	return b
+}
+
 // Build constructs the final Error
 func (b *ErrorBuilder) Build() *Error {
-	return &Error{
-		Code:       b.code,
-		Message:    b.message,
-		HTTPStatus: b.httpStatus,
-		Severity:   b.severity,
-		Cause:      b.cause,
-		Value:      b.value,
+	e := &Error{
+		Code:          b.code,
+		Message:       b.message,
+		PublicMessage: b.publicMessage,
+		HTTPStatus:    b.httpStatus,
+		Severity:      b.severity,
+		Cause:         b.cause,
+		Value:         b.value,
+		Transient:     b.transient,
+		RetryAfter:    b.retryAfter,
+	}
+	if b.stack {
+		e.stack = stackIfEnabled(3)
 	}
+	return e
 }
@@ -3,9 +3,13 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestError_Error tests the Error() method string formatting
@@ -143,6 +147,45 @@ func TestError_WithValue_PreservesCause(t *testing.T) {
 	assert.Contains(t, errorWithValue.Error(), "context")
 }
 
+// TestError_Public tests the message shown to clients
+func TestError_Public(t *testing.T) {
+	plain := NewErrorBuilder().Code(4001).Message("quota exceeded for tenant 42").Build()
+	assert.Equal(t, "quota exceeded for tenant 42", plain.Public(), "falls back to Message")
+
+	public := NewErrorBuilder().Code(4001).Message("quota exceeded for tenant 42").PublicMessage("Quota exceeded").Build()
+	assert.Equal(t, "Quota exceeded", public.Public())
+
+	wrapped := public.Wrap(fmt.Errorf("tenant 42 used 1001 of 1000")).WithValue(42)
+	assert.Equal(t, "Quota exceeded", wrapped.Public(), "Wrap and WithValue keep the public message")
+	assert.Contains(t, wrapped.Error(), "quota exceeded for tenant 42", "logs keep the internal message")
+
+	overridden := wrapped.WithPublicMessage("Upgrade your plan")
+	assert.Equal(t, "Upgrade your plan", overridden.Public())
+	assert.Equal(t, "Quota exceeded", wrapped.Public(), "WithPublicMessage doesn't change the original")
+	assert.Equal(t, wrapped.Cause, overridden.Cause)
+}
+
+// TestError_Transient tests marking errors as worth retrying
+func TestError_Transient(t *testing.T) {
+	assert.True(t, ErrDatabaseConn.Transient)
+	assert.Equal(t, 5*time.Second, ErrDatabaseConn.RetryAfter)
+	assert.False(t, ErrDatabaseRead.Transient)
+
+	wrapped := fmt.Errorf("loading user: %w", ErrDatabaseConn.Wrap(fmt.Errorf("refused")).WithValue(42))
+	assert.True(t, IsTransient(wrapped), "Wrap and WithValue keep Transient")
+	assert.False(t, IsTransient(ErrDatabaseRead.Wrap(fmt.Errorf("syntax error"))))
+	assert.False(t, IsTransient(fmt.Errorf("plain")))
+
+	limited := ErrDefaultMinor.WithRetryAfter(30 * time.Second)
+	assert.True(t, limited.Transient)
+	assert.Equal(t, 30*time.Second, limited.RetryAfter)
+	assert.False(t, ErrDefaultMinor.Transient, "WithRetryAfter doesn't change the original")
+
+	built := NewErrorBuilder().Code(4001).Transient(true).RetryAfter(time.Second).Build()
+	assert.True(t, built.Transient)
+	assert.Equal(t, time.Second, built.Wrap(fmt.Errorf("busy")).RetryAfter)
+}
+
 // TestError_ErrorChain tests the full error chain display
 func TestError_ErrorChain(t *testing.T) {
 	// Create a chain of custom errors
@@ -215,6 +258,51 @@ func TestError_Is(t *testing.T) {
 	}
 }
 
+// TestStdlibInterop tests finding an *Error through other wrapping errors
+func TestStdlibInterop(t *testing.T) {
+	base := fmt.Errorf("connection reset")
+	wrapped := fmt.Errorf("loading user 42: %w", ErrDatabaseRead.Wrap(base))
+
+	assert.True(t, errors.Is(wrapped, ErrDatabaseRead))
+	assert.True(t, errors.Is(wrapped, base))
+	assert.False(t, errors.Is(wrapped, ErrNotFound))
+
+	var target *Error
+	require.True(t, errors.As(wrapped, &target))
+	assert.Equal(t, ErrDatabaseRead.Code, target.Code)
+
+	e, ok := As(wrapped)
+	require.True(t, ok)
+	assert.Same(t, target, e)
+	_, ok = As(base)
+	assert.False(t, ok)
+
+	tests := []struct {
+		name   string
+		err    error
+		code   int
+		status int
+	}{
+		{"nil", nil, 0, http.StatusOK},
+		{"plain error", base, 0, http.StatusInternalServerError},
+		{"error without status", wrapped, ErrDatabaseRead.Code, http.StatusInternalServerError},
+		{"error with status", fmt.Errorf("user 42: %w", ErrNotFound), ErrNotFound.Code, http.StatusNotFound},
+		{"outermost error wins", ErrAPIObjectNotFound.Wrap(ErrDatabaseRead), ErrAPIObjectNotFound.Code, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, CodeOf(tt.err))
+			assert.Equal(t, tt.status, HTTPStatusOf(tt.err))
+		})
+	}
+
+	t.Run("error chain", func(t *testing.T) {
+		chain := ErrAPIGet.Wrap(wrapped).ErrorChain()
+		assert.Equal(t, 2, strings.Count(chain, "\n"), chain)
+		assert.True(t, strings.HasSuffix(chain, "\n2101: Failed to read from database: connection reset\n"), "the chain continues past fmt.Errorf")
+	})
+}
+
 // TestError_DisplayStatus tests HTTP status display
 func TestError_DisplayStatus(t *testing.T) {
 	tests := []struct {
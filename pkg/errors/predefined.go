@@ -1,21 +1,35 @@
 package errors
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 // Predefined errors follow a naming convention of Err<Description>
 
+// internalMessage is shown to clients instead of the messages of critical
+// and database errors, which describe the server's internals
+const internalMessage = "Internal server error"
+
+// StatusClientClosedRequest is the status of requests the client abandoned
+// before the response, as nginx logs them. It is never sent.
+const StatusClientClosedRequest = 499
+
 var (
 	// 1000 level errors are CRITICAL severity
-	ErrDefaultCritical = NewErrorBuilder().Code(1000).Severity(ErrCritical).Message("DEFAULT OR UNKNOWN CRITICAL APPLICATION ERROR!!!").Build()
-	ErrListenAndServe  = NewErrorBuilder().Code(1001).Severity(ErrCritical).Message("FAILED TO LISTEN AND SERVE").Build()
-	ErrShutdownServer  = NewErrorBuilder().Code(1002).Severity(ErrCritical).Message("FAILED TO SHUTDOWN SERVER").Build()
+	ErrDefaultCritical = NewErrorBuilder().Code(1000).Severity(ErrCritical).Message("DEFAULT OR UNKNOWN CRITICAL APPLICATION ERROR!!!").PublicMessage(internalMessage).Build()
+	ErrListenAndServe  = NewErrorBuilder().Code(1001).Severity(ErrCritical).Message("FAILED TO LISTEN AND SERVE").PublicMessage(internalMessage).Build()
+	ErrShutdownServer  = NewErrorBuilder().Code(1002).Severity(ErrCritical).Message("FAILED TO SHUTDOWN SERVER").PublicMessage(internalMessage).Build()
+	ErrInvalidConfig   = NewErrorBuilder().Code(1003).Severity(ErrCritical).Message("INVALID CONFIGURATION").PublicMessage(internalMessage).Build()
+	ErrShutdownHook    = NewErrorBuilder().Code(1004).Severity(ErrCritical).Message("SHUTDOWN HOOK FAILED").PublicMessage(internalMessage).Build()
+	ErrPanic           = NewErrorBuilder().Code(1005).Severity(ErrCritical).Message("HANDLER PANICKED").PublicMessage(internalMessage).Build()
 
 	// 1100 level errors are DATABASE critical errors
-	ErrDatabaseDefaultCritical = NewErrorBuilder().Code(1100).Severity(ErrCritical).Message("DEFAULT OR UNKNOWN CRITICAL DATABASE ERROR!!!").Build()
-	ErrDatabaseLoad            = NewErrorBuilder().Code(1101).Severity(ErrCritical).Message("FAILED TO LOAD DATABASE").Build()
-	ErrDatabaseConn            = NewErrorBuilder().Code(1102).Severity(ErrCritical).Message("FAILED TO CONNECT TO DATABASE").Build()
-	ErrDatabaseMigration       = NewErrorBuilder().Code(1103).Severity(ErrCritical).Message("FAILED TO MIGRATE DATABASE").Build()
-	ErrDatabaseSeed            = NewErrorBuilder().Code(1104).Severity(ErrCritical).Message("FAILED TO SEED DATABASE").Build()
+	ErrDatabaseDefaultCritical = NewErrorBuilder().Code(1100).Severity(ErrCritical).Message("DEFAULT OR UNKNOWN CRITICAL DATABASE ERROR!!!").PublicMessage(internalMessage).Build()
+	ErrDatabaseLoad            = NewErrorBuilder().Code(1101).Severity(ErrCritical).Message("FAILED TO LOAD DATABASE").PublicMessage(internalMessage).Build()
+	ErrDatabaseConn            = NewErrorBuilder().Code(1102).Severity(ErrCritical).HTTPStatus(http.StatusServiceUnavailable).Transient(true).RetryAfter(5 * time.Second).Message("FAILED TO CONNECT TO DATABASE").PublicMessage("Service temporarily unavailable").Build()
+	ErrDatabaseMigration       = NewErrorBuilder().Code(1103).Severity(ErrCritical).Message("FAILED TO MIGRATE DATABASE").PublicMessage(internalMessage).Build()
+	ErrDatabaseSeed            = NewErrorBuilder().Code(1104).Severity(ErrCritical).Message("FAILED TO SEED DATABASE").PublicMessage(internalMessage).Build()
 
 	// 2000 level errors are ERROR severity
 	ErrDefaultError = NewErrorBuilder().Code(2000).Severity(ErrError).Message("Default or unknown error").Build()
@@ -23,21 +37,29 @@ var (
 	ErrNotFound     = NewErrorBuilder().Code(2002).Severity(ErrError).HTTPStatus(http.StatusNotFound).Message("Not found").Build()
 
 	// 2100 level errors are for DATABASE errors
-	ErrDatabaseDefaultError = NewErrorBuilder().Code(2100).Severity(ErrError).Message("Default or unknown database error").Build()
-	ErrDatabaseRead         = NewErrorBuilder().Code(2101).Severity(ErrError).Message("Failed to read from database").Build()
-	ErrDatabaseWrite        = NewErrorBuilder().Code(2102).Severity(ErrError).Message("Failed to write to database").Build()
-	ErrDatabaseUpdate       = NewErrorBuilder().Code(2103).Severity(ErrError).Message("Failed to update database").Build()
-	ErrDatabaseDelete       = NewErrorBuilder().Code(2104).Severity(ErrError).Message("Failed to delete from database").Build()
-	ErrMigrateTable         = NewErrorBuilder().Code(2105).Severity(ErrError).Message("Failed to migrate database table").Build()
-	ErrSortMigrations       = NewErrorBuilder().Code(2106).Severity(ErrError).Message("Failed to sort migrations").Build()
-	ErrSeedObject           = NewErrorBuilder().Code(2107).Severity(ErrError).Message("Failed to seed object").Build()
+	ErrDatabaseDefaultError = NewErrorBuilder().Code(2100).Severity(ErrError).Message("Default or unknown database error").PublicMessage(internalMessage).Build()
+	ErrDatabaseRead         = NewErrorBuilder().Code(2101).Severity(ErrError).Message("Failed to read from database").PublicMessage(internalMessage).Build()
+	ErrDatabaseWrite        = NewErrorBuilder().Code(2102).Severity(ErrError).Message("Failed to write to database").PublicMessage(internalMessage).Build()
+	ErrDatabaseUpdate       = NewErrorBuilder().Code(2103).Severity(ErrError).Message("Failed to update database").PublicMessage(internalMessage).Build()
+	ErrDatabaseDelete       = NewErrorBuilder().Code(2104).Severity(ErrError).Message("Failed to delete from database").PublicMessage(internalMessage).Build()
+	ErrMigrateTable         = NewErrorBuilder().Code(2105).Severity(ErrError).Message("Failed to migrate database table").PublicMessage(internalMessage).Build()
+	ErrSortMigrations       = NewErrorBuilder().Code(2106).Severity(ErrError).Message("Failed to sort migrations").PublicMessage(internalMessage).Build()
+	ErrSeedObject           = NewErrorBuilder().Code(2107).Severity(ErrError).Message("Failed to seed object").PublicMessage(internalMessage).Build()
+	ErrRollbackMigration    = NewErrorBuilder().Code(2108).Severity(ErrError).Message("Failed to roll back migration").PublicMessage(internalMessage).Build()
+	ErrDatabaseTransaction  = NewErrorBuilder().Code(2109).Severity(ErrError).Message("Failed to run database transaction").PublicMessage(internalMessage).Build()
 
 	// 2200 level errors are for AUTH errors
-	ErrAuthDefault    = NewErrorBuilder().Code(2200).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Default or unknown AUTH error").Build()
-	ErrHashPassword   = NewErrorBuilder().Code(2201).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to hash password").Build()
-	ErrGenerateToken  = NewErrorBuilder().Code(2202).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to generate token").Build()
-	ErrGetPermissions = NewErrorBuilder().Code(2203).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to get IAM permissions").Build()
-	ErrGetCookie      = NewErrorBuilder().Code(2204).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to get cookie").Build()
+	ErrAuthDefault       = NewErrorBuilder().Code(2200).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Default or unknown AUTH error").Build()
+	ErrHashPassword      = NewErrorBuilder().Code(2201).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to hash password").Build()
+	ErrGenerateToken     = NewErrorBuilder().Code(2202).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to generate token").Build()
+	ErrGetPermissions    = NewErrorBuilder().Code(2203).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to get IAM permissions").Build()
+	ErrGetCookie         = NewErrorBuilder().Code(2204).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to get cookie").Build()
+	ErrRefreshToken      = NewErrorBuilder().Code(2205).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to refresh token").Build()
+	ErrLoadSigningKey    = NewErrorBuilder().Code(2206).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to load token signing key").Build()
+	ErrSessionStore      = NewErrorBuilder().Code(2207).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to access session store").Build()
+	ErrUpdatePermissions = NewErrorBuilder().Code(2208).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to update IAM permissions").Build()
+	ErrOAuthExchange     = NewErrorBuilder().Code(2209).Severity(ErrError).HTTPStatus(http.StatusBadGateway).Message("Failed to sign in with provider").Build()
+	ErrRevocationStore   = NewErrorBuilder().Code(2210).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to read or write revoked tokens").Build()
 
 	// 2300 level errors are for API errors
 	ErrAPIDefault = NewErrorBuilder().Code(2300).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Default or unknown API error").Build()
@@ -46,13 +68,34 @@ var (
 	ErrAPIPut     = NewErrorBuilder().Code(2303).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to PUT data").Build()
 	ErrAPIDelete  = NewErrorBuilder().Code(2304).Severity(ErrError).HTTPStatus(http.StatusInternalServerError).Message("Failed to DELETE data").Build()
 
+	// 2400 level errors are for background job errors
+	ErrEnqueueJob = NewErrorBuilder().Code(2400).Severity(ErrError).Message("Failed to enqueue job").PublicMessage(internalMessage).Build()
+	ErrRunJob     = NewErrorBuilder().Code(2401).Severity(ErrError).Message("Failed to run job").PublicMessage(internalMessage).Build()
+	ErrRunTask    = NewErrorBuilder().Code(2402).Severity(ErrError).Message("Failed to run scheduled task").PublicMessage(internalMessage).Build()
+
+	// 2500 level errors are for file storage errors
+	ErrStorageWrite  = NewErrorBuilder().Code(2500).Severity(ErrError).Message("Failed to write file").PublicMessage(internalMessage).Build()
+	ErrStorageRead   = NewErrorBuilder().Code(2501).Severity(ErrError).Message("Failed to read file").PublicMessage(internalMessage).Build()
+	ErrStorageDelete = NewErrorBuilder().Code(2502).Severity(ErrError).Message("Failed to delete file").PublicMessage(internalMessage).Build()
+
+	// 2600 level errors are for realtime errors
+	ErrPublishEvent = NewErrorBuilder().Code(2600).Severity(ErrError).Message("Failed to publish realtime event").PublicMessage(internalMessage).Build()
+	ErrStreamEvents = NewErrorBuilder().Code(2601).Severity(ErrError).Message("Failed to stream events").PublicMessage(internalMessage).Build()
+
+	// 2700 level errors are for outbound HTTP errors
+	ErrCircuitOpen = NewErrorBuilder().Code(2700).Severity(ErrError).HTTPStatus(http.StatusServiceUnavailable).Transient(true).Message("Circuit open after repeated upstream failures").PublicMessage("Service temporarily unavailable").Build()
+
 	// 3000 level errors are MINOR severity
 	ErrDefaultMinor = NewErrorBuilder().Code(3000).Severity(ErrMinor).HTTPStatus(http.StatusInternalServerError).Message("Default or unknown warning").Build()
 	ErrDecodeForm   = NewErrorBuilder().Code(3001).Severity(ErrMinor).Message("Failed to decode form").Build()
+	ErrAborted      = NewErrorBuilder().Code(3002).Severity(ErrMinor).HTTPStatus(StatusClientClosedRequest).Message("Client closed request").Build()
 
 	// 3100 level errors are for DATABASE minor errors
 	ErrDatabaseDefaultMinor   = NewErrorBuilder().Code(3100).Severity(ErrMinor).HTTPStatus(http.StatusInternalServerError).Message("Default or unknown database warning").Build()
 	ErrDatabaseObjectNotFound = NewErrorBuilder().Code(3101).Severity(ErrMinor).HTTPStatus(http.StatusNotFound).Message("Object not found").Build()
+	ErrDatabaseInvalidColumn  = NewErrorBuilder().Code(3102).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Invalid sort or filter column").Build()
+	ErrDatabaseInvalidCursor  = NewErrorBuilder().Code(3103).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Invalid cursor").Build()
+	ErrDatabaseConflict       = NewErrorBuilder().Code(3104).Severity(ErrMinor).HTTPStatus(http.StatusConflict).Message("Record was changed by another request").Build()
 
 	// 3200 level errors are for AUTH minor errors
 	ErrAuthDefaultMinor          = NewErrorBuilder().Code(3200).Severity(ErrMinor).HTTPStatus(http.StatusInternalServerError).Message("Default or unknown AUTH warning").Build()
@@ -63,6 +106,13 @@ var (
 	ErrInsufficientPermissions   = NewErrorBuilder().Code(3205).Severity(ErrMinor).HTTPStatus(http.StatusForbidden).Message("Insufficient permissions").Build()
 	ErrAuthMissingHeader         = NewErrorBuilder().Code(3206).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Missing Authorization header").Build()
 	ErrAuthMissingAuthTypeHeader = NewErrorBuilder().Code(3207).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Missing Authorization-Type header").Build()
+	ErrAuthTokenReused           = NewErrorBuilder().Code(3208).Severity(ErrMinor).HTTPStatus(http.StatusUnauthorized).Message("Refresh token was already used or revoked").Build()
+	ErrAuthNoSession             = NewErrorBuilder().Code(3209).Severity(ErrMinor).HTTPStatus(http.StatusUnauthorized).Message("Not signed in").Build()
+	ErrAuthOAuthState            = NewErrorBuilder().Code(3210).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Sign-in expired or was not started here").Build()
+	ErrAuthUnknownProvider       = NewErrorBuilder().Code(3211).Severity(ErrMinor).HTTPStatus(http.StatusNotFound).Message("Unknown sign-in provider").Build()
+	ErrAuthOAuthDenied           = NewErrorBuilder().Code(3212).Severity(ErrMinor).HTTPStatus(http.StatusUnauthorized).Message("Sign-in was cancelled at the provider").Build()
+	ErrAuthRevokedToken          = NewErrorBuilder().Code(3213).Severity(ErrMinor).HTTPStatus(http.StatusUnauthorized).Message("Token was revoked").Build()
+	ErrAuthCrossSite             = NewErrorBuilder().Code(3214).Severity(ErrMinor).HTTPStatus(http.StatusForbidden).Message("Cross-site request refused").Build()
 
 	// 3300 level errors are for API minor errors
 	ErrAPIDefaultMinor       = NewErrorBuilder().Code(3300).Severity(ErrMinor).HTTPStatus(http.StatusInternalServerError).Message("Default or unknown API warning").Build()
@@ -71,4 +121,116 @@ var (
 	ErrAPIPathValue          = NewErrorBuilder().Code(3303).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Invalid path value").Build()
 	ErrAPIObjectNotFound     = NewErrorBuilder().Code(3304).Severity(ErrMinor).HTTPStatus(http.StatusNotFound).Message("Object not found").Build()
 	ErrAPIRequestContentType = NewErrorBuilder().Code(3305).Severity(ErrMinor).HTTPStatus(http.StatusUnsupportedMediaType).Message("Unsupported content type").Build()
+	ErrAPIRateLimited        = NewErrorBuilder().Code(3306).Severity(ErrMinor).HTTPStatus(http.StatusTooManyRequests).Transient(true).RetryAfter(time.Minute).Message("Too many requests").Build()
+	ErrAPIQueryParam         = NewErrorBuilder().Code(3307).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Invalid query parameter").Build()
+	ErrAPIValidation         = NewErrorBuilder().Code(3308).Severity(ErrMinor).HTTPStatus(http.StatusUnprocessableEntity).Message("Validation failed").Build()
+	ErrAPIWebhookSignature   = NewErrorBuilder().Code(3309).Severity(ErrMinor).HTTPStatus(http.StatusUnauthorized).Message("Invalid or expired webhook signature").Build()
+	ErrAPIBotDetected        = NewErrorBuilder().Code(3310).Severity(ErrMinor).HTTPStatus(http.StatusForbidden).Message("Request looks automated").Build()
+	ErrAPIIPForbidden        = NewErrorBuilder().Code(3311).Severity(ErrMinor).HTTPStatus(http.StatusForbidden).Message("Address not allowed").Build()
+	ErrAPIOverloaded         = NewErrorBuilder().Code(3312).Severity(ErrMinor).HTTPStatus(http.StatusServiceUnavailable).Transient(true).RetryAfter(time.Second).Message("Too many requests in progress").Build()
+	ErrAPITimeout            = NewErrorBuilder().Code(3313).Severity(ErrMinor).HTTPStatus(http.StatusGatewayTimeout).Message("Request timed out").Build()
+
+	// 3400 level errors are for background job minor errors
+	ErrJobNotFound = NewErrorBuilder().Code(3400).Severity(ErrMinor).HTTPStatus(http.StatusNotFound).Message("Job not found").Build()
+
+	// 3500 level errors are for file storage minor errors
+	ErrFileNotFound     = NewErrorBuilder().Code(3500).Severity(ErrMinor).HTTPStatus(http.StatusNotFound).Message("File not found").Build()
+	ErrInvalidFileKey   = NewErrorBuilder().Code(3501).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Invalid file key").Build()
+	ErrUploadFile       = NewErrorBuilder().Code(3502).Severity(ErrMinor).HTTPStatus(http.StatusBadRequest).Message("Missing or invalid file upload").Build()
+	ErrInvalidSignature = NewErrorBuilder().Code(3503).Severity(ErrMinor).HTTPStatus(http.StatusForbidden).Message("Invalid or expired signature").Build()
 )
+
+// predefined lists the errors above, which Lookup and Registered return
+var predefined = []*Error{
+	ErrDefaultCritical,
+	ErrListenAndServe,
+	ErrShutdownServer,
+	ErrInvalidConfig,
+	ErrShutdownHook,
+	ErrPanic,
+	ErrDatabaseDefaultCritical,
+	ErrDatabaseLoad,
+	ErrDatabaseConn,
+	ErrDatabaseMigration,
+	ErrDatabaseSeed,
+	ErrDefaultError,
+	ErrDecodeJSON,
+	ErrNotFound,
+	ErrDatabaseDefaultError,
+	ErrDatabaseRead,
+	ErrDatabaseWrite,
+	ErrDatabaseUpdate,
+	ErrDatabaseDelete,
+	ErrMigrateTable,
+	ErrSortMigrations,
+	ErrSeedObject,
+	ErrRollbackMigration,
+	ErrDatabaseTransaction,
+	ErrAuthDefault,
+	ErrHashPassword,
+	ErrGenerateToken,
+	ErrGetPermissions,
+	ErrGetCookie,
+	ErrRefreshToken,
+	ErrLoadSigningKey,
+	ErrSessionStore,
+	ErrUpdatePermissions,
+	ErrOAuthExchange,
+	ErrRevocationStore,
+	ErrAPIDefault,
+	ErrAPIGet,
+	ErrAPIPost,
+	ErrAPIPut,
+	ErrAPIDelete,
+	ErrEnqueueJob,
+	ErrRunJob,
+	ErrRunTask,
+	ErrStorageWrite,
+	ErrStorageRead,
+	ErrStorageDelete,
+	ErrPublishEvent,
+	ErrStreamEvents,
+	ErrCircuitOpen,
+	ErrDefaultMinor,
+	ErrDecodeForm,
+	ErrAborted,
+	ErrDatabaseDefaultMinor,
+	ErrDatabaseObjectNotFound,
+	ErrDatabaseInvalidColumn,
+	ErrDatabaseInvalidCursor,
+	ErrDatabaseConflict,
+	ErrAuthDefaultMinor,
+	ErrAuthInvalidToken,
+	ErrAuthExpiredToken,
+	ErrAuthInvalidCredentials,
+	ErrPrimaryEmailNotFound,
+	ErrInsufficientPermissions,
+	ErrAuthMissingHeader,
+	ErrAuthMissingAuthTypeHeader,
+	ErrAuthTokenReused,
+	ErrAuthNoSession,
+	ErrAuthOAuthState,
+	ErrAuthUnknownProvider,
+	ErrAuthOAuthDenied,
+	ErrAuthRevokedToken,
+	ErrAuthCrossSite,
+	ErrAPIDefaultMinor,
+	ErrAPIIDMismatch,
+	ErrAPIRequestPayload,
+	ErrAPIPathValue,
+	ErrAPIObjectNotFound,
+	ErrAPIRequestContentType,
+	ErrAPIRateLimited,
+	ErrAPIQueryParam,
+	ErrAPIValidation,
+	ErrAPIWebhookSignature,
+	ErrAPIBotDetected,
+	ErrAPIIPForbidden,
+	ErrAPIOverloaded,
+	ErrAPITimeout,
+	ErrJobNotFound,
+	ErrFileNotFound,
+	ErrInvalidFileKey,
+	ErrUploadFile,
+	ErrInvalidSignature,
+}
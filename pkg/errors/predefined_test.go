@@ -14,6 +14,8 @@ func TestPredefinedErrors_Exist(t *testing.T) {
 		ErrDefaultCritical,
 		ErrListenAndServe,
 		ErrShutdownServer,
+		ErrShutdownHook,
+		ErrPanic,
 		// 1100 level - DATABASE CRITICAL
 		ErrDatabaseDefaultCritical,
 		ErrDatabaseLoad,
@@ -33,24 +35,48 @@ func TestPredefinedErrors_Exist(t *testing.T) {
 		ErrMigrateTable,
 		ErrSortMigrations,
 		ErrSeedObject,
+		ErrRollbackMigration,
+		ErrDatabaseTransaction,
 		// 2200 level - AUTH ERROR
 		ErrAuthDefault,
 		ErrHashPassword,
 		ErrGenerateToken,
 		ErrGetPermissions,
 		ErrGetCookie,
+		ErrRefreshToken,
+		ErrLoadSigningKey,
+		ErrSessionStore,
+		ErrUpdatePermissions,
+		ErrOAuthExchange,
+		ErrRevocationStore,
 		// 2300 level - API ERROR
 		ErrAPIDefault,
 		ErrAPIGet,
 		ErrAPIPost,
 		ErrAPIPut,
 		ErrAPIDelete,
+		// 2400 level - JOBS ERROR
+		ErrEnqueueJob,
+		ErrRunJob,
+		ErrRunTask,
+		// 2500 level - STORAGE ERROR
+		ErrStorageWrite,
+		ErrStorageRead,
+		ErrStorageDelete,
+		// 2600 level - REALTIME ERROR
+		ErrPublishEvent,
+		ErrStreamEvents,
+		ErrCircuitOpen,
 		// 3000 level - MINOR
 		ErrDefaultMinor,
 		ErrDecodeForm,
+		ErrAborted,
 		// 3100 level - DATABASE MINOR
 		ErrDatabaseDefaultMinor,
 		ErrDatabaseObjectNotFound,
+		ErrDatabaseInvalidColumn,
+		ErrDatabaseInvalidCursor,
+		ErrDatabaseConflict,
 		// 3200 level - AUTH MINOR
 		ErrAuthDefaultMinor,
 		ErrAuthInvalidToken,
@@ -60,6 +86,13 @@ func TestPredefinedErrors_Exist(t *testing.T) {
 		ErrInsufficientPermissions,
 		ErrAuthMissingHeader,
 		ErrAuthMissingAuthTypeHeader,
+		ErrAuthTokenReused,
+		ErrAuthNoSession,
+		ErrAuthOAuthState,
+		ErrAuthUnknownProvider,
+		ErrAuthOAuthDenied,
+		ErrAuthRevokedToken,
+		ErrAuthCrossSite,
 		// 3300 level - API MINOR
 		ErrAPIDefaultMinor,
 		ErrAPIIDMismatch,
@@ -67,6 +100,21 @@ func TestPredefinedErrors_Exist(t *testing.T) {
 		ErrAPIPathValue,
 		ErrAPIObjectNotFound,
 		ErrAPIRequestContentType,
+		ErrAPIRateLimited,
+		ErrAPIQueryParam,
+		ErrAPIValidation,
+		ErrAPIWebhookSignature,
+		ErrAPIBotDetected,
+		ErrAPIIPForbidden,
+		ErrAPIOverloaded,
+		ErrAPITimeout,
+		// 3400 level - JOBS MINOR
+		ErrJobNotFound,
+		// 3500 level - STORAGE MINOR
+		ErrFileNotFound,
+		ErrInvalidFileKey,
+		ErrUploadFile,
+		ErrInvalidSignature,
 	}
 
 	for _, err := range predefinedErrors {
@@ -87,6 +135,8 @@ func TestPredefinedErrors_SeverityLevels(t *testing.T) {
 		{"ErrDefaultCritical", ErrDefaultCritical, ErrCritical},
 		{"ErrListenAndServe", ErrListenAndServe, ErrCritical},
 		{"ErrShutdownServer", ErrShutdownServer, ErrCritical},
+		{"ErrShutdownHook", ErrShutdownHook, ErrCritical},
+		{"ErrPanic", ErrPanic, ErrCritical},
 		{"ErrDatabaseDefaultCritical", ErrDatabaseDefaultCritical, ErrCritical},
 		{"ErrDatabaseLoad", ErrDatabaseLoad, ErrCritical},
 		{"ErrDatabaseConn", ErrDatabaseConn, ErrCritical},
@@ -105,22 +155,43 @@ func TestPredefinedErrors_SeverityLevels(t *testing.T) {
 		{"ErrMigrateTable", ErrMigrateTable, ErrError},
 		{"ErrSortMigrations", ErrSortMigrations, ErrError},
 		{"ErrSeedObject", ErrSeedObject, ErrError},
+		{"ErrRollbackMigration", ErrRollbackMigration, ErrError},
+		{"ErrDatabaseTransaction", ErrDatabaseTransaction, ErrError},
 		{"ErrAuthDefault", ErrAuthDefault, ErrError},
 		{"ErrHashPassword", ErrHashPassword, ErrError},
 		{"ErrGenerateToken", ErrGenerateToken, ErrError},
 		{"ErrGetPermissions", ErrGetPermissions, ErrError},
 		{"ErrGetCookie", ErrGetCookie, ErrError},
+		{"ErrRefreshToken", ErrRefreshToken, ErrError},
+		{"ErrLoadSigningKey", ErrLoadSigningKey, ErrError},
+		{"ErrSessionStore", ErrSessionStore, ErrError},
+		{"ErrUpdatePermissions", ErrUpdatePermissions, ErrError},
+		{"ErrOAuthExchange", ErrOAuthExchange, ErrError},
+		{"ErrRevocationStore", ErrRevocationStore, ErrError},
 		{"ErrAPIDefault", ErrAPIDefault, ErrError},
 		{"ErrAPIGet", ErrAPIGet, ErrError},
 		{"ErrAPIPost", ErrAPIPost, ErrError},
 		{"ErrAPIPut", ErrAPIPut, ErrError},
 		{"ErrAPIDelete", ErrAPIDelete, ErrError},
+		{"ErrEnqueueJob", ErrEnqueueJob, ErrError},
+		{"ErrRunJob", ErrRunJob, ErrError},
+		{"ErrRunTask", ErrRunTask, ErrError},
+		{"ErrStorageWrite", ErrStorageWrite, ErrError},
+		{"ErrStorageRead", ErrStorageRead, ErrError},
+		{"ErrStorageDelete", ErrStorageDelete, ErrError},
+		{"ErrPublishEvent", ErrPublishEvent, ErrError},
+		{"ErrStreamEvents", ErrStreamEvents, ErrError},
+		{"ErrCircuitOpen", ErrCircuitOpen, ErrError},
 
 		// 3000-3999: MINOR
 		{"ErrDefaultMinor", ErrDefaultMinor, ErrMinor},
 		{"ErrDecodeForm", ErrDecodeForm, ErrMinor},
+		{"ErrAborted", ErrAborted, ErrMinor},
 		{"ErrDatabaseDefaultMinor", ErrDatabaseDefaultMinor, ErrMinor},
 		{"ErrDatabaseObjectNotFound", ErrDatabaseObjectNotFound, ErrMinor},
+		{"ErrDatabaseInvalidColumn", ErrDatabaseInvalidColumn, ErrMinor},
+		{"ErrDatabaseInvalidCursor", ErrDatabaseInvalidCursor, ErrMinor},
+		{"ErrDatabaseConflict", ErrDatabaseConflict, ErrMinor},
 		{"ErrAuthDefaultMinor", ErrAuthDefaultMinor, ErrMinor},
 		{"ErrAuthInvalidToken", ErrAuthInvalidToken, ErrMinor},
 		{"ErrAuthExpiredToken", ErrAuthExpiredToken, ErrMinor},
@@ -129,12 +200,32 @@ func TestPredefinedErrors_SeverityLevels(t *testing.T) {
 		{"ErrInsufficientPermissions", ErrInsufficientPermissions, ErrMinor},
 		{"ErrAuthMissingHeader", ErrAuthMissingHeader, ErrMinor},
 		{"ErrAuthMissingAuthTypeHeader", ErrAuthMissingAuthTypeHeader, ErrMinor},
+		{"ErrAuthTokenReused", ErrAuthTokenReused, ErrMinor},
+		{"ErrAuthNoSession", ErrAuthNoSession, ErrMinor},
+		{"ErrAuthOAuthState", ErrAuthOAuthState, ErrMinor},
+		{"ErrAuthUnknownProvider", ErrAuthUnknownProvider, ErrMinor},
+		{"ErrAuthOAuthDenied", ErrAuthOAuthDenied, ErrMinor},
+		{"ErrAuthRevokedToken", ErrAuthRevokedToken, ErrMinor},
+		{"ErrAuthCrossSite", ErrAuthCrossSite, ErrMinor},
 		{"ErrAPIDefaultMinor", ErrAPIDefaultMinor, ErrMinor},
 		{"ErrAPIIDMismatch", ErrAPIIDMismatch, ErrMinor},
 		{"ErrAPIRequestPayload", ErrAPIRequestPayload, ErrMinor},
 		{"ErrAPIPathValue", ErrAPIPathValue, ErrMinor},
 		{"ErrAPIObjectNotFound", ErrAPIObjectNotFound, ErrMinor},
 		{"ErrAPIRequestContentType", ErrAPIRequestContentType, ErrMinor},
+		{"ErrAPIRateLimited", ErrAPIRateLimited, ErrMinor},
+		{"ErrAPIQueryParam", ErrAPIQueryParam, ErrMinor},
+		{"ErrAPIValidation", ErrAPIValidation, ErrMinor},
+		{"ErrAPIWebhookSignature", ErrAPIWebhookSignature, ErrMinor},
+		{"ErrAPIBotDetected", ErrAPIBotDetected, ErrMinor},
+		{"ErrAPIIPForbidden", ErrAPIIPForbidden, ErrMinor},
+		{"ErrAPIOverloaded", ErrAPIOverloaded, ErrMinor},
+		{"ErrAPITimeout", ErrAPITimeout, ErrMinor},
+		{"ErrJobNotFound", ErrJobNotFound, ErrMinor},
+		{"ErrFileNotFound", ErrFileNotFound, ErrMinor},
+		{"ErrInvalidFileKey", ErrInvalidFileKey, ErrMinor},
+		{"ErrUploadFile", ErrUploadFile, ErrMinor},
+		{"ErrInvalidSignature", ErrInvalidSignature, ErrMinor},
 	}
 
 	for _, tt := range tests {
@@ -155,33 +246,70 @@ func TestPredefinedErrors_HTTPStatusCodes(t *testing.T) {
 		// 404 Not Found
 		{"ErrNotFound", ErrNotFound, http.StatusNotFound},
 		{"ErrDatabaseObjectNotFound", ErrDatabaseObjectNotFound, http.StatusNotFound},
+		{"ErrDatabaseInvalidColumn", ErrDatabaseInvalidColumn, http.StatusBadRequest},
+		{"ErrDatabaseInvalidCursor", ErrDatabaseInvalidCursor, http.StatusBadRequest},
+		{"ErrDatabaseConflict", ErrDatabaseConflict, http.StatusConflict},
 		{"ErrPrimaryEmailNotFound", ErrPrimaryEmailNotFound, http.StatusNotFound},
 		{"ErrAPIObjectNotFound", ErrAPIObjectNotFound, http.StatusNotFound},
+		{"ErrJobNotFound", ErrJobNotFound, http.StatusNotFound},
+		{"ErrAuthUnknownProvider", ErrAuthUnknownProvider, http.StatusNotFound},
+		{"ErrFileNotFound", ErrFileNotFound, http.StatusNotFound},
 
 		// 401 Unauthorized
 		{"ErrAuthInvalidToken", ErrAuthInvalidToken, http.StatusUnauthorized},
 		{"ErrAuthExpiredToken", ErrAuthExpiredToken, http.StatusUnauthorized},
 		{"ErrAuthInvalidCredentials", ErrAuthInvalidCredentials, http.StatusUnauthorized},
+		{"ErrAuthTokenReused", ErrAuthTokenReused, http.StatusUnauthorized},
+		{"ErrAuthNoSession", ErrAuthNoSession, http.StatusUnauthorized},
+		{"ErrAuthOAuthDenied", ErrAuthOAuthDenied, http.StatusUnauthorized},
+		{"ErrAuthRevokedToken", ErrAuthRevokedToken, http.StatusUnauthorized},
 
 		// 403 Forbidden
 		{"ErrInsufficientPermissions", ErrInsufficientPermissions, http.StatusForbidden},
+		{"ErrInvalidSignature", ErrInvalidSignature, http.StatusForbidden},
+		{"ErrAuthCrossSite", ErrAuthCrossSite, http.StatusForbidden},
+		{"ErrAPIBotDetected", ErrAPIBotDetected, http.StatusForbidden},
+		{"ErrAPIIPForbidden", ErrAPIIPForbidden, http.StatusForbidden},
 
 		// 400 Bad Request
 		{"ErrAuthMissingHeader", ErrAuthMissingHeader, http.StatusBadRequest},
 		{"ErrAuthMissingAuthTypeHeader", ErrAuthMissingAuthTypeHeader, http.StatusBadRequest},
+		{"ErrAuthOAuthState", ErrAuthOAuthState, http.StatusBadRequest},
 		{"ErrAPIIDMismatch", ErrAPIIDMismatch, http.StatusBadRequest},
+		{"ErrInvalidFileKey", ErrInvalidFileKey, http.StatusBadRequest},
+		{"ErrUploadFile", ErrUploadFile, http.StatusBadRequest},
 		{"ErrAPIRequestPayload", ErrAPIRequestPayload, http.StatusBadRequest},
 		{"ErrAPIPathValue", ErrAPIPathValue, http.StatusBadRequest},
 
 		// 415 Unsupported Media Type
 		{"ErrAPIRequestContentType", ErrAPIRequestContentType, http.StatusUnsupportedMediaType},
 
+		// 429 Too Many Requests
+		{"ErrAPIRateLimited", ErrAPIRateLimited, http.StatusTooManyRequests},
+		{"ErrAPIQueryParam", ErrAPIQueryParam, http.StatusBadRequest},
+		{"ErrAPIValidation", ErrAPIValidation, http.StatusUnprocessableEntity},
+		{"ErrAPIWebhookSignature", ErrAPIWebhookSignature, http.StatusUnauthorized},
+
+		// 502 Bad Gateway
+		{"ErrOAuthExchange", ErrOAuthExchange, http.StatusBadGateway},
+
+		// 503 Service Unavailable
+		{"ErrDatabaseConn", ErrDatabaseConn, http.StatusServiceUnavailable},
+		{"ErrCircuitOpen", ErrCircuitOpen, http.StatusServiceUnavailable},
+		{"ErrAPIOverloaded", ErrAPIOverloaded, http.StatusServiceUnavailable},
+		{"ErrAPITimeout", ErrAPITimeout, http.StatusGatewayTimeout},
+
 		// 500 Internal Server Error
 		{"ErrAuthDefault", ErrAuthDefault, http.StatusInternalServerError},
 		{"ErrHashPassword", ErrHashPassword, http.StatusInternalServerError},
 		{"ErrGenerateToken", ErrGenerateToken, http.StatusInternalServerError},
 		{"ErrGetPermissions", ErrGetPermissions, http.StatusInternalServerError},
 		{"ErrGetCookie", ErrGetCookie, http.StatusInternalServerError},
+		{"ErrRefreshToken", ErrRefreshToken, http.StatusInternalServerError},
+		{"ErrLoadSigningKey", ErrLoadSigningKey, http.StatusInternalServerError},
+		{"ErrSessionStore", ErrSessionStore, http.StatusInternalServerError},
+		{"ErrRevocationStore", ErrRevocationStore, http.StatusInternalServerError},
+		{"ErrUpdatePermissions", ErrUpdatePermissions, http.StatusInternalServerError},
 		{"ErrAPIDefault", ErrAPIDefault, http.StatusInternalServerError},
 		{"ErrAPIGet", ErrAPIGet, http.StatusInternalServerError},
 		{"ErrAPIPost", ErrAPIPost, http.StatusInternalServerError},
@@ -208,6 +336,8 @@ func TestPredefinedErrors_NoDuplicateCodes(t *testing.T) {
 		ErrDefaultCritical,
 		ErrListenAndServe,
 		ErrShutdownServer,
+		ErrShutdownHook,
+		ErrPanic,
 		// 1100 level
 		ErrDatabaseDefaultCritical,
 		ErrDatabaseLoad,
@@ -227,24 +357,48 @@ func TestPredefinedErrors_NoDuplicateCodes(t *testing.T) {
 		ErrMigrateTable,
 		ErrSortMigrations,
 		ErrSeedObject,
+		ErrRollbackMigration,
+		ErrDatabaseTransaction,
 		// 2200 level
 		ErrAuthDefault,
 		ErrHashPassword,
 		ErrGenerateToken,
 		ErrGetPermissions,
 		ErrGetCookie,
+		ErrRefreshToken,
+		ErrLoadSigningKey,
+		ErrSessionStore,
+		ErrUpdatePermissions,
+		ErrOAuthExchange,
+		ErrRevocationStore,
 		// 2300 level
 		ErrAPIDefault,
 		ErrAPIGet,
 		ErrAPIPost,
 		ErrAPIPut,
 		ErrAPIDelete,
+		// 2400 level
+		ErrEnqueueJob,
+		ErrRunJob,
+		ErrRunTask,
+		// 2500 level
+		ErrStorageWrite,
+		ErrStorageRead,
+		ErrStorageDelete,
+		// 2600 level
+		ErrPublishEvent,
+		ErrStreamEvents,
+		ErrCircuitOpen,
 		// 3000 level
 		ErrDefaultMinor,
 		ErrDecodeForm,
+		ErrAborted,
 		// 3100 level
 		ErrDatabaseDefaultMinor,
 		ErrDatabaseObjectNotFound,
+		ErrDatabaseInvalidColumn,
+		ErrDatabaseInvalidCursor,
+		ErrDatabaseConflict,
 		// 3200 level
 		ErrAuthDefaultMinor,
 		ErrAuthInvalidToken,
@@ -254,6 +408,13 @@ func TestPredefinedErrors_NoDuplicateCodes(t *testing.T) {
 		ErrInsufficientPermissions,
 		ErrAuthMissingHeader,
 		ErrAuthMissingAuthTypeHeader,
+		ErrAuthTokenReused,
+		ErrAuthNoSession,
+		ErrAuthOAuthState,
+		ErrAuthUnknownProvider,
+		ErrAuthOAuthDenied,
+		ErrAuthRevokedToken,
+		ErrAuthCrossSite,
 		// 3300 level
 		ErrAPIDefaultMinor,
 		ErrAPIIDMismatch,
@@ -261,6 +422,21 @@ func TestPredefinedErrors_NoDuplicateCodes(t *testing.T) {
 		ErrAPIPathValue,
 		ErrAPIObjectNotFound,
 		ErrAPIRequestContentType,
+		ErrAPIRateLimited,
+		ErrAPIQueryParam,
+		ErrAPIValidation,
+		ErrAPIWebhookSignature,
+		ErrAPIBotDetected,
+		ErrAPIIPForbidden,
+		ErrAPIOverloaded,
+		ErrAPITimeout,
+		// 3400 level
+		ErrJobNotFound,
+		// 3500 level
+		ErrFileNotFound,
+		ErrInvalidFileKey,
+		ErrUploadFile,
+		ErrInvalidSignature,
 	}
 
 	seenCodes := make(map[int]string)
@@ -287,6 +463,8 @@ func TestPredefinedErrors_CodeRanges(t *testing.T) {
 		{"ErrDefaultCritical", ErrDefaultCritical, 1000, 1099, "critical"},
 		{"ErrListenAndServe", ErrListenAndServe, 1000, 1099, "critical"},
 		{"ErrShutdownServer", ErrShutdownServer, 1000, 1099, "critical"},
+		{"ErrShutdownHook", ErrShutdownHook, 1000, 1099, "critical"},
+		{"ErrPanic", ErrPanic, 1000, 1099, "critical"},
 
 		// Database critical (1100-1199)
 		{"ErrDatabaseDefaultCritical", ErrDatabaseDefaultCritical, 1100, 1199, "database critical"},
@@ -305,26 +483,62 @@ func TestPredefinedErrors_CodeRanges(t *testing.T) {
 		// Auth errors (2200-2299)
 		{"ErrAuthDefault", ErrAuthDefault, 2200, 2299, "auth error"},
 		{"ErrHashPassword", ErrHashPassword, 2200, 2299, "auth error"},
+		{"ErrLoadSigningKey", ErrLoadSigningKey, 2200, 2299, "auth error"},
 
 		// API errors (2300-2399)
 		{"ErrAPIDefault", ErrAPIDefault, 2300, 2399, "api error"},
 		{"ErrAPIGet", ErrAPIGet, 2300, 2399, "api error"},
 
+		// Job errors (2400-2499)
+		{"ErrEnqueueJob", ErrEnqueueJob, 2400, 2499, "jobs error"},
+		{"ErrRunJob", ErrRunJob, 2400, 2499, "jobs error"},
+		{"ErrRunTask", ErrRunTask, 2400, 2499, "jobs error"},
+
+		// Storage errors (2500-2599)
+		{"ErrStorageWrite", ErrStorageWrite, 2500, 2599, "storage error"},
+		{"ErrStorageDelete", ErrStorageDelete, 2500, 2599, "storage error"},
+
+		// Realtime errors (2600-2699)
+		{"ErrPublishEvent", ErrPublishEvent, 2600, 2699, "realtime error"},
+		{"ErrStreamEvents", ErrStreamEvents, 2600, 2699, "realtime error"},
+
+		// Outbound HTTP errors (2700-2799)
+		{"ErrCircuitOpen", ErrCircuitOpen, 2700, 2799, "outbound HTTP error"},
+
 		// General minor (3000-3099)
 		{"ErrDefaultMinor", ErrDefaultMinor, 3000, 3099, "general minor"},
 		{"ErrDecodeForm", ErrDecodeForm, 3000, 3099, "general minor"},
+		{"ErrAborted", ErrAborted, 3000, 3099, "general minor"},
 
 		// Database minor (3100-3199)
 		{"ErrDatabaseDefaultMinor", ErrDatabaseDefaultMinor, 3100, 3199, "database minor"},
 		{"ErrDatabaseObjectNotFound", ErrDatabaseObjectNotFound, 3100, 3199, "database minor"},
+		{"ErrDatabaseInvalidColumn", ErrDatabaseInvalidColumn, 3100, 3199, "database minor"},
+		{"ErrDatabaseInvalidCursor", ErrDatabaseInvalidCursor, 3100, 3199, "database minor"},
+		{"ErrDatabaseConflict", ErrDatabaseConflict, 3100, 3199, "database minor"},
 
 		// Auth minor (3200-3299)
 		{"ErrAuthDefaultMinor", ErrAuthDefaultMinor, 3200, 3299, "auth minor"},
 		{"ErrAuthInvalidToken", ErrAuthInvalidToken, 3200, 3299, "auth minor"},
+		{"ErrAuthTokenReused", ErrAuthTokenReused, 3200, 3299, "auth minor"},
+		{"ErrAuthNoSession", ErrAuthNoSession, 3200, 3299, "auth minor"},
 
 		// API minor (3300-3399)
 		{"ErrAPIDefaultMinor", ErrAPIDefaultMinor, 3300, 3399, "api minor"},
 		{"ErrAPIIDMismatch", ErrAPIIDMismatch, 3300, 3399, "api minor"},
+		{"ErrAPIValidation", ErrAPIValidation, 3300, 3399, "api minor"},
+		{"ErrAPIWebhookSignature", ErrAPIWebhookSignature, 3300, 3399, "api minor"},
+		{"ErrAPIBotDetected", ErrAPIBotDetected, 3300, 3399, "api minor"},
+		{"ErrAPIIPForbidden", ErrAPIIPForbidden, 3300, 3399, "api minor"},
+		{"ErrAPIOverloaded", ErrAPIOverloaded, 3300, 3399, "api minor"},
+		{"ErrAPITimeout", ErrAPITimeout, 3300, 3399, "api minor"},
+
+		// Job minor (3400-3499)
+		{"ErrJobNotFound", ErrJobNotFound, 3400, 3499, "jobs minor"},
+
+		// Storage minor (3500-3599)
+		{"ErrFileNotFound", ErrFileNotFound, 3500, 3599, "storage minor"},
+		{"ErrInvalidSignature", ErrInvalidSignature, 3500, 3599, "storage minor"},
 	}
 
 	for _, tt := range tests {
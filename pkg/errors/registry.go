@@ -0,0 +1,140 @@
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AppCodeMin is the first code applications can register. Codes below it
+// are reserved for Twine's predefined errors.
+const AppCodeMin = 4000
+
+// CodeRange is a block of codes reserved by Reserve. Codes in it can only be
+// registered through its Register method.
+type CodeRange struct {
+	Owner string
+	Min   int
+	Max   int
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[int]*Error{}
+	reserved      = []*CodeRange{{Owner: "twine", Min: 1000, Max: AppCodeMin - 1}}
+)
+
+func init() {
+	for _, e := range predefined {
+		registry[e.Code] = e
+	}
+}
+
+// Register records an application error under code and returns it, so it
+// can be declared as a variable:
+//
+//	var ErrQuotaExceeded = errors.Register(4001, errors.NewErrorBuilder().
+//	    Severity(errors.ErrMinor).
+//	    HTTPStatus(http.StatusTooManyRequests).
+//	    Message("Quota exceeded").
+//	    Build())
+//
+// The template's Severity routes it in the logger and error reporters, and
+// its HTTPStatus is the response status, as for predefined errors. Register
+// panics if code is below AppCodeMin, already registered, or in a range
+// reserved with Reserve.
+func Register(code int, template *Error) *Error {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if code < AppCodeMin {
+		panic(fmt.Sprintf("errors: registering code %d: codes below %d are reserved for twine", code, AppCodeMin))
+	}
+	if r := reservedRange(code); r != nil {
+		panic(fmt.Sprintf("errors: registering code %d: the code is reserved for %s; register it with its CodeRange", code, r.Owner))
+	}
+	return register(code, template)
+}
+
+// Reserve claims codes min to max for owner, such as a module or library,
+// so that other code can't register them. It panics if the range overlaps
+// another reservation or registered code outside it.
+func Reserve(owner string, min, max int) *CodeRange {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if min < AppCodeMin || max < min {
+		panic(fmt.Sprintf("errors: reserving %d-%d for %s: ranges must start at %d or above and end after they start", min, max, owner, AppCodeMin))
+	}
+	for _, r := range reserved {
+		if min <= r.Max && r.Min <= max {
+			panic(fmt.Sprintf("errors: reserving %d-%d for %s: overlaps %d-%d reserved for %s", min, max, owner, r.Min, r.Max, r.Owner))
+		}
+	}
+	for code := range registry {
+		if code >= min && code <= max {
+			panic(fmt.Sprintf("errors: reserving %d-%d for %s: code %d is already registered", min, max, owner, code))
+		}
+	}
+
+	r := &CodeRange{Owner: owner, Min: min, Max: max}
+	reserved = append(reserved, r)
+	return r
+}
+
+// Register records an error under code, which must be in the range, as
+// errors.Register does
+func (r *CodeRange) Register(code int, template *Error) *Error {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if code < r.Min || code > r.Max {
+		panic(fmt.Sprintf("errors: registering code %d: outside %d-%d reserved for %s", code, r.Min, r.Max, r.Owner))
+	}
+	return register(code, template)
+}
+
+// register records a copy of template with code. The caller holds
+// registryMutex.
+func register(code int, template *Error) *Error {
+	if existing, ok := registry[code]; ok {
+		panic(fmt.Sprintf("errors: registering code %d: already registered for %q", code, existing.Message))
+	}
+
+	e := *template
+	e.Code = code
+	registry[code] = &e
+	return &e
+}
+
+// reservedRange returns the reservation code falls in, or nil. The caller
+// holds registryMutex.
+func reservedRange(code int) *CodeRange {
+	for _, r := range reserved {
+		if code >= r.Min && code <= r.Max {
+			return r
+		}
+	}
+	return nil
+}
+
+// Lookup returns the predefined or registered error with code
+func Lookup(code int) (*Error, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	e, ok := registry[code]
+	return e, ok
+}
+
+// Registered returns the predefined and registered errors, ordered by code
+func Registered() []*Error {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	errs := make([]*Error, 0, len(registry))
+	for _, e := range registry {
+		errs = append(errs, e)
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Code < errs[j].Code })
+	return errs
+}
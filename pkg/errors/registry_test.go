@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetRegistry restores the registry after the test
+func resetRegistry(t *testing.T) {
+	t.Helper()
+
+	registryMutex.Lock()
+	saved := make(map[int]*Error, len(registry))
+	for code, e := range registry {
+		saved[code] = e
+	}
+	savedRanges := reserved
+	registryMutex.Unlock()
+
+	t.Cleanup(func() {
+		registryMutex.Lock()
+		defer registryMutex.Unlock()
+		registry = saved
+		reserved = savedRanges
+	})
+}
+
+// TestRegister tests registering application errors
+func TestRegister(t *testing.T) {
+	resetRegistry(t)
+
+	template := NewErrorBuilder().Severity(ErrMinor).HTTPStatus(http.StatusTooManyRequests).Message("Quota exceeded").Build()
+	quota := Register(4001, template)
+
+	assert.Equal(t, 4001, quota.Code)
+	assert.Equal(t, http.StatusTooManyRequests, quota.HTTPStatus)
+	assert.Equal(t, ErrMinor, quota.Severity)
+	assert.Zero(t, template.Code, "the template isn't changed")
+
+	found, ok := Lookup(4001)
+	require.True(t, ok)
+	assert.Same(t, quota, found)
+
+	found, ok = Lookup(ErrNotFound.Code)
+	require.True(t, ok, "predefined errors are registered")
+	assert.Same(t, ErrNotFound, found)
+
+	registered := Registered()
+	assert.Equal(t, ErrDefaultCritical, registered[0])
+	assert.Equal(t, quota, registered[len(registered)-1])
+
+	t.Run("rejects framework codes", func(t *testing.T) {
+		assert.PanicsWithValue(t, "errors: registering code 2101: codes below 4000 are reserved for twine", func() {
+			Register(2101, template)
+		})
+	})
+
+	t.Run("rejects collisions", func(t *testing.T) {
+		assert.PanicsWithValue(t, `errors: registering code 4001: already registered for "Quota exceeded"`, func() {
+			Register(4001, template)
+		})
+	})
+}
+
+// TestReserve tests reserving code ranges
+func TestReserve(t *testing.T) {
+	resetRegistry(t)
+
+	payments := Reserve("payments", 5000, 5099)
+	declined := payments.Register(5001, NewErrorBuilder().Severity(ErrMinor).HTTPStatus(http.StatusPaymentRequired).Message("Card declined").Build())
+	assert.Equal(t, 5001, declined.Code)
+
+	assert.PanicsWithValue(t, "errors: registering code 5002: the code is reserved for payments; register it with its CodeRange", func() {
+		Register(5002, ErrDefaultError)
+	})
+	assert.PanicsWithValue(t, "errors: registering code 5100: outside 5000-5099 reserved for payments", func() {
+		payments.Register(5100, ErrDefaultError)
+	})
+	assert.PanicsWithValue(t, "errors: reserving 5050-5149 for billing: overlaps 5000-5099 reserved for payments", func() {
+		Reserve("billing", 5050, 5149)
+	})
+	assert.Panics(t, func() { Reserve("billing", 3000, 3099) }, "framework codes can't be reserved")
+	assert.Panics(t, func() { Reserve("billing", 6100, 6000) }, "ranges end after they start")
+
+	Register(6001, ErrDefaultError)
+	assert.PanicsWithValue(t, "errors: reserving 6000-6099 for billing: code 6001 is already registered", func() {
+		Reserve("billing", 6000, 6099)
+	})
+}
@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxStackDepth bounds the frames recorded for an error
+const maxStackDepth = 32
+
+var (
+	// captureStacks turns stack traces on for Wrap and CaptureStack
+	captureStacks atomic.Bool
+
+	hooksMutex sync.Mutex
+	hooks      = map[int]func(e *Error, ctx context.Context){}
+	nextHook   int
+)
+
+// OnError calls fn with each error passed to Report with severity ErrError
+// or ErrCritical, e.g. to forward failures to Sentry or Rollbar. kit.Handler
+// reports the errors handlers return, with the request in ctx. It returns a
+// function that removes the hook.
+func OnError(fn func(e *Error, ctx context.Context)) (remove func()) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	id := nextHook
+	nextHook++
+	hooks[id] = fn
+
+	return func() {
+		hooksMutex.Lock()
+		defer hooksMutex.Unlock()
+		delete(hooks, id)
+	}
+}
+
+// Report calls the OnError hooks with e, unless it is ErrMinor. If e has no
+// stack trace, it gets the caller's.
+func Report(ctx context.Context, e *Error) {
+	if e == nil || e.Severity < ErrError {
+		return
+	}
+
+	hooksMutex.Lock()
+	fns := make([]func(e *Error, ctx context.Context), 0, len(hooks))
+	for _, fn := range hooks {
+		fns = append(fns, fn)
+	}
+	hooksMutex.Unlock()
+	if len(fns) == 0 {
+		return
+	}
+
+	if e.stack == nil {
+		copied := *e
+		copied.stack = callers(3)
+		e = &copied
+	}
+	for _, fn := range fns {
+		fn(e, ctx)
+	}
+}
+
+func init() {
+	captureStacks.Store(true)
+}
+
+// CaptureStacks turns recording stack traces in Wrap and builders with
+// CaptureStack on or off. It is on by default; the logger applies
+// LOGGER_STACK_TRACES. Errors reported without a stack still get the
+// reporter's caller's.
+func CaptureStacks(enabled bool) {
+	captureStacks.Store(enabled)
+}
+
+// StackTrace returns the frames of the call stack where e was wrapped,
+// built or reported, innermost first, without the runtime, net/http and
+// testing frames around the application's. Errors declared as variables,
+// or created while CaptureStacks is off, have none.
+func (e *Error) StackTrace() []runtime.Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+
+	frames := make([]runtime.Frame, 0, len(e.stack))
+	iter := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := iter.Next()
+		if !trimmed(frame.Function) {
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// trimmed reports whether a frame of function is left out of stack traces
+func trimmed(function string) bool {
+	for _, prefix := range []string{"runtime.", "net/http.", "testing."} {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackIfEnabled records the call stack if CaptureStacks is on, skipping
+// skip frames as runtime.Callers does
+func stackIfEnabled(skip int) []uintptr {
+	if !captureStacks.Load() {
+		return nil
+	}
+	return callers(skip + 1)
+}
+
+// callers records the call stack, skipping skip frames as runtime.Callers
+// does
+func callers(skip int) []uintptr {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip, pcs[:])
+	return pcs[:n:n]
+}
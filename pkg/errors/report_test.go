@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type contextKey struct{}
+
+// TestReport tests calling the OnError hooks
+func TestReport(t *testing.T) {
+	var reported []*Error
+	var values []any
+	remove := OnError(func(e *Error, ctx context.Context) {
+		reported = append(reported, e)
+		values = append(values, ctx.Value(contextKey{}))
+	})
+	defer remove()
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "request")
+	Report(ctx, ErrDatabaseConn)
+	Report(ctx, ErrDatabaseRead.Wrap(fmt.Errorf("timeout")))
+	Report(ctx, ErrDecodeForm)
+	Report(ctx, nil)
+
+	require.Len(t, reported, 2, "minor errors aren't reported")
+	assert.Equal(t, ErrDatabaseConn.Code, reported[0].Code)
+	assert.Equal(t, ErrDatabaseRead.Code, reported[1].Code)
+	assert.Equal(t, []any{"request", "request"}, values)
+
+	t.Run("stack traces", func(t *testing.T) {
+		assert.Nil(t, ErrDatabaseConn.StackTrace(), "reporting doesn't change declared errors")
+
+		stack := reported[0].StackTrace()
+		require.NotEmpty(t, stack, "errors without a stack get the caller's")
+		assert.True(t, strings.HasSuffix(stack[0].Function, "errors.TestReport"), stack[0].Function)
+
+		wrapped := ErrDatabaseRead.Wrap(fmt.Errorf("timeout"))
+		stack = wrapped.StackTrace()
+		require.NotEmpty(t, stack)
+		assert.True(t, strings.HasSuffix(stack[0].Function, "errors.TestReport.func2"), stack[0].Function)
+		assert.Equal(t, stack, wrapped.WithValue(42).StackTrace())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		remove()
+		Report(ctx, ErrDatabaseConn)
+		assert.Len(t, reported, 2)
+	})
+}
+
+// TestCaptureStacks tests turning stack traces off, and capturing them in
+// builders
+func TestCaptureStacks(t *testing.T) {
+	built := NewErrorBuilder().Code(4000).Severity(ErrError).CaptureStack().Build()
+	require.NotEmpty(t, built.StackTrace())
+	assert.True(t, strings.HasSuffix(built.StackTrace()[0].Function, "errors.TestCaptureStacks"), built.StackTrace()[0].Function)
+	assert.Nil(t, NewErrorBuilder().Code(4000).Build().StackTrace(), "builders only capture with CaptureStack")
+
+	for _, frame := range ErrDatabaseRead.Wrap(fmt.Errorf("timeout")).StackTrace() {
+		assert.False(t, strings.HasPrefix(frame.Function, "testing."), "testing frames are trimmed")
+		assert.False(t, strings.HasPrefix(frame.Function, "runtime."), "runtime frames are trimmed")
+	}
+
+	CaptureStacks(false)
+	defer CaptureStacks(true)
+	assert.Nil(t, ErrDatabaseRead.Wrap(fmt.Errorf("timeout")).StackTrace())
+	assert.Nil(t, NewErrorBuilder().CaptureStack().Build().StackTrace())
+}
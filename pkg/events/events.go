@@ -0,0 +1,203 @@
+// Package events is an in-process event bus. Features subscribe to typed
+// events, such as sending a welcome email on UserSignedUp, and handlers
+// publish them without knowing who listens.
+package events
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sync"
+
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// Handler handles an event of type T
+type Handler[T any] func(ctx context.Context, evt T) error
+
+// Option configures a subscription
+type Option func(*subscriber)
+
+// Async delivers events to the handler in a goroutine, so Publish doesn't
+// wait for it. The handler's context keeps the publisher's values but not
+// its cancellation, and errors are logged rather than returned. Drain
+// waits for async handlers to finish.
+func Async() Option {
+	return func(s *subscriber) {
+		s.async = true
+	}
+}
+
+// subscriber is a handler with its event type erased
+type subscriber struct {
+	id     uint64
+	name   string
+	async  bool
+	handle func(ctx context.Context, evt any) error
+}
+
+// Bus delivers published events to the subscribers of their type. The
+// zero value is not usable; create one with NewBus.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[reflect.Type][]*subscriber
+	nextID      uint64
+	running     sync.WaitGroup
+}
+
+// NewBus creates a Bus without subscribers
+func NewBus() *Bus {
+	return &Bus{subscribers: map[reflect.Type][]*subscriber{}}
+}
+
+var (
+	mu       sync.Mutex
+	instance *Bus
+)
+
+// Default returns the application's Bus, which Publish and Subscribe use
+func Default() *Bus {
+	mu.Lock()
+	defer mu.Unlock()
+	if instance == nil {
+		instance = NewBus()
+	}
+	return instance
+}
+
+// Use makes bus the one returned by Default. It is meant for tests.
+func Use(bus *Bus) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = bus
+}
+
+// Reset forgets the current bus and its subscribers, so the next Default
+// creates an empty one
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = nil
+}
+
+// Subscribe calls h for every event of type T published on the Default bus,
+// usually registered from an init function:
+//
+//	events.Subscribe(func(ctx context.Context, evt UserSignedUp) error {
+//	    _, err := jobs.Enqueue(ctx, "send-welcome-email", evt.UserID, jobs.Options{})
+//	    return err
+//	})
+//
+// Events match by their exact type, so subscribe to UserSignedUp rather
+// than *UserSignedUp if that is what is published. The returned function
+// unsubscribes h.
+func Subscribe[T any](h Handler[T], opts ...Option) (unsubscribe func()) {
+	return SubscribeOn(Default(), h, opts...)
+}
+
+// SubscribeOn is Subscribe on bus
+func SubscribeOn[T any](bus *Bus, h Handler[T], opts ...Option) (unsubscribe func()) {
+	eventType := reflect.TypeFor[T]()
+	sub := &subscriber{
+		name: eventType.String(),
+		handle: func(ctx context.Context, evt any) error {
+			return h(ctx, evt.(T))
+		},
+	}
+	for _, opt := range opts {
+		opt(sub)
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.nextID++
+	sub.id = bus.nextID
+	bus.subscribers[eventType] = append(bus.subscribers[eventType], sub)
+
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		subs := bus.subscribers[eventType]
+		for i, s := range subs {
+			if s.id == sub.id {
+				bus.subscribers[eventType] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers evt to the subscribers of type T on the Default bus:
+//
+//	err := events.Publish(k.Request.Context(), UserSignedUp{UserID: user.ID})
+//
+// Synchronous handlers run in the order they subscribed, before Publish
+// returns; all of them run, and their errors are joined. Async handlers
+// are started and not waited for.
+func Publish[T any](ctx context.Context, evt T) error {
+	return PublishOn(ctx, Default(), evt)
+}
+
+// PublishOn is Publish on bus
+func PublishOn[T any](ctx context.Context, bus *Bus, evt T) error {
+	bus.mu.RLock()
+	subs := append([]*subscriber(nil), bus.subscribers[reflect.TypeFor[T]()]...)
+	bus.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if sub.async {
+			bus.running.Add(1)
+			go func() {
+				defer bus.running.Done()
+				if err := deliver(context.WithoutCancel(ctx), sub, evt); err != nil {
+					logger.Get().With("event", sub.name).Error("Handling %s: %v", sub.name, err)
+				}
+			}()
+			continue
+		}
+		if err := deliver(ctx, sub, evt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// deliver calls sub's handler, turning a panic into an error
+func deliver(ctx context.Context, sub *subscriber, evt any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic handling %s: %v\n%s", sub.name, r, debug.Stack())
+		}
+	}()
+	return sub.handle(ctx, evt)
+}
+
+// Drain waits for the async handlers running on the Default bus, or for
+// ctx to be done. Register it as a shutdown hook, which runs after the
+// server's open requests have finished:
+//
+//	srv.OnShutdown("events", events.Drain)
+func Drain(ctx context.Context) error {
+	return Default().Drain(ctx)
+}
+
+// Drain waits for bus's running async handlers, including any they start,
+// or for ctx to be done, returning its error
+func (b *Bus) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		logger.Get().Warn("Stopped waiting for event handlers: %v", ctx.Err())
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userSignedUp struct {
+	UserID string
+}
+
+type orderPlaced struct {
+	OrderID string
+}
+
+type requestKey struct{}
+
+// TestPublish_Sync tests delivering events to synchronous subscribers in
+// order
+func TestPublish_Sync(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	SubscribeOn(bus, func(ctx context.Context, evt userSignedUp) error {
+		got = append(got, "email "+evt.UserID)
+		return nil
+	})
+	SubscribeOn(bus, func(ctx context.Context, evt userSignedUp) error {
+		got = append(got, "audit "+evt.UserID+" "+ctx.Value(requestKey{}).(string))
+		return nil
+	})
+	SubscribeOn(bus, func(ctx context.Context, evt orderPlaced) error {
+		got = append(got, "order "+evt.OrderID)
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), requestKey{}, "req-1")
+	require.NoError(t, PublishOn(ctx, bus, userSignedUp{UserID: "42"}))
+	assert.Equal(t, []string{"email 42", "audit 42 req-1"}, got)
+
+	require.NoError(t, PublishOn(ctx, bus, &userSignedUp{UserID: "7"}), "events match by exact type")
+	assert.Len(t, got, 2)
+}
+
+// TestPublish_Errors tests that every handler runs and their errors and
+// panics are returned together
+func TestPublish_Errors(t *testing.T) {
+	bus := NewBus()
+	errFirst := errors.New("first failed")
+	var ran int
+	SubscribeOn(bus, func(context.Context, userSignedUp) error { ran++; return errFirst })
+	SubscribeOn(bus, func(context.Context, userSignedUp) error { ran++; panic("boom") })
+	SubscribeOn(bus, func(context.Context, userSignedUp) error { ran++; return nil })
+
+	err := PublishOn(context.Background(), bus, userSignedUp{})
+	assert.Equal(t, 3, ran)
+	assert.ErrorIs(t, err, errFirst)
+	assert.Contains(t, err.Error(), "panic handling events.userSignedUp: boom")
+}
+
+// TestPublish_Async tests that async handlers run in the background, outlive
+// the publisher's context and are waited for by Drain
+func TestPublish_Async(t *testing.T) {
+	bus := NewBus()
+	release := make(chan struct{})
+	var handled atomic.Int32
+	SubscribeOn(bus, func(ctx context.Context, evt userSignedUp) error {
+		<-release
+		if ctx.Err() == nil && ctx.Value(requestKey{}) == "req-1" {
+			handled.Add(1)
+		}
+		return errors.New("logged, not returned")
+	}, Async())
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), requestKey{}, "req-1"))
+	require.NoError(t, PublishOn(ctx, bus, userSignedUp{UserID: "42"}))
+	require.NoError(t, PublishOn(ctx, bus, userSignedUp{UserID: "43"}))
+	cancel()
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	assert.ErrorIs(t, bus.Drain(short), context.DeadlineExceeded, "handlers are still running")
+
+	close(release)
+	require.NoError(t, bus.Drain(context.Background()))
+	assert.Equal(t, int32(2), handled.Load())
+}
+
+// TestSubscribe_Unsubscribe tests removing a subscriber
+func TestSubscribe_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+	var first, second int
+	unsubscribe := SubscribeOn(bus, func(context.Context, userSignedUp) error { first++; return nil })
+	SubscribeOn(bus, func(context.Context, userSignedUp) error { second++; return nil })
+
+	require.NoError(t, PublishOn(context.Background(), bus, userSignedUp{}))
+	unsubscribe()
+	unsubscribe()
+	require.NoError(t, PublishOn(context.Background(), bus, userSignedUp{}))
+
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 2, second)
+}
+
+// TestDefault tests the package functions on the Default bus
+func TestDefault(t *testing.T) {
+	Use(NewBus())
+	t.Cleanup(Reset)
+
+	var mu sync.Mutex
+	var got []string
+	Subscribe(func(ctx context.Context, evt orderPlaced) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, evt.OrderID)
+		return nil
+	}, Async())
+
+	require.NoError(t, Publish(context.Background(), orderPlaced{OrderID: "A-1"}))
+	require.NoError(t, Drain(context.Background()))
+	assert.Equal(t, []string{"A-1"}, got)
+
+	Reset()
+	require.NoError(t, Publish(context.Background(), orderPlaced{OrderID: "A-2"}))
+	assert.Equal(t, []string{"A-1"}, got, "Reset forgets subscribers")
+}
@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// circuit tracks the failures of requests to one host
+type circuit struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	// trial is set while the request after a cooldown decides whether the
+	// circuit closes
+	trial bool
+}
+
+// circuit returns the circuit of host
+func (t *transport) circuit(host string) *circuit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.circuits[host]
+	if !ok {
+		c = &circuit{}
+		t.circuits[host] = c
+	}
+	return c
+}
+
+// allow reports whether a request may be sent at now. Once an open
+// circuit's cooldown has passed, one trial request is let through.
+func (c *circuit) allow(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(c.openUntil) || c.trial {
+		return false
+	}
+	c.trial = true
+	return true
+}
+
+// record counts a request's outcome at now, opening the circuit for
+// cooldown after threshold failures in a row, or after a failed trial
+func (c *circuit) record(ok bool, threshold int, cooldown time.Duration, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ok {
+		c.failures, c.openUntil, c.trial = 0, time.Time{}, false
+		return
+	}
+	c.failures++
+	if threshold > 0 && (c.trial || c.failures >= threshold) {
+		c.openUntil, c.trial = now.Add(cooldown), false
+	}
+}
@@ -0,0 +1,346 @@
+// Package httpclient builds HTTP clients for outbound calls from handlers
+// and jobs, with a timeout, retries with jitter, a circuit breaker per host
+// and a log line per request. Requests made with Context carry the request
+// ID and trace context of the request being handled, so the services
+// called can be correlated with its logs:
+//
+//	var payments = httpclient.New(httpclient.WithName("payments"))
+//
+//	func POST(k *kit.Kit) error {
+//	    req, err := http.NewRequestWithContext(httpclient.Context(k), "GET", paymentsURL, nil)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    resp, err := payments.Do(req)
+//	    ...
+//	}
+package httpclient
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+const (
+	// DefaultTimeout bounds a request, retries included
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetries is how many times a failed request is retried
+	DefaultRetries = 2
+	// DefaultBackoff is the wait before the first retry, doubled for each
+	// retry after it
+	DefaultBackoff = 100 * time.Millisecond
+	// MaxBackoff caps the wait between retries, including waits asked for
+	// with Retry-After
+	MaxBackoff = 5 * time.Second
+	// DefaultBreakerFailures is how many requests to a host fail in a row
+	// before its circuit opens
+	DefaultBreakerFailures = 5
+	// DefaultBreakerCooldown is how long a circuit stays open
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// TraceParentHeader and TraceStateHeader carry W3C trace context
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// transport sends the requests of a client built by New
+type transport struct {
+	base    http.RoundTripper
+	name    string
+	retries int
+	backoff time.Duration
+	// log is nil to log with the singleton logger
+	log *logger.Logger
+
+	failures int
+	cooldown time.Duration
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// Option configures a client built by New
+type Option func(*http.Client, *transport)
+
+// WithTimeout bounds each request, retries included, instead of
+// DefaultTimeout. Zero means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *http.Client, _ *transport) {
+		c.Timeout = d
+	}
+}
+
+// WithRetries retries a failed request up to retries times, waiting
+// backoff before the first retry and doubling it for each one after, with
+// jitter. Zero retries sends each request once.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(_ *http.Client, t *transport) {
+		t.retries, t.backoff = retries, backoff
+	}
+}
+
+// WithCircuitBreaker opens a host's circuit after failures requests to it
+// fail in a row, failing requests to it with ErrCircuitOpen for cooldown.
+// The first request after the cooldown closes the circuit if it succeeds.
+// Zero failures turns the breaker off.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(_ *http.Client, t *transport) {
+		t.failures, t.cooldown = failures, cooldown
+	}
+}
+
+// WithLogger logs requests through log instead of the singleton logger
+func WithLogger(log *logger.Logger) Option {
+	return func(_ *http.Client, t *transport) {
+		t.log = log
+	}
+}
+
+// WithName names the service called, in log lines and ErrCircuitOpen
+func WithName(name string) Option {
+	return func(_ *http.Client, t *transport) {
+		t.name = name
+	}
+}
+
+// WithTransport sends requests with rt instead of a copy of
+// http.DefaultTransport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(_ *http.Client, t *transport) {
+		t.base = rt
+	}
+}
+
+// New builds a client that times out after DefaultTimeout, retries
+// failed requests DefaultRetries times and opens a host's circuit after
+// DefaultBreakerFailures failures, unless opts say otherwise.
+//
+// Only requests that are safe to repeat are retried: GET, HEAD, OPTIONS,
+// PUT and DELETE, and others sent with an Idempotency-Key header. They're
+// retried after network errors and 429, 502, 503 and 504 responses,
+// waiting as long as a Retry-After header asks if it's at most
+// MaxBackoff. A retry that would start after the deadline of the request's
+// context, such as a route's TimeoutMiddleware budget, isn't sent.
+func New(opts ...Option) *http.Client {
+	t := &transport{
+		base:     http.DefaultTransport.(*http.Transport).Clone(),
+		retries:  DefaultRetries,
+		backoff:  DefaultBackoff,
+		failures: DefaultBreakerFailures,
+		cooldown: DefaultBreakerCooldown,
+		circuits: map[string]*circuit{},
+	}
+	c := &http.Client{Transport: t, Timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(c, t)
+	}
+	return c
+}
+
+// propagation is what requests made with Context pass on
+type propagation struct {
+	requestID   string
+	traceParent string
+	traceState  string
+}
+
+type propagationKey struct{}
+
+// Context returns the context of the request k handles, carrying its
+// request ID and trace context for requests made with it. Requests made
+// with it stop at the request's deadline, as k.Deadline returns.
+func Context(k *kit.Kit) context.Context {
+	p := propagation{
+		requestID:   k.RequestID(),
+		traceParent: k.GetHeader(TraceParentHeader),
+		traceState:  k.GetHeader(TraceStateHeader),
+	}
+	return context.WithValue(k.Request.Context(), propagationKey{}, p)
+}
+
+// RoundTrip sends req, retrying it and tripping the circuit of its host
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	req = t.propagate(req)
+
+	c := t.circuit(req.URL.Host)
+	if !c.allow(time.Now()) {
+		err := errors.ErrCircuitOpen.WithValue(t.service(req))
+		t.logRequest(req, nil, err, 0, start)
+		return nil, err
+	}
+
+	resp, attempts, err := t.send(req)
+	c.record(!failed(resp, err), t.failures, t.cooldown, time.Now())
+	t.logRequest(req, resp, err, attempts, start)
+	return resp, err
+}
+
+// send sends req, retrying it while it may be retried
+func (t *transport) send(req *http.Request) (*http.Response, int, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt > t.retries || !retryable(req, resp, err) {
+			return resp, attempt, err
+		}
+
+		wait, ok := t.wait(attempt, resp)
+		if !ok {
+			return resp, attempt, err
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) <= wait {
+			// The retry can't be answered within the caller's budget, so
+			// the caller gets this answer rather than a timeout
+			return resp, attempt, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, attempt, bodyErr
+			}
+			// A RoundTripper mustn't change the caller's request
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, attempt, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// wait returns how long to wait before retrying after attempt: the
+// response's Retry-After, or the backoff with full jitter. It's false
+// when Retry-After asks for more than MaxBackoff.
+func (t *transport) wait(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			wait := time.Duration(seconds) * time.Second
+			return wait, wait <= MaxBackoff
+		}
+	}
+	backoff := t.backoff
+	for i := 1; i < attempt && backoff < MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, MaxBackoff)
+	if backoff <= 0 {
+		return 0, true
+	}
+	return rand.N(backoff + 1), true
+}
+
+// retryable reports whether a request that got resp or err may be sent
+// again
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" {
+			return false
+		}
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// failed reports whether a request counts against its host's circuit: it
+// got no response or a 5xx
+func failed(resp *http.Response, err error) bool {
+	if err != nil {
+		// A request its caller gave up on says nothing about the host
+		return !stderrors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode >= 500
+}
+
+// propagate returns req carrying the request ID and trace context of the
+// context it was made with, unless it sets them itself
+func (t *transport) propagate(req *http.Request) *http.Request {
+	p, ok := req.Context().Value(propagationKey{}).(propagation)
+	if !ok {
+		return req
+	}
+	req = req.Clone(req.Context())
+	for header, value := range map[string]string{
+		kit.RequestIDHeader: p.requestID,
+		TraceParentHeader:   p.traceParent,
+		TraceStateHeader:    p.traceState,
+	} {
+		if value != "" && req.Header.Get(header) == "" {
+			req.Header.Set(header, value)
+		}
+	}
+	return req
+}
+
+// service names the service req calls, for logs and errors
+func (t *transport) service(req *http.Request) string {
+	if t.name != "" {
+		return t.name
+	}
+	return req.URL.Host
+}
+
+// logRequest logs a request once it's answered or has failed: failures at
+// warn level and the rest at debug level
+func (t *transport) logRequest(req *http.Request, resp *http.Response, err error, attempts int, start time.Time) {
+	log := t.log
+	if log == nil {
+		log = logger.Get()
+	}
+	fields := []any{
+		"service", t.service(req),
+		"method", req.Method,
+		"url", req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		"attempts", attempts,
+		"latency", time.Since(start),
+	}
+	if id := req.Header.Get(kit.RequestIDHeader); id != "" {
+		fields = append(fields, "request_id", id)
+	}
+	if resp != nil {
+		fields = append(fields, "status", resp.StatusCode)
+	}
+	log = log.With(fields...)
+
+	switch {
+	case err != nil:
+		log.Warn("Outbound request failed: %v", err)
+	case resp.StatusCode >= 500:
+		log.Warn("Outbound request failed")
+	default:
+		log.Debug("Outbound request")
+	}
+}
@@ -0,0 +1,239 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// quietLogger discards the log lines of a test's requests
+func quietLogger() Option {
+	return WithLogger(logger.New(config.LoggerConfig{Level: config.LogError, Output: io.Discard, ErrorOutput: io.Discard}))
+}
+
+// flakyServer fails the first failures requests with status, then
+// responds 200 with the request body, counting requests in calls
+func flakyServer(t *testing.T, failures int, status int, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if int(calls.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestClient_Retries tests retrying the requests that are safe to repeat
+func TestClient_Retries(t *testing.T) {
+	t.Run("retries idempotent requests", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, 2, http.StatusServiceUnavailable, &calls)
+		client := New(WithRetries(2, time.Millisecond), quietLogger())
+
+		req, err := http.NewRequest("PUT", srv.URL, strings.NewReader("hello"))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello", string(body), "the body is sent again")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("returns the last response after the retries", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, 5, http.StatusBadGateway, &calls)
+		client := New(WithRetries(1, time.Millisecond), quietLogger())
+
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("retries POST only with an Idempotency-Key", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, 1, http.StatusServiceUnavailable, &calls)
+		client := New(WithRetries(2, time.Millisecond), quietLogger())
+
+		resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("charge"))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+
+		calls.Store(0)
+		req, err := http.NewRequest("POST", srv.URL, strings.NewReader("charge"))
+		require.NoError(t, err)
+		req.Header.Set("Idempotency-Key", "charge-1")
+		resp, err = client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("doesn't retry other statuses", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, 1, http.StatusInternalServerError, &calls)
+		client := New(WithRetries(2, time.Millisecond), quietLogger())
+
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("doesn't retry past the context's deadline", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+		client := New(WithRetries(2, time.Millisecond), quietLogger())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err, "the answer rather than a timeout")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load(), "a second is past the deadline")
+	})
+
+	t.Run("honours Retry-After up to MaxBackoff", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+		client := New(WithRetries(2, time.Millisecond), quietLogger())
+
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load(), "a minute is longer than MaxBackoff")
+	})
+}
+
+// TestTransport_Wait tests the backoff between retries
+func TestTransport_Wait(t *testing.T) {
+	tr := &transport{backoff: time.Second}
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 10: MaxBackoff} {
+		for range 20 {
+			wait, ok := tr.wait(attempt, nil)
+			assert.True(t, ok)
+			assert.LessOrEqual(t, wait, max, "attempt %d", attempt)
+			assert.GreaterOrEqual(t, wait, time.Duration(0))
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+	wait, ok := tr.wait(1, resp)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+}
+
+// TestClient_CircuitBreaker tests failing fast while a host keeps failing
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	client := New(WithRetries(0, 0), WithCircuitBreaker(2, 50*time.Millisecond), WithName("payments"), quietLogger())
+
+	get := func() (*http.Response, error) {
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	for range 2 {
+		_, err := get()
+		require.NoError(t, err)
+	}
+	_, err := get()
+	assert.ErrorIs(t, err, errors.ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load(), "the open circuit sends nothing")
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = get()
+	require.NoError(t, err, "a trial request after the cooldown")
+	_, err = get()
+	assert.ErrorIs(t, err, errors.ErrCircuitOpen, "the failed trial opens the circuit again")
+
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	for range 3 {
+		resp, err := get()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+// TestContext tests passing the request ID and trace context on to the
+// services called
+func TestContext(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(kit.RequestIDHeader, "req-1")
+	r.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	k := &kit.Kit{Response: httptest.NewRecorder(), Request: r}
+
+	var buf bytes.Buffer
+	client := New(WithLogger(logger.New(config.LoggerConfig{Level: config.LogDebug, Output: &buf, ErrorOutput: &buf})), WithName("search"))
+	req, err := http.NewRequestWithContext(Context(k), "GET", srv.URL+"/q?term=secret", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-1", got.Get(kit.RequestIDHeader))
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", got.Get(TraceParentHeader))
+	assert.Empty(t, got.Get(TraceStateHeader))
+	assert.Empty(t, req.Header.Get(kit.RequestIDHeader), "the caller's request is left alone")
+
+	assert.Contains(t, buf.String(), "Outbound request")
+	assert.Contains(t, buf.String(), "service=search")
+	assert.Contains(t, buf.String(), "request_id=req-1")
+	assert.NotContains(t, buf.String(), "secret", "query strings aren't logged")
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// DefaultFailedLimit is how many failed jobs FailedHandler lists without a
+// limit query parameter
+const DefaultFailedLimit = 50
+
+// FailedHandler lists failed jobs as JSON, most recently failed first, up
+// to the limit query parameter. Mount the admin handlers behind
+// authentication:
+//
+//	admin.Get("/jobs/failed", jobs.FailedHandler)
+//	admin.Post("/jobs/{id}/retry", jobs.RetryHandler)
+//	admin.Delete("/jobs/{id}", jobs.DiscardHandler)
+func FailedHandler(k *kit.Kit) error {
+	limit := DefaultFailedLimit
+	if raw := k.Request.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return errors.ErrAPIQueryParam.WithValue("limit=" + raw)
+		}
+		limit = n
+	}
+
+	failed, err := Failed(k.Request.Context(), limit)
+	if err != nil {
+		return err
+	}
+	return k.JSON(http.StatusOK, failed)
+}
+
+// RetryHandler queues the failed job named by the id path value to run
+// again, responding 404 if there is no such failed job
+func RetryHandler(k *kit.Kit) error {
+	if err := Retry(k.Request.Context(), k.PathValue("id")); err != nil {
+		return err
+	}
+	return k.NoContent()
+}
+
+// DiscardHandler removes the failed job named by the id path value,
+// responding 404 if there is no such failed job
+func DiscardHandler(k *kit.Kit) error {
+	if err := Discard(k.Request.Context(), k.PathValue("id")); err != nil {
+		return err
+	}
+	return k.NoContent()
+}
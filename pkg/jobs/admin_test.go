@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// saveFailed stores a failed job named name
+func saveFailed(t *testing.T, queue Queue, name string) *Job {
+	t.Helper()
+	job, err := NewJob(name, nil, Options{})
+	require.NoError(t, err)
+	failedAt := time.Now().UTC()
+	job.Status, job.Attempts, job.FailedAt, job.LastError = StatusFailed, job.MaxAttempts, &failedAt, "smtp down"
+	require.NoError(t, queue.Save(context.Background(), job))
+	return job
+}
+
+// TestAdminHandlers tests listing, retrying and discarding failed jobs
+func TestAdminHandlers(t *testing.T) {
+	queue, _ := setupQueue(t)
+	job := saveFailed(t, queue, "send-email")
+
+	w := httptest.NewRecorder()
+	err := FailedHandler(&kit.Kit{Response: w, Request: httptest.NewRequest("GET", "/jobs/failed?limit=5", nil)})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var listed []Job
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, job.ID, listed[0].ID)
+	assert.Equal(t, "smtp down", listed[0].LastError)
+
+	err = FailedHandler(&kit.Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/jobs/failed?limit=all", nil)})
+	assert.ErrorIs(t, err, errors.ErrAPIQueryParam)
+
+	r := httptest.NewRequest("POST", "/jobs/"+job.ID+"/retry", nil)
+	r.SetPathValue("id", job.ID)
+	w = httptest.NewRecorder()
+	require.NoError(t, RetryHandler(&kit.Kit{Response: w, Request: r}))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	stored, _ := queue.Find(context.Background(), job.ID)
+	assert.Equal(t, StatusPending, stored.Status)
+
+	r = httptest.NewRequest("DELETE", "/jobs/"+job.ID, nil)
+	r.SetPathValue("id", job.ID)
+	err = DiscardHandler(&kit.Kit{Response: httptest.NewRecorder(), Request: r})
+	assert.ErrorIs(t, err, errors.ErrJobNotFound, "the job is no longer failed")
+	assert.Equal(t, http.StatusNotFound, errors.HTTPStatusOf(err))
+}
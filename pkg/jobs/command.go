@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// RunCommand runs a twine worker subcommand: work, the default, runs jobs
+// until ctx is done; failed lists failed jobs; retry and discard take a
+// failed job's ID. twine worker builds a small program in the project that
+// imports its jobs package and calls RunCommand, so the project's handlers
+// are registered.
+func RunCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		args = []string{"work"}
+	}
+
+	switch args[0] {
+	case "work":
+		if len(args) > 1 {
+			return fmt.Errorf("invalid work arguments: expected none")
+		}
+		names := Registered()
+		if len(names) == 0 {
+			return fmt.Errorf("no job handlers registered. Register them with jobs.Register in the jobs package")
+		}
+		fmt.Fprintf(out, "Handling %s\n", strings.Join(names, ", "))
+		NewWorker().Run(ctx)
+		return nil
+
+	case "failed":
+		flags := flag.NewFlagSet("failed", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		limit := flags.Int("limit", DefaultFailedLimit, "")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() > 0 || *limit < 1 {
+			return fmt.Errorf("invalid failed arguments: expected [--limit n]")
+		}
+		failed, err := Failed(ctx, *limit)
+		if err != nil {
+			return err
+		}
+		writeFailed(out, failed)
+		return nil
+
+	case "retry", "discard":
+		if len(args) != 2 {
+			return fmt.Errorf("invalid %s arguments: expected a job ID", args[0])
+		}
+		if args[0] == "retry" {
+			if err := Retry(ctx, args[1]); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "✓ Queued %s to run again\n", args[1])
+			return nil
+		}
+		if err := Discard(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "✓ Discarded %s\n", args[1])
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// writeFailed prints failed jobs as a table, with the first line of each
+// error
+func writeFailed(out io.Writer, failed []*Job) {
+	if len(failed) == 0 {
+		fmt.Fprintln(out, "No failed jobs")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tJOB\tATTEMPTS\tFAILED AT\tERROR")
+	for _, job := range failed {
+		failedAt := "-"
+		if job.FailedAt != nil {
+			failedAt = job.FailedAt.Format("2006-01-02 15:04:05")
+		}
+		message, _, _ := strings.Cut(job.LastError, "\n")
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", job.ID, job.Name, job.Attempts, failedAt, message)
+	}
+	w.Flush()
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// TestRunCommand tests the subcommands run by twine worker
+func TestRunCommand(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	require.NoError(t, RunCommand(ctx, []string{"failed"}, &out))
+	assert.Equal(t, "No failed jobs\n", out.String())
+
+	job := saveFailed(t, queue, "send-email")
+	out.Reset()
+	require.NoError(t, RunCommand(ctx, []string{"failed", "--limit", "5"}, &out))
+	assert.Contains(t, out.String(), "ID")
+	assert.Contains(t, out.String(), job.ID)
+	assert.Contains(t, out.String(), "smtp down")
+
+	out.Reset()
+	require.NoError(t, RunCommand(ctx, []string{"retry", job.ID}, &out))
+	assert.Contains(t, out.String(), "Queued "+job.ID)
+	assert.ErrorIs(t, RunCommand(ctx, []string{"discard", job.ID}, &out), errors.ErrJobNotFound)
+
+	job = saveFailed(t, queue, "report")
+	out.Reset()
+	require.NoError(t, RunCommand(ctx, []string{"discard", job.ID}, &out))
+	assert.Contains(t, out.String(), "Discarded "+job.ID)
+}
+
+// TestRunCommand_Errors tests invalid arguments
+func TestRunCommand_Errors(t *testing.T) {
+	setupQueue(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		args     []string
+		errorMsg string
+	}{
+		{[]string{"drain"}, `unknown command "drain"`},
+		{[]string{"work", "now"}, "invalid work arguments"},
+		{[]string{"failed", "--limit", "0"}, "invalid failed arguments"},
+		{[]string{"retry"}, "expected a job ID"},
+		{[]string{"discard", "a", "b"}, "expected a job ID"},
+		{nil, "no job handlers registered"},
+	}
+	for _, tt := range tests {
+		err := RunCommand(ctx, tt.args, &bytes.Buffer{})
+		require.Error(t, err, tt.args)
+		assert.Contains(t, err.Error(), tt.errorMsg)
+	}
+}
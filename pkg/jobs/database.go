@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/database"
+)
+
+// Migration creates the jobs table used by DatabaseQueue. It is registered
+// when the package is imported; migrations whose models reference jobs can
+// list it in their Deps.
+var Migration = database.NewMigrationBuilder().
+	Model(&Job{}).
+	Name("jobs").
+	Down(func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&Job{})
+	}).
+	Build()
+
+func init() {
+	database.RegisterMigration(Migration)
+}
+
+// claimAttempts bounds how many due jobs Claim tries when other workers
+// claim them first
+const claimAttempts = 3
+
+// DatabaseQueue is a Queue in the jobs table. Workers claim jobs with a
+// conditional update, so any number of them can share the table.
+type DatabaseQueue struct {
+	db *gorm.DB
+}
+
+// NewDatabaseQueue creates a DatabaseQueue on db, or on database.GORM() if
+// db is nil
+func NewDatabaseQueue(db *gorm.DB) *DatabaseQueue {
+	return &DatabaseQueue{db: db}
+}
+
+func (q *DatabaseQueue) client(ctx context.Context) *gorm.DB {
+	db := q.db
+	if db == nil {
+		db = database.GORM()
+	}
+	return db.WithContext(ctx)
+}
+
+// Save stores a new job or the updated state of one
+func (q *DatabaseQueue) Save(ctx context.Context, job *Job) error {
+	return q.client(ctx).Save(job).Error
+}
+
+// Claim marks the earliest job due at now as running and returns it
+func (q *DatabaseQueue) Claim(ctx context.Context, now time.Time) (*Job, error) {
+	db := q.client(ctx)
+	now = now.UTC()
+
+	for range claimAttempts {
+		var job Job
+		err := db.Where("status IN ? AND run_at <= ?", []Status{StatusPending, StatusRunning}, now).
+			Order("run_at").
+			First(&job).Error
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Attempts changes with every claim, so only one worker's update
+		// matches
+		job.Status = StatusRunning
+		job.RunAt = now.Add(job.Timeout + leaseGrace)
+		job.UpdatedAt = now
+		result := db.Model(&Job{}).
+			Where("id = ? AND attempts = ?", job.ID, job.Attempts).
+			Updates(map[string]any{
+				"status":     job.Status,
+				"attempts":   job.Attempts + 1,
+				"run_at":     job.RunAt,
+				"updated_at": job.UpdatedAt,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Attempts++
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+// Find returns the job with id, or nil if there is none
+func (q *DatabaseQueue) Find(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	err := q.client(ctx).Where("id = ?", id).First(&job).Error
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Delete removes the job with id
+func (q *DatabaseQueue) Delete(ctx context.Context, id string) error {
+	return q.client(ctx).Where("id = ?", id).Delete(&Job{}).Error
+}
+
+// Failed returns up to limit failed jobs, most recently failed first
+func (q *DatabaseQueue) Failed(ctx context.Context, limit int) ([]*Job, error) {
+	var failed []*Job
+	err := q.client(ctx).
+		Where("status = ?", StatusFailed).
+		Order("failed_at DESC").
+		Limit(limit).
+		Find(&failed).Error
+	return failed, err
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDatabaseQueue_Claim tests claiming due jobs in order
+func TestDatabaseQueue_Claim(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	later, _ := NewJob("later", nil, Options{Delay: time.Hour})
+	second, _ := NewJob("second", nil, Options{})
+	first, _ := NewJob("first", nil, Options{Timeout: time.Minute})
+	first.RunAt = now.Add(-time.Minute)
+	for _, job := range []*Job{later, second, first} {
+		require.NoError(t, queue.Save(ctx, job))
+	}
+
+	claimed, err := queue.Claim(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, "first", claimed.Name)
+	assert.Equal(t, StatusRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+
+	stored, err := queue.Find(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.WithinDuration(t, now.Add(time.Minute+leaseGrace), stored.RunAt, 2*time.Second, "the claim lasts past the timeout")
+
+	claimed, err = queue.Claim(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "second", claimed.Name)
+
+	claimed, err = queue.Claim(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "jobs that aren't due stay queued")
+
+	claimed, err = queue.Claim(ctx, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, "first", claimed.Name, "a job whose claim ran out is claimed again")
+	assert.Equal(t, 2, claimed.Attempts)
+}
+
+// TestDatabaseQueue_ConcurrentClaims tests that each job is claimed once
+func TestDatabaseQueue_ConcurrentClaims(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx := context.Background()
+
+	for range 10 {
+		job, _ := NewJob("count", nil, Options{})
+		require.NoError(t, queue.Save(ctx, job))
+	}
+
+	var mu sync.Mutex
+	claimed := map[string]int{}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := queue.Claim(ctx, time.Now().Add(time.Second))
+				if err != nil || job == nil {
+					return
+				}
+				mu.Lock()
+				claimed[job.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, 10)
+	for id, n := range claimed {
+		assert.Equal(t, 1, n, "job %s claimed more than once", id)
+	}
+}
+
+// TestDatabaseQueue_Failed tests listing failed jobs, most recent first
+func TestDatabaseQueue_Failed(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for i, name := range []string{"older", "newer"} {
+		job, _ := NewJob(name, nil, Options{})
+		failedAt := now.Add(time.Duration(i) * time.Minute)
+		job.Status, job.FailedAt = StatusFailed, &failedAt
+		require.NoError(t, queue.Save(ctx, job))
+	}
+	pending, _ := NewJob("pending", nil, Options{})
+	require.NoError(t, queue.Save(ctx, pending))
+
+	failed, err := queue.Failed(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+	assert.Equal(t, "newer", failed[0].Name)
+	assert.Equal(t, "older", failed[1].Name)
+
+	failed, err = queue.Failed(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, failed, 1)
+}
@@ -0,0 +1,321 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// Defaults for Options fields left at zero
+const (
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = 10 * time.Second
+	DefaultTimeout     = 5 * time.Minute
+)
+
+// MaxBackoff caps the wait between attempts
+const MaxBackoff = time.Hour
+
+// Status is where a job is in its life. Jobs that succeed are removed.
+type Status string
+
+const (
+	// StatusPending jobs run once their RunAt passes
+	StatusPending Status = "pending"
+	// StatusRunning jobs are claimed by a worker until their RunAt passes,
+	// when another worker can claim them again
+	StatusRunning Status = "running"
+	// StatusFailed jobs used their attempts or failed permanently, and wait
+	// for Retry or Discard
+	StatusFailed Status = "failed"
+)
+
+// Job is a unit of background work and its state in the queue
+type Job struct {
+	ID          string        `json:"id" gorm:"primaryKey;size:36"`
+	Name        string        `json:"name" gorm:"size:255;index"`
+	Payload     string        `json:"payload" gorm:"type:text"`
+	Status      Status        `json:"status" gorm:"size:16;index:idx_jobs_status_run_at"`
+	Attempts    int           `json:"attempts"`
+	MaxAttempts int           `json:"max_attempts"`
+	Backoff     time.Duration `json:"backoff"`
+	Timeout     time.Duration `json:"timeout"`
+	RunAt       time.Time     `json:"run_at" gorm:"index:idx_jobs_status_run_at"`
+	LastError   string        `json:"last_error,omitempty" gorm:"type:text"`
+	FailedAt    *time.Time    `json:"failed_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Decode unmarshals the job's JSON payload into v
+func (j *Job) Decode(v any) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// Options control when and how often a job runs
+type Options struct {
+	// Delay postpones the first attempt
+	Delay time.Duration
+	// MaxAttempts is how many times the job runs before it fails; 0 uses
+	// DefaultMaxAttempts
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, doubling for each one
+	// after it up to MaxBackoff; 0 uses DefaultBackoff
+	Backoff time.Duration
+	// Timeout bounds each attempt; 0 uses DefaultTimeout
+	Timeout time.Duration
+}
+
+// Handler runs a job. Returning an error retries the job after its
+// backoff, unless it was the last attempt or the error is Permanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// Handle adapts a function taking the decoded payload to a Handler:
+//
+//	jobs.Register("send-email", jobs.Handle(func(ctx context.Context, email Email) error {
+//	    return mailer.Send(ctx, email)
+//	}))
+//
+// A payload that doesn't decode fails the job permanently.
+func Handle[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := job.Decode(&payload); err != nil {
+			return Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// permanentError marks an error that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err so the job fails without further attempts, such as
+// for a payload that can never succeed
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p permanentError
+	return stderrors.As(err, &p)
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]Handler{}
+)
+
+// Register sets the handler for jobs named name. Register handlers in the
+// web process and the worker alike, usually from an init function.
+func Register(name string, h Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[name] = h
+}
+
+// Registered returns the names of the registered handlers, sorted
+func Registered() []string {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func handler(name string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[name]
+	return h, ok
+}
+
+// Queue stores jobs for workers. DatabaseQueue and RedisQueue implement
+// it.
+type Queue interface {
+	// Save stores a new job or the updated state of one
+	Save(ctx context.Context, job *Job) error
+	// Claim marks the earliest job due at now as running, counting an
+	// attempt, and returns it, or nil if no job is due. The claim lasts
+	// until the job's RunAt, which Claim sets past its timeout.
+	Claim(ctx context.Context, now time.Time) (*Job, error)
+	// Find returns the job with id, or nil if there is none
+	Find(ctx context.Context, id string) (*Job, error)
+	// Delete removes the job with id
+	Delete(ctx context.Context, id string) error
+	// Failed returns up to limit failed jobs, most recently failed first
+	Failed(ctx context.Context, limit int) ([]*Job, error)
+}
+
+var (
+	mu       sync.Mutex
+	instance Queue
+)
+
+// Get returns the application's Queue, creating it from config.Get().Jobs
+// when first called
+func Get() Queue {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if instance == nil {
+		cfg := config.Get().Jobs
+		queue, err := New(cfg)
+		if err != nil {
+			logger.Get().Error("Creating %s job queue: %v; using database", cfg.Driver, err)
+			queue = NewDatabaseQueue(nil)
+		}
+		instance = queue
+	}
+	return instance
+}
+
+// New creates the Queue for cfg.Driver
+func New(cfg config.JobsConfig) (Queue, error) {
+	switch cfg.Driver {
+	case config.JobsDatabase, "":
+		return NewDatabaseQueue(nil), nil
+	case config.JobsRedis:
+		return NewRedisQueue(cfg.RedisURL)
+	}
+	return nil, fmt.Errorf("unknown jobs driver %q", cfg.Driver)
+}
+
+// Use makes queue the one returned by Get. It is meant for tests and for
+// applications with their own Queue.
+func Use(queue Queue) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = queue
+}
+
+// Reset forgets the current queue, so the next Get creates it again
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = nil
+}
+
+// Enqueue adds a job named name to the queue returned by Get, with payload
+// encoded as JSON:
+//
+//	_, err := jobs.Enqueue(ctx, "send-email", Email{To: user.Email}, jobs.Options{Delay: time.Minute})
+//
+// The job runs on a worker with the handler registered for name.
+func Enqueue(ctx context.Context, name string, payload any, opts Options) (*Job, error) {
+	job, err := NewJob(name, payload, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := Get().Save(ctx, job); err != nil {
+		return nil, errors.ErrEnqueueJob.Wrap(err)
+	}
+	return job, nil
+}
+
+// NewJob creates a pending job without queueing it, for Queue.Save
+func NewJob(name string, payload any, opts Options) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.ErrEnqueueJob.Wrap(fmt.Errorf("encoding %s payload: %w", name, err))
+	}
+
+	now := time.Now().UTC()
+	job := &Job{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Payload:     string(data),
+		Status:      StatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		Backoff:     opts.Backoff,
+		Timeout:     opts.Timeout,
+		RunAt:       now.Add(opts.Delay),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	if job.Backoff <= 0 {
+		job.Backoff = DefaultBackoff
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = DefaultTimeout
+	}
+	return job, nil
+}
+
+// Failed returns up to limit failed jobs from the queue returned by Get,
+// most recently failed first
+func Failed(ctx context.Context, limit int) ([]*Job, error) {
+	return Get().Failed(ctx, limit)
+}
+
+// Retry queues the failed job with id to run again now, with its attempts
+// reset
+func Retry(ctx context.Context, id string) error {
+	queue := Get()
+	job, err := queue.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.Status != StatusFailed {
+		return errors.ErrJobNotFound.WithValue(id)
+	}
+
+	now := time.Now().UTC()
+	job.Status = StatusPending
+	job.Attempts = 0
+	job.RunAt = now
+	job.FailedAt = nil
+	job.UpdatedAt = now
+	return queue.Save(ctx, job)
+}
+
+// Discard removes the failed job with id
+func Discard(ctx context.Context, id string) error {
+	queue := Get()
+	job, err := queue.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.Status != StatusFailed {
+		return errors.ErrJobNotFound.WithValue(id)
+	}
+	return queue.Delete(ctx, id)
+}
+
+// backoff returns the wait after a job's attempt fails
+func backoff(job *Job) time.Duration {
+	wait := job.Backoff
+	for i := 1; i < job.Attempts && wait < MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, MaxBackoff)
+}
+
+// leaseGrace is how long past its timeout a claimed job stays claimed, so
+// a slow worker finishes before another claims the job
+const leaseGrace = 30 * time.Second
@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+type email struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+// setupQueue makes a DatabaseQueue on a new SQLite database the one
+// returned by Get
+func setupQueue(t *testing.T) (*DatabaseQueue, *gorm.DB) {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&Job{}))
+
+	queue := NewDatabaseQueue(db)
+	Use(queue)
+	t.Cleanup(Reset)
+	return queue, db
+}
+
+// registerHandler registers h for the rest of the test
+func registerHandler(t *testing.T, name string, h Handler) {
+	t.Helper()
+	Register(name, h)
+	t.Cleanup(func() {
+		handlersMu.Lock()
+		defer handlersMu.Unlock()
+		delete(handlers, name)
+	})
+}
+
+// TestNewJob tests encoding payloads and applying option defaults
+func TestNewJob(t *testing.T) {
+	before := time.Now().UTC()
+	job, err := NewJob("send-email", email{To: "ada@example.com"}, Options{Delay: time.Hour})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, `{"to":"ada@example.com","subject":""}`, job.Payload)
+	assert.Equal(t, DefaultMaxAttempts, job.MaxAttempts)
+	assert.Equal(t, DefaultBackoff, job.Backoff)
+	assert.Equal(t, DefaultTimeout, job.Timeout)
+	assert.WithinDuration(t, before.Add(time.Hour), job.RunAt, time.Second)
+
+	var decoded email
+	require.NoError(t, job.Decode(&decoded))
+	assert.Equal(t, "ada@example.com", decoded.To)
+
+	job, err = NewJob("report", nil, Options{MaxAttempts: 1, Backoff: time.Second, Timeout: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.MaxAttempts)
+	assert.Equal(t, time.Second, job.Backoff)
+	assert.Equal(t, time.Minute, job.Timeout)
+
+	_, err = NewJob("bad", func() {}, Options{})
+	assert.ErrorIs(t, err, errors.ErrEnqueueJob)
+}
+
+// TestEnqueue tests saving jobs to the queue returned by Get
+func TestEnqueue(t *testing.T) {
+	queue, _ := setupQueue(t)
+
+	job, err := Enqueue(context.Background(), "send-email", email{To: "ada@example.com"}, Options{})
+	require.NoError(t, err)
+
+	stored, err := queue.Find(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "send-email", stored.Name)
+	assert.Equal(t, job.Payload, stored.Payload)
+}
+
+// TestHandle tests decoding payloads for typed handlers
+func TestHandle(t *testing.T) {
+	var got email
+	h := Handle(func(ctx context.Context, e email) error {
+		got = e
+		return nil
+	})
+
+	require.NoError(t, h(context.Background(), &Job{Payload: `{"to":"ada@example.com"}`}))
+	assert.Equal(t, "ada@example.com", got.To)
+
+	err := h(context.Background(), &Job{Payload: `not json`})
+	assert.True(t, IsPermanent(err), "payloads that don't decode never succeed")
+}
+
+// TestPermanent tests marking errors that shouldn't be retried
+func TestPermanent(t *testing.T) {
+	cause := fmt.Errorf("unknown template")
+	err := Permanent(cause)
+	assert.True(t, IsPermanent(err))
+	assert.True(t, IsPermanent(fmt.Errorf("sending: %w", err)))
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "unknown template", err.Error())
+
+	assert.False(t, IsPermanent(cause))
+	assert.Nil(t, Permanent(nil))
+}
+
+// TestBackoff tests doubling the wait between attempts up to MaxBackoff
+func TestBackoff(t *testing.T) {
+	job := &Job{Backoff: 10 * time.Second}
+	for attempts, want := range map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		3:  40 * time.Second,
+		20: MaxBackoff,
+	} {
+		job.Attempts = attempts
+		assert.Equal(t, want, backoff(job), "after %d attempts", attempts)
+	}
+}
+
+// TestRetryAndDiscard tests requeueing and removing failed jobs
+func TestRetryAndDiscard(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx := context.Background()
+
+	failedAt := time.Now().UTC()
+	job, err := NewJob("send-email", nil, Options{})
+	require.NoError(t, err)
+	job.Status, job.Attempts, job.LastError, job.FailedAt = StatusFailed, 3, "timeout", &failedAt
+	require.NoError(t, queue.Save(ctx, job))
+
+	require.NoError(t, Retry(ctx, job.ID))
+	stored, err := queue.Find(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, stored.Status)
+	assert.Equal(t, 0, stored.Attempts)
+	assert.Nil(t, stored.FailedAt)
+
+	assert.ErrorIs(t, Retry(ctx, job.ID), errors.ErrJobNotFound, "only failed jobs are retried")
+	assert.ErrorIs(t, Discard(ctx, job.ID), errors.ErrJobNotFound, "only failed jobs are discarded")
+	assert.ErrorIs(t, Retry(ctx, "missing"), errors.ErrJobNotFound)
+
+	stored.Status, stored.FailedAt = StatusFailed, &failedAt
+	require.NoError(t, queue.Save(ctx, stored))
+	require.NoError(t, Discard(ctx, job.ID))
+	stored, err = queue.Find(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/cstone-io/twine/internal/redis"
+)
+
+// Redis keys used by RedisQueue. Pending and running jobs share a sorted
+// set scored by the time they can next be claimed.
+const (
+	redisJobPrefix  = "jobs:job:"
+	redisPendingKey = "jobs:pending"
+	redisFailedKey  = "jobs:failed"
+)
+
+// redisClaimBatch is how many due jobs Claim reads at once
+const redisClaimBatch = 10
+
+// RedisQueue is a Queue on a Redis server. Each job is stored as JSON under
+// a "jobs:job:" key; the "jobs:pending" and "jobs:failed" sorted sets order
+// them.
+type RedisQueue struct {
+	client *redis.Client
+}
+
+// NewRedisQueue creates a RedisQueue for a redis:// or rediss:// URL. It
+// connects when first used.
+func NewRedisQueue(rawURL string) (*RedisQueue, error) {
+	client, err := redis.NewClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisQueue{client: client}, nil
+}
+
+// Save stores a new job or the updated state of one
+func (q *RedisQueue) Save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	commands := [][]string{{"SET", redisJobPrefix + job.ID, string(data)}}
+	if job.Status == StatusFailed {
+		failedAt := job.UpdatedAt
+		if job.FailedAt != nil {
+			failedAt = *job.FailedAt
+		}
+		commands = append(commands,
+			[]string{"ZREM", redisPendingKey, job.ID},
+			[]string{"ZADD", redisFailedKey, score(failedAt), job.ID})
+	} else {
+		commands = append(commands,
+			[]string{"ZREM", redisFailedKey, job.ID},
+			[]string{"ZADD", redisPendingKey, score(job.RunAt), job.ID})
+	}
+	_, err = q.client.Do(ctx, commands...)
+	return err
+}
+
+// Claim marks the earliest job due at now as running and returns it.
+// Removing the job from the pending set is the claim, so only one worker
+// gets it.
+func (q *RedisQueue) Claim(ctx context.Context, now time.Time) (*Job, error) {
+	replies, err := q.client.Do(ctx, []string{"ZRANGEBYSCORE", redisPendingKey, "-inf", score(now), "LIMIT", "0", strconv.Itoa(redisClaimBatch)})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range redis.Strings(replies[0]) {
+		replies, err := q.client.Do(ctx,
+			[]string{"ZREM", redisPendingKey, id},
+			[]string{"GET", redisJobPrefix + id})
+		if err != nil {
+			return nil, err
+		}
+		if replies[0] != int64(1) || replies[1] == nil {
+			// Another worker claimed it, or it finished and left its
+			// place behind
+			continue
+		}
+
+		var job Job
+		if err := json.Unmarshal([]byte(replies[1].(string)), &job); err != nil {
+			return nil, err
+		}
+		job.Status = StatusRunning
+		job.Attempts++
+		job.RunAt = now.UTC().Add(job.Timeout + leaseGrace)
+		job.UpdatedAt = now.UTC()
+		if err := q.Save(ctx, &job); err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+	return nil, nil
+}
+
+// Find returns the job with id, or nil if there is none
+func (q *RedisQueue) Find(ctx context.Context, id string) (*Job, error) {
+	replies, err := q.client.Do(ctx, []string{"GET", redisJobPrefix + id})
+	if err != nil || replies[0] == nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(replies[0].(string)), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Delete removes the job with id
+func (q *RedisQueue) Delete(ctx context.Context, id string) error {
+	_, err := q.client.Do(ctx,
+		[]string{"DEL", redisJobPrefix + id},
+		[]string{"ZREM", redisPendingKey, id},
+		[]string{"ZREM", redisFailedKey, id})
+	return err
+}
+
+// Failed returns up to limit failed jobs, most recently failed first
+func (q *RedisQueue) Failed(ctx context.Context, limit int) ([]*Job, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	replies, err := q.client.Do(ctx, []string{"ZREVRANGE", redisFailedKey, "0", strconv.Itoa(limit - 1)})
+	if err != nil {
+		return nil, err
+	}
+
+	failed := []*Job{}
+	for _, id := range redis.Strings(replies[0]) {
+		job, err := q.Find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil {
+			failed = append(failed, job)
+		}
+	}
+	return failed, nil
+}
+
+// Close closes the idle connections
+func (q *RedisQueue) Close() error {
+	return q.client.Close()
+}
+
+// score is t as a sorted set score, in Unix milliseconds
+func score(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
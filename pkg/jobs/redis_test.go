@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// TestRedisQueue tests saving, claiming and failing jobs on a Redis server
+func TestRedisQueue(t *testing.T) {
+	ctx := context.Background()
+	server := testutil.SetupFakeRedis(t)
+	queue, err := NewRedisQueue(server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { queue.Close() })
+	now := time.Now().UTC()
+
+	later, _ := NewJob("later", nil, Options{Delay: time.Hour})
+	job, _ := NewJob("send-email", email{To: "ada@example.com"}, Options{Timeout: time.Minute})
+	require.NoError(t, queue.Save(ctx, later))
+	require.NoError(t, queue.Save(ctx, job))
+
+	claimed, err := queue.Claim(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, job.ID, claimed.ID)
+	assert.Equal(t, StatusRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+	assert.Equal(t, job.Payload, claimed.Payload)
+
+	claimed, err = queue.Claim(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "a claimed job isn't due until its claim runs out")
+
+	claimed, err = queue.Claim(ctx, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, 2, claimed.Attempts, "a job whose claim ran out is claimed again")
+
+	failedAt := now
+	claimed.Status, claimed.FailedAt, claimed.LastError = StatusFailed, &failedAt, "smtp down"
+	require.NoError(t, queue.Save(ctx, claimed))
+	failed, err := queue.Failed(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "smtp down", failed[0].LastError)
+
+	claimed, err = queue.Claim(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, "later", claimed.Name, "failed jobs aren't claimed")
+
+	require.NoError(t, queue.Delete(ctx, job.ID))
+	found, err := queue.Find(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	failed, err = queue.Failed(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+}
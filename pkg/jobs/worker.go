@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// Worker runs queued jobs with the registered handlers
+type Worker struct {
+	// Queue is where jobs are claimed from; nil uses Get
+	Queue Queue
+	// Concurrency is how many jobs run at once
+	Concurrency int
+	// PollInterval is how long an idle runner waits before claiming again
+	PollInterval time.Duration
+
+	now func() time.Time
+}
+
+// NewWorker creates a Worker for the queue returned by Get, with the
+// JOBS_CONCURRENCY and JOBS_POLL_INTERVAL settings
+func NewWorker() *Worker {
+	cfg := config.Get().Jobs
+	return &Worker{
+		Concurrency:  cfg.Concurrency,
+		PollInterval: cfg.PollInterval,
+	}
+}
+
+// Run runs jobs until ctx is done, then waits for the running jobs to
+// finish. Jobs keep their own timeouts rather than ctx, so a shutdown
+// drains them instead of failing them.
+func (w *Worker) Run(ctx context.Context) {
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = config.DefaultJobsConcurrency
+	}
+
+	logger.Get().Info("Running jobs with %d workers", concurrency)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+	logger.Get().Info("Job workers stopped")
+}
+
+// Start runs w in a goroutine and returns a function that waits for it to
+// drain after ctx is done. Stop it with the server's shutdown hooks:
+//
+//	ctx, stop := context.WithCancel(context.Background())
+//	drained := jobs.NewWorker().Start(ctx)
+//	srv.OnShutdown("jobs", func(context.Context) error {
+//	    stop()
+//	    drained()
+//	    return nil
+//	})
+//	srv.Run(context.Background())
+func (w *Worker) Start(ctx context.Context) (wait func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+	return func() { <-done }
+}
+
+// loop claims and runs jobs until ctx is done
+func (w *Worker) loop(ctx context.Context) {
+	poll := w.PollInterval
+	if poll <= 0 {
+		poll = config.DefaultJobsPollInterval
+	}
+
+	for ctx.Err() == nil {
+		ran, err := w.RunNext(context.WithoutCancel(ctx))
+		if err != nil {
+			logger.Get().Error("Claiming job: %v", err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(poll):
+		}
+	}
+}
+
+// RunNext claims one due job and runs it, reporting whether there was one
+func (w *Worker) RunNext(ctx context.Context) (bool, error) {
+	queue := w.queue()
+	job, err := queue.Claim(ctx, w.clock())
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	log := logger.Get().With("job", job.Name, "job_id", job.ID, "attempt", job.Attempts)
+	start := time.Now()
+	runErr := w.run(ctx, job)
+	now := w.clock().UTC()
+	job.UpdatedAt = now
+
+	if runErr == nil {
+		log.With("duration_ms", time.Since(start).Milliseconds()).Info("Job %s done", job.Name)
+		return true, queue.Delete(ctx, job.ID)
+	}
+
+	job.LastError = runErr.Error()
+	if job.Attempts >= job.MaxAttempts || IsPermanent(runErr) {
+		job.Status = StatusFailed
+		job.FailedAt = &now
+		log.CustomError(errors.ErrRunJob.Wrap(runErr).WithValue(job.Name))
+	} else {
+		job.Status = StatusPending
+		job.RunAt = now.Add(backoff(job))
+		log.Warn("Job %s failed, retrying at %s: %v", job.Name, job.RunAt.Format(time.RFC3339), runErr)
+	}
+	return true, queue.Save(ctx, job)
+}
+
+// run calls job's handler with its timeout, turning a panic into an error
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	h, ok := handler(job.Name)
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for job %q", job.Name))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h(ctx, job)
+}
+
+func (w *Worker) queue() Queue {
+	if w.Queue == nil {
+		return Get()
+	}
+	return w.Queue
+}
+
+func (w *Worker) clock() time.Time {
+	if w.now == nil {
+		return time.Now()
+	}
+	return w.now()
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWorker_RunNext tests completing, retrying and failing jobs
+func TestWorker_RunNext(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx := context.Background()
+	// Jobs enqueued during the test are due
+	now := time.Now().UTC().Add(time.Second)
+	w := &Worker{Queue: queue, now: func() time.Time { return now }}
+
+	var sent []string
+	registerHandler(t, "send-email", Handle(func(ctx context.Context, e email) error {
+		if e.To == "" {
+			return fmt.Errorf("missing recipient")
+		}
+		sent = append(sent, e.To)
+		return nil
+	}))
+	registerHandler(t, "invalid", func(context.Context, *Job) error {
+		return Permanent(fmt.Errorf("invalid report"))
+	})
+	registerHandler(t, "panics", func(context.Context, *Job) error {
+		panic("nil map")
+	})
+
+	ran, err := w.RunNext(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran, "nothing is queued")
+
+	done, _ := Enqueue(ctx, "send-email", email{To: "ada@example.com"}, Options{})
+	ran, err = w.RunNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, []string{"ada@example.com"}, sent)
+	stored, _ := queue.Find(ctx, done.ID)
+	assert.Nil(t, stored, "finished jobs are removed")
+
+	retried, _ := Enqueue(ctx, "send-email", email{}, Options{MaxAttempts: 2, Backoff: time.Minute})
+	_, err = w.RunNext(ctx)
+	require.NoError(t, err)
+	stored, _ = queue.Find(ctx, retried.ID)
+	assert.Equal(t, StatusPending, stored.Status)
+	assert.Equal(t, "missing recipient", stored.LastError)
+	assert.WithinDuration(t, now.Add(time.Minute), stored.RunAt, time.Second, "retries wait for the backoff")
+
+	now = now.Add(time.Minute)
+	_, err = w.RunNext(ctx)
+	require.NoError(t, err)
+	stored, _ = queue.Find(ctx, retried.ID)
+	assert.Equal(t, StatusFailed, stored.Status, "the last attempt fails the job")
+	assert.Equal(t, 2, stored.Attempts)
+	assert.NotNil(t, stored.FailedAt)
+
+	for _, name := range []string{"invalid", "unregistered"} {
+		job, _ := Enqueue(ctx, name, nil, Options{})
+		_, err = w.RunNext(ctx)
+		require.NoError(t, err)
+		stored, _ = queue.Find(ctx, job.ID)
+		assert.Equal(t, StatusFailed, stored.Status, "%s jobs fail without retries", name)
+		assert.Equal(t, 1, stored.Attempts, name)
+	}
+	failed, _ := Failed(ctx, 10)
+	require.Len(t, failed, 3)
+	errs := map[string]string{}
+	for _, job := range failed {
+		errs[job.Name] = job.LastError
+	}
+	assert.Equal(t, `no handler registered for job "unregistered"`, errs["unregistered"])
+
+	panicked, _ := Enqueue(ctx, "panics", nil, Options{})
+	_, err = w.RunNext(ctx)
+	require.NoError(t, err)
+	stored, _ = queue.Find(ctx, panicked.ID)
+	assert.Equal(t, StatusPending, stored.Status, "a panic is retried like an error")
+	assert.Contains(t, stored.LastError, "panic: nil map")
+}
+
+// TestWorker_Timeout tests bounding each attempt with the job's timeout
+func TestWorker_Timeout(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx := context.Background()
+	w := &Worker{Queue: queue}
+
+	registerHandler(t, "slow", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job, _ := Enqueue(ctx, "slow", nil, Options{Timeout: 10 * time.Millisecond, MaxAttempts: 1})
+
+	_, err := w.RunNext(ctx)
+	require.NoError(t, err)
+	stored, _ := queue.Find(ctx, job.ID)
+	assert.Equal(t, StatusFailed, stored.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), stored.LastError)
+}
+
+// TestWorker_Run tests running queued jobs and draining on shutdown
+func TestWorker_Run(t *testing.T) {
+	queue, _ := setupQueue(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	registerHandler(t, "count", func(ctx context.Context, job *Job) error {
+		if count.Add(1) == 3 {
+			close(started)
+			<-release
+		}
+		return nil
+	})
+	for range 3 {
+		_, err := Enqueue(context.Background(), "count", nil, Options{})
+		require.NoError(t, err)
+	}
+
+	w := &Worker{Queue: queue, Concurrency: 2, PollInterval: 10 * time.Millisecond}
+	wait := w.Start(ctx)
+
+	<-started
+	cancel()
+	close(release)
+	wait()
+
+	assert.Equal(t, int32(3), count.Load())
+	remaining, err := queue.Claim(context.Background(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, remaining, "the running job finished after shutdown began")
+}
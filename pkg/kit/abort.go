@@ -0,0 +1,54 @@
+package kit
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// Aborted reports whether the client closed the request, as when a user
+// navigates away or an HTMX poll is superseded. A handler doing slow work
+// can stop early:
+//
+//	for _, row := range rows {
+//	    if k.Aborted() {
+//	        return errors.ErrAborted
+//	    }
+//	    ...
+//	}
+//
+// A request cut short by TimeoutMiddleware isn't aborted, and neither is
+// one whose context middleware cancelled once the handler returned.
+func (k *Kit) Aborted() bool {
+	ctx := k.client
+	if ctx == nil {
+		// A Kit built outside Handler, as in tests
+		ctx = k.Request.Context()
+	}
+	return stderrors.Is(ctx.Err(), context.Canceled)
+}
+
+// Deadline returns when the request's time budget, set by
+// middleware.TimeoutMiddleware, runs out, and false if it has none. Work
+// bound to k.Request.Context() stops then: queries through k.DB() and the
+// stores, and calls made with httpclient.Context(k). A handler can skip
+// optional work when little is left:
+//
+//	if deadline, ok := k.Deadline(); ok && time.Until(deadline) < time.Second {
+//	    return k.Render("search/results", results)
+//	}
+func (k *Kit) Deadline() (time.Time, bool) {
+	return k.Request.Context().Deadline()
+}
+
+// abortedError returns err, or ErrAborted wrapping it when the client has
+// closed the request, so a failed write or read isn't handled as the
+// server's error
+func (k *Kit) abortedError(err error) error {
+	if err == nil || !k.Aborted() || stderrors.Is(err, errors.ErrAborted) {
+		return err
+	}
+	return errors.ErrAborted.Wrap(err)
+}
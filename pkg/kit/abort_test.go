@@ -0,0 +1,87 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+// abortedRequest returns a request whose client has gone away
+func abortedRequest(body string) *http.Request {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest("POST", "/poll", strings.NewReader(body)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+// TestKit_Aborted tests detecting a request the client closed
+func TestKit_Aborted(t *testing.T) {
+	assert.False(t, (&Kit{Request: httptest.NewRequest("GET", "/", nil)}).Aborted())
+	assert.True(t, (&Kit{Request: abortedRequest("")}).Aborted())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	timedOut := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	assert.False(t, (&Kit{Request: timedOut}).Aborted(), "a timeout isn't the client's doing")
+}
+
+// TestKit_Deadline tests reading the request's time budget
+func TestKit_Deadline(t *testing.T) {
+	_, ok := (&Kit{Request: httptest.NewRequest("GET", "/", nil)}).Deadline()
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	deadline, ok := (&Kit{Request: httptest.NewRequest("GET", "/", nil).WithContext(ctx)}).Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second)
+}
+
+// TestKit_AbortedHelpers tests the helpers returning ErrAborted without
+// doing the work
+func TestKit_AbortedHelpers(t *testing.T) {
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: abortedRequest(`{"name":"x"}`)}
+
+	var v struct{ Name string }
+	assert.ErrorIs(t, k.Decode(&v), twineerrors.ErrAborted)
+	assert.Empty(t, v.Name)
+	assert.ErrorIs(t, k.JSON(http.StatusOK, v), twineerrors.ErrAborted)
+	assert.ErrorIs(t, k.RenderTemplate("page", nil), twineerrors.ErrAborted)
+	assert.ErrorIs(t, k.Render("page", nil), twineerrors.ErrAborted)
+	assert.Empty(t, w.Body.String(), "nothing written")
+
+	assert.NoError(t, k.abortedError(nil))
+	assert.Equal(t, twineerrors.StatusClientClosedRequest, twineerrors.HTTPStatusOf(k.abortedError(assert.AnError)))
+}
+
+// TestHandler_Aborted tests not handling the errors of aborted requests as
+// the server's
+func TestHandler_Aborted(t *testing.T) {
+	reported := false
+	t.Cleanup(UseErrorReporter(reporterFunc(func(context.Context, ErrorReport) { reported = true })))
+
+	handled := false
+	originalHandler := errorHandler
+	t.Cleanup(func() { errorHandler = originalHandler })
+	UseErrorHandler(func(k *Kit, err error) { handled = true })
+
+	h := Handler(func(k *Kit) error {
+		return twineerrors.ErrDatabaseRead.Wrap(context.Canceled)
+	})
+	w := httptest.NewRecorder()
+	h(w, abortedRequest(""))
+
+	assert.False(t, handled, "nobody is left to respond to")
+	assert.False(t, reported)
+	assert.Empty(t, w.Body.String())
+}
@@ -0,0 +1,146 @@
+package kit
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// Cookies set by SetAuthCookies and SetSessionCookie. Authorization reads
+// the access token from TokenCookie.
+const (
+	TokenCookie        = "token"
+	RefreshTokenCookie = "refresh_token"
+	SessionCookie      = "session"
+)
+
+// SetAuthCookies sets the access and refresh tokens of a login as
+// HTTP-only cookies, expiring after AUTH_ACCESS_TTL and AUTH_REFRESH_TTL:
+//
+//	pair, err := auth.NewTokenPair(k.Request.Context(), user.ID, user.Email)
+//	if err != nil {
+//	    return err
+//	}
+//	k.SetAuthCookies(pair.AccessToken, pair.RefreshToken)
+//
+// The cookies are marked Secure as SecureCookies reports.
+func (k *Kit) SetAuthCookies(access, refresh string) {
+	cfg := config.Get().Auth
+	accessTTL, refreshTTL := cfg.AccessTTL, cfg.RefreshTTL
+	if accessTTL <= 0 {
+		accessTTL = config.DefaultAccessTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = config.DefaultRefreshTTL
+	}
+
+	k.setAuthCookie(TokenCookie, access, accessTTL)
+	k.setAuthCookie(RefreshTokenCookie, refresh, refreshTTL)
+}
+
+// ClearAuthCookies removes the cookies set by SetAuthCookies, on logout
+func (k *Kit) ClearAuthCookies() {
+	k.setAuthCookie(TokenCookie, "", -1)
+	k.setAuthCookie(RefreshTokenCookie, "", -1)
+}
+
+// SetSessionCookie sets the session ID of a login made with auth.Login as
+// an HTTP-only cookie expiring after ttl
+func (k *Kit) SetSessionCookie(id string, ttl time.Duration) {
+	k.setAuthCookie(SessionCookie, id, ttl)
+}
+
+// ClearSessionCookie removes the cookie set by SetSessionCookie
+func (k *Kit) ClearSessionCookie() {
+	k.setAuthCookie(SessionCookie, "", -1)
+}
+
+// SecureCookies reports whether cookies set for the request are marked
+// Secure: on HTTPS requests, and always in production, where TLS usually
+// ends at a proxy in front of the application
+func (k *Kit) SecureCookies() bool {
+	return k.Request.TLS != nil || config.Get().IsProd()
+}
+
+// setAuthCookie sets an HTTP-only cookie for ttl, or deletes it if ttl is
+// negative
+func (k *Kit) setAuthCookie(name, value string, ttl time.Duration) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl / time.Second),
+		SameSite: http.SameSiteStrictMode,
+		Secure:   k.SecureCookies(),
+		HttpOnly: true,
+	}
+	if ttl < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.Expires = time.Now().Add(ttl)
+	}
+	http.SetCookie(k.Response, cookie)
+}
+
+// logout signs the request's user out for Logout, set with UseLogout
+var logout func(k *Kit) error
+
+// UseLogout sets how Logout signs users out. Applications using pkg/auth
+// set it to auth.Logout when they start:
+//
+//	kit.UseLogout(auth.Logout)
+func UseLogout(fn func(k *Kit) error) {
+	logout = fn
+}
+
+// Logout signs the request's user out: it ends their session and revokes
+// their tokens with the function set by UseLogout, and clears the auth and
+// session cookies. Call it from a POST route:
+//
+//	// app/api/auth/logout/route.go
+//	func POST(k *kit.Kit) error {
+//	    if err := k.Logout(); err != nil {
+//	        return err
+//	    }
+//	    return k.Redirect("/")
+//	}
+//
+// So that other sites can't sign users out, only POST and DELETE requests
+// from this site are accepted, judged by their Sec-Fetch-Site or Origin
+// header; others fail with errors.ErrAuthCrossSite. Without UseLogout it
+// fails with errors.ErrAuthDefault rather than leave the user's tokens
+// valid.
+func (k *Kit) Logout() error {
+	if !k.sameSite() {
+		return errors.ErrAuthCrossSite
+	}
+	if logout == nil {
+		return errors.ErrAuthDefault.WithValue("no logout; call kit.UseLogout(auth.Logout)")
+	}
+	if err := logout(k); err != nil {
+		return err
+	}
+	k.ClearAuthCookies()
+	k.ClearSessionCookie()
+	return nil
+}
+
+// sameSite reports whether a request that changes state came from this
+// site. Clients sending neither Sec-Fetch-Site nor Origin aren't browsers
+// acting for another site.
+func (k *Kit) sameSite() bool {
+	if k.Request.Method != http.MethodPost && k.Request.Method != http.MethodDelete {
+		return false
+	}
+	if site := k.GetHeader("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none"
+	}
+	if origin := k.GetHeader("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		return err == nil && u.Host == k.Request.Host
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package kit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// TestSetAuthCookies tests setting both token cookies, and reading the
+// access token back with Authorization
+func TestSetAuthCookies(t *testing.T) {
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest(http.MethodPost, "https://example.com/login", nil)}
+
+	k.SetAuthCookies("access", "refresh")
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2)
+	byName := map[string]*http.Cookie{}
+	for _, c := range cookies {
+		byName[c.Name] = c
+		assert.True(t, c.HttpOnly)
+		assert.True(t, c.Secure, "the request is HTTPS")
+		assert.Equal(t, http.SameSiteStrictMode, c.SameSite)
+	}
+	assert.Equal(t, "access", byName[TokenCookie].Value)
+	assert.Equal(t, int(config.DefaultAccessTTL/time.Second), byName[TokenCookie].MaxAge)
+	assert.Equal(t, "refresh", byName[RefreshTokenCookie].Value)
+	assert.Equal(t, int(config.DefaultRefreshTTL/time.Second), byName[RefreshTokenCookie].MaxAge)
+
+	next := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		next.AddCookie(c)
+	}
+	token, err := (&Kit{Request: next}).Authorization()
+	require.NoError(t, err)
+	assert.Equal(t, "access", token)
+}
+
+// TestClearAuthCookies tests expiring both token cookies
+func TestClearAuthCookies(t *testing.T) {
+	setEnv(t, config.EnvDevelopment)
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest(http.MethodPost, "/logout", nil)}
+
+	k.ClearAuthCookies()
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2)
+	for _, c := range cookies {
+		assert.Empty(t, c.Value)
+		assert.Equal(t, -1, c.MaxAge)
+		assert.False(t, c.Secure)
+	}
+}
+
+// TestSessionCookie tests setting and expiring the session cookie
+func TestSessionCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest(http.MethodPost, "/login", nil)}
+
+	k.SetSessionCookie("id", time.Hour)
+	k.ClearSessionCookie()
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2)
+	assert.Equal(t, SessionCookie, cookies[0].Name)
+	assert.Equal(t, "id", cookies[0].Value)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Empty(t, cookies[1].Value)
+	assert.Equal(t, -1, cookies[1].MaxAge)
+}
+
+// TestLogout tests signing out only on requests from this site
+func TestLogout(t *testing.T) {
+	original := logout
+	t.Cleanup(func() { UseLogout(original) })
+	calls := 0
+	UseLogout(func(*Kit) error {
+		calls++
+		return nil
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		allowed bool
+	}{
+		{"same-origin fetch", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-origin"}, true},
+		{"same origin", http.MethodPost, map[string]string{"Origin": "http://example.com"}, true},
+		{"non-browser client", http.MethodDelete, nil, true},
+		{"cross-site fetch", http.MethodPost, map[string]string{"Sec-Fetch-Site": "cross-site"}, false},
+		{"same-site subdomain", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-site"}, false},
+		{"other origin", http.MethodPost, map[string]string{"Origin": "https://evil.example.net"}, false},
+		{"GET", http.MethodGet, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "http://example.com/api/auth/logout", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+
+			err := (&Kit{Response: w, Request: r}).Logout()
+			if !tt.allowed {
+				assert.ErrorIs(t, err, errors.ErrAuthCrossSite)
+				assert.Zero(t, calls)
+				assert.Empty(t, w.Result().Cookies())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, calls)
+			assert.Len(t, w.Result().Cookies(), 3, "the token and session cookies are cleared")
+		})
+	}
+}
+
+// TestLogout_NoLogout tests refusing to sign out without UseLogout, which
+// would leave the user's tokens valid
+func TestLogout_NoLogout(t *testing.T) {
+	original := logout
+	t.Cleanup(func() { UseLogout(original) })
+	UseLogout(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/api/auth/logout", nil)
+	r.Header.Set("Sec-Fetch-Site", "same-origin")
+	err := (&Kit{Response: w, Request: r}).Logout()
+	assert.ErrorIs(t, err, errors.ErrAuthDefault)
+	assert.Empty(t, w.Result().Cookies())
+}
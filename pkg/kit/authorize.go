@@ -0,0 +1,149 @@
+package kit
+
+import (
+	"sync"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// Authorizer decides what the signed-in user may do, for Can, HasRole and
+// middleware.RequireRole and RequirePermission. Importing pkg/auth/rbac
+// installs one backed by the database.
+type Authorizer interface {
+	// HasRole reports whether the request's user has role
+	HasRole(k *Kit, role string) (bool, error)
+	// Can reports whether the request's user has permission
+	Can(k *Kit, permission string) (bool, error)
+}
+
+var (
+	authorizerMu sync.RWMutex
+	authorizer   Authorizer
+)
+
+// UseAuthorizer makes a the Authorizer requests are checked with
+func UseAuthorizer(a Authorizer) {
+	authorizerMu.Lock()
+	defer authorizerMu.Unlock()
+	authorizer = a
+}
+
+// DefaultAuthorizer returns the Authorizer set with UseAuthorizer, or nil
+func DefaultAuthorizer() Authorizer {
+	authorizerMu.RLock()
+	defer authorizerMu.RUnlock()
+	return authorizer
+}
+
+// CheckRole reports whether the request's user has role. Without an
+// Authorizer nobody has any role.
+func (k *Kit) CheckRole(role string) (bool, error) {
+	a := DefaultAuthorizer()
+	if a == nil {
+		return false, nil
+	}
+	return a.HasRole(k, role)
+}
+
+// CheckPermission reports whether the request's user has permission.
+// Without an Authorizer nobody has any permission.
+func (k *Kit) CheckPermission(permission string) (bool, error) {
+	a := DefaultAuthorizer()
+	if a == nil {
+		return false, nil
+	}
+	return a.Can(k, permission)
+}
+
+// HasRole is CheckRole for templates and conditions, logging errors and
+// treating them as not having the role
+func (k *Kit) HasRole(role string) bool {
+	ok, err := k.CheckRole(role)
+	if err != nil {
+		k.Logger().Error("Checking role %s: %v", role, err)
+	}
+	return ok && err == nil
+}
+
+// Can is CheckPermission for templates and conditions, logging errors and
+// treating them as not having the permission. Pass it to a template to
+// show only what the user may use:
+//
+//	return k.Render("orders", map[string]any{"Orders": orders, "Can": k.Can})
+//
+//	{{if call .Can "orders:write"}}<button>Edit</button>{{end}}
+func (k *Kit) Can(permission string) bool {
+	ok, err := k.CheckPermission(permission)
+	if err != nil {
+		k.Logger().Error("Checking permission %s: %v", permission, err)
+	}
+	return ok && err == nil
+}
+
+// PolicyChecker decides whether the signed-in user may take an action on
+// a record, for Authorize and Allowed. Importing pkg/policy installs one
+// checking the policies registered there.
+type PolicyChecker interface {
+	// Allowed reports whether the request's user may take action on obj
+	Allowed(k *Kit, action string, obj any) (bool, error)
+}
+
+var (
+	policyCheckerMu sync.RWMutex
+	policyChecker   PolicyChecker
+)
+
+// UsePolicyChecker makes c the PolicyChecker actions are checked with
+func UsePolicyChecker(c PolicyChecker) {
+	policyCheckerMu.Lock()
+	defer policyCheckerMu.Unlock()
+	policyChecker = c
+}
+
+// DefaultPolicyChecker returns the PolicyChecker set with
+// UsePolicyChecker, or nil
+func DefaultPolicyChecker() PolicyChecker {
+	policyCheckerMu.RLock()
+	defer policyCheckerMu.RUnlock()
+	return policyChecker
+}
+
+// CheckAllowed reports whether the request's user may take action on obj.
+// Without a PolicyChecker nobody may take any action.
+func (k *Kit) CheckAllowed(action string, obj any) (bool, error) {
+	c := DefaultPolicyChecker()
+	if c == nil {
+		return false, nil
+	}
+	return c.Allowed(k, action, obj)
+}
+
+// Authorize returns ErrInsufficientPermissions unless the request's user
+// may take action on obj, so handlers can return its error:
+//
+//	if err := k.Authorize(policy.Edit, post); err != nil {
+//	    return err
+//	}
+func (k *Kit) Authorize(action string, obj any) error {
+	ok, err := k.CheckAllowed(action, obj)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.ErrInsufficientPermissions.WithValue(action)
+	}
+	return nil
+}
+
+// Allowed is CheckAllowed for templates and conditions, logging errors and
+// treating them as not allowed. Templates get it as .Allowed, to show only
+// the actions the user may take:
+//
+//	{{if call .Allowed "edit" .Post}}<a href="/posts/{{.Post.ID}}/edit">Edit</a>{{end}}
+func (k *Kit) Allowed(action string, obj any) bool {
+	ok, err := k.CheckAllowed(action, obj)
+	if err != nil {
+		k.Logger().Error("Checking %s on %T: %v", action, obj, err)
+	}
+	return ok && err == nil
+}
@@ -0,0 +1,102 @@
+package kit
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// authorizerFunc adapts a function to Authorizer, passing roles as
+// "role:<name>"
+type authorizerFunc func(name string) (bool, error)
+
+func (f authorizerFunc) HasRole(_ *Kit, role string) (bool, error) {
+	return f("role:" + role)
+}
+
+func (f authorizerFunc) Can(_ *Kit, permission string) (bool, error) {
+	return f(permission)
+}
+
+// TestAuthorizer tests checking roles and permissions with the
+// installed Authorizer
+func TestAuthorizer(t *testing.T) {
+	original := DefaultAuthorizer()
+	defer UseAuthorizer(original)
+
+	k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+
+	t.Run("denies everything without an authorizer", func(t *testing.T) {
+		UseAuthorizer(nil)
+		ok, err := k.CheckPermission("orders:write")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.False(t, k.HasRole("admin"))
+	})
+
+	t.Run("asks the authorizer", func(t *testing.T) {
+		UseAuthorizer(authorizerFunc(func(name string) (bool, error) {
+			return name == "orders:write" || name == "role:admin", nil
+		}))
+		assert.True(t, k.Can("orders:write"))
+		assert.False(t, k.Can("orders:delete"))
+		assert.True(t, k.HasRole("admin"))
+		assert.False(t, k.HasRole("owner"))
+	})
+
+	t.Run("treats errors as denied", func(t *testing.T) {
+		UseAuthorizer(authorizerFunc(func(string) (bool, error) {
+			return true, fmt.Errorf("database down")
+		}))
+		_, err := k.CheckRole("admin")
+		assert.Error(t, err)
+		assert.False(t, k.Can("orders:write"))
+		assert.False(t, k.HasRole("admin"))
+	})
+}
+
+// policyCheckerFunc adapts a function to PolicyChecker
+type policyCheckerFunc func(action string, obj any) (bool, error)
+
+func (f policyCheckerFunc) Allowed(_ *Kit, action string, obj any) (bool, error) {
+	return f(action, obj)
+}
+
+// TestPolicyChecker tests authorizing actions with the installed
+// PolicyChecker
+func TestPolicyChecker(t *testing.T) {
+	original := DefaultPolicyChecker()
+	defer UsePolicyChecker(original)
+
+	k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+
+	t.Run("denies everything without a checker", func(t *testing.T) {
+		UsePolicyChecker(nil)
+		assert.ErrorIs(t, k.Authorize("view", "post"), errors.ErrInsufficientPermissions)
+		assert.False(t, k.Allowed("view", "post"))
+	})
+
+	t.Run("asks the checker", func(t *testing.T) {
+		UsePolicyChecker(policyCheckerFunc(func(action string, obj any) (bool, error) {
+			return action == "view" && obj == "post", nil
+		}))
+		assert.NoError(t, k.Authorize("view", "post"))
+		assert.ErrorIs(t, k.Authorize("edit", "post"), errors.ErrInsufficientPermissions)
+		assert.True(t, k.Allowed("view", "post"))
+		assert.True(t, k.viewData(nil).(map[string]any)["Allowed"].(func(string, any) bool)("view", "post"),
+			"templates get Allowed")
+	})
+
+	t.Run("returns the checker's errors", func(t *testing.T) {
+		UsePolicyChecker(policyCheckerFunc(func(string, any) (bool, error) {
+			return true, fmt.Errorf("no policy")
+		}))
+		assert.EqualError(t, k.Authorize("view", "post"), "no policy")
+		assert.False(t, k.Allowed("view", "post"))
+	})
+}
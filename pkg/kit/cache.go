@@ -0,0 +1,17 @@
+package kit
+
+import (
+	"github.com/cstone-io/twine/pkg/cache"
+)
+
+// Cache returns the application cache bound to the request's context, for
+// memoizing expensive queries behind a page:
+//
+//	posts, err := cache.In(k.Cache(), "posts:recent", time.Minute, func() ([]Post, error) {
+//	    return store.List(k.Request.Context(), database.ListOptions{PerPage: 10})
+//	}, "posts")
+//
+// Handlers that change the records call k.Cache().Invalidate("posts").
+func (k *Kit) Cache() *cache.Cache {
+	return cache.NewCache(k.Request.Context(), nil)
+}
@@ -0,0 +1,38 @@
+package kit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/cache"
+)
+
+type requestKey struct{}
+
+// TestKit_Cache tests that the request's cache uses the application store
+// and the request's context
+func TestKit_Cache(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+	cache.Use(store)
+	t.Cleanup(cache.Reset)
+
+	req := httptest.NewRequest("GET", "/posts", nil)
+	req = req.WithContext(context.WithValue(req.Context(), requestKey{}, "posts"))
+	k := &Kit{Response: httptest.NewRecorder(), Request: req}
+
+	assert.Equal(t, store, k.Cache().Store())
+	assert.Equal(t, "posts", k.Cache().Context().Value(requestKey{}))
+
+	count, err := cache.In(k.Cache(), "count", time.Minute, func() (int, error) { return 3, nil }, "posts")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 1, store.Len())
+
+	require.NoError(t, k.Cache().Invalidate("posts"))
+	assert.Equal(t, 0, store.Len())
+}
@@ -0,0 +1,40 @@
+package kit
+
+import (
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// DB returns the GORM client of the database returned by database.Get,
+// bound to the request's context, so its queries stop when the request's
+// time budget runs out or the client goes away:
+//
+//	var posts []Post
+//	err := k.DB().Where("published = ?", true).Find(&posts).Error
+func (k *Kit) DB() *gorm.DB {
+	return database.GORM().WithContext(k.Request.Context())
+}
+
+// DatabaseHealthz is a readiness handler for the database returned by
+// database.Get. It pings the database and responds 200, or 503 if the ping
+// fails, with the pool statistics:
+//
+//	r.Get("/healthz", kit.DatabaseHealthz)
+func DatabaseHealthz(k *Kit) error {
+	d := database.Get()
+	if d == nil {
+		return k.JSON(http.StatusServiceUnavailable, database.Health{Status: "unavailable"})
+	}
+
+	health, err := d.Health(k.Request.Context())
+	if err != nil {
+		logger.Get().CustomError(errors.ErrDatabaseConn.Wrap(err))
+		return k.JSON(http.StatusServiceUnavailable, health)
+	}
+	return k.JSON(http.StatusOK, health)
+}
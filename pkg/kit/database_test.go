@@ -0,0 +1,66 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/database"
+)
+
+// useTestDB makes an in-memory database the one returned by database.Get
+func useTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, database.Use(db))
+	t.Cleanup(database.Reset)
+	return db
+}
+
+// TestKit_DB tests binding queries to the request's context
+func TestKit_DB(t *testing.T) {
+	useTestDB(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	k := &Kit{Request: httptest.NewRequest("GET", "/", nil).WithContext(ctx)}
+	assert.Same(t, ctx, k.DB().Statement.Context)
+	require.NoError(t, k.DB().Exec("SELECT 1").Error)
+
+	cancel()
+	assert.ErrorIs(t, k.DB().Exec("SELECT 1").Error, context.Canceled, "queries stop with the request")
+}
+
+// TestDatabaseHealthz tests the readiness handler
+func TestDatabaseHealthz(t *testing.T) {
+	db := useTestDB(t)
+
+	rec := httptest.NewRecorder()
+	k := &Kit{Response: rec, Request: httptest.NewRequest(http.MethodGet, "/healthz", nil)}
+	require.NoError(t, DatabaseHealthz(k))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var health database.Health
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+	assert.Equal(t, "ok", health.Status)
+	assert.Positive(t, health.Pool.Open)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	rec = httptest.NewRecorder()
+	k = &Kit{Response: rec, Request: httptest.NewRequest(http.MethodGet, "/healthz", nil)}
+	require.NoError(t, DatabaseHealthz(k))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"unavailable"`)
+}
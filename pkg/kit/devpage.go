@@ -0,0 +1,257 @@
+package kit
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	pkgtemplate "github.com/cstone-io/twine/pkg/template"
+)
+
+// devSourceContext is how many lines around each stack frame the
+// developer error page shows
+const devSourceContext = 5
+
+// frameworkPrefix is the package path of the framework's own frames, which
+// the developer error page looks past for the application's code
+const frameworkPrefix = "github.com/cstone-io/twine/pkg/"
+
+// sensitiveFields are headers and form fields whose values the developer
+// error page hides
+var sensitiveFields = regexp.MustCompile(`(?i)authorization|cookie|token|secret|password|api[-_]?key|csrf`)
+
+// devErrorPage shows an error in the browser in development
+var devErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Public}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2937; }
+h1 { font-size: 1.25rem; color: #b91c1c; }
+h2 { font-size: 1rem; margin-top: 2rem; }
+pre { background: #f3f4f6; padding: 1rem; overflow-x: auto; line-height: 1.5; margin: 0.25rem 0 1rem; }
+table { border-collapse: collapse; font-size: 0.875rem; }
+td { padding: 0.25rem 1rem 0.25rem 0; vertical-align: top; font-family: ui-monospace, monospace; }
+td:first-child { color: #6b7280; }
+.chain { font-family: ui-monospace, monospace; }
+.frame { font-family: ui-monospace, monospace; font-size: 0.875rem; }
+.app { font-weight: bold; }
+.line { display: block; }
+.number { display: inline-block; width: 3rem; color: #9ca3af; user-select: none; }
+.error { background: #fee2e2; }
+</style>
+</head>
+<body>
+<h1>{{.Status}} {{.Public}}</h1>
+{{- if .Handler}}
+<p>In {{.Handler.Function}} at {{.Handler.File}}:{{.Handler.Line}}{{if .Route}}, serving {{.Route}}{{end}}</p>
+{{- else if .Route}}
+<p>Serving {{.Route}}</p>
+{{- end}}
+
+<h2>Error chain</h2>
+{{- range .Chain}}
+<div class="chain">{{.}}</div>
+{{- end}}
+
+{{- if .Frames}}
+<h2>Stack trace</h2>
+{{- range .Frames}}
+<div class="frame{{if .App}} app{{end}}">{{.Function}}<br>{{.File}}:{{.Line}}</div>
+{{- if .Source}}
+<pre>{{$line := .Line}}{{range .Source}}<span class="line{{if eq .Number $line}} error{{end}}"><span class="number">{{.Number}}</span>{{.Text}}</span>{{end}}</pre>
+{{- end}}
+{{- end}}
+{{- end}}
+
+<h2>Request</h2>
+<table>
+<tr><td>{{.Method}}</td><td>{{.URL}}</td></tr>
+{{- if .RequestID}}
+<tr><td>Request ID</td><td>{{.RequestID}}</td></tr>
+{{- end}}
+{{- if .User}}
+<tr><td>User</td><td>{{.User}}</td></tr>
+{{- end}}
+</table>
+{{- range .Sections}}
+{{- if .Values}}
+<h2>{{.Name}}</h2>
+<table>
+{{- range .Values}}
+<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+<p>This page is shown in development only. Set TWINE_ENV=production to see what clients get.</p>
+</body>
+</html>
+`))
+
+// devPage is the data of devErrorPage
+type devPage struct {
+	Status    int
+	Public    string
+	Chain     []string
+	Frames    []devFrame
+	Handler   *devFrame
+	Route     string
+	Method    string
+	URL       string
+	RequestID string
+	User      string
+	Sections  []devSection
+}
+
+// devFrame is a stack frame with the source around it
+type devFrame struct {
+	Function string
+	File     string
+	Line     int
+	App      bool
+	Source   []pkgtemplate.SnippetLine
+}
+
+// devSection is a titled list of request values
+type devSection struct {
+	Name   string
+	Values []devValue
+}
+
+// devValue is a named request value
+type devValue struct {
+	Name  string
+	Value string
+}
+
+// renderDevErrorPage responds with the developer error page for e, and
+// reports whether it did: in development, to requests that accept HTML
+func (k *Kit) renderDevErrorPage(e *errors.Error) bool {
+	if !config.Get().IsDev() || !strings.Contains(k.Request.Header.Get("Accept"), "text/html") {
+		return false
+	}
+
+	status := errors.HTTPStatusOf(e)
+	page := k.newDevPage(e, status)
+	k.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	k.Response.Header().Set("Cache-Control", "no-store")
+	k.Response.WriteHeader(status)
+	if err := devErrorPage.Execute(k.Response, page); err != nil {
+		fmt.Fprintf(k.Response, "<pre>%s</pre>", template.HTMLEscapeString(e.ErrorChain()))
+	}
+	return true
+}
+
+// newDevPage describes e and the request for the developer error page
+func (k *Kit) newDevPage(e *errors.Error, status int) devPage {
+	requestID := k.RequestID()
+	r := k.Request
+	page := devPage{
+		Status:    status,
+		Public:    e.Public(),
+		Chain:     strings.Split(strings.TrimSpace(e.ErrorChain()), "\n"),
+		Route:     r.Pattern,
+		Method:    r.Method,
+		URL:       r.URL.RequestURI(),
+		RequestID: requestID,
+		User:      k.GetContext("user"),
+	}
+
+	for _, frame := range e.StackTrace() {
+		page.Frames = append(page.Frames, newDevFrame(frame))
+	}
+	for i := range page.Frames {
+		if page.Frames[i].App {
+			page.Handler = &page.Frames[i]
+			break
+		}
+	}
+
+	page.Sections = []devSection{
+		{Name: "Path parameters", Values: pathValues(r)},
+		{Name: "Query", Values: devValues(r.URL.Query())},
+		{Name: "Form", Values: devValues(r.PostForm)},
+		{Name: "Headers", Values: devValues(r.Header)},
+		{Name: "Cookies", Values: cookieValues(r)},
+	}
+	return page
+}
+
+// newDevFrame reads the source around frame
+func newDevFrame(frame runtime.Frame) devFrame {
+	return devFrame{
+		Function: frame.Function,
+		File:     frame.File,
+		Line:     frame.Line,
+		App:      !strings.HasPrefix(frame.Function, frameworkPrefix) || strings.HasSuffix(frame.File, "_test.go"),
+		Source:   readSource(frame.File, frame.Line),
+	}
+}
+
+// readSource returns the lines of file around line, or nil if it can't be
+// read, as in a binary built elsewhere
+func readSource(file string, line int) []pkgtemplate.SnippetLine {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []pkgtemplate.SnippetLine
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan() && n <= line+devSourceContext; n++ {
+		if n >= line-devSourceContext {
+			lines = append(lines, pkgtemplate.SnippetLine{Number: n, Text: scanner.Text()})
+		}
+	}
+	return lines
+}
+
+// pathWildcards finds the wildcards of a route pattern, such as {id} and
+// {path...}
+var pathWildcards = regexp.MustCompile(`\{([^}.]+)(?:\.\.\.)?\}`)
+
+// pathValues returns the path parameters of the matched route
+func pathValues(r *http.Request) []devValue {
+	var values []devValue
+	for _, m := range pathWildcards.FindAllStringSubmatch(r.Pattern, -1) {
+		values = append(values, devValue{Name: m[1], Value: r.PathValue(m[1])})
+	}
+	return values
+}
+
+// devValues lists values by name, hiding sensitive ones
+func devValues(values map[string][]string) []devValue {
+	list := make([]devValue, 0, len(values))
+	for name, vs := range values {
+		value := strings.Join(vs, ", ")
+		if sensitiveFields.MatchString(name) {
+			value = config.Redacted
+		}
+		list = append(list, devValue{Name: name, Value: value})
+	}
+	slices.SortFunc(list, func(a, b devValue) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+// cookieValues lists the request's cookies by name, hiding their values,
+// which hold sessions and tokens
+func cookieValues(r *http.Request) []devValue {
+	var values []devValue
+	for _, cookie := range r.Cookies() {
+		values = append(values, devValue{Name: cookie.Name, Value: config.Redacted})
+	}
+	return values
+}
@@ -0,0 +1,71 @@
+package kit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
+)
+
+// TestDevErrorPage tests the error page browsers get in development
+func TestDevErrorPage(t *testing.T) {
+	setEnv(t, config.EnvDevelopment)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /posts/{id}", Handler(func(k *Kit) error {
+		k.Request.ParseForm()
+		k.SetContext("user", "42")
+		return twineerrors.ErrDatabaseWrite.Wrap(twineerrors.ErrDefaultError.Wrap(assertError("disk full")))
+	}))
+	serve := func(accept string) *httptest.ResponseRecorder {
+		form := url.Values{"title": {"Hello"}, "password": {"hunter2"}}
+		r := httptest.NewRequest("POST", "/posts/7?draft=1", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Accept", accept)
+		r.Header.Set("Authorization", "Bearer abc.def")
+		r.AddCookie(&http.Cookie{Name: SessionCookie, Value: "session-secret"})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("text/html,application/xhtml+xml")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+
+	assert.Contains(t, body, "500 Internal server error")
+	assert.Contains(t, body, "serving POST /posts/{id}", "the route")
+	assert.Contains(t, body, "devpage_test.go:", "the handler's file")
+	assert.Contains(t, body, "disk full", "the error chain")
+	assert.Contains(t, body, "return twineerrors.ErrDatabaseWrite.Wrap", "the source of the frame")
+	assert.Contains(t, body, "<td>id</td><td>7</td>", "path parameters")
+	assert.Contains(t, body, "<td>draft</td><td>1</td>", "query")
+	assert.Contains(t, body, "<td>title</td><td>Hello</td>", "form")
+	assert.Contains(t, body, "<td>User</td><td>42</td>")
+	for _, name := range []string{"password", "Authorization", "Cookie", "session"} {
+		assert.Contains(t, body, "<td>"+name+"</td><td>"+config.Redacted+"</td>")
+	}
+
+	w = serve("application/json")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"), "API clients get JSON")
+
+	setEnv(t, config.EnvProduction)
+	w = serve("text/html")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), "disk full")
+}
+
+// assertError is an error with a fixed message
+type assertError string
+
+func (e assertError) Error() string {
+	return string(e)
+}
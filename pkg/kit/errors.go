@@ -2,7 +2,11 @@ package kit
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cstone-io/twine/pkg/config"
 	"github.com/cstone-io/twine/pkg/errors"
 	"github.com/cstone-io/twine/pkg/logger"
 )
@@ -16,31 +20,64 @@ func UseErrorHandler(h ErrorHandlerFunc) {
 }
 
 var (
+	// errorHandler logs the error chain and responds with the public
+	// message and code, so causes never reach the client outside
+	// development. In development, browsers get the developer error page.
 	errorHandler = func(kit *Kit, err error) {
-		if e, ok := err.(*errors.Error); ok {
+		if e, ok := errors.As(err); ok {
 			logger.Get().CustomError(e)
+			if kit.renderDevErrorPage(e) {
+				return
+			}
 			// If user has set up templates, they can render an error page
 			// For now, return JSON error
-			status := e.HTTPStatus
-			if status == 0 {
-				status = http.StatusInternalServerError
-			}
-			kit.JSON(status, map[string]any{
-				"error":  e.Message,
+			kit.JSON(errors.HTTPStatusOf(e), withDetails(map[string]any{
+				"error":  e.Public(),
 				"code":   e.Code,
 				"status": e.HTTPStatus,
-			})
+			}, e))
 		} else {
 			e := errors.ErrDefaultError.Wrap(err)
 			logger.Get().CustomError(e)
-			kit.JSON(http.StatusInternalServerError, map[string]any{
-				"error": e.Message,
+			if kit.renderDevErrorPage(e) {
+				return
+			}
+			kit.JSON(http.StatusInternalServerError, withDetails(map[string]any{
+				"error": e.Public(),
 				"code":  e.Code,
-			})
+			}, e))
 		}
 	}
 )
 
+// withDetails adds the error chain and stack trace of e to an error
+// response in development
+func withDetails(body map[string]any, e *errors.Error) map[string]any {
+	if !config.Get().IsDev() {
+		return body
+	}
+	body["detail"] = strings.TrimSpace(e.ErrorChain())
+	if frames := e.StackTrace(); len(frames) > 0 {
+		stack := make([]string, 0, len(frames))
+		for _, frame := range frames {
+			stack = append(stack, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+		}
+		body["stack"] = stack
+	}
+	return body
+}
+
+// setRetryAfter sends the Retry-After header for errors with a RetryAfter,
+// in whole seconds rounded up, before the error handler responds
+func (k *Kit) setRetryAfter(err error) {
+	e, ok := errors.As(err)
+	if !ok || e.RetryAfter <= 0 {
+		return
+	}
+	seconds := (e.RetryAfter + time.Second - 1) / time.Second
+	k.Response.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+}
+
 // NotFoundHandler returns a handler for 404 errors
 func NotFoundHandler() http.HandlerFunc {
 	return Handler(func(kit *Kit) error {
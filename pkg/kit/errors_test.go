@@ -1,17 +1,30 @@
 package kit
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cstone-io/twine/pkg/config"
 	twineerrors "github.com/cstone-io/twine/pkg/errors"
 )
 
+// setEnv sets TWINE_ENV's setting for a test
+func setEnv(t *testing.T, env string) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Env
+	t.Cleanup(func() { cfg.Env = original })
+	cfg.Env = env
+}
+
 // TestUseErrorHandler tests custom error handler registration
 func TestUseErrorHandler(t *testing.T) {
 	// Save original error handler
@@ -96,6 +109,7 @@ func TestDefaultErrorHandler(t *testing.T) {
 
 	// Reset to default
 	errorHandler = originalHandler
+	setEnv(t, config.EnvProduction)
 
 	t.Run("handles Twine Error with correct status", func(t *testing.T) {
 		customErr := &twineerrors.Error{
@@ -141,6 +155,41 @@ func TestDefaultErrorHandler(t *testing.T) {
 		assert.Contains(t, w.Body.String(), `"error":"Custom error"`)
 	})
 
+	t.Run("finds Twine Error wrapped with fmt.Errorf", func(t *testing.T) {
+		h := Handler(func(k *Kit) error {
+			return fmt.Errorf("loading user 42: %w", twineerrors.ErrNotFound)
+		})
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, 404, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"Not found"`)
+		assert.NotContains(t, w.Body.String(), "user 42")
+	})
+
+	t.Run("sends Retry-After for errors with a retry delay", func(t *testing.T) {
+		tests := []struct {
+			err      error
+			expected string
+		}{
+			{twineerrors.ErrAPIRateLimited, "60"},
+			{twineerrors.ErrAPIRateLimited.WithRetryAfter(1500 * time.Millisecond), "2"},
+			{fmt.Errorf("connecting: %w", twineerrors.ErrDatabaseConn.Wrap(errors.New("refused"))), "5"},
+			{twineerrors.ErrNotFound, ""},
+		}
+		for _, tt := range tests {
+			h := Handler(func(k *Kit) error {
+				return tt.err
+			})
+
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, tt.expected, w.Header().Get("Retry-After"), tt.err.Error())
+		}
+	})
+
 	t.Run("handles standard Go error", func(t *testing.T) {
 		h := Handler(func(k *Kit) error {
 			return errors.New("standard error")
@@ -156,6 +205,33 @@ func TestDefaultErrorHandler(t *testing.T) {
 		assert.Contains(t, w.Body.String(), `"code"`)
 	})
 
+	t.Run("shows the public message, not the cause", func(t *testing.T) {
+		h := Handler(func(k *Kit) error {
+			return twineerrors.ErrDatabaseRead.Wrap(errors.New(`pq: relation "users" does not exist`))
+		})
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, 500, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"Internal server error"`)
+		assert.Contains(t, w.Body.String(), `"code":2101`)
+		assert.NotContains(t, w.Body.String(), "Failed to read")
+		assert.NotContains(t, w.Body.String(), "relation")
+	})
+
+	t.Run("shows a public message set on the error", func(t *testing.T) {
+		h := Handler(func(k *Kit) error {
+			return twineerrors.ErrAPIPost.Wrap(errors.New("upstream timeout")).WithPublicMessage("Try again later")
+		})
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Contains(t, w.Body.String(), `"error":"Try again later"`)
+		assert.NotContains(t, w.Body.String(), "upstream")
+	})
+
 	t.Run("returns JSON error response", func(t *testing.T) {
 		h := Handler(func(k *Kit) error {
 			return twineerrors.ErrNotFound
@@ -172,6 +248,29 @@ func TestDefaultErrorHandler(t *testing.T) {
 	})
 }
 
+// TestDefaultErrorHandler_Development tests adding the cause and stack
+// trace to error responses in development
+func TestDefaultErrorHandler_Development(t *testing.T) {
+	setEnv(t, config.EnvDevelopment)
+
+	h := Handler(func(k *Kit) error {
+		return twineerrors.ErrDatabaseRead.Wrap(errors.New(`pq: relation "users" does not exist`))
+	})
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/", nil))
+
+	var body struct {
+		Error  string   `json:"error"`
+		Detail string   `json:"detail"`
+		Stack  []string `json:"stack"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Internal server error", body.Error)
+	assert.Contains(t, body.Detail, `relation "users" does not exist`)
+	require.NotEmpty(t, body.Stack)
+	assert.Contains(t, body.Stack[0], "errors_test.go:")
+}
+
 // TestNotFoundHandler tests 404 handler
 func TestNotFoundHandler(t *testing.T) {
 	t.Run("returns 404 error", func(t *testing.T) {
@@ -0,0 +1,77 @@
+package kit
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+// FormErrors are validation messages by form field name, the name in the
+// field's form tag. The message for "" concerns the whole form.
+type FormErrors map[string]string
+
+// Add records message for field, keeping the first message a field gets
+func (e FormErrors) Add(field, message string) {
+	if _, ok := e[field]; !ok {
+		e[field] = message
+	}
+}
+
+// Error lists the messages by field, so FormErrors can be returned as an
+// error
+func (e FormErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = e[field]
+		if field != "" {
+			messages[i] = field + " " + e[field]
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Form builds a form for model, decoded with k.Decode, showing errs next to
+// its fields. It posts back to the request's path with the ViewContext's
+// CSRF token. Pass it to a template to render with the formFields and
+// formField functions:
+//
+//	return k.Render("posts/new", map[string]any{"Form": k.Form(&post, errs)})
+func (k *Kit) Form(model any, errs FormErrors) *template.Form {
+	return &template.Form{
+		Model:     model,
+		Errors:    errs,
+		Action:    k.Request.URL.Path,
+		CSRFToken: k.View().CSRFToken,
+	}
+}
+
+// RenderForm writes the form k.Form builds for model as an HTML fragment,
+// such as the response to an Ajax submit. With errs it's a 422, so a form
+// that failed validation comes back holding what was entered:
+//
+//	var post Post
+//	if err := k.Decode(&post); err != nil {
+//	    return err
+//	}
+//	if errs := post.Validate(); len(errs) > 0 {
+//	    return k.RenderForm(&post, errs)
+//	}
+func (k *Kit) RenderForm(model any, errs FormErrors) error {
+	html, err := k.Form(model, errs).HTML()
+	if err != nil {
+		return err
+	}
+	status := http.StatusOK
+	if len(errs) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	return k.HTML(status, string(html))
+}
@@ -0,0 +1,84 @@
+package kit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formPost struct {
+	Title string `form:"title"`
+	Draft bool   `form:"draft"`
+}
+
+// Validate checks a decoded post
+func (p formPost) Validate() FormErrors {
+	errs := FormErrors{}
+	if p.Title == "" {
+		errs.Add("title", "is required")
+	}
+	return errs
+}
+
+// TestFormErrors tests collecting validation messages
+func TestFormErrors(t *testing.T) {
+	errs := FormErrors{}
+	errs.Add("title", "is required")
+	errs.Add("title", "is too short")
+	errs.Add("", "Could not save")
+	errs.Add("body", "is required")
+
+	assert.Equal(t, "is required", errs["title"], "the first message is kept")
+	assert.Equal(t, "Could not save; body is required; title is required", errs.Error())
+}
+
+// TestKit_RenderForm tests re-rendering forms that failed validation
+func TestKit_RenderForm(t *testing.T) {
+	newKit := func(body string) (*Kit, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest("POST", "/posts?draft=1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		k := &Kit{Response: w, Request: req}
+		k.View().CSRFToken = "token"
+		return k, w
+	}
+
+	t.Run("repopulates the form with errors", func(t *testing.T) {
+		k, w := newKit("title=&draft=true")
+		var post formPost
+		require.NoError(t, k.Decode(&post))
+
+		require.NoError(t, k.RenderForm(&post, post.Validate()))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		assert.Contains(t, body, `<form method="post" action="/posts" class="form">`)
+		assert.Contains(t, body, `<input type="hidden" name="csrf_token" value="token">`)
+		assert.Contains(t, body, `<p class="form-error" id="title-error">is required</p>`)
+		assert.Contains(t, body, `name="draft" value="true" checked>`)
+	})
+
+	t.Run("renders valid forms as 200", func(t *testing.T) {
+		k, w := newKit("title=Hello")
+		var post formPost
+		require.NoError(t, k.Decode(&post))
+
+		require.NoError(t, k.RenderForm(&post, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `value="Hello"`)
+		assert.NotContains(t, w.Body.String(), "form-error")
+	})
+
+	t.Run("builds forms for templates", func(t *testing.T) {
+		k, _ := newKit("")
+		form := k.Form(&formPost{}, FormErrors{"title": "is required"})
+		assert.Equal(t, "/posts", form.Action)
+		assert.Equal(t, "token", form.CSRFToken)
+		assert.Equal(t, map[string]string{"title": "is required"}, form.Errors)
+	})
+}
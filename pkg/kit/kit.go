@@ -1,31 +1,72 @@
 package kit
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+
+	"github.com/cstone-io/twine/pkg/errors"
 )
 
 // Kit wraps http.ResponseWriter and *http.Request for convenient access
 type Kit struct {
 	Response http.ResponseWriter
 	Request  *http.Request
+
+	// client is the context of the request as the server received it,
+	// which only the client closing cancels. Middleware replaces
+	// Request's context with ones it cancels itself, as TimeoutMiddleware
+	// does once the handler returns.
+	client context.Context
 }
 
 // HandlerFunc is the signature for Twine handlers that return errors
 type HandlerFunc func(kit *Kit) error
 
-// Handler converts a Kit.HandlerFunc to an http.HandlerFunc
+// Handler converts a Kit.HandlerFunc to an http.HandlerFunc. A panic in h
+// is recovered and handled as an errors.ErrPanic error. Errors of requests
+// the client closed are logged at debug level, as nobody is left to respond
+// to.
 func Handler(h HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		kit := &Kit{
 			Response: w,
 			Request:  r,
+			client:   r.Context(),
 		}
-		if err := h(kit); err != nil {
+		if err := run(h, kit); err != nil {
+			if kit.Aborted() {
+				kit.Logger().Debug("Request aborted by the client: %v", err)
+				return
+			}
+			kit.report(err)
+			kit.setRetryAfter(err)
 			if errorHandler != nil {
 				errorHandler(kit, err)
 				return
 			}
-			kit.Text(http.StatusInternalServerError, err.Error())
+			kit.Text(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 		}
 	}
 }
+
+// run calls h, returning a panic as ErrPanic with the stack where it
+// happened. http.ErrAbortHandler is re-panicked for net/http to abort the
+// response.
+func run(h HandlerFunc, k *Kit) (err error) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		cause, ok := p.(error)
+		if !ok {
+			cause = fmt.Errorf("%v", p)
+		}
+		err = errors.ErrPanic.Wrap(cause)
+	}()
+	return h(k)
+}
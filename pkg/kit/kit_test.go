@@ -2,11 +2,14 @@ package kit
 
 import (
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
 )
 
 // TestHandler_Conversion tests HandlerFunc to http.HandlerFunc conversion
@@ -121,6 +124,37 @@ func TestHandler_ErrorHandling(t *testing.T) {
 		assert.Equal(t, 500, w.Code)
 		assert.NotEmpty(t, w.Body.String())
 	})
+
+	t.Run("panic is handled as an error", func(t *testing.T) {
+		var handled error
+		originalHandler := errorHandler
+		t.Cleanup(func() { errorHandler = originalHandler })
+		UseErrorHandler(func(k *Kit, err error) {
+			handled = err
+			originalHandler(k, err)
+		})
+
+		h := Handler(func(k *Kit) error {
+			var m map[string]int
+			m["count"]++
+			return nil
+		})
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, 500, w.Code)
+		assert.ErrorIs(t, handled, twineerrors.ErrPanic)
+		assert.Contains(t, handled.Error(), "assignment to entry in nil map")
+	})
+
+	t.Run("aborted handler panics through", func(t *testing.T) {
+		h := Handler(func(k *Kit) error {
+			panic(http.ErrAbortHandler)
+		})
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	})
 }
 
 // TestHandler_Integration tests realistic handler scenarios
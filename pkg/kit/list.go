@@ -0,0 +1,65 @@
+package kit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// ListOptions reads the list options from the query string:
+//
+//	/posts?page=2&per_page=50&sort=-created_at&filter[status]=published
+//
+// per_page defaults to database.DefaultPerPage and is at most
+// database.MaxPerPage. Invalid numbers return ErrAPIQueryParam.
+func (k *Kit) ListOptions() (database.ListOptions, error) {
+	query := k.Request.URL.Query()
+	opts := database.ListOptions{Page: 1, PerPage: database.DefaultPerPage, Sort: query.Get("sort")}
+
+	for _, p := range []struct {
+		name  string
+		value *int
+		max   int
+	}{
+		{"page", &opts.Page, 0},
+		{"per_page", &opts.PerPage, database.MaxPerPage},
+	} {
+		raw := query.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || (p.max > 0 && n > p.max) {
+			return database.ListOptions{}, errors.ErrAPIQueryParam.WithValue(fmt.Sprintf("%s=%s", p.name, raw))
+		}
+		*p.value = n
+	}
+
+	for key, values := range query {
+		column, ok := strings.CutPrefix(key, "filter[")
+		if !ok || !strings.HasSuffix(column, "]") {
+			continue
+		}
+		if opts.Filters == nil {
+			opts.Filters = map[string]string{}
+		}
+		opts.Filters[strings.TrimSuffix(column, "]")] = values[0]
+	}
+
+	return opts, nil
+}
+
+// SearchOptions reads the search terms from ?q= and the list options as
+// ListOptions does:
+//
+//	/posts?q=go+generics&page=2
+func (k *Kit) SearchOptions() (database.SearchOptions, error) {
+	opts, err := k.ListOptions()
+	if err != nil {
+		return database.SearchOptions{}, err
+	}
+	return database.SearchOptions{ListOptions: opts, Query: strings.TrimSpace(k.Request.URL.Query().Get("q"))}, nil
+}
@@ -0,0 +1,51 @@
+package kit
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// TestKit_ListOptions tests reading list options from the query string
+func TestKit_ListOptions(t *testing.T) {
+	kitFor := func(target string) *Kit {
+		return &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", target, nil)}
+	}
+
+	opts, err := kitFor("/posts").ListOptions()
+	require.NoError(t, err)
+	assert.Equal(t, database.ListOptions{Page: 1, PerPage: database.DefaultPerPage}, opts)
+
+	opts, err = kitFor("/posts?page=3&per_page=50&sort=-created_at,title&filter[status]=published&filter[author_id]=7&q=x").ListOptions()
+	require.NoError(t, err)
+	assert.Equal(t, database.ListOptions{
+		Page:    3,
+		PerPage: 50,
+		Sort:    "-created_at,title",
+		Filters: map[string]string{"status": "published", "author_id": "7"},
+	}, opts)
+
+	for _, query := range []string{"page=0", "page=two", "per_page=101", "per_page=-1"} {
+		_, err := kitFor("/posts?" + query).ListOptions()
+		assert.ErrorIs(t, err, errors.ErrAPIQueryParam, query)
+	}
+}
+
+// TestKit_SearchOptions tests reading search terms with the list options
+func TestKit_SearchOptions(t *testing.T) {
+	kitFor := func(target string) *Kit {
+		return &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", target, nil)}
+	}
+
+	opts, err := kitFor("/posts?q=+go+generics+&page=2").SearchOptions()
+	require.NoError(t, err)
+	assert.Equal(t, database.SearchOptions{ListOptions: database.ListOptions{Page: 2, PerPage: database.DefaultPerPage}, Query: "go generics"}, opts)
+
+	_, err = kitFor("/posts?q=go&page=0").SearchOptions()
+	assert.ErrorIs(t, err, errors.ErrAPIQueryParam)
+}
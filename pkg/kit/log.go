@@ -0,0 +1,66 @@
+package kit
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// RequestIDHeader carries the request ID, from a proxy or client, and back in
+// the response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from RequestIDHeader
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID returns the ID correlating the request's log lines. On the first
+// call it takes the ID from the X-Request-ID header, or generates one, and
+// sets it on the response.
+func (k *Kit) RequestID() string {
+	if id, ok := k.Request.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+
+	id := k.GetHeader(RequestIDHeader)
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+	k.Request = k.Request.WithContext(context.WithValue(k.Request.Context(), requestIDKey{}, id))
+	k.Response.Header().Set(RequestIDHeader, id)
+	return id
+}
+
+// validRequestID reports whether an ID from a header is safe to log
+func validRequestID(id string) bool {
+	return id != "" && len(id) <= maxRequestIDLength && !strings.ContainsFunc(id, func(r rune) bool {
+		return r > unicode.MaxASCII || !unicode.IsGraphic(r) || unicode.IsSpace(r)
+	})
+}
+
+// Logger returns a logger whose entries carry the request ID, method, path,
+// route pattern and, once JWTMiddleware has run, the user ID
+func (k *Kit) Logger() *logger.Logger {
+	return k.logger(logger.Get())
+}
+
+// logger adds the request's fields to base
+func (k *Kit) logger(base *logger.Logger) *logger.Logger {
+	fields := []any{
+		"request_id", k.RequestID(),
+		"method", k.Request.Method,
+		"path", k.Request.URL.Path,
+	}
+	if k.Request.Pattern != "" {
+		fields = append(fields, "route", k.Request.Pattern)
+	}
+	if user := k.GetContext("user"); user != "" {
+		fields = append(fields, "user_id", user)
+	}
+	return base.With(fields...)
+}
@@ -0,0 +1,69 @@
+package kit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// TestKit_RequestID tests taking or generating the request ID
+func TestKit_RequestID(t *testing.T) {
+	t.Run("generates an ID once", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		k := &Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+
+		id := k.RequestID()
+		assert.Len(t, id, 36)
+		assert.Equal(t, id, k.RequestID())
+		assert.Equal(t, id, w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("uses the header", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(RequestIDHeader, "edge-1234")
+		k := &Kit{Response: httptest.NewRecorder(), Request: r}
+
+		assert.Equal(t, "edge-1234", k.RequestID())
+	})
+
+	t.Run("replaces unsafe header values", func(t *testing.T) {
+		for _, id := range []string{"has space", "line\nbreak", strings.Repeat("a", 129), "ünïcode"} {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set(RequestIDHeader, id)
+			k := &Kit{Response: httptest.NewRecorder(), Request: r}
+
+			assert.NotEqual(t, id, k.RequestID())
+		}
+	})
+}
+
+// TestKit_Logger tests the request fields on the request logger
+func TestKit_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	base := logger.New(config.LoggerConfig{Level: config.LogInfo, Output: &buf, ErrorOutput: &buf})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", Handler(func(k *Kit) error {
+		k.logger(base).Info("before auth")
+		k.SetContext("user", "u-7")
+		k.logger(base).Info("after auth")
+		return nil
+	}))
+
+	r := httptest.NewRequest("GET", "/users/42", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], `before auth request_id=req-1 method=GET path=/users/42 route="GET /users/{id}"`), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], `after auth request_id=req-1 method=GET path=/users/42 route="GET /users/{id}" user_id=u-7`), lines[1])
+}
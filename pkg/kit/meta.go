@@ -0,0 +1,118 @@
+package kit
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// Meta describes a page's head tags: its title and description, and the
+// OpenGraph and Twitter card tags link previews use. Fields left empty
+// come from the meta settings of twine.yaml. Layouts render it as .Meta:
+//
+//	<title>{{.Meta.Title}}</title>
+//	{{.Meta.Tags}}
+type Meta struct {
+	Title       string
+	Description string
+	// Canonical is the page's preferred URL, also og:url
+	Canonical string
+	// OGImage is the image link previews show, as an absolute URL
+	OGImage string
+	// OGType is og:type, "website" when empty
+	OGType string
+	// TwitterCard is twitter:card, "summary_large_image" for pages with an
+	// OGImage and "summary" otherwise when empty
+	TwitterCard string
+	// Robots is the robots meta tag, such as "noindex"
+	Robots string
+	// SiteName is og:site_name
+	SiteName string
+}
+
+// metaTags renders Meta.Tags
+var metaTags = template.Must(template.New("meta").Parse(`
+{{- with .Description}}<meta name="description" content="{{.}}">
+{{end}}
+{{- with .Robots}}<meta name="robots" content="{{.}}">
+{{end}}
+{{- with .Canonical}}<link rel="canonical" href="{{.}}">
+<meta property="og:url" content="{{.}}">
+{{end}}
+{{- with .SiteName}}<meta property="og:site_name" content="{{.}}">
+{{end}}
+{{- with .Title}}<meta property="og:title" content="{{.}}">
+{{end}}
+{{- with .Description}}<meta property="og:description" content="{{.}}">
+{{end}}
+{{- with .OGImage}}<meta property="og:image" content="{{.}}">
+{{end}}
+{{- if or .Title .OGImage}}<meta property="og:type" content="{{.OGType}}">
+<meta name="twitter:card" content="{{.TwitterCard}}">
+{{end}}`))
+
+// Tags renders the description, robots, canonical link, OpenGraph and
+// Twitter card tags of the fields set. The <title> is left to the layout.
+func (m Meta) Tags() template.HTML {
+	if m.OGType == "" {
+		m.OGType = "website"
+	}
+	if m.TwitterCard == "" {
+		m.TwitterCard = "summary"
+		if m.OGImage != "" {
+			m.TwitterCard = "summary_large_image"
+		}
+	}
+
+	var b strings.Builder
+	if err := metaTags.Execute(&b, m); err != nil {
+		return ""
+	}
+	return template.HTML(b.String())
+}
+
+// withDefaults fills the fields of m left empty from d
+func (m Meta) withDefaults(d Meta) Meta {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&m.Title, d.Title)
+	fill(&m.Description, d.Description)
+	fill(&m.Canonical, d.Canonical)
+	fill(&m.OGImage, d.OGImage)
+	fill(&m.OGType, d.OGType)
+	fill(&m.TwitterCard, d.TwitterCard)
+	fill(&m.Robots, d.Robots)
+	fill(&m.SiteName, d.SiteName)
+	return m
+}
+
+// configMeta returns the meta settings of twine.yaml
+func configMeta() Meta {
+	cfg := config.Get().Meta
+	return Meta{
+		Title:       cfg.Title,
+		Description: cfg.Description,
+		OGImage:     cfg.OGImage,
+		TwitterCard: cfg.TwitterCard,
+		SiteName:    cfg.SiteName,
+	}
+}
+
+// SetMeta sets the page's head tags, rendered by the layout as .Meta.
+// Fields left empty keep what middleware set before, or else the meta
+// settings of twine.yaml:
+//
+//	k.SetMeta(kit.Meta{
+//	    Title:       post.Title,
+//	    Description: post.Summary,
+//	    OGImage:     post.CoverURL,
+//	    Canonical:   "https://example.com/posts/" + post.Slug,
+//	})
+func (k *Kit) SetMeta(meta Meta) {
+	view := k.View()
+	view.Meta = meta.withDefaults(view.Meta)
+}
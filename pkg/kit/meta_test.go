@@ -0,0 +1,86 @@
+package kit
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// setMetaConfig sets the meta settings of twine.yaml for a test
+func setMetaConfig(t *testing.T, meta config.MetaConfig) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Meta
+	t.Cleanup(func() { cfg.Meta = original })
+	cfg.Meta = meta
+}
+
+// TestKit_SetMeta tests setting head tags over middleware's and the
+// configured defaults
+func TestKit_SetMeta(t *testing.T) {
+	setMetaConfig(t, config.MetaConfig{SiteName: "Acme", Title: "Acme", Description: "Tools for everyone"})
+	k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+
+	k.SetMeta(Meta{Robots: "noindex"})
+	k.SetMeta(Meta{Title: "Anvils", OGImage: "https://acme.test/anvil.png"})
+
+	t.Run("merges into map data", func(t *testing.T) {
+		meta := k.viewData(nil).(map[string]any)["Meta"].(Meta)
+		assert.Equal(t, Meta{
+			Title:       "Anvils",
+			Description: "Tools for everyone",
+			OGImage:     "https://acme.test/anvil.png",
+			Robots:      "noindex",
+			SiteName:    "Acme",
+		}, meta)
+	})
+
+	t.Run("fills structs embedding ViewContext", func(t *testing.T) {
+		type page struct{ ViewContext }
+		data := k.viewData(page{ViewContext{Meta: Meta{Title: "Hammers"}}}).(page)
+		assert.Equal(t, "Hammers", data.Meta.Title)
+		assert.Equal(t, "noindex", data.Meta.Robots)
+		assert.Equal(t, "Acme", data.Meta.SiteName)
+	})
+}
+
+// TestMeta_Tags tests rendering head tags
+func TestMeta_Tags(t *testing.T) {
+	t.Run("renders the fields set", func(t *testing.T) {
+		meta := Meta{
+			Title:       `Anvils & "Hammers"`,
+			Description: "Heavy",
+			Canonical:   "https://acme.test/anvils",
+			OGImage:     "https://acme.test/anvil.png",
+			SiteName:    "Acme",
+		}
+		assert.Equal(t, `<meta name="description" content="Heavy">
+<link rel="canonical" href="https://acme.test/anvils">
+<meta property="og:url" content="https://acme.test/anvils">
+<meta property="og:site_name" content="Acme">
+<meta property="og:title" content="Anvils &amp; &#34;Hammers&#34;">
+<meta property="og:description" content="Heavy">
+<meta property="og:image" content="https://acme.test/anvil.png">
+<meta property="og:type" content="website">
+<meta name="twitter:card" content="summary_large_image">
+`, string(meta.Tags()))
+	})
+
+	t.Run("renders nothing for empty meta", func(t *testing.T) {
+		assert.Empty(t, Meta{}.Tags())
+	})
+
+	t.Run("keeps the type and card set", func(t *testing.T) {
+		tags := string(Meta{Title: "Post", OGType: "article", Robots: "noindex"}.Tags())
+		assert.Contains(t, tags, `<meta name="robots" content="noindex">`)
+		assert.Contains(t, tags, `<meta property="og:type" content="article">`)
+		assert.Contains(t, tags, `<meta name="twitter:card" content="summary">`)
+	})
+
+	t.Run("filters unsafe link URLs", func(t *testing.T) {
+		assert.Contains(t, string(Meta{Canonical: "javascript:alert(1)"}.Tags()), `<link rel="canonical" href="#ZgotmplZ">`)
+	})
+}
@@ -0,0 +1,57 @@
+package kit
+
+import (
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/realtime"
+)
+
+// SSESubscribe streams the events published to topics with
+// realtime.Publish to the client, until it disconnects or the server shuts
+// down:
+//
+//	r.Get("/orders/{id}/events", func(k *kit.Kit) error {
+//	    return k.SSESubscribe("orders:" + k.PathValue("id"))
+//	})
+//
+// In the browser, new EventSource("/orders/42/events") receives them. An
+// idle stream sends a comment every REALTIME_HEARTBEAT, keeping proxies
+// from closing it.
+func (k *Kit) SSESubscribe(topics ...string) error {
+	// Subscribe first, so nothing published while the stream opens is lost
+	sub := realtime.Default().Subscribe(topics...)
+	defer sub.Close()
+
+	stream, err := k.SSE()
+	if err != nil {
+		return err
+	}
+
+	heartbeat := config.Get().Realtime.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = config.DefaultRealtimeHeartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.Request.Context().Done():
+			return nil
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return nil
+			}
+			// A failed write means the client went away
+			if err := stream.Send(SSEEvent{Event: msg.Event, Data: msg.Data}); err != nil {
+				return nil
+			}
+			ticker.Reset(heartbeat)
+		case <-ticker.C:
+			if err := stream.Comment(""); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package kit
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/realtime"
+)
+
+// TestSSESubscribe tests streaming published events to a client until it
+// disconnects
+func TestSSESubscribe(t *testing.T) {
+	hub := realtime.NewHub(nil)
+	realtime.Use(hub)
+	t.Cleanup(func() {
+		hub.Close()
+		realtime.Reset()
+	})
+
+	server := httptest.NewServer(Handler(func(k *Kit) error {
+		return k.SSESubscribe("orders:42")
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	require.Eventually(t, func() bool { return hub.Subscribers("orders:42") == 1 }, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, hub.Publish("orders:43", "other order"))
+	require.NoError(t, hub.PublishEvent("orders:42", "status", map[string]string{"status": "shipped"}))
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	assert.Equal(t, []string{"event: status", `data: {"status":"shipped"}`, ""}, lines)
+
+	cancel()
+	assert.Eventually(t, func() bool { return hub.Subscribers("orders:42") == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+// TestSSESubscribe_HubClosed tests ending the stream when the hub closes,
+// as it does on shutdown
+func TestSSESubscribe_HubClosed(t *testing.T) {
+	hub := realtime.NewHub(nil)
+	realtime.Use(hub)
+	t.Cleanup(realtime.Reset)
+
+	done := make(chan error)
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest(http.MethodGet, "/events", nil)}
+	go func() { done <- k.SSESubscribe("orders:42") }()
+
+	require.Eventually(t, func() bool { return hub.Subscribers("orders:42") == 1 }, 2*time.Second, 10*time.Millisecond)
+	hub.Close()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream didn't end")
+	}
+}
@@ -0,0 +1,52 @@
+package kit
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/cache"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+// RenderCached renders the full page name and caches its HTML in k.Cache()
+// for ttl, for expensive pages that rarely change. data is only called to
+// build the page on a miss. The entry's key is "page:<name>:" followed by
+// keyFn(k), or the request URI when keyFn is nil; tags group it for
+// k.Cache().Invalidate:
+//
+//	return k.RenderCached("blog/index", 5*time.Minute, nil, func() (any, error) {
+//	    return loadPosts(k.Request.Context())
+//	}, "posts")
+//
+// The page is cached with the ViewContext merged in, so a page showing the
+// current user, flashes or a CSRF token must vary its key by them, or not
+// be cached.
+func (k *Kit) RenderCached(name string, ttl time.Duration, keyFn func(k *Kit) string, data func() (any, error), tags ...string) error {
+	if k.Aborted() {
+		return errors.ErrAborted
+	}
+	key := k.Request.URL.RequestURI()
+	if keyFn != nil {
+		key = keyFn(k)
+	}
+
+	html, err := cache.In(k.Cache(), "page:"+name+":"+key, ttl, func() (string, error) {
+		d, err := data()
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := template.RenderFull(&buf, name, k.viewData(d)); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}, tags...)
+	if err != nil {
+		return k.templateError(err)
+	}
+
+	template.Record(k.Request.Context(), name)
+	return k.HTML(http.StatusOK, html)
+}
@@ -0,0 +1,125 @@
+package kit
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/cache"
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+// TestKit_RenderCached tests caching rendered pages
+func TestKit_RenderCached(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+	cache.Use(store)
+	t.Cleanup(cache.Reset)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "posts.html"), []byte(`{{define "posts"}}{{.AppName}}: {{.Count}} posts{{end}}`), 0644))
+	require.NoError(t, template.LoadTemplates(filepath.Join(dir, "posts.html")))
+	t.Cleanup(func() { template.SetTemplates(nil) })
+
+	calls := 0
+	data := func() (any, error) {
+		calls++
+		return map[string]any{"Count": calls}, nil
+	}
+	render := func(path string, keyFn func(*Kit) string) (*httptest.ResponseRecorder, error) {
+		w := httptest.NewRecorder()
+		k := &Kit{Response: w, Request: httptest.NewRequest("GET", path, nil)}
+		k.View().AppName = "Blog"
+		return w, k.RenderCached("posts", time.Minute, keyFn, data, "posts")
+	}
+
+	w, err := render("/posts?page=1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Blog: 1 posts", w.Body.String())
+
+	w, err = render("/posts?page=1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Blog: 1 posts", w.Body.String(), "served from the cache")
+	assert.Equal(t, 1, calls)
+
+	w, err = render("/posts?page=2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Blog: 2 posts", w.Body.String(), "keyed by the request URI")
+
+	byPath := func(k *Kit) string { return k.Request.URL.Path }
+	_, err = render("/posts?page=3", byPath)
+	require.NoError(t, err)
+	w, err = render("/posts?page=4", byPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Blog: 3 posts", w.Body.String(), "keyed by keyFn")
+
+	require.NoError(t, (&Kit{Request: httptest.NewRequest("GET", "/", nil)}).Cache().Invalidate("posts"))
+	w, err = render("/posts?page=1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Blog: 4 posts", w.Body.String(), "invalidated by tag")
+
+	t.Run("returns and doesn't cache data errors", func(t *testing.T) {
+		failure := errors.New("database down")
+		k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/failing", nil)}
+		err := k.RenderCached("posts", time.Minute, nil, func() (any, error) { return nil, failure })
+		assert.ErrorIs(t, err, failure)
+
+		_, err = render("/failing", nil)
+		require.NoError(t, err)
+	})
+}
+
+// BenchmarkKit_Render measures rendering a page for each request
+func BenchmarkKit_Render(b *testing.B) {
+	loadBenchmarkPage(b)
+	r := httptest.NewRequest("GET", "/", nil)
+	data := map[string]any{"Items": []string{"a", "b", "c", "d", "e"}}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		k := &Kit{Response: httptest.NewRecorder(), Request: r}
+		if err := k.Render("page", data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkKit_RenderCached measures serving a cached page
+func BenchmarkKit_RenderCached(b *testing.B) {
+	cache.Use(cache.NewMemoryStore(10))
+	b.Cleanup(cache.Reset)
+	loadBenchmarkPage(b)
+	r := httptest.NewRequest("GET", "/", nil)
+	data := func() (any, error) { return map[string]any{"Items": []string{"a", "b", "c", "d", "e"}}, nil }
+
+	b.ReportAllocs()
+	for b.Loop() {
+		k := &Kit{Response: httptest.NewRecorder(), Request: r}
+		if err := k.RenderCached("page", time.Minute, nil, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// loadBenchmarkPage loads a page with a layout for the benchmarks
+func loadBenchmarkPage(b *testing.B) {
+	b.Helper()
+	dir := b.TempDir()
+	page := `{{define "base"}}<html><head><title>{{.AppName}}</title></head><body>{{template "content" .}}</body></html>{{end}}
+{{define "page"}}{{template "base" .}}{{end}}
+{{define "content"}}<ul>{{range .Items}}<li class="item">{{.}}</li>{{end}}</ul>{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(page), 0644); err != nil {
+		b.Fatal(err)
+	}
+	if err := template.LoadTemplates(filepath.Join(dir, "page.html")); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { template.SetTemplates(nil) })
+}
@@ -0,0 +1,160 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// ErrorReport describes an error for an ErrorReporter. The request fields
+// are empty for errors reported outside a handler.
+type ErrorReport struct {
+	Error     *errors.Error
+	Stack     []runtime.Frame
+	Time      time.Time
+	RequestID string
+	Method    string
+	Path      string
+	Route     string
+	UserID    string
+}
+
+// ErrorReporter sends errors to a tracking service such as Sentry or
+// Rollbar. Report is called on the goroutine that failed, so slow reporters
+// should send in the background.
+type ErrorReporter interface {
+	Report(ctx context.Context, report ErrorReport)
+}
+
+type kitKey struct{}
+
+// UseErrorReporter sends r every ErrError and ErrCritical error returned by
+// a handler, except those with a 4xx status, or passed to errors.Report,
+// with the request and stack trace. It returns a function that stops
+// reporting to r.
+func UseErrorReporter(r ErrorReporter) (remove func()) {
+	return errors.OnError(func(e *errors.Error, ctx context.Context) {
+		r.Report(ctx, newErrorReport(ctx, e))
+	})
+}
+
+// newErrorReport describes e, with the request of the Kit in ctx if any
+func newErrorReport(ctx context.Context, e *errors.Error) ErrorReport {
+	report := ErrorReport{Error: e, Stack: e.StackTrace(), Time: time.Now()}
+	if k, ok := ctx.Value(kitKey{}).(*Kit); ok {
+		report.RequestID = k.RequestID()
+		report.Method = k.Request.Method
+		report.Path = k.Request.URL.Path
+		report.Route = k.Request.Pattern
+		report.UserID = k.GetContext("user")
+	}
+	return report
+}
+
+// report passes a handler's error to errors.Report with the Kit in the
+// context. Errors with a 4xx status, such as ErrNotFound, are the client's
+// and aren't reported.
+func (k *Kit) report(err error) {
+	e, ok := errors.As(err)
+	if !ok {
+		e = errors.ErrDefaultError.Wrap(err)
+	}
+	if e.HTTPStatus >= 400 && e.HTTPStatus < 500 {
+		return
+	}
+	errors.Report(context.WithValue(k.Request.Context(), kitKey{}, k), e)
+}
+
+// NopReporter discards reports. It suits development and tests that need
+// an ErrorReporter.
+type NopReporter struct{}
+
+// Report does nothing
+func (NopReporter) Report(context.Context, ErrorReport) {}
+
+// WebhookReporter posts each report as JSON to a URL, e.g. a Slack workflow
+// or an error collector, in the background
+type WebhookReporter struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookReporter creates a WebhookReporter that posts to url, giving up
+// on a request after 10 seconds
+func NewWebhookReporter(url string) *WebhookReporter {
+	return &WebhookReporter{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// webhookPayload is the JSON body WebhookReporter posts
+type webhookPayload struct {
+	Code      int          `json:"code"`
+	Message   string       `json:"message"`
+	Error     string       `json:"error"`
+	Severity  string       `json:"severity"`
+	Time      time.Time    `json:"time"`
+	RequestID string       `json:"request_id,omitempty"`
+	Method    string       `json:"method,omitempty"`
+	Path      string       `json:"path,omitempty"`
+	Route     string       `json:"route,omitempty"`
+	UserID    string       `json:"user_id,omitempty"`
+	Stack     []stackFrame `json:"stack,omitempty"`
+}
+
+type stackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Report posts report in a new goroutine, logging failures
+func (w *WebhookReporter) Report(_ context.Context, report ErrorReport) {
+	payload := webhookPayload{
+		Code:      report.Error.Code,
+		Message:   report.Error.Message,
+		Error:     report.Error.ErrorChain(),
+		Severity:  "error",
+		Time:      report.Time,
+		RequestID: report.RequestID,
+		Method:    report.Method,
+		Path:      report.Path,
+		Route:     report.Route,
+		UserID:    report.UserID,
+	}
+	if report.Error.Severity == errors.ErrCritical {
+		payload.Severity = "critical"
+	}
+	for _, frame := range report.Stack {
+		payload.Stack = append(payload.Stack, stackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.Get().Error("Error reporter: encoding report: %v", err)
+		return
+	}
+	go w.post(body)
+}
+
+// post sends body to the webhook
+func (w *WebhookReporter) post(body []byte) {
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Get().Error("Error reporter: posting report: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Get().Error("Error reporter: webhook responded %s", resp.Status)
+	}
+}
@@ -0,0 +1,126 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// reporterFunc adapts a function to ErrorReporter
+type reporterFunc func(ctx context.Context, report ErrorReport)
+
+func (f reporterFunc) Report(ctx context.Context, report ErrorReport) {
+	f(ctx, report)
+}
+
+// TestUseErrorReporter tests reporting the errors handlers return
+func TestUseErrorReporter(t *testing.T) {
+	originalHandler := errorHandler
+	defer func() {
+		errorHandler = originalHandler
+	}()
+	UseErrorHandler(func(k *Kit, err error) {
+		k.Text(http.StatusInternalServerError, "failed")
+	})
+
+	var reports []ErrorReport
+	remove := UseErrorReporter(reporterFunc(func(_ context.Context, report ErrorReport) {
+		reports = append(reports, report)
+	}))
+	defer remove()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", Handler(func(k *Kit) error {
+		k.SetContext("user", "u-7")
+		return errors.ErrDatabaseRead.Wrap(fmt.Errorf("connection reset"))
+	}))
+	mux.HandleFunc("GET /plain", Handler(func(k *Kit) error {
+		return fmt.Errorf("boom")
+	}))
+	mux.HandleFunc("GET /missing", Handler(func(k *Kit) error {
+		return errors.ErrNotFound
+	}))
+	mux.HandleFunc("GET /minor", Handler(func(k *Kit) error {
+		return errors.ErrDecodeForm
+	}))
+
+	r := httptest.NewRequest("GET", "/orders/42", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	for _, path := range []string{"/plain", "/missing", "/minor"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	require.Len(t, reports, 2, "4xx and minor errors aren't reported")
+	report := reports[0]
+	assert.Equal(t, errors.ErrDatabaseRead.Code, report.Error.Code)
+	assert.Equal(t, "req-1", report.RequestID)
+	assert.Equal(t, "GET", report.Method)
+	assert.Equal(t, "/orders/42", report.Path)
+	assert.Equal(t, "GET /orders/{id}", report.Route)
+	assert.Equal(t, "u-7", report.UserID)
+	require.NotEmpty(t, report.Stack)
+	assert.Contains(t, report.Stack[0].Function, "TestUseErrorReporter")
+
+	assert.Equal(t, errors.ErrDefaultError.Code, reports[1].Error.Code)
+	assert.Equal(t, "/plain", reports[1].Path)
+
+	t.Run("outside a handler", func(t *testing.T) {
+		reports = nil
+		errors.Report(context.Background(), errors.ErrDatabaseConn)
+		require.Len(t, reports, 1)
+		assert.Empty(t, reports[0].RequestID)
+		assert.NotEmpty(t, reports[0].Stack)
+	})
+}
+
+// TestWebhookReporter tests posting reports as JSON
+func TestWebhookReporter(t *testing.T) {
+	received := make(chan webhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var payload webhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	e := errors.ErrDatabaseConn.Wrap(fmt.Errorf("refused"))
+	NewWebhookReporter(server.URL).Report(context.Background(), ErrorReport{
+		Error:     e,
+		Stack:     e.StackTrace(),
+		Time:      time.Now(),
+		RequestID: "req-1",
+		Path:      "/orders",
+	})
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, errors.ErrDatabaseConn.Code, payload.Code)
+		assert.Equal(t, "critical", payload.Severity)
+		assert.Contains(t, payload.Error, "refused")
+		assert.Equal(t, "req-1", payload.RequestID)
+		assert.Equal(t, "/orders", payload.Path)
+		require.NotEmpty(t, payload.Stack)
+		assert.Contains(t, payload.Stack[0].Function, "TestWebhookReporter")
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+}
+
+// TestNopReporter tests that NopReporter is an ErrorReporter
+func TestNopReporter(t *testing.T) {
+	var reporter ErrorReporter = NopReporter{}
+	assert.NotPanics(t, func() {
+		reporter.Report(context.Background(), ErrorReport{Error: errors.ErrDatabaseConn})
+	})
+}
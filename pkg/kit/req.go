@@ -5,21 +5,26 @@ import (
 	"encoding/json"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cstone-io/twine/pkg/errors"
 )
 
-// Decode decodes the request body into v based on Content-Type
+// Decode decodes the request body into v based on Content-Type. It returns
+// errors.ErrAborted if the client closed the request.
 func (k *Kit) Decode(v any) error {
+	if k.Aborted() {
+		return errors.ErrAborted
+	}
 	contentType := k.GetHeader("Content-Type")
 
 	switch {
 	case contentType == "application/json":
-		return k.decodeJSON(v)
+		return k.abortedError(k.decodeJSON(v))
 	case contentType == "application/x-www-form-urlencoded":
-		return k.decodeForm(v)
+		return k.abortedError(k.decodeForm(v))
 	default:
 		return errors.ErrAPIRequestContentType
 	}
@@ -45,23 +50,43 @@ func (k *Kit) decodeForm(v any) error {
 		if tag != "" && field.CanSet() {
 			formValue := k.Request.FormValue(tag)
 
+			if field.Type() == reflect.TypeOf(time.Time{}) {
+				if formValue != "" {
+					parsed, err := parseFormTime(formValue)
+					if err != nil {
+						return errors.ErrDecodeForm.Wrap(err).WithValue(tag)
+					}
+					field.Set(reflect.ValueOf(parsed))
+				}
+				continue
+			}
+
 			switch field.Kind() {
 			case reflect.String:
 				field.SetString(formValue)
 			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 				if formValue != "" {
-					value := reflect.ValueOf(formValue)
-					field.SetInt(value.Int())
+					parsed, err := strconv.ParseInt(formValue, 10, field.Type().Bits())
+					if err != nil {
+						return errors.ErrDecodeForm.Wrap(err).WithValue(tag)
+					}
+					field.SetInt(parsed)
 				}
 			case reflect.Float32, reflect.Float64:
 				if formValue != "" {
-					value := reflect.ValueOf(formValue)
-					field.SetFloat(value.Float())
+					parsed, err := strconv.ParseFloat(formValue, field.Type().Bits())
+					if err != nil {
+						return errors.ErrDecodeForm.Wrap(err).WithValue(tag)
+					}
+					field.SetFloat(parsed)
 				}
 			case reflect.Bool:
 				if formValue != "" {
-					value := reflect.ValueOf(formValue)
-					field.SetBool(value.Bool())
+					parsed, err := strconv.ParseBool(formValue)
+					if err != nil {
+						return errors.ErrDecodeForm.Wrap(err).WithValue(tag)
+					}
+					field.SetBool(parsed)
 				}
 			case reflect.Struct:
 				nestedPtr := reflect.New(field.Type())
@@ -85,6 +110,21 @@ func (k *Kit) decodeForm(v any) error {
 	return nil
 }
 
+// formTimeLayouts are the layouts accepted for time.Time form fields, covering
+// RFC 3339 and the values sent by datetime-local and date inputs
+var formTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+func parseFormTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range formTimeLayouts {
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, err
+}
+
 // PathValue extracts a path parameter by key
 func (k *Kit) PathValue(key string) string {
 	return k.Request.PathValue(key)
@@ -92,7 +132,7 @@ func (k *Kit) PathValue(key string) string {
 
 // Authorization extracts the authorization token from cookie or header
 func (k *Kit) Authorization() (string, error) {
-	cookie, err := k.GetCookie("token")
+	cookie, err := k.GetCookie(TokenCookie)
 	if err == nil && cookie != "" {
 		return cookie, nil
 	}
@@ -115,7 +155,8 @@ func (k *Kit) GetHeader(key string) string {
 	return k.Request.Header.Get(key)
 }
 
-// SetContext sets a context value on the request
+// SetContext sets a context value on the request. Data for templates
+// belongs in View.
 func (k *Kit) SetContext(key, value string) {
 	k.Request = k.Request.WithContext(context.WithValue(k.Request.Context(), key, value))
 }
@@ -129,7 +170,8 @@ func (k *Kit) GetContext(key string) string {
 	return val.(string)
 }
 
-// SetCookie sets an HTTP cookie
+// SetCookie sets an HTTP-only cookie for 12 hours, marked Secure as
+// SecureCookies reports
 func (k *Kit) SetCookie(key, value string) {
 	http.SetCookie(k.Response, &http.Cookie{
 		Name:     key,
@@ -137,7 +179,7 @@ func (k *Kit) SetCookie(key, value string) {
 		Path:     "/",
 		Expires:  time.Now().Add(12 * time.Hour),
 		SameSite: http.SameSiteStrictMode,
-		Secure:   false, // TODO: configure for dev and production
+		Secure:   k.SecureCookies(),
 		HttpOnly: true,
 	})
 }
@@ -9,10 +9,12 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cstone-io/twine/pkg/config"
 	twineerrors "github.com/cstone-io/twine/pkg/errors"
 )
 
@@ -129,6 +131,53 @@ func TestKit_Decode(t *testing.T) {
 		assert.Equal(t, "", result.Optional)
 	})
 
+	t.Run("decodes form with numeric, bool and time fields", func(t *testing.T) {
+		type Form struct {
+			Count     int       `form:"count"`
+			Price     float64   `form:"price"`
+			Published bool      `form:"published"`
+			PostedAt  time.Time `form:"posted_at"`
+		}
+
+		form := url.Values{}
+		form.Add("count", "42")
+		form.Add("price", "9.5")
+		form.Add("published", "true")
+		form.Add("posted_at", "2024-03-01T09:30")
+
+		r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		k := &Kit{Request: r}
+
+		var result Form
+		err := k.Decode(&result)
+		require.NoError(t, err)
+		assert.Equal(t, 42, result.Count)
+		assert.Equal(t, 9.5, result.Price)
+		assert.True(t, result.Published)
+		assert.Equal(t, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC), result.PostedAt)
+	})
+
+	t.Run("returns error for invalid numeric form value", func(t *testing.T) {
+		type Form struct {
+			Count int `form:"count"`
+		}
+
+		form := url.Values{}
+		form.Add("count", "many")
+
+		r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		k := &Kit{Request: r}
+
+		var result Form
+		err := k.Decode(&result)
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, twineerrors.ErrDecodeForm))
+	})
+
 	t.Run("ignores fields without form tags", func(t *testing.T) {
 		type Form struct {
 			Tagged   string `form:"tagged"`
@@ -449,6 +498,7 @@ func TestKit_Cookies(t *testing.T) {
 	})
 
 	t.Run("cookie attributes are correct", func(t *testing.T) {
+		setEnv(t, config.EnvDevelopment)
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest("GET", "/", nil)
 
@@ -463,4 +513,13 @@ func TestKit_Cookies(t *testing.T) {
 		assert.True(t, cookie.HttpOnly)
 		assert.False(t, cookie.Expires.IsZero())
 	})
+
+	t.Run("cookies are secure in production", func(t *testing.T) {
+		setEnv(t, config.EnvProduction)
+
+		w := httptest.NewRecorder()
+		k := &Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+		k.SetCookie("test", "value")
+		assert.True(t, w.Result().Cookies()[0].Secure, "TLS ends at a proxy")
+	})
 }
@@ -0,0 +1,201 @@
+package kit
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"reflect"
+
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// ResourceStore is the store behind a Resource. database.CRUDStore and the
+// stores embedding it satisfy it.
+type ResourceStore[T any] interface {
+	List(ctx context.Context, opts database.ListOptions) ([]T, int64, error)
+	Get(ctx context.Context, id string, preloads ...string) (*T, error)
+	Create(ctx context.Context, item T) error
+	Update(ctx context.Context, item T) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Resource provides the JSON handlers of a REST API over a store, for
+// route.go files to delegate to:
+//
+//	// app/api/posts/route.go
+//	var posts = &kit.Resource[models.Post]{Store: stores.NewPostStore(), Validate: validatePost}
+//
+//	func GET(k *kit.Kit) error  { return posts.List(k) }
+//	func POST(k *kit.Kit) error { return posts.Create(k) }
+//
+//	// app/api/posts/[id]/route.go
+//	func GET(k *kit.Kit) error    { return posts.Get(k) }
+//	func PUT(k *kit.Kit) error    { return posts.Update(k) }
+//	func DELETE(k *kit.Kit) error { return posts.Delete(k) }
+//
+// Store errors are returned for the error handler, so a missing record is
+// a 404, a column the store doesn't allow a 400 and a version conflict a
+// 409. A body that doesn't decode is a 400.
+type Resource[T any] struct {
+	Store ResourceStore[T]
+	// Serialize returns what is written for an item, such as a struct
+	// leaving out private fields. Items are written as they are without it.
+	Serialize func(item *T) any
+	// Validate checks an item before it's created or updated. An item with
+	// errors isn't saved, and the response is a 422 listing them by field.
+	Validate func(item *T) FormErrors
+	// Preloads are the associations loaded by List and Get
+	Preloads []string
+	// Param is the path parameter holding the ID, "id" if empty
+	Param string
+}
+
+// List responds with a page of items, selected by the query string as
+// k.ListOptions reads it, and the number of items on every page
+func (r *Resource[T]) List(k *Kit) error {
+	opts, err := k.ListOptions()
+	if err != nil {
+		return err
+	}
+	opts.Preloads = r.Preloads
+
+	items, total, err := r.Store.List(k.Request.Context(), opts)
+	if err != nil {
+		return err
+	}
+
+	serialized := make([]any, len(items))
+	for i := range items {
+		serialized[i] = r.serialize(&items[i])
+	}
+	return k.JSON(http.StatusOK, map[string]any{
+		"items":    serialized,
+		"total":    total,
+		"page":     opts.Page,
+		"per_page": opts.PerPage,
+	})
+}
+
+// Get responds with the item whose ID is in the path
+func (r *Resource[T]) Get(k *Kit) error {
+	item, err := r.Store.Get(k.Request.Context(), k.PathValue(r.param()), r.Preloads...)
+	if err != nil {
+		return err
+	}
+	return k.JSON(http.StatusOK, r.serialize(item))
+}
+
+// Create decodes an item from the request body, validates it and saves it,
+// responding 201 with the item. A uuid.UUID ID field is given a new UUID,
+// whatever the client sent.
+func (r *Resource[T]) Create(k *Kit) error {
+	var item T
+	if err := decodeResource(k, &item); err != nil {
+		return err
+	}
+	if id, ok := resourceID(&item); ok && id.Type() == reflect.TypeOf(uuid.UUID{}) {
+		id.Set(reflect.ValueOf(uuid.New()))
+	}
+	if errs := r.validate(&item); len(errs) > 0 {
+		return validationFailed(k, errs)
+	}
+
+	if err := r.Store.Create(k.Request.Context(), item); err != nil {
+		return err
+	}
+	return k.JSON(http.StatusCreated, r.serialize(&item))
+}
+
+// Update decodes the request body over the item whose ID is in the path,
+// so fields left out keep their values, then validates and saves it. A
+// body changing the ID returns ErrAPIIDMismatch.
+func (r *Resource[T]) Update(k *Kit) error {
+	item, err := r.Store.Get(k.Request.Context(), k.PathValue(r.param()))
+	if err != nil {
+		return err
+	}
+
+	var before any
+	if id, ok := resourceID(item); ok {
+		before = id.Interface()
+	}
+	if err := decodeResource(k, item); err != nil {
+		return err
+	}
+	if id, ok := resourceID(item); ok && !reflect.DeepEqual(before, id.Interface()) {
+		return errors.ErrAPIIDMismatch
+	}
+	if errs := r.validate(item); len(errs) > 0 {
+		return validationFailed(k, errs)
+	}
+
+	if err := r.Store.Update(k.Request.Context(), *item); err != nil {
+		return err
+	}
+	return k.JSON(http.StatusOK, r.serialize(item))
+}
+
+// Delete deletes the item whose ID is in the path, responding 204
+func (r *Resource[T]) Delete(k *Kit) error {
+	if err := r.Store.Delete(k.Request.Context(), k.PathValue(r.param())); err != nil {
+		return err
+	}
+	return k.NoContent()
+}
+
+func (r *Resource[T]) param() string {
+	if r.Param == "" {
+		return "id"
+	}
+	return r.Param
+}
+
+func (r *Resource[T]) serialize(item *T) any {
+	if r.Serialize == nil {
+		return item
+	}
+	return r.Serialize(item)
+}
+
+func (r *Resource[T]) validate(item *T) FormErrors {
+	if r.Validate == nil {
+		return nil
+	}
+	return r.Validate(item)
+}
+
+// decodeResource decodes the request body into item, returning
+// ErrAPIRequestPayload, a 400, for a body that doesn't decode
+func decodeResource[T any](k *Kit, item *T) error {
+	err := k.Decode(item)
+	if stderrors.Is(err, errors.ErrDecodeJSON) || stderrors.Is(err, errors.ErrDecodeForm) {
+		return errors.ErrAPIRequestPayload.Wrap(err)
+	}
+	return err
+}
+
+// resourceID returns the ID field of item, including one promoted from an
+// embedded struct such as database.BaseModel, if it has one
+func resourceID[T any](item *T) (reflect.Value, bool) {
+	v := reflect.ValueOf(item).Elem()
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	field := v.FieldByName("ID")
+	return field, field.IsValid() && field.CanSet()
+}
+
+// validationFailed responds with ErrAPIValidation and errs by field, in the
+// shape of the error handler's responses
+func validationFailed(k *Kit, errs FormErrors) error {
+	e := errors.ErrAPIValidation
+	return k.JSON(e.HTTPStatus, map[string]any{
+		"error":  e.Public(),
+		"code":   e.Code,
+		"status": e.HTTPStatus,
+		"fields": errs,
+	})
+}
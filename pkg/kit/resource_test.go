@@ -0,0 +1,181 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// resourcePost is the model of the test resource
+type resourcePost struct {
+	ID     uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	Secret string    `json:"secret"`
+}
+
+// CRUDStore backs Resource
+var _ ResourceStore[resourcePost] = (*database.CRUDStore[resourcePost])(nil)
+
+// memoryStore is a ResourceStore of posts in memory
+type memoryStore struct {
+	posts    map[string]resourcePost
+	lastList database.ListOptions
+}
+
+func (s *memoryStore) List(ctx context.Context, opts database.ListOptions) ([]resourcePost, int64, error) {
+	s.lastList = opts
+	if opts.Sort == "secret" {
+		return nil, 0, errors.ErrDatabaseInvalidColumn
+	}
+	var posts []resourcePost
+	for _, post := range s.posts {
+		posts = append(posts, post)
+	}
+	return posts, int64(len(posts)), nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id string, preloads ...string) (*resourcePost, error) {
+	post, ok := s.posts[id]
+	if !ok {
+		return nil, errors.ErrDatabaseObjectNotFound
+	}
+	return &post, nil
+}
+
+func (s *memoryStore) Create(ctx context.Context, post resourcePost) error {
+	s.posts[post.ID.String()] = post
+	return nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, post resourcePost) error {
+	s.posts[post.ID.String()] = post
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id string) error {
+	if _, ok := s.posts[id]; !ok {
+		return errors.ErrDatabaseObjectNotFound
+	}
+	delete(s.posts, id)
+	return nil
+}
+
+// TestResource tests the handlers of a resource over a store
+func TestResource(t *testing.T) {
+	id := uuid.New()
+	store := &memoryStore{posts: map[string]resourcePost{
+		id.String(): {ID: id, Title: "Hello", Secret: "s3cret"},
+	}}
+	posts := &Resource[resourcePost]{
+		Store: store,
+		Serialize: func(post *resourcePost) any {
+			return map[string]any{"id": post.ID, "title": post.Title}
+		},
+		Validate: func(post *resourcePost) FormErrors {
+			errs := FormErrors{}
+			if strings.TrimSpace(post.Title) == "" {
+				errs.Add("title", "is required")
+			}
+			return errs
+		},
+		Preloads: []string{"Author"},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /posts", Handler(posts.List))
+	mux.Handle("POST /posts", Handler(posts.Create))
+	mux.Handle("GET /posts/{id}", Handler(posts.Get))
+	mux.Handle("PUT /posts/{id}", Handler(posts.Update))
+	mux.Handle("DELETE /posts/{id}", Handler(posts.Delete))
+
+	serve := func(method, target, body string) (*httptest.ResponseRecorder, map[string]any) {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		var decoded map[string]any
+		json.Unmarshal(w.Body.Bytes(), &decoded)
+		return w, decoded
+	}
+
+	t.Run("list", func(t *testing.T) {
+		w, body := serve("GET", "/posts?page=2&per_page=5", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(1), body["total"])
+		assert.Equal(t, float64(2), body["page"])
+		assert.Equal(t, float64(5), body["per_page"])
+		assert.Equal(t, []any{map[string]any{"id": id.String(), "title": "Hello"}}, body["items"], "serialized")
+		assert.Equal(t, []string{"Author"}, store.lastList.Preloads)
+
+		w, body = serve("GET", "/posts?per_page=500", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, float64(errors.ErrAPIQueryParam.Code), body["code"])
+
+		w, _ = serve("GET", "/posts?sort=secret", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "the store's error")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		w, body := serve("GET", "/posts/"+id.String(), "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, map[string]any{"id": id.String(), "title": "Hello"}, body)
+
+		w, _ = serve("GET", "/posts/"+uuid.NewString(), "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		chosen := uuid.New()
+		w, body := serve("POST", "/posts", `{"id":"`+chosen.String()+`","title":"New"}`)
+		require.Equal(t, http.StatusCreated, w.Code)
+		created, err := uuid.Parse(body["id"].(string))
+		require.NoError(t, err)
+		assert.NotEqual(t, chosen, created, "a new ID, not the client's")
+		assert.Equal(t, "New", store.posts[created.String()].Title)
+
+		w, body = serve("POST", "/posts", `{"title":" "}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, float64(errors.ErrAPIValidation.Code), body["code"])
+		assert.Equal(t, map[string]any{"title": "is required"}, body["fields"])
+
+		w, _ = serve("POST", "/posts", `{`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		w, body := serve("PUT", "/posts/"+id.String(), `{"title":"Edited"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Edited", body["title"])
+		assert.Equal(t, resourcePost{ID: id, Title: "Edited", Secret: "s3cret"}, store.posts[id.String()], "fields left out keep their values")
+
+		w, body = serve("PUT", "/posts/"+id.String(), `{"id":"`+uuid.NewString()+`"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, float64(errors.ErrAPIIDMismatch.Code), body["code"])
+
+		w, _ = serve("PUT", "/posts/"+id.String(), `{"title":""}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "Edited", store.posts[id.String()].Title, "not saved")
+
+		w, _ = serve("PUT", "/posts/"+uuid.NewString(), `{"title":"Edited"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w, _ := serve("DELETE", "/posts/"+id.String(), "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.NotContains(t, store.posts, id.String())
+
+		w, _ = serve("DELETE", "/posts/"+id.String(), "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -2,16 +2,26 @@ package kit
 
 import (
 	"encoding/json"
+	stderrors "errors"
 	"net/http"
 
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
 	"github.com/cstone-io/twine/pkg/template"
 )
 
-// JSON writes a JSON response
+// JSON writes a JSON response, serialized for the request's JSONView and
+// trimmed to the fields a ?fields= query parameter lists, as Serialize
+// does. It returns errors.ErrAborted without encoding v if the client
+// closed the request.
 func (k *Kit) JSON(status int, v any) error {
+	if k.Aborted() {
+		return errors.ErrAborted
+	}
+	v = Serialize(v, k.JSONView(), k.sparseFields())
 	k.Response.Header().Set("Content-Type", "application/json")
 	k.Response.WriteHeader(status)
-	return json.NewEncoder(k.Response).Encode(v)
+	return k.abortedError(json.NewEncoder(k.Response).Encode(v))
 }
 
 // Text writes a plain text response
@@ -44,16 +54,59 @@ func (k *Kit) NoContent() error {
 	return nil
 }
 
-// RenderTemplate renders a full page template
+// RenderTemplate renders a full page template with data merged with the
+// request's ViewContext. Like the other Render methods, it returns
+// errors.ErrAborted without rendering if the client closed the request.
 func (k *Kit) RenderTemplate(name string, data any) error {
+	if k.Aborted() {
+		return errors.ErrAborted
+	}
 	k.Response.Header().Set("Content-Type", "text/html")
-	return template.RenderFull(k.Response, name, data)
+	template.Record(k.Request.Context(), name)
+	return k.templateError(template.RenderFull(k.Response, name, k.viewData(data)))
 }
 
 // RenderPartial renders a template component (for Ajax partial responses)
 func (k *Kit) RenderPartial(name string, data any) error {
+	if k.Aborted() {
+		return errors.ErrAborted
+	}
+	k.Response.Header().Set("Content-Type", "text/html")
+	template.Record(k.Request.Context(), name)
+	return k.templateError(template.RenderPartial(k.Response, name, k.viewData(data)))
+}
+
+// RenderIn renders a page or component of a template set loaded with
+// template.LoadSet. It's recorded as "set/name".
+func (k *Kit) RenderIn(set, name string, data any) error {
+	if k.Aborted() {
+		return errors.ErrAborted
+	}
 	k.Response.Header().Set("Content-Type", "text/html")
-	return template.RenderPartial(k.Response, name, data)
+	template.Record(k.Request.Context(), set+"/"+name)
+	return k.templateError(template.RenderIn(k.Response, set, name, k.viewData(data)))
+}
+
+// RenderComponent renders a component with props, such as an Ajax partial
+// replacing one card. Props aren't merged with the ViewContext.
+func (k *Kit) RenderComponent(name string, props any) error {
+	if k.Aborted() {
+		return errors.ErrAborted
+	}
+	k.Response.Header().Set("Content-Type", "text/html")
+	template.Record(k.Request.Context(), name)
+	return k.templateError(template.RenderComponent(k.Response, name, props))
+}
+
+// templateError shows a template that failed to parse in the browser while
+// templates.reload is set, as in development, and returns other errors
+func (k *Kit) templateError(err error) error {
+	var parseErr *template.ParseError
+	if !stderrors.As(err, &parseErr) || !config.Get().Templates.Reload {
+		return k.abortedError(err)
+	}
+	k.Logger().Error("Parsing templates: %v", parseErr)
+	return k.HTML(http.StatusInternalServerError, parseErr.HTML())
 }
 
 // Render automatically chooses between full and partial rendering based on X-Alpine-Request header
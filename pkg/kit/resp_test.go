@@ -1,11 +1,17 @@
 package kit
 
 import (
+	"errors"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/template"
 )
 
 // TestKit_JSON tests JSON response writing
@@ -190,6 +196,81 @@ func TestKit_HTML(t *testing.T) {
 	})
 }
 
+// TestKit_RenderTemplate_ParseError tests showing templates that fail to
+// parse in the browser while templates.reload is set
+func TestKit_RenderTemplate_ParseError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.html")
+	require.NoError(t, os.WriteFile(path, []byte(`{{define "page"}}{{.Title}{{end}}`), 0644))
+	require.Error(t, template.LoadTemplates(filepath.Join(dir, "*.html")))
+	t.Cleanup(func() { template.SetTemplates(nil) })
+
+	cfg := config.Get()
+	original := cfg.Templates
+	t.Cleanup(func() { cfg.Templates = original })
+
+	t.Run("renders the error page with reload", func(t *testing.T) {
+		cfg.Templates.Reload = true
+		w := httptest.NewRecorder()
+		k := &Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+
+		require.NoError(t, k.RenderTemplate("page", nil))
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), path+", line 1")
+	})
+
+	t.Run("returns the error without reload", func(t *testing.T) {
+		cfg.Templates.Reload = false
+		w := httptest.NewRecorder()
+		k := &Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+
+		err := k.RenderPartial("page", nil)
+		var parseErr *template.ParseError
+		require.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, path, parseErr.File)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+// TestKit_RenderIn tests rendering pages of template sets
+func TestKit_RenderIn(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.html"), []byte(`{{define "base"}}<main>{{block "content" .}}{{end}}</main>{{end}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dashboard.html"), []byte(`{{define "content"}}{{.}}{{end}}`), 0644))
+	require.NoError(t, template.LoadSet("admin", template.SetConfig{
+		Layouts: []string{filepath.Join(dir, "base.html")},
+		Pages:   []string{filepath.Join(dir, "dashboard.html")},
+		Layout:  "base",
+	}))
+
+	recorder := &template.Recorder{}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	k := &Kit{Response: w, Request: r.WithContext(template.WithRecorder(r.Context(), recorder))}
+
+	require.NoError(t, k.RenderIn("admin", "dashboard", "Stats"))
+	assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<main>Stats</main>", w.Body.String())
+	assert.Equal(t, []string{"admin/dashboard"}, recorder.Names())
+}
+
+// TestKit_RenderComponent tests rendering a component with its props
+func TestKit_RenderComponent(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "card.html"), []byte(`{{define "user-card"}}<div>{{.Name}}</div>{{end}}`), 0644))
+	require.NoError(t, template.LoadTemplates(filepath.Join(dir, "card.html")))
+	t.Cleanup(func() { template.SetTemplates(nil) })
+
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+	k.View().AppName = "Twine"
+
+	require.NoError(t, k.RenderComponent("user-card", struct{ Name string }{"Ada"}))
+	assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<div>Ada</div>", w.Body.String())
+}
+
 // TestKit_NoContent tests 204 No Content response
 func TestKit_NoContent(t *testing.T) {
 	t.Run("writes 204 No Content", func(t *testing.T) {
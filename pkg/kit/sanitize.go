@@ -0,0 +1,15 @@
+package kit
+
+import "github.com/cstone-io/twine/pkg/sanitize"
+
+// Sanitize cleans user-generated HTML with policy, or the application's
+// policy when nil, before it's written to a response, such as a comment
+// swapped into the page after an Ajax post:
+//
+//	return k.HTML(http.StatusOK, kit.Sanitize(nil, comment.Body))
+func Sanitize(policy *sanitize.Policy, html string) string {
+	if policy == nil {
+		policy = sanitize.Get()
+	}
+	return policy.Sanitize(html)
+}
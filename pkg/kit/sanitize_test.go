@@ -0,0 +1,19 @@
+package kit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cstone-io/twine/pkg/sanitize"
+)
+
+// TestSanitize tests cleaning HTML with a policy or the application's
+func TestSanitize(t *testing.T) {
+	sanitize.Reset()
+	t.Cleanup(sanitize.Reset)
+
+	html := `<p onclick="x">Hi <a href="javascript:x">there</a></p><script>alert(1)</script>`
+	assert.Equal(t, `<p>Hi <a>there</a></p>`, Sanitize(nil, html))
+	assert.Equal(t, `Hi there`, Sanitize(sanitize.StrictPolicy(), html))
+}
@@ -0,0 +1,315 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultJSONView is the JSON view of requests that don't set one with
+	// SetJSONView
+	DefaultJSONView = "public"
+	// FieldsParam is the query parameter listing the fields of a sparse
+	// response, e.g. ?fields=id,title
+	FieldsParam = "fields"
+)
+
+type jsonViewKey struct{}
+
+// SetJSONView sets the view k.JSON serializes the request's responses for.
+// Fields tagged with views, such as `view:"admin"`, are only written for
+// those views:
+//
+//	type User struct {
+//	    ID    uuid.UUID `json:"id"`
+//	    Email string    `json:"email" view:"owner,admin"`
+//	    Notes string    `json:"notes" view:"admin"`
+//	}
+//
+//	k.SetJSONView("admin")
+//	return k.JSON(http.StatusOK, user)
+//
+// Fields without a view tag are written for every view.
+func (k *Kit) SetJSONView(view string) {
+	k.Request = k.Request.WithContext(context.WithValue(k.Request.Context(), jsonViewKey{}, view))
+}
+
+// JSONView returns the view set with SetJSONView, or DefaultJSONView
+func (k *Kit) JSONView() string {
+	if view, ok := k.Request.Context().Value(jsonViewKey{}).(string); ok {
+		return view
+	}
+	return DefaultJSONView
+}
+
+// sparseFields returns the fields listed in the FieldsParam query
+// parameter, or nil without one
+func (k *Kit) sparseFields() []string {
+	raw := k.Request.URL.Query().Get(FieldsParam)
+	if raw == "" {
+		return nil
+	}
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Serialize returns v as it's encoded for view: without the struct fields
+// whose view tag doesn't list view, and, when fields isn't nil, only with
+// the listed fields of the outermost structs, by JSON name. The outermost
+// structs are v itself, the elements of a slice and the values of a map,
+// so a list in an envelope such as {"items": [...], "total": 3} is
+// trimmed and the envelope isn't. k.JSON serializes with the request's
+// JSONView and the FieldsParam query parameter.
+func Serialize(v any, view string, fields []string) any {
+	if v == nil {
+		return nil
+	}
+	var only map[string]bool
+	if fields != nil {
+		only = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			only[field] = true
+		}
+	}
+	return serialize(reflect.ValueOf(v), view, only)
+}
+
+// jsonMember is a field of a jsonObject
+type jsonMember struct {
+	name  string
+	value any
+}
+
+// jsonObject is a serialized struct, keeping its fields' order
+type jsonObject []jsonMember
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(member.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// serialize serializes v for view, keeping only the fields in only of the
+// outermost structs when it isn't nil
+func serialize(v reflect.Value, view string, only map[string]bool) any {
+	if !v.IsValid() {
+		return nil
+	}
+	t := v.Type()
+	if only == nil && !hasViews(t) || marshals(t) {
+		return v.Interface()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return serialize(v.Elem(), view, only)
+	case reflect.Struct:
+		plan := structPlanFor(t)
+		object := make(jsonObject, 0, len(plan))
+		for _, field := range plan {
+			if field.views != nil && !slices.Contains(field.views, view) {
+				continue
+			}
+			if only != nil && !only[field.name] {
+				continue
+			}
+			fv, err := v.FieldByIndexErr(field.index)
+			if err != nil || field.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			object = append(object, jsonMember{field.name, serialize(fv, view, nil)})
+		}
+		return object
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && (v.IsNil() || t.Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = serialize(v.Index(i), view, only)
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() || t.Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			m[iter.Key().String()] = serialize(iter.Value(), view, only)
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+// marshals reports whether values of t encode themselves
+func marshals(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType) ||
+		t.Kind() != reflect.Pointer && (reflect.PointerTo(t).Implements(marshalerType) || reflect.PointerTo(t).Implements(textMarshalerType))
+}
+
+// structField is a field of a struct as encoding/json writes it
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	// views are the views the field is written for, nil for every view
+	views []string
+}
+
+var (
+	structPlans sync.Map // reflect.Type -> []structField
+	viewTypes   sync.Map // reflect.Type -> bool
+)
+
+// structPlanFor returns the fields encoding/json writes for struct type t,
+// with their views
+func structPlanFor(t reflect.Type) []structField {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.([]structField)
+	}
+	var fields []structField
+	depths := map[string]int{}
+	var walk func(t reflect.Type, index []int, depth int)
+	walk = func(t reflect.Type, index []int, depth int) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			fieldIndex := append(slices.Clone(index), i)
+
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				// As encoding/json, the exported fields of embedded structs
+				// are promoted, unless they're behind an unexported pointer
+				if f.IsExported() || f.Type.Kind() != reflect.Pointer {
+					walk(ft, fieldIndex, depth+1)
+				}
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			// As encoding/json, the shallowest field of a name wins
+			if d, ok := depths[name]; ok && d <= depth {
+				continue
+			}
+			depths[name] = depth
+			fields = slices.DeleteFunc(fields, func(sf structField) bool { return sf.name == name })
+
+			field := structField{index: fieldIndex, name: name, omitEmpty: slices.Contains(strings.Split(options, ","), "omitempty")}
+			if views, ok := f.Tag.Lookup("view"); ok {
+				field.views = strings.Split(views, ",")
+				for j := range field.views {
+					field.views[j] = strings.TrimSpace(field.views[j])
+				}
+			}
+			fields = append(fields, field)
+		}
+	}
+	walk(t, nil, 0)
+
+	plan, _ := structPlans.LoadOrStore(t, fields)
+	return plan.([]structField)
+}
+
+// hasViews reports whether values of t may hold a struct field with a view
+// tag, and so need serializing
+func hasViews(t reflect.Type) bool {
+	if cached, ok := viewTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	found := findViews(t, map[reflect.Type]bool{})
+	viewTypes.Store(t, found)
+	return found
+}
+
+// findViews looks for view tags in t, skipping the types in seen, which
+// are already being looked at higher up a recursive type
+func findViews(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return findViews(t.Elem(), seen)
+	case reflect.Interface:
+		// The dynamic type is only known from a value
+		return true
+	case reflect.Struct:
+		if marshals(t) {
+			return false
+		}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() && !f.Anonymous {
+				continue
+			}
+			if _, ok := f.Tag.Lookup("view"); ok || findViews(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isEmptyValue reports whether v is empty as encoding/json's omitempty
+// means it
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
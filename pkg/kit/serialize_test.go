@@ -0,0 +1,99 @@
+package kit
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serializedAudit struct {
+	CreatedBy string    `json:"created_by" view:"admin"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type serializedUser struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name"`
+	Email    string          `json:"email" view:"owner, admin"`
+	Notes    string          `json:"notes,omitempty" view:"admin"`
+	Password string          `json:"-"`
+	Manager  *serializedUser `json:"manager,omitempty"`
+	serializedAudit
+}
+
+// encode returns v serialized for view with fields, as JSON
+func encode(t *testing.T, v any, view string, fields []string) string {
+	t.Helper()
+	b, err := json.Marshal(Serialize(v, view, fields))
+	require.NoError(t, err)
+	return string(b)
+}
+
+// TestSerialize tests writing fields for views and sparse fieldsets
+func TestSerialize(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := serializedUser{
+		ID: 1, Name: "Ada", Email: "ada@example.com", Password: "hash",
+		Manager:         &serializedUser{ID: 2, Name: "Grace", Email: "grace@example.com", Notes: "lead"},
+		serializedAudit: serializedAudit{CreatedBy: "root", UpdatedAt: at},
+	}
+
+	assert.JSONEq(t, `{"id":1,"name":"Ada","updated_at":"2026-01-02T03:04:05Z",
+		"manager":{"id":2,"name":"Grace","updated_at":"0001-01-01T00:00:00Z"}}`,
+		encode(t, user, DefaultJSONView, nil))
+	assert.JSONEq(t, `{"id":1,"name":"Ada","email":"ada@example.com","updated_at":"2026-01-02T03:04:05Z",
+		"manager":{"id":2,"name":"Grace","email":"grace@example.com","updated_at":"0001-01-01T00:00:00Z"}}`,
+		encode(t, &user, "owner", nil))
+	assert.JSONEq(t, `{"id":1,"name":"Ada","email":"ada@example.com","created_by":"root","updated_at":"2026-01-02T03:04:05Z",
+		"manager":{"id":2,"name":"Grace","email":"grace@example.com","notes":"lead","created_by":"","updated_at":"0001-01-01T00:00:00Z"}}`,
+		encode(t, user, "admin", nil))
+
+	t.Run("keeps the field order", func(t *testing.T) {
+		assert.Equal(t, `{"id":1,"name":"Ada","updated_at":"2026-01-02T03:04:05Z"}`,
+			encode(t, serializedUser{ID: 1, Name: "Ada", serializedAudit: serializedAudit{UpdatedAt: at}}, DefaultJSONView, nil))
+	})
+
+	t.Run("sparse fieldsets", func(t *testing.T) {
+		assert.Equal(t, `{"id":1,"name":"Ada"}`, encode(t, user, "admin", []string{"id", "name", "missing"}))
+		assert.Equal(t, `{"id":1}`, encode(t, user, DefaultJSONView, []string{"id", "email"}), "views still apply")
+		assert.Equal(t, `[{"name":"Ada"},{"name":"Grace"}]`,
+			encode(t, []serializedUser{user, *user.Manager}, DefaultJSONView, []string{"name"}))
+		assert.JSONEq(t, `{"items":[{"id":1}],"total":1}`,
+			encode(t, map[string]any{"items": []*serializedUser{&user}, "total": 1}, DefaultJSONView, []string{"id"}),
+			"an envelope keeps its keys")
+	})
+
+	t.Run("leaves values without views alone", func(t *testing.T) {
+		plain := struct {
+			A int               `json:"a"`
+			B map[string]string `json:"b"`
+			C []byte            `json:"c"`
+		}{1, map[string]string{"k": "v"}, []byte("hi")}
+		assert.Equal(t, plain, Serialize(plain, DefaultJSONView, nil))
+		assert.Nil(t, Serialize(nil, DefaultJSONView, []string{"id"}))
+		want, _ := json.Marshal(plain)
+		assert.Equal(t, string(want), encode(t, plain, DefaultJSONView, []string{"a", "b", "c"}))
+	})
+}
+
+// TestKit_JSON_Serialize tests k.JSON serializing for the request
+func TestKit_JSON_Serialize(t *testing.T) {
+	user := serializedUser{ID: 1, Name: "Ada", Email: "ada@example.com"}
+
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest("GET", "/users/1?fields=id,email", nil)}
+	assert.Equal(t, DefaultJSONView, k.JSONView())
+	require.NoError(t, k.JSON(200, user))
+	assert.Equal(t, `{"id":1}`+"\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	k = &Kit{Response: w, Request: httptest.NewRequest("GET", "/users/1?fields=id,email", nil)}
+	k.SetJSONView("owner")
+	assert.Equal(t, "owner", k.JSONView())
+	require.NoError(t, k.JSON(200, user))
+	assert.Equal(t, `{"id":1,"email":"ada@example.com"}`+"\n", w.Body.String())
+}
@@ -0,0 +1,103 @@
+package kit
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// SSEEvent is one server-sent event
+type SSEEvent struct {
+	// ID is the client's Last-Event-ID after this event, sent back when it
+	// reconnects
+	ID string
+	// Event names the event, for addEventListener; empty is a "message"
+	// event
+	Event string
+	// Data is the payload, sent as one data line per line
+	Data string
+	// Retry asks the client to wait this long before reconnecting
+	Retry time.Duration
+}
+
+// SSEStream writes server-sent events to a response. Create one with
+// Kit.SSE.
+type SSEStream struct {
+	w    http.ResponseWriter
+	ctrl *http.ResponseController
+}
+
+// SSE starts a text/event-stream response for server-sent events:
+//
+//	stream, err := k.SSE()
+//	if err != nil {
+//	    return err
+//	}
+//	for progress := range updates {
+//	    if err := stream.Send(kit.SSEEvent{Event: "progress", Data: progress}); err != nil {
+//	        return nil // the client went away
+//	    }
+//	}
+//
+// The server's write timeout doesn't apply to the stream, which lasts
+// until the handler returns or the request's context is done.
+func (k *Kit) SSE() (*SSEStream, error) {
+	ctrl := http.NewResponseController(k.Response)
+	if err := ctrl.SetWriteDeadline(time.Time{}); err != nil && !stderrors.Is(err, http.ErrNotSupported) {
+		return nil, errors.ErrStreamEvents.Wrap(err)
+	}
+
+	header := k.Response.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Keeps nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	k.Response.WriteHeader(http.StatusOK)
+	if err := ctrl.Flush(); err != nil {
+		return nil, errors.ErrStreamEvents.Wrap(err)
+	}
+	return &SSEStream{w: k.Response, ctrl: ctrl}, nil
+}
+
+// Send writes evt and flushes it to the client
+func (s *SSEStream) Send(evt SSEEvent) error {
+	var b strings.Builder
+	if evt.ID != "" {
+		b.WriteString("id: " + oneLine(evt.ID) + "\n")
+	}
+	if evt.Event != "" {
+		b.WriteString("event: " + oneLine(evt.Event) + "\n")
+	}
+	if evt.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", evt.Retry.Milliseconds())
+	}
+	data := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(evt.Data)
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Comment writes a comment line, which clients ignore. An empty comment
+// works as a heartbeat, keeping proxies from closing an idle stream.
+func (s *SSEStream) Comment(text string) error {
+	return s.write(": " + oneLine(text) + "\n\n")
+}
+
+func (s *SSEStream) write(text string) error {
+	if _, err := s.w.Write([]byte(text)); err != nil {
+		return err
+	}
+	return s.ctrl.Flush()
+}
+
+// oneLine replaces line breaks, which would end a field early
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
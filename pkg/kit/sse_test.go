@@ -0,0 +1,45 @@
+package kit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSSE tests the stream's headers and the encoding of events and
+// comments
+func TestSSE(t *testing.T) {
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest(http.MethodGet, "/events", nil)}
+
+	stream, err := k.SSE()
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(SSEEvent{Data: "hello"}))
+	require.NoError(t, stream.Send(SSEEvent{ID: "7", Event: "order\nupdated", Data: "<p>one</p>\r\n<p>two</p>", Retry: 3 * time.Second}))
+	require.NoError(t, stream.Comment(""))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "data: hello\n\n"+
+		"id: 7\nevent: order updated\nretry: 3000\ndata: <p>one</p>\ndata: <p>two</p>\n\n"+
+		": \n\n", w.Body.String())
+}
+
+// unflushable is a ResponseWriter that can't stream
+type unflushable struct {
+	http.ResponseWriter
+}
+
+// TestSSE_Unsupported tests failing when the response can't be flushed
+func TestSSE_Unsupported(t *testing.T) {
+	k := &Kit{Response: unflushable{httptest.NewRecorder()}, Request: httptest.NewRequest(http.MethodGet, "/events", nil)}
+
+	_, err := k.SSE()
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package kit
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/storage"
+)
+
+// SaveUploadedFile stores the file uploaded in the multipart form field
+// under key in the configured store, streaming it rather than reading it
+// into memory. A key ending in "/" is a prefix, and the file gets a random
+// name with its extension:
+//
+//	upload, err := k.SaveUploadedFile("avatar", "avatars/")
+//	if err != nil {
+//	    return err
+//	}
+//	user.AvatarKey = upload.Key
+//
+// A missing or malformed upload fails with errors.ErrUploadFile.
+func (k *Kit) SaveUploadedFile(field, key string) (*storage.Upload, error) {
+	file, header, err := k.Request.FormFile(field)
+	if err != nil {
+		return nil, errors.ErrUploadFile.Wrap(err).WithValue(field)
+	}
+	defer file.Close()
+
+	if key == "" || strings.HasSuffix(key, "/") {
+		key = storage.UniqueKey(key, header.Filename)
+	}
+	upload := &storage.Upload{
+		Key:         key,
+		Filename:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Size:        header.Size,
+	}
+	opts := storage.PutOptions{ContentType: upload.ContentType}
+	if err := storage.Put(k.Request.Context(), key, file, opts); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// SignedFileHandler serves files from the local storage driver at the URLs
+// made by storage.SignedURL. Mount it at STORAGE_URL with a {key...}
+// wildcard:
+//
+//	r.Get("/files/{key...}", kit.SignedFileHandler)
+//
+// Requests without a valid, unexpired signature get 403. Other drivers
+// serve their own signed URLs, so with them it responds 404.
+func SignedFileHandler(k *Kit) error {
+	key := k.PathValue("key")
+	local, ok := storage.Default().(*storage.LocalDriver)
+	if !ok {
+		return errors.ErrFileNotFound.WithValue(key)
+	}
+	if err := local.Verify(key, k.Request.URL.Query()); err != nil {
+		return err
+	}
+
+	path, err := local.Path(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return errors.ErrFileNotFound.WithValue(key)
+	}
+	if err != nil {
+		return errors.ErrStorageRead.Wrap(err).WithValue(key)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return errors.ErrFileNotFound.WithValue(key)
+	}
+	k.Response.Header().Set("Cache-Control", "private")
+	http.ServeContent(k.Response, k.Request, info.Name(), info.ModTime(), f)
+	return nil
+}
@@ -0,0 +1,108 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/storage"
+)
+
+// setupStorage makes a LocalDriver in a temporary directory, served at
+// /files, the one returned by storage.Default
+func setupStorage(t *testing.T) *storage.LocalDriver {
+	t.Helper()
+	driver := storage.NewLocalDriver(t.TempDir(), "/files")
+	storage.Use(driver)
+	t.Cleanup(storage.Reset)
+	return driver
+}
+
+// uploadRequest builds a multipart request with a file in field
+func uploadRequest(t *testing.T, field, filename, contentType, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	require.NoError(t, err)
+	io.WriteString(part, content)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest("POST", "/avatar", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+// TestKit_SaveUploadedFile tests storing an uploaded file
+func TestKit_SaveUploadedFile(t *testing.T) {
+	setupStorage(t)
+	ctx := context.Background()
+
+	k := &Kit{Response: httptest.NewRecorder(), Request: uploadRequest(t, "avatar", "me.png", "image/png", "png-data")}
+	upload, err := k.SaveUploadedFile("avatar", "avatars/42.png")
+	require.NoError(t, err)
+	assert.Equal(t, &storage.Upload{Key: "avatars/42.png", Filename: "me.png", ContentType: "image/png", Size: 8}, upload)
+
+	r, err := storage.Get(ctx, "avatars/42.png")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "png-data", string(data))
+
+	k = &Kit{Response: httptest.NewRecorder(), Request: uploadRequest(t, "avatar", "me.png", "image/png", "png-data")}
+	upload, err = k.SaveUploadedFile("avatar", "avatars/")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upload.Key, "avatars/"), upload.Key)
+	assert.True(t, strings.HasSuffix(upload.Key, ".png"), upload.Key)
+	assert.NotEqual(t, "avatars/me.png", upload.Key)
+
+	k = &Kit{Response: httptest.NewRecorder(), Request: uploadRequest(t, "document", "me.png", "image/png", "png-data")}
+	_, err = k.SaveUploadedFile("avatar", "avatars/")
+	assert.ErrorIs(t, err, errors.ErrUploadFile)
+
+	k = &Kit{Response: httptest.NewRecorder(), Request: uploadRequest(t, "avatar", "me.png", "image/png", "png-data")}
+	_, err = k.SaveUploadedFile("avatar", "../avatars/42.png")
+	assert.ErrorIs(t, err, errors.ErrInvalidFileKey)
+}
+
+// TestSignedFileHandler tests serving local files at signed URLs
+func TestSignedFileHandler(t *testing.T) {
+	driver := setupStorage(t)
+	ctx := context.Background()
+	require.NoError(t, driver.Put(ctx, "reports/march.csv", strings.NewReader("a,b\n"), storage.PutOptions{}))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /files/{key...}", Handler(SignedFileHandler))
+	serve := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+
+	signed, err := driver.SignedURL(ctx, "reports/march.csv", time.Minute)
+	require.NoError(t, err)
+	w := serve(signed)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a,b\n", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+
+	assert.Equal(t, http.StatusForbidden, serve("/files/reports/march.csv").Code, "unsigned requests are refused")
+
+	missing, err := driver.SignedURL(ctx, "reports/april.csv", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, serve(missing).Code)
+}
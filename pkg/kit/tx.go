@@ -0,0 +1,66 @@
+package kit
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/database"
+)
+
+// TxScope is the transaction a Tx closure runs in. Pass its GORM client to
+// stores' WithTx so they share it.
+type TxScope interface {
+	GORM() *gorm.DB
+}
+
+// txScope is the TxScope for a GORM transaction
+type txScope struct {
+	tx *gorm.DB
+}
+
+func (s txScope) GORM() *gorm.DB {
+	return s.tx
+}
+
+// TransactorFunc runs fn in a transaction, committing if it returns nil and
+// rolling back otherwise
+type TransactorFunc func(ctx context.Context, fn func(TxScope) error) error
+
+// transactor runs the transactions started by Tx, databaseTransactor
+// unless set with UseTransactor
+var transactor TransactorFunc
+
+// UseTransactor sets how Tx runs transactions. Applications only need it
+// for a database other than the one returned by database.Get, and nil
+// goes back to that one.
+func UseTransactor(t TransactorFunc) {
+	transactor = t
+}
+
+// databaseTransactor runs fn in a transaction on the database returned by
+// database.Get
+func databaseTransactor(ctx context.Context, fn func(TxScope) error) error {
+	return database.Transaction(ctx, func(tx *gorm.DB) error {
+		return fn(txScope{tx})
+	})
+}
+
+// Tx runs fn in a database transaction bound to the request's context, so
+// the stores it uses write atomically:
+//
+//	return k.Tx(func(store kit.TxScope) error {
+//	    if err := orders.WithTx(store.GORM()).Create(k.Request.Context(), order); err != nil {
+//	        return err
+//	    }
+//	    return stock.WithTx(store.GORM()).Update(k.Request.Context(), item)
+//	})
+//
+// The transaction is rolled back if fn returns an error or panics.
+func (k *Kit) Tx(fn func(store TxScope) error) error {
+	run := transactor
+	if run == nil {
+		run = databaseTransactor
+	}
+	return run(k.Request.Context(), fn)
+}
@@ -0,0 +1,65 @@
+package kit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// txOrder is the model written in test transactions
+type txOrder struct {
+	ID   uint
+	Item string
+}
+
+// TestKit_Tx tests running closures with the transactor
+func TestKit_Tx(t *testing.T) {
+	saved := transactor
+	t.Cleanup(func() { transactor = saved })
+
+	k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("POST", "/orders", nil)}
+
+	var ctx context.Context
+	UseTransactor(func(c context.Context, fn func(TxScope) error) error {
+		ctx = c
+		return fn(nil)
+	})
+	called := false
+	require.NoError(t, k.Tx(func(TxScope) error {
+		called = true
+		return nil
+	}))
+	assert.True(t, called)
+	assert.Equal(t, k.Request.Context(), ctx, "transactions use the request's context")
+}
+
+// TestKit_Tx_Database tests running a handler's writes in a transaction on
+// the database returned by database.Get
+func TestKit_Tx_Database(t *testing.T) {
+	saved := transactor
+	t.Cleanup(func() { transactor = saved })
+	UseTransactor(nil)
+
+	db := useTestDB(t)
+	require.NoError(t, db.AutoMigrate(&txOrder{}))
+	orders := database.NewCRUDStore[txOrder](db)
+
+	k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("POST", "/orders", nil)}
+	err := k.Tx(func(store TxScope) error {
+		if err := orders.WithTx(store.GORM()).Create(k.Request.Context(), txOrder{ID: 1, Item: "book"}); err != nil {
+			return err
+		}
+		return errors.ErrDatabaseWrite
+	})
+	assert.Same(t, errors.ErrDatabaseWrite, err)
+
+	list, _, err := orders.List(context.Background(), database.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list, "the write was rolled back")
+}
@@ -0,0 +1,24 @@
+package kit
+
+import "context"
+
+type userKey struct{}
+
+// SetUser stores the request's user record, for CurrentUser.
+// middleware.LoadUser sets it after JWT or session validation.
+func (k *Kit) SetUser(user any) {
+	k.Request = k.Request.WithContext(context.WithValue(k.Request.Context(), userKey{}, user))
+}
+
+// CurrentUser returns the request's user record, set by
+// middleware.LoadUser, as a T. ok is false when there is none or it isn't
+// a T:
+//
+//	user, ok := kit.CurrentUser[*models.User](k)
+//	if !ok {
+//	    return errors.ErrAuthNoSession
+//	}
+func CurrentUser[T any](k *Kit) (user T, ok bool) {
+	user, ok = k.Request.Context().Value(userKey{}).(T)
+	return user, ok
+}
@@ -0,0 +1,25 @@
+package kit
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCurrentUser tests reading the request's user record as its type
+func TestCurrentUser(t *testing.T) {
+	type user struct{ Name string }
+	k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+
+	_, ok := CurrentUser[*user](k)
+	assert.False(t, ok, "no user was set")
+
+	k.SetUser(&user{Name: "Ada"})
+	u, ok := CurrentUser[*user](k)
+	assert.True(t, ok)
+	assert.Equal(t, "Ada", u.Name)
+
+	_, ok = CurrentUser[user](k)
+	assert.False(t, ok, "the record is a *user")
+}
@@ -0,0 +1,168 @@
+package kit
+
+import (
+	"context"
+	"maps"
+	"reflect"
+)
+
+type viewKey struct{}
+
+// ViewContext is the data layouts and middleware contribute to every
+// template rendered for a request. RenderTemplate, RenderPartial, Render and
+// RenderIn merge it with the handler's data:
+//
+//   - nil data renders the view context as a map
+//   - a map[string]any gets AppName, User, Flashes, CSRFToken, Meta,
+//     Allowed and Values as keys, unless the handler set them
+//   - a struct, or pointer to one, embedding ViewContext gets the fields
+//     the handler left empty
+//
+// Other data renders unchanged. User defaults to the record set by
+// SetUser, as middleware.LoadUser does, Meta to the meta settings of
+// twine.yaml and Allowed to k.Allowed.
+type ViewContext struct {
+	AppName   string
+	User      any
+	Flashes   []Flash
+	CSRFToken string
+	// Meta are the page's head tags, set with k.SetMeta
+	Meta Meta
+	// Allowed reports whether the user may take an action on a record,
+	// for templates to hide the others: {{if call .Allowed "edit" .Post}}
+	Allowed func(action string, obj any) bool
+	// Values are other data for templates, such as the current section
+	Values map[string]any
+}
+
+// Flash is a one-time message shown on the next page rendered
+type Flash struct {
+	Kind    string // e.g. "success" or "error"
+	Message string
+}
+
+// Set sets a value for templates, rendered as a top-level key of map data
+// and as .Values.key of structs
+func (v *ViewContext) Set(key string, value any) {
+	if v.Values == nil {
+		v.Values = make(map[string]any)
+	}
+	v.Values[key] = value
+}
+
+// Flash adds a message of kind to the page rendered
+func (v *ViewContext) Flash(kind, message string) {
+	v.Flashes = append(v.Flashes, Flash{Kind: kind, Message: message})
+}
+
+// Map returns the view context as template data
+func (v ViewContext) Map() map[string]any {
+	m := make(map[string]any, len(v.Values)+6)
+	maps.Copy(m, v.Values)
+	m["AppName"] = v.AppName
+	m["User"] = v.User
+	m["Flashes"] = v.Flashes
+	m["CSRFToken"] = v.CSRFToken
+	m["Meta"] = v.Meta
+	m["Allowed"] = v.Allowed
+	return m
+}
+
+// View returns the request's view context for middleware to fill in:
+//
+//	func Layout() middleware.Middleware {
+//	    return func(next kit.HandlerFunc) kit.HandlerFunc {
+//	        return func(k *kit.Kit) error {
+//	            k.View().AppName = "My Twine App"
+//	            k.View().Set("section", "dashboard")
+//	            return next(k)
+//	        }
+//	    }
+//	}
+func (k *Kit) View() *ViewContext {
+	if v, ok := k.Request.Context().Value(viewKey{}).(*ViewContext); ok {
+		return v
+	}
+	v := &ViewContext{}
+	k.Request = k.Request.WithContext(context.WithValue(k.Request.Context(), viewKey{}, v))
+	return v
+}
+
+// viewData merges the request's view context into data
+func (k *Kit) viewData(data any) any {
+	var view ViewContext
+	if v, ok := k.Request.Context().Value(viewKey{}).(*ViewContext); ok {
+		view = *v
+	}
+	if view.User == nil {
+		view.User = k.Request.Context().Value(userKey{})
+	}
+	view.Meta = view.Meta.withDefaults(configMeta())
+	if view.Allowed == nil {
+		view.Allowed = k.Allowed
+	}
+
+	switch d := data.(type) {
+	case nil:
+		return view.Map()
+	case map[string]any:
+		m := view.Map()
+		maps.Copy(m, d)
+		return m
+	}
+
+	rv := reflect.ValueOf(data)
+	isPointer := rv.Kind() == reflect.Pointer
+	if isPointer {
+		if rv.IsNil() {
+			return data
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return data
+	}
+	field, ok := rv.Type().FieldByName("ViewContext")
+	if !ok || !field.Anonymous || field.Type != reflect.TypeOf(ViewContext{}) {
+		return data
+	}
+
+	// Fill a copy of value structs, leaving the handler's data alone
+	if !isPointer {
+		copied := reflect.New(rv.Type()).Elem()
+		copied.Set(rv)
+		rv = copied
+	}
+	embedded := rv.FieldByIndex(field.Index)
+	merged := embedded.Interface().(ViewContext).withDefaults(view)
+	embedded.Set(reflect.ValueOf(merged))
+	if isPointer {
+		return data
+	}
+	return rv.Interface()
+}
+
+// withDefaults fills the fields of v left empty from d. Values and flashes
+// of both are kept, with v's values taking precedence.
+func (v ViewContext) withDefaults(d ViewContext) ViewContext {
+	if v.AppName == "" {
+		v.AppName = d.AppName
+	}
+	if v.User == nil {
+		v.User = d.User
+	}
+	if v.CSRFToken == "" {
+		v.CSRFToken = d.CSRFToken
+	}
+	v.Meta = v.Meta.withDefaults(d.Meta)
+	if v.Allowed == nil {
+		v.Allowed = d.Allowed
+	}
+	v.Flashes = append(append([]Flash(nil), d.Flashes...), v.Flashes...)
+	if len(d.Values) > 0 {
+		values := maps.Clone(d.Values)
+		maps.Copy(values, v.Values)
+		v.Values = values
+	}
+	return v
+}
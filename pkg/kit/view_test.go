@@ -0,0 +1,99 @@
+package kit
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+// TestKit_View tests filling the view context across middleware
+func TestKit_View(t *testing.T) {
+	k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+
+	k.View().AppName = "Twine"
+	k.View().Set("section", "admin")
+	k.View().Flash("success", "Saved")
+
+	view := k.View()
+	assert.Equal(t, "Twine", view.AppName)
+	assert.Equal(t, map[string]any{"section": "admin"}, view.Values)
+	assert.Equal(t, []Flash{{Kind: "success", Message: "Saved"}}, view.Flashes)
+}
+
+// TestKit_ViewData tests merging the view context with handler data
+func TestKit_ViewData(t *testing.T) {
+	type user struct{ Name string }
+	newKit := func() *Kit {
+		k := &Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+		k.View().AppName = "Twine"
+		k.View().CSRFToken = "token"
+		k.View().Set("section", "admin")
+		k.SetUser(&user{Name: "Ada"})
+		return k
+	}
+
+	t.Run("renders nil data as the view context", func(t *testing.T) {
+		data := newKit().viewData(nil).(map[string]any)
+		assert.Equal(t, "Twine", data["AppName"])
+		assert.Equal(t, &user{Name: "Ada"}, data["User"], "the user defaults to SetUser's")
+		assert.Equal(t, "admin", data["section"])
+	})
+
+	t.Run("merges maps with the handler's keys winning", func(t *testing.T) {
+		handler := map[string]any{"Title": "Home", "section": "home"}
+		data := newKit().viewData(handler).(map[string]any)
+		assert.Equal(t, "Home", data["Title"])
+		assert.Equal(t, "home", data["section"])
+		assert.Equal(t, "token", data["CSRFToken"])
+		assert.NotContains(t, handler, "AppName", "the handler's map is left alone")
+	})
+
+	t.Run("fills structs embedding ViewContext", func(t *testing.T) {
+		type page struct {
+			ViewContext
+			Title string
+		}
+
+		filled := newKit().viewData(page{Title: "Home", ViewContext: ViewContext{AppName: "Custom"}}).(page)
+		assert.Equal(t, "Home", filled.Title)
+		assert.Equal(t, "Custom", filled.AppName, "fields the handler set are kept")
+		assert.Equal(t, "token", filled.CSRFToken)
+		assert.Equal(t, "admin", filled.Values["section"])
+
+		pointer := &page{Title: "Home"}
+		assert.Same(t, pointer, newKit().viewData(pointer))
+		assert.Equal(t, "Twine", pointer.AppName)
+		assert.Equal(t, &user{Name: "Ada"}, pointer.User)
+	})
+
+	t.Run("leaves other data unchanged", func(t *testing.T) {
+		type plain struct{ Title string }
+		k := newKit()
+		assert.Equal(t, plain{Title: "Home"}, k.viewData(plain{Title: "Home"}))
+		assert.Equal(t, "text", k.viewData("text"))
+		assert.Equal(t, []int{1}, k.viewData([]int{1}))
+	})
+}
+
+// TestKit_Render_ViewContext tests templates seeing the view context
+func TestKit_Render_ViewContext(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "page.html"),
+		[]byte(`{{define "page"}}{{.AppName}}: {{.Title}}{{range .Flashes}} [{{.Message}}]{{end}}{{end}}`), 0644))
+	require.NoError(t, template.LoadTemplates(filepath.Join(dir, "page.html")))
+	t.Cleanup(func() { template.SetTemplates(nil) })
+
+	w := httptest.NewRecorder()
+	k := &Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+	k.View().AppName = "Twine"
+	k.View().Flash("success", "Saved")
+
+	require.NoError(t, k.Render("page", map[string]any{"Title": "Home"}))
+	assert.Equal(t, "Twine: Home [Saved]", w.Body.String())
+}
@@ -0,0 +1,180 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+const (
+	// MaxRawBody caps the body RawBody reads
+	MaxRawBody = 10 << 20
+	// WebhookTolerance is how old a signed timestamp may be, as in
+	// Stripe's scheme, before the signature counts as a replay
+	WebhookTolerance = 5 * time.Minute
+)
+
+// WebhookScheme checks a webhook's signature, the value of its signature
+// header, against body signed with secret. It returns an error for a
+// signature that doesn't match or has expired.
+type WebhookScheme func(secret, signature string, body []byte) error
+
+// The common webhook signature schemes
+var (
+	// WebhookGitHub checks GitHub's X-Hub-Signature-256 header,
+	// sha256=<hex HMAC-SHA256 of the body>
+	WebhookGitHub WebhookScheme = func(secret, signature string, body []byte) error {
+		digest, ok := strings.CutPrefix(signature, "sha256=")
+		if !ok {
+			return errors.ErrAPIWebhookSignature
+		}
+		return compareHex(digest, hmacSHA256(secret, body))
+	}
+
+	// WebhookStripe checks Stripe's Stripe-Signature header,
+	// t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">, which may list
+	// several v1 signatures while a secret is rolled. Timestamps older than
+	// WebhookTolerance are rejected.
+	WebhookStripe WebhookScheme = func(secret, signature string, body []byte) error {
+		var timestamp string
+		var digests []string
+		for _, part := range strings.Split(signature, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				digests = append(digests, value)
+			}
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || len(digests) == 0 {
+			return errors.ErrAPIWebhookSignature
+		}
+		if age := time.Since(time.Unix(seconds, 0)); age > WebhookTolerance || age < -WebhookTolerance {
+			return errors.ErrAPIWebhookSignature.WithValue("timestamp " + timestamp)
+		}
+
+		expected := hmacSHA256(secret, append([]byte(timestamp+"."), body...))
+		for _, digest := range digests {
+			if compareHex(digest, expected) == nil {
+				return nil
+			}
+		}
+		return errors.ErrAPIWebhookSignature
+	}
+
+	// WebhookHex checks a header holding the hex HMAC-SHA256 of the body
+	WebhookHex WebhookScheme = func(secret, signature string, body []byte) error {
+		return compareHex(signature, hmacSHA256(secret, body))
+	}
+
+	// WebhookBase64 checks a header holding the base64 HMAC-SHA256 of the
+	// body, as Shopify's X-Shopify-Hmac-Sha256
+	WebhookBase64 WebhookScheme = func(secret, signature string, body []byte) error {
+		given, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil || !hmac.Equal(given, hmacSHA256(secret, body)) {
+			return errors.ErrAPIWebhookSignature
+		}
+		return nil
+	}
+)
+
+// VerifyWebhookSignature checks the signature in the request's header
+// against its raw body signed with secret, returning
+// ErrAPIWebhookSignature, a 401, unless it matches. The body can still be
+// decoded afterwards:
+//
+//	if err := k.VerifyWebhookSignature(secret, "Stripe-Signature", kit.WebhookStripe); err != nil {
+//	    return err
+//	}
+//	var event StripeEvent
+//	if err := k.Decode(&event); err != nil {
+//	    return err
+//	}
+func (k *Kit) VerifyWebhookSignature(secret, header string, scheme WebhookScheme) error {
+	body, err := k.RawBody()
+	if err != nil {
+		return err
+	}
+	signature := k.GetHeader(header)
+	if secret == "" || signature == "" {
+		return errors.ErrAPIWebhookSignature.WithValue(header)
+	}
+	return scheme(secret, signature, body)
+}
+
+type rawBodyKey struct{}
+
+// RawBody reads the request body as sent, such as to check a signature,
+// and leaves it to be read again by Decode. Bodies over MaxRawBody return
+// ErrAPIRequestPayload.
+func (k *Kit) RawBody() ([]byte, error) {
+	if body, ok := k.Request.Context().Value(rawBodyKey{}).([]byte); ok {
+		return body, nil
+	}
+	if k.Request.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(k.Request.Body, MaxRawBody+1))
+	k.Request.Body.Close()
+	if err != nil {
+		return nil, k.abortedError(errors.ErrAPIRequestPayload.Wrap(err))
+	}
+	if len(body) > MaxRawBody {
+		return nil, errors.ErrAPIRequestPayload.WithValue("body over " + strconv.Itoa(MaxRawBody) + " bytes")
+	}
+	k.Request = k.Request.WithContext(context.WithValue(k.Request.Context(), rawBodyKey{}, body))
+	k.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// webhookPrefix namespaces the delivery IDs WebhookSeen caches
+const webhookPrefix = "twine:webhook:"
+
+// WebhookSeen records a webhook delivery's ID, such as GitHub's
+// X-GitHub-Delivery header or a Stripe event's ID, in the application
+// cache for ttl, and reports whether it was recorded before. Senders retry
+// deliveries, so handlers acknowledge ones already seen without acting on
+// them again:
+//
+//	if seen, err := k.WebhookSeen(event.ID, 24*time.Hour); err != nil || seen {
+//	    return err
+//	}
+//
+// Two deliveries of the same ID at the same moment may both be unseen, so
+// handlers doing what mustn't happen twice also need a unique constraint.
+func (k *Kit) WebhookSeen(id string, ttl time.Duration) (bool, error) {
+	c := k.Cache()
+	key := webhookPrefix + id
+	if _, found, err := c.Store().Get(c.Context(), key); err != nil || found {
+		return found, err
+	}
+	return false, c.Store().Set(c.Context(), key, []byte{1}, ttl)
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of body with secret
+func hmacSHA256(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// compareHex compares a hex digest with expected in constant time
+func compareHex(digest string, expected []byte) error {
+	given, err := hex.DecodeString(digest)
+	if err != nil || !hmac.Equal(given, expected) {
+		return errors.ErrAPIWebhookSignature
+	}
+	return nil
+}
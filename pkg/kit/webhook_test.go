@@ -0,0 +1,123 @@
+package kit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/cache"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// webhookKit returns a Kit for a webhook delivering body with header set
+// to signature
+func webhookKit(body, header, signature string) *Kit {
+	r := httptest.NewRequest("POST", "/api/webhooks", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if header != "" {
+		r.Header.Set(header, signature)
+	}
+	return &Kit{Response: httptest.NewRecorder(), Request: r}
+}
+
+// sign returns the HMAC-SHA256 of body with secret
+func sign(secret, body string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
+
+// TestKit_VerifyWebhookSignature tests checking the common signature
+// schemes
+func TestKit_VerifyWebhookSignature(t *testing.T) {
+	const secret, body = "whsec_test", `{"id":"evt_1","type":"charge.succeeded"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		header    string
+		signature string
+		scheme    WebhookScheme
+		valid     bool
+	}{
+		{"github", "X-Hub-Signature-256", "sha256=" + hex.EncodeToString(sign(secret, body)), WebhookGitHub, true},
+		{"github without prefix", "X-Hub-Signature-256", hex.EncodeToString(sign(secret, body)), WebhookGitHub, false},
+		{"github with another secret", "X-Hub-Signature-256", "sha256=" + hex.EncodeToString(sign("other", body)), WebhookGitHub, false},
+		{"stripe", "Stripe-Signature", "t=" + now + ",v1=" + hex.EncodeToString(sign(secret, now+"."+body)), WebhookStripe, true},
+		{"stripe rolling secrets", "Stripe-Signature", "t=" + now + ",v1=" + hex.EncodeToString(sign("old", now+"."+body)) + ",v1=" + hex.EncodeToString(sign(secret, now+"."+body)), WebhookStripe, true},
+		{"stripe replayed", "Stripe-Signature", "t=" + stale + ",v1=" + hex.EncodeToString(sign(secret, stale+"."+body)), WebhookStripe, false},
+		{"stripe without timestamp", "Stripe-Signature", "v1=" + hex.EncodeToString(sign(secret, "."+body)), WebhookStripe, false},
+		{"hex", "X-Signature", hex.EncodeToString(sign(secret, body)), WebhookHex, true},
+		{"base64", "X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(sign(secret, body)), WebhookBase64, true},
+		{"base64 garbage", "X-Shopify-Hmac-Sha256", "not base64!", WebhookBase64, false},
+		{"missing header", "", "", WebhookHex, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == "" {
+				header = "X-Signature"
+			}
+			k := webhookKit(body, tt.header, tt.signature)
+			err := k.VerifyWebhookSignature(secret, header, tt.scheme)
+			if !tt.valid {
+				assert.ErrorIs(t, err, errors.ErrAPIWebhookSignature)
+				return
+			}
+			require.NoError(t, err)
+
+			var event struct{ ID, Type string }
+			require.NoError(t, k.Decode(&event), "the body is left to decode")
+			assert.Equal(t, "evt_1", event.ID)
+		})
+	}
+
+	t.Run("rejects an empty secret", func(t *testing.T) {
+		k := webhookKit(body, "X-Signature", hex.EncodeToString(sign("", body)))
+		assert.ErrorIs(t, k.VerifyWebhookSignature("", "X-Signature", WebhookHex), errors.ErrAPIWebhookSignature)
+	})
+}
+
+// TestKit_RawBody tests reading the body as sent, more than once
+func TestKit_RawBody(t *testing.T) {
+	k := webhookKit("payload", "", "")
+	body, err := k.RawBody()
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(body))
+	again, err := k.RawBody()
+	require.NoError(t, err)
+	assert.Equal(t, body, again)
+
+	k = webhookKit(strings.Repeat("x", MaxRawBody+1), "", "")
+	_, err = k.RawBody()
+	assert.ErrorIs(t, err, errors.ErrAPIRequestPayload)
+}
+
+// TestKit_WebhookSeen tests recognizing deliveries already handled
+func TestKit_WebhookSeen(t *testing.T) {
+	cache.Use(cache.NewMemoryStore(0))
+	t.Cleanup(cache.Reset)
+
+	k := webhookKit("", "", "")
+	seen, err := k.WebhookSeen("evt_1", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, seen)
+
+	seen, err = k.WebhookSeen("evt_1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, seen, "a retried delivery")
+
+	seen, err = k.WebhookSeen("evt_2", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, seen)
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// badKey is the key given to a field value without one, as in log/slog
+const badKey = "!BADKEY"
+
+// slogLevels maps each level to the slog level JSON entries are written at
+var slogLevels = map[config.LogLevel]slog.Level{
+	config.LogTrace:    slog.LevelDebug - 4,
+	config.LogDebug:    slog.LevelDebug,
+	config.LogInfo:     slog.LevelInfo,
+	config.LogWarn:     slog.LevelWarn,
+	config.LogError:    slog.LevelError,
+	config.LogCritical: slog.LevelError + 4,
+}
+
+// levelNames are the level names written in JSON entries, matching
+// LOGGER_LEVEL
+var levelNames = map[slog.Level]string{
+	slogLevels[config.LogTrace]:    "trace",
+	slogLevels[config.LogDebug]:    "debug",
+	slogLevels[config.LogInfo]:     "info",
+	slogLevels[config.LogWarn]:     "warn",
+	slogLevels[config.LogError]:    "error",
+	slogLevels[config.LogCritical]: "critical",
+}
+
+// newJSONHandler writes entries to w as JSON objects with time, level and
+// msg properties followed by the fields. Levels are filtered before entries
+// get here, so every level is enabled.
+func newJSONHandler(w io.Writer) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: slogLevels[config.LogTrace],
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 {
+				if level, ok := a.Value.Any().(slog.Level); ok {
+					a.Value = slog.StringValue(levelNames[level])
+				}
+			}
+			return a
+		},
+	})
+}
+
+// formatFields writes fields as " key=value" pairs, quoting values that
+// contain spaces, quotes or '='. Fields are paired as log/slog pairs them:
+// a slog.Attr stands alone, and a value without a string key before it gets
+// the key !BADKEY.
+func formatFields(fields []any) string {
+	var b strings.Builder
+	for len(fields) > 0 {
+		var key string
+		var value any
+		switch f := fields[0].(type) {
+		case slog.Attr:
+			key, value, fields = f.Key, f.Value.Resolve().Any(), fields[1:]
+		case string:
+			if len(fields) == 1 {
+				key, value, fields = badKey, f, nil
+			} else {
+				key, value, fields = f, fields[1], fields[2:]
+			}
+		default:
+			key, value, fields = badKey, f, fields[1:]
+		}
+		b.WriteString(" " + key + "=" + quote(formatValue(value)))
+	}
+	return b.String()
+}
+
+// formatValue formats a field value, preferring the error message or text
+// form of values that have one
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case encoding.TextMarshaler:
+		if text, err := v.MarshalText(); err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// quote quotes s if it is empty or would be ambiguous in a key=value pair
+func quote(s string) string {
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r == '"' || r == '=' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -1,9 +1,14 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cstone-io/twine/pkg/config"
 	"github.com/cstone-io/twine/pkg/errors"
@@ -14,15 +19,36 @@ var (
 	instance *Logger
 )
 
-// Logger provides structured logging with multiple severity levels
+// Logger provides structured logging with multiple severity levels. Messages
+// are formatted with fmt, and fields added with With are written after them
+// as key=value pairs, or as JSON properties with LOGGER_FORMAT=json.
 type Logger struct {
+	*core
+
+	// fields are the alternating keys and values added by With
+	fields []any
+	// throttle is set on loggers returned by Throttle
+	throttle *throttleKey
+}
+
+// core is the state shared by a Logger and those derived from it with With
+type core struct {
 	traceLogger    *log.Logger
 	debugLogger    *log.Logger
 	infoLogger     *log.Logger
 	warnLogger     *log.Logger
 	errorLogger    *log.Logger
 	criticalLogger *log.Logger
-	level          config.LogLevel
+	level          atomic.Int64 // config.LogLevel
+
+	// handler and errorHandler receive the entries for Output and
+	// ErrorOutput when they aren't written as text: JSON encoders with
+	// LOGGER_FORMAT=json, or the handler given to UseHandler
+	mu           sync.RWMutex
+	handler      slog.Handler
+	errorHandler slog.Handler
+
+	sampler *sampler
 }
 
 // Get returns the singleton logger instance
@@ -30,71 +56,182 @@ func Get() *Logger {
 	once.Do(func() {
 		cfg := config.Get()
 		initialize(cfg.Logger)
+		errors.CaptureStacks(cfg.Logger.StackTraces)
+
+		// LOGGER_LEVEL and LOGGER_STACK_TRACES are applied on config reloads
+		config.Subscribe(func(_, cfg *config.Config) {
+			instance.SetLevel(cfg.Logger.Level)
+			errors.CaptureStacks(cfg.Logger.StackTraces)
+		})
 	})
 	return instance
 }
 
 func initialize(cfg config.LoggerConfig) {
+	instance = New(cfg)
+}
+
+// New creates a Logger with the given settings. Most code uses Get; New
+// suits tests and components that log somewhere else.
+func New(cfg config.LoggerConfig) *Logger {
 	logfmt := log.Ldate | log.Ltime | log.Lshortfile
-	instance = &Logger{
+	l := &Logger{core: &core{
 		traceLogger:    log.New(io.MultiWriter(cfg.Output), "TRACE: ", logfmt),
 		debugLogger:    log.New(io.MultiWriter(cfg.Output), "DEBUG: ", logfmt),
 		infoLogger:     log.New(io.MultiWriter(cfg.Output), "INFO: ", logfmt),
 		warnLogger:     log.New(io.MultiWriter(cfg.Output), "WARN: ", logfmt),
 		errorLogger:    log.New(io.MultiWriter(cfg.ErrorOutput), "ERROR: ", logfmt),
 		criticalLogger: log.New(io.MultiWriter(cfg.ErrorOutput), "CRITICAL: ", logfmt),
-		level:          cfg.Level,
+		sampler:        newSampler(),
+	}}
+	if cfg.Format == config.LogJSON {
+		l.handler = newJSONHandler(cfg.Output)
+		l.errorHandler = newJSONHandler(cfg.ErrorOutput)
+	}
+	l.SetLevel(cfg.Level)
+	return l
+}
+
+// Level returns the minimum level the logger writes
+func (l *Logger) Level() config.LogLevel {
+	return config.LogLevel(l.level.Load())
+}
+
+// SetLevel changes the minimum level the logger writes, for this logger and
+// every logger derived from it with With. It is safe to call while other
+// goroutines log.
+func (l *Logger) SetLevel(level config.LogLevel) {
+	l.level.Store(int64(level))
+}
+
+// With returns a Logger that writes the given fields with every entry, after
+// any fields l already has. Fields alternate keys and values:
+//
+//	log := logger.Get().With("request_id", id, "user_id", userID)
+//	log.Info("request completed")
+//	// INFO: 2025/01/02 15:04:05 logger.go:135: request completed request_id=42 user_id=7
+func (l *Logger) With(fields ...any) *Logger {
+	return &Logger{
+		core:     l.core,
+		fields:   append(l.fields[:len(l.fields):len(l.fields)], fields...),
+		throttle: l.throttle,
 	}
 }
 
 // Trace logs trace-level messages
 func (l *Logger) Trace(format string, v ...interface{}) {
-	if l.level <= config.LogTrace {
-		l.traceLogger.Printf(format, v...)
+	if l.Level() <= config.LogTrace {
+		l.write(config.LogTrace, l.traceLogger, format, v)
 	}
 }
 
 // Debug logs debug-level messages
 func (l *Logger) Debug(format string, v ...interface{}) {
-	if l.level <= config.LogDebug {
-		l.debugLogger.Printf(format, v...)
+	if l.Level() <= config.LogDebug {
+		l.write(config.LogDebug, l.debugLogger, format, v)
 	}
 }
 
 // Info logs info-level messages
 func (l *Logger) Info(format string, v ...interface{}) {
-	if l.level <= config.LogInfo {
-		l.infoLogger.Printf(format, v...)
+	if l.Level() <= config.LogInfo {
+		l.write(config.LogInfo, l.infoLogger, format, v)
 	}
 }
 
 // Warn logs warning-level messages
 func (l *Logger) Warn(format string, v ...interface{}) {
-	if l.level <= config.LogWarn {
-		l.warnLogger.Printf(format, v...)
+	if l.Level() <= config.LogWarn {
+		l.write(config.LogWarn, l.warnLogger, format, v)
 	}
 }
 
 // Error logs error-level messages
 func (l *Logger) Error(format string, v ...interface{}) {
-	if l.level <= config.LogError {
-		l.errorLogger.Printf(format, v...)
+	if l.Level() <= config.LogError {
+		l.write(config.LogError, l.errorLogger, format, v)
 	}
 }
 
 // Critical logs critical-level messages (always logged)
 func (l *Logger) Critical(format string, v ...interface{}) {
-	l.criticalLogger.Printf(format, v...)
+	l.write(config.LogCritical, l.criticalLogger, format, v)
+}
+
+// write encodes an entry in the configured format, unless sampling or a
+// throttle drops it
+func (l *Logger) write(level config.LogLevel, text *log.Logger, format string, v []any) {
+	fields, ok := l.admit(level, format)
+	if !ok {
+		return
+	}
+
+	msg := fmt.Sprintf(format, v...)
+	if h := l.handlerFor(level); h != nil {
+		l.handle(h, level, msg, fields, time.Now(), callerPC())
+		return
+	}
+	// The caller reported is the logging method, as with log.Printf
+	text.Output(2, msg+formatFields(fields))
 }
 
-// CustomError logs a structured error based on its severity
+// admit applies sampling and the throttle to an entry, returning its fields
+func (l *Logger) admit(level config.LogLevel, format string) ([]any, bool) {
+	if !l.sampler.sampled(level, format) {
+		return nil, false
+	}
+	if l.throttle == nil {
+		return l.fields, true
+	}
+
+	ok, suppressed := l.sampler.allow(l.throttle)
+	if ok && suppressed > 0 {
+		return append(l.fields[:len(l.fields):len(l.fields)], "suppressed", suppressed), true
+	}
+	return l.fields, ok
+}
+
+// CustomError logs a structured error based on its severity. ErrError and
+// ErrCritical errors with a stack trace have it printed after the error
+// chain, or in a stack field in JSON.
 func (l *Logger) CustomError(e *errors.Error) {
 	switch e.Severity {
 	case errors.ErrMinor:
 		l.Warn("%s", e.ErrorChain())
 	case errors.ErrError:
-		l.Error("%s", e.ErrorChain())
+		l.withStack(config.LogError, e).Error("%s", l.stackText(config.LogError, e))
 	case errors.ErrCritical:
-		l.Critical("%s", e.ErrorChain())
+		l.withStack(config.LogCritical, e).Critical("%s", l.stackText(config.LogCritical, e))
+	}
+}
+
+// withStack adds e's stack trace as a field, for entries at level that go to
+// a handler
+func (l *Logger) withStack(level config.LogLevel, e *errors.Error) *Logger {
+	stack := e.StackTrace()
+	if len(stack) == 0 || l.handlerFor(level) == nil {
+		return l
+	}
+
+	frames := make([]string, len(stack))
+	for i, frame := range stack {
+		frames[i] = fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line)
+	}
+	return l.With("stack", frames)
+}
+
+// stackText returns e's error chain, followed by its stack trace for text
+// entries at level
+func (l *Logger) stackText(level config.LogLevel, e *errors.Error) string {
+	chain := e.ErrorChain()
+	if l.handlerFor(level) != nil {
+		return chain
+	}
+
+	var b strings.Builder
+	b.WriteString(chain)
+	for _, frame := range e.StackTrace() {
+		fmt.Fprintf(&b, "\tat %s (%s:%d)\n", frame.Function, frame.File, frame.Line)
 	}
+	return b.String()
 }
@@ -2,14 +2,20 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/cstone-io/twine/pkg/config"
 	"github.com/cstone-io/twine/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // resetLogger resets the singleton for testing
@@ -436,6 +442,32 @@ func TestLogger_CustomError(t *testing.T) {
 		assert.Contains(t, output, "CRITICAL:")
 		assert.Contains(t, output, "critical error")
 	})
+
+	t.Run("stack trace printed for errors", func(t *testing.T) {
+		resetLogger()
+		var buf bytes.Buffer
+		logger := createTestLogger(&buf, config.LogTrace)
+
+		logger.CustomError(errors.ErrDatabaseRead.Wrap(assert.AnError))
+		assert.Regexp(t, `\tat github.com/cstone-io/twine/pkg/logger.TestLogger_CustomError.func\d+ \(.*logger_test.go:\d+\)`, buf.String())
+
+		buf.Reset()
+		logger.CustomError(errors.ErrDecodeForm.Wrap(assert.AnError))
+		assert.NotContains(t, buf.String(), "\tat ", "minor errors have no stack trace")
+	})
+
+	t.Run("stack trace field in JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(config.LoggerConfig{Level: config.LogTrace, Format: config.LogJSON, Output: &buf, ErrorOutput: &buf})
+
+		logger.CustomError(errors.ErrDatabaseRead.Wrap(assert.AnError))
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		stack, ok := entry["stack"].([]any)
+		require.True(t, ok, "stack is a list of frames")
+		assert.Contains(t, stack[0], "TestLogger_CustomError")
+	})
 }
 
 // TestLogger_OutputRouting tests that regular logs go to Output and errors to ErrorOutput
@@ -619,7 +651,7 @@ func TestLogger_Initialize(t *testing.T) {
 		assert.NotNil(t, instance.warnLogger)
 		assert.NotNil(t, instance.errorLogger)
 		assert.NotNil(t, instance.criticalLogger)
-		assert.Equal(t, config.LogTrace, instance.level)
+		assert.Equal(t, config.LogTrace, instance.Level())
 	})
 }
 
@@ -655,3 +687,224 @@ func TestLogger_RealOutputs(t *testing.T) {
 		instance.Error("test stderr logging")
 	})
 }
+
+// TestLogger_SetLevel tests changing the level of a running logger
+func TestLogger_SetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := createTestLogger(&buf, config.LogWarn)
+
+	logger.Info("before")
+	assert.Empty(t, buf.String())
+
+	logger.SetLevel(config.LogDebug)
+	assert.Equal(t, config.LogDebug, logger.Level())
+
+	logger.Info("after")
+	assert.Contains(t, buf.String(), "after")
+}
+
+// TestLogger_With tests adding fields to entries
+func TestLogger_With(t *testing.T) {
+	var buf bytes.Buffer
+	logger := createTestLogger(&buf, config.LogInfo)
+
+	requestLog := logger.With("method", "GET", "path", "/users")
+	requestLog.With("status", 200, "message", "not found", "err", assert.AnError).Info("request %s", "completed")
+	requestLog.Info("second")
+	logger.Info("plain")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `request completed method=GET path=/users status=200 message="not found" err="assert.AnError general error for testing"`)
+	assert.True(t, strings.HasSuffix(lines[1], "second method=GET path=/users"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], "plain"), lines[2])
+
+	t.Run("level is shared", func(t *testing.T) {
+		buf.Reset()
+		logger.SetLevel(config.LogError)
+		requestLog.Info("filtered")
+		assert.Empty(t, buf.String())
+		logger.SetLevel(config.LogInfo)
+	})
+}
+
+// TestFormatFields tests pairing and quoting fields
+func TestFormatFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []any
+		want   string
+	}{
+		{"none", nil, ""},
+		{"pairs", []any{"a", 1, "b", true}, " a=1 b=true"},
+		{"quoted", []any{"empty", "", "eq", "a=b", "quote", `say "hi"`}, ` empty="" eq="a=b" quote="say \"hi\""`},
+		{"missing value", []any{"a", 1, "b"}, " a=1 !BADKEY=b"},
+		{"missing key", []any{42, "a", 1}, " !BADKEY=42 a=1"},
+		{"attr", []any{slog.Int("n", 3), "a", 1}, " n=3 a=1"},
+		{"duration", []any{"took", 1500 * time.Millisecond}, " took=1.5s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatFields(tt.fields))
+		})
+	}
+}
+
+// TestLogger_JSON tests LOGGER_FORMAT=json output
+func TestLogger_JSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	initialize(config.LoggerConfig{
+		Level:       config.LogTrace,
+		Format:      config.LogJSON,
+		Output:      &stdout,
+		ErrorOutput: &stderr,
+	})
+	logger := instance.With("request_id", "abc")
+
+	logger.With("status", 200, "dur_ms", 45).Info("request %s", "completed")
+	logger.Trace("tracing")
+	logger.Critical("down")
+
+	decode := func(line string) map[string]any {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		return entry
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 2)
+
+	entry := decode(lines[0])
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "request completed", entry["msg"])
+	assert.Equal(t, "abc", entry["request_id"])
+	assert.Equal(t, float64(200), entry["status"])
+	assert.Equal(t, float64(45), entry["dur_ms"])
+	assert.NotEmpty(t, entry["time"])
+
+	assert.Equal(t, "trace", decode(lines[1])["level"])
+
+	entry = decode(strings.TrimSpace(stderr.String()))
+	assert.Equal(t, "critical", entry["level"])
+	assert.Equal(t, "down", entry["msg"])
+}
+
+// TestLogger_Slog tests logging through the slog adapter
+func TestLogger_Slog(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(config.LoggerConfig{Level: config.LogInfo, Output: &buf, ErrorOutput: &buf})
+
+	s := l.With("app", "shop").Slog()
+	s.Debug("filtered")
+	s.With("client", "http").WithGroup("req").Info("sent", "method", "GET", slog.Int("status", 200))
+	s.Error("failed", "err", assert.AnError)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "INFO: ")
+	assert.True(t, strings.HasSuffix(lines[0], "sent app=shop client=http req.method=GET req.status=200"), lines[0])
+	assert.Contains(t, lines[1], "ERROR: ")
+	assert.Contains(t, lines[1], "failed app=shop err=")
+
+	assert.False(t, s.Enabled(context.Background(), slog.LevelDebug))
+	l.SetLevel(config.LogDebug)
+	assert.True(t, s.Enabled(context.Background(), slog.LevelDebug))
+}
+
+// TestToLogLevel tests mapping slog levels to log levels
+func TestToLogLevel(t *testing.T) {
+	assert.Equal(t, config.LogTrace, toLogLevel(slog.LevelDebug-4))
+	assert.Equal(t, config.LogDebug, toLogLevel(slog.LevelDebug))
+	assert.Equal(t, config.LogInfo, toLogLevel(slog.LevelInfo))
+	assert.Equal(t, config.LogInfo, toLogLevel(slog.LevelInfo+2))
+	assert.Equal(t, config.LogWarn, toLogLevel(slog.LevelWarn))
+	assert.Equal(t, config.LogError, toLogLevel(slog.LevelError))
+	assert.Equal(t, config.LogCritical, toLogLevel(slog.LevelError+4))
+}
+
+// TestLogger_UseHandler tests sending entries to an external handler
+func TestLogger_UseHandler(t *testing.T) {
+	var text, external bytes.Buffer
+	l := New(config.LoggerConfig{Level: config.LogInfo, Output: &text, ErrorOutput: &text})
+	l.UseHandler(slog.NewTextHandler(&external, &slog.HandlerOptions{Level: slog.LevelWarn, AddSource: true}))
+
+	l.With("user", 7).Warn("slow %s", "request")
+	l.Info("below the handler's level")
+	l.Slog().Error("from slog", "n", 1)
+
+	assert.Empty(t, text.String())
+	output := external.String()
+	assert.Contains(t, output, `level=WARN source=`)
+	assert.Contains(t, output, `logger_test.go:`)
+	assert.Contains(t, output, `msg="slow request" user=7`)
+	assert.Contains(t, output, `msg="from slog" n=1`)
+	assert.NotContains(t, output, "below")
+}
+
+// setNow fixes the time seen by sampling and throttles
+func setNow(t *testing.T, start time.Time) *time.Time {
+	t.Helper()
+	current := start
+	now = func() time.Time { return current }
+	t.Cleanup(func() { now = time.Now })
+	return &current
+}
+
+// TestLogger_SetSampling tests logging the first entries, then one in every M
+func TestLogger_SetSampling(t *testing.T) {
+	clock := setNow(t, time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC))
+	var buf bytes.Buffer
+	l := New(config.LoggerConfig{Level: config.LogInfo, Output: &buf, ErrorOutput: &buf})
+	l.SetSampling(config.LogError, Sampling{First: 2, Thereafter: 3, Window: time.Minute})
+
+	for i := 1; i <= 8; i++ {
+		l.Error("retry %d failed", i)
+		l.Info("info %d", i)
+	}
+
+	output := buf.String()
+	assert.Equal(t, 8, strings.Count(output, "INFO: "), "other levels aren't sampled")
+	for i, logged := range []bool{true, true, false, false, true, false, false, true} {
+		assert.Equal(t, logged, strings.Contains(output, fmt.Sprintf("retry %d failed", i+1)), i+1)
+	}
+
+	t.Run("new window", func(t *testing.T) {
+		buf.Reset()
+		*clock = clock.Add(time.Minute)
+		l.With("a", 1).Error("retry %d failed", 9)
+		assert.Contains(t, buf.String(), "retry 9 failed")
+	})
+
+	t.Run("turned off", func(t *testing.T) {
+		buf.Reset()
+		l.SetSampling(config.LogError, Sampling{})
+		for range 5 {
+			l.Error("unsampled")
+		}
+		assert.Equal(t, 5, strings.Count(buf.String(), "unsampled"))
+	})
+}
+
+// TestLogger_Throttle tests writing one entry per interval for a key
+func TestLogger_Throttle(t *testing.T) {
+	clock := setNow(t, time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC))
+	var buf bytes.Buffer
+	l := New(config.LoggerConfig{Level: config.LogInfo, Output: &buf, ErrorOutput: &buf})
+
+	for range 4 {
+		l.Throttle("payments", time.Minute).Error("payments down")
+		l.Throttle("search", time.Minute).Warn("search slow")
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), "payments down"))
+	assert.Equal(t, 1, strings.Count(buf.String(), "search slow"))
+
+	buf.Reset()
+	*clock = clock.Add(time.Minute)
+	l.Throttle("payments", time.Minute).With("attempt", 5).Error("payments down")
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(buf.String()), "payments down attempt=5 suppressed=3"), buf.String())
+
+	buf.Reset()
+	l.Throttle("payments", time.Minute).Slog().Error("from slog")
+	assert.Empty(t, buf.String(), "slog entries share the throttle")
+}
@@ -0,0 +1,244 @@
+// Package rotate provides a log file that rotates by size and age, keeping a
+// limited number of optionally compressed backups.
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat stamps backups, sorting in time order and safe in file
+// names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Options control when a File rotates and which backups it keeps. Zero
+// values turn each one off.
+type Options struct {
+	// MaxSize rotates the file before a write would take it past this many
+	// bytes
+	MaxSize int64
+	// MaxAge rotates the file once it has been written to for this long
+	MaxAge time.Duration
+	// MaxBackups is how many rotated files are kept, newest first
+	MaxBackups int
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// File is an io.WriteCloser appending to a log file. When it rotates, the
+// file is renamed with a timestamp, e.g. app-2025-01-02T15-04-05.000.log,
+// and a new one is started. It is safe for concurrent use.
+type File struct {
+	path string
+	opts Options
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// Open opens path for appending, creating it if needed
+func Open(path string, opts Options) (*File, error) {
+	f := &File{path: path, opts: opts, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write appends p, rotating first if the file is due
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.due(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate starts a new file now, e.g. on a signal from an external scheduler
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// Close closes the file. A later Write opens it again.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the file, carrying on from its current size
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = f.now()
+	return nil
+}
+
+// due reports whether the file should rotate before writing n bytes. An
+// empty file never rotates, so a single large write still lands somewhere.
+func (f *File) due(n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.opts.MaxSize > 0 && f.size+int64(n) > f.opts.MaxSize {
+		return true
+	}
+	return f.opts.MaxAge > 0 && f.now().Sub(f.opened) >= f.opts.MaxAge
+}
+
+// rotate renames the file to a backup, opens a new one and tidies the
+// backups
+func (f *File) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+
+	ext := filepath.Ext(f.path)
+	stamp := f.now()
+	backup := strings.TrimSuffix(f.path, ext) + "-" + stamp.Format(backupTimeFormat) + ext
+	// Rotating twice in a millisecond moves the later stamp on, rather than
+	// overwriting the earlier backup
+	for exists(backup) || exists(backup+".gz") {
+		stamp = stamp.Add(time.Millisecond)
+		backup = strings.TrimSuffix(f.path, ext) + "-" + stamp.Format(backupTimeFormat) + ext
+	}
+	if err := os.Rename(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.tidy()
+}
+
+// tidy compresses backups if asked and removes those past MaxBackups
+func (f *File) tidy() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		if f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups {
+			if err := os.Remove(backup); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.opts.Compress && !strings.HasSuffix(backup, ".gz") {
+			if err := compress(backup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backups lists the file's backups, newest first
+func (f *File) backups() ([]string, error) {
+	dir := filepath.Dir(f.path)
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []string{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gz")
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, entry.Name()))
+	}
+
+	// The timestamps sort in time order
+	slices.SortFunc(backups, func(a, b string) int {
+		return strings.Compare(strings.TrimSuffix(b, ".gz"), strings.TrimSuffix(a, ".gz"))
+	})
+	return backups, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// compress replaces path with path.gz
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,174 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTest opens a File in a temporary directory with a controllable clock
+func openTest(t *testing.T, opts Options) (*File, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	f, err := Open(filepath.Join(t.TempDir(), "app.log"), opts)
+	require.NoError(t, err)
+	f.now = func() time.Time { return now }
+	f.opened = now
+	t.Cleanup(func() { f.Close() })
+	return f, &now
+}
+
+// files lists the names in the File's directory
+func files(t *testing.T, f *File) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	require.NoError(t, err)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+// TestFile_MaxSize tests rotating before the file grows too large
+func TestFile_MaxSize(t *testing.T) {
+	f, now := openTest(t, Options{MaxSize: 10})
+
+	_, err := f.Write([]byte("12345\n"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("123\n"))
+	require.NoError(t, err)
+	*now = now.Add(time.Second)
+	_, err = f.Write([]byte("next\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"app-2025-01-02T15-04-06.000.log", "app.log"}, files(t, f))
+	assert.Equal(t, "12345\n123\n", read(t, filepath.Join(filepath.Dir(f.path), "app-2025-01-02T15-04-06.000.log")))
+	assert.Equal(t, "next\n", read(t, f.path))
+}
+
+// TestFile_LargeWrite tests that a write bigger than MaxSize still lands
+func TestFile_LargeWrite(t *testing.T) {
+	f, _ := openTest(t, Options{MaxSize: 4})
+
+	_, err := f.Write([]byte("too large\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"app.log"}, files(t, f))
+	assert.Equal(t, "too large\n", read(t, f.path))
+}
+
+// TestFile_MaxAge tests rotating an old file
+func TestFile_MaxAge(t *testing.T) {
+	f, now := openTest(t, Options{MaxAge: time.Hour})
+
+	_, err := f.Write([]byte("first\n"))
+	require.NoError(t, err)
+	*now = now.Add(30 * time.Minute)
+	_, err = f.Write([]byte("second\n"))
+	require.NoError(t, err)
+	assert.Len(t, files(t, f), 1)
+
+	*now = now.Add(30 * time.Minute)
+	_, err = f.Write([]byte("third\n"))
+	require.NoError(t, err)
+	assert.Len(t, files(t, f), 2)
+	assert.Equal(t, "third\n", read(t, f.path))
+}
+
+// TestFile_MaxBackups tests removing the oldest backups
+func TestFile_MaxBackups(t *testing.T) {
+	f, now := openTest(t, Options{MaxBackups: 2})
+
+	for range 4 {
+		_, err := f.Write([]byte("line\n"))
+		require.NoError(t, err)
+		*now = now.Add(time.Second)
+		require.NoError(t, f.Rotate())
+	}
+
+	assert.Equal(t, []string{
+		"app-2025-01-02T15-04-08.000.log",
+		"app-2025-01-02T15-04-09.000.log",
+		"app.log",
+	}, files(t, f))
+}
+
+// TestFile_Compress tests gzipping backups
+func TestFile_Compress(t *testing.T) {
+	f, _ := openTest(t, Options{Compress: true})
+
+	_, err := f.Write([]byte("compressed\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Rotate())
+
+	assert.Equal(t, []string{"app-2025-01-02T15-04-05.000.log.gz", "app.log"}, files(t, f))
+
+	file, err := os.Open(filepath.Join(filepath.Dir(f.path), "app-2025-01-02T15-04-05.000.log.gz"))
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "compressed\n", string(content))
+}
+
+// TestFile_Append tests continuing an existing file
+func TestFile_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0644))
+
+	f, err := Open(path, Options{MaxSize: 12})
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("new\n"))
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "the existing size counts toward MaxSize")
+	assert.Equal(t, "new\n", read(t, path))
+}
+
+// TestFile_Close tests reopening after Close
+func TestFile_Close(t *testing.T) {
+	f, _ := openTest(t, Options{})
+
+	require.NoError(t, f.Close())
+	require.NoError(t, f.Close())
+	_, err := f.Write([]byte("reopened\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "reopened\n", read(t, f.path))
+}
+
+// TestFile_SameMillisecond tests that quick rotations keep every backup
+func TestFile_SameMillisecond(t *testing.T) {
+	f, _ := openTest(t, Options{})
+
+	for range 2 {
+		_, err := f.Write([]byte("line\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Rotate())
+	}
+
+	assert.Equal(t, []string{
+		"app-2025-01-02T15-04-05.000.log",
+		"app-2025-01-02T15-04-05.001.log",
+		"app.log",
+	}, files(t, f))
+}
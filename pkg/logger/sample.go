@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// now is replaced in tests
+var now = time.Now
+
+// Sampling limits how often the same message is logged at a level. Within
+// each Window, the first First entries with a given format string are
+// written, then one in every Thereafter; the rest are dropped. A zero
+// Thereafter drops everything after the first First.
+type Sampling struct {
+	First      int
+	Thereafter int
+	Window     time.Duration
+}
+
+// sampler counts entries per level and format string
+type sampler struct {
+	mu       sync.Mutex
+	policies map[config.LogLevel]Sampling
+	counts   map[sampleKey]*sampleCount
+
+	throttles map[string]*throttle
+}
+
+type sampleKey struct {
+	level  config.LogLevel
+	format string
+}
+
+type sampleCount struct {
+	start time.Time
+	n     int
+}
+
+// throttle tracks one key passed to Throttle
+type throttle struct {
+	last       time.Time
+	suppressed int
+}
+
+// SetSampling samples entries at level, for this logger and every logger
+// sharing its level. The zero Sampling turns sampling off:
+//
+//	logger.Get().SetSampling(config.LogError, logger.Sampling{First: 10, Thereafter: 100, Window: time.Minute})
+func (l *Logger) SetSampling(level config.LogLevel, s Sampling) {
+	l.sampler.mu.Lock()
+	defer l.sampler.mu.Unlock()
+
+	if s == (Sampling{}) {
+		delete(l.sampler.policies, level)
+	} else {
+		l.sampler.policies[level] = s
+	}
+	for key := range l.sampler.counts {
+		if key.level == level {
+			delete(l.sampler.counts, key)
+		}
+	}
+}
+
+// sampled reports whether an entry with format passes level's sampling
+func (s *sampler) sampled(level config.LogLevel, format string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy, ok := s.policies[level]
+	if !ok {
+		return true
+	}
+
+	t := now()
+	key := sampleKey{level, format}
+	count := s.counts[key]
+	if count == nil || (policy.Window > 0 && t.Sub(count.start) >= policy.Window) {
+		count = &sampleCount{start: t}
+		s.counts[key] = count
+	}
+	count.n++
+
+	if count.n <= policy.First {
+		return true
+	}
+	return policy.Thereafter > 0 && (count.n-policy.First)%policy.Thereafter == 0
+}
+
+// Throttle returns a logger that writes at most one entry per interval for
+// key, across every logger sharing this one's level. Dropped entries are
+// counted in a suppressed field on the next one written, so a retry loop or
+// a failing downstream can't flood the logs:
+//
+//	for {
+//	    if err := charge(); err != nil {
+//	        log.Throttle("payments-down", time.Minute).Error("charging card: %v", err)
+//	    }
+//	}
+func (l *Logger) Throttle(key string, interval time.Duration) *Logger {
+	throttled := l.With()
+	throttled.throttle = &throttleKey{key: key, interval: interval}
+	return throttled
+}
+
+// Throttle returns the singleton logger throttled to one entry per interval
+// for key. See (*Logger).Throttle.
+func Throttle(key string, interval time.Duration) *Logger {
+	return Get().Throttle(key, interval)
+}
+
+// throttleKey is the key and interval a Logger from Throttle checks
+type throttleKey struct {
+	key      string
+	interval time.Duration
+}
+
+// allow reports whether an entry for k may be written now, and how many were
+// dropped since the last one
+func (s *sampler) allow(k *throttleKey) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	state := s.throttles[k.key]
+	if state == nil {
+		s.throttles[k.key] = &throttle{last: t}
+		return true, 0
+	}
+	if t.Sub(state.last) < k.interval {
+		state.suppressed++
+		return false, 0
+	}
+
+	suppressed := state.suppressed
+	state.last, state.suppressed = t, 0
+	return true, suppressed
+}
+
+func newSampler() *sampler {
+	return &sampler{
+		policies:  map[config.LogLevel]Sampling{},
+		counts:    map[sampleKey]*sampleCount{},
+		throttles: map[string]*throttle{},
+	}
+}
@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// Slog returns a *slog.Logger that writes through the singleton logger. See
+// (*Logger).Slog.
+func Slog() *slog.Logger {
+	return Get().Slog()
+}
+
+// Slog returns a *slog.Logger that writes through l, with l's level, format,
+// outputs and fields. Pass it to libraries that accept a *slog.Logger, or
+// make it the default so the slog and log packages write through l too:
+//
+//	slog.SetDefault(logger.Slog())
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(&bridge{logger: l})
+}
+
+// UseHandler sends the singleton logger's entries to h instead of encoding
+// them as text or JSON, e.g. to ship them with an OpenTelemetry or Sentry
+// handler. See (*Logger).UseHandler.
+func UseHandler(h slog.Handler) {
+	Get().UseHandler(h)
+}
+
+// UseHandler sends entries to h instead of encoding them as text or JSON.
+// LOGGER_LEVEL still applies, and entries h doesn't enable are dropped. It
+// affects every logger sharing l's level. Don't pass the handler of a logger
+// returned by Slog, or entries loop back to h.
+func (l *Logger) UseHandler(h slog.Handler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handler, l.errorHandler = h, h
+}
+
+// handlerFor returns the handler for entries at level, or nil to write text
+func (l *Logger) handlerFor(level config.LogLevel) slog.Handler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if level >= config.LogError {
+		return l.errorHandler
+	}
+	return l.handler
+}
+
+// handle sends an entry logged at t from pc to h
+func (l *Logger) handle(h slog.Handler, level config.LogLevel, msg string, fields []any, t time.Time, pc uintptr) {
+	ctx := context.Background()
+	if !h.Enabled(ctx, slogLevels[level]) {
+		return
+	}
+
+	record := slog.NewRecord(t, slogLevels[level], msg, pc)
+	record.Add(fields...)
+	h.Handle(ctx, record)
+}
+
+// callerPC returns the program counter of the caller of the logging method
+func callerPC() uintptr {
+	// Skip runtime.Callers, callerPC, write and the logging method
+	var pcs [1]uintptr
+	runtime.Callers(4, pcs[:])
+	return pcs[0]
+}
+
+// toLogLevel returns the level a slog level is logged at
+func toLogLevel(level slog.Level) config.LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return config.LogTrace
+	case level < slog.LevelInfo:
+		return config.LogDebug
+	case level < slog.LevelWarn:
+		return config.LogInfo
+	case level < slog.LevelError:
+		return config.LogWarn
+	case level < slogLevels[config.LogCritical]:
+		return config.LogError
+	}
+	return config.LogCritical
+}
+
+// bridge is a slog.Handler that writes records through a Logger
+type bridge struct {
+	logger *Logger
+	// group prefixes the keys of attributes added after WithGroup
+	group string
+}
+
+func (b *bridge) Enabled(_ context.Context, level slog.Level) bool {
+	return toLogLevel(level) >= b.logger.Level() || toLogLevel(level) == config.LogCritical
+}
+
+func (b *bridge) Handle(_ context.Context, record slog.Record) error {
+	fields := make([]any, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		fields = append(fields, b.prefix(a))
+		return true
+	})
+
+	level := toLogLevel(record.Level)
+	l := b.logger.With(fields...)
+	fields, ok := l.admit(level, record.Message)
+	if !ok {
+		return nil
+	}
+	if h := l.handlerFor(level); h != nil {
+		l.handle(h, level, record.Message, fields, record.Time, record.PC)
+		return nil
+	}
+	l.textLogger(level).Output(2, record.Message+formatFields(fields))
+	return nil
+}
+
+func (b *bridge) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]any, len(attrs))
+	for i, a := range attrs {
+		fields[i] = b.prefix(a)
+	}
+	return &bridge{logger: b.logger.With(fields...), group: b.group}
+}
+
+func (b *bridge) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return b
+	}
+	return &bridge{logger: b.logger, group: b.group + name + "."}
+}
+
+// prefix adds the group to a's key
+func (b *bridge) prefix(a slog.Attr) slog.Attr {
+	a.Key = b.group + a.Key
+	return a
+}
+
+// textLogger returns the text logger for level
+func (c *core) textLogger(level config.LogLevel) *log.Logger {
+	switch level {
+	case config.LogTrace:
+		return c.traceLogger
+	case config.LogDebug:
+		return c.debugLogger
+	case config.LogInfo:
+		return c.infoLogger
+	case config.LogWarn:
+		return c.warnLogger
+	case config.LogError:
+		return c.errorLogger
+	}
+	return c.criticalLogger
+}
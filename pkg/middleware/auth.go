@@ -1,12 +1,22 @@
 package middleware
 
 import (
+	"context"
+	stderrors "errors"
+
 	"github.com/cstone-io/twine/pkg/auth"
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
 	"github.com/cstone-io/twine/pkg/kit"
 )
 
-// JWTMiddleware validates JWT tokens and auto-redirects on failure
+// JWTMiddleware validates JWT tokens and auto-redirects on failure,
+// including for tokens revoked with auth.Revoke. The user ID is in
+// k.GetContext("user"), and every claim in
+// auth.ClaimsFromContext(k.Request.Context()).
 func JWTMiddleware() Middleware {
+	config.Require(config.FeatureAuth)
+
 	return func(next kit.HandlerFunc) kit.HandlerFunc {
 		return func(k *kit.Kit) error {
 			token, err := k.Authorization()
@@ -14,12 +24,146 @@ func JWTMiddleware() Middleware {
 				return k.Redirect("/auth/login")
 			}
 
-			userID, err := auth.ParseToken(token)
+			claims, err := auth.ParseToken(token)
+			if err != nil {
+				return k.Redirect("/auth/login")
+			}
+			revoked, err := auth.IsRevoked(k.Request.Context(), claims.ID)
+			if err != nil {
+				return err
+			}
+			if revoked {
+				return k.Redirect("/auth/login")
+			}
+
+			k.Request = k.Request.WithContext(auth.ContextWithClaims(k.Request.Context(), claims))
+			k.SetContext("user", claims.UserID)
+			return next(k)
+		}
+	}
+}
+
+// SessionMiddleware requires a session started with auth.Login, redirecting
+// to the login page without one. The user ID is in k.GetContext("user").
+func SessionMiddleware() Middleware {
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			userID, err := auth.CurrentUser(k)
+			if stderrors.Is(err, errors.ErrAuthNoSession) {
+				return k.Redirect("/auth/login")
+			}
+			if err != nil {
+				return err
+			}
+
+			k.SetContext("user", userID.String())
+			return next(k)
+		}
+	}
+}
+
+// UserLoader fetches the user record for a user ID, for LoadUser
+type UserLoader interface {
+	LoadUser(ctx context.Context, id string) (any, error)
+}
+
+// UserLoaderFunc is a function that is a UserLoader
+type UserLoaderFunc func(ctx context.Context, id string) (any, error)
+
+// LoadUser calls f
+func (f UserLoaderFunc) LoadUser(ctx context.Context, id string) (any, error) {
+	return f(ctx, id)
+}
+
+// StoreLoader loads users with a store's Get, such as that of a
+// database.CRUDStore[models.User], with preloads:
+//
+//	middleware.LoadUser(middleware.StoreLoader(users.Get, "Roles"))
+func StoreLoader[T any](get func(ctx context.Context, id string, preloads ...string) (*T, error), preloads ...string) UserLoader {
+	return UserLoaderFunc(func(ctx context.Context, id string) (any, error) {
+		user, err := get(ctx, id, preloads...)
+		if err != nil || user == nil {
+			return nil, err
+		}
+		return user, nil
+	})
+}
+
+// LoadUser fetches the record of the user in k.GetContext("user") from
+// store once per request, for handlers to read with kit.CurrentUser. Use it
+// after JWTMiddleware or SessionMiddleware:
+//
+//	account := router.NewRouter("/account")
+//	account.Use(middleware.SessionMiddleware(), middleware.LoadUser(middleware.StoreLoader(users.Get)))
+//
+//	user, _ := kit.CurrentUser[*models.User](k)
+//
+// Requests without a user pass through. Users the store can't find, with
+// errors.ErrNotFound or ErrDatabaseObjectNotFound or a nil record, were
+// deleted since signing in and are redirected to the login page.
+func LoadUser(store UserLoader) Middleware {
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			id := k.GetContext("user")
+			if id == "" {
+				return next(k)
+			}
+			if _, ok := kit.CurrentUser[any](k); ok {
+				return next(k)
+			}
+
+			user, err := store.LoadUser(k.Request.Context(), id)
+			if stderrors.Is(err, errors.ErrNotFound) || stderrors.Is(err, errors.ErrDatabaseObjectNotFound) ||
+				(err == nil && user == nil) {
+				return k.Redirect("/auth/login")
+			}
 			if err != nil {
+				return err
+			}
+
+			k.SetUser(user)
+			return next(k)
+		}
+	}
+}
+
+// RequireRole allows only users with role, checked by the kit.Authorizer.
+// Use it after JWTMiddleware or SessionMiddleware:
+//
+//	admin := router.NewRouter("/admin")
+//	admin.Use(middleware.SessionMiddleware(), middleware.RequireRole("admin"))
+//
+// Requests without a user are redirected to the login page, and users
+// without the role get errors.ErrInsufficientPermissions.
+func RequireRole(role string) Middleware {
+	return requireUser(func(k *kit.Kit) (bool, error) {
+		return k.CheckRole(role)
+	})
+}
+
+// RequirePermission allows only users with permission, such as
+// "orders:write", checked by the kit.Authorizer like RequireRole
+func RequirePermission(permission string) Middleware {
+	return requireUser(func(k *kit.Kit) (bool, error) {
+		return k.CheckPermission(permission)
+	})
+}
+
+// requireUser allows requests by a user for whom allowed is true
+func requireUser(allowed func(k *kit.Kit) (bool, error)) Middleware {
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			if k.GetContext("user") == "" {
 				return k.Redirect("/auth/login")
 			}
 
-			k.SetContext("user", userID)
+			ok, err := allowed(k)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.ErrInsufficientPermissions
+			}
 			return next(k)
 		}
 	}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
+	stderrors "errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/google/uuid"
@@ -11,6 +14,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/cstone-io/twine/pkg/auth"
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
 	"github.com/cstone-io/twine/pkg/kit"
 )
 
@@ -33,6 +38,17 @@ func setupTestAuth(t *testing.T) func() {
 	}
 }
 
+// TestJWTMiddleware_RequiresSecret tests that using the middleware makes
+// AUTH_SECRET required
+func TestJWTMiddleware_RequiresSecret(t *testing.T) {
+	JWTMiddleware()
+
+	cfg := &config.Config{Server: config.ServerConfig{Port: "3000"}}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AUTH_SECRET: is required")
+}
+
 // TestJWTMiddleware tests JWT authentication middleware
 func TestJWTMiddleware(t *testing.T) {
 	cleanup := setupTestAuth(t)
@@ -141,6 +157,29 @@ func TestJWTMiddleware(t *testing.T) {
 		assert.Equal(t, "/auth/login", w.Header().Get("Location"))
 	})
 
+	t.Run("redirects on revoked token", func(t *testing.T) {
+		auth.UseRevocationStore(auth.NewMemoryRevocationStore())
+		defer auth.ResetRevocationStore()
+		token, err := auth.NewToken(uuid.New(), "test@example.com")
+		require.NoError(t, err)
+		require.NoError(t, auth.RevokeToken(context.Background(), token.Token))
+
+		handlerCalled := false
+		wrapped := JWTMiddleware()(func(k *kit.Kit) error {
+			handlerCalled = true
+			return k.Text(200, "ok")
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/protected", nil)
+		r.Header.Set("Authorization", "Bearer "+token.Token)
+
+		require.NoError(t, wrapped(&kit.Kit{Response: w, Request: r}))
+		assert.False(t, handlerCalled)
+		assert.Equal(t, 303, w.Code)
+		assert.Equal(t, "/auth/login", w.Header().Get("Location"))
+	})
+
 	t.Run("sets user context on success", func(t *testing.T) {
 		userID := uuid.New()
 		token, err := auth.NewToken(userID, "test@example.com")
@@ -167,6 +206,34 @@ func TestJWTMiddleware(t *testing.T) {
 		assert.Equal(t, userID.String(), capturedUserID)
 	})
 
+	t.Run("sets claims context on success", func(t *testing.T) {
+		userID := uuid.New()
+		token, err := auth.NewToken(userID, "test@example.com", auth.WithRoles("admin"))
+		require.NoError(t, err)
+
+		var captured *auth.Claims
+
+		mw := JWTMiddleware()
+		handler := func(k *kit.Kit) error {
+			captured = auth.ClaimsFromContext(k.Request.Context())
+			return k.Text(200, "ok")
+		}
+
+		wrapped := mw(handler)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token.Token)
+
+		k := &kit.Kit{Response: w, Request: r}
+
+		err = wrapped(k)
+		require.NoError(t, err)
+		require.NotNil(t, captured)
+		assert.Equal(t, userID.String(), captured.UserID)
+		assert.True(t, captured.HasRole("admin"))
+	})
+
 	t.Run("handles Ajax redirect on auth failure", func(t *testing.T) {
 		mw := JWTMiddleware()
 		handler := func(k *kit.Kit) error {
@@ -305,3 +372,181 @@ func TestJWTMiddleware_Integration(t *testing.T) {
 		assert.Equal(t, user2ID.String(), w2.Body.String())
 	})
 }
+
+// TestSessionMiddleware tests requiring a session started with auth.Login
+func TestSessionMiddleware(t *testing.T) {
+	auth.UseSessionStore(auth.NewMemorySessionStore())
+	defer auth.ResetSessionStore()
+
+	userID := uuid.New()
+	w := httptest.NewRecorder()
+	login := &kit.Kit{Response: w, Request: httptest.NewRequest("POST", "/auth/login", nil)}
+	require.NoError(t, auth.Login(login, userID))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	wrapped := SessionMiddleware()(func(k *kit.Kit) error {
+		return k.Text(200, k.GetContext("user"))
+	})
+
+	t.Run("sets user context with a session", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(cookies[0])
+
+		require.NoError(t, wrapped(&kit.Kit{Response: w, Request: r}))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, userID.String(), w.Body.String())
+	})
+
+	t.Run("redirects without a session", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(&http.Cookie{Name: kit.SessionCookie, Value: "forged"})
+
+		require.NoError(t, wrapped(&kit.Kit{Response: w, Request: r}))
+		assert.Equal(t, 303, w.Code)
+		assert.Equal(t, "/auth/login", w.Header().Get("Location"))
+	})
+}
+
+// stubAuthorizer grants fixed roles and permissions to every user
+type stubAuthorizer struct {
+	roles       []string
+	permissions []string
+	err         error
+}
+
+func (a stubAuthorizer) HasRole(_ *kit.Kit, role string) (bool, error) {
+	return slices.Contains(a.roles, role), a.err
+}
+
+func (a stubAuthorizer) Can(_ *kit.Kit, permission string) (bool, error) {
+	return slices.Contains(a.permissions, permission), a.err
+}
+
+// TestRequireRole tests allowing only users the authorizer grants a role
+// or permission
+func TestRequireRole(t *testing.T) {
+	authorizer := kit.DefaultAuthorizer()
+	defer kit.UseAuthorizer(authorizer)
+	kit.UseAuthorizer(stubAuthorizer{roles: []string{"admin"}, permissions: []string{"orders:write"}})
+
+	handler := func(k *kit.Kit) error {
+		return k.Text(200, "ok")
+	}
+	serve := func(mw Middleware, user string) (*httptest.ResponseRecorder, error) {
+		w := httptest.NewRecorder()
+		k := &kit.Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+		if user != "" {
+			k.SetContext("user", user)
+		}
+		return w, mw(handler)(k)
+	}
+
+	t.Run("allows users with the role or permission", func(t *testing.T) {
+		for _, mw := range []Middleware{RequireRole("admin"), RequirePermission("orders:write")} {
+			w, err := serve(mw, "u-1")
+			require.NoError(t, err)
+			assert.Equal(t, 200, w.Code)
+		}
+	})
+
+	t.Run("rejects users without them", func(t *testing.T) {
+		for _, mw := range []Middleware{RequireRole("owner"), RequirePermission("orders:delete")} {
+			_, err := serve(mw, "u-1")
+			assert.ErrorIs(t, err, errors.ErrInsufficientPermissions)
+		}
+	})
+
+	t.Run("redirects requests without a user", func(t *testing.T) {
+		w, err := serve(RequireRole("admin"), "")
+		require.NoError(t, err)
+		assert.Equal(t, 303, w.Code)
+		assert.Equal(t, "/auth/login", w.Header().Get("Location"))
+	})
+
+	t.Run("returns authorizer errors", func(t *testing.T) {
+		failure := stderrors.New("database down")
+		kit.UseAuthorizer(stubAuthorizer{err: failure})
+		_, err := serve(RequirePermission("orders:write"), "u-1")
+		assert.ErrorIs(t, err, failure)
+	})
+}
+
+// TestLoadUser tests loading the user record once per request
+func TestLoadUser(t *testing.T) {
+	type user struct{ ID string }
+	users := map[string]*user{"u-1": {ID: "u-1"}}
+	loads := 0
+	store := UserLoaderFunc(func(_ context.Context, id string) (any, error) {
+		loads++
+		if id == "broken" {
+			return nil, stderrors.New("database down")
+		}
+		u, ok := users[id]
+		if !ok {
+			return nil, errors.ErrDatabaseObjectNotFound
+		}
+		return u, nil
+	})
+
+	var loaded *user
+	handler := func(k *kit.Kit) error {
+		loaded, _ = kit.CurrentUser[*user](k)
+		return k.Text(200, "ok")
+	}
+	serve := func(mw Middleware, id string) (*httptest.ResponseRecorder, error) {
+		loaded, loads = nil, 0
+		w := httptest.NewRecorder()
+		k := &kit.Kit{Response: w, Request: httptest.NewRequest("GET", "/", nil)}
+		if id != "" {
+			k.SetContext("user", id)
+		}
+		return w, mw(handler)(k)
+	}
+
+	t.Run("stores the user for the handler", func(t *testing.T) {
+		_, err := serve(Chain(LoadUser(store), LoadUser(store)), "u-1")
+		require.NoError(t, err)
+		assert.Same(t, users["u-1"], loaded)
+		assert.Equal(t, 1, loads, "loaded once per request")
+	})
+
+	t.Run("passes requests without a user", func(t *testing.T) {
+		w, err := serve(LoadUser(store), "")
+		require.NoError(t, err)
+		assert.Equal(t, 200, w.Code)
+		assert.Nil(t, loaded)
+		assert.Zero(t, loads)
+	})
+
+	t.Run("redirects deleted users", func(t *testing.T) {
+		w, err := serve(LoadUser(store), "u-2")
+		require.NoError(t, err)
+		assert.Equal(t, 303, w.Code)
+		assert.Equal(t, "/auth/login", w.Header().Get("Location"))
+	})
+
+	t.Run("returns store errors", func(t *testing.T) {
+		_, err := serve(LoadUser(store), "broken")
+		assert.EqualError(t, err, "database down")
+	})
+
+	t.Run("loads with a store's Get", func(t *testing.T) {
+		var preloaded []string
+		get := func(_ context.Context, id string, preloads ...string) (*user, error) {
+			preloaded = preloads
+			return users[id], nil
+		}
+
+		_, err := serve(LoadUser(StoreLoader(get, "Roles")), "u-1")
+		require.NoError(t, err)
+		assert.Same(t, users["u-1"], loaded)
+		assert.Equal(t, []string{"Roles"}, preloaded)
+
+		w, err := serve(LoadUser(StoreLoader(get)), "u-2")
+		require.NoError(t, err)
+		assert.Equal(t, 303, w.Code, "a nil record is a deleted user")
+	})
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strconv"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// DefaultBasicAuthRealm is the realm browsers show in the sign-in prompt
+// without WithRealm
+const DefaultBasicAuthRealm = "Restricted"
+
+// basicAuth checks the credentials of a BasicAuth or BasicAuthFunc
+// middleware
+type basicAuth struct {
+	validate func(user, password string) bool
+	realm    string
+}
+
+// BasicAuthOption configures a middleware returned by BasicAuth or
+// BasicAuthFunc
+type BasicAuthOption func(*basicAuth)
+
+// WithRealm names the protected area in the browser's sign-in prompt,
+// instead of DefaultBasicAuthRealm
+func WithRealm(realm string) BasicAuthOption {
+	return func(b *basicAuth) {
+		b.realm = realm
+	}
+}
+
+// BasicAuth requires HTTP basic authentication with one of users, a map
+// of user names to passwords, such as to keep a staging environment or an
+// internal tool private before real authentication is wired up. User names
+// and passwords are compared in constant time. Requests without valid credentials get
+// errors.ErrAuthInvalidCredentials, a 401 asking the browser to prompt.
+// The user name is in k.GetContext("user").
+//
+//	r.Use(middleware.BasicAuth(map[string]string{"staging": os.Getenv("STAGING_PASSWORD")}))
+//
+// Basic authentication sends the password with every request, so serve it
+// over HTTPS only.
+func BasicAuth(users map[string]string, opts ...BasicAuthOption) Middleware {
+	type credentials struct {
+		user, password [sha256.Size]byte
+	}
+	accounts := make([]credentials, 0, len(users))
+	for user, password := range users {
+		accounts = append(accounts, credentials{sha256.Sum256([]byte(user)), sha256.Sum256([]byte(password))})
+	}
+
+	return BasicAuthFunc(func(user, password string) bool {
+		// Every account is compared, user name and password, so a request
+		// takes as long whether or not it names a known user
+		gotUser := sha256.Sum256([]byte(user))
+		gotPassword := sha256.Sum256([]byte(password))
+		match := 0
+		for _, account := range accounts {
+			match |= subtle.ConstantTimeCompare(gotUser[:], account.user[:]) &
+				subtle.ConstantTimeCompare(gotPassword[:], account.password[:])
+		}
+		return match == 1
+	}, opts...)
+}
+
+// BasicAuthFunc requires HTTP basic authentication with credentials
+// validate accepts, such as ones checked against a hashed password.
+// validate should compare in constant time, as with
+// crypto/subtle.ConstantTimeCompare. It is otherwise the same as
+// BasicAuth.
+func BasicAuthFunc(validate func(user, password string) bool, opts ...BasicAuthOption) Middleware {
+	b := &basicAuth{validate: validate, realm: DefaultBasicAuthRealm}
+	for _, opt := range opts {
+		opt(b)
+	}
+	challenge := "Basic realm=" + strconv.Quote(b.realm) + `, charset="UTF-8"`
+
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			user, password, ok := k.Request.BasicAuth()
+			if !ok || !b.validate(user, password) {
+				k.Response.Header().Set("WWW-Authenticate", challenge)
+				return errors.ErrAuthInvalidCredentials
+			}
+			k.SetContext("user", user)
+			return next(k)
+		}
+	}
+}
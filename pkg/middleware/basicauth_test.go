@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// TestBasicAuth tests requiring basic authentication credentials
+func TestBasicAuth(t *testing.T) {
+	var user string
+	handler := BasicAuth(map[string]string{"staging": "s3cret", "ops": ""})(func(k *kit.Kit) error {
+		user = k.GetContext("user")
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		header   bool
+		allowed  bool
+	}{
+		{"valid", "staging", "s3cret", true, true},
+		{"wrong password", "staging", "secret", true, false},
+		{"unknown user", "admin", "s3cret", true, false},
+		{"another user's password", "ops", "s3cret", true, false},
+		{"empty password", "ops", "", true, true},
+		{"unknown user with empty password", "nobody", "", true, false},
+		{"no credentials", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user = ""
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header {
+				r.SetBasicAuth(tt.user, tt.password)
+			}
+			err := handler(&kit.Kit{Response: w, Request: r})
+			if !tt.allowed {
+				assert.ErrorIs(t, err, errors.ErrAuthInvalidCredentials)
+				assert.Equal(t, `Basic realm="Restricted", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+				assert.Empty(t, user)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.user, user)
+			assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+// TestBasicAuthFunc tests validating credentials with a function, in a
+// named realm
+func TestBasicAuthFunc(t *testing.T) {
+	handler := BasicAuthFunc(func(user, password string) bool {
+		return user == "ci" && password == "token"
+	}, WithRealm(`Internal "tools"`))(func(k *kit.Kit) error { return nil })
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("ci", "token")
+	assert.NoError(t, handler(&kit.Kit{Response: httptest.NewRecorder(), Request: r}))
+
+	w := httptest.NewRecorder()
+	r.SetBasicAuth("ci", "wrong")
+	assert.ErrorIs(t, handler(&kit.Kit{Response: w, Request: r}), errors.ErrAuthInvalidCredentials)
+	assert.Equal(t, `Basic realm="Internal \"tools\"", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+}
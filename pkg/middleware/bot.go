@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// DefaultBotUserAgents are the user agent substrings BotDetection refuses
+// form posts from: HTTP libraries and crawlers that no browser sends
+var DefaultBotUserAgents = []string{
+	"curl/", "wget/", "python-requests", "python-urllib", "aiohttp", "go-http-client",
+	"java/", "okhttp", "libwww-perl", "scrapy", "httpclient", "headlesschrome",
+	"phantomjs", "bot", "crawler", "spider",
+}
+
+// botDetector checks the form posts of a Honeypot or BotDetection
+// middleware
+type botDetector struct {
+	// field is the honeypot input, empty for none
+	field string
+	// header must be set on form posts, empty for none
+	header string
+	// userAgents are lower-case user agent substrings to refuse
+	userAgents []string
+	// emptyUserAgent refuses form posts without a User-Agent
+	emptyUserAgent bool
+	respond        kit.HandlerFunc
+}
+
+// BotOption configures a middleware returned by Honeypot or BotDetection
+type BotOption func(*botDetector)
+
+// WithBotHeader refuses form posts without header, which a script on the
+// page sets and simple bots don't, such as X-Alpine-Request for forms
+// submitted with Alpine Ajax. Forms must then be sent by the script, so
+// don't use it for forms that should work without JavaScript.
+func WithBotHeader(header string) BotOption {
+	return func(d *botDetector) {
+		d.header = header
+	}
+}
+
+// WithBotUserAgents refuses form posts whose User-Agent contains one of
+// patterns, ignoring case, instead of DefaultBotUserAgents
+func WithBotUserAgents(patterns ...string) BotOption {
+	return func(d *botDetector) {
+		d.userAgents = make([]string, len(patterns))
+		for i, pattern := range patterns {
+			d.userAgents[i] = strings.ToLower(pattern)
+		}
+	}
+}
+
+// WithBotResponse responds to refused form posts with h instead of
+// returning errors.ErrAPIBotDetected, a 403. Responding as a successful
+// submit, such as redirecting to the thank-you page, tells a bot nothing:
+//
+//	middleware.Honeypot("website", middleware.WithBotResponse(func(k *kit.Kit) error {
+//	    return k.Redirect("/contact/thanks")
+//	}))
+func WithBotResponse(h kit.HandlerFunc) BotOption {
+	return func(d *botDetector) {
+		d.respond = h
+	}
+}
+
+// Honeypot refuses form posts that fill in fieldName, an input hidden from
+// people that bots fill in with everything else. Render it in the form, such
+// as by setting template.Form's Honeypot to the same name:
+//
+//	r.Use(middleware.Honeypot("website"))
+//
+// Options add the checks of BotDetection to it. Requests other than form
+// posts are let through.
+func Honeypot(fieldName string, opts ...BotOption) Middleware {
+	d := &botDetector{field: fieldName}
+	return d.middleware(opts)
+}
+
+// BotDetection refuses form posts from user agents in
+// DefaultBotUserAgents, or without a User-Agent, with
+// errors.ErrAPIBotDetected. Options change the rules, add a required
+// header and change the response. Requests other than form posts are let
+// through, so crawlers can still read the page:
+//
+//	r.Use(middleware.BotDetection(middleware.WithBotHeader("X-Alpine-Request")))
+func BotDetection(opts ...BotOption) Middleware {
+	d := &botDetector{userAgents: DefaultBotUserAgents, emptyUserAgent: true}
+	return d.middleware(opts)
+}
+
+// middleware applies opts to d and returns the middleware checking with it
+func (d *botDetector) middleware(opts []BotOption) Middleware {
+	for _, opt := range opts {
+		opt(d)
+	}
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			if !formPost(k.Request) {
+				return next(k)
+			}
+			if reason := d.check(k); reason != "" {
+				k.Logger().With("reason", reason, "user_agent", k.Request.UserAgent()).Info("Refused bot form post to %s", k.Request.URL.Path)
+				if d.respond != nil {
+					return d.respond(k)
+				}
+				return errors.ErrAPIBotDetected.WithValue(reason)
+			}
+			return next(k)
+		}
+	}
+}
+
+// check returns why the form post k handles looks automated, or "" if it
+// doesn't
+func (d *botDetector) check(k *kit.Kit) string {
+	if d.field != "" && k.Request.PostFormValue(d.field) != "" {
+		return "honeypot"
+	}
+	if d.header != "" && k.GetHeader(d.header) == "" {
+		return "missing " + d.header
+	}
+	userAgent := strings.ToLower(k.Request.UserAgent())
+	if userAgent == "" {
+		if d.emptyUserAgent {
+			return "missing user agent"
+		}
+		return ""
+	}
+	for _, pattern := range d.userAgents {
+		if strings.Contains(userAgent, pattern) {
+			return "user agent"
+		}
+	}
+	return ""
+}
+
+// formPost reports whether r submits a form
+func formPost(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
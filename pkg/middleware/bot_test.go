@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// formKit returns a Kit for a form post of values from userAgent
+func formKit(values url.Values, userAgent string) *kit.Kit {
+	r := httptest.NewRequest("POST", "/contact", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("User-Agent", userAgent)
+	return &kit.Kit{Response: httptest.NewRecorder(), Request: r}
+}
+
+const browser = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15"
+
+// TestHoneypot tests refusing form posts that fill in the honeypot
+func TestHoneypot(t *testing.T) {
+	var decoded struct {
+		Email string `form:"email"`
+	}
+	handler := Honeypot("website")(func(k *kit.Kit) error {
+		return k.Decode(&decoded)
+	})
+
+	err := handler(formKit(url.Values{"email": {"ada@example.com"}, "website": {""}}, browser))
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", decoded.Email, "the form can still be decoded")
+
+	err = handler(formKit(url.Values{"email": {"spam@example.com"}, "website": {"http://spam.example"}}, browser))
+	assert.ErrorIs(t, err, errors.ErrAPIBotDetected)
+	assert.Equal(t, http.StatusForbidden, errors.HTTPStatusOf(err))
+
+	r := httptest.NewRequest("POST", "/api/contact", strings.NewReader(`{"website":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	var called bool
+	err = Honeypot("website")(func(k *kit.Kit) error {
+		called = true
+		return nil
+	})(&kit.Kit{Response: httptest.NewRecorder(), Request: r})
+	require.NoError(t, err)
+	assert.True(t, called, "only form posts are checked")
+
+	t.Run("responds as configured", func(t *testing.T) {
+		handler := Honeypot("website", WithBotResponse(func(k *kit.Kit) error {
+			return k.Redirect("/contact/thanks")
+		}))(func(k *kit.Kit) error {
+			t.Fatal("the handler runs for a bot")
+			return nil
+		})
+		k := formKit(url.Values{"website": {"x"}}, browser)
+		require.NoError(t, handler(k))
+		assert.Equal(t, "/contact/thanks", k.Response.Header().Get("Location"))
+	})
+}
+
+// TestBotDetection tests the user agent and header heuristics
+func TestBotDetection(t *testing.T) {
+	ok := func(k *kit.Kit) error { return nil }
+
+	tests := []struct {
+		name      string
+		mw        Middleware
+		userAgent string
+		header    bool
+		bot       bool
+	}{
+		{"browser", BotDetection(), browser, false, false},
+		{"curl", BotDetection(), "curl/8.7.1", false, true},
+		{"python", BotDetection(), "python-requests/2.32.3", false, true},
+		{"crawler", BotDetection(), "Mozilla/5.0 (compatible; Googlebot/2.1)", false, true},
+		{"no user agent", BotDetection(), "", false, true},
+		{"custom rules", BotDetection(WithBotUserAgents("Safari")), browser, false, true},
+		{"custom rules allow curl", BotDetection(WithBotUserAgents("Safari")), "curl/8.7.1", false, false},
+		{"missing header", BotDetection(WithBotHeader("X-Alpine-Request")), browser, false, true},
+		{"with header", BotDetection(WithBotHeader("X-Alpine-Request")), browser, true, false},
+		{"honeypot with header", Honeypot("website", WithBotHeader("X-Alpine-Request")), "", false, true},
+		{"honeypot alone allows any agent", Honeypot("website"), "curl/8.7.1", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := formKit(url.Values{"email": {"ada@example.com"}}, tt.userAgent)
+			if tt.header {
+				k.Request.Header.Set("X-Alpine-Request", "true")
+			}
+			err := tt.mw(ok)(k)
+			if tt.bot {
+				assert.ErrorIs(t, err, errors.ErrAPIBotDetected)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	err := BotDetection()(ok)(&kit.Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/contact", nil)})
+	assert.NoError(t, err, "crawlers can read pages")
+}
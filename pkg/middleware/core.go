@@ -2,30 +2,117 @@ package middleware
 
 import (
 	"context"
+	stderrors "errors"
+	"net/http"
 	"time"
 
+	"github.com/cstone-io/twine/pkg/errors"
 	"github.com/cstone-io/twine/pkg/kit"
-	"github.com/cstone-io/twine/pkg/logger"
 )
 
-// LoggingMiddleware logs incoming requests
-func LoggingMiddleware() Middleware {
+// TimeoutMiddleware gives each request a time budget of d. The request's
+// context is cancelled when it runs out, so database queries and outbound
+// calls bound to it stop too, and handlers can read what's left with
+// k.Deadline. Nested timeouts keep the shorter budget. An error the budget
+// running out caused, such as a query it cut short, becomes
+// errors.ErrAPITimeout, a 504.
+func TimeoutMiddleware(d time.Duration) Middleware {
 	return func(next kit.HandlerFunc) kit.HandlerFunc {
 		return func(k *kit.Kit) error {
-			logger.Get().Info("Request: %s %s", k.Request.Method, k.Request.URL.Path)
-			return next(k)
+			ctx, cancel := context.WithTimeout(k.Request.Context(), d)
+			defer cancel()
+
+			k.Request = k.Request.WithContext(ctx)
+			err := next(k)
+			if stderrors.Is(ctx.Err(), context.DeadlineExceeded) &&
+				stderrors.Is(err, context.DeadlineExceeded) && !stderrors.Is(err, errors.ErrAPITimeout) {
+				return errors.ErrAPITimeout.Wrap(err)
+			}
+			return err
 		}
 	}
 }
 
-// TimeoutMiddleware adds a timeout to request processing
-func TimeoutMiddleware(d time.Duration) Middleware {
+// ErrorBoundaryFunc renders a handler error for a route subtree. Returning a
+// non-nil error passes it on to the global error handler.
+type ErrorBoundaryFunc func(k *kit.Kit, err error) error
+
+// ErrorBoundaryMiddleware routes handler errors to the given boundary instead
+// of the global error handler
+func ErrorBoundaryMiddleware(boundary ErrorBoundaryFunc) Middleware {
 	return func(next kit.HandlerFunc) kit.HandlerFunc {
 		return func(k *kit.Kit) error {
-			ctx, cancel := context.WithTimeout(k.Request.Context(), d)
-			defer cancel()
+			if err := next(k); err != nil {
+				return boundary(k, err)
+			}
+			return nil
+		}
+	}
+}
 
-			k.Request = k.Request.WithContext(ctx)
+// NotFoundMiddleware forces a 404 status on responses written by the wrapped
+// handler, so scoped not-found pages can render templates as usual
+func NotFoundMiddleware() Middleware {
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			k.Response = &notFoundWriter{ResponseWriter: k.Response}
+			return next(k)
+		}
+	}
+}
+
+// notFoundWriter rewrites an implicit or explicit 200 status to 404
+type notFoundWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *notFoundWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if status == http.StatusOK {
+		status = http.StatusNotFound
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *notFoundWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// FlushError sends the 404 status and what has been written so far, for
+// http.ResponseController
+func (w *notFoundWriter) FlushError() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Flush implements http.Flusher
+func (w *notFoundWriter) Flush() {
+	w.FlushError()
+}
+
+// Unwrap returns the ResponseWriter, for http.ResponseController
+func (w *notFoundWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// JSONView serializes the JSON responses of the routes it wraps for view,
+// as k.SetJSONView does, such as to show admin-only fields under /admin:
+//
+//	// app/admin/layout.go
+//	r.Use(middleware.JSONView("admin"))
+func JSONView(view string) Middleware {
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			k.SetJSONView(view)
 			return next(k)
 		}
 	}
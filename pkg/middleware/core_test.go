@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -8,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	twineerrors "github.com/cstone-io/twine/pkg/errors"
 	"github.com/cstone-io/twine/pkg/kit"
 )
 
@@ -31,6 +35,7 @@ func TestLoggingMiddleware(t *testing.T) {
 		err := wrapped(k)
 		require.NoError(t, err)
 		assert.True(t, handlerCalled)
+		assert.NotEmpty(t, w.Header().Get(kit.RequestIDHeader), "assigns a request ID")
 	})
 
 	t.Run("logs different HTTP methods", func(t *testing.T) {
@@ -220,6 +225,217 @@ func TestTimeoutMiddleware(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "value", capturedValue)
 	})
+
+	t.Run("handler errors reach the client", func(t *testing.T) {
+		// The timeout context is cancelled once the handler returns, which
+		// mustn't pass for the client going away
+		handler := kit.Handler(TimeoutMiddleware(time.Second)(func(k *kit.Kit) error {
+			return twineerrors.ErrNotFound
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, 404, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"`+twineerrors.ErrNotFound.Public()+`"`)
+	})
+	t.Run("handler that runs out of time responds 504", func(t *testing.T) {
+		handler := kit.Handler(TimeoutMiddleware(10 * time.Millisecond)(func(k *kit.Kit) error {
+			<-k.Request.Context().Done()
+			return twineerrors.ErrDatabaseRead.Wrap(k.Request.Context().Err())
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, 504, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"`+twineerrors.ErrAPITimeout.Public()+`"`)
+	})
+
+	t.Run("other errors keep their status after the budget runs out", func(t *testing.T) {
+		handler := kit.Handler(TimeoutMiddleware(10 * time.Millisecond)(func(k *kit.Kit) error {
+			<-k.Request.Context().Done()
+			return twineerrors.ErrAPIValidation
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, 422, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"`+twineerrors.ErrAPIValidation.Public()+`"`)
+	})
+
+	t.Run("nested timeouts wrap once", func(t *testing.T) {
+		mw := TimeoutMiddleware(10 * time.Millisecond)
+		wrapped := mw(mw(func(k *kit.Kit) error {
+			<-k.Request.Context().Done()
+			return k.Request.Context().Err()
+		}))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		err := wrapped(&kit.Kit{Response: w, Request: r})
+
+		require.Error(t, err)
+		e, ok := twineerrors.As(err)
+		require.True(t, ok)
+		assert.Equal(t, twineerrors.ErrAPITimeout.Code, e.Code)
+		assert.Equal(t, context.DeadlineExceeded, e.Cause)
+	})
+}
+
+// TestErrorBoundaryMiddleware tests routing handler errors to a boundary
+func TestErrorBoundaryMiddleware(t *testing.T) {
+	t.Run("renders handler error with boundary", func(t *testing.T) {
+		var captured error
+		boundary := func(k *kit.Kit, err error) error {
+			captured = err
+			return k.Text(500, "boundary: "+err.Error())
+		}
+
+		handlerErr := errors.New("boom")
+		wrapped := ErrorBoundaryMiddleware(boundary)(func(k *kit.Kit) error {
+			return handlerErr
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		err := wrapped(k)
+		require.NoError(t, err)
+		assert.Equal(t, handlerErr, captured)
+		assert.Equal(t, "boundary: boom", w.Body.String())
+	})
+
+	t.Run("skips boundary on success", func(t *testing.T) {
+		called := false
+		boundary := func(k *kit.Kit, err error) error {
+			called = true
+			return nil
+		}
+
+		wrapped := ErrorBoundaryMiddleware(boundary)(func(k *kit.Kit) error {
+			return k.Text(200, "ok")
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		require.NoError(t, wrapped(k))
+		assert.False(t, called)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+
+	t.Run("boundary error falls through", func(t *testing.T) {
+		boundaryErr := errors.New("boundary failed")
+		boundary := func(k *kit.Kit, err error) error {
+			return boundaryErr
+		}
+
+		wrapped := ErrorBoundaryMiddleware(boundary)(func(k *kit.Kit) error {
+			return errors.New("boom")
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		assert.Equal(t, boundaryErr, wrapped(k))
+	})
+}
+
+// TestNotFoundMiddleware tests forcing a 404 status for scoped not-found pages
+func TestNotFoundMiddleware(t *testing.T) {
+	t.Run("implicit status becomes 404", func(t *testing.T) {
+		wrapped := NotFoundMiddleware()(func(k *kit.Kit) error {
+			_, err := k.Response.Write([]byte("missing"))
+			return err
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard/nope", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		require.NoError(t, wrapped(k))
+		assert.Equal(t, 404, w.Code)
+		assert.Equal(t, "missing", w.Body.String())
+	})
+
+	t.Run("explicit 200 becomes 404", func(t *testing.T) {
+		wrapped := NotFoundMiddleware()(func(k *kit.Kit) error {
+			return k.HTML(200, "<h1>Not here</h1>")
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard/nope", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		require.NoError(t, wrapped(k))
+		assert.Equal(t, 404, w.Code)
+		assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("other statuses are preserved", func(t *testing.T) {
+		wrapped := NotFoundMiddleware()(func(k *kit.Kit) error {
+			return k.Redirect("/login")
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard/nope", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		require.NoError(t, wrapped(k))
+		assert.Equal(t, 303, w.Code)
+	})
+
+	t.Run("flushing sends 404", func(t *testing.T) {
+		wrapped := NotFoundMiddleware()(func(k *kit.Kit) error {
+			return http.NewResponseController(k.Response).Flush()
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard/nope", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		require.NoError(t, wrapped(k))
+		assert.True(t, w.Flushed)
+		assert.Equal(t, 404, w.Code)
+	})
+
+	t.Run("unwraps for http.ResponseController", func(t *testing.T) {
+		var unwrapped http.ResponseWriter
+		wrapped := NotFoundMiddleware()(func(k *kit.Kit) error {
+			if u, ok := k.Response.(interface{ Unwrap() http.ResponseWriter }); ok {
+				unwrapped = u.Unwrap()
+			}
+			return nil
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/dashboard/nope", nil)
+		k := &kit.Kit{Response: w, Request: r}
+
+		require.NoError(t, wrapped(k))
+		assert.Same(t, w, unwrapped)
+	})
+}
+
+// TestJSONView tests serializing the JSON responses of a subtree for a view
+func TestJSONView(t *testing.T) {
+	type account struct {
+		ID    int    `json:"id"`
+		Notes string `json:"notes" view:"admin"`
+	}
+	wrapped := JSONView("admin")(func(k *kit.Kit) error {
+		return k.JSON(200, account{ID: 1, Notes: "vip"})
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/accounts/1", nil)
+	require.NoError(t, wrapped(&kit.Kit{Response: w, Request: r}))
+	assert.JSONEq(t, `{"id":1,"notes":"vip"}`, w.Body.String())
 }
 
 // TestCoreMiddleware_Integration tests realistic middleware scenarios
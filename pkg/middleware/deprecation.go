@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// deprecation holds the headers a Deprecation middleware sends
+type deprecation struct {
+	date   time.Time
+	sunset time.Time
+	link   string
+}
+
+// DeprecationOption configures a middleware returned by Deprecation
+type DeprecationOption func(*deprecation)
+
+// WithSunset sends a Sunset header with the date the routes stop working,
+// as YYYY-MM-DD or an RFC 3339 timestamp
+func WithSunset(date string) DeprecationOption {
+	return func(d *deprecation) {
+		d.sunset = mustParseDeprecationDate("sunset", date)
+	}
+}
+
+// WithDeprecationLink links to a page about the deprecation, such as a
+// migration guide, in a Link header with rel="deprecation"
+func WithDeprecationLink(url string) DeprecationOption {
+	return func(d *deprecation) {
+		d.link = url
+	}
+}
+
+// Deprecation marks the routes it wraps deprecated since date, YYYY-MM-DD or
+// an RFC 3339 timestamp, with a Deprecation response header (RFC 9745).
+// Clients keep working; the header tells them to move on. It panics on an
+// invalid date, as middleware is set up at startup:
+//
+//	// app/api/v1/middleware.go
+//	func Middleware() []middleware.Middleware {
+//	    return []middleware.Middleware{
+//	        middleware.Deprecation("2026-06-01", middleware.WithSunset("2027-01-01")),
+//	    }
+//	}
+//
+// twine routes generate adds it to the routes of the API versions listed
+// under routes.api.versions in twine.yaml.
+func Deprecation(date string, opts ...DeprecationOption) Middleware {
+	d := &deprecation{date: mustParseDeprecationDate("date", date)}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	deprecated := "@" + strconv.FormatInt(d.date.Unix(), 10)
+	var sunset, link string
+	if !d.sunset.IsZero() {
+		sunset = d.sunset.UTC().Format(http.TimeFormat)
+	}
+	if d.link != "" {
+		link = "<" + d.link + `>; rel="deprecation"`
+	}
+
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			header := k.Response.Header()
+			header.Set("Deprecation", deprecated)
+			if sunset != "" {
+				header.Set("Sunset", sunset)
+			}
+			if link != "" {
+				header.Add("Link", link)
+			}
+			return next(k)
+		}
+	}
+}
+
+// mustParseDeprecationDate parses a YYYY-MM-DD date or an RFC 3339
+// timestamp, panicking if it's neither
+func mustParseDeprecationDate(name, value string) time.Time {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic("middleware.Deprecation: invalid " + name + " " + strconv.Quote(value) + ", expected YYYY-MM-DD or RFC 3339")
+	}
+	return t
+}
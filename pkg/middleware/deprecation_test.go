@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// TestDeprecation tests the Deprecation, Sunset and Link headers
+func TestDeprecation(t *testing.T) {
+	serve := func(mw Middleware, handler kit.HandlerFunc) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		k := &kit.Kit{Response: w, Request: httptest.NewRequest("GET", "/api/v1/users", nil)}
+		mw(handler)(k)
+		return w
+	}
+	ok := func(k *kit.Kit) error { return nil }
+
+	t.Run("date only", func(t *testing.T) {
+		w := serve(Deprecation("2026-06-01"), ok)
+		assert.Equal(t, "@1780272000", w.Header().Get("Deprecation"))
+		assert.Empty(t, w.Header().Get("Sunset"))
+		assert.Empty(t, w.Header().Get("Link"))
+	})
+
+	t.Run("sunset and link", func(t *testing.T) {
+		mw := Deprecation("2026-06-01T12:00:00+02:00",
+			WithSunset("2027-01-01"),
+			WithDeprecationLink("https://example.com/migrate"))
+		w := serve(mw, func(k *kit.Kit) error {
+			k.Response.Header().Add("Link", `</api/v1/users?page=2>; rel="next"`)
+			return errors.New("failed")
+		})
+		assert.Equal(t, "@1780308000", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, []string{`<https://example.com/migrate>; rel="deprecation"`, `</api/v1/users?page=2>; rel="next"`}, w.Header().Values("Link"))
+	})
+
+	t.Run("invalid dates panic", func(t *testing.T) {
+		assert.PanicsWithValue(t, `middleware.Deprecation: invalid date "June 2026", expected YYYY-MM-DD or RFC 3339`, func() {
+			Deprecation("June 2026")
+		})
+		assert.Panics(t, func() {
+			Deprecation("2026-06-01", WithSunset("soon"))
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// MaxInFlight handles at most n requests at once, shedding load when more
+// arrive than the server, or the database behind it, can take. Up to queue
+// requests beyond n wait up to timeout for one to finish. Others, and those
+// still waiting after timeout, get errors.ErrAPIOverloaded, a 503 with a
+// Retry-After header.
+//
+// Each call has its own limit, shared by the routes it wraps: used on the
+// root router it limits the whole application, and on a layout just that
+// subtree:
+//
+//	// app/reports/layout.go
+//	r.Use(middleware.MaxInFlight(8, 32, 2*time.Second))
+//
+// It panics if n isn't positive.
+func MaxInFlight(n, queue int, timeout time.Duration) Middleware {
+	if n <= 0 {
+		panic("middleware.MaxInFlight: n must be positive")
+	}
+	slots := make(chan struct{}, n)
+	var waiting atomic.Int64
+	overloaded := errors.ErrAPIOverloaded.WithRetryAfter(max(timeout, time.Second))
+
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			select {
+			case slots <- struct{}{}:
+			default:
+				if waiting.Add(1) > int64(queue) {
+					waiting.Add(-1)
+					return overloaded
+				}
+				timer := time.NewTimer(timeout)
+				select {
+				case slots <- struct{}{}:
+					waiting.Add(-1)
+					timer.Stop()
+				case <-timer.C:
+					waiting.Add(-1)
+					return overloaded
+				case <-k.Request.Context().Done():
+					waiting.Add(-1)
+					timer.Stop()
+					if k.Aborted() {
+						return errors.ErrAborted
+					}
+					return overloaded
+				}
+			}
+			defer func() { <-slots }()
+			return next(k)
+		}
+	}
+}
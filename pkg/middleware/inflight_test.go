@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// inFlightServer returns a handler limited by mw that holds requests until
+// release is closed, announcing each on started
+func inFlightServer(mw Middleware, started chan<- struct{}, release <-chan struct{}) func(ctx context.Context) error {
+	handler := mw(func(k *kit.Kit) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+	return func(ctx context.Context) error {
+		r := httptest.NewRequest("GET", "/reports", nil).WithContext(ctx)
+		return handler(&kit.Kit{Response: httptest.NewRecorder(), Request: r})
+	}
+}
+
+// TestMaxInFlight tests bounding concurrent requests with a wait queue
+func TestMaxInFlight(t *testing.T) {
+	t.Run("sheds load without a queue", func(t *testing.T) {
+		started, release := make(chan struct{}, 1), make(chan struct{})
+		serve := inFlightServer(MaxInFlight(1, 0, time.Second), started, release)
+
+		done := make(chan error)
+		go func() { done <- serve(context.Background()) }()
+		<-started
+
+		err := serve(context.Background())
+		assert.ErrorIs(t, err, errors.ErrAPIOverloaded)
+		assert.Equal(t, 503, errors.HTTPStatusOf(err))
+		e, ok := errors.As(err)
+		require.True(t, ok)
+		assert.Equal(t, time.Second, e.RetryAfter)
+
+		close(release)
+		assert.NoError(t, <-done)
+		go func() { <-started }()
+		assert.NoError(t, serve(context.Background()), "the slot is free again")
+	})
+
+	t.Run("queues requests for a slot", func(t *testing.T) {
+		started, release := make(chan struct{}, 2), make(chan struct{})
+		serve := inFlightServer(MaxInFlight(1, 1, time.Second), started, release)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = serve(context.Background())
+			}()
+		}
+		<-started
+		select {
+		case <-started:
+			t.Fatal("two requests ran at once")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(release)
+		wg.Wait()
+		assert.NoError(t, errs[0])
+		assert.NoError(t, errs[1], "the queued request runs once a slot frees")
+	})
+}
+
+// TestMaxInFlight_Timeout tests giving up on queued requests
+func TestMaxInFlight_Timeout(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	serve := inFlightServer(MaxInFlight(1, 4, 20*time.Millisecond), started, release)
+
+	go serve(context.Background())
+	<-started
+
+	start := time.Now()
+	assert.ErrorIs(t, serve(context.Background()), errors.ErrAPIOverloaded)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond, "waits for a slot first")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, serve(ctx), errors.ErrAborted, "a client that leaves stops waiting")
+
+	assert.Panics(t, func() { MaxInFlight(0, 0, 0) })
+}
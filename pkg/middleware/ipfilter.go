@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync/atomic"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// prefixSet matches addresses against IPs and CIDR ranges. Lookups mask the
+// address to each prefix length in the set and look it up, so they cost one
+// map lookup per distinct length however many ranges there are.
+type prefixSet struct {
+	prefixes map[netip.Prefix]struct{}
+	// Distinct prefix lengths of the IPv4 and IPv6 ranges, longest first
+	v4, v6 []int
+}
+
+// newPrefixSet parses ranges, each an IP or CIDR range
+func newPrefixSet(ranges []string) (*prefixSet, error) {
+	s := &prefixSet{prefixes: map[netip.Prefix]struct{}{}}
+	for _, r := range ranges {
+		prefix, err := netip.ParsePrefix(r)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(r)
+			if addrErr != nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", r)
+			}
+			addr = addr.Unmap().WithZone("")
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefix = prefix.Masked()
+		s.prefixes[prefix] = struct{}{}
+
+		lengths := &s.v6
+		if prefix.Addr().Is4() {
+			lengths = &s.v4
+		}
+		if !slices.Contains(*lengths, prefix.Bits()) {
+			*lengths = append(*lengths, prefix.Bits())
+		}
+	}
+	slices.SortFunc(s.v4, func(a, b int) int { return b - a })
+	slices.SortFunc(s.v6, func(a, b int) int { return b - a })
+	return s, nil
+}
+
+// contains reports whether addr is in one of the set's ranges
+func (s *prefixSet) contains(addr netip.Addr) bool {
+	lengths := s.v6
+	if addr.Is4() {
+		lengths = s.v4
+	}
+	for _, bits := range lengths {
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if _, ok := s.prefixes[prefix]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// empty reports whether the set has no ranges
+func (s *prefixSet) empty() bool {
+	return len(s.prefixes) == 0
+}
+
+// ipFilter is the allow and deny lists of an IPFilter middleware
+type ipFilter struct {
+	allow, deny *prefixSet
+}
+
+// newIPFilter parses the allow and deny lists
+func newIPFilter(allowCIDRs, denyCIDRs []string) (*ipFilter, error) {
+	allow, err := newPrefixSet(allowCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("allowed: %w", err)
+	}
+	deny, err := newPrefixSet(denyCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("denied: %w", err)
+	}
+	return &ipFilter{allow: allow, deny: deny}, nil
+}
+
+// allowed reports whether a client at ip may make requests
+func (f *ipFilter) allowed(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	if f.deny.contains(addr) {
+		return false
+	}
+	return f.allow.empty() || f.allow.contains(addr)
+}
+
+// IPFilter refuses requests from clients in denyCIDRs, and from clients
+// outside allowCIDRs unless it's empty, with errors.ErrAPIIPForbidden, a
+// 403. Entries are IPs or CIDR ranges, and a client in both lists is
+// refused. The client's address is read from X-Forwarded-For only through
+// the trusted proxies. It panics on an entry that isn't an IP or range, as
+// middleware is set up at startup:
+//
+//	// app/admin/layout.go
+//	r.Use(middleware.IPFilter([]string{"203.0.113.0/24", "10.8.0.0/16"}, nil))
+func IPFilter(allowCIDRs, denyCIDRs []string) Middleware {
+	f, err := newIPFilter(allowCIDRs, denyCIDRs)
+	if err != nil {
+		panic("middleware.IPFilter: " + err.Error())
+	}
+	return filterIPs(func() *ipFilter { return f })
+}
+
+// IPFilterFromConfig filters clients as IPFilter does, with the
+// server.ip_allow and server.ip_deny settings (SERVER_IP_ALLOW and
+// SERVER_IP_DENY). Lists changed by config.Reload apply to the requests
+// after it. It panics on an entry that isn't an IP or range, as IPFilter
+// does.
+func IPFilterFromConfig() Middleware {
+	cfg := config.Get()
+	f, err := newIPFilter(cfg.Server.IPAllow, cfg.Server.IPDeny)
+	if err != nil {
+		panic("middleware.IPFilterFromConfig: " + err.Error())
+	}
+
+	var current atomic.Pointer[ipFilter]
+	current.Store(f)
+	config.Subscribe(func(old, new *config.Config) {
+		reloadIPFilter(&current, old, new)
+	})
+	return filterIPs(current.Load)
+}
+
+// reloadIPFilter replaces the filter in current if new changes the lists.
+// Lists that don't parse are logged, and the previous filter is kept.
+func reloadIPFilter(current *atomic.Pointer[ipFilter], old, new *config.Config) {
+	if slices.Equal(old.Server.IPAllow, new.Server.IPAllow) && slices.Equal(old.Server.IPDeny, new.Server.IPDeny) {
+		return
+	}
+	f, err := newIPFilter(new.Server.IPAllow, new.Server.IPDeny)
+	if err != nil {
+		logger.Get().Warn("Keeping the previous IP filter: %v", err)
+		return
+	}
+	current.Store(f)
+}
+
+// filterIPs returns the middleware refusing the clients the filter returned
+// by filter refuses
+func filterIPs(filter func() *ipFilter) Middleware {
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			if ip := clientIP(k.Request); !filter().allowed(ip) {
+				k.Logger().With("remote_ip", ip).Info("Refused request to %s from a filtered address", k.Request.URL.Path)
+				return errors.ErrAPIIPForbidden.WithValue(ip)
+			}
+			return next(k)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// requestFrom runs mw for a request from remoteAddr, reporting whether it
+// reached the handler
+func requestFrom(t *testing.T, mw Middleware, remoteAddr string) (bool, error) {
+	t.Helper()
+	var reached bool
+	r := httptest.NewRequest("GET", "/admin", nil)
+	r.RemoteAddr = remoteAddr
+	err := mw(func(k *kit.Kit) error {
+		reached = true
+		return nil
+	})(&kit.Kit{Response: httptest.NewRecorder(), Request: r})
+	return reached, err
+}
+
+// TestIPFilter tests allowing and denying clients by range
+func TestIPFilter(t *testing.T) {
+	mw := IPFilter(
+		[]string{"203.0.113.0/24", "10.8.0.0/16", "2001:db8::/32", "198.51.100.7"},
+		[]string{"203.0.113.66", "2001:db8:bad::/48"},
+	)
+
+	tests := []struct {
+		remoteAddr string
+		allowed    bool
+	}{
+		{"203.0.113.9:1234", true},
+		{"203.0.113.66:1234", false},
+		{"10.8.255.1:1234", true},
+		{"10.9.0.1:1234", false},
+		{"198.51.100.7:1234", true},
+		{"198.51.100.8:1234", false},
+		{"[2001:db8::1]:1234", true},
+		{"[2001:db8:bad::1]:1234", false},
+		{"[::ffff:203.0.113.9]:1234", true},
+		{"[fe80::1%eth0]:1234", false},
+		{"pipe", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			reached, err := requestFrom(t, mw, tt.remoteAddr)
+			assert.Equal(t, tt.allowed, reached)
+			if !tt.allowed {
+				assert.ErrorIs(t, err, errors.ErrAPIIPForbidden)
+				assert.Equal(t, 403, errors.HTTPStatusOf(err))
+			}
+		})
+	}
+
+	t.Run("denies only", func(t *testing.T) {
+		mw := IPFilter(nil, []string{"192.0.2.0/24"})
+		reached, _ := requestFrom(t, mw, "198.51.100.1:1234")
+		assert.True(t, reached)
+		reached, _ = requestFrom(t, mw, "192.0.2.1:1234")
+		assert.False(t, reached)
+	})
+
+	t.Run("through trusted proxies", func(t *testing.T) {
+		cfg := config.Get()
+		original := cfg.Server.TrustedProxies
+		t.Cleanup(func() { cfg.Server.TrustedProxies = original })
+		cfg.Server.TrustedProxies = []string{"10.0.0.0/8"}
+
+		r := httptest.NewRequest("GET", "/admin", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-For", "203.0.113.66")
+		err := mw(func(k *kit.Kit) error { return nil })(&kit.Kit{Response: httptest.NewRecorder(), Request: r})
+		assert.ErrorIs(t, err, errors.ErrAPIIPForbidden, "the client, not the proxy, is filtered")
+	})
+
+	assert.PanicsWithValue(t, `middleware.IPFilter: allowed: "office" is not an IP address or CIDR range`, func() {
+		IPFilter([]string{"office"}, nil)
+	})
+}
+
+// TestNewPrefixSet tests normalizing ranges
+func TestNewPrefixSet(t *testing.T) {
+	s, err := newPrefixSet([]string{"10.1.2.3/8", "::ffff:192.0.2.0/120", "2001:db8::1"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{24, 8}, s.v4, "mapped ranges are IPv4, longest first")
+	assert.Equal(t, []int{128}, s.v6)
+	assert.Len(t, s.prefixes, 3)
+
+	_, err = newPrefixSet([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+// TestIPFilterFromConfig tests filtering with the reloadable settings
+func TestIPFilterFromConfig(t *testing.T) {
+	t.Setenv("AUTH_SECRET", "test-secret-key-for-testing-at-least-32")
+	t.Setenv("SERVER_IP_ALLOW", "")
+	t.Setenv("SERVER_IP_DENY", "192.0.2.0/24")
+	require.NoError(t, config.Reload())
+	t.Cleanup(func() {
+		cfg := config.Get()
+		cfg.Server.IPAllow, cfg.Server.IPDeny = nil, nil
+	})
+
+	mw := IPFilterFromConfig()
+	reached, _ := requestFrom(t, mw, "192.0.2.1:1234")
+	assert.False(t, reached)
+
+	t.Setenv("SERVER_IP_DENY", "198.51.100.0/24")
+	require.NoError(t, config.Reload())
+	reached, _ = requestFrom(t, mw, "192.0.2.1:1234")
+	assert.True(t, reached, "the reloaded list applies")
+	reached, _ = requestFrom(t, mw, "198.51.100.1:1234")
+	assert.False(t, reached)
+}
+
+// TestIPFilterFromConfig_Invalid tests panicking on invalid lists at
+// startup and keeping the previous filter when a reload has them
+func TestIPFilterFromConfig_Invalid(t *testing.T) {
+	cfg := config.Get()
+	t.Cleanup(func() { cfg.Server.IPAllow, cfg.Server.IPDeny = nil, nil })
+
+	cfg.Server.IPDeny = []string{"not-an-ip"}
+	assert.Panics(t, func() { IPFilterFromConfig() })
+
+	f, err := newIPFilter(nil, []string{"192.0.2.0/24"})
+	require.NoError(t, err)
+	var current atomic.Pointer[ipFilter]
+	current.Store(f)
+
+	old := &config.Config{}
+	old.Server.IPDeny = []string{"192.0.2.0/24"}
+	next := &config.Config{}
+	next.Server.IPDeny = []string{"192.0.2.0/33"}
+	reloadIPFilter(&current, old, next)
+	assert.Same(t, f, current.Load(), "the previous filter is kept")
+
+	next.Server.IPDeny = []string{"198.51.100.0/24"}
+	reloadIPFilter(&current, old, next)
+	assert.True(t, current.Load().allowed("192.0.2.1"), "a valid list replaces the filter")
+}
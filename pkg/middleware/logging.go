@@ -0,0 +1,322 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// commonLogTime is the timestamp layout of the Common Log Format
+const commonLogTime = "02/Jan/2006:15:04:05 -0700"
+
+// requestLogger writes the requests handled by a LoggingMiddleware
+type requestLogger struct {
+	format config.RequestLogFormat
+	fields []string
+	// log receives entries in the default format, and out lines in the
+	// others
+	log *logger.Logger
+	out io.Writer
+
+	// Sampling of 2xx requests, counted per second
+	first, thereafter int
+	mu                sync.Mutex
+	window            time.Time
+	count             int
+}
+
+// LoggingOption configures a middleware returned by LoggingMiddleware
+type LoggingOption func(*requestLogger)
+
+// WithLogFormat writes requests in format instead of LOGGER_REQUEST_FORMAT's
+func WithLogFormat(format config.RequestLogFormat) LoggingOption {
+	return func(l *requestLogger) {
+		l.format = format
+	}
+}
+
+// WithLogFields writes the given config.RequestLogFields with each request,
+// instead of LOGGER_REQUEST_FIELDS. The Common and Combined formats have
+// fixed fields.
+func WithLogFields(fields ...string) LoggingOption {
+	return func(l *requestLogger) {
+		l.fields = fields
+	}
+}
+
+// WithLogSampling logs, each second, the first first requests answered with
+// a 2xx status and then one in every thereafter, instead of the
+// LOGGER_REQUEST_SAMPLE_* settings. Other statuses are always logged. Zero
+// first logs every request.
+func WithLogSampling(first, thereafter int) LoggingOption {
+	return func(l *requestLogger) {
+		l.first, l.thereafter = first, thereafter
+	}
+}
+
+// WithLogger writes requests in the default format through log instead of
+// the singleton logger
+func WithLogger(log *logger.Logger) LoggingOption {
+	return func(l *requestLogger) {
+		l.log = log
+	}
+}
+
+// WithLogOutput writes requests in the Common, Combined and JSON formats to
+// w instead of LOGGER_OUTPUT
+func WithLogOutput(w io.Writer) LoggingOption {
+	return func(l *requestLogger) {
+		l.out = w
+	}
+}
+
+// LoggingMiddleware logs each request once it is handled, with its status,
+// in the format and with the fields of the LOGGER_REQUEST_* settings. It
+// assigns the request ID the rest of the request's log lines share. A
+// handler's error is logged with the error's status, as the error handler
+// will respond, and a request the client closed first with 499:
+//
+//	r.Use(middleware.LoggingMiddleware(middleware.WithLogFormat(config.RequestLogCombined)))
+func LoggingMiddleware(opts ...LoggingOption) Middleware {
+	cfg := config.Get().Logger
+	l := &requestLogger{
+		format:     cfg.RequestFormat,
+		fields:     cfg.RequestFields,
+		out:        cfg.Output,
+		first:      cfg.RequestSampleFirst,
+		thereafter: cfg.RequestSampleThereafter,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			k.RequestID()
+			start := time.Now()
+			rec := &responseRecorder{ResponseWriter: k.Response}
+			k.Response = rec
+
+			err := next(k)
+
+			status := rec.status
+			if status == 0 && k.Aborted() {
+				status = errors.StatusClientClosedRequest
+			}
+			if err != nil && status == 0 {
+				status = http.StatusInternalServerError
+				if e, ok := errors.As(err); ok && e.HTTPStatus != 0 {
+					status = e.HTTPStatus
+				}
+			}
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if l.sampled(status) {
+				l.write(k, status, rec.bytes, start)
+			}
+			return err
+		}
+	}
+}
+
+// sampled reports whether a request answered with status passes sampling
+func (l *requestLogger) sampled(status int) bool {
+	if l.first <= 0 || status < 200 || status >= 300 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := time.Now()
+	if t.Sub(l.window) >= time.Second {
+		l.window, l.count = t, 0
+	}
+	l.count++
+	if l.count <= l.first {
+		return true
+	}
+	return l.thereafter > 0 && (l.count-l.first)%l.thereafter == 0
+}
+
+// write logs a handled request in the format
+func (l *requestLogger) write(k *kit.Kit, status int, bytes int64, start time.Time) {
+	r := k.Request
+	switch l.format {
+	case config.RequestLogCommon, config.RequestLogCombined:
+		line := commonLogLine(k, status, bytes, start)
+		if l.format == config.RequestLogCombined {
+			line += " " + quoteOrDash(r.Referer()) + " " + quoteOrDash(r.UserAgent())
+		}
+		l.out.Write([]byte(line + "\n"))
+	case config.RequestLogJSON:
+		entry := requestEntry{
+			Time:   start.Format(time.RFC3339Nano),
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Status: status,
+		}
+		for _, field := range l.fields {
+			switch field {
+			case "latency":
+				ms := float64(time.Since(start).Microseconds()) / 1000
+				entry.Latency = &ms
+			case "bytes":
+				entry.Bytes = &bytes
+			case "user_agent":
+				entry.UserAgent = r.UserAgent()
+			case "referer":
+				entry.Referer = r.Referer()
+			case "remote_ip":
+				entry.RemoteIP = clientIP(r)
+			case "request_id":
+				entry.RequestID = k.RequestID()
+			case "user_id":
+				entry.UserID = k.GetContext("user")
+			case "route":
+				entry.Route = r.Pattern
+			}
+		}
+		line, _ := json.Marshal(entry)
+		l.out.Write(append(line, '\n'))
+	default:
+		log := l.log
+		if log == nil {
+			log = logger.Get()
+		}
+		fields := []any{"method", r.Method, "path", r.URL.Path, "status", status}
+		for _, field := range l.fields {
+			switch field {
+			case "latency":
+				fields = append(fields, field, time.Since(start))
+			case "bytes":
+				fields = append(fields, field, bytes)
+			case "user_agent":
+				fields = append(fields, field, r.UserAgent())
+			case "referer":
+				fields = append(fields, field, r.Referer())
+			case "remote_ip":
+				fields = append(fields, field, clientIP(r))
+			case "request_id":
+				fields = append(fields, field, k.RequestID())
+			case "user_id":
+				if user := k.GetContext("user"); user != "" {
+					fields = append(fields, field, user)
+				}
+			case "route":
+				if r.Pattern != "" {
+					fields = append(fields, field, r.Pattern)
+				}
+			}
+		}
+		log.With(fields...).Info("Request")
+	}
+}
+
+// requestEntry is a request in the JSON format
+type requestEntry struct {
+	Time      string   `json:"time"`
+	Method    string   `json:"method"`
+	Path      string   `json:"path"`
+	Status    int      `json:"status"`
+	Latency   *float64 `json:"latency_ms,omitempty"`
+	Bytes     *int64   `json:"bytes,omitempty"`
+	UserAgent string   `json:"user_agent,omitempty"`
+	Referer   string   `json:"referer,omitempty"`
+	RemoteIP  string   `json:"remote_ip,omitempty"`
+	RequestID string   `json:"request_id,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	Route     string   `json:"route,omitempty"`
+}
+
+// commonLogLine formats a request in the Common Log Format:
+//
+//	127.0.0.1 - 42 [10/Oct/2025:13:55:36 -0700] "GET /posts HTTP/1.1" 200 2326
+func commonLogLine(k *kit.Kit, status int, bytes int64, start time.Time) string {
+	r := k.Request
+	user := k.GetContext("user")
+	if user == "" || strings.ContainsFunc(user, func(c rune) bool { return c <= ' ' || c == '"' }) {
+		user = "-"
+	}
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+	return clientIP(r) + " - " + user + " [" + start.Format(commonLogTime) + "] " +
+		strconv.Quote(r.Method+" "+r.RequestURI+" "+r.Proto) + " " + strconv.Itoa(status) + " " + size
+}
+
+// quoteOrDash quotes s for the Combined Log Format, or returns "-" when it
+// is empty
+func quoteOrDash(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+	return strconv.Quote(s)
+}
+
+// clientIP returns the address of the client, from the X-Forwarded-For
+// header of trusted proxies: the last address before them
+func clientIP(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	cfg := config.Get()
+	if !cfg.Server.IsTrustedProxy(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for _, hop := range slices.Backward(forwarded) {
+		hop = strings.TrimSpace(hop)
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !cfg.Server.IsTrustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// responseRecorder records the status and size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	// Informational responses, such as 103 Early Hints, precede the status
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the ResponseWriter, for http.ResponseController
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// serveLogged runs handler behind mw for a request, returning the response
+func serveLogged(t *testing.T, mw Middleware, handler kit.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.RemoteAddr = "203.0.113.7:51234"
+	k := &kit.Kit{Response: w, Request: r}
+	k.SetContext("user", "42")
+	mw(handler)(k)
+	return w
+}
+
+// ok responds 200 with a short body
+func ok(k *kit.Kit) error {
+	return k.Text(http.StatusOK, "hello")
+}
+
+// TestLoggingMiddleware_Formats tests each request log format
+func TestLoggingMiddleware_Formats(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "/posts?page=2", nil)
+		r.Header.Set("Referer", "https://example.com/")
+		r.Header.Set("User-Agent", `Mozilla/5.0 "test"`)
+		r.Header.Set(kit.RequestIDHeader, "req-1")
+		return r
+	}
+
+	t.Run("default", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := logger.New(config.LoggerConfig{Level: config.LogInfo, Output: &buf, ErrorOutput: &buf})
+		mw := LoggingMiddleware(WithLogFormat(config.RequestLogDefault), WithLogger(log),
+			WithLogFields("bytes", "request_id", "user_id", "remote_ip"))
+
+		serveLogged(t, mw, ok, newRequest())
+		assert.Contains(t, buf.String(), "Request method=GET path=/posts status=200 bytes=5 request_id=req-1 user_id=42 remote_ip=203.0.113.7")
+		assert.NotContains(t, buf.String(), "latency", "only the selected fields")
+	})
+
+	t.Run("common", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw := LoggingMiddleware(WithLogFormat(config.RequestLogCommon), WithLogOutput(&buf))
+
+		serveLogged(t, mw, ok, newRequest())
+		assert.Regexp(t, regexp.MustCompile(`^203\.0\.113\.7 - 42 \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /posts\?page=2 HTTP/1\.1" 200 5\n$`), buf.String())
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw := LoggingMiddleware(WithLogFormat(config.RequestLogCombined), WithLogOutput(&buf))
+
+		serveLogged(t, mw, func(k *kit.Kit) error {
+			k.Response.WriteHeader(http.StatusNoContent)
+			return nil
+		}, newRequest())
+		assert.True(t, strings.HasSuffix(buf.String(), `" 204 - "https://example.com/" "Mozilla/5.0 \"test\""`+"\n"), buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw := LoggingMiddleware(WithLogFormat(config.RequestLogJSON), WithLogOutput(&buf),
+			WithLogFields("latency", "bytes", "user_agent", "request_id"))
+
+		serveLogged(t, mw, ok, newRequest())
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "GET", entry["method"])
+		assert.Equal(t, "/posts", entry["path"])
+		assert.Equal(t, float64(200), entry["status"])
+		assert.Equal(t, float64(5), entry["bytes"])
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Contains(t, entry, "latency_ms")
+		assert.Contains(t, entry, "time")
+		assert.NotContains(t, entry, "user_id", "only the selected fields")
+	})
+}
+
+// TestLoggingMiddleware_Errors tests logging a handler error with the
+// status it will get
+func TestLoggingMiddleware_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	mw := LoggingMiddleware(WithLogFormat(config.RequestLogJSON), WithLogOutput(&buf), WithLogFields())
+
+	serveLogged(t, mw, func(k *kit.Kit) error { return errors.ErrNotFound }, httptest.NewRequest("GET", "/missing", nil))
+	serveLogged(t, mw, func(k *kit.Kit) error { return assert.AnError }, httptest.NewRequest("GET", "/broken", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	serveLogged(t, mw, func(k *kit.Kit) error {
+		return errors.ErrDatabaseRead.Wrap(context.Canceled)
+	}, httptest.NewRequest("GET", "/poll", nil).WithContext(ctx))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"status":404`)
+	assert.Contains(t, lines[1], `"status":500`)
+	assert.Contains(t, lines[2], `"status":499`, "the client closed the request")
+}
+
+// TestLoggingMiddleware_TimeoutErrors tests that an error returned under
+// TimeoutMiddleware isn't logged as the client closing the request
+func TestLoggingMiddleware_TimeoutErrors(t *testing.T) {
+	var buf bytes.Buffer
+	mw := LoggingMiddleware(WithLogFormat(config.RequestLogJSON), WithLogOutput(&buf), WithLogFields())
+	handler := kit.Handler(mw(TimeoutMiddleware(time.Second)(func(k *kit.Kit) error {
+		return errors.ErrNotFound
+	})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, buf.String(), `"status":404`)
+}
+
+// TestLoggingMiddleware_Sampling tests sampling successful requests while
+// logging every failure
+func TestLoggingMiddleware_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	mw := LoggingMiddleware(WithLogFormat(config.RequestLogCommon), WithLogOutput(&buf), WithLogSampling(2, 5))
+
+	for range 12 {
+		serveLogged(t, mw, ok, httptest.NewRequest("GET", "/", nil))
+	}
+	for range 3 {
+		serveLogged(t, mw, func(k *kit.Kit) error {
+			return k.Text(http.StatusBadGateway, "down")
+		}, httptest.NewRequest("GET", "/", nil))
+	}
+
+	assert.Equal(t, 4, strings.Count(buf.String(), `" 200 `), "the first 2, then one in 5 of the next 10")
+	assert.Equal(t, 3, strings.Count(buf.String(), `" 502 `))
+}
+
+// TestClientIP tests believing X-Forwarded-For only from trusted proxies
+func TestClientIP(t *testing.T) {
+	cfg := config.Get()
+	original := cfg.Server.TrustedProxies
+	t.Cleanup(func() { cfg.Server.TrustedProxies = original })
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8"}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.9, 10.0.0.2")
+
+	r.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "192.0.2.1", clientIP(r))
+
+	r.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "203.0.113.9", clientIP(r), "the first address before the trusted proxies")
+}
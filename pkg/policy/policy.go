@@ -0,0 +1,116 @@
+// Package policy decides what users may do with records, one policy per
+// model. Register a policy for a model at start-up:
+//
+//	type PostPolicy struct {
+//	    policy.Deny[*models.User, *models.Post]
+//	}
+//
+//	func (PostPolicy) CanView(user *models.User, post *models.Post) bool {
+//	    return post.Published || user != nil && post.AuthorID == user.ID
+//	}
+//
+//	func (PostPolicy) CanEdit(user *models.User, post *models.Post) bool {
+//	    return user != nil && post.AuthorID == user.ID
+//	}
+//
+//	policy.Register[*models.User, *models.Post](PostPolicy{})
+//
+// then check it in handlers with k.Authorize, which returns
+// ErrInsufficientPermissions when the user may not:
+//
+//	if err := k.Authorize(policy.Edit, post); err != nil {
+//	    return err
+//	}
+//
+// and in templates with .Allowed, to hide the actions the user may not
+// take:
+//
+//	{{if call .Allowed "edit" .Post}}<a href="/posts/{{.Post.ID}}/edit">Edit</a>{{end}}
+//
+// The user is the record set by middleware.LoadUser, read with
+// kit.CurrentUser, or the zero U, such as a nil pointer, when nobody is
+// signed in or it isn't a U.
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// The actions a Policy decides
+const (
+	View   = "view"
+	Create = "create"
+	Edit   = "edit"
+	Delete = "delete"
+)
+
+// Policy decides what a user of type U may do with records of type T
+type Policy[U, T any] interface {
+	CanView(user U, obj T) bool
+	CanCreate(user U, obj T) bool
+	CanEdit(user U, obj T) bool
+	CanDelete(user U, obj T) bool
+}
+
+// Deny denies every action. Embed it in a policy to deny the actions the
+// policy doesn't define.
+type Deny[U, T any] struct{}
+
+func (Deny[U, T]) CanView(U, T) bool   { return false }
+func (Deny[U, T]) CanCreate(U, T) bool { return false }
+func (Deny[U, T]) CanEdit(U, T) bool   { return false }
+func (Deny[U, T]) CanDelete(U, T) bool { return false }
+
+// check decides action on a record for the request's user
+type check func(k *kit.Kit, action string, obj any) (bool, error)
+
+var (
+	mu       sync.RWMutex
+	policies = map[reflect.Type]check{}
+)
+
+// Register makes p the policy for records of type T, replacing any
+// registered before
+func Register[U, T any](p Policy[U, T]) {
+	mu.Lock()
+	defer mu.Unlock()
+	policies[reflect.TypeFor[T]()] = func(k *kit.Kit, action string, obj any) (bool, error) {
+		user, _ := kit.CurrentUser[U](k)
+		record := obj.(T)
+		switch action {
+		case View:
+			return p.CanView(user, record), nil
+		case Create:
+			return p.CanCreate(user, record), nil
+		case Edit:
+			return p.CanEdit(user, record), nil
+		case Delete:
+			return p.CanDelete(user, record), nil
+		}
+		return false, fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// Checker checks actions with the registered policies. Importing this
+// package installs it with kit.UsePolicyChecker.
+type Checker struct{}
+
+// Allowed decides action on obj with the policy registered for its type.
+// A type without one returns an error.
+func (Checker) Allowed(k *kit.Kit, action string, obj any) (bool, error) {
+	mu.RLock()
+	c, ok := policies[reflect.TypeOf(obj)]
+	mu.RUnlock()
+	if !ok {
+		return false, fmt.Errorf("no policy registered for %T", obj)
+	}
+	return c(k, action, obj)
+}
+
+func init() {
+	kit.UsePolicyChecker(Checker{})
+}
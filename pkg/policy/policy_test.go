@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+type testUser struct{ ID int }
+
+type testPost struct {
+	AuthorID  int
+	Published bool
+}
+
+// postPolicy lets anyone view published posts and authors edit theirs
+type postPolicy struct {
+	Deny[*testUser, *testPost]
+}
+
+func (postPolicy) CanView(user *testUser, post *testPost) bool {
+	return post.Published || user != nil && post.AuthorID == user.ID
+}
+
+func (postPolicy) CanEdit(user *testUser, post *testPost) bool {
+	return user != nil && post.AuthorID == user.ID
+}
+
+// newKit returns a Kit for a request by user, or nobody when nil
+func newKit(user *testUser) *kit.Kit {
+	k := &kit.Kit{Response: httptest.NewRecorder(), Request: httptest.NewRequest("GET", "/", nil)}
+	if user != nil {
+		k.SetUser(user)
+	}
+	return k
+}
+
+// TestPolicy tests checking actions with a registered policy
+func TestPolicy(t *testing.T) {
+	Register[*testUser, *testPost](postPolicy{})
+
+	author, reader := &testUser{ID: 1}, &testUser{ID: 2}
+	draft := &testPost{AuthorID: 1}
+	published := &testPost{AuthorID: 1, Published: true}
+
+	tests := []struct {
+		name    string
+		user    *testUser
+		action  string
+		post    *testPost
+		allowed bool
+	}{
+		{"anyone views published posts", nil, View, published, true},
+		{"nobody else views drafts", reader, View, draft, false},
+		{"authors view their drafts", author, View, draft, true},
+		{"authors edit their posts", author, Edit, published, true},
+		{"readers can't edit", reader, Edit, published, false},
+		{"signed out users can't edit", nil, Edit, published, false},
+		{"actions left to Deny are denied", author, Delete, published, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newKit(tt.user)
+			assert.Equal(t, tt.allowed, k.Allowed(tt.action, tt.post))
+			err := k.Authorize(tt.action, tt.post)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errors.ErrInsufficientPermissions)
+			}
+		})
+	}
+
+	t.Run("errors without a policy or for unknown actions", func(t *testing.T) {
+		k := newKit(author)
+		_, err := k.CheckAllowed(Edit, &testUser{})
+		assert.ErrorContains(t, err, "no policy registered for *policy.testUser")
+		_, err = k.CheckAllowed("publish", published)
+		assert.ErrorContains(t, err, `unknown action "publish"`)
+		assert.False(t, k.Allowed("publish", published))
+	})
+
+	t.Run("templates hide disallowed actions", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "post.html"),
+			[]byte(`{{define "post"}}Post{{if call .Allowed "edit" .Post}} <a>Edit</a>{{end}}{{end}}`), 0644))
+		require.NoError(t, template.LoadTemplates(filepath.Join(dir, "post.html")))
+		t.Cleanup(func() { template.SetTemplates(nil) })
+
+		for _, user := range []*testUser{author, reader} {
+			k := newKit(user)
+			require.NoError(t, k.Render("post", map[string]any{"Post": published}))
+			body := k.Response.(*httptest.ResponseRecorder).Body.String()
+			if user == author {
+				assert.Equal(t, "Post <a>Edit</a>", body)
+			} else {
+				assert.Equal(t, "Post", body)
+			}
+		}
+	})
+}
@@ -2,8 +2,11 @@ package public
 
 import (
 	"embed"
+	"io/fs"
 	"net/http"
 	"strings"
+
+	"github.com/cstone-io/twine/pkg/config"
 )
 
 // AssetsFS should be set by the user application using //go:embed
@@ -22,10 +25,21 @@ const (
 	PublicPath = "/public/"
 )
 
+// Cache-Control of the files FileServerHandler serves: browsers revalidate
+// them in development, so edits show on reload, and cache them for an hour
+// in production
+const (
+	DevCacheControl  = "no-cache"
+	ProdCacheControl = "public, max-age=3600"
+)
+
 // FileServerHandler returns an HTTP handler for serving embedded static files
 func FileServerHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasPrefix(r.URL.Path, PublicPath) {
+		if name, ok := strings.CutPrefix(r.URL.Path, PublicPath); ok {
+			if _, err := fs.Stat(AssetsFS, name); err == nil {
+				w.Header().Set("Cache-Control", cacheControl())
+			}
 			http.StripPrefix(PublicPath, http.FileServer(http.FS(AssetsFS))).ServeHTTP(w, r)
 		} else {
 			http.NotFound(w, r)
@@ -33,6 +47,14 @@ func FileServerHandler() http.Handler {
 	})
 }
 
+// cacheControl returns the Cache-Control of files for TWINE_ENV
+func cacheControl() string {
+	if config.Get().IsDev() {
+		return DevCacheControl
+	}
+	return ProdCacheControl
+}
+
 // Asset returns the path to a static asset
 func Asset(name string) string {
 	return AssetsPath + name
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/cstone-io/twine/pkg/config"
 )
 
 //go:embed testdata
@@ -130,6 +132,29 @@ func TestFileServerHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("caches files by environment", func(t *testing.T) {
+		cfg := config.Get()
+		original := cfg.Env
+		t.Cleanup(func() { cfg.Env = original })
+		handler := FileServerHandler()
+
+		cfg.Env = config.EnvDevelopment
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/public/testdata/test.txt", nil))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, DevCacheControl, w.Header().Get("Cache-Control"))
+
+		cfg.Env = config.EnvProduction
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/public/testdata/test.txt", nil))
+		assert.Equal(t, ProdCacheControl, w.Header().Get("Cache-Control"))
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/public/testdata/missing.txt", nil))
+		assert.Equal(t, 404, w.Code)
+		assert.Empty(t, w.Header().Get("Cache-Control"), "missing files aren't cached")
+	})
+
 	t.Run("strips /public/ prefix correctly", func(t *testing.T) {
 		handler := FileServerHandler()
 
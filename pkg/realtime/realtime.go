@@ -0,0 +1,314 @@
+// Package realtime pushes events to browsers. Handlers publish to a topic,
+// such as "orders:42", and every client streaming that topic with
+// k.SSESubscribe receives the event, on this instance or, with the Redis
+// broker, any other.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// SubscriptionBuffer is how many messages a subscription holds for a slow
+// client. Messages published while it is full are dropped for that client.
+const SubscriptionBuffer = 64
+
+// publishTimeout bounds sending a message to the broker
+const publishTimeout = 5 * time.Second
+
+// Message is an event published to a topic
+type Message struct {
+	Topic string `json:"topic"`
+	// Event names the event for the client; empty is a "message" event
+	Event string `json:"event,omitempty"`
+	// Data is the payload: strings and byte slices as they are, other
+	// values encoded as JSON
+	Data string `json:"data"`
+}
+
+// Broker carries messages between the hubs of every instance. RedisBroker
+// implements it; a Hub without one delivers only to its own subscribers.
+type Broker interface {
+	// Publish sends msg to every instance's hub, including this one's
+	Publish(ctx context.Context, msg Message) error
+	// Listen calls deliver with each message published on any instance,
+	// until ctx is done or the connection fails
+	Listen(ctx context.Context, deliver func(Message)) error
+}
+
+// Hub fans messages out to the subscriptions of their topic. The zero
+// value is not usable; create one with NewHub.
+type Hub struct {
+	broker Broker
+
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+	closed bool
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// NewHub creates a Hub. With a broker, it listens for messages from every
+// instance until closed; a nil broker keeps messages on this instance.
+func NewHub(broker Broker) *Hub {
+	h := &Hub{
+		broker: broker,
+		topics: map[string]map[*Subscription]struct{}{},
+		done:   make(chan struct{}),
+	}
+	if broker == nil {
+		close(h.done)
+		h.stop = func() {}
+		return h
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	h.stop = stop
+	go h.listen(ctx)
+	return h
+}
+
+var (
+	mu       sync.Mutex
+	instance *Hub
+)
+
+// Default returns the application's Hub, creating it from
+// config.Get().Realtime when first called
+func Default() *Hub {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if instance == nil {
+		cfg := config.Get().Realtime
+		broker, err := New(cfg)
+		if err != nil {
+			logger.Get().Error("Creating %s realtime broker: %v; delivering on this instance only", cfg.Driver, err)
+		}
+		instance = NewHub(broker)
+	}
+	return instance
+}
+
+// New creates the Broker for cfg.Driver, which is nil for memory
+func New(cfg config.RealtimeConfig) (Broker, error) {
+	switch cfg.Driver {
+	case config.RealtimeMemory, "":
+		return nil, nil
+	case config.RealtimeRedis:
+		broker, err := NewRedisBroker(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		return broker, nil
+	}
+	return nil, fmt.Errorf("unknown realtime driver %q", cfg.Driver)
+}
+
+// Use makes hub the one returned by Default. It is meant for tests.
+func Use(hub *Hub) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = hub
+}
+
+// Reset forgets the current hub without closing it, so the next Default
+// creates another
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = nil
+}
+
+// Close closes the Default hub, if one was created, ending every stream.
+// Register it to run when the server shuts down, as open streams would
+// otherwise hold the shutdown until it times out:
+//
+//	srv.Instance.RegisterOnShutdown(realtime.Close)
+func Close() {
+	mu.Lock()
+	hub := instance
+	mu.Unlock()
+	if hub != nil {
+		hub.Close()
+	}
+}
+
+// Publish sends payload to the clients subscribed to topic on the Default
+// hub, as a "message" event:
+//
+//	err := realtime.Publish("orders:42", order)
+//
+// Strings and byte slices, such as rendered HTML, are sent as they are;
+// other payloads are encoded as JSON.
+func Publish(topic string, payload any) error {
+	return Default().Publish(topic, payload)
+}
+
+// PublishEvent is Publish with an event name, which clients listen for
+// with addEventListener
+func PublishEvent(topic, event string, payload any) error {
+	return Default().PublishEvent(topic, event, payload)
+}
+
+// Publish sends payload to topic's subscribers as a "message" event
+func (h *Hub) Publish(topic string, payload any) error {
+	return h.PublishEvent(topic, "", payload)
+}
+
+// PublishEvent sends payload to topic's subscribers as the named event
+func (h *Hub) PublishEvent(topic, event string, payload any) error {
+	msg := Message{Topic: topic, Event: event}
+	switch v := payload.(type) {
+	case string:
+		msg.Data = v
+	case []byte:
+		msg.Data = string(v)
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return errors.ErrPublishEvent.Wrap(err).WithValue(topic)
+		}
+		msg.Data = string(data)
+	}
+
+	if h.broker == nil {
+		h.deliver(msg)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := h.broker.Publish(ctx, msg); err != nil {
+		return errors.ErrPublishEvent.Wrap(err).WithValue(topic)
+	}
+	return nil
+}
+
+// Subscribe starts receiving the messages published to topics. Close the
+// subscription when done with it.
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	sub := &Subscription{hub: h, topics: topics, ch: make(chan Message, SubscriptionBuffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.ch)
+		return sub
+	}
+	for _, topic := range topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = map[*Subscription]struct{}{}
+		}
+		h.topics[topic][sub] = struct{}{}
+	}
+	return sub
+}
+
+// Subscribers returns how many subscriptions topic has on this instance
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Close stops listening to the broker and closes every subscription
+func (h *Hub) Close() {
+	h.stop()
+	<-h.done
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	closed := map[*Subscription]bool{}
+	for _, subs := range h.topics {
+		for sub := range subs {
+			if !closed[sub] {
+				closed[sub] = true
+				close(sub.ch)
+			}
+		}
+	}
+	h.topics = map[string]map[*Subscription]struct{}{}
+}
+
+// deliver queues msg for each subscription of its topic, dropping it for
+// those that are full
+func (h *Hub) deliver(msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.topics[msg.Topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			logger.Get().With("topic", msg.Topic).Warn("Dropped realtime event for a slow client on %s", msg.Topic)
+		}
+	}
+}
+
+// listen delivers the broker's messages until ctx is done, reconnecting
+// with a growing delay when the connection fails
+func (h *Hub) listen(ctx context.Context) {
+	defer close(h.done)
+	delay := time.Second
+	for {
+		start := time.Now()
+		err := h.broker.Listen(ctx, h.deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			delay = time.Second
+		}
+		logger.Get().Warn("Realtime broker disconnected: %v; reconnecting in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, 30*time.Second)
+	}
+}
+
+// Subscription receives the messages published to its topics
+type Subscription struct {
+	hub    *Hub
+	topics []string
+	ch     chan Message
+	once   sync.Once
+}
+
+// Messages returns the channel messages arrive on. It is closed when the
+// subscription or its hub is.
+func (s *Subscription) Messages() <-chan Message {
+	return s.ch
+}
+
+// Close stops the subscription and closes its channel
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		h := s.hub
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.closed {
+			return
+		}
+		for _, topic := range s.topics {
+			delete(h.topics[topic], s)
+			if len(h.topics[topic]) == 0 {
+				delete(h.topics, topic)
+			}
+		}
+		close(s.ch)
+	})
+}
@@ -0,0 +1,116 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive waits for the next message on sub
+func receive(t *testing.T, sub *Subscription) Message {
+	t.Helper()
+	select {
+	case msg, ok := <-sub.Messages():
+		require.True(t, ok, "subscription closed")
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+		return Message{}
+	}
+}
+
+// TestHub_Publish tests fanning messages out to the subscribers of their
+// topic only
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub(nil)
+	t.Cleanup(hub.Close)
+
+	first := hub.Subscribe("orders:42")
+	second := hub.Subscribe("orders:42", "orders:43")
+	other := hub.Subscribe("orders:43")
+	assert.Equal(t, 2, hub.Subscribers("orders:42"))
+
+	require.NoError(t, hub.Publish("orders:42", map[string]any{"status": "shipped"}))
+	require.NoError(t, hub.PublishEvent("orders:42", "note", "<p>Left at the door</p>"))
+
+	for _, sub := range []*Subscription{first, second} {
+		assert.Equal(t, Message{Topic: "orders:42", Data: `{"status":"shipped"}`}, receive(t, sub))
+		assert.Equal(t, Message{Topic: "orders:42", Event: "note", Data: "<p>Left at the door</p>"}, receive(t, sub))
+	}
+	assert.Empty(t, other.Messages())
+}
+
+// TestHub_PublishUnencodable tests that a payload JSON can't encode fails
+func TestHub_PublishUnencodable(t *testing.T) {
+	hub := NewHub(nil)
+	t.Cleanup(hub.Close)
+
+	assert.Error(t, hub.Publish("orders:42", make(chan int)))
+}
+
+// TestHub_SlowSubscriber tests dropping messages for a full subscription
+// without blocking the publisher
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := NewHub(nil)
+	t.Cleanup(hub.Close)
+	sub := hub.Subscribe("ticks")
+
+	for i := range SubscriptionBuffer + 10 {
+		require.NoError(t, hub.Publish("ticks", i))
+	}
+	assert.Len(t, sub.Messages(), SubscriptionBuffer)
+	assert.Equal(t, "0", receive(t, sub).Data)
+}
+
+// TestSubscription_Close tests that a closed subscription stops receiving
+// and its channel is closed
+func TestSubscription_Close(t *testing.T) {
+	hub := NewHub(nil)
+	t.Cleanup(hub.Close)
+	sub := hub.Subscribe("orders:42")
+
+	sub.Close()
+	sub.Close()
+	assert.Equal(t, 0, hub.Subscribers("orders:42"))
+	require.NoError(t, hub.Publish("orders:42", "ignored"))
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+}
+
+// TestHub_Close tests that closing a hub closes its subscriptions, and
+// those made after
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(nil)
+	sub := hub.Subscribe("orders:42")
+
+	hub.Close()
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+	sub.Close()
+
+	_, ok = <-hub.Subscribe("orders:42").Messages()
+	assert.False(t, ok)
+}
+
+// TestDefault tests the package functions on the hub set with Use, and
+// Close without a hub
+func TestDefault(t *testing.T) {
+	Reset()
+	Close()
+
+	hub := NewHub(nil)
+	Use(hub)
+	t.Cleanup(Reset)
+	sub := Default().Subscribe("orders:42")
+
+	require.NoError(t, Publish("orders:42", "placed"))
+	require.NoError(t, PublishEvent("orders:42", "status", "shipped"))
+	assert.Equal(t, "placed", receive(t, sub).Data)
+	assert.Equal(t, "status", receive(t, sub).Event)
+
+	Close()
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+}
@@ -0,0 +1,91 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cstone-io/twine/internal/redis"
+)
+
+// redisPrefix starts the channel of each topic. Redis channels are shared
+// by every database on a server, whatever the URL selects.
+const redisPrefix = "realtime:"
+
+// RedisBroker is a Broker on Redis pub/sub. Each topic is published to a
+// "realtime:" channel, and every hub listens to all of them on one
+// connection, keeping the messages of topics it has subscribers for.
+type RedisBroker struct {
+	client *redis.Client
+}
+
+// NewRedisBroker creates a RedisBroker for a redis:// or rediss:// URL,
+// such as redis://:password@localhost:6379/0. It connects when first used.
+func NewRedisBroker(rawURL string) (*RedisBroker, error) {
+	client, err := redis.NewClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisBroker{client: client}, nil
+}
+
+// Publish sends msg to the topic's channel
+func (b *RedisBroker) Publish(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.Do(ctx, []string{"PUBLISH", redisPrefix + msg.Topic, string(payload)})
+	return err
+}
+
+// Listen subscribes to every topic's channel on its own connection and
+// calls deliver with the messages that arrive, until ctx is done or the
+// connection fails
+func (b *RedisBroker) Listen(ctx context.Context, deliver func(Message)) error {
+	conn, err := b.client.Dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Send(ctx, []string{"PSUBSCRIBE", redisPrefix + "*"}); err != nil {
+		return err
+	}
+	if _, err := conn.Receive(); err != nil {
+		return err
+	}
+
+	// Closing the connection ends the blocked Receive
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	for {
+		reply, err := conn.Receive()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		// A message is ["pmessage", pattern, channel, payload]
+		parts, ok := reply.([]any)
+		if !ok || len(parts) != 4 || parts[0] != "pmessage" {
+			continue
+		}
+		payload, _ := parts[3].(string)
+		var msg Message
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			continue
+		}
+		deliver(msg)
+	}
+}
+
+// Close closes the idle connections
+func (b *RedisBroker) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,80 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// listening waits until n hubs have subscribed to the broker's channels
+func listening(t *testing.T, server *testutil.FakeRedis, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		count := 0
+		for _, command := range server.Commands() {
+			if command == "PSUBSCRIBE realtime:*" {
+				count++
+			}
+		}
+		return count >= n
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// TestRedisBroker tests delivering messages published on one instance to
+// the subscribers on every instance
+func TestRedisBroker(t *testing.T) {
+	server := testutil.SetupFakeRedis(t)
+	newHub := func() *Hub {
+		broker, err := NewRedisBroker(server.URL)
+		require.NoError(t, err)
+		hub := NewHub(broker)
+		t.Cleanup(func() {
+			hub.Close()
+			broker.Close()
+		})
+		return hub
+	}
+	a, b := newHub(), newHub()
+	listening(t, server, 2)
+
+	onA := a.Subscribe("orders:42")
+	onB := b.Subscribe("orders:42")
+	require.NoError(t, a.PublishEvent("orders:42", "status", map[string]string{"status": "shipped"}))
+
+	want := Message{Topic: "orders:42", Event: "status", Data: `{"status":"shipped"}`}
+	assert.Equal(t, want, receive(t, onA))
+	assert.Equal(t, want, receive(t, onB))
+	assert.Contains(t, server.Commands(), `PUBLISH realtime:orders:42 {"topic":"orders:42","event":"status","data":"{\"status\":\"shipped\"}"}`)
+}
+
+// TestRedisBroker_Unavailable tests that publishing fails while Redis is
+// unreachable, and closing the hub stops its reconnecting
+func TestRedisBroker_Unavailable(t *testing.T) {
+	broker, err := NewRedisBroker("redis://127.0.0.1:1")
+	require.NoError(t, err)
+	hub := NewHub(broker)
+
+	assert.Error(t, hub.Publish("orders:42", "placed"))
+	hub.Close()
+}
+
+// TestNew tests choosing the broker from the configuration
+func TestNew(t *testing.T) {
+	broker, err := New(config.RealtimeConfig{Driver: config.RealtimeMemory})
+	require.NoError(t, err)
+	assert.Nil(t, broker)
+
+	broker, err = New(config.RealtimeConfig{Driver: config.RealtimeRedis, RedisURL: "redis://localhost:6379/0"})
+	require.NoError(t, err)
+	assert.IsType(t, &RedisBroker{}, broker)
+
+	_, err = New(config.RealtimeConfig{Driver: config.RealtimeRedis, RedisURL: "localhost:6379"})
+	assert.Error(t, err)
+	_, err = New(config.RealtimeConfig{Driver: "nats"})
+	assert.Error(t, err)
+}
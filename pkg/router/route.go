@@ -15,6 +15,9 @@ const (
 	POST   Method = "POST "
 	PUT    Method = "PUT "
 	DELETE Method = "DELETE "
+
+	// ANY matches every method
+	ANY Method = ""
 )
 
 // Route represents an HTTP route with handler and metadata
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/cstone-io/twine/pkg/config"
 	"github.com/cstone-io/twine/pkg/kit"
 	"github.com/cstone-io/twine/pkg/logger"
 	"github.com/cstone-io/twine/pkg/middleware"
@@ -29,6 +30,12 @@ func NewRouter(prefix string) *Router {
 	}
 }
 
+// NewRouterFromConfig creates a root Router mounted at the configured
+// routes root
+func NewRouterFromConfig(cfg *config.Config) *Router {
+	return NewRouter(cfg.Routes.Root)
+}
+
 // Sub adds a child router to this router
 func (r *Router) Sub(sub *Router) {
 	r.mu.Lock()
@@ -73,6 +80,12 @@ func (r *Router) Delete(pattern string, h kit.HandlerFunc) {
 	r.handle(DELETE, pattern, h)
 }
 
+// Any registers a route for every method, such as a fallback that should
+// answer POST with the same page as GET rather than 405
+func (r *Router) Any(pattern string, h kit.HandlerFunc) {
+	r.handle(ANY, pattern, h)
+}
+
 func (r *Router) initializeRoutes(prefix string, routes *[]Route) {
 	for _, sub := range r.Children {
 		fullPrefix := trim(prefix) + trim(sub.Prefix)
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/cstone-io/twine/pkg/config"
 	"github.com/cstone-io/twine/pkg/kit"
 	"github.com/stretchr/testify/assert"
 )
@@ -35,6 +36,15 @@ func TestRouter_NewRouter(t *testing.T) {
 	})
 }
 
+// TestRouter_NewRouterFromConfig tests mounting routes at the routes root
+func TestRouter_NewRouterFromConfig(t *testing.T) {
+	r := NewRouterFromConfig(&config.Config{Routes: config.RoutesConfig{Root: "/app/"}})
+	assert.Equal(t, "/app", r.Prefix)
+
+	r = NewRouterFromConfig(&config.Config{Routes: config.RoutesConfig{Root: "/"}})
+	assert.Equal(t, "", r.Prefix)
+}
+
 // TestRouter_Get tests GET route registration
 func TestRouter_Get(t *testing.T) {
 	t.Run("registers GET route", func(t *testing.T) {
@@ -108,6 +118,40 @@ func TestRouter_Delete(t *testing.T) {
 	})
 }
 
+// TestRouter_Any tests registering a route for every method
+func TestRouter_Any(t *testing.T) {
+	t.Run("registers route without a method", func(t *testing.T) {
+		r := NewRouter("")
+
+		r.Any("/docs/", func(k *kit.Kit) error {
+			return k.Text(404, "Not found")
+		})
+
+		assert.Len(t, r.Routes, 1)
+		assert.Equal(t, ANY, r.Routes[0].Method)
+		assert.Equal(t, "/docs/", r.Routes[0].FullPath())
+	})
+
+	t.Run("answers methods other routes on the path don't handle", func(t *testing.T) {
+		r := NewRouter("")
+
+		r.Get("/docs/{slug}", func(k *kit.Kit) error {
+			return k.Text(200, "page")
+		})
+		r.Any("/docs/", func(k *kit.Kit) error {
+			return k.Text(404, "Not found")
+		})
+
+		mux := r.InitializeAsRoot()
+
+		for method, want := range map[string]int{"GET": 200, "POST": 404, "DELETE": 404} {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(method, "/docs/intro", nil))
+			assert.Equal(t, want, w.Code, method)
+		}
+	})
+}
+
 // TestRouter_Use tests middleware registration
 func TestRouter_Use(t *testing.T) {
 	t.Run("adds single middleware", func(t *testing.T) {
@@ -0,0 +1,88 @@
+// Package sanitize cleans user-generated HTML, such as comments written in
+// a rich text editor, so it can be rendered or swapped into a page without
+// becoming an injection vector. A Policy lists the elements and attributes
+// kept; everything else is removed and text is escaped.
+package sanitize
+
+import "sync"
+
+// Policy is what Sanitize keeps. Scripts, styles, frames and event handler
+// attributes such as onclick are always removed, whatever the policy.
+type Policy struct {
+	// Elements maps the elements kept to the attributes they keep
+	Elements map[string][]string
+	// Attributes are kept on every element of Elements
+	Attributes []string
+	// URLSchemes are the schemes href, src and other URL attributes may
+	// use. Relative URLs are always kept; other URLs, such as javascript:
+	// ones, remove their attribute.
+	URLSchemes []string
+	// LinkRel, when set, is the rel of every link with an href, replacing
+	// the one given, e.g. "nofollow noopener"
+	LinkRel string
+}
+
+// UGCPolicy keeps the formatting of user-generated content: paragraphs,
+// headings, emphasis, lists, quotes, code, tables, links and images. Links
+// may be http, https or mailto and are marked nofollow.
+func UGCPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"p": nil, "br": nil, "hr": nil, "span": nil, "div": nil,
+			"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+			"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "s": nil,
+			"del": nil, "ins": nil, "mark": nil, "small": nil, "sub": nil, "sup": nil,
+			"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+			"blockquote": {"cite"}, "q": {"cite"}, "code": nil, "pre": nil, "kbd": nil,
+			"table": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+			"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"}, "caption": nil,
+			"a":   {"href"},
+			"img": {"src", "alt", "width", "height"},
+		},
+		Attributes: []string{"title"},
+		URLSchemes: []string{"http", "https", "mailto"},
+		LinkRel:    "nofollow noopener",
+	}
+}
+
+// StrictPolicy removes every element, keeping only the text
+func StrictPolicy() *Policy {
+	return &Policy{}
+}
+
+var (
+	mu       sync.Mutex
+	instance *Policy
+)
+
+// Get returns the application's policy, UGCPolicy unless another was set
+// with Use
+func Get() *Policy {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if instance == nil {
+		instance = UGCPolicy()
+	}
+	return instance
+}
+
+// Use makes policy the one returned by Get, and so the one the sanitize
+// template function applies
+func Use(policy *Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = policy
+}
+
+// Reset forgets the current policy, so Get returns UGCPolicy again
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = nil
+}
+
+// Sanitize cleans html with the policy returned by Get
+func Sanitize(html string) string {
+	return Get().Sanitize(html)
+}
@@ -0,0 +1,189 @@
+package sanitize
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// rawText are the elements whose content isn't markup, removed with their
+// content whatever the policy
+var rawText = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Textarea: true, atom.Title: true, atom.Xmp: true,
+	atom.Iframe: true, atom.Noembed: true, atom.Noframes: true, atom.Noscript: true,
+}
+
+// dangerous are elements never kept, even when a policy lists them
+var dangerous = map[atom.Atom]bool{
+	atom.Object: true, atom.Embed: true, atom.Applet: true, atom.Base: true, atom.Link: true,
+	atom.Meta: true, atom.Form: true, atom.Frame: true, atom.Frameset: true, atom.Svg: true,
+	atom.Math: true, atom.Template: true, atom.Plaintext: true,
+}
+
+// void are the elements without content or a closing tag
+var void = map[atom.Atom]bool{
+	atom.Area: true, atom.Br: true, atom.Col: true, atom.Hr: true, atom.Img: true, atom.Wbr: true,
+}
+
+// urlAttributes are the attributes holding URLs, checked against
+// Policy.URLSchemes
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "action": true, "formaction": true,
+	"poster": true, "background": true, "longdesc": true, "xlink:href": true,
+}
+
+// Sanitize returns html keeping only the elements and attributes of the
+// policy. Text is escaped, comments are removed and elements left open are
+// closed, so the result can't break out of the element it's rendered in.
+func (p *Policy) Sanitize(s string) string {
+	var b strings.Builder
+	var open []string
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		// Comments, doctypes and CDATA sections are dropped
+		switch z.Next() {
+		case html.ErrorToken:
+			// The end of s. A tag left unterminated is dropped, as browsers do.
+			return closeAll(&b, open)
+		case html.TextToken:
+			b.WriteString(html.EscapeString(string(z.Text())))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			if rawText[t.DataAtom] {
+				// The tokenizer reads the content as text up to the
+				// closing tag
+				skipRawText(z, t.Data)
+				continue
+			}
+			if t.DataAtom == atom.Plaintext {
+				return closeAll(&b, open)
+			}
+			open = p.writeStartTag(&b, t, open)
+		case html.EndTagToken:
+			open = p.writeEndTag(&b, z.Token(), open)
+		}
+	}
+}
+
+// writeStartTag writes t if the policy keeps it and returns the elements
+// left open
+func (p *Policy) writeStartTag(b *strings.Builder, t html.Token, open []string) []string {
+	if !p.keepElement(t) {
+		return open
+	}
+
+	b.WriteString("<" + t.Data)
+	seen := map[string]bool{}
+	for _, attr := range t.Attr {
+		if seen[attr.Key] || !p.keepAttribute(t.Data, attr) {
+			continue
+		}
+		seen[attr.Key] = true
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if t.DataAtom == atom.A && p.LinkRel != "" && seen["href"] {
+		b.WriteString(` rel="` + html.EscapeString(p.LinkRel) + `"`)
+	}
+	b.WriteString(">")
+
+	if void[t.DataAtom] {
+		return open
+	}
+	return append(open, t.Data)
+}
+
+// writeEndTag closes t if it's open, along with the elements opened inside
+// it, and returns the elements left open
+func (p *Policy) writeEndTag(b *strings.Builder, t html.Token, open []string) []string {
+	if !p.keepElement(t) {
+		return open
+	}
+	i := slices.Index(open, t.Data)
+	if i < 0 {
+		return open
+	}
+	for j := len(open) - 1; j >= i; j-- {
+		b.WriteString("</" + open[j] + ">")
+	}
+	return open[:i]
+}
+
+// keepElement reports whether the policy keeps the element of t
+func (p *Policy) keepElement(t html.Token) bool {
+	_, ok := p.Elements[t.Data]
+	return ok && !dangerous[t.DataAtom] && !rawText[t.DataAtom]
+}
+
+// keepAttribute reports whether the policy keeps attr on element
+func (p *Policy) keepAttribute(element string, attr html.Attribute) bool {
+	if !validAttributeName(attr.Key) || strings.HasPrefix(attr.Key, "on") {
+		return false
+	}
+	if element == "a" && attr.Key == "rel" && p.LinkRel != "" {
+		return false
+	}
+	if !slices.Contains(p.Elements[element], attr.Key) && !slices.Contains(p.Attributes, attr.Key) {
+		return false
+	}
+	if urlAttributes[attr.Key] {
+		return p.allowURL(attr.Val)
+	}
+	return true
+}
+
+// allowURL reports whether u is relative or uses one of the policy's
+// schemes
+func (p *Policy) allowURL(u string) bool {
+	// Browsers ignore whitespace and control characters in schemes, as in
+	// "java\tscript:"
+	u = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, u)
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	return slices.Contains(p.URLSchemes, strings.ToLower(u[:i]))
+}
+
+// skipRawText reads past the content and closing tag of the raw text
+// element name
+func skipRawText(z *html.Tokenizer, name string) {
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return
+		case html.EndTagToken:
+			if tag, _ := z.TagName(); string(tag) == name {
+				return
+			}
+		}
+	}
+}
+
+// closeAll closes the open elements and returns the sanitized HTML
+func closeAll(b *strings.Builder, open []string) string {
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// validAttributeName reports whether name is made of letters, digits and
+// the punctuation attribute names use
+func validAttributeName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') && c != '-' && c != '_' && c != ':' {
+			return false
+		}
+	}
+	return name != ""
+}
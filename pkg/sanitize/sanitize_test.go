@@ -0,0 +1,80 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPolicy_Sanitize tests cleaning HTML with the UGC policy
+func TestPolicy_Sanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"keeps formatting", `<p>Hello <b>world</b><br/>again</p>`, `<p>Hello <b>world</b><br>again</p>`},
+		{"escapes text", `1 < 2 & "quotes"`, `1 &lt; 2 &amp; &#34;quotes&#34;`},
+		{"keeps entities", `&lt;b&gt; &amp; &copy;`, `&lt;b&gt; &amp; ©`},
+		{"removes scripts with their content", `a<script>alert(1)</script>b`, `ab`},
+		{"removes scripts in any case", `a<SCRIPT type="x">alert("</b>")</ScRiPt >b`, `ab`},
+		{"removes styles", `<style>body{display:none}</style>text`, `text`},
+		{"removes elements not in the policy but keeps their text", `<div><form action="/x"><input name="a">Hi</form></div>`, `<div>Hi</div>`},
+		{"removes event handlers", `<p onclick="alert(1)" title="t">x</p>`, `<p title="t">x</p>`},
+		{"removes attributes not in the policy", `<p class="big" style="color:red">x</p>`, `<p>x</p>`},
+		{"removes javascript URLs", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"removes obfuscated javascript URLs", `<a href="  JaVa&#x09;script&colon;alert(1)">x</a>`, `<a>x</a>`},
+		{"removes data URLs", `<img src="data:text/html;base64,PHNjcmlwdD4=">`, `<img>`},
+		{"keeps relative and allowed URLs", `<a href="/posts?id=1&amp;x=2">a</a><a href="mailto:a@b.c">b</a>`,
+			`<a href="/posts?id=1&amp;x=2" rel="nofollow noopener">a</a><a href="mailto:a@b.c" rel="nofollow noopener">b</a>`},
+		{"replaces rel", `<a href="https://example.com" rel="opener">x</a>`, `<a href="https://example.com" rel="nofollow noopener">x</a>`},
+		{"escapes attribute values", `<img alt='"><script>' src=x.png>`, `<img alt="&#34;&gt;&lt;script&gt;" src="x.png">`},
+		{"keeps the first of repeated attributes", `<img src="a.png" src="javascript:x">`, `<img src="a.png">`},
+		{"removes comments", `a<!-- <script>x</script> -->b`, `ab`},
+		{"removes doctypes and CDATA", `<!DOCTYPE html><![CDATA[x]]>a`, `a`},
+		{"closes open elements", `<ul><li><b>one`, `<ul><li><b>one</b></li></ul>`},
+		{"closes elements opened inside closed ones", `<p><em>a</p>b`, `<p><em>a</em></p>b`},
+		{"ignores closing tags of elements not open", `</div></p>a</b>`, `a`},
+		{"drops unterminated tags", `a<b title="x`, `a`},
+		{"escapes stray brackets", `a < b <3 >`, `a &lt; b &lt;3 &gt;`},
+		{"removes bogus comments as browsers do", `a</ c>b<?php x ?>c`, `abc`},
+		{"removes svg", `<svg><a href="/x">x</a></svg>`, `<a href="/x" rel="nofollow noopener">x</a>`},
+	}
+
+	policy := UGCPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Sanitize(tt.in))
+		})
+	}
+}
+
+// TestPolicy_SanitizeCustom tests policies configured by applications
+func TestPolicy_SanitizeCustom(t *testing.T) {
+	t.Run("strict policies keep only text", func(t *testing.T) {
+		assert.Equal(t, "Hello world", StrictPolicy().Sanitize(`<p>Hello <b>world</b></p><script>x</script>`))
+	})
+
+	t.Run("keeps the configured elements and attributes", func(t *testing.T) {
+		policy := &Policy{
+			Elements:   map[string][]string{"a": {"href"}, "span": {"data-id"}, "script": nil},
+			Attributes: []string{"class"},
+			URLSchemes: []string{"https"},
+		}
+		assert.Equal(t,
+			`<a href="https://example.com" class="link">x</a><span data-id="1" class="tag">y</span><a>z</a>`,
+			policy.Sanitize(`<a href="https://example.com" class="link" rel="me">x</a><span data-id="1" class="tag" onmouseover="x">y</span><script>s</script><a href="http://example.com">z</a>`),
+			"scripts are removed even when listed")
+	})
+}
+
+// TestGet tests setting the application's policy
+func TestGet(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+
+	assert.Equal(t, `<b>x</b>`, Sanitize(`<b>x</b>`), "UGCPolicy is the default")
+
+	Use(StrictPolicy())
+	assert.Equal(t, `x`, Sanitize(`<b>x</b>`))
+}
@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TestParseEvery tests parsing intervals and aligning their ticks
+func TestParseEvery(t *testing.T) {
+	s, err := ParseEvery("5m")
+	require.NoError(t, err)
+	assert.Equal(t, at("2026-03-01T10:05:00Z"), s.Next(at("2026-03-01T10:02:30Z")))
+	assert.Equal(t, at("2026-03-01T10:10:00Z"), s.Next(at("2026-03-01T10:05:00Z")))
+	assert.Equal(t, "every 5m0s", s.(interval).String())
+
+	for _, spec := range []string{"", "5", "soon", "500ms", "-1m"} {
+		_, err := ParseEvery(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// TestParseCron tests the next run of cron expressions
+func TestParseCron(t *testing.T) {
+	// 2026-03-01 is a Sunday
+	from := at("2026-03-01T10:02:30Z")
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"* * * * *", "2026-03-01T10:03:00Z"},
+		{"*/15 * * * *", "2026-03-01T10:15:00Z"},
+		{"0 3 * * *", "2026-03-02T03:00:00Z"},
+		{"30 9-17/4 * * *", "2026-03-01T13:30:00Z"},
+		{"0 0 1,15 * *", "2026-03-15T00:00:00Z"},
+		{"0 9 * * mon-fri", "2026-03-02T09:00:00Z"},
+		{"0 9 * * 7", "2026-03-08T09:00:00Z"},
+		{"0 0 1 jan *", "2027-01-01T00:00:00Z"},
+		{"0 0 13 * fri", "2026-03-06T00:00:00Z"},
+		{"0 0 31 * *", "2026-03-31T00:00:00Z"},
+		{"0 0 29 2 *", "2028-02-29T00:00:00Z"},
+		{"@hourly", "2026-03-01T11:00:00Z"},
+		{"@daily", "2026-03-02T00:00:00Z"},
+		{"@weekly", "2026-03-08T00:00:00Z"},
+		{"@monthly", "2026-04-01T00:00:00Z"},
+		{"@yearly", "2027-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseCron(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, at(tt.want), s.Next(from))
+		})
+	}
+}
+
+// TestParseCron_Never tests that an expression that never matches has no
+// next run
+func TestParseCron_Never(t *testing.T) {
+	s, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(at("2026-03-01T00:00:00Z")).IsZero())
+}
+
+// TestParseCron_Invalid tests rejecting malformed expressions
+func TestParseCron_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@sometimes",
+	} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// TestCron_Location tests evaluating an expression in a time zone
+func TestCron_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	task := Cron("0 3 * * *").In(loc)
+	next := task.schedule.Next(at("2026-03-01T00:00:00Z"))
+	assert.Equal(t, at("2026-03-01T01:00:00Z"), next.UTC())
+}
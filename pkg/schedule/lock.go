@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cstone-io/twine/internal/redis"
+	"github.com/cstone-io/twine/pkg/database"
+)
+
+// Locker hands out expiring locks shared by every instance of an
+// application. MemoryLocker, DatabaseLocker and RedisLocker implement it.
+type Locker interface {
+	// Acquire takes the lock on key for owner until ttl passes, reporting
+	// false if someone else holds it
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release gives up owner's lock on key before it expires
+	Release(ctx context.Context, key, owner string) error
+}
+
+// MemoryLocker is a Locker within one process, for applications running a
+// single instance and for tests
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+	now   func() time.Time
+}
+
+type memoryLock struct {
+	owner string
+	until time.Time
+}
+
+// NewMemoryLocker creates an empty MemoryLocker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: map[string]memoryLock{}, now: time.Now}
+}
+
+// Acquire takes the lock on key for owner until ttl passes
+func (l *MemoryLocker) Acquire(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for k, lock := range l.locks {
+		if !now.Before(lock.until) {
+			delete(l.locks, k)
+		}
+	}
+	if _, ok := l.locks[key]; ok {
+		return false, nil
+	}
+	l.locks[key] = memoryLock{owner: owner, until: now.Add(ttl)}
+	return true, nil
+}
+
+// Release gives up owner's lock on key
+func (l *MemoryLocker) Release(_ context.Context, key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks[key].owner == owner {
+		delete(l.locks, key)
+	}
+	return nil
+}
+
+// Lock is a row of the schedule_locks table used by DatabaseLocker
+type Lock struct {
+	Name        string    `gorm:"primaryKey;size:255"`
+	Owner       string    `gorm:"size:64"`
+	LockedUntil time.Time `gorm:"index"`
+}
+
+// TableName is schedule_locks
+func (Lock) TableName() string {
+	return "schedule_locks"
+}
+
+// Migration creates the schedule_locks table used by DatabaseLocker. It is
+// registered when the package is imported.
+var Migration = database.NewMigrationBuilder().
+	Model(&Lock{}).
+	Name("schedule_locks").
+	Down(func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&Lock{})
+	}).
+	Build()
+
+func init() {
+	database.RegisterMigration(Migration)
+}
+
+// DatabaseLocker is a Locker in the schedule_locks table. Lock names are
+// the primary key, so only one instance's insert succeeds.
+type DatabaseLocker struct {
+	db *gorm.DB
+}
+
+// NewDatabaseLocker creates a DatabaseLocker on db, or on database.GORM()
+// if db is nil
+func NewDatabaseLocker(db *gorm.DB) *DatabaseLocker {
+	return &DatabaseLocker{db: db}
+}
+
+func (l *DatabaseLocker) client(ctx context.Context) *gorm.DB {
+	db := l.db
+	if db == nil {
+		db = database.GORM()
+	}
+	return db.WithContext(ctx)
+}
+
+// Acquire takes the lock on key for owner until ttl passes, clearing
+// expired locks first
+func (l *DatabaseLocker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	db := l.client(ctx)
+	now := time.Now().UTC()
+
+	if err := db.Where("locked_until <= ?", now).Delete(&Lock{}).Error; err != nil {
+		return false, err
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Lock{Name: key, Owner: owner, LockedUntil: now.Add(ttl)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Release gives up owner's lock on key
+func (l *DatabaseLocker) Release(ctx context.Context, key, owner string) error {
+	return l.client(ctx).Where("name = ? AND owner = ?", key, owner).Delete(&Lock{}).Error
+}
+
+// redisLockPrefix namespaces RedisLocker's keys
+const redisLockPrefix = "schedule:lock:"
+
+// RedisLocker is a Locker on a Redis server, using SET NX with an expiry
+type RedisLocker struct {
+	client *redis.Client
+}
+
+// NewRedisLocker creates a RedisLocker for a redis:// or rediss:// URL. It
+// connects when first used.
+func NewRedisLocker(rawURL string) (*RedisLocker, error) {
+	client, err := redis.NewClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisLocker{client: client}, nil
+}
+
+// Acquire takes the lock on key for owner until ttl passes
+func (l *RedisLocker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	ms := max(ttl.Milliseconds(), 1)
+	replies, err := l.client.Do(ctx, []string{"SET", redisLockPrefix + key, owner, "NX", "PX", strconv.FormatInt(ms, 10)})
+	if err != nil {
+		return false, err
+	}
+	return replies[0] != nil, nil
+}
+
+// Release gives up owner's lock on key. A lock that expired and was taken
+// by another owner between the check and the delete is released too, which
+// only lets a run start early.
+func (l *RedisLocker) Release(ctx context.Context, key, owner string) error {
+	replies, err := l.client.Do(ctx, []string{"GET", redisLockPrefix + key})
+	if err != nil || replies[0] != owner {
+		return err
+	}
+	_, err = l.client.Do(ctx, []string{"DEL", redisLockPrefix + key})
+	return err
+}
+
+// Close closes the idle connections
+func (l *RedisLocker) Close() error {
+	return l.client.Close()
+}
@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+)
+
+// setupDatabaseLocker creates a DatabaseLocker on a new SQLite database
+func setupDatabaseLocker(t *testing.T) *DatabaseLocker {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&Lock{}))
+	return NewDatabaseLocker(db)
+}
+
+// testLocker checks the behaviour every Locker shares
+func testLocker(t *testing.T, locker Locker) {
+	ctx := context.Background()
+
+	ok, err := locker.Acquire(ctx, "report", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = locker.Acquire(ctx, "report", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "the lock is held by a")
+
+	ok, err = locker.Acquire(ctx, "cleanup", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "locks on other keys are independent")
+
+	require.NoError(t, locker.Release(ctx, "report", "b"))
+	ok, err = locker.Acquire(ctx, "report", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "only the owner releases a lock")
+
+	require.NoError(t, locker.Release(ctx, "report", "a"))
+	ok, err = locker.Acquire(ctx, "report", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestMemoryLocker tests locking within a process
+func TestMemoryLocker(t *testing.T) {
+	testLocker(t, NewMemoryLocker())
+}
+
+// TestMemoryLocker_Expiry tests that an expired lock can be taken
+func TestMemoryLocker_Expiry(t *testing.T) {
+	ctx := context.Background()
+	locker := NewMemoryLocker()
+	now := time.Now()
+	locker.now = func() time.Time { return now }
+
+	ok, _ := locker.Acquire(ctx, "report", "a", time.Minute)
+	require.True(t, ok)
+
+	now = now.Add(time.Minute)
+	ok, _ = locker.Acquire(ctx, "report", "b", time.Minute)
+	assert.True(t, ok)
+	assert.Len(t, locker.locks, 1)
+}
+
+// TestDatabaseLocker tests locking with the schedule_locks table
+func TestDatabaseLocker(t *testing.T) {
+	testLocker(t, setupDatabaseLocker(t))
+}
+
+// TestDatabaseLocker_Expiry tests that expired locks are cleared
+func TestDatabaseLocker_Expiry(t *testing.T) {
+	ctx := context.Background()
+	locker := setupDatabaseLocker(t)
+	require.NoError(t, locker.db.Create(&Lock{Name: "old", Owner: "a", LockedUntil: time.Now().UTC().Add(-time.Second)}).Error)
+	require.NoError(t, locker.db.Create(&Lock{Name: "report", Owner: "a", LockedUntil: time.Now().UTC().Add(-time.Second)}).Error)
+
+	ok, err := locker.Acquire(ctx, "report", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	var locks []Lock
+	require.NoError(t, locker.db.Find(&locks).Error)
+	require.Len(t, locks, 1)
+	assert.Equal(t, "b", locks[0].Owner)
+}
+
+// TestRedisLocker tests locking on a Redis server
+func TestRedisLocker(t *testing.T) {
+	server := testutil.SetupFakeRedis(t)
+	locker, err := NewRedisLocker(server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { locker.Close() })
+
+	testLocker(t, locker)
+	assert.Contains(t, server.Commands(), "SET schedule:lock:report a NX PX 60000")
+}
@@ -0,0 +1,229 @@
+// Package schedule runs recurring tasks on an interval or a cron
+// expression. Each tick runs on one instance of the application, and a run
+// still going when the next tick comes makes that tick skip.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// DefaultTimeout bounds a task's run unless its Builder sets a Timeout
+const DefaultTimeout = 30 * time.Minute
+
+// Task is a registered recurring task
+type Task struct {
+	// Name identifies the task in logs, locks and Stats
+	Name string
+	// Schedule decides when the task runs
+	Schedule Schedule
+	// Timeout bounds each run
+	Timeout time.Duration
+	// Fn does the work
+	Fn func(ctx context.Context) error
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats are a task's counters since the process started
+type Stats struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	// Runs counts finished runs, including failed ones
+	Runs int64 `json:"runs"`
+	// Failures counts runs that returned an error, panicked or timed out
+	Failures int64 `json:"failures"`
+	// Skipped counts ticks passed over because the previous run was still
+	// going
+	Skipped      int64         `json:"skipped"`
+	LastRun      *time.Time    `json:"last_run,omitempty"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+	// Next is when the task next runs, once a Scheduler is running it
+	Next *time.Time `json:"next,omitempty"`
+}
+
+// Stats returns a copy of t's counters
+func (t *Task) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.stats
+	stats.Name = t.Name
+	stats.Schedule = fmt.Sprint(t.Schedule)
+	return stats
+}
+
+func (t *Task) record(start time.Time, duration time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Runs++
+	t.stats.LastRun = &start
+	t.stats.LastDuration = duration
+	t.stats.LastError = ""
+	if err != nil {
+		t.stats.Failures++
+		t.stats.LastError = err.Error()
+	}
+}
+
+func (t *Task) skip() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Skipped++
+}
+
+func (t *Task) setNext(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Next = &next
+}
+
+// Builder configures a task before Do registers it
+type Builder struct {
+	schedule Schedule
+	err      error
+	timeout  time.Duration
+}
+
+// Every starts a task that runs at each multiple of an interval such as
+// "5m" or "1h". Ticks are aligned to the clock, so "1h" runs on the hour.
+func Every(spec string) *Builder {
+	s, err := ParseEvery(spec)
+	return &Builder{schedule: s, err: err}
+}
+
+// Cron starts a task that runs on a cron expression such as "0 3 * * *";
+// see ParseCron
+func Cron(spec string) *Builder {
+	s, err := ParseCron(spec)
+	return &Builder{schedule: s, err: err}
+}
+
+// In evaluates a cron expression in loc instead of UTC. It has no effect
+// on Every.
+func (b *Builder) In(loc *time.Location) *Builder {
+	if c, ok := b.schedule.(*cron); ok {
+		in := *c
+		in.location = loc
+		b.schedule = &in
+	}
+	return b
+}
+
+// Timeout bounds each run of the task. A run past its timeout has its
+// context cancelled and counts as failed.
+func (b *Builder) Timeout(d time.Duration) *Builder {
+	b.timeout = d
+	return b
+}
+
+// Do registers fn as the task named name, usually from an init function:
+//
+//	schedule.Cron("0 3 * * *").Do("prune-sessions", func(ctx context.Context) error {
+//	    return sessions.Prune(ctx)
+//	})
+//
+// It panics if the schedule is invalid or name is already registered, as
+// both are programming errors.
+func (b *Builder) Do(name string, fn func(ctx context.Context) error) *Task {
+	if b.err != nil {
+		panic(fmt.Sprintf("schedule: task %q: %v", name, b.err))
+	}
+	task := &Task{Name: name, Schedule: b.schedule, Timeout: b.timeout, Fn: fn}
+	if task.Timeout <= 0 {
+		task.Timeout = DefaultTimeout
+	}
+
+	tasksMu.Lock()
+	defer tasksMu.Unlock()
+	if _, ok := tasks[name]; ok {
+		panic(fmt.Sprintf("schedule: task %q registered twice", name))
+	}
+	tasks[name] = task
+	return task
+}
+
+var (
+	tasksMu sync.RWMutex
+	tasks   = map[string]*Task{}
+)
+
+// Tasks returns the registered tasks, sorted by name
+func Tasks() []*Task {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+	all := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		all = append(all, task)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// AllStats returns the Stats of the registered tasks, sorted by name
+func AllStats() []Stats {
+	all := Tasks()
+	stats := make([]Stats, len(all))
+	for i, task := range all {
+		stats[i] = task.Stats()
+	}
+	return stats
+}
+
+var (
+	mu       sync.Mutex
+	instance Locker
+)
+
+// Get returns the application's Locker, creating it from
+// config.Get().Schedule when first called
+func Get() Locker {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if instance == nil {
+		cfg := config.Get().Schedule
+		locker, err := New(cfg)
+		if err != nil {
+			logger.Get().Error("Creating %s schedule locker: %v; using database", cfg.Locker, err)
+			locker = NewDatabaseLocker(nil)
+		}
+		instance = locker
+	}
+	return instance
+}
+
+// New creates the Locker for cfg.Locker
+func New(cfg config.ScheduleConfig) (Locker, error) {
+	switch cfg.Locker {
+	case config.ScheduleDatabase, "":
+		return NewDatabaseLocker(nil), nil
+	case config.ScheduleRedis:
+		return NewRedisLocker(cfg.RedisURL)
+	case config.ScheduleMemory:
+		return NewMemoryLocker(), nil
+	}
+	return nil, fmt.Errorf("unknown schedule locker %q", cfg.Locker)
+}
+
+// Use makes locker the one returned by Get. It is meant for tests and for
+// applications with their own Locker.
+func Use(locker Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = locker
+}
+
+// Reset forgets the current locker, so the next Get creates it again
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = nil
+}
@@ -0,0 +1,91 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// register registers a task for the rest of the test
+func register(t *testing.T, b *Builder, name string, fn func(ctx context.Context) error) *Task {
+	t.Helper()
+	task := b.Do(name, fn)
+	t.Cleanup(func() {
+		tasksMu.Lock()
+		defer tasksMu.Unlock()
+		delete(tasks, name)
+	})
+	return task
+}
+
+func noop(context.Context) error { return nil }
+
+// TestBuilder_Do tests registering tasks
+func TestBuilder_Do(t *testing.T) {
+	report := register(t, Cron("0 3 * * *").Timeout(time.Minute), "report", noop)
+	cleanup := register(t, Every("5m"), "cleanup", noop)
+
+	assert.Equal(t, time.Minute, report.Timeout)
+	assert.Equal(t, DefaultTimeout, cleanup.Timeout)
+	assert.Equal(t, []*Task{cleanup, report}, Tasks())
+
+	assert.PanicsWithValue(t, `schedule: task "report" registered twice`, func() {
+		Every("1m").Do("report", noop)
+	})
+	assert.Panics(t, func() { Cron("0 3 * *").Do("broken", noop) })
+	assert.Panics(t, func() { Every("often").Do("broken", noop) })
+	assert.Len(t, Tasks(), 2)
+}
+
+// TestNew tests choosing a locker from configuration
+func TestNew(t *testing.T) {
+	locker, err := New(config.ScheduleConfig{Locker: config.ScheduleDatabase})
+	require.NoError(t, err)
+	assert.IsType(t, &DatabaseLocker{}, locker)
+
+	locker, err = New(config.ScheduleConfig{Locker: config.ScheduleMemory})
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryLocker{}, locker)
+
+	locker, err = New(config.ScheduleConfig{Locker: config.ScheduleRedis, RedisURL: "redis://localhost:6379/0"})
+	require.NoError(t, err)
+	assert.IsType(t, &RedisLocker{}, locker)
+
+	_, err = New(config.ScheduleConfig{Locker: "etcd"})
+	assert.Error(t, err)
+}
+
+// TestUse tests replacing the locker returned by Get
+func TestUse(t *testing.T) {
+	locker := NewMemoryLocker()
+	Use(locker)
+	t.Cleanup(Reset)
+	assert.Same(t, locker, Get())
+}
+
+// TestStatusHandler tests serving task stats as JSON
+func TestStatusHandler(t *testing.T) {
+	task := register(t, Every("1m"), "report", noop)
+	task.record(time.Now(), 1500*time.Millisecond, nil)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, StatusHandler(&kit.Kit{Response: w, Request: httptest.NewRequest("GET", "/schedule", nil)}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stats []Stats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	require.Len(t, stats, 1)
+	assert.Equal(t, "report", stats[0].Name)
+	assert.Equal(t, "every 1m0s", stats[0].Schedule)
+	assert.Equal(t, int64(1), stats[0].Runs)
+	assert.Equal(t, 1500*time.Millisecond, stats[0].LastDuration)
+}
@@ -0,0 +1,186 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// runGrace is how long past its timeout a run keeps its lock, so a run
+// finishing late still blocks the next one
+const runGrace = 30 * time.Second
+
+// Scheduler runs tasks when they are due. Every instance of an application
+// can run one; the Locker makes sure each tick runs on only one of them.
+type Scheduler struct {
+	// Locker coordinates instances; nil uses Get
+	Locker Locker
+	// Tasks are the tasks to run; nil runs every registered task
+	Tasks []*Task
+
+	owner     string
+	ownerOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewScheduler creates a Scheduler for the registered tasks and the locker
+// returned by Get
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Run runs tasks until ctx is done, then waits for the running ones to
+// finish. Runs keep their own timeouts rather than ctx, so a shutdown
+// drains them instead of cancelling them.
+func (s *Scheduler) Run(ctx context.Context) {
+	all := s.Tasks
+	if all == nil {
+		all = Tasks()
+	}
+
+	logger.Get().Info("Scheduling %d tasks", len(all))
+	var loops sync.WaitGroup
+	for _, task := range all {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			s.loop(ctx, task)
+		}()
+	}
+	loops.Wait()
+	s.wg.Wait()
+	logger.Get().Info("Scheduler stopped")
+}
+
+// Start runs s in a goroutine and returns a function that waits for it to
+// drain after ctx is done. Stop it with the server's shutdown hooks:
+//
+//	ctx, stop := context.WithCancel(context.Background())
+//	drained := schedule.NewScheduler().Start(ctx)
+//	srv.OnShutdown("schedule", func(context.Context) error {
+//	    stop()
+//	    drained()
+//	    return nil
+//	})
+//	srv.Run(context.Background())
+func (s *Scheduler) Start(ctx context.Context) (wait func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	return func() { <-done }
+}
+
+// loop waits for each of task's ticks and starts a run for it
+func (s *Scheduler) loop(ctx context.Context, task *Task) {
+	next := task.Schedule.Next(time.Now())
+	for {
+		if next.IsZero() {
+			logger.Get().Warn("Task %s has no upcoming runs", task.Name)
+			return
+		}
+		task.setNext(next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		tick := next
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.tick(context.WithoutCancel(ctx), task, tick)
+		}()
+		next = task.Schedule.Next(tick)
+	}
+}
+
+// tick runs task for the tick at, unless another instance claimed the tick
+// or the previous run is still going. It reports whether task ran.
+func (s *Scheduler) tick(ctx context.Context, task *Task, at time.Time) bool {
+	locker := s.locker()
+	log := logger.Get().With("task", task.Name)
+
+	// The tick's lock is left to expire, so instances whose clocks are a
+	// little behind find it taken
+	tickTTL := max(time.Until(task.Schedule.Next(at)), time.Second)
+	claimed, err := locker.Acquire(ctx, fmt.Sprintf("%s:%d", task.Name, at.Unix()), s.ownerID(), tickTTL)
+	if err != nil {
+		log.Error("Locking task %s: %v", task.Name, err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	runKey := task.Name + ":running"
+	free, err := locker.Acquire(ctx, runKey, s.ownerID(), task.Timeout+runGrace)
+	if err != nil {
+		log.Error("Locking task %s: %v", task.Name, err)
+		return false
+	}
+	if !free {
+		task.skip()
+		log.Warn("Task %s skipped, its previous run is still going", task.Name)
+		return false
+	}
+	defer func() {
+		if err := locker.Release(ctx, runKey, s.ownerID()); err != nil {
+			log.Error("Unlocking task %s: %v", task.Name, err)
+		}
+	}()
+
+	start := time.Now()
+	runErr := run(ctx, task)
+	duration := time.Since(start)
+	task.record(start.UTC(), duration, runErr)
+
+	log = log.With("duration_ms", duration.Milliseconds())
+	if runErr != nil {
+		log.CustomError(errors.ErrRunTask.Wrap(runErr).WithValue(task.Name))
+	} else {
+		log.Info("Task %s done", task.Name)
+	}
+	return true
+}
+
+// run calls task's function with its timeout, turning a panic into an
+// error
+func run(ctx context.Context, task *Task) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, task.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	if err := task.Fn(ctx); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (s *Scheduler) locker() Locker {
+	if s.Locker == nil {
+		return Get()
+	}
+	return s.Locker
+}
+
+// ownerID identifies s in the locks it holds
+func (s *Scheduler) ownerID() string {
+	s.ownerOnce.Do(func() { s.owner = uuid.NewString() })
+	return s.owner
+}
@@ -0,0 +1,138 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScheduler_Tick tests running a task and recording its stats
+func TestScheduler_Tick(t *testing.T) {
+	ctx := context.Background()
+	s := &Scheduler{Locker: NewMemoryLocker()}
+	var calls int
+	task := register(t, Every("1m"), "report", func(ctx context.Context) error {
+		calls++
+		if calls == 2 {
+			return errors.New("report failed")
+		}
+		return nil
+	})
+	tick := time.Now().Truncate(time.Minute)
+
+	assert.True(t, s.tick(ctx, task, tick))
+	stats := task.Stats()
+	assert.Equal(t, int64(1), stats.Runs)
+	assert.Zero(t, stats.Failures)
+	require.NotNil(t, stats.LastRun)
+
+	assert.False(t, s.tick(ctx, task, tick), "a tick runs once")
+
+	assert.True(t, s.tick(ctx, task, tick.Add(time.Minute)))
+	stats = task.Stats()
+	assert.Equal(t, int64(2), stats.Runs)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, "report failed", stats.LastError)
+	assert.Equal(t, 2, calls)
+}
+
+// TestScheduler_Tick_Instances tests that instances sharing a locker run
+// each tick once
+func TestScheduler_Tick_Instances(t *testing.T) {
+	ctx := context.Background()
+	locker := setupDatabaseLocker(t)
+	var calls atomic.Int32
+	task := register(t, Every("1m"), "report", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+	tick := time.Now().Truncate(time.Minute)
+
+	a := &Scheduler{Locker: locker}
+	b := &Scheduler{Locker: locker}
+	assert.True(t, a.tick(ctx, task, tick))
+	assert.False(t, b.tick(ctx, task, tick))
+	assert.True(t, b.tick(ctx, task, tick.Add(time.Minute)))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// TestScheduler_Tick_Overlap tests skipping a tick while the previous run
+// is still going
+func TestScheduler_Tick_Overlap(t *testing.T) {
+	ctx := context.Background()
+	s := &Scheduler{Locker: NewMemoryLocker()}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	task := register(t, Every("1m"), "report", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	tick := time.Now().Truncate(time.Minute)
+
+	done := make(chan bool)
+	go func() { done <- s.tick(ctx, task, tick) }()
+	<-started
+
+	assert.False(t, s.tick(ctx, task, tick.Add(time.Minute)))
+	close(release)
+	assert.True(t, <-done)
+
+	stats := task.Stats()
+	assert.Equal(t, int64(1), stats.Runs)
+	assert.Equal(t, int64(1), stats.Skipped)
+}
+
+// TestScheduler_Tick_Failures tests that panics and timeouts fail a run
+func TestScheduler_Tick_Failures(t *testing.T) {
+	ctx := context.Background()
+	s := &Scheduler{Locker: NewMemoryLocker()}
+	tick := time.Now().Truncate(time.Minute)
+
+	panics := register(t, Every("1m"), "panics", func(context.Context) error {
+		panic("boom")
+	})
+	assert.True(t, s.tick(ctx, panics, tick))
+	assert.Contains(t, panics.Stats().LastError, "panic: boom")
+
+	slow := register(t, Every("1m").Timeout(10*time.Millisecond), "slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	assert.True(t, s.tick(ctx, slow, tick))
+	assert.Equal(t, context.DeadlineExceeded.Error(), slow.Stats().LastError)
+}
+
+// TestScheduler_Run tests running tasks on their schedule and draining on
+// shutdown
+func TestScheduler_Run(t *testing.T) {
+	var calls atomic.Int32
+	finished := make(chan struct{})
+	task := register(t, Every("1s"), "tick", func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{Locker: NewMemoryLocker(), Tasks: []*Task{task}}
+	wait := s.Start(ctx)
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, 3*time.Second, 5*time.Millisecond)
+	cancel()
+	wait()
+
+	select {
+	case <-finished:
+	default:
+		t.Fatal("the running task was not drained")
+	}
+	assert.Equal(t, int32(1), calls.Load())
+	assert.NotNil(t, task.Stats().Next)
+}
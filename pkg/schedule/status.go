@@ -0,0 +1,15 @@
+package schedule
+
+import (
+	"net/http"
+
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// StatusHandler responds with the Stats of every registered task as JSON.
+// Mount it behind authentication:
+//
+//	admin.Get("/schedule", schedule.StatusHandler)
+func StatusHandler(k *kit.Kit) error {
+	return k.JSON(http.StatusOK, AllStats())
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptsGzip tests reading Accept-Encoding
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected bool
+	}{
+		{"gzip, deflate, br", true},
+		{"br;q=1.0, gzip;q=0.8", true},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"identity", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, acceptsGzip(tt.header), tt.header)
+	}
+}
+
+// TestServer_Compression tests gzipping compressible responses
+func TestServer_Compression(t *testing.T) {
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+		case "/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		io.WriteString(w, "<html><body>hello</body></html>")
+	})
+	srv := NewServer("", page)
+	srv.Compress = true
+	handler := srv.withCompression(srv.Instance.Handler)
+
+	serve := func(path, encoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("gzips HTML", func(t *testing.T) {
+		w := serve("/", "gzip")
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"), "sniffed before compressing")
+
+		gz, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, "<html><body>hello</body></html>", string(body))
+	})
+
+	t.Run("passes through", func(t *testing.T) {
+		for _, tt := range []struct{ path, encoding string }{
+			{"/", ""},
+			{"/image", "gzip"},
+			{"/events", "gzip"},
+			{"/empty", "gzip"},
+		} {
+			w := serve(tt.path, tt.encoding)
+			assert.Empty(t, w.Header().Get("Content-Encoding"), tt.path)
+		}
+	})
+
+	t.Run("off unless enabled", func(t *testing.T) {
+		srv := NewServer("", page)
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		srv.withCompression(page).ServeHTTP(w, r)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+}
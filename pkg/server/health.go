@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// HealthCheckTimeout bounds each health check run by the readiness
+// endpoint
+const HealthCheckTimeout = 2 * time.Second
+
+// Default paths of the liveness and readiness endpoints
+const (
+	DefaultHealthPath = "/healthz"
+	DefaultReadyPath  = "/readyz"
+)
+
+// Health check statuses
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+var (
+	healthMu     sync.Mutex
+	healthChecks = map[string]func(ctx context.Context) error{}
+)
+
+// RegisterHealthCheck adds a check run by the readiness endpoint, replacing
+// any registered under name. The application isn't ready while fn returns
+// an error. pkg/cache registers "cache", and applications with a database
+// register its checks:
+//
+//	server.RegisterHealthCheck("database", database.Ping)
+//	server.RegisterHealthCheck("migrations", database.Migrated)
+func RegisterHealthCheck(name string, fn func(ctx context.Context) error) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	healthChecks[name] = fn
+}
+
+// ResetHealthChecks removes every registered health check, for tests
+func ResetHealthChecks() {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	healthChecks = map[string]func(ctx context.Context) error{}
+}
+
+// Health is the JSON body of the liveness and readiness endpoints
+type Health struct {
+	Status string                 `json:"status"` // StatusOK or StatusUnavailable
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus is the result of one health check
+type CheckStatus struct {
+	Status   string `json:"status"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// CheckHealth runs the registered health checks at once, each bounded by
+// HealthCheckTimeout. Failures are logged, at most once a minute for each
+// check, and leave the returned status unavailable.
+func CheckHealth(ctx context.Context) Health {
+	healthMu.Lock()
+	names := make([]string, 0, len(healthChecks))
+	for name := range healthChecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]func(ctx context.Context) error, len(names))
+	for i, name := range names {
+		checks[i] = healthChecks[name]
+	}
+	healthMu.Unlock()
+
+	statuses := make([]CheckStatus, len(names))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+			statuses[i] = CheckStatus{Status: StatusOK, Duration: time.Since(start).Milliseconds()}
+			if err != nil {
+				statuses[i].Status = StatusUnavailable
+				logger.Throttle("health:"+names[i], time.Minute).Warn("Health check %s failed: %v", names[i], err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	health := Health{Status: StatusOK, Checks: make(map[string]CheckStatus, len(names))}
+	for i, name := range names {
+		health.Checks[name] = statuses[i]
+		if statuses[i].Status != StatusOK {
+			health.Status = StatusUnavailable
+		}
+	}
+	return health
+}
+
+// LivenessHandler responds 200 while the process serves requests, without
+// running the health checks, so an orchestrator restarts only a stuck
+// process rather than one waiting on a dependency
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, Health{Status: StatusOK})
+	})
+}
+
+// ReadinessHandler runs the registered health checks, responding 200 when
+// they pass and 503 Service Unavailable otherwise, with each check's status
+func ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, CheckHealth(r.Context()))
+	})
+}
+
+// writeHealth writes health as JSON, with 503 unless it is ok
+func writeHealth(w http.ResponseWriter, health Health) {
+	status := http.StatusOK
+	if health.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(health)
+}
+
+// withHealth serves the liveness and readiness endpoints at HealthPath and
+// ReadyPath ahead of next
+func (s *Server) withHealth(next http.Handler) http.Handler {
+	if s.HealthPath == "" && s.ReadyPath == "" {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+	live, ready := LivenessHandler(), ReadinessHandler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case s.HealthPath != "" && r.URL.Path == s.HealthPath:
+			live.ServeHTTP(w, r)
+		case s.ReadyPath != "" && r.URL.Path == s.ReadyPath:
+			ready.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
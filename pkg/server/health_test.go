@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// decodeHealth decodes the body of a health endpoint response
+func decodeHealth(t *testing.T, body []byte) Health {
+	t.Helper()
+	var health Health
+	require.NoError(t, json.Unmarshal(body, &health))
+	return health
+}
+
+// TestCheckHealth tests running the registered health checks
+func TestCheckHealth(t *testing.T) {
+	t.Cleanup(ResetHealthChecks)
+
+	t.Run("ok without checks", func(t *testing.T) {
+		ResetHealthChecks()
+		health := CheckHealth(context.Background())
+		assert.Equal(t, StatusOK, health.Status)
+		assert.Empty(t, health.Checks)
+	})
+
+	t.Run("reports each check", func(t *testing.T) {
+		ResetHealthChecks()
+		RegisterHealthCheck("database", func(ctx context.Context) error { return nil })
+		RegisterHealthCheck("cache", func(ctx context.Context) error { return stderrors.New("connection refused") })
+
+		health := CheckHealth(context.Background())
+		assert.Equal(t, StatusUnavailable, health.Status)
+		assert.Equal(t, StatusOK, health.Checks["database"].Status)
+		assert.Equal(t, StatusUnavailable, health.Checks["cache"].Status)
+	})
+
+	t.Run("replaces a check by name", func(t *testing.T) {
+		ResetHealthChecks()
+		RegisterHealthCheck("cache", func(ctx context.Context) error { return stderrors.New("down") })
+		RegisterHealthCheck("cache", func(ctx context.Context) error { return nil })
+
+		health := CheckHealth(context.Background())
+		assert.Equal(t, StatusOK, health.Status)
+		assert.Len(t, health.Checks, 1)
+	})
+
+	t.Run("bounds each check", func(t *testing.T) {
+		ResetHealthChecks()
+		RegisterHealthCheck("slow", func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		health := CheckHealth(ctx)
+		assert.Equal(t, StatusUnavailable, health.Checks["slow"].Status)
+	})
+}
+
+// TestReadinessHandler tests responding 503 with the failing component
+func TestReadinessHandler(t *testing.T) {
+	t.Cleanup(ResetHealthChecks)
+	ResetHealthChecks()
+	RegisterHealthCheck("database", func(ctx context.Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	ReadinessHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, StatusOK, decodeHealth(t, rec.Body.Bytes()).Checks["database"].Status)
+
+	RegisterHealthCheck("migrations", func(ctx context.Context) error { return stderrors.New("pending migrations: 002_create_books") })
+	rec = httptest.NewRecorder()
+	ReadinessHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	health := decodeHealth(t, rec.Body.Bytes())
+	assert.Equal(t, StatusUnavailable, health.Status)
+	assert.Equal(t, StatusUnavailable, health.Checks["migrations"].Status)
+	assert.NotContains(t, rec.Body.String(), "002_create_books", "errors are logged, not exposed")
+}
+
+// TestServer_Health tests serving the endpoints ahead of the application's
+// handler, at the configured paths
+func TestServer_Health(t *testing.T) {
+	t.Cleanup(ResetHealthChecks)
+	ResetHealthChecks()
+	RegisterHealthCheck("database", func(ctx context.Context) error { return stderrors.New("down") })
+
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	t.Run("wired by default", func(t *testing.T) {
+		srv := NewServer("127.0.0.1:0", app)
+		srv.Start()
+		t.Cleanup(func() { srv.Shutdown() })
+		require.NotNil(t, srv.Addr())
+		base := "http://" + srv.Addr().String()
+
+		res, err := http.Get(base + DefaultHealthPath)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode, "liveness ignores dependencies")
+
+		res, err = http.Get(base + DefaultReadyPath)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+		res, err = http.Get(base + "/posts")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusTeapot, res.StatusCode)
+	})
+
+	t.Run("configured paths", func(t *testing.T) {
+		srv := NewServerFromConfig(&config.Config{Server: config.ServerConfig{HealthPath: "/livez", ReadyPath: ""}}, app)
+		handler := srv.withHealth(srv.Instance.Handler)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/livez", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", DefaultReadyPath, nil))
+		assert.Equal(t, http.StatusTeapot, rec.Code, "empty path disables the endpoint")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := NewServer("", app)
+		srv.HealthPath, srv.ReadyPath = "", ""
+		handler := srv.withHealth(srv.Instance.Handler)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", DefaultHealthPath, nil))
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+	})
+}
@@ -2,20 +2,85 @@ package server
 
 import (
 	"context"
+	stderrors "errors"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
 	"sync"
+	"syscall"
 	"time"
 
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/cstone-io/twine/pkg/config"
 	"github.com/cstone-io/twine/pkg/errors"
 	"github.com/cstone-io/twine/pkg/logger"
 )
 
-// Server wraps an http.Server with graceful shutdown
+var (
+	// validate checks the configuration before the server starts
+	validate = func(cfg *config.Config) error {
+		if cfg == nil {
+			return config.Validate()
+		}
+		return cfg.Validate()
+	}
+	// exit ends the process when the configuration is invalid
+	exit = os.Exit
+)
+
+// Server wraps an http.Server with HTTPS and graceful shutdown: on SIGINT
+// or SIGTERM it stops accepting connections, waits for open requests to
+// finish, then runs its shutdown hooks
 type Server struct {
 	Instance *http.Server
+
+	// ShutdownTimeout is how long Shutdown waits for open requests, and then
+	// again for the shutdown hooks
+	ShutdownTimeout time.Duration
+
+	// RedirectAddr is the address of a listener redirecting HTTP to HTTPS,
+	// such as ":80", when serving HTTPS. It also answers the challenges of
+	// autocert. Empty means none.
+	RedirectAddr string
+
+	// HealthPath and ReadyPath are where Start serves the liveness and
+	// readiness endpoints, DefaultHealthPath and DefaultReadyPath unless
+	// changed. Empty turns an endpoint off.
+	HealthPath string
+	ReadyPath  string
+
+	// Compress gzips responses for clients that accept it
+	Compress bool
+
+	// config is validated by Start; nil means config.Get
+	config *config.Config
+
+	// Certificate files or autocert manager served by Start, if any
+	certFile, keyFile string
+	autocert          *autocert.Manager
+
+	mu       sync.Mutex
+	started  bool
+	hooks    []shutdownHook
+	listener net.Listener
+	redirect *http.Server
+	// redirectListener is the listener of redirect
+	redirectListener net.Listener
+	// errc receives the error that stopped the server from serving
+	errc chan error
+}
+
+// shutdownHook is a function registered with OnShutdown
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
 }
 
-// NewServer creates a new Server with the given address and handler
+// NewServer creates a new Server with the given address and handler, and
+// the default timeouts and header limit
 func NewServer(addr string, handler http.Handler) *Server {
 	if addr == "" {
 		addr = ":3000"
@@ -23,37 +88,209 @@ func NewServer(addr string, handler http.Handler) *Server {
 
 	return &Server{
 		Instance: &http.Server{
-			Addr:    addr,
-			Handler: handler,
+			Addr:           addr,
+			Handler:        handler,
+			ReadTimeout:    config.DefaultReadTimeout,
+			WriteTimeout:   config.DefaultWriteTimeout,
+			IdleTimeout:    config.DefaultIdleTimeout,
+			MaxHeaderBytes: config.DefaultMaxHeaderBytes,
 		},
+		ShutdownTimeout: config.DefaultShutdownTimeout,
+		HealthPath:      DefaultHealthPath,
+		ReadyPath:       DefaultReadyPath,
+		errc:            make(chan error, 1),
 	}
 }
 
-// Start starts the server in a goroutine
+// NewServerFromConfig creates a Server listening on the configured port, with
+// the configured timeouts, header limit, shutdown grace period, health
+// endpoints, compression and TLS settings, and validates cfg instead of config.Get when started
+func NewServerFromConfig(cfg *config.Config, handler http.Handler) *Server {
+	s := NewServer(cfg.Server.Addr(), handler)
+	s.Instance.ReadTimeout = cfg.Server.ReadTimeout
+	s.Instance.WriteTimeout = cfg.Server.WriteTimeout
+	s.Instance.IdleTimeout = cfg.Server.IdleTimeout
+	s.Instance.MaxHeaderBytes = cfg.Server.MaxHeaderBytes
+	s.ShutdownTimeout = cfg.Server.ShutdownTimeout
+	s.HealthPath = cfg.Server.HealthPath
+	s.ReadyPath = cfg.Server.ReadyPath
+	s.Compress = cfg.Server.Compress
+	s.config = cfg
+	s.useTLSConfig(cfg.TLS)
+	return s
+}
+
+// Start validates the configuration, exiting with every problem logged if it
+// is invalid, then listens and serves in a goroutine: HTTPS with HTTP/2 when
+// a certificate or autocert is configured, or HTTP otherwise. The health
+// endpoints are served ahead of the handler. An address that can't be
+// listened on or a certificate that can't be loaded is logged and ends Run.
 func (s *Server) Start() {
-	go func() {
-		log := logger.Get()
+	if err := validate(s.config); err != nil {
+		logger.Get().CustomError(errors.ErrInvalidConfig.Wrap(err))
+		exit(1)
+		return
+	}
+	s.mu.Lock()
+	s.started = true
+	s.mu.Unlock()
+	s.Instance.Handler = s.withHealth(s.withCompression(s.Instance.Handler))
+
+	if err := s.configureTLS(); err != nil {
+		s.fail(err)
+		return
+	}
+	ln, err := net.Listen("tcp", s.Instance.Addr)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
 
-		log.Info("Listening on %s", s.Instance.Addr)
-		if err := s.Instance.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.CustomError(errors.ErrListenAndServe.Wrap(err))
+	log := logger.Get()
+	serve := func() error { return s.Instance.Serve(ln) }
+	if s.Instance.TLSConfig != nil {
+		log.Info("Listening on %s (HTTPS)", ln.Addr())
+		serve = func() error { return s.Instance.ServeTLS(ln, "", "") }
+		if s.RedirectAddr != "" {
+			if err := s.startRedirect(); err != nil {
+				s.fail(err)
+				return
+			}
+		}
+	} else {
+		log.Info("Listening on %s", ln.Addr())
+	}
+
+	go func() {
+		if err := serve(); err != nil && err != http.ErrServerClosed {
+			s.fail(err)
 		}
 	}()
 }
 
-// AwaitShutdown blocks until context is cancelled, then gracefully shuts down
+// fail logs err as the reason the server stopped serving and ends Run
+func (s *Server) fail(err error) {
+	e := errors.ErrListenAndServe.Wrap(err)
+	logger.Get().CustomError(e)
+	select {
+	case s.errc <- e:
+	default:
+	}
+}
+
+// Addr returns the address the server is listening on, such as the port
+// picked for ":0", or nil before Start
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// OnShutdown registers fn to run on shutdown, after open requests have
+// finished, with a context cancelled once the grace period is over. Hooks
+// run in the order registered, each even if one before it failed:
+//
+//	srv.OnShutdown("events", events.Drain)
+//	srv.OnShutdown("database", func(context.Context) error {
+//	    return database.Close()
+//	})
+//
+// Use Instance.RegisterOnShutdown for what must stop before requests can
+// finish, such as realtime.Close for open event streams.
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Run starts the server, unless Start or ListenTLS did, and blocks until ctx is done, SIGINT or SIGTERM is
+// received, or the server stops serving, then shuts down. A second signal
+// during shutdown ends the process at once. It returns an error if the
+// server failed or didn't shut down cleanly, for main to exit non-zero:
+//
+//	if err := srv.Run(context.Background()); err != nil {
+//	    os.Exit(1)
+//	}
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if !started {
+		s.Start()
+	}
+
+	var serveErr error
+	select {
+	case serveErr = <-s.errc:
+	case <-ctx.Done():
+		logger.Get().Info("Shutting down")
+	}
+	stop()
+
+	return stderrors.Join(serveErr, s.Shutdown())
+}
+
+// AwaitShutdown blocks until ctx is done, then shuts down. Run also
+// handles signals and serving errors.
 func (s *Server) AwaitShutdown(ctx context.Context) error {
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		<-ctx.Done()
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := s.Instance.Shutdown(shutdownCtx); err != nil {
-			logger.Get().CustomError(errors.ErrShutdownServer.Wrap(err))
+	<-ctx.Done()
+	return s.Shutdown()
+}
+
+// Shutdown stops accepting connections and waits up to ShutdownTimeout for
+// open requests to finish, closing the ones left after it. It then runs
+// the shutdown hooks, which get ShutdownTimeout again. Every error is
+// logged, and returned joined.
+func (s *Server) Shutdown() error {
+	log := logger.Get()
+	var errs []error
+
+	s.mu.Lock()
+	hooks := slices.Clone(s.hooks)
+	servers := []*http.Server{s.Instance}
+	if s.redirect != nil {
+		servers = append(servers, s.redirect)
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := s.shutdownContext()
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+			err := errors.ErrShutdownServer.Wrap(err)
+			log.CustomError(err)
+			errs = append(errs, err)
 		}
-	}()
-	wg.Wait()
-	return nil
+	}
+	cancel()
+
+	ctx, cancel = s.shutdownContext()
+	defer cancel()
+	for _, hook := range hooks {
+		if err := hook.fn(ctx); err != nil {
+			err := errors.ErrShutdownHook.Wrap(err).WithValue(hook.name)
+			log.CustomError(err)
+			errs = append(errs, err)
+		}
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// shutdownContext returns a context for a step of Shutdown, without a
+// deadline when ShutdownTimeout is zero
+func (s *Server) shutdownContext() (context.Context, context.CancelFunc) {
+	if s.ShutdownTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.ShutdownTimeout)
 }
@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +11,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
 )
 
 // TestNewServer tests server creation
@@ -37,6 +42,15 @@ func TestNewServer(t *testing.T) {
 		assert.Equal(t, ":3000", srv.Instance.Addr)
 	})
 
+	t.Run("sets default limits", func(t *testing.T) {
+		srv := NewServer("", http.NotFoundHandler())
+
+		assert.Equal(t, config.DefaultReadTimeout, srv.Instance.ReadTimeout)
+		assert.Equal(t, config.DefaultWriteTimeout, srv.Instance.WriteTimeout)
+		assert.Equal(t, config.DefaultIdleTimeout, srv.Instance.IdleTimeout)
+		assert.Equal(t, config.DefaultMaxHeaderBytes, srv.Instance.MaxHeaderBytes)
+	})
+
 	t.Run("accepts different address formats", func(t *testing.T) {
 		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 
@@ -104,6 +118,53 @@ func TestServer_Start(t *testing.T) {
 	})
 }
 
+// TestServer_Start_InvalidConfig tests that Start exits instead of serving
+// with an invalid configuration
+func TestServer_Start_InvalidConfig(t *testing.T) {
+	originalValidate, originalExit := validate, exit
+	defer func() { validate, exit = originalValidate, originalExit }()
+
+	code := -1
+	validate = func(*config.Config) error {
+		return config.ValidationError{{Var: "PORT", Message: "must be a number"}}
+	}
+	exit = func(c int) { code = c }
+
+	srv := NewServer("127.0.0.1:0", http.NotFoundHandler())
+	srv.Start()
+
+	assert.Equal(t, 1, code)
+}
+
+// TestNewServerFromConfig tests that the server uses and validates the
+// Config it was given
+func TestNewServerFromConfig(t *testing.T) {
+	originalExit := exit
+	defer func() { exit = originalExit }()
+	code := -1
+	exit = func(c int) { code = c }
+
+	cfg := &config.Config{Server: config.ServerConfig{
+		Port:            "8081",
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		IdleTimeout:     time.Minute,
+		MaxHeaderBytes:  4096,
+		ShutdownTimeout: 20 * time.Second,
+	}}
+	srv := NewServerFromConfig(cfg, http.NotFoundHandler())
+	assert.Equal(t, ":8081", srv.Instance.Addr)
+	assert.Equal(t, 5*time.Second, srv.Instance.ReadTimeout)
+	assert.Equal(t, 10*time.Second, srv.Instance.WriteTimeout)
+	assert.Equal(t, time.Minute, srv.Instance.IdleTimeout)
+	assert.Equal(t, 4096, srv.Instance.MaxHeaderBytes)
+	assert.Equal(t, 20*time.Second, srv.ShutdownTimeout)
+
+	cfg.Server.Port = "not-a-port"
+	srv.Start()
+	assert.Equal(t, 1, code)
+}
+
 // TestServer_AwaitShutdown tests graceful shutdown
 func TestServer_AwaitShutdown(t *testing.T) {
 	t.Run("blocks until context is cancelled", func(t *testing.T) {
@@ -172,6 +233,124 @@ func TestServer_AwaitShutdown(t *testing.T) {
 	})
 }
 
+// TestServer_Shutdown tests draining open requests and running the
+// shutdown hooks
+func TestServer_Shutdown(t *testing.T) {
+	// slowServer starts a server whose requests take delay, returning it
+	// once a request is being handled, with the request's result
+	slowServer := func(t *testing.T, delay time.Duration) (*Server, chan error) {
+		t.Helper()
+		started := make(chan struct{})
+		srv := NewServer("127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(delay)
+			w.Write([]byte("done"))
+		}))
+		srv.Start()
+		require.NotNil(t, srv.Addr())
+
+		result := make(chan error, 1)
+		go func() {
+			res, err := http.Get("http://" + srv.Addr().String())
+			if err == nil {
+				body, _ := io.ReadAll(res.Body)
+				res.Body.Close()
+				if string(body) != "done" {
+					err = fmt.Errorf("unexpected body %q", body)
+				}
+			}
+			result <- err
+		}()
+		<-started
+		return srv, result
+	}
+
+	t.Run("waits for open requests", func(t *testing.T) {
+		srv, result := slowServer(t, 100*time.Millisecond)
+
+		require.NoError(t, srv.Shutdown())
+		assert.NoError(t, <-result)
+
+		_, err := http.Get("http://" + srv.Addr().String())
+		assert.Error(t, err, "no new connections after shutdown")
+	})
+
+	t.Run("closes requests left after the grace period", func(t *testing.T) {
+		srv, result := slowServer(t, time.Second)
+		srv.ShutdownTimeout = 20 * time.Millisecond
+
+		err := srv.Shutdown()
+		assert.ErrorIs(t, err, errors.ErrShutdownServer)
+		assert.Error(t, <-result)
+	})
+
+	t.Run("runs hooks in order after requests finish", func(t *testing.T) {
+		srv, result := slowServer(t, 50*time.Millisecond)
+
+		var calls []string
+		srv.OnShutdown("first", func(ctx context.Context) error {
+			select {
+			case err := <-result:
+				assert.NoError(t, err, "request finished before hooks")
+			default:
+				t.Error("hook ran before the open request finished")
+			}
+			calls = append(calls, "first")
+			return fmt.Errorf("closing")
+		})
+		srv.OnShutdown("second", func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok, "hooks get the grace period")
+			calls = append(calls, "second")
+			return nil
+		})
+
+		err := srv.Shutdown()
+		assert.Equal(t, []string{"first", "second"}, calls)
+		assert.ErrorIs(t, err, errors.ErrShutdownHook)
+		assert.ErrorContains(t, err, "closing")
+
+		var hookErr *errors.Error
+		require.ErrorAs(t, err, &hookErr)
+		assert.Equal(t, "first", hookErr.Value)
+	})
+}
+
+// TestServer_Run tests serving until cancelled or failing
+func TestServer_Run(t *testing.T) {
+	t.Run("shuts down when the context is done", func(t *testing.T) {
+		srv := NewServer("127.0.0.1:0", http.NotFoundHandler())
+		hooked := false
+		srv.OnShutdown("test", func(context.Context) error {
+			hooked = true
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		assert.NoError(t, srv.Run(ctx))
+		assert.True(t, hooked)
+	})
+
+	t.Run("returns the error when it can't listen", func(t *testing.T) {
+		taken := NewServer("127.0.0.1:0", http.NotFoundHandler())
+		taken.Start()
+		defer taken.Shutdown()
+
+		srv := NewServer(taken.Addr().String(), http.NotFoundHandler())
+		done := make(chan error, 1)
+		go func() { done <- srv.Run(context.Background()) }()
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, errors.ErrListenAndServe)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run did not return after failing to listen")
+		}
+	})
+}
+
 // TestServer_Integration tests realistic server scenarios
 func TestServer_Integration(t *testing.T) {
 	t.Run("complete server lifecycle", func(t *testing.T) {
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// ListenTLS starts the server like Start, serving HTTPS and HTTP/2 with the
+// PEM certificate chain and private key in certFile and keyFile:
+//
+//	srv.RedirectAddr = ":80"
+//	srv.ListenTLS("cert.pem", "key.pem")
+//	srv.Run(context.Background())
+func (s *Server) ListenTLS(certFile, keyFile string) {
+	s.certFile, s.keyFile = certFile, keyFile
+	s.autocert = nil
+	s.Start()
+}
+
+// useTLSConfig serves the certificate or autocert domains of cfg, if set
+func (s *Server) useTLSConfig(cfg config.TLSConfig) {
+	if !cfg.Enabled() {
+		return
+	}
+	s.certFile, s.keyFile = cfg.CertFile, cfg.KeyFile
+	if len(cfg.AutocertDomains) > 0 {
+		s.autocert = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		if cfg.AutocertDirectoryURL != "" {
+			s.autocert.Client = &acme.Client{DirectoryURL: cfg.AutocertDirectoryURL}
+		}
+	}
+	if cfg.RedirectPort != "" {
+		s.RedirectAddr = ":" + cfg.RedirectPort
+	}
+}
+
+// configureTLS loads the certificate files, or sets up autocert, in
+// Instance.TLSConfig
+func (s *Server) configureTLS() error {
+	if s.certFile == "" && s.autocert == nil {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.Instance.TLSConfig != nil {
+		tlsConfig = s.Instance.TLSConfig.Clone()
+	}
+	if s.autocert != nil {
+		tlsConfig.GetCertificate = s.autocert.GetCertificate
+		// Answer TLS-ALPN-01 challenges on the HTTPS port, as well as
+		// HTTP-01 ones on the redirect listener
+		if len(tlsConfig.NextProtos) == 0 {
+			tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		}
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+	} else {
+		cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	s.Instance.TLSConfig = tlsConfig
+	return nil
+}
+
+// startRedirect listens on RedirectAddr, redirecting to HTTPS and answering
+// autocert's challenges
+func (s *Server) startRedirect() error {
+	ln, err := net.Listen("tcp", s.RedirectAddr)
+	if err != nil {
+		return err
+	}
+
+	handler := s.redirectHandler()
+	if s.autocert != nil {
+		handler = s.autocert.HTTPHandler(handler)
+	}
+	redirect := &http.Server{
+		Handler:        handler,
+		ReadTimeout:    s.Instance.ReadTimeout,
+		WriteTimeout:   s.Instance.WriteTimeout,
+		IdleTimeout:    s.Instance.IdleTimeout,
+		MaxHeaderBytes: s.Instance.MaxHeaderBytes,
+	}
+	s.mu.Lock()
+	s.redirect = redirect
+	s.redirectListener = ln
+	s.mu.Unlock()
+
+	logger.Get().Info("Redirecting HTTP on %s to HTTPS", ln.Addr())
+	go func() {
+		if err := redirect.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.fail(err)
+		}
+	}()
+	return nil
+}
+
+// redirectHandler redirects requests to the same URL over HTTPS, on the
+// port the server listens on
+func (s *Server) redirectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		port := "443"
+		if addr := s.Addr(); addr != nil {
+			_, port, _ = net.SplitHostPort(addr.String())
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		// 301 turns other methods into GET, which 308 keeps
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// writeCertificate writes a self-signed certificate for localhost and its
+// key, returning their paths
+func writeCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, &x509.Certificate{SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// TestServer_ListenTLS tests serving HTTPS and HTTP/2, and redirecting HTTP
+func TestServer_ListenTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+	srv := NewServer("127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.RedirectAddr = "127.0.0.1:0"
+	srv.ListenTLS(certFile, keyFile)
+	t.Cleanup(func() { srv.Shutdown() })
+	require.NotNil(t, srv.Addr())
+
+	t.Run("serves HTTP/2 over TLS", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		}}
+		res, err := client.Get("https://" + srv.Addr().String())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, 2, res.ProtoMajor)
+		assert.Equal(t, uint16(tls.VersionTLS13), res.TLS.Version)
+	})
+
+	t.Run("redirects HTTP to HTTPS", func(t *testing.T) {
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		_, port, _ := net.SplitHostPort(srv.Addr().String())
+		base := "http://" + srv.redirectListener.Addr().String()
+
+		res, err := client.Get(base + "/posts?page=2")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusMovedPermanently, res.StatusCode)
+		assert.Equal(t, "https://127.0.0.1:"+port+"/posts?page=2", res.Header.Get("Location"))
+
+		res, err = client.Post(base+"/posts", "text/plain", strings.NewReader("post"))
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusPermanentRedirect, res.StatusCode)
+	})
+
+	t.Run("shuts down both listeners", func(t *testing.T) {
+		require.NoError(t, srv.Shutdown())
+		_, err := net.Dial("tcp", srv.redirectListener.Addr().String())
+		assert.Error(t, err)
+	})
+}
+
+// TestServer_ListenTLS_MissingCertificate tests that Run fails when the
+// certificate can't be loaded
+func TestServer_ListenTLS_MissingCertificate(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", http.NotFoundHandler())
+	srv.ListenTLS("missing.pem", "missing.key")
+	assert.Nil(t, srv.Addr())
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(context.Background()) }()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, errors.ErrListenAndServe)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after failing to load the certificate")
+	}
+}
+
+// TestNewServerFromConfig_TLS tests serving the configured certificate or
+// autocert domains
+func TestNewServerFromConfig_TLS(t *testing.T) {
+	t.Run("certificate files", func(t *testing.T) {
+		certFile, keyFile := writeCertificate(t)
+		srv := NewServerFromConfig(&config.Config{TLS: config.TLSConfig{CertFile: certFile, KeyFile: keyFile}}, http.NotFoundHandler())
+
+		assert.Empty(t, srv.RedirectAddr)
+		require.NoError(t, srv.configureTLS())
+		assert.Len(t, srv.Instance.TLSConfig.Certificates, 1)
+		assert.Equal(t, uint16(tls.VersionTLS12), srv.Instance.TLSConfig.MinVersion)
+	})
+
+	t.Run("autocert", func(t *testing.T) {
+		srv := NewServerFromConfig(&config.Config{TLS: config.TLSConfig{
+			AutocertDomains:  []string{"example.com"},
+			AutocertCacheDir: t.TempDir(),
+			RedirectPort:     "80",
+		}}, http.NotFoundHandler())
+
+		assert.Equal(t, ":80", srv.RedirectAddr)
+		require.NotNil(t, srv.autocert)
+		assert.NoError(t, srv.autocert.HostPolicy(context.Background(), "example.com"))
+		assert.Error(t, srv.autocert.HostPolicy(context.Background(), "other.example"), "only the configured domains")
+		require.NoError(t, srv.configureTLS())
+		assert.NotNil(t, srv.Instance.TLSConfig.GetCertificate)
+		assert.Equal(t, []string{"h2", "http/1.1", acme.ALPNProto}, srv.Instance.TLSConfig.NextProtos)
+
+		// The redirect listener answers challenges before redirecting
+		handler := srv.autocert.HTTPHandler(srv.redirectHandler())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "http://example.com/.well-known/acme-challenge/unknown", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "http://example.com/", nil))
+		assert.Equal(t, "https://example.com/", rec.Header().Get("Location"))
+	})
+
+	t.Run("plain HTTP", func(t *testing.T) {
+		srv := NewServerFromConfig(&config.Config{}, http.NotFoundHandler())
+		require.NoError(t, srv.configureTLS())
+		assert.Nil(t, srv.Instance.TLSConfig)
+	})
+}
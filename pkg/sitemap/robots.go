@@ -0,0 +1,40 @@
+package sitemap
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// RobotsHandler serves robots.txt with the rules of the robots settings
+// and the sitemap's URL. Mount it at /robots.txt:
+//
+//	mux.Handle("/robots.txt", sitemap.RobotsHandler())
+func RobotsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(Robots(baseURL(r))))
+	})
+}
+
+// Robots returns robots.txt for the site at base, such as
+// https://example.com
+func Robots(base string) string {
+	cfg := config.Get().Robots
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, p := range cfg.Allow {
+		b.WriteString("Allow: " + p + "\n")
+	}
+	for _, p := range cfg.Disallow {
+		b.WriteString("Disallow: " + p + "\n")
+	}
+	if len(cfg.Allow) == 0 && len(cfg.Disallow) == 0 {
+		// An empty Disallow allows everything
+		b.WriteString("Disallow:\n")
+	}
+	b.WriteString("\nSitemap: " + base + "/sitemap.xml\n")
+	return b.String()
+}
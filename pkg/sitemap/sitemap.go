@@ -0,0 +1,201 @@
+// Package sitemap serves /sitemap.xml, listing the application's pages for
+// search engines, and /robots.txt. The static pages come from the route
+// tree: routes.gen.go passes them to SetPages, so a new page.go appears in
+// the sitemap when routes are generated. Pages with parameters, such as
+// /posts/{id}, are listed by a Provider instead.
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// Entry is a URL in the sitemap
+type Entry struct {
+	// Path is the page's path, such as /posts/hello, joined to the site's
+	// URL, or an absolute URL
+	Path string
+	// LastModified is when the page last changed, left out when zero
+	LastModified time.Time
+	// ChangeFrequency hints how often the page changes: always, hourly,
+	// daily, weekly, monthly, yearly or never
+	ChangeFrequency string
+	// Priority is the page's importance relative to the site's other
+	// pages, from 0.0 to 1.0, left out when zero
+	Priority float64
+}
+
+// Provider lists URLs the route tree can't, such as one per blog post
+type Provider interface {
+	Entries(ctx context.Context) ([]Entry, error)
+}
+
+// ProviderFunc adapts a function to a Provider
+type ProviderFunc func(ctx context.Context) ([]Entry, error)
+
+// Entries calls f
+func (f ProviderFunc) Entries(ctx context.Context) ([]Entry, error) {
+	return f(ctx)
+}
+
+var (
+	mu        sync.Mutex
+	pages     []string
+	providers []Provider
+)
+
+// SetPages sets the paths of the static pages, replacing those set
+// before. routes.gen.go calls it with every page without parameters.
+func SetPages(paths ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	pages = append([]string(nil), paths...)
+}
+
+// Register adds a provider of URLs to the sitemap:
+//
+//	sitemap.Register(sitemap.ProviderFunc(func(ctx context.Context) ([]sitemap.Entry, error) {
+//	    posts, err := postStore.List(ctx, database.ListOptions{})
+//	    ...
+//	}))
+func Register(provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers = append(providers, provider)
+}
+
+// Reset forgets the pages and providers
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	pages, providers = nil, nil
+}
+
+// Entries returns the static pages, then the providers' entries, leaving
+// out the paths sitemap.exclude matches. Static pages are below
+// routes.root.
+func Entries(ctx context.Context) ([]Entry, error) {
+	mu.Lock()
+	static, list := pages, providers
+	mu.Unlock()
+
+	cfg := config.Get()
+	root := strings.TrimSuffix(cfg.Routes.Root, "/")
+	entries := make([]Entry, 0, len(static))
+	for _, page := range static {
+		entries = append(entries, Entry{Path: root + page})
+	}
+	for _, provider := range list {
+		provided, err := provider.Entries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, provided...)
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if !excluded(cfg.Sitemap.Exclude, entry.Path) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// excluded reports whether one of patterns matches p
+func excluded(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// urlSet is the sitemap document
+type urlSet struct {
+	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []url    `xml:"url"`
+}
+
+// url is an entry of the sitemap document
+type url struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+// Handler serves the sitemap. Mount it at /sitemap.xml:
+//
+//	mux.Handle("/sitemap.xml", sitemap.Handler())
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries, err := Entries(r.Context())
+		if err != nil {
+			logger.Get().Error("Listing sitemap entries: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		base := baseURL(r)
+		doc := urlSet{URLs: make([]url, len(entries))}
+		for i, entry := range entries {
+			doc.URLs[i] = url{Loc: absolute(base, entry.Path), ChangeFreq: entry.ChangeFrequency}
+			if !entry.LastModified.IsZero() {
+				doc.URLs[i].LastMod = entry.LastModified.UTC().Format(time.RFC3339)
+			}
+			if entry.Priority > 0 {
+				doc.URLs[i].Priority = strconv.FormatFloat(entry.Priority, 'f', -1, 64)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write([]byte(xml.Header))
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			logger.Get().Error("Writing sitemap: %v", err)
+		}
+	})
+}
+
+// absolute joins p to base unless it's already an absolute URL
+func absolute(base, p string) string {
+	if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+		return p
+	}
+	return base + p
+}
+
+// baseURL is sitemap.base_url, or the scheme and host the request came to,
+// believing the X-Forwarded-* headers of trusted proxies
+func baseURL(r *http.Request) string {
+	cfg := config.Get()
+	if cfg.Sitemap.BaseURL != "" {
+		return strings.TrimSuffix(cfg.Sitemap.BaseURL, "/")
+	}
+
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if cfg.Server.IsTrustedProxy(r.RemoteAddr) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+	}
+	return scheme + "://" + host
+}
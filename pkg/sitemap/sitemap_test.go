@@ -0,0 +1,134 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// setSitemapConfig sets the sitemap, robots and routes settings for a test
+func setSitemapConfig(t *testing.T, sitemap config.SitemapConfig, robots config.RobotsConfig, root string) {
+	t.Helper()
+	cfg := config.Get()
+	originalSitemap, originalRobots, originalRoot := cfg.Sitemap, cfg.Robots, cfg.Routes.Root
+	t.Cleanup(func() {
+		cfg.Sitemap, cfg.Robots, cfg.Routes.Root = originalSitemap, originalRobots, originalRoot
+	})
+	cfg.Sitemap, cfg.Robots, cfg.Routes.Root = sitemap, robots, root
+}
+
+// TestEntries tests listing static pages and provided entries
+func TestEntries(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+	setSitemapConfig(t, config.SitemapConfig{Exclude: []string{"/app/login", "/posts/draft-*"}}, config.RobotsConfig{}, "/app")
+
+	SetPages("/", "/about", "/login")
+	Register(ProviderFunc(func(ctx context.Context) ([]Entry, error) {
+		return []Entry{{Path: "/posts/hello"}, {Path: "/posts/draft-1"}}, nil
+	}))
+
+	entries, err := Entries(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Path: "/app/"}, {Path: "/app/about"}, {Path: "/posts/hello"}}, entries,
+		"pages are below routes.root, and excluded paths are left out")
+
+	SetPages("/contact")
+	entries, err = Entries(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "/app/contact", entries[0].Path, "pages are replaced")
+
+	Register(ProviderFunc(func(ctx context.Context) ([]Entry, error) {
+		return nil, errors.New("database down")
+	}))
+	_, err = Entries(context.Background())
+	assert.EqualError(t, err, "database down")
+}
+
+// TestHandler tests serving sitemap.xml
+func TestHandler(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+
+	SetPages("/", "/about")
+	Register(ProviderFunc(func(ctx context.Context) ([]Entry, error) {
+		return []Entry{{
+			Path:            "/posts/a&b",
+			LastModified:    time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600)),
+			ChangeFrequency: "weekly",
+			Priority:        0.8,
+		}, {Path: "https://cdn.example.com/feed"}}, nil
+	}))
+
+	t.Run("uses the request's host", func(t *testing.T) {
+		setSitemapConfig(t, config.SitemapConfig{}, config.RobotsConfig{}, "/")
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, httptest.NewRequest("GET", "http://localhost:3000/sitemap.xml", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://localhost:3000/</loc>
+  </url>
+  <url>
+    <loc>http://localhost:3000/about</loc>
+  </url>
+  <url>
+    <loc>http://localhost:3000/posts/a&amp;b</loc>
+    <lastmod>2026-03-04T04:06:07Z</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>https://cdn.example.com/feed</loc>
+  </url>
+</urlset>`, w.Body.String())
+	})
+
+	t.Run("uses the configured base URL", func(t *testing.T) {
+		setSitemapConfig(t, config.SitemapConfig{BaseURL: "https://example.com/"}, config.RobotsConfig{}, "/")
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sitemap.xml", nil))
+		assert.Contains(t, w.Body.String(), "<loc>https://example.com/about</loc>")
+	})
+
+	t.Run("fails when a provider fails", func(t *testing.T) {
+		Register(ProviderFunc(func(ctx context.Context) ([]Entry, error) {
+			return nil, errors.New("database down")
+		}))
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, httptest.NewRequest("GET", "/sitemap.xml", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+// TestRobotsHandler tests serving robots.txt
+func TestRobotsHandler(t *testing.T) {
+	t.Run("allows everything by default", func(t *testing.T) {
+		setSitemapConfig(t, config.SitemapConfig{}, config.RobotsConfig{}, "/")
+		w := httptest.NewRecorder()
+		RobotsHandler().ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/robots.txt", nil))
+
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "User-agent: *\nDisallow:\n\nSitemap: http://example.com/sitemap.xml\n", w.Body.String())
+	})
+
+	t.Run("lists the configured rules", func(t *testing.T) {
+		robots := config.RobotsConfig{Disallow: []string{"/admin", "/api"}, Allow: []string{"/api/docs"}}
+		setSitemapConfig(t, config.SitemapConfig{BaseURL: "https://example.com"}, robots, "/")
+		w := httptest.NewRecorder()
+		RobotsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/robots.txt", nil))
+
+		assert.Equal(t, "User-agent: *\nAllow: /api/docs\nDisallow: /admin\nDisallow: /api\n\nSitemap: https://example.com/sitemap.xml\n", w.Body.String())
+	})
+}
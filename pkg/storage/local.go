@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// LocalDriver is a Driver storing files under a directory. Its signed URLs
+// point at a route serving the files, such as kit.SignedFileHandler.
+type LocalDriver struct {
+	root    string
+	baseURL string
+	key     []byte
+	now     func() time.Time
+}
+
+// NewLocalDriver creates a LocalDriver storing files under root. baseURL
+// is where the files are served, such as "/files" or
+// "https://example.com/files", for SignedURL.
+func NewLocalDriver(root, baseURL string) *LocalDriver {
+	return &LocalDriver{root: root, baseURL: strings.TrimSuffix(baseURL, "/"), now: time.Now}
+}
+
+// Path returns the file path for key
+func (d *LocalDriver) Path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes r to a temporary file beside the one for key, then renames it
+// into place, so readers never see a partial file
+func (d *LocalDriver) Put(_ context.Context, key string, r io.Reader, _ PutOptions) error {
+	target, err := d.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	return nil
+}
+
+// Get opens the file for key
+func (d *LocalDriver) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	target, err := d.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if os.IsNotExist(err) {
+		return nil, errors.ErrFileNotFound.WithValue(key)
+	}
+	if err != nil {
+		return nil, errors.ErrStorageRead.Wrap(err).WithValue(key)
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, errors.ErrFileNotFound.WithValue(key)
+	}
+	return f, nil
+}
+
+// Delete removes the file for key
+func (d *LocalDriver) Delete(_ context.Context, key string) error {
+	target, err := d.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return errors.ErrStorageDelete.Wrap(err).WithValue(key)
+	}
+	return nil
+}
+
+// SignedURL returns the file's URL under the base URL, with an expiry and
+// a signature keyed by AUTH_SECRET that Verify checks
+func (d *LocalDriver) SignedURL(_ context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if d.baseURL == "" {
+		return "", fmt.Errorf("signing %s: STORAGE_URL is required for local signed URLs", key)
+	}
+
+	expires := strconv.FormatInt(d.now().Add(expiry).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {d.sign(key, expires)},
+	}
+	return d.baseURL + "/" + escapePath(key) + "?" + query.Encode(), nil
+}
+
+// Verify checks the expires and signature query parameters of a URL from
+// SignedURL for key
+func (d *LocalDriver) Verify(key string, query url.Values) error {
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || d.now().Unix() > unix {
+		return errors.ErrInvalidSignature.WithValue(key)
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(d.sign(key, expires))) {
+		return errors.ErrInvalidSignature.WithValue(key)
+	}
+	return nil
+}
+
+func (d *LocalDriver) sign(key, expires string) string {
+	secret := d.key
+	if secret == nil {
+		secret = []byte(config.Get().Auth.SecretKey)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// escapePath escapes each segment of a slash-separated key for a URL path
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
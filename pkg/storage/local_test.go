@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// read returns the contents of the file under key
+func read(t *testing.T, driver Driver, key string) string {
+	t.Helper()
+	r, err := driver.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestLocalDriver tests storing, reading and deleting files on disk
+func TestLocalDriver(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	driver := NewLocalDriver(root, "")
+
+	require.NoError(t, driver.Put(ctx, "avatars/42.png", strings.NewReader("first"), PutOptions{ContentType: "image/png"}))
+	require.NoError(t, driver.Put(ctx, "avatars/42.png", strings.NewReader("second"), PutOptions{}))
+	assert.Equal(t, "second", read(t, driver, "avatars/42.png"))
+
+	data, err := os.ReadFile(filepath.Join(root, "avatars", "42.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+	entries, err := os.ReadDir(filepath.Join(root, "avatars"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+
+	_, err = driver.Get(ctx, "avatars")
+	assert.ErrorIs(t, err, errors.ErrFileNotFound, "directories aren't files")
+	_, err = driver.Get(ctx, "avatars/7.png")
+	assert.ErrorIs(t, err, errors.ErrFileNotFound)
+
+	require.NoError(t, driver.Delete(ctx, "avatars/42.png"))
+	require.NoError(t, driver.Delete(ctx, "avatars/42.png"), "deleting a missing file succeeds")
+	_, err = driver.Get(ctx, "avatars/42.png")
+	assert.ErrorIs(t, err, errors.ErrFileNotFound)
+
+	err = driver.Put(ctx, "../outside.txt", strings.NewReader("x"), PutOptions{})
+	assert.ErrorIs(t, err, errors.ErrInvalidFileKey)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(root), "outside.txt"))
+}
+
+// TestLocalDriver_SignedURL tests signing and verifying URLs
+func TestLocalDriver_SignedURL(t *testing.T) {
+	ctx := context.Background()
+	driver := NewLocalDriver(t.TempDir(), "https://example.com/files/")
+	driver.key = []byte("test-secret")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	driver.now = func() time.Time { return now }
+
+	_, err := NewLocalDriver(t.TempDir(), "").SignedURL(ctx, "a.txt", time.Minute)
+	assert.Error(t, err, "signed URLs need STORAGE_URL")
+
+	signed, err := driver.SignedURL(ctx, "reports/march 2026.csv", time.Hour)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", u.Host)
+	assert.Equal(t, "/files/reports/march%202026.csv", u.EscapedPath())
+	assert.Equal(t, "1772370000", u.Query().Get("expires"))
+
+	query := u.Query()
+	assert.NoError(t, driver.Verify("reports/march 2026.csv", query))
+	assert.ErrorIs(t, driver.Verify("reports/april 2026.csv", query), errors.ErrInvalidSignature)
+
+	tampered := u.Query()
+	tampered.Set("expires", "1772380000")
+	assert.ErrorIs(t, driver.Verify("reports/march 2026.csv", tampered), errors.ErrInvalidSignature)
+
+	now = now.Add(2 * time.Hour)
+	assert.ErrorIs(t, driver.Verify("reports/march 2026.csv", query), errors.ErrInvalidSignature, "the URL expired")
+}
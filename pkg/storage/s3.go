@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// MaxSignedURLExpiry is the longest expiry S3 accepts for a signed URL
+const MaxSignedURLExpiry = 7 * 24 * time.Hour
+
+// unsignedPayload stands in for the body's hash, so bodies are streamed
+// instead of read twice
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Options configure an S3Driver
+type S3Options struct {
+	// Endpoint is the server's URL, e.g. http://localhost:9000 for MinIO;
+	// empty uses AWS in Region
+	Endpoint string
+	Region   string
+	Bucket   string
+	// AccessKey and SecretKey sign requests with AWS Signature Version 4
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path rather than the host name
+	PathStyle bool
+	// Client sends the requests; nil uses http.DefaultClient
+	Client *http.Client
+}
+
+// S3Driver is a Driver on Amazon S3 or a compatible server such as MinIO,
+// Cloudflare R2 or DigitalOcean Spaces
+type S3Driver struct {
+	opts     S3Options
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+// NewS3Driver creates an S3Driver for opts
+func NewS3Driver(opts S3Options) (*S3Driver, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint %q must be an http:// or https:// URL", opts.Endpoint)
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &S3Driver{opts: opts, endpoint: endpoint, now: time.Now}, nil
+}
+
+// Put uploads r under key. Readers without a known size, such as a request
+// body, are first copied to a temporary file, as S3 needs the length up
+// front.
+func (d *S3Driver) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error {
+	u, err := d.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	body, size, cleanup, err := sized(r)
+	if err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	defer cleanup()
+
+	// NopCloser keeps the transport from closing the caller's reader
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), io.NopCloser(body))
+	if err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if opts.ContentType != "" {
+		req.Header.Set("Content-Type", opts.ContentType)
+	}
+	if _, err := d.do(req); err != nil {
+		return errors.ErrStorageWrite.Wrap(err).WithValue(key)
+	}
+	return nil
+}
+
+// Get downloads the object under key. The body is streamed from S3 as it
+// is read.
+func (d *S3Driver) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	u, err := d.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.ErrStorageRead.Wrap(err).WithValue(key)
+	}
+
+	resp, err := d.do(req)
+	if err != nil {
+		if s3Status(err) == http.StatusNotFound {
+			return nil, errors.ErrFileNotFound.WithValue(key)
+		}
+		return nil, errors.ErrStorageRead.Wrap(err).WithValue(key)
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object under key
+func (d *S3Driver) Delete(ctx context.Context, key string) error {
+	u, err := d.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return errors.ErrStorageDelete.Wrap(err).WithValue(key)
+	}
+	if _, err := d.do(req); err != nil && s3Status(err) != http.StatusNotFound {
+		return errors.ErrStorageDelete.Wrap(err).WithValue(key)
+	}
+	return nil
+}
+
+// SignedURL returns a presigned GET URL for the object under key. expiry is
+// capped at MaxSignedURLExpiry.
+func (d *S3Driver) SignedURL(_ context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := d.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	expiry = min(max(expiry, time.Second), MaxSignedURLExpiry)
+
+	now := d.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := d.scope(now)
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {d.opts.AccessKey + "/" + scope},
+		"X-Amz-Date":          {amzDate},
+		"X-Amz-Expires":       {strconv.Itoa(int(expiry / time.Second))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + d.signature(now, amzDate, scope, canonical)
+	return u.String(), nil
+}
+
+// objectURL returns the URL of the object under key
+func (d *S3Driver) objectURL(key string) (*url.URL, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	u := *d.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if d.opts.PathStyle {
+		path += "/" + d.opts.Bucket
+	} else {
+		u.Host = d.opts.Bucket + "." + u.Host
+	}
+	u.Path = path + "/" + key
+	u.RawPath = s3Escape(u.Path, true)
+	return &u, nil
+}
+
+// do signs req with an Authorization header and sends it, failing on a
+// status that isn't 2xx
+func (d *S3Driver) do(req *http.Request) (*http.Response, error) {
+	now := d.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := d.scope(now)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + unsignedPayload + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		strings.Join(signed, ";"),
+		unsignedPayload,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		d.opts.AccessKey, scope, strings.Join(signed, ";"), d.signature(now, amzDate, scope, canonical)))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &s3Error{status: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+func (d *S3Driver) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + d.opts.Region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request with a key derived from the secret
+// key, the date and the region
+func (d *S3Driver) signature(now time.Time, amzDate, scope, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+d.opts.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, d.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key, escaped as Signature Version
+// 4 expects
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters, and
+// slashes when keepSlash is set for paths, as Signature Version 4 expects
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && keepSlash {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Error is a response from S3 that isn't 2xx
+type s3Error struct {
+	status int
+	body   string
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("S3 responded %d: %s", e.status, e.body)
+}
+
+// s3Status returns the status of an s3Error, or 0
+func s3Status(err error) int {
+	if e, ok := err.(*s3Error); ok {
+		return e.status
+	}
+	return 0
+}
+
+// sized returns r with its length, copying it to a temporary file when
+// the length isn't known. cleanup removes the file.
+func sized(r io.Reader) (body io.Reader, size int64, cleanup func(), err error) {
+	cleanup = func() {}
+	switch v := r.(type) {
+	case *bytes.Reader:
+		return v, int64(v.Len()), cleanup, nil
+	case *bytes.Buffer:
+		return v, int64(v.Len()), cleanup, nil
+	case *strings.Reader:
+		return v, int64(v.Len()), cleanup, nil
+	case io.Seeker:
+		current, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			break
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			break
+		}
+		if _, err := v.Seek(current, io.SeekStart); err != nil {
+			return nil, 0, cleanup, err
+		}
+		return r, end - current, cleanup, nil
+	}
+
+	tmp, err := os.CreateTemp("", "twine-upload-*")
+	if err != nil {
+		return nil, 0, cleanup, err
+	}
+	cleanup = func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	size, err = io.Copy(tmp, r)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, func() {}, err
+	}
+	return tmp, size, cleanup, nil
+}
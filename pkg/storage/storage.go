@@ -0,0 +1,162 @@
+// Package storage stores files such as user uploads on local disk or an
+// S3-compatible server, behind one Driver interface
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/logger"
+)
+
+// PutOptions describe a file being stored
+type PutOptions struct {
+	// ContentType is stored with the file where the driver supports it,
+	// and served with it
+	ContentType string
+}
+
+// Driver stores files by key, a slash-separated path such as
+// "avatars/42.png". LocalDriver and S3Driver implement it.
+type Driver interface {
+	// Put stores the contents of r under key, replacing any file there
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error
+	// Get opens the file under key, or fails with errors.ErrFileNotFound.
+	// The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file under key. Deleting a missing file succeeds.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that serves the file under key until expiry
+	// passes, without other authentication
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+var (
+	mu       sync.Mutex
+	instance Driver
+)
+
+// Default returns the application's Driver, creating it from
+// config.Get().Storage when first called
+func Default() Driver {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if instance == nil {
+		cfg := config.Get().Storage
+		driver, err := New(cfg)
+		if err != nil {
+			logger.Get().Error("Creating %s storage: %v; using local disk", cfg.Driver, err)
+			driver = NewLocalDriver(cfg.Root, cfg.URL)
+		}
+		instance = driver
+	}
+	return instance
+}
+
+// New creates the Driver for cfg.Driver
+func New(cfg config.StorageConfig) (Driver, error) {
+	switch cfg.Driver {
+	case config.StorageLocal, "":
+		return NewLocalDriver(cfg.Root, cfg.URL), nil
+	case config.StorageS3:
+		return NewS3Driver(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+// Use makes driver the one returned by Default. It is meant for tests and
+// for applications with their own Driver.
+func Use(driver Driver) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = driver
+}
+
+// Reset forgets the current driver, so the next Default creates it again
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = nil
+}
+
+// Put stores the contents of r under key in the driver returned by
+// Default. r is streamed rather than read into memory:
+//
+//	err := storage.Put(ctx, "reports/2026-03.csv", file, storage.PutOptions{ContentType: "text/csv"})
+func Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error {
+	return Default().Put(ctx, key, r, opts)
+}
+
+// Get opens the file under key in the driver returned by Default
+func Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return Default().Get(ctx, key)
+}
+
+// Delete removes the file under key in the driver returned by Default
+func Delete(ctx context.Context, key string) error {
+	return Default().Delete(ctx, key)
+}
+
+// SignedURL returns a URL serving the file under key in the driver
+// returned by Default until expiry passes
+func SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return Default().SignedURL(ctx, key, expiry)
+}
+
+// CleanKey checks that key is a relative slash-separated path that stays
+// inside the store, and returns it cleaned
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsAny(key, "\\\x00") {
+		return "", errors.ErrInvalidFileKey.WithValue(key)
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.ErrInvalidFileKey.WithValue(key)
+	}
+	return cleaned, nil
+}
+
+// Upload describes a file saved from a multipart form
+type Upload struct {
+	// Key is where the file was stored
+	Key string `json:"key"`
+	// Filename is the name the client sent
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// UniqueKey returns a key under prefix with a random name and filename's
+// extension, e.g. "avatars/0b9e....png", so uploads never overwrite each
+// other or trust the client's name
+func UniqueKey(prefix, filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	for _, c := range ext[min(1, len(ext)):] {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9') {
+			ext = ""
+			break
+		}
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return prefix + uuid.NewString() + ext
+}
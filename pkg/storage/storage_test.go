@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+	"github.com/cstone-io/twine/pkg/errors"
+)
+
+// TestCleanKey tests accepting keys inside the store and rejecting others
+func TestCleanKey(t *testing.T) {
+	valid := map[string]string{
+		"avatar.png":          "avatar.png",
+		"avatars/42.png":      "avatars/42.png",
+		"avatars//42.png":     "avatars/42.png",
+		"a/../avatars/42.png": "avatars/42.png",
+		"reports/2026 q1.csv": "reports/2026 q1.csv",
+	}
+	for key, want := range valid {
+		got, err := CleanKey(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, want, got)
+	}
+
+	for _, key := range []string{"", ".", "..", "../secret", "a/../../secret", "/etc/passwd", `avatars\42.png`, "a\x00b"} {
+		_, err := CleanKey(key)
+		assert.ErrorIs(t, err, errors.ErrInvalidFileKey, key)
+	}
+}
+
+// TestUniqueKey tests generating upload keys
+func TestUniqueKey(t *testing.T) {
+	key := UniqueKey("avatars/", "Me.PNG")
+	assert.True(t, strings.HasPrefix(key, "avatars/"), key)
+	assert.True(t, strings.HasSuffix(key, ".png"), key)
+	assert.NotEqual(t, key, UniqueKey("avatars", "Me.PNG"))
+
+	assert.NotContains(t, UniqueKey("", "evil.p<h>p"), ".")
+	assert.NotContains(t, UniqueKey("", "README"), ".")
+	assert.NotContains(t, UniqueKey("", "README"), "/")
+}
+
+// TestNew tests choosing a driver from configuration
+func TestNew(t *testing.T) {
+	driver, err := New(config.StorageConfig{Driver: config.StorageLocal, Root: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &LocalDriver{}, driver)
+
+	driver, err = New(config.StorageConfig{Driver: config.StorageS3, S3Bucket: "uploads", S3Endpoint: "http://localhost:9000"})
+	require.NoError(t, err)
+	assert.IsType(t, &S3Driver{}, driver)
+
+	_, err = New(config.StorageConfig{Driver: config.StorageS3})
+	assert.Error(t, err, "a bucket is required")
+
+	_, err = New(config.StorageConfig{Driver: "ftp"})
+	assert.Error(t, err)
+}
+
+// TestPackageFunctions tests Put, Get, Delete and SignedURL on the driver
+// set with Use
+func TestPackageFunctions(t *testing.T) {
+	ctx := context.Background()
+	driver := NewLocalDriver(t.TempDir(), "/files")
+	driver.key = []byte("test-secret")
+	Use(driver)
+	t.Cleanup(Reset)
+	assert.Same(t, driver, Default())
+
+	require.NoError(t, Put(ctx, "notes/hello.txt", strings.NewReader("hello"), PutOptions{}))
+	r, err := Get(ctx, "notes/hello.txt")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	url, err := SignedURL(ctx, "notes/hello.txt", 0)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, "/files/notes/hello.txt?expires="), url)
+
+	require.NoError(t, Delete(ctx, "notes/hello.txt"))
+	_, err = Get(ctx, "notes/hello.txt")
+	assert.ErrorIs(t, err, errors.ErrFileNotFound)
+}
@@ -0,0 +1,122 @@
+package template
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Components are templates rendered with their own data, their props,
+// rather than with dot. By convention each lives in
+// templates/components/<name>.html and defines a template of that name:
+//
+//	{{define "user-card"}}<div class="card">{{.Name}}</div>{{end}}
+//
+// Pages render them with the component function, passing a props struct or
+// a map built with props:
+//
+//	{{component "user-card" .Author}}
+//	{{component "user-card" (props "Name" .Author.Name)}}
+//
+// and handlers with RenderComponent, e.g. for an Ajax partial. twine
+// templates check reports calls to undefined components, and fields a
+// component uses that its props struct, <Name>Props, lacks.
+
+var (
+	// componentProps are the props types declared with RegisterComponent
+	componentProps = map[string]reflect.Type{}
+	componentMutex sync.RWMutex
+)
+
+// RegisterComponent declares the props of the named component by example,
+// so rendering it with props of another type fails rather than rendering
+// empty fields:
+//
+//	template.RegisterComponent("user-card", UserCardProps{})
+//
+// Pointers to the type are accepted too.
+func RegisterComponent(name string, props any) {
+	componentMutex.Lock()
+	defer componentMutex.Unlock()
+	componentProps[name] = indirectType(reflect.TypeOf(props))
+}
+
+// RenderComponent renders the named component with props
+func RenderComponent(w io.Writer, name string, props any) error {
+	if err := checkProps(name, props); err != nil {
+		return err
+	}
+	return execute(w, name, props)
+}
+
+// checkProps reports props of another type than the component registered
+func checkProps(name string, props any) error {
+	componentMutex.RLock()
+	want, ok := componentProps[name]
+	componentMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	if got := indirectType(reflect.TypeOf(props)); got != want {
+		return fmt.Errorf("component %q takes %s props, got %T", name, want, props)
+	}
+	return nil
+}
+
+// indirectType returns the type t points to, or t
+func indirectType(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// componentFuncs returns the component function rendering components
+// defined in tmpl
+func componentFuncs(tmpl *template.Template) template.FuncMap {
+	return template.FuncMap{
+		"component": func(name string, props any) (template.HTML, error) {
+			if err := checkProps(name, props); err != nil {
+				return "", err
+			}
+			component := tmpl.Lookup(name)
+			if component == nil {
+				return "", fmt.Errorf("component %q is not defined", name)
+			}
+
+			buf := getBuffer()
+			defer putBuffer(buf)
+			if err := component.Execute(buf, props); err != nil {
+				return "", err
+			}
+			return template.HTML(buf.String()), nil
+		},
+	}
+}
+
+// component stands in for the component function in FuncMap, so templates
+// using it parse, until LoadTemplates or LoadSet binds it to the templates
+// it loads
+func component(name string, props any) (template.HTML, error) {
+	return "", fmt.Errorf("component %q rendered from templates not loaded by LoadTemplates or LoadSet", name)
+}
+
+// props builds a component's props from key and value pairs:
+//
+//	{{component "badge" (props "Label" "New" "Color" "green")}}
+func props(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("props takes key and value pairs, got %d arguments", len(pairs))
+	}
+	m := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("props key %v is not a string", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
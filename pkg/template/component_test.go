@@ -0,0 +1,101 @@
+package template
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cardProps are the props of the card component in the tests
+type cardProps struct {
+	Name string
+	Role string
+}
+
+// loadComponents loads a page and a card component for testing
+func loadComponents(t *testing.T) {
+	t.Helper()
+	resetTemplates()
+	t.Cleanup(resetTemplates)
+	t.Cleanup(func() {
+		componentMutex.Lock()
+		defer componentMutex.Unlock()
+		componentProps = map[string]reflect.Type{}
+	})
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"components/card.html": `{{define "card"}}<div>{{.Name}} ({{.Role}})</div>{{end}}`,
+		"pages/team.html":      `{{define "team"}}{{range .}}{{component "card" .}}{{end}}{{end}}`,
+		"pages/inline.html":    `{{define "inline"}}{{component "card" (props "Name" "Ada" "Role" "<admin>")}}{{end}}`,
+		"pages/missing.html":   `{{define "missing"}}{{component "avatar" .}}{{end}}`,
+	})
+	require.NoError(t, LoadTemplates(filepath.Join(dir, "*", "*.html")))
+}
+
+// TestComponent tests rendering components from templates
+func TestComponent(t *testing.T) {
+	loadComponents(t)
+	render := func(name string, data any) (string, error) {
+		var buf bytes.Buffer
+		err := RenderFull(&buf, name, data)
+		return buf.String(), err
+	}
+
+	t.Run("renders components with their props", func(t *testing.T) {
+		out, err := render("team", []cardProps{{"Ada", "admin"}, {"Grace", "editor"}})
+		require.NoError(t, err)
+		assert.Equal(t, "<div>Ada (admin)</div><div>Grace (editor)</div>", out)
+	})
+
+	t.Run("builds props from pairs and escapes them", func(t *testing.T) {
+		out, err := render("inline", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "<div>Ada (&lt;admin&gt;)</div>", out)
+	})
+
+	t.Run("fails for undefined components", func(t *testing.T) {
+		_, err := render("missing", nil)
+		assert.ErrorContains(t, err, `component "avatar" is not defined`)
+	})
+
+	t.Run("checks registered props types", func(t *testing.T) {
+		RegisterComponent("card", cardProps{})
+
+		_, err := render("team", []cardProps{{"Ada", "admin"}})
+		require.NoError(t, err)
+		_, err = render("team", []*cardProps{{"Ada", "admin"}})
+		require.NoError(t, err, "pointers to the props type are accepted")
+
+		_, err = render("inline", nil)
+		assert.ErrorContains(t, err, `component "card" takes template.cardProps props, got map[string]interface {}`)
+	})
+}
+
+// TestRenderComponent tests rendering a component on its own
+func TestRenderComponent(t *testing.T) {
+	loadComponents(t)
+	RegisterComponent("card", cardProps{})
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderComponent(&buf, "card", cardProps{"Ada", "admin"}))
+	assert.Equal(t, "<div>Ada (admin)</div>", buf.String())
+
+	assert.Error(t, RenderComponent(&buf, "card", "Ada"))
+}
+
+// TestProps tests building props from key and value pairs
+func TestProps(t *testing.T) {
+	m, err := props("Name", "Ada", "Admin", true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"Name": "Ada", "Admin": true}, m)
+
+	_, err = props("Name")
+	assert.ErrorContains(t, err, "key and value pairs")
+	_, err = props(1, "Ada")
+	assert.ErrorContains(t, err, "not a string")
+}
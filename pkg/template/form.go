@@ -0,0 +1,247 @@
+package template
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// CSRFField is the name of the hidden input forms send their CSRF token in
+const CSRFField = "csrf_token"
+
+// Form renders a form from a model's fields, the same ones kit.Decode reads:
+// exported fields with a form tag, and the fields of structs tagged so. A
+// label tag sets the label, which is otherwise the field name in words, and
+// an input tag the input type, which otherwise follows the field's type:
+//
+//	type Post struct {
+//	    Title string    `form:"title" label:"Headline"`
+//	    Body  string    `form:"body" input:"textarea"`
+//	    Draft bool      `form:"draft"`
+//	    Date  time.Time `form:"date" input:"date"`
+//	}
+//
+// Values are the model's, so a form re-rendered after a failed submit keeps
+// what was entered. Password inputs are always empty. Slices aren't
+// rendered.
+type Form struct {
+	// Model is the struct, or pointer to one, the form edits
+	Model any
+	// Errors are validation messages by form field name. The message for
+	// "" concerns the whole form.
+	Errors map[string]string
+	// Action is the URL the form posts to
+	Action string
+	// Method is the form's method, "post" when empty
+	Method string
+	// CSRFToken is sent in the CSRFField hidden input
+	CSRFToken string
+	// Honeypot names an input hidden from people, for
+	// middleware.Honeypot to refuse the posts of bots that fill it in
+	Honeypot string
+	// Submit is the submit button's label, "Save" when empty
+	Submit string
+}
+
+// FormField is a field of a Form
+type FormField struct {
+	Name    string // form tag, also the input's id
+	Label   string
+	Type    string // input type, or "textarea"
+	Value   string
+	Checked bool   // for checkboxes
+	Step    string // for number inputs of floats
+	Error   string
+}
+
+var formTemplates = template.Must(template.New("form").Parse(`
+{{- define "form"}}<form method="{{.Method}}" action="{{.Action}}" class="form">{{template "fields" .}}<button type="submit">{{.Submit}}</button></form>{{end}}
+
+{{- define "fields"}}
+{{- with .CSRFToken}}<input type="hidden" name="` + CSRFField + `" value="{{.}}">{{end}}
+{{- with .Honeypot}}<div class="form-honeypot" aria-hidden="true" style="position:absolute;left:-10000px"><label for="{{.}}">Leave this empty</label><input type="text" id="{{.}}" name="{{.}}" tabindex="-1" autocomplete="off"></div>{{end}}
+{{- with index .Errors ""}}<p class="form-error">{{.}}</p>{{end}}
+{{- range .Fields}}{{template "field" .}}{{end}}
+{{- end}}
+
+{{- define "field"}}<div class="form-field{{if .Error}} form-field-invalid{{end}}">
+{{- if eq .Type "checkbox"}}<input type="checkbox" id="{{.Name}}" name="{{.Name}}" value="true"{{if .Checked}} checked{{end}}{{template "invalid" .}}> <label for="{{.Name}}">{{.Label}}</label>
+{{- else}}<label for="{{.Name}}">{{.Label}}</label>
+{{- if eq .Type "textarea"}}<textarea id="{{.Name}}" name="{{.Name}}"{{template "invalid" .}}>{{.Value}}</textarea>
+{{- else}}<input type="{{.Type}}" id="{{.Name}}" name="{{.Name}}" value="{{.Value}}"{{with .Step}} step="{{.}}"{{end}}{{template "invalid" .}}>
+{{- end}}
+{{- end}}
+{{- with .Error}}<p class="form-error" id="{{$.Name}}-error">{{.}}</p>{{end}}</div>
+{{- end}}
+
+{{- define "invalid"}}{{if .Error}} aria-invalid="true" aria-describedby="{{.Name}}-error"{{end}}{{end}}
+`))
+
+// formData is what formTemplates render
+type formData struct {
+	*Form
+	Fields []FormField
+}
+
+// HTML renders the whole form: the CSRF token, every field and a submit
+// button
+func (f *Form) HTML() (template.HTML, error) {
+	data := formData{Form: f, Fields: f.Fields()}
+	if data.Method == "" {
+		data.Method = "post"
+	}
+	if data.Submit == "" {
+		data.Submit = "Save"
+	}
+	return executeForm("form", data)
+}
+
+// Fields lists the form's fields in the model's order
+func (f *Form) Fields() []FormField {
+	v := reflect.ValueOf(f.Model)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return f.appendFields(nil, v)
+}
+
+// appendFields appends the form fields of struct v to fields
+func (f *Form) appendFields(fields []FormField, v reflect.Value) []FormField {
+	for i := 0; i < v.NumField(); i++ {
+		typeField := v.Type().Field(i)
+		name := typeField.Tag.Get("form")
+		if name == "" || name == "-" || !typeField.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}) {
+			fields = f.appendFields(fields, value)
+			continue
+		}
+
+		field, ok := newFormField(typeField, value)
+		if !ok {
+			continue
+		}
+		field.Name = name
+		field.Error = f.Errors[name]
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// newFormField describes a field by its tags, type and value. ok is false
+// for types forms don't render.
+func newFormField(typeField reflect.StructField, value reflect.Value) (field FormField, ok bool) {
+	field.Label = typeField.Tag.Get("label")
+	if field.Label == "" {
+		field.Label = fieldLabel(typeField.Name)
+	}
+	field.Type = typeField.Tag.Get("input")
+
+	switch {
+	case value.Type() == reflect.TypeOf(time.Time{}):
+		if field.Type == "" {
+			field.Type = "datetime-local"
+		}
+		if t := value.Interface().(time.Time); !t.IsZero() {
+			layout := "2006-01-02T15:04"
+			if field.Type == "date" {
+				layout = "2006-01-02"
+			}
+			field.Value = t.Format(layout)
+		}
+	case value.Kind() == reflect.String:
+		field.Value = value.String()
+	case value.CanInt():
+		field.Value = strconv.FormatInt(value.Int(), 10)
+		if field.Type == "" {
+			field.Type = "number"
+		}
+	case value.CanUint():
+		field.Value = strconv.FormatUint(value.Uint(), 10)
+		if field.Type == "" {
+			field.Type = "number"
+		}
+	case value.CanFloat():
+		field.Value = strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits())
+		if field.Type == "" {
+			field.Type, field.Step = "number", "any"
+		}
+	case value.Kind() == reflect.Bool:
+		field.Checked = value.Bool()
+		field.Type = "checkbox"
+	default:
+		return field, false
+	}
+
+	if field.Type == "" {
+		field.Type = "text"
+	}
+	if field.Type == "password" {
+		field.Value = ""
+	}
+	return field, true
+}
+
+// fieldLabel turns a field name into words: PublishedAt is "Published at"
+// and URL stays "URL"
+func fieldLabel(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		startsWord := i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+		if startsWord {
+			b.WriteRune(' ')
+			// Keep acronyms such as ID upper case
+			if i+1 < len(runes) && unicode.IsUpper(runes[i+1]) {
+				b.WriteRune(r)
+				continue
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// executeForm renders one of formTemplates
+func executeForm(name string, data any) (template.HTML, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := formTemplates.ExecuteTemplate(buf, name, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// formFields renders the CSRF token, form-wide error and fields of f, for
+// templates writing their own <form>:
+//
+//	<form method="post" action="/posts">{{formFields .Form}}<button>Publish</button></form>
+func formFields(f *Form) (template.HTML, error) {
+	return executeForm("fields", formData{Form: f, Fields: f.Fields()})
+}
+
+// formField renders the label, input and error of one field of f:
+//
+//	{{formField .Form "title"}}
+func formField(f *Form, name string) (template.HTML, error) {
+	for _, field := range f.Fields() {
+		if field.Name == name {
+			return executeForm("field", field)
+		}
+	}
+	return "", fmt.Errorf("form has no field %q", name)
+}
@@ -0,0 +1,131 @@
+package template
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formAddress struct {
+	City string `form:"city"`
+}
+
+type formModel struct {
+	ID          uint        `form:"-"`
+	Title       string      `form:"title" label:"Headline"`
+	Body        string      `form:"body" input:"textarea"`
+	Email       string      `form:"email" input:"email"`
+	Password    string      `form:"password" input:"password"`
+	Views       int         `form:"views"`
+	Price       float64     `form:"price"`
+	Draft       bool        `form:"draft"`
+	PublishedAt time.Time   `form:"published_at"`
+	Tags        []string    `form:"tags"`
+	Address     formAddress `form:"address"`
+	internal    string      `form:"internal"`
+	Untagged    string
+}
+
+// TestForm_Fields tests describing a model's fields
+func TestForm_Fields(t *testing.T) {
+	model := &formModel{
+		Title:       "Hello",
+		Password:    "secret",
+		Views:       3,
+		Price:       9.5,
+		Draft:       true,
+		PublishedAt: time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+		Address:     formAddress{City: "Oslo"},
+	}
+	form := &Form{Model: model, Errors: map[string]string{"title": "is required"}}
+
+	fields := form.Fields()
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	assert.Equal(t, []string{"title", "body", "email", "password", "views", "price", "draft", "published_at", "city"}, names,
+		"fields follow the model, skipping untagged, unexported, slice and - fields")
+
+	assert.Equal(t, FormField{Name: "title", Label: "Headline", Type: "text", Value: "Hello", Error: "is required"}, fields[0])
+	assert.Equal(t, "textarea", fields[1].Type)
+	assert.Equal(t, "email", fields[2].Type)
+	assert.Empty(t, fields[3].Value, "passwords aren't rendered")
+	assert.Equal(t, FormField{Name: "views", Label: "Views", Type: "number", Value: "3"}, fields[4])
+	assert.Equal(t, FormField{Name: "price", Label: "Price", Type: "number", Value: "9.5", Step: "any"}, fields[5])
+	assert.Equal(t, FormField{Name: "draft", Label: "Draft", Type: "checkbox", Checked: true}, fields[6])
+	assert.Equal(t, FormField{Name: "published_at", Label: "Published at", Type: "datetime-local", Value: "2026-01-02T15:04"}, fields[7])
+	assert.Equal(t, "Oslo", fields[8].Value)
+
+	assert.Empty(t, (&Form{Model: (*formModel)(nil)}).Fields())
+	assert.Empty(t, (&Form{Model: "title"}).Fields())
+}
+
+// TestFieldLabel tests turning field names into labels
+func TestFieldLabel(t *testing.T) {
+	for name, label := range map[string]string{
+		"Title":       "Title",
+		"PublishedAt": "Published at",
+		"UserID":      "User ID",
+		"URL":         "URL",
+		"HTMLBody":    "HTML body",
+	} {
+		assert.Equal(t, label, fieldLabel(name), name)
+	}
+}
+
+// TestForm_HTML tests rendering whole forms
+func TestForm_HTML(t *testing.T) {
+	type post struct {
+		Title string `form:"title"`
+		Draft bool   `form:"draft"`
+	}
+	form := &Form{
+		Model:     post{Title: `<b>"Hi"</b>`},
+		Errors:    map[string]string{"": "Could not save", "title": "is too short"},
+		Action:    "/posts",
+		CSRFToken: "token",
+	}
+
+	html, err := form.HTML()
+	require.NoError(t, err)
+	assert.Equal(t, `<form method="post" action="/posts" class="form">`+
+		`<input type="hidden" name="csrf_token" value="token">`+
+		`<p class="form-error">Could not save</p>`+
+		`<div class="form-field form-field-invalid"><label for="title">Title</label>`+
+		`<input type="text" id="title" name="title" value="&lt;b&gt;&#34;Hi&#34;&lt;/b&gt;" aria-invalid="true" aria-describedby="title-error">`+
+		`<p class="form-error" id="title-error">is too short</p></div>`+
+		`<div class="form-field"><input type="checkbox" id="draft" name="draft" value="true"> <label for="draft">Draft</label></div>`+
+		`<button type="submit">Save</button></form>`, string(html))
+
+	form = &Form{Model: post{}, Honeypot: "website"}
+	html, err = form.HTML()
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<div class="form-honeypot" aria-hidden="true" style="position:absolute;left:-10000px">`+
+		`<label for="website">Leave this empty</label><input type="text" id="website" name="website" tabindex="-1" autocomplete="off"></div>`)
+}
+
+// TestFormFuncs tests the formFields and formField template functions
+func TestFormFuncs(t *testing.T) {
+	type post struct {
+		Title string `form:"title"`
+		Body  string `form:"body" input:"textarea"`
+	}
+	tmpl := template.Must(template.New("page").Funcs(FuncMap()).Parse(
+		`<form>{{formFields .}}</form>|{{formField . "body"}}`))
+
+	var buf bytes.Buffer
+	form := &Form{Model: post{Title: "Hi", Body: "Text"}, CSRFToken: "token"}
+	require.NoError(t, tmpl.Execute(&buf, form))
+	assert.Equal(t, `<form><input type="hidden" name="csrf_token" value="token">`+
+		`<div class="form-field"><label for="title">Title</label><input type="text" id="title" name="title" value="Hi"></div>`+
+		`<div class="form-field"><label for="body">Body</label><textarea id="body" name="body">Text</textarea></div></form>|`+
+		`<div class="form-field"><label for="body">Body</label><textarea id="body" name="body">Text</textarea></div>`, buf.String())
+
+	_, err := formField(form, "missing")
+	assert.ErrorContains(t, err, `form has no field "missing"`)
+}
@@ -0,0 +1,54 @@
+package template
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	// sourceFS is the filesystem set with UseFS
+	sourceFS    fs.FS
+	sourceMutex sync.RWMutex
+)
+
+// UseFS sets the filesystem LoadTemplates and LoadSet read templates from,
+// such as an embed.FS holding the templates directory. Patterns are then
+// paths within fsys, such as "templates/**/*.html". twine build calls it
+// from a generated embed.gen.go, so a binary needs no templates on disk.
+// A nil fsys reads from disk again.
+func UseFS(fsys fs.FS) {
+	sourceMutex.Lock()
+	defer sourceMutex.Unlock()
+	sourceFS = fsys
+}
+
+// source returns the filesystem set with UseFS, or disk
+func source() fs.FS {
+	sourceMutex.RLock()
+	defer sourceMutex.RUnlock()
+	if sourceFS == nil {
+		return diskFS{}
+	}
+	return sourceFS
+}
+
+// diskFS reads files by their paths on disk which, unlike with os.DirFS,
+// may be absolute or relative to the working directory
+type diskFS struct{}
+
+// Open opens the named file
+func (diskFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// Glob returns the files matching pattern
+func (diskFS) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+// Stat describes the named file
+func (diskFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
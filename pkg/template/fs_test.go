@@ -0,0 +1,82 @@
+package template
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/*.html
+var testdataFS embed.FS
+
+// TestLoadFS tests loading templates from a filesystem
+func TestLoadFS(t *testing.T) {
+	t.Run("renders templates from an embed.FS", func(t *testing.T) {
+		resetTemplates()
+		t.Cleanup(resetTemplates)
+
+		require.NoError(t, LoadFS(testdataFS, "testdata/test.html", "testdata/partial.html"))
+
+		var buf bytes.Buffer
+		require.NoError(t, RenderFull(&buf, "test", map[string]string{"Name": "World"}))
+		assert.Equal(t, "Hello World", buf.String())
+	})
+
+	t.Run("reports parse errors with the file's source", func(t *testing.T) {
+		resetTemplates()
+		t.Cleanup(resetTemplates)
+		fsys := fstest.MapFS{"templates/page.html": {Data: []byte("<p>\n{{.Name}\n</p>")}}
+
+		err := LoadFS(fsys, "templates/*.html")
+		var parseErr *ParseError
+		require.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "templates/page.html", parseErr.File)
+		assert.Equal(t, 2, parseErr.Line)
+		assert.Equal(t, []SnippetLine{{1, "<p>"}, {2, "{{.Name}"}, {3, "</p>"}}, parseErr.Snippet)
+	})
+
+	t.Run("fails for patterns matching no files", func(t *testing.T) {
+		resetTemplates()
+		t.Cleanup(resetTemplates)
+
+		err := LoadFS(testdataFS, "templates/*.html")
+		assert.ErrorContains(t, err, "pattern matches no files")
+	})
+}
+
+// TestUseFS tests reading LoadTemplates and LoadSet patterns from the
+// filesystem set with UseFS
+func TestUseFS(t *testing.T) {
+	resetTemplates()
+	resetSets()
+	t.Cleanup(resetTemplates)
+	t.Cleanup(resetSets)
+	setReload(t, true)
+
+	fsys := fstest.MapFS{
+		"templates/index.html":            {Data: []byte(`{{define "index"}}embedded{{end}}`)},
+		"templates/admin/base.html":       {Data: []byte(`{{define "base"}}[{{block "content" .}}{{end}}]{{end}}`)},
+		"templates/admin/pages/home.html": {Data: []byte(`{{define "content"}}admin{{end}}`)},
+	}
+	UseFS(fsys)
+	t.Cleanup(func() { UseFS(nil) })
+
+	require.NoError(t, LoadTemplates("templates/*.html"))
+	var buf bytes.Buffer
+	require.NoError(t, RenderFull(&buf, "index", nil), "reload leaves embedded templates alone")
+	assert.Equal(t, "embedded", buf.String())
+
+	require.NoError(t, LoadSet("admin", SetConfig{
+		Layouts: []string{"templates/admin/*.html"},
+		Pages:   []string{"templates/admin/pages/*.html"},
+		Layout:  "base",
+	}))
+	out, err := renderIn(t, "admin", "home", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[admin]", out)
+}
@@ -1,8 +1,11 @@
 package template
 
 import (
+	"fmt"
 	"html/template"
 	"time"
+
+	"github.com/cstone-io/twine/pkg/sanitize"
 )
 
 // FuncMap returns the default template functions
@@ -22,6 +25,11 @@ func FuncMap() template.FuncMap {
 		"gt":             gt,
 		"ge":             ge,
 		"asset":          asset,
+		"component":      component,
+		"props":          props,
+		"formFields":     formFields,
+		"formField":      formField,
+		"sanitize":       sanitizeHTML,
 	}
 }
 
@@ -50,6 +58,24 @@ func le(a, b int) bool  { return a <= b }
 func gt(a, b int) bool  { return a > b }
 func ge(a, b int) bool  { return a >= b }
 
+// sanitizeHTML cleans user-generated HTML with the application's
+// sanitize.Policy, so it renders as markup without running scripts:
+//
+//	<div class="comment">{{sanitize .Comment.Body}}</div>
+func sanitizeHTML(v any) template.HTML {
+	var s string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		s = v
+	case template.HTML:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+	return template.HTML(sanitize.Sanitize(s))
+}
+
 // asset returns the path to a static asset
 func asset(name string) string {
 	return "/public/assets/" + name
@@ -1,10 +1,15 @@
 package template
 
 import (
+	"bytes"
+	"html/template"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/sanitize"
 )
 
 // TestFormatDate tests date formatting
@@ -150,6 +155,20 @@ func TestAsset(t *testing.T) {
 	})
 }
 
+// TestSanitizeHTML tests cleaning user-generated HTML in templates
+func TestSanitizeHTML(t *testing.T) {
+	sanitize.Reset()
+	t.Cleanup(sanitize.Reset)
+
+	tmpl := template.Must(template.New("comment").Funcs(FuncMap()).Parse(`<div>{{sanitize .}}</div>`))
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, `<b>Hi</b><script>alert(1)</script><img src=x onerror=alert(1)>`))
+	assert.Equal(t, `<div><b>Hi</b><img src="x"></div>`, buf.String())
+
+	assert.Equal(t, template.HTML(`<em>x</em>`), sanitizeHTML(template.HTML(`<em onclick="x">x</em>`)))
+	assert.Equal(t, template.HTML(""), sanitizeHTML(nil))
+}
+
 // TestFuncMap tests FuncMap registration
 func TestFuncMap(t *testing.T) {
 	t.Run("contains all helper functions", func(t *testing.T) {
@@ -170,6 +189,11 @@ func TestFuncMap(t *testing.T) {
 			"gt",
 			"ge",
 			"asset",
+			"component",
+			"props",
+			"formFields",
+			"formField",
+			"sanitize",
 		}
 
 		for _, name := range expectedFuncs {
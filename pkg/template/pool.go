@@ -0,0 +1,43 @@
+package template
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity above which render buffers are dropped
+// rather than pooled, so one huge page doesn't pin its memory
+const maxPooledBuffer = 256 << 10
+
+// buffers are reused across renders
+var buffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// getBuffer returns an empty render buffer
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+// buffered runs render into a pooled buffer and copies the output to w in
+// one write. A template failing halfway writes nothing, so the error page
+// isn't appended to half a page, and locks held while rendering aren't held
+// while writing to a slow client.
+func buffered(w io.Writer, render func(io.Writer) error) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := render(buf); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
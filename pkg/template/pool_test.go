@@ -0,0 +1,101 @@
+package template
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuffered tests writing renders at once, or not at all
+func TestBuffered(t *testing.T) {
+	resetTemplates()
+	t.Cleanup(resetTemplates)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"page.html": `{{define "page"}}<h1>Title</h1>{{index .Items 1}}{{end}}`,
+	})
+	require.NoError(t, LoadTemplates(filepath.Join(dir, "page.html")))
+
+	var buf bytes.Buffer
+	err := RenderFull(&buf, "page", map[string][]string{"Items": {"first"}})
+	require.Error(t, err)
+	assert.Empty(t, buf.String(), "half a page isn't written")
+
+	buf.Reset()
+	require.NoError(t, RenderFull(&buf, "page", map[string][]string{"Items": {"first", "ok"}}))
+	assert.Equal(t, "<h1>Title</h1>ok", buf.String())
+
+	large := getBuffer()
+	large.Grow(2 * maxPooledBuffer)
+	putBuffer(large)
+	assert.NotSame(t, large, getBuffer(), "large buffers aren't pooled")
+}
+
+// benchmarkFiles are a layout, page and component like an app's
+var benchmarkFiles = map[string]string{
+	"layouts/base.html":    `{{define "base"}}<!DOCTYPE html><html><head><title>{{block "title" .}}App{{end}}</title></head><body>{{block "content" .}}{{end}}</body></html>{{end}}`,
+	"components/card.html": `{{define "card"}}<div class="card"><h2>{{.Name}}</h2><p>{{.Role}}</p></div>{{end}}`,
+	"pages/team.html":      `{{define "team"}}{{template "base" .}}{{end}}{{define "content"}}{{range .Members}}{{component "card" .}}{{end}}{{end}}`,
+}
+
+// benchmarkData is the team page's data
+func benchmarkData() map[string]any {
+	members := make([]cardProps, 20)
+	for i := range members {
+		members[i] = cardProps{Name: "Ada Lovelace", Role: strings.Repeat("admin ", 3)}
+	}
+	return map[string]any{"Members": members}
+}
+
+// BenchmarkRenderFull measures rendering a page with a layout and
+// components
+func BenchmarkRenderFull(b *testing.B) {
+	resetTemplates()
+	b.Cleanup(resetTemplates)
+	dir := b.TempDir()
+	writeFiles(b, dir, benchmarkFiles)
+	if err := LoadTemplates(filepath.Join(dir, "*", "*.html")); err != nil {
+		b.Fatal(err)
+	}
+	data := benchmarkData()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := RenderFull(io.Discard, "team", data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkRenderIn measures rendering a page of a template set
+func BenchmarkRenderIn(b *testing.B) {
+	resetSets()
+	b.Cleanup(resetSets)
+	dir := b.TempDir()
+	writeFiles(b, dir, benchmarkFiles)
+	err := LoadSet("bench", SetConfig{
+		Layouts: []string{filepath.Join(dir, "layouts", "*.html"), filepath.Join(dir, "components", "*.html")},
+		Pages:   []string{filepath.Join(dir, "pages", "*.html")},
+		Layout:  "base",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := benchmarkData()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := RenderIn(io.Discard, "bench", "team", data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package template
+
+import (
+	"context"
+	"sync"
+)
+
+type recorderKey struct{}
+
+// Recorder collects the names of the templates rendered for a request, so
+// tests can check which page a handler rendered
+type Recorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// Names returns the recorded template names in render order
+func (r *Recorder) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+// WithRecorder returns a copy of ctx whose renders are recorded in r
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// Record notes that the named template was rendered for ctx. It does nothing
+// unless ctx carries a Recorder.
+func Record(ctx context.Context, name string) {
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = append(r.names, name)
+}
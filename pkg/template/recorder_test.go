@@ -0,0 +1,22 @@
+package template
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecorder tests recording rendered template names
+func TestRecorder(t *testing.T) {
+	recorder := &Recorder{}
+	ctx := WithRecorder(context.Background(), recorder)
+
+	Record(ctx, "index")
+	Record(ctx, "partial")
+	assert.Equal(t, []string{"index", "partial"}, recorder.Names())
+
+	// Without a recorder nothing happens
+	Record(context.Background(), "index")
+	assert.Len(t, recorder.Names(), 2)
+}
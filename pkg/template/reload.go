@@ -0,0 +1,198 @@
+package template
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// snippetContext is how many lines around a parse error's line ParseError
+// shows
+const snippetContext = 3
+
+// ParseError is a template file that failed to parse
+type ParseError struct {
+	// File is the path of the template
+	File string
+	// Line is the line of the error, or 0 when the parser gave none
+	Line int
+	// Message is the parser's description of the error
+	Message string
+	// Snippet holds the lines around Line
+	Snippet []SnippetLine
+	// Err is the error from html/template
+	Err error
+}
+
+// SnippetLine is a numbered line of a template
+type SnippetLine struct {
+	Number int
+	Text   string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.File + ": " + e.Message
+	}
+	return e.File + ":" + strconv.Itoa(e.Line) + ": " + e.Message
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// errorPage shows a ParseError in the browser
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Template error</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2937; }
+h1 { font-size: 1.25rem; color: #b91c1c; }
+pre { background: #f3f4f6; padding: 1rem; overflow-x: auto; line-height: 1.5; }
+.line { display: block; }
+.number { display: inline-block; width: 3rem; color: #9ca3af; user-select: none; }
+.error { background: #fee2e2; }
+</style>
+</head>
+<body>
+<h1>Template error in {{.File}}{{if .Line}}, line {{.Line}}{{end}}</h1>
+<p>{{.Message}}</p>
+{{- if .Snippet}}
+<pre>{{range .Snippet}}<span class="line{{if eq .Number $.Line}} error{{end}}"><span class="number">{{.Number}}</span>{{.Text}}</span>{{end}}</pre>
+{{- end}}
+<p>Fix the template and reload the page.</p>
+</body>
+</html>
+`))
+
+// HTML renders the error as a page for the browser
+func (e *ParseError) HTML() string {
+	var b strings.Builder
+	if err := errorPage.Execute(&b, e); err != nil {
+		return template.HTMLEscapeString(e.Error())
+	}
+	return b.String()
+}
+
+// parseLocation finds the line and message in an html/template error such
+// as `template: index.html:12: unexpected "}" in operand`
+var parseLocation = regexp.MustCompile(`(?s)^(?:html/)?template: ?[^:]*:(\d+):(?:\d+:)? ?(.*)$`)
+
+// newParseError describes the failure to parse file of fsys
+func newParseError(fsys fs.FS, file string, err error) *ParseError {
+	e := &ParseError{File: file, Message: err.Error(), Err: err}
+	if m := parseLocation.FindStringSubmatch(err.Error()); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Message = m[2]
+	}
+	if e.Line > 0 {
+		e.Snippet = readSnippet(fsys, file, e.Line)
+	}
+	return e
+}
+
+// readSnippet returns the lines of file around line
+func readSnippet(fsys fs.FS, file string, line int) []SnippetLine {
+	f, err := fsys.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []SnippetLine
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan() && n <= line+snippetContext; n++ {
+		if n >= line-snippetContext {
+			lines = append(lines, SnippetLine{Number: n, Text: scanner.Text()})
+		}
+	}
+	return lines
+}
+
+// parseFiles parses the files of fsys matching patterns one by one, so a
+// failure names its file. funcs are added to FuncMap.
+func parseFiles(fsys fs.FS, patterns []string, funcs template.FuncMap) (*template.Template, error) {
+	tmpl := template.New("")
+	tmpl.Funcs(FuncMap()).Funcs(componentFuncs(tmpl)).Funcs(funcs)
+	files, err := globFiles(fsys, patterns)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := parseFile(fsys, tmpl, file); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// parseFile parses file of fsys into tmpl as a template named after the
+// file's base name, as template.ParseFiles does
+func parseFile(fsys fs.FS, tmpl *template.Template, file string) error {
+	b, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return err
+	}
+	if _, err := tmpl.New(filepath.Base(file)).Parse(string(b)); err != nil {
+		return newParseError(fsys, file, err)
+	}
+	return nil
+}
+
+// globFiles returns the files of fsys matching patterns, failing for a
+// pattern that matches none as template.ParseGlob does
+func globFiles(fsys fs.FS, patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("html/template: pattern matches no files: %#q", pattern)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// stampFiles describes the files of fsys matching patterns by their names,
+// sizes and modification times. Embedded files never change.
+func stampFiles(fsys fs.FS, patterns []string) string {
+	var b strings.Builder
+	for _, pattern := range patterns {
+		files, _ := fs.Glob(fsys, pattern)
+		for _, file := range files {
+			info, err := fs.Stat(fsys, file)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(&b, "%s:%d:%d\n", file, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// refresh parses the templates again if their files changed since they
+// were loaded
+func refresh() {
+	templateMutex.RLock()
+	fsys, list, current := files, patterns, stamp
+	templateMutex.RUnlock()
+	if len(list) == 0 || stampFiles(fsys, list) == current {
+		return
+	}
+
+	templateMutex.Lock()
+	defer templateMutex.Unlock()
+	if stampFiles(files, patterns) != stamp {
+		load(files, patterns)
+	}
+}
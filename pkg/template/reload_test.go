@@ -0,0 +1,111 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// setReload sets templates.reload for the rest of the test
+func setReload(t *testing.T, reload bool) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.Templates
+	cfg.Templates.Reload = reload
+	t.Cleanup(func() { cfg.Templates = original })
+}
+
+// writeTemplate writes content to path with a modification time after any
+// earlier write, as editors saving quickly would
+func writeTemplate(t *testing.T, path, content string, age time.Duration) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	modified := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, modified, modified))
+}
+
+// TestLoadTemplates_ParseError tests naming the file and line that failed
+// to parse
+func TestLoadTemplates_ParseError(t *testing.T) {
+	resetTemplates()
+	t.Cleanup(resetTemplates)
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "a.html"), `{{define "a"}}fine{{end}}`, 0)
+	broken := filepath.Join(dir, "b.html")
+	writeTemplate(t, broken, "<h1>\n  {{.Title}}\n  {{if .Admin}\n</h1>\n", 0)
+
+	err := LoadTemplates(filepath.Join(dir, "*.html"))
+	var parseErr *ParseError
+	require.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, broken, parseErr.File)
+	assert.Equal(t, 3, parseErr.Line)
+	assert.Contains(t, parseErr.Message, "bad character")
+	assert.Equal(t, []SnippetLine{
+		{1, "<h1>"}, {2, "  {{.Title}}"}, {3, "  {{if .Admin}"}, {4, "</h1>"},
+	}, parseErr.Snippet)
+	assert.Contains(t, parseErr.Error(), broken+":3: ")
+
+	page := parseErr.HTML()
+	assert.Contains(t, page, broken+", line 3")
+	assert.Contains(t, page, `<span class="line error"><span class="number">3</span>  {{if .Admin}</span>`)
+	assert.Contains(t, page, "&lt;h1&gt;", "the template's source is escaped")
+}
+
+// TestRender_Reload tests re-parsing templates whose files changed
+func TestRender_Reload(t *testing.T) {
+	resetTemplates()
+	t.Cleanup(resetTemplates)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.html")
+	writeTemplate(t, path, `{{define "page"}}first{{end}}`, 3*time.Second)
+	require.NoError(t, LoadTemplates(filepath.Join(dir, "*.html")))
+
+	render := func() (string, error) {
+		var buf bytes.Buffer
+		err := RenderFull(&buf, "page", nil)
+		return buf.String(), err
+	}
+
+	t.Run("keeps the parsed templates without reload", func(t *testing.T) {
+		setReload(t, false)
+		writeTemplate(t, path, `{{define "page"}}second{{end}}`, 2*time.Second)
+		out, err := render()
+		require.NoError(t, err)
+		assert.Equal(t, "first", out)
+	})
+
+	t.Run("re-parses changed files with reload", func(t *testing.T) {
+		setReload(t, true)
+		out, err := render()
+		require.NoError(t, err)
+		assert.Equal(t, "second", out)
+
+		writeTemplate(t, path, `{{define "page"}}{{.Missing}{{end}}`, time.Second)
+		_, err = render()
+		var parseErr *ParseError
+		require.True(t, errors.As(err, &parseErr), "a broken edit is reported")
+		assert.Equal(t, path, parseErr.File)
+
+		writeTemplate(t, path, `{{define "page"}}fixed{{end}}`, 0)
+		out, err = render()
+		require.NoError(t, err)
+		assert.Equal(t, "fixed", out)
+	})
+
+	t.Run("leaves templates set with SetTemplates", func(t *testing.T) {
+		setReload(t, true)
+		SetTemplates(GetTemplates())
+		writeTemplate(t, path, `{{define "page"}}ignored{{end}}`, -time.Second)
+		out, err := render()
+		require.NoError(t, err)
+		assert.Equal(t, "fixed", out)
+	})
+}
@@ -0,0 +1,179 @@
+package template
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cstone-io/twine/pkg/config"
+)
+
+// SetConfig describes a named template set, such as "admin" or "public",
+// with its own layouts and functions
+type SetConfig struct {
+	// Layouts are patterns of the files every page of the set shares: base
+	// layouts, partials and components
+	Layouts []string
+	// Pages are patterns of page files. Each page is parsed with its own
+	// copy of the layouts, so pages can define the same blocks, such as
+	// "title" and "content", without clashing. A page is named after its
+	// file without the extension: pages/dashboard.html is "dashboard".
+	Pages []string
+	// Layout is the template every page renders, such as "base", filled in
+	// with the blocks the page defines. When empty, a page renders its own
+	// file, which can call {{template "base" .}} itself.
+	Layout string
+	// Funcs are added to FuncMap for the set, replacing functions of the
+	// same name
+	Funcs template.FuncMap
+	// FS holds the set's files, such as an embed.FS. When nil they're read
+	// from the filesystem set with UseFS, or from disk.
+	FS fs.FS
+}
+
+// set is a parsed template set
+type set struct {
+	config  SetConfig
+	layouts *template.Template
+	// pages are the templates each page renders: the set's layout in the
+	// page's copy of the layouts, or the page's own file
+	pages map[string]*template.Template
+	// stamp describes the set's files, to tell when they change
+	stamp string
+	// err is why the set last failed to parse
+	err error
+}
+
+var (
+	sets     = map[string]*set{}
+	setMutex sync.RWMutex
+)
+
+// LoadSet loads the named template set, replacing any loaded before under
+// the same name. Its pages and components are rendered with RenderIn. As
+// with LoadTemplates, a failure is a *ParseError naming the file and line,
+// and with templates.reload set the set is parsed again whenever its files
+// change.
+func LoadSet(name string, cfg SetConfig) error {
+	setMutex.Lock()
+	defer setMutex.Unlock()
+
+	if cfg.FS == nil {
+		cfg.FS = source()
+	}
+	s := &set{config: cfg}
+	sets[name] = s
+	return s.load()
+}
+
+// RenderIn renders name from the named set: the page of that name, or else
+// the layout, partial or component defined in the set's layouts
+func RenderIn(w io.Writer, setName, name string, data any) error {
+	if config.Get().Templates.Reload {
+		refreshSet(setName)
+	}
+	return buffered(w, func(w io.Writer) error {
+		return executeIn(w, setName, name, data)
+	})
+}
+
+// executeIn renders name from the named set
+func executeIn(w io.Writer, setName, name string, data any) error {
+	setMutex.RLock()
+	defer setMutex.RUnlock()
+
+	s, ok := sets[setName]
+	if !ok {
+		return fmt.Errorf("template set %q not loaded. Call template.LoadSet() first", setName)
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if page, ok := s.pages[name]; ok {
+		return page.Execute(w, data)
+	}
+	return s.layouts.ExecuteTemplate(w, name, data)
+}
+
+// load parses the set. It must be called with setMutex held.
+func (s *set) load() error {
+	s.stamp = stampFiles(s.config.FS, s.files())
+
+	layouts, pages, err := parseSet(s.config)
+	s.err = err
+	if err != nil {
+		return err
+	}
+	s.layouts, s.pages = layouts, pages
+	return nil
+}
+
+// files returns the patterns of all the set's files
+func (s *set) files() []string {
+	return append(append([]string(nil), s.config.Layouts...), s.config.Pages...)
+}
+
+// parseSet parses the layouts of cfg, then each page with a copy of them
+func parseSet(cfg SetConfig) (*template.Template, map[string]*template.Template, error) {
+	layouts, err := parseFiles(cfg.FS, cfg.Layouts, cfg.Funcs)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := globFiles(cfg.FS, cfg.Pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := make(map[string]*template.Template, len(files))
+	seen := make(map[string]string, len(files))
+	for _, file := range files {
+		base := filepath.Base(file)
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+		if other, ok := seen[name]; ok {
+			return nil, nil, fmt.Errorf("html/template: pages %s and %s are both named %q", other, file, name)
+		}
+		seen[name] = file
+
+		tmpl, err := layouts.Clone()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := parseFile(cfg.FS, tmpl, file); err != nil {
+			return nil, nil, err
+		}
+
+		page := tmpl.Lookup(base)
+		if cfg.Layout != "" {
+			if page = tmpl.Lookup(cfg.Layout); page == nil {
+				return nil, nil, fmt.Errorf("html/template: layout %q of page %s is not defined", cfg.Layout, file)
+			}
+		}
+		pages[name] = page
+	}
+	return layouts, pages, nil
+}
+
+// refreshSet parses the named set again if its files changed since it was
+// loaded
+func refreshSet(name string) {
+	setMutex.RLock()
+	s := sets[name]
+	var current string
+	if s != nil {
+		current = s.stamp
+	}
+	setMutex.RUnlock()
+	if s == nil || stampFiles(s.config.FS, s.files()) == current {
+		return
+	}
+
+	setMutex.Lock()
+	defer setMutex.Unlock()
+	if stampFiles(s.config.FS, s.files()) != s.stamp {
+		s.load()
+	}
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetSets removes the loaded template sets for testing
+func resetSets() {
+	setMutex.Lock()
+	defer setMutex.Unlock()
+	sets = map[string]*set{}
+}
+
+// writeFiles writes files, keyed by their paths under dir
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// renderIn renders name from set to a string
+func renderIn(t *testing.T, set, name string, data any) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	err := RenderIn(&buf, set, name, data)
+	return strings.TrimSpace(buf.String()), err
+}
+
+// TestLoadSet tests loading and rendering template sets
+func TestLoadSet(t *testing.T) {
+	resetSets()
+	t.Cleanup(resetSets)
+	setReload(t, false)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"admin/layouts/base.html":     `{{define "base"}}<main class="admin">{{block "content" .}}{{end}}</main>{{end}}`,
+		"admin/layouts/card.html":     `{{define "card"}}<div>{{shout .}}</div>{{end}}`,
+		"admin/pages/dashboard.html":  `{{define "content"}}Dashboard {{.}}{{end}}`,
+		"admin/pages/users.html":      `{{define "content"}}Users{{end}}`,
+		"admin/pages/team.html":       `{{define "content"}}{{component "card" "ada"}}{{end}}`,
+		"public/layouts/base.html":    `{{define "base"}}<main class="public">{{block "content" .}}{{end}}</main>{{end}}`,
+		"public/pages/dashboard.html": `{{template "base" .}}{{define "content"}}Welcome{{end}}`,
+	})
+
+	require.NoError(t, LoadSet("admin", SetConfig{
+		Layouts: []string{filepath.Join(dir, "admin/layouts/*.html")},
+		Pages:   []string{filepath.Join(dir, "admin/pages/*.html")},
+		Layout:  "base",
+		Funcs:   template.FuncMap{"shout": strings.ToUpper},
+	}))
+	require.NoError(t, LoadSet("public", SetConfig{
+		Layouts: []string{filepath.Join(dir, "public/layouts/*.html")},
+		Pages:   []string{filepath.Join(dir, "public/pages/*.html")},
+	}))
+
+	t.Run("renders pages in the set's layout", func(t *testing.T) {
+		out, err := renderIn(t, "admin", "dashboard", "today")
+		require.NoError(t, err)
+		assert.Equal(t, `<main class="admin">Dashboard today</main>`, out)
+
+		out, err = renderIn(t, "admin", "users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, `<main class="admin">Users</main>`, out, "pages define the same blocks")
+	})
+
+	t.Run("renders pages that call their layout", func(t *testing.T) {
+		out, err := renderIn(t, "public", "dashboard", nil)
+		require.NoError(t, err)
+		assert.Equal(t, `<main class="public">Welcome</main>`, out)
+	})
+
+	t.Run("renders components with the set's functions", func(t *testing.T) {
+		out, err := renderIn(t, "admin", "card", "stats")
+		require.NoError(t, err)
+		assert.Equal(t, `<div>STATS</div>`, out)
+
+		_, err = renderIn(t, "public", "card", "stats")
+		assert.Error(t, err, "sets don't share templates")
+
+		out, err = renderIn(t, "admin", "team", nil)
+		require.NoError(t, err)
+		assert.Equal(t, `<main class="admin"><div>ADA</div></main>`, out)
+	})
+
+	t.Run("fails for sets not loaded", func(t *testing.T) {
+		_, err := renderIn(t, "missing", "dashboard", nil)
+		assert.ErrorContains(t, err, `template set "missing" not loaded`)
+	})
+}
+
+// TestLoadSet_Errors tests reporting sets that fail to load
+func TestLoadSet_Errors(t *testing.T) {
+	resetSets()
+	t.Cleanup(resetSets)
+	setReload(t, false)
+
+	t.Run("reports the page that failed to parse", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"pages/broken.html": `{{define "content"}}{{.Name}{{end}}`})
+
+		err := LoadSet("broken", SetConfig{Pages: []string{filepath.Join(dir, "pages/*.html")}})
+		var parseErr *ParseError
+		require.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, filepath.Join(dir, "pages/broken.html"), parseErr.File)
+
+		_, err = renderIn(t, "broken", "broken", nil)
+		assert.True(t, errors.As(err, &parseErr), "renders report the error")
+	})
+
+	t.Run("rejects pages with the same name", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"a/index.html": "a", "b/index.html": "b"})
+
+		err := LoadSet("twice", SetConfig{Pages: []string{filepath.Join(dir, "*/index.html")}})
+		assert.ErrorContains(t, err, `are both named "index"`)
+	})
+
+	t.Run("rejects layouts the pages don't define", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"home.html": `{{define "content"}}Home{{end}}`})
+
+		err := LoadSet("layoutless", SetConfig{Pages: []string{filepath.Join(dir, "*.html")}, Layout: "base"})
+		assert.ErrorContains(t, err, `layout "base" of page `)
+	})
+
+	t.Run("rejects patterns matching no files", func(t *testing.T) {
+		err := LoadSet("empty", SetConfig{Pages: []string{filepath.Join(t.TempDir(), "*.html")}})
+		assert.ErrorContains(t, err, "pattern matches no files")
+	})
+}
+
+// TestRenderIn_Reload tests re-parsing sets whose files changed
+func TestRenderIn_Reload(t *testing.T) {
+	resetSets()
+	t.Cleanup(resetSets)
+	setReload(t, true)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "home.html")
+	writeTemplate(t, path, "first", time.Second)
+	require.NoError(t, LoadSet("site", SetConfig{Pages: []string{filepath.Join(dir, "*.html")}}))
+
+	writeTemplate(t, path, "second", 0)
+	out, err := renderIn(t, "site", "home", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", out)
+}
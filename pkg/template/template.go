@@ -3,41 +3,66 @@ package template
 import (
 	"html/template"
 	"io"
+	"io/fs"
 	"sync"
+
+	"github.com/cstone-io/twine/pkg/config"
 )
 
 var (
 	templates     *template.Template
 	templateMutex sync.RWMutex
+
+	// files and patterns are those templates were loaded from, for
+	// reloading
+	files    fs.FS
+	patterns []string
+	// stamp describes the files parsed from patterns, to tell when they
+	// change
+	stamp string
+	// parseErr is why the files last failed to parse
+	parseErr error
 )
 
-// LoadTemplates loads all templates from the given patterns
+// LoadTemplates loads all templates from the given patterns, on disk or in
+// the filesystem set with UseFS. Each file is parsed on its own, so a
+// failure is a *ParseError naming the file and line. With templates.reload
+// set, the patterns are parsed again whenever their files change.
 func LoadTemplates(patterns ...string) error {
+	return LoadFS(source(), patterns...)
+}
+
+// LoadFS loads all templates matching the given patterns in fsys, such as
+// an embed.FS, so a binary doesn't need its templates on disk. Templates
+// load and render as with LoadTemplates.
+func LoadFS(fsys fs.FS, patterns ...string) error {
 	templateMutex.Lock()
 	defer templateMutex.Unlock()
+	return load(fsys, patterns)
+}
+
+// load parses patterns of fsys into templates, remembering them for
+// reloading. It must be called with templateMutex held.
+func load(fsys fs.FS, list []string) error {
+	files, patterns = fsys, list
+	stamp = stampFiles(fsys, list)
 
-	tmpl, err := template.New("").Funcs(FuncMap()).ParseGlob(patterns[0])
+	tmpl, err := parseFiles(fsys, list, nil)
+	parseErr = err
 	if err != nil {
 		return err
 	}
-
-	// Parse additional patterns if provided
-	for i := 1; i < len(patterns); i++ {
-		tmpl, err = tmpl.ParseGlob(patterns[i])
-		if err != nil {
-			return err
-		}
-	}
-
 	templates = tmpl
 	return nil
 }
 
-// SetTemplates allows users to set a custom template instance
+// SetTemplates allows users to set a custom template instance. It isn't
+// reloaded.
 func SetTemplates(tmpl *template.Template) {
 	templateMutex.Lock()
 	defer templateMutex.Unlock()
 	templates = tmpl
+	files, patterns, stamp, parseErr = nil, nil, "", nil
 }
 
 // GetTemplates returns the current template instance
@@ -49,21 +74,32 @@ func GetTemplates() *template.Template {
 
 // RenderFull renders a full page template
 func RenderFull(w io.Writer, name string, data any) error {
-	templateMutex.RLock()
-	defer templateMutex.RUnlock()
-
-	if templates == nil {
-		return template.New("").Execute(w, "Templates not loaded. Call template.LoadTemplates() first.")
-	}
-
-	return templates.ExecuteTemplate(w, name, data)
+	return execute(w, name, data)
 }
 
 // RenderPartial renders a template component (for Ajax partial responses)
 func RenderPartial(w io.Writer, name string, data any) error {
+	return execute(w, name, data)
+}
+
+// execute renders name after reloading changed templates
+func execute(w io.Writer, name string, data any) error {
+	if config.Get().Templates.Reload {
+		refresh()
+	}
+	return buffered(w, func(w io.Writer) error {
+		return executeTemplate(w, name, data)
+	})
+}
+
+// executeTemplate renders name from the loaded templates
+func executeTemplate(w io.Writer, name string, data any) error {
 	templateMutex.RLock()
 	defer templateMutex.RUnlock()
 
+	if parseErr != nil {
+		return parseErr
+	}
 	if templates == nil {
 		return template.New("").Execute(w, "Templates not loaded. Call template.LoadTemplates() first.")
 	}
@@ -16,6 +16,7 @@ func resetTemplates() {
 	templateMutex.Lock()
 	defer templateMutex.Unlock()
 	templates = nil
+	files, patterns, stamp, parseErr = nil, nil, "", nil
 }
 
 // TestLoadTemplates tests template loading
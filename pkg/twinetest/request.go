@@ -0,0 +1,93 @@
+package twinetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+// Request builds a request to an App
+type Request struct {
+	app     *App
+	method  string
+	path    string
+	header  http.Header
+	body    io.Reader
+	cookies []*http.Cookie
+}
+
+// Header sets a request header
+func (r *Request) Header(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// Ajax marks the request as an Alpine Ajax request, so kit.Render renders
+// the partial
+func (r *Request) Ajax() *Request {
+	return r.Header("X-Alpine-Request", "true")
+}
+
+// Cookie adds a cookie to the request
+func (r *Request) Cookie(cookie *http.Cookie) *Request {
+	r.cookies = append(r.cookies, cookie)
+	return r
+}
+
+// Form sets a URL-encoded form body
+func (r *Request) Form(form url.Values) *Request {
+	r.header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.body = strings.NewReader(form.Encode())
+	return r
+}
+
+// JSON sets v, encoded as JSON, as the body
+func (r *Request) JSON(v any) *Request {
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.app.t.Fatalf("twinetest: encoding JSON body: %v", err)
+	}
+
+	r.header.Set("Content-Type", "application/json")
+	r.body = bytes.NewReader(data)
+	return r
+}
+
+// Body sets a raw body with the given content type
+func (r *Request) Body(contentType string, body io.Reader) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// Do serves the request and returns the recorded response
+func (r *Request) Do() *Response {
+	r.app.t.Helper()
+
+	recorder := &template.Recorder{}
+	req := httptest.NewRequest(r.method, r.path, r.body)
+	req = req.WithContext(template.WithRecorder(req.Context(), recorder))
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	r.app.handler.ServeHTTP(w, req)
+
+	return &Response{
+		t:         r.app.t,
+		Code:      w.Code,
+		Header:    w.Header(),
+		Body:      w.Body.String(),
+		Templates: recorder.Names(),
+	}
+}
@@ -0,0 +1,86 @@
+package twinetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Response is the recorded response to a Request. The Assert methods report
+// failures with t.Errorf and return the response, so they can be chained.
+type Response struct {
+	t testing.TB
+
+	Code   int
+	Header http.Header
+	Body   string
+
+	// Templates are the templates rendered through kit, in render order
+	Templates []string
+}
+
+// AssertStatus checks the status code
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Errorf("expected status %d, got %d\nbody: %s", code, r.Code, r.Body)
+	}
+	return r
+}
+
+// AssertTemplateUsed checks that the named template was rendered
+func (r *Response) AssertTemplateUsed(name string) *Response {
+	r.t.Helper()
+	if !slices.Contains(r.Templates, name) {
+		r.t.Errorf("expected template %q to be rendered, rendered %v", name, r.Templates)
+	}
+	return r
+}
+
+// AssertContains checks that the body contains s
+func (r *Response) AssertContains(s string) *Response {
+	r.t.Helper()
+	if !strings.Contains(r.Body, s) {
+		r.t.Errorf("expected body to contain %q\nbody: %s", s, r.Body)
+	}
+	return r
+}
+
+// AssertNotContains checks that the body doesn't contain s
+func (r *Response) AssertNotContains(s string) *Response {
+	r.t.Helper()
+	if strings.Contains(r.Body, s) {
+		r.t.Errorf("expected body not to contain %q\nbody: %s", s, r.Body)
+	}
+	return r
+}
+
+// AssertHeader checks a response header
+func (r *Response) AssertHeader(key, value string) *Response {
+	r.t.Helper()
+	if got := r.Header.Get(key); got != value {
+		r.t.Errorf("expected header %s to be %q, got %q", key, value, got)
+	}
+	return r
+}
+
+// AssertRedirect checks for a redirect to location
+func (r *Response) AssertRedirect(location string) *Response {
+	r.t.Helper()
+	if r.Code < 300 || r.Code >= 400 {
+		r.t.Errorf("expected a redirect to %s, got status %d", location, r.Code)
+		return r
+	}
+	return r.AssertHeader("Location", location)
+}
+
+// DecodeJSON decodes the body into v
+func (r *Response) DecodeJSON(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal([]byte(r.Body), v); err != nil {
+		r.t.Fatalf("decoding JSON response: %v\nbody: %s", err, r.Body)
+	}
+	return r
+}
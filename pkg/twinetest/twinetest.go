@@ -0,0 +1,244 @@
+package twinetest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/middleware"
+	"github.com/cstone-io/twine/pkg/router"
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+// DefaultTemplates is the pattern templates are loaded from, relative to the
+// project root, matching the scaffolded main.go
+const DefaultTemplates = "templates/**/*.html"
+
+// App is an application under test. Requests are served in memory by the
+// application's routes, against an in-memory SQLite database.
+type App struct {
+	t       testing.TB
+	handler http.Handler
+	db      *gorm.DB
+	root    string
+}
+
+type options struct {
+	register    []func(*router.Router)
+	middlewares []middleware.Middleware
+	templates   []string
+	models      []any
+	noDatabase  bool
+}
+
+// Option configures New
+type Option func(*options)
+
+// Routes registers routes on the app's router, usually the generated
+// app.RegisterRoutes
+func Routes(register func(*router.Router)) Option {
+	return func(o *options) {
+		o.register = append(o.register, register)
+	}
+}
+
+// Middleware adds middleware around every route, like r.Use in main.go
+func Middleware(middlewares ...middleware.Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// Templates loads templates from the given patterns, relative to the project
+// root, instead of DefaultTemplates
+func Templates(patterns ...string) Option {
+	return func(o *options) {
+		o.templates = patterns
+	}
+}
+
+// Models auto-migrates models into the test database, in addition to the
+// registered migrations
+func Models(models ...any) Option {
+	return func(o *options) {
+		o.models = append(o.models, models...)
+	}
+}
+
+// NoDatabase leaves the database alone, for apps that don't use one or tests
+// that connect to a real one
+func NoDatabase() Option {
+	return func(o *options) {
+		o.noDatabase = true
+	}
+}
+
+// New boots an app for a test. It loads the project's templates, replaces
+// the database with an in-memory SQLite database with registered migrations
+// applied, and builds the router from the given routes. The templates and
+// database are global, so tests using New must not run in parallel.
+//
+//	ta := twinetest.New(t, twinetest.Routes(app.RegisterRoutes))
+//	ta.Get("/").AssertStatus(200).AssertTemplateUsed("index")
+func New(t testing.TB, opts ...Option) *App {
+	t.Helper()
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	root, err := projectRoot()
+	if err != nil {
+		t.Fatalf("twinetest: %v", err)
+	}
+
+	a := &App{t: t, root: root}
+	a.loadTemplates(o.templates)
+	if !o.noDatabase {
+		a.openDatabase(o.models)
+	}
+
+	r := router.NewRouter("")
+	r.Use(o.middlewares...)
+	for _, register := range o.register {
+		register(r)
+	}
+	a.handler = r.InitializeAsRoot()
+
+	return a
+}
+
+// Handler returns the app's HTTP handler
+func (a *App) Handler() http.Handler {
+	return a.handler
+}
+
+// DB returns the test database, or nil with NoDatabase
+func (a *App) DB() *gorm.DB {
+	return a.db
+}
+
+// Root returns the project root, the nearest directory with a go.mod
+func (a *App) Root() string {
+	return a.root
+}
+
+// Request starts building a request to path
+func (a *App) Request(method, path string) *Request {
+	return &Request{app: a, method: method, path: path, header: http.Header{}}
+}
+
+// Get performs a GET request
+func (a *App) Get(path string) *Response {
+	return a.Request(http.MethodGet, path).Do()
+}
+
+// Delete performs a DELETE request
+func (a *App) Delete(path string) *Response {
+	return a.Request(http.MethodDelete, path).Do()
+}
+
+// PostForm performs a POST request with a URL-encoded form
+func (a *App) PostForm(path string, form url.Values) *Response {
+	return a.Request(http.MethodPost, path).Form(form).Do()
+}
+
+// PostJSON performs a POST request with v encoded as JSON
+func (a *App) PostJSON(path string, v any) *Response {
+	return a.Request(http.MethodPost, path).JSON(v).Do()
+}
+
+// PutJSON performs a PUT request with v encoded as JSON
+func (a *App) PutJSON(path string, v any) *Response {
+	return a.Request(http.MethodPut, path).JSON(v).Do()
+}
+
+// loadTemplates loads the project's templates, restoring the previous ones
+// when the test ends
+func (a *App) loadTemplates(patterns []string) {
+	a.t.Helper()
+
+	if patterns == nil {
+		if _, err := os.Stat(filepath.Join(a.root, "templates")); err != nil {
+			return
+		}
+		patterns = []string{DefaultTemplates}
+	}
+
+	abs := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		abs[i] = filepath.Join(a.root, pattern)
+	}
+
+	previous := template.GetTemplates()
+	a.t.Cleanup(func() { template.SetTemplates(previous) })
+
+	if err := template.LoadTemplates(abs...); err != nil {
+		a.t.Fatalf("twinetest: loading templates: %v", err)
+	}
+}
+
+// databaseCount names each test database, so tests don't share one
+var databaseCount atomic.Int64
+
+// openDatabase makes a fresh in-memory SQLite database the app's database
+func (a *App) openDatabase(models []any) {
+	a.t.Helper()
+
+	name := fmt.Sprintf("file:twinetest%d?mode=memory&cache=shared", databaseCount.Add(1))
+	db, err := gorm.Open(database.SQLite(name), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		a.t.Fatalf("twinetest: opening SQLite: %v", err)
+	}
+
+	a.t.Cleanup(func() {
+		database.Reset()
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := database.Use(db); err != nil {
+		a.t.Fatalf("twinetest: applying migrations to SQLite: %v\n"+
+			"Migrations must work on SQLite; use twinetest.NoDatabase() for Postgres-only schemas", err)
+	}
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			a.t.Fatalf("twinetest: migrating models: %v", err)
+		}
+	}
+
+	a.db = db
+}
+
+// projectRoot returns the nearest directory with a go.mod, starting from the
+// package directory go test runs in
+func projectRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod not found above %s", strings.TrimSuffix(dir, string(filepath.Separator)))
+		}
+		dir = parent
+	}
+}
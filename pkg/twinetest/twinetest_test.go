@@ -0,0 +1,139 @@
+package twinetest
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/kit"
+	"github.com/cstone-io/twine/pkg/router"
+	"github.com/cstone-io/twine/pkg/template"
+)
+
+type post struct {
+	database.BaseModel
+	Title string
+}
+
+// registerTestRoutes stands in for a project's generated app.RegisterRoutes
+func registerTestRoutes(r *router.Router) {
+	r.Get("/", func(k *kit.Kit) error {
+		return k.Render("index", map[string]string{"Title": "Home"})
+	})
+	r.Get("/posts", func(k *kit.Kit) error {
+		var posts []post
+		if err := database.GORM().Find(&posts).Error; err != nil {
+			return err
+		}
+		return k.JSON(http.StatusOK, posts)
+	})
+	r.Post("/posts", func(k *kit.Kit) error {
+		p := post{Title: k.Request.FormValue("title")}
+		if err := database.GORM().Create(&p).Error; err != nil {
+			return err
+		}
+		return k.Redirect("/posts")
+	})
+}
+
+func newTestApp(t *testing.T, opts ...Option) *App {
+	opts = append([]Option{
+		Routes(registerTestRoutes),
+		Templates("pkg/twinetest/testdata/*.html"),
+		Models(&post{}),
+	}, opts...)
+	return New(t, opts...)
+}
+
+// TestNew_Render tests rendering a page through the app's routes
+func TestNew_Render(t *testing.T) {
+	app := newTestApp(t)
+
+	res := app.Get("/").
+		AssertStatus(http.StatusOK).
+		AssertTemplateUsed("index").
+		AssertContains("<h1>Home</h1>").
+		AssertHeader("Content-Type", "text/html")
+	assert.Equal(t, []string{"index"}, res.Templates)
+
+	app.Request(http.MethodGet, "/").Ajax().Do().AssertTemplateUsed("index")
+}
+
+// TestNew_Database tests that BaseModel tables migrate on SQLite and that
+// each app gets an empty database
+func TestNew_Database(t *testing.T) {
+	app := newTestApp(t)
+	require.NotNil(t, app.DB())
+
+	app.PostForm("/posts", url.Values{"title": {"Hello"}}).
+		AssertStatus(http.StatusSeeOther).
+		AssertRedirect("/posts")
+
+	var posts []post
+	app.Get("/posts").AssertStatus(http.StatusOK).DecodeJSON(&posts)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "Hello", posts[0].Title)
+
+	var count int64
+	require.NoError(t, newTestApp(t).DB().Model(&post{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+// TestNew_Middleware tests middleware wrapping every route
+func TestNew_Middleware(t *testing.T) {
+	header := func(next kit.HandlerFunc) kit.HandlerFunc {
+		return func(k *kit.Kit) error {
+			k.Response.Header().Set("X-Test", "yes")
+			return next(k)
+		}
+	}
+
+	app := newTestApp(t, Middleware(header), NoDatabase())
+	assert.Nil(t, app.DB())
+	app.Get("/").AssertHeader("X-Test", "yes")
+}
+
+// TestNew_RestoresTemplates tests that templates are restored after the test
+func TestNew_RestoresTemplates(t *testing.T) {
+	before := template.GetTemplates()
+
+	t.Run("app", func(t *testing.T) {
+		newTestApp(t, NoDatabase())
+		assert.NotSame(t, before, template.GetTemplates())
+	})
+
+	assert.Same(t, before, template.GetTemplates())
+}
+
+// TestResponse_Assertions tests that failed assertions are reported
+func TestResponse_Assertions(t *testing.T) {
+	mock := &recordingT{TB: t}
+	res := &Response{t: mock, Code: http.StatusOK, Header: http.Header{}, Body: "hello", Templates: []string{"index"}}
+
+	res.AssertStatus(http.StatusOK).AssertTemplateUsed("index").AssertContains("hello").AssertNotContains("bye")
+	assert.Empty(t, mock.errors)
+
+	res.AssertStatus(http.StatusCreated).
+		AssertTemplateUsed("post").
+		AssertContains("bye").
+		AssertNotContains("hello").
+		AssertHeader("X-Missing", "value").
+		AssertRedirect("/")
+	assert.Len(t, mock.errors, 6)
+}
+
+// recordingT records Errorf calls instead of failing the test
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, format)
+}
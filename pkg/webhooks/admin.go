@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// DeliveriesHandler lists the delivery log as JSON, a page at a time as
+// k.ListOptions reads it, e.g. ?filter[status]=failed&filter[endpoint_id]=...
+// Mount the admin handlers behind authentication:
+//
+//	admin.Get("/webhooks/deliveries", webhooks.DeliveriesHandler)
+//	admin.Post("/webhooks/deliveries/{id}/redeliver", webhooks.RedeliverHandler)
+func DeliveriesHandler(k *kit.Kit) error {
+	opts, err := k.ListOptions()
+	if err != nil {
+		return err
+	}
+	deliveries, total, err := Default().Deliveries(k.Request.Context(), opts)
+	if err != nil {
+		return err
+	}
+	return k.JSON(http.StatusOK, map[string]any{
+		"items":    deliveries,
+		"total":    total,
+		"page":     opts.Page,
+		"per_page": opts.PerPage,
+	})
+}
+
+// RedeliverHandler sends the delivery named by the id path value again,
+// responding 404 if there is no such delivery
+func RedeliverHandler(k *kit.Kit) error {
+	if err := Default().Redeliver(k.Request.Context(), k.PathValue("id")); err != nil {
+		return err
+	}
+	return k.NoContent()
+}
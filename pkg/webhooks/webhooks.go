@@ -0,0 +1,413 @@
+// Package webhooks sends an application's events to the HTTP endpoints its
+// users register, such as customers integrating with it. Each delivery is
+// signed, sent by a job on the jobs queue, retried with backoff when the
+// endpoint fails, and logged in the webhook_deliveries table:
+//
+//	endpoint, err := webhooks.Default().AddEndpoint(ctx, account.ID.String(), "https://example.com/hooks", "order.paid")
+//	// Show endpoint.Secret to the customer once, to check signatures with
+//
+//	err = webhooks.Default().PublishTo(ctx, account.ID.String(), "order.paid", order)
+//
+// Receivers check the Twine-Signature header as Stripe's, such as with
+// kit.WebhookStripe.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/httpclient"
+	"github.com/cstone-io/twine/pkg/jobs"
+)
+
+// DeliverJob names the jobs that deliver webhooks
+const DeliverJob = "twine:webhooks:deliver"
+
+const (
+	// MaxAttempts is how many times a delivery is sent before it fails
+	MaxAttempts = 8
+	// Backoff is the wait before a delivery's second attempt, doubling for
+	// each one after it
+	Backoff = 30 * time.Second
+	// DeliveryTimeout bounds each attempt
+	DeliveryTimeout = 15 * time.Second
+	// maxResponseBody is how much of an endpoint's response is logged
+	maxResponseBody = 1024
+)
+
+// The headers of a delivery
+const (
+	// SignatureHeader holds t=<unix time>,v1=<hex HMAC-SHA256 of
+	// "<t>.<body>"> with the endpoint's secret
+	SignatureHeader = "Twine-Signature"
+	// EventHeader holds the event type
+	EventHeader = "Twine-Event"
+	// DeliveryHeader holds the delivery's ID, the same across its attempts
+	DeliveryHeader = "Twine-Delivery"
+)
+
+// Endpoint is a URL that receives the events it subscribes to
+type Endpoint struct {
+	ID string `json:"id" gorm:"primaryKey;size:36"`
+	// Owner is whose endpoint it is, such as a customer account's ID
+	Owner  string `json:"owner" gorm:"size:255;index"`
+	URL    string `json:"url" gorm:"size:2048"`
+	Secret string `json:"-" gorm:"size:255"`
+	// Events are comma-separated event types, or "*" for every event
+	Events    string    `json:"events" gorm:"type:text"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName names the endpoints table
+func (Endpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// Subscribed reports whether the endpoint receives events of type event
+func (e *Endpoint) Subscribed(event string) bool {
+	for _, subscribed := range strings.Split(e.Events, ",") {
+		if subscribed = strings.TrimSpace(subscribed); subscribed == "*" || subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is where a delivery is in its life
+type DeliveryStatus string
+
+const (
+	// StatusPending deliveries are waiting for an attempt
+	StatusPending DeliveryStatus = "pending"
+	// StatusSucceeded deliveries got a 2xx response
+	StatusSucceeded DeliveryStatus = "succeeded"
+	// StatusFailed deliveries used their attempts, or their endpoint is
+	// gone
+	StatusFailed DeliveryStatus = "failed"
+)
+
+// Delivery is an event sent to an endpoint, and how sending it went
+type Delivery struct {
+	ID         string `json:"id" gorm:"primaryKey;size:36"`
+	EndpointID string `json:"endpoint_id" gorm:"size:36;index"`
+	Event      string `json:"event" gorm:"size:255;index"`
+	// Payload is the JSON body sent
+	Payload        string         `json:"payload" gorm:"type:text"`
+	Status         DeliveryStatus `json:"status" gorm:"size:16;index"`
+	Attempts       int            `json:"attempts"`
+	ResponseStatus int            `json:"response_status,omitempty"`
+	// ResponseBody is the start of the endpoint's last response
+	ResponseBody string     `json:"response_body,omitempty" gorm:"type:text"`
+	LastError    string     `json:"last_error,omitempty" gorm:"type:text"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName names the deliveries table
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// EndpointMigration creates the webhook_endpoints table. It is registered
+// when the package is imported.
+var EndpointMigration = database.NewMigrationBuilder().
+	Model(&Endpoint{}).
+	Name("webhook_endpoints").
+	Down(func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&Endpoint{})
+	}).
+	Build()
+
+// DeliveryMigration creates the webhook_deliveries table. It is registered
+// when the package is imported.
+var DeliveryMigration = database.NewMigrationBuilder().
+	Model(&Delivery{}).
+	Name("webhook_deliveries").
+	Deps(EndpointMigration).
+	Down(func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&Delivery{})
+	}).
+	Build()
+
+func init() {
+	database.RegisterMigrations(EndpointMigration, DeliveryMigration)
+	jobs.Register(DeliverJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload deliverPayload
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		return Default().deliver(ctx, payload.DeliveryID, job.Attempts >= job.MaxAttempts)
+	})
+}
+
+// deliverPayload is the payload of a DeliverJob
+type deliverPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// envelope is the body of a delivery
+type envelope struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Webhooks manages endpoints and sends them events
+type Webhooks struct {
+	db   *gorm.DB
+	http *http.Client
+}
+
+// New creates a Webhooks on db, or on database.GORM() if db is nil
+func New(db *gorm.DB) *Webhooks {
+	return &Webhooks{
+		db: db,
+		// Jobs retry failed deliveries, with a longer backoff
+		http: httpclient.New(httpclient.WithTimeout(DeliveryTimeout), httpclient.WithRetries(0, 0), httpclient.WithName("webhooks")),
+	}
+}
+
+// Default returns a Webhooks on database.GORM()
+func Default() *Webhooks {
+	return New(nil)
+}
+
+func (w *Webhooks) client(ctx context.Context) *gorm.DB {
+	db := w.db
+	if db == nil {
+		db = database.GORM()
+	}
+	return db.WithContext(ctx)
+}
+
+// AddEndpoint registers url to receive events of the given types, or every
+// event for "*", on behalf of owner. The endpoint's Secret is generated to
+// sign its deliveries. URLs that aren't http or https return
+// ErrAPIRequestPayload.
+func (w *Webhooks) AddEndpoint(ctx context.Context, owner, rawURL string, events ...string) (*Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.ErrAPIRequestPayload.WithValue("webhook URL " + rawURL)
+	}
+	secret := make([]byte, 24)
+	rand.Read(secret)
+
+	endpoint := &Endpoint{
+		ID:     uuid.NewString(),
+		Owner:  owner,
+		URL:    rawURL,
+		Secret: "whsec_" + hex.EncodeToString(secret),
+		Events: strings.Join(events, ","),
+		Active: true,
+	}
+	if err := w.client(ctx).Create(endpoint).Error; err != nil {
+		return nil, errors.ErrDatabaseWrite.Wrap(err)
+	}
+	return endpoint, nil
+}
+
+// Endpoints returns owner's endpoints
+func (w *Webhooks) Endpoints(ctx context.Context, owner string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	if err := w.client(ctx).Where("owner = ?", owner).Order("created_at").Find(&endpoints).Error; err != nil {
+		return nil, errors.ErrDatabaseRead.Wrap(err)
+	}
+	return endpoints, nil
+}
+
+// RemoveEndpoint deletes the endpoint with id. Its pending deliveries fail.
+func (w *Webhooks) RemoveEndpoint(ctx context.Context, id string) error {
+	if err := w.client(ctx).Delete(&Endpoint{}, "id = ?", id).Error; err != nil {
+		return errors.ErrDatabaseDelete.Wrap(err)
+	}
+	return nil
+}
+
+// Publish sends an event of type event, with payload as its data, to every
+// active endpoint subscribed to it
+func (w *Webhooks) Publish(ctx context.Context, event string, payload any) error {
+	return w.publish(ctx, w.client(ctx).Where("active = ?", true), event, payload)
+}
+
+// PublishTo sends an event to owner's active endpoints subscribed to it
+func (w *Webhooks) PublishTo(ctx context.Context, owner, event string, payload any) error {
+	return w.publish(ctx, w.client(ctx).Where("active = ? AND owner = ?", true, owner), event, payload)
+}
+
+// publish creates and queues a delivery of event to each endpoint query
+// finds subscribed to it
+func (w *Webhooks) publish(ctx context.Context, query *gorm.DB, event string, payload any) error {
+	var endpoints []Endpoint
+	if err := query.Find(&endpoints).Error; err != nil {
+		return errors.ErrDatabaseRead.Wrap(err)
+	}
+
+	now := time.Now().UTC()
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribed(event) {
+			continue
+		}
+		delivery := &Delivery{
+			ID:         uuid.NewString(),
+			EndpointID: endpoint.ID,
+			Event:      event,
+			Status:     StatusPending,
+		}
+		body, err := json.Marshal(envelope{ID: delivery.ID, Event: event, CreatedAt: now, Data: payload})
+		if err != nil {
+			return errors.ErrEnqueueJob.Wrap(fmt.Errorf("encoding %s payload: %w", event, err))
+		}
+		delivery.Payload = string(body)
+
+		if err := w.client(ctx).Create(delivery).Error; err != nil {
+			return errors.ErrDatabaseWrite.Wrap(err)
+		}
+		if err := enqueue(ctx, delivery.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redeliver sends the delivery with id again, such as after fixing the
+// endpoint, with a fresh set of attempts
+func (w *Webhooks) Redeliver(ctx context.Context, id string) error {
+	result := w.client(ctx).Model(&Delivery{}).Where("id = ?", id).Update("status", StatusPending)
+	if result.Error != nil {
+		return errors.ErrDatabaseUpdate.Wrap(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrDatabaseObjectNotFound.WithValue(id)
+	}
+	return enqueue(ctx, id)
+}
+
+// Deliveries lists the delivery log, newest first unless opts sort it
+// otherwise. It can be sorted and filtered by endpoint_id, event, status
+// and created_at.
+func (w *Webhooks) Deliveries(ctx context.Context, opts database.ListOptions) ([]Delivery, int64, error) {
+	if opts.Sort == "" {
+		opts.Sort = "-created_at"
+	}
+	return database.NewCRUDStore[Delivery](w.client(ctx)).
+		Columns("endpoint_id", "event", "status", "created_at").
+		List(ctx, opts)
+}
+
+// enqueue queues a job sending the delivery with id
+func enqueue(ctx context.Context, id string) error {
+	_, err := jobs.Enqueue(ctx, DeliverJob, deliverPayload{DeliveryID: id}, jobs.Options{
+		MaxAttempts: MaxAttempts,
+		Backoff:     Backoff,
+		Timeout:     DeliveryTimeout + 5*time.Second,
+	})
+	return err
+}
+
+// deliver sends the delivery with id and logs the attempt, returning an
+// error for the job to retry unless the endpoint responded 2xx. The
+// delivery fails on its final attempt, or at once if its endpoint is gone.
+// Endpoints responding 410 Gone are deactivated.
+func (w *Webhooks) deliver(ctx context.Context, id string, final bool) error {
+	var delivery Delivery
+	if err := w.client(ctx).First(&delivery, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return jobs.Permanent(errors.ErrDatabaseObjectNotFound.Wrap(err).WithValue(id))
+		}
+		return errors.ErrDatabaseRead.Wrap(err)
+	}
+	var endpoints []Endpoint
+	if err := w.client(ctx).Limit(1).Find(&endpoints, "id = ?", delivery.EndpointID).Error; err != nil {
+		return errors.ErrDatabaseRead.Wrap(err)
+	}
+	if len(endpoints) == 0 || !endpoints[0].Active {
+		return w.record(ctx, &delivery, nil, jobs.Permanent(fmt.Errorf("endpoint %s is gone or inactive", delivery.EndpointID)), true)
+	}
+	endpoint := endpoints[0]
+
+	resp, err := w.send(ctx, &endpoint, &delivery)
+	if err == nil && resp.StatusCode == http.StatusGone {
+		w.client(ctx).Model(&endpoint).Update("active", false)
+		err = jobs.Permanent(fmt.Errorf("%s responded 410 Gone; endpoint deactivated", endpoint.URL))
+	} else if err == nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		err = fmt.Errorf("%s responded %d", endpoint.URL, resp.StatusCode)
+	}
+	return w.record(ctx, &delivery, resp, err, final || jobs.IsPermanent(err))
+}
+
+// send posts the delivery's payload to endpoint, signed with its secret
+func (w *Webhooks) send(ctx context.Context, endpoint *Endpoint, delivery *Delivery) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "twine-webhooks")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, time.Now(), []byte(delivery.Payload)))
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// record logs an attempt to send delivery that got resp or err, failing
+// the delivery if failed is set and err isn't nil
+func (w *Webhooks) record(ctx context.Context, delivery *Delivery, resp *http.Response, err error, failed bool) error {
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastError = ""
+	if resp != nil {
+		body, _ := io.ReadAll(resp.Body)
+		delivery.ResponseStatus, delivery.ResponseBody = resp.StatusCode, string(body)
+	}
+	switch {
+	case err == nil:
+		delivery.Status, delivery.DeliveredAt = StatusSucceeded, &now
+	case failed:
+		delivery.Status, delivery.LastError = StatusFailed, err.Error()
+	default:
+		delivery.Status, delivery.LastError = StatusPending, err.Error()
+	}
+	if saveErr := w.client(ctx).Save(delivery).Error; saveErr != nil {
+		return errors.ErrDatabaseWrite.Wrap(saveErr)
+	}
+	return err
+}
+
+// Sign returns the signature header of a delivery of body sent at t,
+// signed with secret: t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,252 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstone-io/twine/internal/testutil"
+	"github.com/cstone-io/twine/pkg/database"
+	"github.com/cstone-io/twine/pkg/errors"
+	"github.com/cstone-io/twine/pkg/jobs"
+	"github.com/cstone-io/twine/pkg/kit"
+)
+
+// setup makes a new SQLite database the one returned by database.GORM,
+// with a job queue on it
+func setup(t *testing.T) {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&jobs.Job{}))
+	require.NoError(t, database.Use(db))
+	t.Cleanup(database.Reset)
+	jobs.Use(jobs.NewDatabaseQueue(db))
+	t.Cleanup(jobs.Reset)
+}
+
+// receiver is an endpoint responding status, checking each delivery's
+// signature with the secret it's given and counting them in calls
+type receiver struct {
+	*httptest.Server
+	secret string
+	status atomic.Int32
+	calls  atomic.Int32
+	event  atomic.Value
+}
+
+func newReceiver(t *testing.T) *receiver {
+	t.Helper()
+	r := &receiver{}
+	r.status.Store(http.StatusOK)
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.calls.Add(1)
+		body, _ := io.ReadAll(req.Body)
+		if err := kit.WebhookStripe(r.secret, req.Header.Get(SignatureHeader), body); err != nil {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		r.event.Store(req.Header.Get(EventHeader))
+		w.WriteHeader(int(r.status.Load()))
+		w.Write([]byte("thanks"))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// addEndpoint registers r for events, telling it the secret
+func (r *receiver) addEndpoint(t *testing.T, owner string, events ...string) *Endpoint {
+	t.Helper()
+	endpoint, err := Default().AddEndpoint(context.Background(), owner, r.URL, events...)
+	require.NoError(t, err)
+	r.secret = endpoint.Secret
+	return endpoint
+}
+
+// deliveries returns every delivery, oldest first
+func deliveries(t *testing.T) []Delivery {
+	t.Helper()
+	var all []Delivery
+	require.NoError(t, database.GORM().Order("created_at").Find(&all).Error)
+	return all
+}
+
+// TestWebhooks_AddEndpoint tests registering endpoints
+func TestWebhooks_AddEndpoint(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+
+	endpoint, err := Default().AddEndpoint(ctx, "acct_1", "https://example.com/hooks", "order.paid", "order.refunded")
+	require.NoError(t, err)
+	assert.True(t, endpoint.Active)
+	assert.Contains(t, endpoint.Secret, "whsec_")
+	assert.True(t, endpoint.Subscribed("order.refunded"))
+	assert.False(t, endpoint.Subscribed("order.created"))
+
+	all := &Endpoint{Events: "*"}
+	assert.True(t, all.Subscribed("anything"))
+
+	for _, bad := range []string{"ftp://example.com", "example.com/hooks", "https://"} {
+		_, err := Default().AddEndpoint(ctx, "acct_1", bad, "*")
+		assert.ErrorIs(t, err, errors.ErrAPIRequestPayload, bad)
+	}
+
+	endpoints, err := Default().Endpoints(ctx, "acct_1")
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	encoded, _ := json.Marshal(endpoints[0])
+	assert.NotContains(t, string(encoded), endpoint.Secret, "the secret isn't serialized")
+
+	require.NoError(t, Default().RemoveEndpoint(ctx, endpoint.ID))
+	endpoints, err = Default().Endpoints(ctx, "acct_1")
+	require.NoError(t, err)
+	assert.Empty(t, endpoints)
+}
+
+// TestWebhooks_Publish tests delivering events to the endpoints
+// subscribed to them through the job queue
+func TestWebhooks_Publish(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+	r := newReceiver(t)
+	endpoint := r.addEndpoint(t, "acct_1", "order.paid")
+	other := newReceiver(t)
+	other.addEndpoint(t, "acct_2", "*")
+
+	require.NoError(t, Default().PublishTo(ctx, "acct_1", "order.paid", map[string]string{"order": "ord_1"}))
+	require.NoError(t, Default().PublishTo(ctx, "acct_1", "order.refunded", nil))
+
+	ran, err := jobs.NewWorker().RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	ran, _ = jobs.NewWorker().RunNext(ctx)
+	assert.False(t, ran, "only subscribed endpoints get a delivery")
+
+	assert.Equal(t, int32(1), r.calls.Load())
+	assert.Equal(t, int32(0), other.calls.Load(), "other owners' endpoints get nothing")
+	assert.Equal(t, "order.paid", r.event.Load())
+
+	logged := deliveries(t)
+	require.Len(t, logged, 1)
+	assert.Equal(t, endpoint.ID, logged[0].EndpointID)
+	assert.Equal(t, StatusSucceeded, logged[0].Status)
+	assert.Equal(t, 1, logged[0].Attempts)
+	assert.Equal(t, http.StatusOK, logged[0].ResponseStatus)
+	assert.Equal(t, "thanks", logged[0].ResponseBody)
+	assert.NotNil(t, logged[0].DeliveredAt)
+
+	var body envelope
+	require.NoError(t, json.Unmarshal([]byte(logged[0].Payload), &body))
+	assert.Equal(t, logged[0].ID, body.ID)
+	assert.Equal(t, map[string]any{"order": "ord_1"}, body.Data)
+
+	require.NoError(t, Default().Publish(ctx, "order.created", nil))
+	ran, _ = jobs.NewWorker().RunNext(ctx)
+	assert.True(t, ran, "Publish reaches every owner's endpoints")
+	assert.Equal(t, int32(1), other.calls.Load())
+}
+
+// TestWebhooks_Deliver tests retrying and failing deliveries
+func TestWebhooks_Deliver(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+	r := newReceiver(t)
+	r.addEndpoint(t, "acct_1", "*")
+	require.NoError(t, Default().PublishTo(ctx, "acct_1", "order.paid", nil))
+	id := deliveries(t)[0].ID
+
+	r.status.Store(http.StatusInternalServerError)
+	err := Default().deliver(ctx, id, false)
+	require.Error(t, err)
+	assert.False(t, jobs.IsPermanent(err), "the job retries")
+	delivery := deliveries(t)[0]
+	assert.Equal(t, StatusPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	assert.Contains(t, delivery.LastError, "responded 500")
+
+	require.Error(t, Default().deliver(ctx, id, true))
+	delivery = deliveries(t)[0]
+	assert.Equal(t, StatusFailed, delivery.Status, "the final attempt fails the delivery")
+	assert.Equal(t, 2, delivery.Attempts)
+
+	r.status.Store(http.StatusOK)
+	require.NoError(t, Default().Redeliver(ctx, id))
+	assert.Equal(t, StatusPending, deliveries(t)[0].Status)
+	ran, err := jobs.NewWorker().RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	delivery = deliveries(t)[0]
+	assert.Equal(t, StatusSucceeded, delivery.Status)
+	assert.Empty(t, delivery.LastError)
+
+	assert.ErrorIs(t, Default().Redeliver(ctx, "missing"), errors.ErrDatabaseObjectNotFound)
+
+	r.status.Store(http.StatusGone)
+	err = Default().deliver(ctx, id, false)
+	assert.True(t, jobs.IsPermanent(err))
+	assert.Equal(t, StatusFailed, deliveries(t)[0].Status)
+	endpoints, _ := Default().Endpoints(ctx, "acct_1")
+	assert.False(t, endpoints[0].Active, "an endpoint responding 410 is deactivated")
+
+	calls := r.calls.Load()
+	err = Default().deliver(ctx, id, false)
+	assert.True(t, jobs.IsPermanent(err))
+	assert.Equal(t, calls, r.calls.Load(), "inactive endpoints get nothing")
+}
+
+// TestSign tests that receivers can check signatures as Stripe's
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("whsec_test", time.Now(), body)
+	assert.NoError(t, kit.WebhookStripe("whsec_test", signature, body))
+	assert.Error(t, kit.WebhookStripe("other", signature, body))
+	assert.Error(t, kit.WebhookStripe("whsec_test", Sign("whsec_test", time.Now().Add(-time.Hour), body), body))
+}
+
+// TestAdminHandlers tests listing and redelivering deliveries
+func TestAdminHandlers(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+	r := newReceiver(t)
+	r.addEndpoint(t, "acct_1", "*")
+	require.NoError(t, Default().PublishTo(ctx, "acct_1", "order.paid", nil))
+	require.NoError(t, Default().PublishTo(ctx, "acct_1", "order.refunded", nil))
+	var paid Delivery
+	require.NoError(t, database.GORM().First(&paid, "event = ?", "order.paid").Error)
+	id := paid.ID
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/webhooks/deliveries?filter[event]=order.paid", nil)
+	require.NoError(t, DeliveriesHandler(&kit.Kit{Response: w, Request: req}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Items []Delivery `json:"items"`
+		Total int64      `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Equal(t, int64(1), listed.Total)
+	require.Len(t, listed.Items, 1)
+	assert.Equal(t, id, listed.Items[0].ID)
+
+	req = httptest.NewRequest("GET", "/webhooks/deliveries?filter[secret]=x", nil)
+	err := DeliveriesHandler(&kit.Kit{Response: httptest.NewRecorder(), Request: req})
+	assert.Error(t, err, "only the listed columns filter")
+
+	req = httptest.NewRequest("POST", "/webhooks/deliveries/"+id+"/redeliver", nil)
+	req.SetPathValue("id", id)
+	w = httptest.NewRecorder()
+	require.NoError(t, RedeliverHandler(&kit.Kit{Response: w, Request: req}))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest("POST", "/webhooks/deliveries/missing/redeliver", nil)
+	req.SetPathValue("id", "missing")
+	err = RedeliverHandler(&kit.Kit{Response: httptest.NewRecorder(), Request: req})
+	assert.Equal(t, http.StatusNotFound, errors.HTTPStatusOf(err))
+}
@@ -15,8 +15,11 @@ package twine
 //	})
 
 import (
+	"context"
 	"embed"
 	"html/template"
+	"io/fs"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -45,6 +48,24 @@ type Kit = kit.Kit
 // HandlerFunc is the signature for Twine handlers that return errors.
 type HandlerFunc = kit.HandlerFunc
 
+// ViewContext is the data layouts contribute to every template rendered for
+// a request, merged with the handler's data.
+type ViewContext = kit.ViewContext
+
+// Flash is a one-time message shown on the next page rendered.
+type Flash = kit.Flash
+
+// Meta is a page's title, description and OpenGraph tags, set with
+// k.SetMeta.
+type Meta = kit.Meta
+
+// FormErrors are validation messages by form field name, shown next to the
+// fields by k.RenderForm.
+type FormErrors = kit.FormErrors
+
+// Form renders a form from a model's form tags, with values and errors.
+type Form = pkgtemplate.Form
+
 // ErrorHandlerFunc is the signature for custom error handlers.
 type ErrorHandlerFunc = kit.ErrorHandlerFunc
 
@@ -58,6 +79,18 @@ func UseErrorHandler(h ErrorHandlerFunc) {
 	kit.UseErrorHandler(h)
 }
 
+// ErrorReporter sends errors to a tracking service such as Sentry.
+type ErrorReporter = kit.ErrorReporter
+
+// ErrorReport describes an error for an ErrorReporter.
+type ErrorReport = kit.ErrorReport
+
+// UseErrorReporter sends r the ErrError and ErrCritical errors returned by
+// Kit handlers, with the request and stack trace.
+func UseErrorReporter(r ErrorReporter) (remove func()) {
+	return kit.UseErrorReporter(r)
+}
+
 // NotFoundHandler returns a handler for 404 errors.
 func NotFoundHandler() http.HandlerFunc {
 	return kit.NotFoundHandler()
@@ -102,9 +135,10 @@ func Chain(middlewares ...Middleware) Middleware {
 	return middleware.Chain(middlewares...)
 }
 
-// LoggingMiddleware logs incoming requests.
-func LoggingMiddleware() Middleware {
-	return middleware.LoggingMiddleware()
+// LoggingMiddleware logs each request once it is handled, in the format of
+// LOGGER_REQUEST_FORMAT unless an option overrides it.
+func LoggingMiddleware(opts ...middleware.LoggingOption) Middleware {
+	return middleware.LoggingMiddleware(opts...)
 }
 
 // TimeoutMiddleware adds a timeout to request processing.
@@ -117,6 +151,36 @@ func JWTMiddleware() Middleware {
 	return middleware.JWTMiddleware()
 }
 
+// SessionMiddleware requires a server-side session and auto-redirects
+// without one.
+func SessionMiddleware() Middleware {
+	return middleware.SessionMiddleware()
+}
+
+// RequireRole allows only users with a role, after JWTMiddleware or
+// SessionMiddleware.
+func RequireRole(role string) Middleware {
+	return middleware.RequireRole(role)
+}
+
+// RequirePermission allows only users with a permission, after
+// JWTMiddleware or SessionMiddleware.
+func RequirePermission(permission string) Middleware {
+	return middleware.RequirePermission(permission)
+}
+
+// UserLoader fetches the user record for a user ID, for LoadUser.
+type UserLoader = middleware.UserLoader
+
+// UserLoaderFunc is a function that is a UserLoader.
+type UserLoaderFunc = middleware.UserLoaderFunc
+
+// LoadUser fetches the signed-in user's record once per request, for
+// kit.CurrentUser, after JWTMiddleware or SessionMiddleware.
+func LoadUser(store UserLoader) Middleware {
+	return middleware.LoadUser(store)
+}
+
 // ============================================================================
 // Authentication & Security
 // ============================================================================
@@ -127,21 +191,67 @@ type Token = auth.Token
 // Credentials holds user authentication credentials.
 type Credentials = auth.Credentials
 
+// Claims are the claims of a parsed access token.
+type Claims = auth.Claims
+
+// TokenOption adds roles, a tenant or custom claims to a token.
+type TokenOption = auth.TokenOption
+
 // NewToken generates a new JWT token for a user.
-func NewToken(userID uuid.UUID, email string) (*Token, error) {
-	return auth.NewToken(userID, email)
+func NewToken(userID uuid.UUID, email string, opts ...TokenOption) (*Token, error) {
+	return auth.NewToken(userID, email, opts...)
 }
 
-// ParseToken validates and parses a JWT token, returning the user ID.
-func ParseToken(tokenString string) (string, error) {
+// ParseToken validates and parses a JWT token, returning its claims.
+func ParseToken(tokenString string) (*Claims, error) {
 	return auth.ParseToken(tokenString)
 }
 
-// HashPassword hashes a password using bcrypt.
+// TokenPair is an access token with the refresh token that renews it.
+type TokenPair = auth.TokenPair
+
+// NewTokenPair generates an access and refresh token pair for a user.
+func NewTokenPair(ctx context.Context, userID uuid.UUID, email string, opts ...TokenOption) (*TokenPair, error) {
+	return auth.NewTokenPair(ctx, userID, email, opts...)
+}
+
+// RefreshToken exchanges a refresh token for a new pair, rotating it.
+func RefreshToken(ctx context.Context, refresh string) (*TokenPair, error) {
+	return auth.RefreshToken(ctx, refresh)
+}
+
+// Login signs a user in with a server-side session.
+func Login(k *Kit, userID uuid.UUID) error {
+	return auth.Login(k, userID)
+}
+
+// Logout ends the request's server-side session and revokes its tokens.
+func Logout(k *Kit) error {
+	return auth.Logout(k)
+}
+
+// Revoke rejects the access token with a jti claim until it expires.
+func Revoke(ctx context.Context, tokenID string, expiry time.Time) error {
+	return auth.Revoke(ctx, tokenID, expiry)
+}
+
+// CurrentUser returns the ID of the user signed in with Login.
+func CurrentUser(k *Kit) (uuid.UUID, error) {
+	return auth.CurrentUser(k)
+}
+
+// HashPassword hashes a password with Argon2id, or bcrypt when
+// AUTH_PASSWORD_HASHER is bcrypt.
 func HashPassword(password string) (string, error) {
 	return auth.HashPassword(password)
 }
 
+// NeedsRehash reports whether a password hash was made with other settings
+// than HashPassword uses now.
+func NeedsRehash(hash string) bool {
+	return auth.NeedsRehash(hash)
+}
+
 // ============================================================================
 // Database
 // ============================================================================
@@ -149,6 +259,12 @@ func HashPassword(password string) (string, error) {
 // BaseModel provides standard fields for database models.
 type BaseModel = database.BaseModel
 
+// SoftDelete adds soft delete support to models without BaseModel.
+type SoftDelete = database.SoftDelete
+
+// Version is a model field type that turns on optimistic locking.
+type Version = database.Version
+
 // Polymorphic provides fields for polymorphic relationships.
 type Polymorphic = database.Polymorphic
 
@@ -194,6 +310,24 @@ func NewSeeder(db *gorm.DB, batchSize int) *Seeder {
 	return database.NewSeeder(db, batchSize)
 }
 
+// RegisterSeed adds a seed to run with twine db seed.
+func RegisterSeed(s *database.Seed) {
+	database.RegisterSeed(s)
+}
+
+// RegisterSeeds adds multiple seeds to run with twine db seed.
+func RegisterSeeds(ss ...*database.Seed) {
+	database.RegisterSeeds(ss...)
+}
+
+// Seed populates the database with data for one or more environments.
+type Seed = database.Seed
+
+// NewSeedBuilder creates a new SeedBuilder instance.
+func NewSeedBuilder() *database.SeedBuilder {
+	return database.NewSeedBuilder()
+}
+
 // ============================================================================
 // Templates
 // ============================================================================
@@ -203,6 +337,17 @@ func LoadTemplates(patterns ...string) error {
 	return pkgtemplate.LoadTemplates(patterns...)
 }
 
+// LoadTemplatesFS loads all templates matching the given patterns in fsys,
+// such as an embed.FS.
+func LoadTemplatesFS(fsys fs.FS, patterns ...string) error {
+	return pkgtemplate.LoadFS(fsys, patterns...)
+}
+
+// UseTemplatesFS sets the filesystem LoadTemplates reads templates from.
+func UseTemplatesFS(fsys fs.FS) {
+	pkgtemplate.UseFS(fsys)
+}
+
 // SetTemplates allows users to set a custom template instance.
 func SetTemplates(tmpl *template.Template) {
 	pkgtemplate.SetTemplates(tmpl)
@@ -213,6 +358,24 @@ func GetTemplates() *template.Template {
 	return pkgtemplate.GetTemplates()
 }
 
+// RegisterComponent declares the props type of the named component.
+func RegisterComponent(name string, props any) {
+	pkgtemplate.RegisterComponent(name, props)
+}
+
+// TemplateSetConfig describes a named template set with its own layouts
+// and functions.
+type TemplateSetConfig = pkgtemplate.SetConfig
+
+// LoadTemplateSet loads a named template set, rendered with k.RenderIn.
+func LoadTemplateSet(name string, cfg TemplateSetConfig) error {
+	return pkgtemplate.LoadSet(name, cfg)
+}
+
+// TemplateParseError is a template that failed to parse, with its file, line
+// and the surrounding source.
+type TemplateParseError = pkgtemplate.ParseError
+
 // Reload reloads templates from the same patterns (useful in development).
 func Reload(patterns ...string) error {
 	return pkgtemplate.Reload(patterns...)
@@ -232,6 +395,24 @@ func Config() *config.Config {
 	return config.Get()
 }
 
+// IsDev reports whether TWINE_ENV is development.
+func IsDev() bool {
+	return config.Get().IsDev()
+}
+
+// IsProd reports whether TWINE_ENV is neither development nor test, such as
+// production or staging.
+func IsProd() bool {
+	return config.Get().IsProd()
+}
+
+// Environments of TWINE_ENV.
+const (
+	EnvDevelopment = config.EnvDevelopment
+	EnvTest        = config.EnvTest
+	EnvProduction  = config.EnvProduction
+)
+
 // DatabaseConfig holds database connection settings.
 type DatabaseConfig = config.DatabaseConfig
 
@@ -259,6 +440,11 @@ func Logger() *logger.Logger {
 	return logger.Get()
 }
 
+// Slog returns a *slog.Logger that writes through the singleton logger.
+func Slog() *slog.Logger {
+	return logger.Slog()
+}
+
 // ============================================================================
 // Errors
 // ============================================================================
@@ -298,6 +484,7 @@ var (
 	ErrDatabaseUpdate         = errors.ErrDatabaseUpdate
 	ErrDatabaseDelete         = errors.ErrDatabaseDelete
 	ErrDatabaseObjectNotFound = errors.ErrDatabaseObjectNotFound
+	ErrDatabaseConflict       = errors.ErrDatabaseConflict
 	ErrDatabaseConn           = errors.ErrDatabaseConn
 	ErrDatabaseMigration      = errors.ErrDatabaseMigration
 
@@ -316,6 +503,8 @@ var (
 	ErrAPIIDMismatch         = errors.ErrAPIIDMismatch
 	ErrAPIPathValue          = errors.ErrAPIPathValue
 	ErrAPIRequestContentType = errors.ErrAPIRequestContentType
+	ErrAPIRateLimited        = errors.ErrAPIRateLimited
+	ErrAPIQueryParam         = errors.ErrAPIQueryParam
 
 	// Server errors
 	ErrListenAndServe = errors.ErrListenAndServe